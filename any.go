@@ -0,0 +1,76 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Pack wraps m in an *anypb.Any
+// Returns an error for a nil message, which anypb.New would pack as an empty Any
+func Pack(m proto.Message) (*anypb.Any, error) {
+	if m == nil || !m.ProtoReflect().IsValid() {
+		return nil, fmt.Errorf("%w: cannot pack a nil message", ErrNilMessage)
+	}
+	return anypb.New(m)
+}
+
+// PackAll wraps each message in an *anypb.Any
+// The error lists every message that could not be packed
+func PackAll(msgs ...proto.Message) ([]*anypb.Any, error) {
+	result := make([]*anypb.Any, len(msgs))
+	var errs []error
+	for i, m := range msgs {
+		a, err := Pack(m)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("index %d: %w", i, err))
+			continue
+		}
+		result[i] = a
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+// UnpackTo unmarshals a into a new message of type T, which must be a generated message
+// pointer type such as *timestamppb.Timestamp. Fails with ErrTypeMismatch if a holds a
+// different type, naming both, and with ErrNilMessage if a is nil
+func UnpackTo[T proto.Message](a *anypb.Any) (T, error) {
+	var zero T
+	if a == nil {
+		return zero, fmt.Errorf("%w: cannot unpack a nil Any", ErrNilMessage)
+	}
+
+	m := zero.ProtoReflect().Type().New().Interface()
+	if !a.MessageIs(m) {
+		return zero, fmt.Errorf("%w: expected %s, got %s",
+			ErrTypeMismatch, m.ProtoReflect().Descriptor().FullName(), a.GetTypeUrl())
+	}
+	if err := a.UnmarshalTo(m); err != nil {
+		return zero, fmt.Errorf("unpacking %s: %w", a.GetTypeUrl(), err)
+	}
+	return m.(T), nil
+}
+
+// UnpackAll unpacks every Any in as with UnpackTo
+// The error lists every Any that could not be unpacked
+func UnpackAll[T proto.Message](as []*anypb.Any) ([]T, error) {
+	result := make([]T, len(as))
+	var errs []error
+	for i, a := range as {
+		m, err := UnpackTo[T](a)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("index %d: %w", i, err))
+			continue
+		}
+		result[i] = m
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return result, nil
+}
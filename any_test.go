@@ -0,0 +1,120 @@
+package protobaggins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestPack(t *testing.T) {
+	t.Parallel()
+
+	t.Run("packs a message", func(t *testing.T) {
+		t.Parallel()
+		a, err := Pack(wrapperspb.String("ring"))
+		require.NoError(t, err)
+		assert.Equal(t, "type.googleapis.com/google.protobuf.StringValue", a.GetTypeUrl())
+	})
+
+	t.Run("rejects nil", func(t *testing.T) {
+		t.Parallel()
+		_, err := Pack(nil)
+		require.ErrorIs(t, err, ErrNilMessage)
+		_, err = Pack((*wrapperspb.StringValue)(nil))
+		require.ErrorIs(t, err, ErrNilMessage)
+	})
+}
+
+func TestPackAll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("packs every message", func(t *testing.T) {
+		t.Parallel()
+		as, err := PackAll(wrapperspb.String("a"), durationpb.New(time.Second))
+		require.NoError(t, err)
+		require.Len(t, as, 2)
+		assert.True(t, as[1].MessageIs(&durationpb.Duration{}))
+	})
+
+	t.Run("reports every failed index", func(t *testing.T) {
+		t.Parallel()
+		_, err := PackAll(nil, wrapperspb.String("a"), (*wrapperspb.StringValue)(nil))
+		require.Error(t, err)
+		assert.Regexp(t, `^index 0: .+\nindex 2: .+$`, err.Error())
+	})
+}
+
+func TestUnpackTo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unpacks the expected type", func(t *testing.T) {
+		t.Parallel()
+		want := timestamppb.New(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+		a, err := Pack(want)
+		require.NoError(t, err)
+
+		got, err := UnpackTo[*timestamppb.Timestamp](a)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, got))
+	})
+
+	t.Run("names both types on mismatch", func(t *testing.T) {
+		t.Parallel()
+		a, err := Pack(wrapperspb.String("ring"))
+		require.NoError(t, err)
+
+		got, err := UnpackTo[*durationpb.Duration](a)
+		require.ErrorIs(t, err, ErrTypeMismatch)
+		assert.Nil(t, got)
+		assert.Contains(t, err.Error(), "expected google.protobuf.Duration, got type.googleapis.com/google.protobuf.StringValue")
+	})
+
+	t.Run("nil Any", func(t *testing.T) {
+		t.Parallel()
+		_, err := UnpackTo[*durationpb.Duration](nil)
+		require.ErrorIs(t, err, ErrNilMessage)
+	})
+
+	t.Run("malformed payload", func(t *testing.T) {
+		t.Parallel()
+		a := &anypb.Any{TypeUrl: "type.googleapis.com/google.protobuf.Duration", Value: []byte{0xff}}
+		_, err := UnpackTo[*durationpb.Duration](a)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unpacking type.googleapis.com/google.protobuf.Duration")
+	})
+}
+
+func TestUnpackAll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unpacks every Any", func(t *testing.T) {
+		t.Parallel()
+		as, err := PackAll(wrapperspb.String("a"), wrapperspb.String("b"))
+		require.NoError(t, err)
+
+		got, err := UnpackAll[*wrapperspb.StringValue](as)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, "b", got[1].GetValue())
+	})
+
+	t.Run("reports every failed index", func(t *testing.T) {
+		t.Parallel()
+		as, err := PackAll(wrapperspb.String("a"), durationpb.New(time.Second))
+		require.NoError(t, err)
+
+		got, err := UnpackAll[*wrapperspb.StringValue](append(as, nil))
+		require.Error(t, err)
+		assert.Nil(t, got)
+		require.ErrorIs(t, err, ErrTypeMismatch)
+		require.ErrorIs(t, err, ErrNilMessage)
+		assert.Regexp(t, `^index 1: .+\nindex 2: .+$`, err.Error())
+	})
+}
@@ -0,0 +1,162 @@
+package protobaggins
+
+import (
+	"sync"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// WithArena allocates the scalar values produced by a conversion in blocks instead of
+// one by one, together with their kind, which roughly halves the allocations of
+// converting maps and lists of scalars. It pays off with a Converter, which reuses the
+// rest of a block in its next conversions.
+//
+// The values are ordinary values that can be modified and kept indefinitely, but a
+// block stays in memory as long as any of its values does: keeping a single value of a
+// conversion retains up to 255 values next to it, some of which may come from other
+// conversions by the same Converter. Avoid it when a few values of large conversions
+// are kept for long
+func WithArena() Option {
+	return func(o *options) {
+		o.arena = true
+	}
+}
+
+// The largest block holds maxArenaBlock values of one kind. Blocks start small and
+// double in size so that small conversions do not allocate large blocks
+const (
+	minArenaBlock = 8
+	maxArenaBlock = 256
+)
+
+type stringNode struct {
+	value structpb.Value
+	kind  structpb.Value_StringValue
+}
+
+type numberNode struct {
+	value structpb.Value
+	kind  structpb.Value_NumberValue
+}
+
+type boolNode struct {
+	value structpb.Value
+	kind  structpb.Value_BoolValue
+}
+
+type nullNode struct {
+	value structpb.Value
+	kind  structpb.Value_NullValue
+}
+
+// arena hands out values from the unused part of its current block of each kind
+type arena struct {
+	strings []stringNode
+	numbers []numberNode
+	bools   []boolNode
+	nulls   []nullNode
+}
+
+// take returns the next node of the block, starting a new one if it is used up
+func take[T any](block *[]T) *T {
+	if len(*block) == 0 {
+		size := min(max(2*cap(*block), minArenaBlock), maxArenaBlock)
+		*block = make([]T, size)
+	}
+	n := &(*block)[0]
+	*block = (*block)[1:]
+	return n
+}
+
+func (e *encoder) newString(s string) *structpb.Value {
+	if e.opts.intern != nil {
+		if v := e.opts.intern.stringValue(s); v != nil {
+			return v
+		}
+	}
+	if e.arena == nil {
+		return structpb.NewStringValue(s)
+	}
+	n := take(&e.arena.strings)
+	n.kind.StringValue = s
+	n.value.Kind = &n.kind
+	return &n.value
+}
+
+func (e *encoder) newNumber(f float64) *structpb.Value {
+	if e.opts.intern != nil {
+		if v := e.opts.intern.numberValue(f); v != nil {
+			return v
+		}
+	}
+	if e.arena == nil {
+		return structpb.NewNumberValue(f)
+	}
+	n := take(&e.arena.numbers)
+	n.kind.NumberValue = f
+	n.value.Kind = &n.kind
+	return &n.value
+}
+
+func (e *encoder) newBool(b bool) *structpb.Value {
+	if e.opts.intern != nil {
+		return e.opts.intern.boolValue(b)
+	}
+	if e.arena == nil {
+		return structpb.NewBoolValue(b)
+	}
+	n := take(&e.arena.bools)
+	n.kind.BoolValue = b
+	n.value.Kind = &n.kind
+	return &n.value
+}
+
+func (e *encoder) newNull() *structpb.Value {
+	if e.opts.intern != nil {
+		return e.opts.intern.nullValue
+	}
+	if e.arena == nil {
+		return structpb.NewNullValue()
+	}
+	n := take(&e.arena.nulls)
+	n.value.Kind = &n.kind
+	return &n.value
+}
+
+// encoderPool reuses the scratch state of the encoders of a Converter: their path,
+// cycle detection map and arena
+type encoderPool struct {
+	pool sync.Pool
+}
+
+// get returns an encoder with opts, ready for a conversion
+func (p *encoderPool) get(opts options) *encoder {
+	if p == nil {
+		return newEncoder(opts)
+	}
+	e, ok := p.pool.Get().(*encoder)
+	if !ok {
+		return newEncoder(opts)
+	}
+	e.opts = opts
+	return e
+}
+
+// put returns e to the pool once its conversion is done
+func (p *encoderPool) put(e *encoder) {
+	if p == nil {
+		return
+	}
+	clear(e.seen)
+	*e = encoder{seen: e.seen, path: e.path[:0], arena: e.arena}
+	p.pool.Put(e)
+}
+
+// newEncoder returns an encoder with opts for a single conversion
+func newEncoder(opts options) *encoder {
+	e := &encoder{opts: opts}
+	if opts.arena {
+		e.arena = &arena{}
+	}
+	return e
+}
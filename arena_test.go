@@ -0,0 +1,126 @@
+package protobaggins
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestWithArena(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"name":   "frodo",
+		"age":    50,
+		"ring":   true,
+		"nil":    nil,
+		"scores": []any{1.5, int64(2), uint32(3), "x", false},
+		"nested": map[string]any{"deep": []any{map[string]any{"k": "v"}}},
+	}
+
+	t.Run("same result", func(t *testing.T) {
+		t.Parallel()
+		want, err := NewValue(input)
+		require.NoError(t, err)
+		got, err := NewValue(input, WithArena())
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, got))
+
+		out, err := protojson.Marshal(got)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"frodo","age":50,"ring":true,"nil":null,"scores":[1.5,2,3,"x",false],"nested":{"deep":[{"k":"v"}]}}`, string(out))
+	})
+
+	t.Run("values are independent", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithArena())
+		first, err := c.SliceToStructValues([]any{"a", "b"})
+		require.NoError(t, err)
+		second, err := c.SliceToStructValues([]any{"c"})
+		require.NoError(t, err)
+
+		first[0].Kind = &structpb.Value_NumberValue{NumberValue: 1}
+		first[1].GetKind().(*structpb.Value_StringValue).StringValue = "changed"
+		assert.Equal(t, []any{1.0, "changed"}, StructValuesToSlice(first))
+		assert.Equal(t, []any{"c"}, StructValuesToSlice(second))
+	})
+
+	t.Run("reflection", func(t *testing.T) {
+		t.Parallel()
+		type point struct{ X, Y float64 }
+		s, err := EncodeStruct(point{X: 1, Y: 2}, WithArena())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"X": 1.0, "Y": 2.0}, s.AsMap())
+	})
+
+	t.Run("concurrent converter", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithArena())
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for range 50 {
+					got, err := c.MapToStructValues(input)
+					if !assert.NoError(t, err) {
+						return
+					}
+					assert.Equal(t, "frodo", got["name"].GetStringValue())
+				}
+			})
+		}
+		wg.Wait()
+	})
+}
+
+func TestConverterReuse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("MapToStructValuesInto", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter()
+		dst := map[string]*structpb.Value{"stale": structpb.NewNullValue()}
+		got, err := c.MapToStructValuesInto(dst, map[string]any{"a": 1})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": 1.0}, StructValuesToMap(got))
+		assert.Equal(t, map[string]any{"a": 1.0}, StructValuesToMap(dst), "dst is reused")
+
+		got, err = c.MapToStructValuesInto(nil, map[string]any{"b": 2})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"b": 2.0}, StructValuesToMap(got))
+
+		_, err = c.MapToStructValuesInto(dst, map[string]any{"bad": make(chan int)})
+		require.Error(t, err)
+	})
+
+	t.Run("AppendStructValues", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter()
+		dst := make([]*structpb.Value, 0, 4)
+		dst, err := c.AppendStructValues(dst, []any{"a"})
+		require.NoError(t, err)
+		dst, err = c.AppendStructValues(dst, []any{"b", 1})
+		require.NoError(t, err)
+		assert.Equal(t, []any{"a", "b", 1.0}, StructValuesToSlice(dst))
+
+		reused, err := c.AppendStructValues(dst[:0], []any{true})
+		require.NoError(t, err)
+		assert.Equal(t, []any{true}, StructValuesToSlice(reused))
+
+		failed, err := c.AppendStructValues(reused, []any{1, make(chan int)})
+		require.Error(t, err)
+		assert.Len(t, failed, 1)
+	})
+
+	t.Run("zero converter", func(t *testing.T) {
+		t.Parallel()
+		var c Converter
+		got, err := c.MapToStructValues(map[string]any{"a": "b"})
+		require.NoError(t, err)
+		assert.Equal(t, "b", got["a"].GetStringValue())
+	})
+}
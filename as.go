@@ -0,0 +1,37 @@
+package protobaggins
+
+import (
+	"errors"
+	"reflect"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// As converts v to a T with the coercions of DecodeStruct: numbers and numeric strings
+// become integers when they are whole and in range, strings become time.Time, lists
+// become slices and Structs become structs or maps, at any depth, e.g.
+//
+//	ports, err := protobaggins.As[[]uint16](v)
+//
+// Null and nil values convert to the zero T. Every part of v that fails to convert is
+// reported, each error prefixed with its path
+func As[T any](v *structpb.Value, opts ...Option) (T, error) {
+	var out T
+	d := decoder{opts: newOptions(opts)}
+	var errs []error
+	d.decodeReflect("", v, reflect.ValueOf(&out).Elem(), &errs)
+	if len(errs) > 0 {
+		var zero T
+		return zero, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// MustAs is As that panics on failure, for tests and values known to be valid
+func MustAs[T any](v *structpb.Value, opts ...Option) T {
+	out, err := As[T](v, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package protobaggins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestAs(t *testing.T) {
+	t.Parallel()
+
+	mustValue := func(t *testing.T, v any) *structpb.Value {
+		t.Helper()
+		value, err := structpb.NewValue(v)
+		require.NoError(t, err)
+		return value
+	}
+
+	t.Run("scalars", func(t *testing.T) {
+		t.Parallel()
+		i, err := As[int](structpb.NewNumberValue(42))
+		require.NoError(t, err)
+		assert.Equal(t, 42, i)
+
+		i, err = As[int](structpb.NewStringValue("42"))
+		require.NoError(t, err)
+		assert.Equal(t, 42, i)
+
+		_, err = As[int8](structpb.NewNumberValue(300))
+		require.ErrorIs(t, err, ErrNotCoercible)
+		_, err = As[int](structpb.NewNumberValue(1.5))
+		require.ErrorIs(t, err, ErrNotCoercible)
+
+		s, err := As[string](structpb.NewBoolValue(true))
+		require.NoError(t, err)
+		assert.Equal(t, "true", s)
+
+		ts, err := As[time.Time](structpb.NewStringValue("2024-05-01T12:00:00Z"))
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), ts)
+	})
+
+	t.Run("containers", func(t *testing.T) {
+		t.Parallel()
+		ports, err := As[[]uint16](mustValue(t, []any{80, "443"}))
+		require.NoError(t, err)
+		assert.Equal(t, []uint16{80, 443}, ports)
+
+		type server struct {
+			Host  string   `json:"host"`
+			Ports []int    `json:"ports"`
+			Tags  []string `json:"tags"`
+		}
+		srv, err := As[server](mustValue(t, map[string]any{"host": "a", "ports": []any{1}}))
+		require.NoError(t, err)
+		assert.Equal(t, server{Host: "a", Ports: []int{1}}, srv)
+
+		m, err := As[map[string]float64](mustValue(t, map[string]any{"a": 1, "b": "2.5"}))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]float64{"a": 1, "b": 2.5}, m)
+
+		anything, err := As[any](mustValue(t, []any{"x"}))
+		require.NoError(t, err)
+		assert.Equal(t, []any{"x"}, anything)
+	})
+
+	t.Run("errors carry paths", func(t *testing.T) {
+		t.Parallel()
+		out, err := As[[]int](mustValue(t, []any{1, "x", true}))
+		require.ErrorIs(t, err, ErrNotCoercible)
+		assert.Contains(t, err.Error(), "[1]: ")
+		assert.Nil(t, out)
+	})
+
+	t.Run("null and nil", func(t *testing.T) {
+		t.Parallel()
+		i, err := As[int](nil)
+		require.NoError(t, err)
+		assert.Zero(t, i)
+		p, err := As[*int](structpb.NewNullValue())
+		require.NoError(t, err)
+		assert.Nil(t, p)
+	})
+
+	t.Run("must", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, []string{"a"}, MustAs[[]string](mustValue(t, []any{"a"})))
+		assert.Panics(t, func() { MustAs[int](structpb.NewStringValue("x")) })
+	})
+}
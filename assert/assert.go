@@ -0,0 +1,131 @@
+// Package assert provides test assertions for structpb values that report failures as
+// readable path-based diffs instead of protobuf wire dumps
+package assert
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// TestingT is the subset of testing.TB used by the assertions
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Option configures how values are compared
+type Option func(*options)
+
+type options struct {
+	ignorePaths []string
+	onlyPaths   []string
+	extraKeys   bool
+	epsilon     float64
+}
+
+// IgnorePaths skips differences at the given paths and anywhere beneath them
+// Paths use the notation of protobaggins.Diff, e.g. "metadata.createdAt" or "items[0]"
+func IgnorePaths(paths ...string) Option {
+	return func(o *options) {
+		o.ignorePaths = append(o.ignorePaths, paths...)
+	}
+}
+
+// OnlyPaths compares only the values at the given paths and beneath them, e.g. the
+// fields a test cares about in a larger payload. Differences above them, such as a
+// parent of another kind, still count
+func OnlyPaths(paths ...string) Option {
+	return func(o *options) {
+		o.onlyPaths = append(o.onlyPaths, paths...)
+	}
+}
+
+// IgnoreExtraKeys allows got to have struct keys that want does not, so that want only
+// lists the keys it expects. Extra list items are still differences
+func IgnoreExtraKeys() Option {
+	return func(o *options) {
+		o.extraKeys = true
+	}
+}
+
+// FloatEpsilon treats numbers as equal when they differ by at most epsilon
+func FloatEpsilon(epsilon float64) Option {
+	return func(o *options) {
+		o.epsilon = epsilon
+	}
+}
+
+// AssertValueEqual fails the test with a path-based diff if want and got differ
+// Returns true if the values are considered equal
+func AssertValueEqual(t TestingT, want, got *structpb.Value, opts ...Option) bool {
+	t.Helper()
+	return report(t, "values", protobaggins.Diff(want, got), opts)
+}
+
+// AssertStructEqual fails the test with a path-based diff if want and got differ
+// Returns true if the Structs are considered equal
+func AssertStructEqual(t TestingT, want, got *structpb.Struct, opts ...Option) bool {
+	t.Helper()
+	return report(t, "structs", protobaggins.DiffStructs(want, got), opts)
+}
+
+func report(t TestingT, what string, changes []protobaggins.Change, opts []Option) bool {
+	t.Helper()
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var b strings.Builder
+	count := 0
+	for _, change := range changes {
+		if o.ignored(change) {
+			continue
+		}
+		b.WriteString("\n  ")
+		b.WriteString(change.String())
+		count++
+	}
+	if count == 0 {
+		return true
+	}
+
+	t.Errorf("%s differ (- want, + got, ~ changed):%s", what, b.String())
+	return false
+}
+
+func (o *options) ignored(change protobaggins.Change) bool {
+	for _, path := range o.ignorePaths {
+		if protobaggins.WithinPath(change.Path, path) {
+			return true
+		}
+	}
+	if len(o.onlyPaths) > 0 && !slices.ContainsFunc(o.onlyPaths, func(only string) bool {
+		return protobaggins.WithinPath(change.Path, only) || protobaggins.WithinPath(only, change.Path)
+	}) {
+		return true
+	}
+	if o.extraKeys && change.Type == protobaggins.ChangeAdded && isKey(change.Path) {
+		return true
+	}
+
+	if o.epsilon > 0 && change.Type == protobaggins.ChangeModified {
+		oldNum, oldOK := change.Old.GetKind().(*structpb.Value_NumberValue)
+		newNum, newOK := change.New.GetKind().(*structpb.Value_NumberValue)
+		if oldOK && newOK && math.Abs(oldNum.NumberValue-newNum.NumberValue) <= o.epsilon {
+			return true
+		}
+	}
+	return false
+}
+
+// isKey reports whether path ends with a struct key rather than a list index. The
+// root, the empty path, is neither
+func isKey(path string) bool {
+	return path != "" && (!strings.HasSuffix(path, "]") || strings.HasSuffix(path, `"]`))
+}
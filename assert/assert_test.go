@@ -0,0 +1,146 @@
+package assert
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// recorder captures failures instead of failing the enclosing test
+type recorder struct {
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func mustStruct(t *testing.T, m map[string]any) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(m)
+	require.NoError(t, err)
+	return s
+}
+
+func TestAssertStructEqual(t *testing.T) {
+	t.Parallel()
+
+	t.Run("equal structs", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		ok := AssertStructEqual(r,
+			mustStruct(t, map[string]any{"a": []any{1, "x"}}),
+			mustStruct(t, map[string]any{"a": []any{1, "x"}}),
+		)
+		assert.True(t, ok)
+		assert.Empty(t, r.failures)
+	})
+
+	t.Run("reports path based diff", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		ok := AssertStructEqual(r,
+			mustStruct(t, map[string]any{"spec": map[string]any{"replicas": 2, "debug": true}}),
+			mustStruct(t, map[string]any{"spec": map[string]any{"replicas": 3, "image": "nginx"}}),
+		)
+		assert.False(t, ok)
+		require.Len(t, r.failures, 1)
+		assert.Equal(t, "structs differ (- want, + got, ~ changed):\n"+
+			"  - spec.debug: true\n"+
+			"  + spec.image: \"nginx\"\n"+
+			"  ~ spec.replicas: 2 -> 3", r.failures[0])
+	})
+
+	t.Run("ignore paths", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		ok := AssertStructEqual(r,
+			mustStruct(t, map[string]any{"meta": map[string]any{"createdAt": "a"}, "metadata": 1, "name": "x"}),
+			mustStruct(t, map[string]any{"meta": map[string]any{"createdAt": "b"}, "metadata": 2, "name": "x"}),
+			IgnorePaths("meta"),
+		)
+		assert.False(t, ok, "metadata is not beneath meta")
+		require.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], "~ metadata: 1 -> 2")
+		assert.NotContains(t, r.failures[0], "createdAt")
+	})
+
+	t.Run("float epsilon", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		ok := AssertStructEqual(r,
+			mustStruct(t, map[string]any{"ratio": 0.1 + 0.2}),
+			mustStruct(t, map[string]any{"ratio": 0.3}),
+			FloatEpsilon(1e-9),
+		)
+		assert.True(t, ok)
+		assert.Empty(t, r.failures)
+	})
+
+	t.Run("only paths", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		ok := AssertStructEqual(r,
+			mustStruct(t, map[string]any{"spec": map[string]any{"replicas": 2}, "status": "ready", "tags": []any{"a"}}),
+			mustStruct(t, map[string]any{"spec": map[string]any{"replicas": 3}, "status": "pending", "tags": "a"}),
+			OnlyPaths("spec.replicas", "tags[0]"),
+		)
+		assert.False(t, ok)
+		require.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], "~ spec.replicas: 2 -> 3")
+		assert.Contains(t, r.failures[0], "tags", "a changed parent of a compared path counts")
+		assert.NotContains(t, r.failures[0], "status")
+	})
+
+	t.Run("ignore extra keys", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		ok := AssertStructEqual(r,
+			mustStruct(t, map[string]any{"name": "x", "items": []any{1}}),
+			mustStruct(t, map[string]any{"name": "x", "id": 7, "labels": map[string]any{"a b": 1}, "items": []any{1, 2}}),
+			IgnoreExtraKeys(),
+		)
+		assert.False(t, ok)
+		require.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], "+ items[1]: 2")
+		assert.NotContains(t, r.failures[0], "id")
+		assert.NotContains(t, r.failures[0], "labels")
+	})
+
+	t.Run("ignore extra keys still reports a value added at the root", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		assert.False(t, AssertValueEqual(r, nil, structpb.NewStringValue("x"), IgnoreExtraKeys()))
+		require.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], "+ (root)")
+	})
+}
+
+func TestAssertValueEqual(t *testing.T) {
+	t.Parallel()
+
+	t.Run("equal values", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		assert.True(t, AssertValueEqual(r, structpb.NewStringValue("x"), structpb.NewStringValue("x")))
+		assert.Empty(t, r.failures)
+	})
+
+	t.Run("different kinds", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		assert.False(t, AssertValueEqual(r, structpb.NewStringValue("1"), structpb.NewNumberValue(1)))
+		require.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], `~ (root): "1" -> 1`)
+	})
+
+	t.Run("passes a real testing.T", func(t *testing.T) {
+		t.Parallel()
+		AssertValueEqual(t, structpb.NewBoolValue(true), structpb.NewBoolValue(true))
+	})
+}
@@ -0,0 +1,74 @@
+package assert
+
+import (
+	testifyassert "github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Normalize converts structpb values to their canonical Go form so they can be compared
+// with reflect.DeepEqual or testify: Values, Structs and ListValues become the result of
+// AsInterface, AsMap and AsSlice, and maps and slices of Values are converted element
+// by element. Any other value is returned unchanged
+func Normalize(v any) any {
+	switch v := v.(type) {
+	case *structpb.Value:
+		if v == nil {
+			return nil
+		}
+		return v.AsInterface()
+	case *structpb.Struct:
+		if v == nil {
+			return map[string]any(nil)
+		}
+		return v.AsMap()
+	case *structpb.ListValue:
+		if v == nil {
+			return []any(nil)
+		}
+		return v.AsSlice()
+	case map[string]*structpb.Value:
+		if v == nil {
+			return map[string]any(nil)
+		}
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			result[k] = Normalize(item)
+		}
+		return result
+	case []*structpb.Value:
+		if v == nil {
+			return []any(nil)
+		}
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = Normalize(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// ObjectsAreEqual is testify's assert.ObjectsAreEqual applied to normalized values,
+// so a *structpb.Struct compares equal to another Struct or map[string]any with the
+// same contents
+func ObjectsAreEqual(expected, actual any) bool {
+	return testifyassert.ObjectsAreEqual(Normalize(expected), Normalize(actual))
+}
+
+// Equal is testify's assert.Equal applied to normalized values, so failures show a diff
+// of plain Go maps and slices instead of protobuf internals
+func Equal(t testifyassert.TestingT, expected, actual any, msgAndArgs ...any) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	return testifyassert.Equal(t, Normalize(expected), Normalize(actual), msgAndArgs...)
+}
+
+// NotEqual is testify's assert.NotEqual applied to normalized values
+func NotEqual(t testifyassert.TestingT, expected, actual any, msgAndArgs ...any) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	return testifyassert.NotEqual(t, Normalize(expected), Normalize(actual), msgAndArgs...)
+}
@@ -0,0 +1,76 @@
+package assert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil structpb values", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, Normalize((*structpb.Value)(nil)))
+		assert.Equal(t, map[string]any(nil), Normalize((*structpb.Struct)(nil)))
+		assert.Equal(t, []any(nil), Normalize((*structpb.ListValue)(nil)))
+		assert.Equal(t, map[string]any(nil), Normalize(map[string]*structpb.Value(nil)))
+		assert.Equal(t, []any(nil), Normalize([]*structpb.Value(nil)))
+	})
+
+	t.Run("structpb values", func(t *testing.T) {
+		t.Parallel()
+		s := mustStruct(t, map[string]any{"a": []any{1, "x"}})
+		expected := map[string]any{"a": []any{float64(1), "x"}}
+
+		assert.Equal(t, expected, Normalize(s))
+		assert.Equal(t, expected, Normalize(structpb.NewStructValue(s)))
+		assert.Equal(t, []any{float64(1), "x"}, Normalize(s.GetFields()["a"].GetListValue()))
+		assert.Equal(t, expected, Normalize(s.GetFields()))
+		assert.Equal(t, []any{float64(1), "x"}, Normalize(s.GetFields()["a"].GetListValue().GetValues()))
+	})
+
+	t.Run("other values unchanged", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, 42, Normalize(42))
+		assert.Equal(t, map[string]int{"a": 1}, Normalize(map[string]int{"a": 1}))
+	})
+}
+
+func TestObjectsAreEqual(t *testing.T) {
+	t.Parallel()
+
+	s := mustStruct(t, map[string]any{"name": "frodo", "age": 50})
+
+	assert.True(t, ObjectsAreEqual(s, mustStruct(t, map[string]any{"age": 50, "name": "frodo"})))
+	assert.True(t, ObjectsAreEqual(s, map[string]any{"age": float64(50), "name": "frodo"}))
+	assert.False(t, ObjectsAreEqual(s, mustStruct(t, map[string]any{"name": "sam", "age": 50})))
+}
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	t.Run("equal", func(t *testing.T) {
+		t.Parallel()
+		v, err := structpb.NewValue([]any{"a", true})
+		require.NoError(t, err)
+		assert.True(t, Equal(t, []any{"a", true}, v))
+	})
+
+	t.Run("failure shows plain values", func(t *testing.T) {
+		t.Parallel()
+		r := &recorder{}
+		ok := Equal(r, mustStruct(t, map[string]any{"name": "frodo"}), mustStruct(t, map[string]any{"name": "sam"}))
+		assert.False(t, ok)
+		require.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], `"name":"frodo"`)
+		assert.NotContains(t, r.failures[0], "Kind")
+	})
+
+	t.Run("not equal", func(t *testing.T) {
+		t.Parallel()
+		assert.True(t, NotEqual(t, structpb.NewStringValue("a"), structpb.NewStringValue("b")))
+	})
+}
@@ -0,0 +1,57 @@
+package assert
+
+import (
+	"reflect"
+
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// RequireT is a TestingT that can also stop the test
+type RequireT interface {
+	TestingT
+	FailNow()
+}
+
+// RequireRoundTrip checks that v survives conversion in both directions under the given
+// conversion options and stops the test otherwise. A Go value is converted to a
+// *structpb.Value and back, and the result must convert to the same *structpb.Value
+// again; the Go value recovered the second time must also be deeply equal to the first.
+// A *structpb.Value or *structpb.Struct is checked starting from the protobuf side
+func RequireRoundTrip(t RequireT, v any, opts ...protobaggins.Option) {
+	t.Helper()
+
+	var first *structpb.Value
+	switch v := v.(type) {
+	case *structpb.Value:
+		first = v
+	case *structpb.Struct:
+		first = structpb.NewStructValue(v)
+	default:
+		var err error
+		first, err = protobaggins.NewValue(v, opts...)
+		if err != nil {
+			t.Errorf("converting %T to a structpb value: %v", v, err)
+			t.FailNow()
+			return
+		}
+	}
+
+	decoded := protobaggins.ValueToInterface(first, opts...)
+	second, err := protobaggins.NewValue(decoded, opts...)
+	if err != nil {
+		t.Errorf("converting decoded %T back to a structpb value: %v", decoded, err)
+		t.FailNow()
+		return
+	}
+	if !report(t, "round-tripped values", protobaggins.Diff(first, second), nil) {
+		t.FailNow()
+		return
+	}
+
+	redecoded := protobaggins.ValueToInterface(second, opts...)
+	if !reflect.DeepEqual(decoded, redecoded) {
+		t.Errorf("decoded Go values differ between round trips:\n  first:  %#v\n  second: %#v", decoded, redecoded)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,89 @@
+package assert
+
+import (
+	"math"
+	"net/url"
+	"runtime"
+	"testing"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// stopRecorder is a recorder that also supports FailNow
+type stopRecorder struct {
+	recorder
+	stopped bool
+}
+
+func (r *stopRecorder) FailNow() {
+	r.stopped = true
+	runtime.Goexit()
+}
+
+func runRequire(fn func(t RequireT)) *stopRecorder {
+	r := &stopRecorder{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(r)
+	}()
+	<-done
+	return r
+}
+
+func TestRequireRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	t.Run("plain go values", func(t *testing.T) {
+		t.Parallel()
+		RequireRoundTrip(t, map[string]any{
+			"name":  "frodo",
+			"age":   50,
+			"items": []any{"ring", true, nil, 1.5},
+		})
+	})
+
+	t.Run("structpb inputs", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": []any{1, "x"}})
+		require.NoError(t, err)
+		RequireRoundTrip(t, s)
+		RequireRoundTrip(t, structpb.NewStringValue("x"))
+	})
+
+	t.Run("tagged bytes option", func(t *testing.T) {
+		t.Parallel()
+		RequireRoundTrip(t, map[string]any{"blob": []byte{0, 1, 2}}, protobaggins.WithTaggedBytes())
+	})
+
+	t.Run("unconvertible value", func(t *testing.T) {
+		t.Parallel()
+		r := runRequire(func(rt RequireT) {
+			RequireRoundTrip(rt, struct{ Field string }{"x"})
+		})
+		assert.True(t, r.stopped)
+		require.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], "converting struct")
+	})
+
+	t.Run("exploded urls are stable", func(t *testing.T) {
+		t.Parallel()
+		u, err := url.Parse("https://example.com/path?q=1")
+		require.NoError(t, err)
+		RequireRoundTrip(t, map[string]any{"link": u}, protobaggins.WithExplodedURLs())
+	})
+
+	t.Run("lossy conversion is reported", func(t *testing.T) {
+		t.Parallel()
+		// AsInterface turns NaN into the string "NaN", which does not convert back to a number
+		r := runRequire(func(rt RequireT) {
+			RequireRoundTrip(rt, structpb.NewNumberValue(math.NaN()))
+		})
+		assert.True(t, r.stopped)
+		require.Len(t, r.failures, 1)
+		assert.Contains(t, r.failures[0], `~ (root): NaN -> "NaN"`)
+	})
+}
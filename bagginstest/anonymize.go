@@ -0,0 +1,176 @@
+package bagginstest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Faker returns a fake replacement for a scalar value, drawing randomness from r
+// Fakers should return a value of the same kind as original so the document keeps its shape
+type Faker func(r *rand.Rand, original *structpb.Value) *structpb.Value
+
+// AnonymizeRule selects values to replace and the Faker used to replace them. A rule
+// matches a field when Key matches its key and Path matches its full path; a nil pattern
+// matches anything. When a rule matches a list or struct, every scalar beneath it is faked
+type AnonymizeRule struct {
+	Key  *regexp.Regexp
+	Path *regexp.Regexp
+	Fake Faker
+}
+
+// ByKey returns a rule matching fields whose key matches the regular expression pattern
+func ByKey(pattern string, fake Faker) AnonymizeRule {
+	return AnonymizeRule{Key: regexp.MustCompile(pattern), Fake: fake}
+}
+
+// ByPath returns a rule matching values whose path matches the regular expression pattern
+func ByPath(pattern string, fake Faker) AnonymizeRule {
+	return AnonymizeRule{Path: regexp.MustCompile(pattern), Fake: fake}
+}
+
+// DefaultAnonymizeRules covers common personal data keys: emails, names, phone numbers,
+// addresses and identifiers
+var DefaultAnonymizeRules = []AnonymizeRule{
+	ByKey(`(?i)e-?mail`, FakeEmail),
+	ByKey(`(?i)name$`, FakeName),
+	ByKey(`(?i)(phone|mobile|fax)`, FakeFormatted),
+	ByKey(`(?i)(address|street|city|zip|postal)`, FakeFormatted),
+	ByKey(`(?i)(^id$|_id$|[a-z]Id$|uuid|token|secret|password)`, FakeFormatted),
+}
+
+// Anonymize returns a copy of s with every value matched by a rule replaced by a fake.
+// The first matching rule wins. Fakes are deterministic for a given salt and original
+// value, so the same email appears as the same fake everywhere in the document while
+// different salts produce unrelated fakes. The input is not modified
+func Anonymize(s *structpb.Struct, salt string, rules ...AnonymizeRule) *structpb.Struct {
+	if s == nil {
+		return nil
+	}
+	out, _ := proto.Clone(s).(*structpb.Struct)
+	a := anonymizer{salt: salt, rules: rules}
+	for key, field := range out.GetFields() {
+		a.visit(key, key, field, nil)
+	}
+	return out
+}
+
+type anonymizer struct {
+	salt  string
+	rules []AnonymizeRule
+}
+
+func (a *anonymizer) visit(path, key string, v *structpb.Value, active Faker) {
+	if active == nil {
+		active = a.match(path, key)
+	}
+
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		for k, field := range kind.StructValue.GetFields() {
+			a.visit(path+"."+k, k, field, active)
+		}
+	case *structpb.Value_ListValue:
+		for i, item := range kind.ListValue.GetValues() {
+			a.visit(fmt.Sprintf("%s[%d]", path, i), key, item, active)
+		}
+	case *structpb.Value_NullValue:
+		// null carries no data worth hiding
+	default:
+		if active != nil {
+			v.Kind = active(a.rand(v), v).GetKind()
+		}
+	}
+}
+
+func (a *anonymizer) match(path, key string) Faker {
+	for _, rule := range a.rules {
+		if rule.Fake == nil {
+			continue
+		}
+		if (rule.Key == nil || rule.Key.MatchString(key)) && (rule.Path == nil || rule.Path.MatchString(path)) {
+			return rule.Fake
+		}
+	}
+	return nil
+}
+
+// rand seeds a generator from the salt and the original value
+func (a *anonymizer) rand(v *structpb.Value) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(a.salt))
+	h.Write([]byte{0})
+	h.Write([]byte(fmt.Sprintf("%T:%v", v.GetKind(), v.AsInterface())))
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+var (
+	fakeFirstNames = []string{"Frodo", "Samwise", "Meriadoc", "Peregrin", "Rosie", "Lobelia", "Bilbo", "Primula", "Hamfast", "Belladonna"}
+	fakeLastNames  = []string{"Baggins", "Gamgee", "Brandybuck", "Took", "Cotton", "Sackville", "Proudfoot", "Bolger", "Boffin", "Burrows"}
+	fakeDomains    = []string{"example.com", "example.org", "example.net"}
+)
+
+// FakeName replaces strings with a random "First Last" name
+func FakeName(r *rand.Rand, original *structpb.Value) *structpb.Value {
+	if _, ok := original.GetKind().(*structpb.Value_StringValue); !ok {
+		return FakeFormatted(r, original)
+	}
+	return structpb.NewStringValue(fakeFirstNames[r.Intn(len(fakeFirstNames))] + " " +
+		fakeLastNames[r.Intn(len(fakeLastNames))])
+}
+
+// FakeEmail replaces strings with a random address at a reserved example domain
+func FakeEmail(r *rand.Rand, original *structpb.Value) *structpb.Value {
+	if _, ok := original.GetKind().(*structpb.Value_StringValue); !ok {
+		return FakeFormatted(r, original)
+	}
+	local := strings.ToLower(fakeFirstNames[r.Intn(len(fakeFirstNames))] + "." +
+		fakeLastNames[r.Intn(len(fakeLastNames))])
+	return structpb.NewStringValue(fmt.Sprintf("%s%d@%s", local, r.Intn(1000), fakeDomains[r.Intn(len(fakeDomains))]))
+}
+
+// FakeFormatted keeps the format of the original while replacing its content: in strings
+// every letter becomes a random letter of the same case and every digit a random digit,
+// numbers keep their sign, magnitude and integer-ness, and booleans are randomized
+func FakeFormatted(r *rand.Rand, original *structpb.Value) *structpb.Value {
+	switch kind := original.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		runes := []rune(kind.StringValue)
+		for i, c := range runes {
+			switch {
+			case unicode.IsUpper(c):
+				runes[i] = rune('A' + r.Intn(26))
+			case unicode.IsLower(c):
+				runes[i] = rune('a' + r.Intn(26))
+			case unicode.IsDigit(c):
+				runes[i] = rune('0' + r.Intn(10))
+			}
+		}
+		return structpb.NewStringValue(string(runes))
+	case *structpb.Value_NumberValue:
+		return structpb.NewNumberValue(fakeNumber(r, kind.NumberValue))
+	case *structpb.Value_BoolValue:
+		return structpb.NewBoolValue(r.Intn(2) == 1)
+	default:
+		return original
+	}
+}
+
+func fakeNumber(r *rand.Rand, n float64) float64 {
+	if n == 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+		return n
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(math.Abs(n))))
+	fake := magnitude * (1 + r.Float64()*9)
+	if n == math.Trunc(n) {
+		fake = math.Trunc(fake)
+	}
+	return math.Copysign(fake, n)
+}
@@ -0,0 +1,148 @@
+package bagginstest
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestAnonymize(t *testing.T) {
+	t.Parallel()
+
+	input := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		return mustStruct(t, map[string]any{
+			"name":  "Frodo Baggins",
+			"email": "frodo@shire.example",
+			"id":    "AB-1234",
+			"age":   float64(50),
+			"friends": []any{
+				map[string]any{"name": "Sam", "email": "frodo@shire.example"},
+			},
+			"home":    map[string]any{"address": "Bag End, Hobbiton", "region": "shire"},
+			"comment": nil,
+		})
+	}
+
+	t.Run("default rules preserve structure and types", func(t *testing.T) {
+		t.Parallel()
+		s := input(t)
+		got := Anonymize(s, "salt", DefaultAnonymizeRules...)
+		fields := got.GetFields()
+
+		assert.NotEqual(t, "Frodo Baggins", fields["name"].GetStringValue())
+		assert.Regexp(t, `^\w+ \w+$`, fields["name"].GetStringValue())
+		assert.Regexp(t, `^[a-z.]+\d+@example\.(com|org|net)$`, fields["email"].GetStringValue())
+		assert.Regexp(t, `^[A-Z]{2}-\d{4}$`, fields["id"].GetStringValue())
+		assert.InDelta(t, 50, fields["age"].GetNumberValue(), 0)
+		_, isNull := fields["comment"].GetKind().(*structpb.Value_NullValue)
+		assert.True(t, isNull)
+
+		home := fields["home"].GetStructValue().GetFields()
+		assert.Equal(t, "shire", home["region"].GetStringValue())
+		assert.Regexp(t, `^[A-Z][a-z]{2} [A-Z][a-z]{2}, [A-Z][a-z]{7}$`, home["address"].GetStringValue())
+
+		friend := fields["friends"].GetListValue().GetValues()[0].GetStructValue().GetFields()
+		assert.Equal(t, fields["email"].GetStringValue(), friend["email"].GetStringValue(),
+			"equal originals map to equal fakes")
+
+		assert.Equal(t, "Frodo Baggins", s.GetFields()["name"].GetStringValue(), "input is not modified")
+	})
+
+	t.Run("deterministic per salt", func(t *testing.T) {
+		t.Parallel()
+		a := Anonymize(input(t), "one", DefaultAnonymizeRules...)
+		b := Anonymize(input(t), "one", DefaultAnonymizeRules...)
+		c := Anonymize(input(t), "two", DefaultAnonymizeRules...)
+		assert.Equal(t, a.AsMap(), b.AsMap())
+		assert.NotEqual(t, a.AsMap(), c.AsMap())
+	})
+
+	t.Run("path rule fakes whole subtree", func(t *testing.T) {
+		t.Parallel()
+		got := Anonymize(input(t), "", ByPath(`^home$`, FakeFormatted))
+		home := got.GetFields()["home"].GetStructValue().GetFields()
+		assert.NotEqual(t, "shire", home["region"].GetStringValue())
+		assert.Len(t, home["region"].GetStringValue(), len("shire"))
+		assert.Equal(t, "Frodo Baggins", got.GetFields()["name"].GetStringValue())
+	})
+
+	t.Run("first matching rule wins", func(t *testing.T) {
+		t.Parallel()
+		constant := func(_ *rand.Rand, _ *structpb.Value) *structpb.Value {
+			return structpb.NewStringValue("redacted")
+		}
+		got := Anonymize(input(t), "",
+			AnonymizeRule{Key: regexp.MustCompile(`^name$`), Path: regexp.MustCompile(`^friends`), Fake: constant},
+			ByKey(`^name$`, FakeName),
+		)
+		friend := got.GetFields()["friends"].GetListValue().GetValues()[0].GetStructValue().GetFields()
+		assert.Equal(t, "redacted", friend["name"].GetStringValue())
+		assert.NotEqual(t, "redacted", got.GetFields()["name"].GetStringValue())
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, Anonymize(nil, "", DefaultAnonymizeRules...))
+	})
+}
+
+func TestFakeFormatted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value *structpb.Value
+		check func(t *testing.T, got *structpb.Value)
+	}{
+		{
+			name:  "integer keeps magnitude and sign",
+			value: structpb.NewNumberValue(-4321),
+			check: func(t *testing.T, got *structpb.Value) {
+				t.Helper()
+				n := got.GetNumberValue()
+				assert.LessOrEqual(t, n, -1000.0)
+				assert.Greater(t, n, -10000.0)
+				assert.Equal(t, float64(int64(n)), n)
+			},
+		},
+		{
+			name:  "zero",
+			value: structpb.NewNumberValue(0),
+			check: func(t *testing.T, got *structpb.Value) {
+				t.Helper()
+				assert.Zero(t, got.GetNumberValue())
+			},
+		},
+		{
+			name:  "bool stays bool",
+			value: structpb.NewBoolValue(true),
+			check: func(t *testing.T, got *structpb.Value) {
+				t.Helper()
+				_, ok := got.GetKind().(*structpb.Value_BoolValue)
+				assert.True(t, ok)
+			},
+		},
+		{
+			name:  "string keeps punctuation",
+			value: structpb.NewStringValue("+1 (555) 010-9999"),
+			check: func(t *testing.T, got *structpb.Value) {
+				t.Helper()
+				assert.Regexp(t, `^\+\d \(\d{3}\) \d{3}-\d{4}$`, got.GetStringValue())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := FakeFormatted(rand.New(rand.NewSource(1)), tt.value)
+			require.NotNil(t, got)
+			tt.check(t, got)
+		})
+	}
+}
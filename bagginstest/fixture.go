@@ -0,0 +1,123 @@
+package bagginstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// FixtureOption configures LoadStructFixture
+type FixtureOption func(*fixtureOptions)
+
+type fixtureOptions struct {
+	data  any
+	funcs template.FuncMap
+}
+
+// WithTemplateData renders the fixture as a text/template with data before parsing it,
+// e.g. `name: {{ .Name }}`. Without this option fixtures are parsed verbatim
+func WithTemplateData(data any) FixtureOption {
+	return func(o *fixtureOptions) {
+		o.data = data
+	}
+}
+
+// WithTemplateFuncs adds functions available to fixture templates, see WithTemplateData
+func WithTemplateFuncs(funcs template.FuncMap) FixtureOption {
+	return func(o *fixtureOptions) {
+		if o.funcs == nil {
+			o.funcs = template.FuncMap{}
+		}
+		for name, fn := range funcs {
+			o.funcs[name] = fn
+		}
+	}
+}
+
+// LoadStructFixture reads a JSON or YAML fixture file into a *structpb.Struct, failing
+// the test with the file name and the line of any syntax error. The format is chosen
+// by extension: .yaml and .yml are YAML, everything else is JSON
+func LoadStructFixture(t testing.TB, path string, opts ...FixtureOption) *structpb.Struct {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	s, err := ParseStructFixture(path, data, opts...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return s
+}
+
+// ParseStructFixture parses fixture contents as LoadStructFixture does, using name to
+// choose the format and to prefix errors
+func ParseStructFixture(name string, data []byte, opts ...FixtureOption) (*structpb.Struct, error) {
+	var o fixtureOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.data != nil || o.funcs != nil {
+		tmpl, err := template.New(filepath.Base(name)).Option("missingkey=error").Funcs(o.funcs).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: parsing template: %w", name, err)
+		}
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, o.data); err != nil {
+			return nil, fmt.Errorf("fixture %s: rendering template: %w", name, err)
+		}
+		data = rendered.Bytes()
+	}
+
+	if ext := strings.ToLower(filepath.Ext(name)); ext == ".yaml" || ext == ".yml" {
+		s, err := protobaggins.YAMLToStruct(data)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", name, err)
+		}
+		return s, nil
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("fixture %s: %s", name, describeJSONError(data, err))
+	}
+	m, ok := decoded.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("fixture %s: top level must be an object, got %T", name, decoded)
+	}
+	v, err := protobaggins.NewValue(m)
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", name, err)
+	}
+	return v.GetStructValue(), nil
+}
+
+// describeJSONError adds the line and column to JSON syntax and type errors
+func describeJSONError(data []byte, err error) string {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err.Error()
+	}
+	before := data[:min(int(offset), len(data))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return fmt.Sprintf("line %d, column %d: %v", line, column, err)
+}
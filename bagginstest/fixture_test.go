@@ -0,0 +1,105 @@
+package bagginstest
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadStructFixture(t *testing.T) {
+	t.Parallel()
+
+	t.Run("yaml", func(t *testing.T) {
+		t.Parallel()
+		s := LoadStructFixture(t, "testdata/fixture.yaml")
+		assert.Equal(t, map[string]any{
+			"name":  "frodo",
+			"age":   float64(50),
+			"items": []any{"ring", "sting"},
+			"home":  map[string]any{"region": "shire", "1": "numeric key"},
+		}, s.AsMap())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		s := LoadStructFixture(t, "testdata/fixture.json")
+		assert.Equal(t, "frodo", s.GetFields()["name"].GetStringValue())
+		assert.Len(t, s.GetFields()["items"].GetListValue().GetValues(), 2)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+		ft := runFake(t, func(tb testing.TB) {
+			LoadStructFixture(tb, filepath.Join(t.TempDir(), "missing.yaml"))
+		})
+		require.Len(t, ft.failures, 1)
+		assert.Contains(t, ft.failures[0], "reading fixture")
+	})
+}
+
+func TestParseStructFixture(t *testing.T) {
+	t.Parallel()
+
+	t.Run("json syntax error reports position", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParseStructFixture("case.json", []byte("{\n  \"a\": 1,\n  \"b\": ]\n}"))
+		require.Error(t, err)
+		// the offset is past the bad byte, or at it with Go 1.25 under GOEXPERIMENT=jsonv2
+		assert.Regexp(t, `fixture case\.json: line 3, column [89]:`, err.Error())
+	})
+
+	t.Run("yaml syntax error reports line", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParseStructFixture("case.yaml", []byte("a: 1\nb: [unclosed\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "fixture case.yaml")
+		assert.Contains(t, err.Error(), "line")
+	})
+
+	t.Run("top level must be an object", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParseStructFixture("case.json", []byte(`[1, 2]`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "top level must be an object")
+
+		_, err = ParseStructFixture("case.json", []byte(`{"a": 1} {"b": 2}`))
+		require.Error(t, err)
+	})
+
+	t.Run("template substitution", func(t *testing.T) {
+		t.Parallel()
+		s, err := ParseStructFixture("case.yaml",
+			[]byte("name: {{ .Name }}\nshout: {{ upper .Name }}\n"),
+			WithTemplateData(map[string]any{"Name": "sam"}),
+			WithTemplateFuncs(template.FuncMap{"upper": strings.ToUpper}),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"name": "sam", "shout": "SAM"}, s.AsMap())
+	})
+
+	t.Run("missing template key", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParseStructFixture("case.yaml", []byte("name: {{ .Missing }}\n"),
+			WithTemplateData(map[string]any{"Name": "sam"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rendering template")
+	})
+
+	t.Run("malformed template", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParseStructFixture("case.yaml", []byte("name: {{ .Name "), WithTemplateData(struct{}{}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parsing template")
+	})
+
+	t.Run("templates are not rendered by default", func(t *testing.T) {
+		t.Parallel()
+		s, err := ParseStructFixture("case.json", []byte(`{"name": "{{ .Name }}"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "{{ .Name }}", s.GetFields()["name"].GetStringValue())
+	})
+}
@@ -0,0 +1,185 @@
+package bagginstest
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// GenerateOption configures GenerateValue
+type GenerateOption func(*generateOptions)
+
+type generateOptions struct {
+	maxDepth     int
+	maxSize      int
+	maxStringLen int
+	kinds        []protobaggins.Kind
+	// weights of the kinds, equal when nil
+	weights map[protobaggins.Kind]int
+}
+
+func newGenerateOptions(opts []GenerateOption) generateOptions {
+	o := generateOptions{
+		maxDepth:     3,
+		maxSize:      5,
+		maxStringLen: 16,
+		kinds:        protobaggins.AllKinds,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// MaxDepth limits how deeply lists and structs nest, 0 produces only scalars
+// The default is 3
+func MaxDepth(depth int) GenerateOption {
+	return func(o *generateOptions) {
+		o.maxDepth = max(depth, 0)
+	}
+}
+
+// MaxSize limits the number of elements in each generated list and fields in each
+// generated struct. The default is 5
+func MaxSize(size int) GenerateOption {
+	return func(o *generateOptions) {
+		o.maxSize = max(size, 0)
+	}
+}
+
+// MaxStringLen limits the length in runes of generated strings and struct keys
+// The default is 16
+func MaxStringLen(length int) GenerateOption {
+	return func(o *generateOptions) {
+		o.maxStringLen = max(length, 0)
+	}
+}
+
+// Kinds restricts the kinds that are generated. Container kinds are only chosen while
+// the depth limit allows, so leaves are drawn from the scalar kinds given here, or null
+// if none are. By default all kinds are generated
+func Kinds(kinds ...protobaggins.Kind) GenerateOption {
+	return func(o *generateOptions) {
+		o.kinds = kinds
+		o.weights = nil
+	}
+}
+
+// KindWeights generates kinds in proportion to their weights, e.g. mostly strings and
+// structs, replacing Kinds. Kinds without a positive weight are not generated, and
+// leaves are drawn from the scalar kinds as Kinds describes
+func KindWeights(weights map[protobaggins.Kind]int) GenerateOption {
+	return func(o *generateOptions) {
+		o.kinds = nil
+		o.weights = make(map[protobaggins.Kind]int, len(weights))
+		for _, k := range protobaggins.AllKinds {
+			if weights[k] > 0 {
+				o.kinds = append(o.kinds, k)
+				o.weights[k] = weights[k]
+			}
+		}
+	}
+}
+
+// GenerateValue returns a random *structpb.Value drawn from r. Numbers are always
+// finite and strings are valid UTF-8, so every generated value can be marshaled with
+// protojson. The same seed and options always produce the same value
+func GenerateValue(r *rand.Rand, opts ...GenerateOption) *structpb.Value {
+	o := newGenerateOptions(opts)
+	return o.value(r, o.maxDepth)
+}
+
+// GenerateStruct returns a random *structpb.Struct, see GenerateValue
+func GenerateStruct(r *rand.Rand, opts ...GenerateOption) *structpb.Struct {
+	o := newGenerateOptions(opts)
+	return o.structValue(r, max(o.maxDepth-1, 0))
+}
+
+func (o *generateOptions) value(r *rand.Rand, depth int) *structpb.Value {
+	switch o.kind(r, depth) {
+	case protobaggins.KindBool:
+		return structpb.NewBoolValue(r.Intn(2) == 1)
+	case protobaggins.KindNumber:
+		return structpb.NewNumberValue(o.number(r))
+	case protobaggins.KindString:
+		return structpb.NewStringValue(o.text(r))
+	case protobaggins.KindList:
+		values := make([]*structpb.Value, r.Intn(o.maxSize+1))
+		for i := range values {
+			values[i] = o.value(r, depth-1)
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values})
+	case protobaggins.KindStruct:
+		return structpb.NewStructValue(o.structValue(r, depth-1))
+	default:
+		return structpb.NewNullValue()
+	}
+}
+
+// kind picks the kind of a value at depth, null if no kind may be generated there
+func (o *generateOptions) kind(r *rand.Rand, depth int) protobaggins.Kind {
+	candidates := make([]protobaggins.Kind, 0, len(o.kinds))
+	total := 0
+	for _, k := range o.kinds {
+		if k.IsScalar() || depth > 0 {
+			candidates = append(candidates, k)
+			total += o.weight(k)
+		}
+	}
+	if len(candidates) == 0 {
+		return protobaggins.KindNull
+	}
+
+	n := r.Intn(total)
+	for _, k := range candidates {
+		if n -= o.weight(k); n < 0 {
+			return k
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+func (o *generateOptions) weight(k protobaggins.Kind) int {
+	if o.weights == nil {
+		return 1
+	}
+	return o.weights[k]
+}
+
+func (o *generateOptions) structValue(r *rand.Rand, depth int) *structpb.Struct {
+	n := r.Intn(o.maxSize + 1)
+	fields := make(map[string]*structpb.Value, n)
+	for range n {
+		fields[o.text(r)] = o.value(r, depth)
+	}
+	return &structpb.Struct{Fields: fields}
+}
+
+// number favors integers, which are the most common numbers in real payloads
+func (o *generateOptions) number(r *rand.Rand) float64 {
+	switch r.Intn(4) {
+	case 0:
+		return float64(r.Intn(201) - 100)
+	case 1:
+		return float64(r.Int63n(1<<53)) * float64(1-2*r.Intn(2))
+	case 2:
+		return r.NormFloat64() * 1000
+	default:
+		return math.Round(r.Float64()*10000) / 100
+	}
+}
+
+// alphabet mixes ASCII with multi-byte characters to exercise UTF-8 handling
+var alphabet = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 _-.[]\"éüñßøΩλ中文🙂")
+
+func (o *generateOptions) text(r *rand.Rand) string {
+	n := r.Intn(o.maxStringLen + 1)
+	var b strings.Builder
+	for range n {
+		b.WriteRune(alphabet[r.Intn(len(alphabet))])
+	}
+	return b.String()
+}
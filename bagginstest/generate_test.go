@@ -0,0 +1,135 @@
+package bagginstest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// measure returns the maximum nesting depth and largest container size in v
+func measure(v *structpb.Value) (depth, size int) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_ListValue:
+		size = len(kind.ListValue.GetValues())
+		for _, item := range kind.ListValue.GetValues() {
+			d, s := measure(item)
+			depth, size = max(depth, d+1), max(size, s)
+		}
+		return max(depth, 1), size
+	case *structpb.Value_StructValue:
+		size = len(kind.StructValue.GetFields())
+		for _, item := range kind.StructValue.GetFields() {
+			d, s := measure(item)
+			depth, size = max(depth, d+1), max(size, s)
+		}
+		return max(depth, 1), size
+	default:
+		return 0, 0
+	}
+}
+
+func TestGenerateValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deterministic for a seed", func(t *testing.T) {
+		t.Parallel()
+		a := GenerateValue(rand.New(rand.NewSource(7)))
+		b := GenerateValue(rand.New(rand.NewSource(7)))
+		assert.True(t, proto.Equal(a, b))
+	})
+
+	t.Run("always marshals", func(t *testing.T) {
+		t.Parallel()
+		r := rand.New(rand.NewSource(1))
+		for range 200 {
+			v := GenerateValue(r)
+			_, err := protojson.Marshal(v)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("respects depth and size limits", func(t *testing.T) {
+		t.Parallel()
+		r := rand.New(rand.NewSource(2))
+		for range 200 {
+			depth, size := measure(GenerateValue(r, MaxDepth(2), MaxSize(3)))
+			assert.LessOrEqual(t, depth, 2)
+			assert.LessOrEqual(t, size, 3)
+		}
+	})
+
+	t.Run("zero depth yields scalars", func(t *testing.T) {
+		t.Parallel()
+		r := rand.New(rand.NewSource(3))
+		for range 100 {
+			assert.True(t, protobaggins.KindOf(GenerateValue(r, MaxDepth(0))).IsScalar())
+		}
+	})
+
+	t.Run("restricts kinds", func(t *testing.T) {
+		t.Parallel()
+		r := rand.New(rand.NewSource(4))
+		for range 100 {
+			v := GenerateValue(r, Kinds(protobaggins.KindString), MaxStringLen(4))
+			require.Equal(t, protobaggins.KindString, protobaggins.KindOf(v))
+			assert.True(t, utf8.ValidString(v.GetStringValue()))
+			assert.LessOrEqual(t, utf8.RuneCountInString(v.GetStringValue()), 4)
+		}
+	})
+
+	t.Run("weights kinds", func(t *testing.T) {
+		t.Parallel()
+		r := rand.New(rand.NewSource(7))
+		counts := make(map[protobaggins.Kind]int)
+		for range 1000 {
+			v := GenerateValue(r, MaxDepth(0), KindWeights(map[protobaggins.Kind]int{
+				protobaggins.KindString: 9,
+				protobaggins.KindBool:   1,
+				protobaggins.KindNumber: 0,
+			}))
+			counts[protobaggins.KindOf(v)]++
+		}
+		assert.Len(t, counts, 2)
+		assert.Greater(t, counts[protobaggins.KindString], 800)
+		assert.Greater(t, counts[protobaggins.KindBool], 50)
+	})
+
+	t.Run("container only kinds fall back to null leaves", func(t *testing.T) {
+		t.Parallel()
+		r := rand.New(rand.NewSource(5))
+		v := GenerateValue(r, Kinds(protobaggins.KindList), MaxDepth(1))
+		for _, item := range v.GetListValue().GetValues() {
+			assert.Equal(t, protobaggins.KindNull, protobaggins.KindOf(item))
+		}
+	})
+
+	t.Run("numbers are finite", func(t *testing.T) {
+		t.Parallel()
+		r := rand.New(rand.NewSource(6))
+		for range 500 {
+			n := GenerateValue(r, Kinds(protobaggins.KindNumber)).GetNumberValue()
+			assert.False(t, math.IsNaN(n) || math.IsInf(n, 0))
+		}
+	})
+}
+
+func TestGenerateStruct(t *testing.T) {
+	t.Parallel()
+
+	r := rand.New(rand.NewSource(8))
+	for range 100 {
+		s := GenerateStruct(r, MaxDepth(2), MaxSize(4))
+		require.NotNil(t, s)
+		depth, size := measure(structpb.NewStructValue(s))
+		assert.LessOrEqual(t, depth, 2)
+		assert.LessOrEqual(t, size, 4)
+	}
+}
@@ -0,0 +1,127 @@
+// Package bagginstest provides helpers for testing code that produces or consumes
+// structpb payloads: golden files, snapshots, fixtures and value generators
+package bagginstest
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/protobaggins/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// UpdateEnv is the environment variable that, when set to a true value, makes
+// CompareGolden rewrite golden files instead of comparing against them
+const UpdateEnv = "UPDATE_GOLDEN"
+
+// Updating reports whether golden files should be rewritten. This is the case when the
+// test binary defines an -update flag that is set, e.g. `go test ./... -update` after
+//
+//	var _ = flag.Bool("update", false, "update golden files")
+//
+// or when the UPDATE_GOLDEN environment variable is set to a true value. The flag is not
+// registered by this package so that it cannot collide with one the test defines itself
+func Updating() bool {
+	if f := flag.Lookup("update"); f != nil {
+		if on, err := strconv.ParseBool(f.Value.String()); err == nil && on {
+			return true
+		}
+	}
+	on, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	return on
+}
+
+// isTextFormat reports whether path should hold prototext rather than JSON
+func isTextFormat(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txtpb", ".textproto", ".prototxt", ".pbtxt":
+		return true
+	default:
+		return false
+	}
+}
+
+// textFieldSep matches the separator after a field name at the start of a prototext
+// line, which the protobuf runtime deliberately pads with a random extra space
+var textFieldSep = regexp.MustCompile(`(?m)^([ \t]*[\w.]+:) +`)
+
+// MarshalGolden serializes s for a golden file. Files ending in .txtpb, .textproto,
+// .prototxt or .pbtxt use prototext, everything else uses indented JSON with sorted
+// keys. Prototext output is normalized to a single space after each field name, so
+// both forms are byte-for-byte deterministic across builds
+func MarshalGolden(path string, s *structpb.Struct) ([]byte, error) {
+	if isTextFormat(path) {
+		data, err := prototext.MarshalOptions{Multiline: true}.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		return textFieldSep.ReplaceAll(data, []byte("$1 ")), nil
+	}
+	data, err := json.MarshalIndent(s.AsMap(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// UnmarshalGolden parses golden file contents in the format implied by path
+func UnmarshalGolden(path string, data []byte) (*structpb.Struct, error) {
+	s := &structpb.Struct{}
+	if isTextFormat(path) {
+		return s, prototext.Unmarshal(data, s)
+	}
+	return s, protojson.Unmarshal(data, s)
+}
+
+// SaveGolden writes s to the golden file at path, creating parent directories as needed
+func SaveGolden(t testing.TB, path string, s *structpb.Struct) {
+	t.Helper()
+
+	data, err := MarshalGolden(path, s)
+	if err != nil {
+		t.Fatalf("marshaling golden file %s: %v", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("creating golden directory for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("writing golden file %s: %v", path, err)
+	}
+}
+
+// LoadGolden reads the golden file at path, failing the test if it is missing or malformed
+func LoadGolden(t testing.TB, path string) *structpb.Struct {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file %s (run with -update or %s=1 to create it): %v", path, UpdateEnv, err)
+	}
+	s, err := UnmarshalGolden(path, data)
+	if err != nil {
+		t.Fatalf("parsing golden file %s: %v", path, err)
+	}
+	return s
+}
+
+// CompareGolden checks got against the golden file at path and reports a path-based
+// diff on mismatch. The comparison is semantic, so formatting differences in the file
+// are ignored. When Updating is true the golden file is rewritten with got instead
+// Returns true if got matches, or the file was updated
+func CompareGolden(t testing.TB, path string, got *structpb.Struct, opts ...assert.Option) bool {
+	t.Helper()
+
+	if Updating() {
+		SaveGolden(t, path, got)
+		return true
+	}
+	return assert.AssertStructEqual(t, LoadGolden(t, path), got, opts...)
+}
@@ -0,0 +1,168 @@
+package bagginstest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// fakeT records failures instead of failing the enclosing test
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// runFake runs fn against a fakeT on its own goroutine so Fatalf can stop it
+func runFake(t *testing.T, fn func(tb testing.TB)) *fakeT {
+	t.Helper()
+	ft := &fakeT{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(ft)
+	}()
+	<-done
+	return ft
+}
+
+func mustStruct(t *testing.T, m map[string]any) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(m)
+	require.NoError(t, err)
+	return s
+}
+
+func TestMarshalGolden(t *testing.T) {
+	t.Parallel()
+
+	s := mustStruct(t, map[string]any{"b": 1, "a": []any{"x", nil}})
+
+	t.Run("json is deterministic", func(t *testing.T) {
+		t.Parallel()
+		data, err := MarshalGolden("case.json", s)
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"a\": [\n    \"x\",\n    null\n  ],\n  \"b\": 1\n}\n", string(data))
+
+		again, err := MarshalGolden("case.json", s)
+		require.NoError(t, err)
+		assert.Equal(t, data, again)
+	})
+
+	t.Run("text is normalized", func(t *testing.T) {
+		t.Parallel()
+		data, err := MarshalGolden("case.txtpb", s)
+		require.NoError(t, err)
+		assert.Equal(t, "fields: {\n  key: \"a\"\n  value: {\n    list_value: {\n"+
+			"      values: {\n        string_value: \"x\"\n      }\n"+
+			"      values: {\n        null_value: NULL_VALUE\n      }\n    }\n  }\n}\n"+
+			"fields: {\n  key: \"b\"\n  value: {\n    number_value: 1\n  }\n}\n", string(data))
+	})
+
+	t.Run("text keeps spaces inside values", func(t *testing.T) {
+		t.Parallel()
+		data, err := MarshalGolden("case.txtpb", mustStruct(t, map[string]any{"k": "a:  b"}))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `string_value: "a:  b"`)
+	})
+
+	t.Run("text round trip", func(t *testing.T) {
+		t.Parallel()
+		data, err := MarshalGolden("case.txtpb", s)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "fields")
+
+		result, err := UnmarshalGolden("case.txtpb", data)
+		require.NoError(t, err)
+		assert.Equal(t, s.AsMap(), result.AsMap())
+	})
+
+	t.Run("json round trip", func(t *testing.T) {
+		t.Parallel()
+		data, err := MarshalGolden("case.json", s)
+		require.NoError(t, err)
+
+		result, err := UnmarshalGolden("case.json", data)
+		require.NoError(t, err)
+		assert.Equal(t, s.AsMap(), result.AsMap())
+	})
+}
+
+func TestSaveLoadGolden(t *testing.T) {
+	t.Parallel()
+
+	t.Run("creates directories", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "nested", "dir", "case.json")
+		s := mustStruct(t, map[string]any{"name": "frodo"})
+
+		SaveGolden(t, path, s)
+		assert.Equal(t, s.AsMap(), LoadGolden(t, path).AsMap())
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+		ft := runFake(t, func(tb testing.TB) {
+			LoadGolden(tb, filepath.Join(t.TempDir(), "missing.json"))
+		})
+		require.Len(t, ft.failures, 1)
+		assert.Contains(t, ft.failures[0], "-update")
+	})
+
+	t.Run("malformed file", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "bad.json")
+		require.NoError(t, os.WriteFile(path, []byte("[1, 2]"), 0o644))
+
+		ft := runFake(t, func(tb testing.TB) { LoadGolden(tb, path) })
+		require.Len(t, ft.failures, 1)
+		assert.Contains(t, ft.failures[0], "parsing golden file")
+	})
+}
+
+func TestCompareGolden(t *testing.T) {
+	t.Run("matches committed golden file", func(t *testing.T) {
+		got := mustStruct(t, map[string]any{"name": "frodo", "age": 50, "items": []any{"ring", "sting"}})
+		assert.True(t, CompareGolden(t, "testdata/hobbit.golden.json", got))
+	})
+
+	t.Run("reports diff on mismatch", func(t *testing.T) {
+		got := mustStruct(t, map[string]any{"name": "sam", "age": 50, "items": []any{"ring", "sting"}})
+		ft := runFake(t, func(tb testing.TB) {
+			assert.False(t, CompareGolden(tb, "testdata/hobbit.golden.json", got))
+		})
+		require.Len(t, ft.failures, 1)
+		assert.Contains(t, ft.failures[0], `~ name: "frodo" -> "sam"`)
+	})
+
+	t.Run("update rewrites the file", func(t *testing.T) {
+		t.Setenv(UpdateEnv, "true")
+		path := filepath.Join(t.TempDir(), "case.json")
+		got := mustStruct(t, map[string]any{"name": "sam"})
+
+		assert.True(t, Updating())
+		assert.True(t, CompareGolden(t, path, got))
+		assert.Equal(t, got.AsMap(), LoadGolden(t, path).AsMap())
+	})
+
+	t.Run("not updating by default", func(t *testing.T) {
+		t.Setenv(UpdateEnv, "")
+		assert.False(t, Updating())
+	})
+}
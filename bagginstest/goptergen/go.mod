@@ -0,0 +1,18 @@
+module github.com/robbyt/protobaggins/bagginstest/goptergen
+
+go 1.25.1
+
+require (
+	github.com/leanovate/gopter v0.2.11
+	github.com/robbyt/protobaggins v0.0.0
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/robbyt/protobaggins => ../../
@@ -0,0 +1,162 @@
+// Package goptergen adapts the bagginstest value generator to github.com/leanovate/gopter
+// and provides a shrinker so failing structpb inputs are reduced to minimal examples
+package goptergen
+
+import (
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+
+	"github.com/leanovate/gopter"
+	"github.com/robbyt/protobaggins/bagginstest"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Value returns a gopter generator of *structpb.Value built on bagginstest.GenerateValue
+func Value(opts ...bagginstest.GenerateOption) gopter.Gen {
+	return func(params *gopter.GenParameters) *gopter.GenResult {
+		result := gopter.NewGenResult(bagginstest.GenerateValue(params.Rng, opts...), ShrinkValue)
+		result.ResultType = reflect.TypeFor[*structpb.Value]()
+		return result
+	}
+}
+
+// Struct returns a gopter generator of *structpb.Struct built on bagginstest.GenerateStruct
+func Struct(opts ...bagginstest.GenerateOption) gopter.Gen {
+	return func(params *gopter.GenParameters) *gopter.GenResult {
+		result := gopter.NewGenResult(bagginstest.GenerateStruct(params.Rng, opts...), ShrinkStruct)
+		result.ResultType = reflect.TypeFor[*structpb.Struct]()
+		return result
+	}
+}
+
+// ShrinkValue is a gopter.Shrinker for *structpb.Value. Candidates are tried simplest
+// first: null, then removing list elements or struct fields, then shrinking children,
+// and finally simplifying scalars towards false, zero and the empty string
+func ShrinkValue(value any) gopter.Shrink {
+	v, ok := value.(*structpb.Value)
+	if !ok || v == nil {
+		return gopter.NoShrink
+	}
+	var candidates []any
+	if _, isNull := v.GetKind().(*structpb.Value_NullValue); !isNull {
+		candidates = append(candidates, structpb.NewNullValue())
+	}
+	for _, c := range shrinkValue(v) {
+		candidates = append(candidates, c)
+	}
+	return shrinkOf(candidates)
+}
+
+// ShrinkStruct is a gopter.Shrinker for *structpb.Struct, see ShrinkValue
+func ShrinkStruct(value any) gopter.Shrink {
+	s, ok := value.(*structpb.Struct)
+	if !ok || s == nil {
+		return gopter.NoShrink
+	}
+	var candidates []any
+	for _, c := range shrinkStruct(s) {
+		candidates = append(candidates, c)
+	}
+	return shrinkOf(candidates)
+}
+
+// shrinkOf iterates over a fixed list of candidates
+func shrinkOf(candidates []any) gopter.Shrink {
+	i := 0
+	return func() (any, bool) {
+		if i >= len(candidates) {
+			return nil, false
+		}
+		i++
+		return candidates[i-1], true
+	}
+}
+
+// shrinkValue returns the one-step simplifications of v without changing its kind
+func shrinkValue(v *structpb.Value) []*structpb.Value {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		if kind.BoolValue {
+			return []*structpb.Value{structpb.NewBoolValue(false)}
+		}
+	case *structpb.Value_NumberValue:
+		return shrinkNumber(kind.NumberValue)
+	case *structpb.Value_StringValue:
+		runes := []rune(kind.StringValue)
+		if len(runes) > 0 {
+			return []*structpb.Value{
+				structpb.NewStringValue(""),
+				structpb.NewStringValue(string(runes[:len(runes)/2])),
+			}
+		}
+	case *structpb.Value_ListValue:
+		var out []*structpb.Value
+		for _, l := range shrinkList(kind.ListValue) {
+			out = append(out, structpb.NewListValue(l))
+		}
+		return out
+	case *structpb.Value_StructValue:
+		var out []*structpb.Value
+		for _, s := range shrinkStruct(kind.StructValue) {
+			out = append(out, structpb.NewStructValue(s))
+		}
+		return out
+	}
+	return nil
+}
+
+func shrinkNumber(n float64) []*structpb.Value {
+	if n == 0 {
+		return nil
+	}
+	out := []*structpb.Value{structpb.NewNumberValue(0)}
+	if t := math.Trunc(n); t != n {
+		out = append(out, structpb.NewNumberValue(t))
+	}
+	if h := math.Trunc(n / 2); h != 0 && h != n {
+		out = append(out, structpb.NewNumberValue(h))
+	}
+	return out
+}
+
+func shrinkList(l *structpb.ListValue) []*structpb.ListValue {
+	values := l.GetValues()
+	var out []*structpb.ListValue
+	for i := range values {
+		out = append(out, &structpb.ListValue{Values: slices.Delete(slices.Clone(values), i, i+1)})
+	}
+	for i, item := range values {
+		for _, c := range shrinkValue(item) {
+			replaced := slices.Clone(values)
+			replaced[i] = c
+			out = append(out, &structpb.ListValue{Values: replaced})
+		}
+	}
+	return out
+}
+
+func shrinkStruct(s *structpb.Struct) []*structpb.Struct {
+	fields := s.GetFields()
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var out []*structpb.Struct
+	for _, k := range keys {
+		without := maps.Clone(fields)
+		delete(without, k)
+		out = append(out, &structpb.Struct{Fields: without})
+	}
+	for _, k := range keys {
+		for _, c := range shrinkValue(fields[k]) {
+			replaced := maps.Clone(fields)
+			replaced[k] = c
+			out = append(out, &structpb.Struct{Fields: replaced})
+		}
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package goptergen
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/prop"
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/bagginstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	properties := gopter.NewProperties(gopter.DefaultTestParametersWithSeed(1))
+	properties.Property("values survive a protojson round trip", prop.ForAll(
+		func(v *structpb.Value) bool {
+			data, err := protojson.Marshal(v)
+			if err != nil {
+				return false
+			}
+			decoded := &structpb.Value{}
+			return protojson.Unmarshal(data, decoded) == nil && proto.Equal(v, decoded)
+		},
+		Value(),
+	))
+	properties.TestingRun(t)
+}
+
+func TestStruct(t *testing.T) {
+	t.Parallel()
+
+	properties := gopter.NewProperties(gopter.DefaultTestParametersWithSeed(2))
+	properties.Property("structs respect size limits", prop.ForAll(
+		func(s *structpb.Struct) bool {
+			return len(s.GetFields()) <= 2
+		},
+		Struct(bagginstest.MaxSize(2)),
+	))
+	properties.TestingRun(t)
+}
+
+func TestShrinkValue(t *testing.T) {
+	t.Parallel()
+
+	collect := func(shrink gopter.Shrink) []*structpb.Value {
+		var out []*structpb.Value
+		for {
+			v, ok := shrink()
+			if !ok {
+				return out
+			}
+			out = append(out, v.(*structpb.Value))
+		}
+	}
+
+	t.Run("null and unknown inputs do not shrink", func(t *testing.T) {
+		t.Parallel()
+		assert.Empty(t, collect(ShrinkValue(structpb.NewNullValue())))
+		assert.Empty(t, collect(ShrinkValue("not a value")))
+	})
+
+	t.Run("scalars shrink towards zero values", func(t *testing.T) {
+		t.Parallel()
+		candidates := collect(ShrinkValue(structpb.NewNumberValue(10.5)))
+		require.Len(t, candidates, 4)
+		assert.Equal(t, protobaggins.KindNull, protobaggins.KindOf(candidates[0]))
+		assert.Zero(t, candidates[1].GetNumberValue())
+		assert.InEpsilon(t, 10.0, candidates[2].GetNumberValue(), 0.001)
+		assert.InEpsilon(t, 5.0, candidates[3].GetNumberValue(), 0.001)
+
+		candidates = collect(ShrinkValue(structpb.NewStringValue("abcd")))
+		require.Len(t, candidates, 3)
+		assert.Empty(t, candidates[1].GetStringValue())
+		assert.Equal(t, "ab", candidates[2].GetStringValue())
+
+		candidates = collect(ShrinkValue(structpb.NewBoolValue(true)))
+		require.Len(t, candidates, 2)
+		assert.False(t, candidates[1].GetBoolValue())
+	})
+
+	t.Run("containers remove then shrink children", func(t *testing.T) {
+		t.Parallel()
+		v, err := structpb.NewValue(map[string]any{"a": true, "b": []any{"x"}})
+		require.NoError(t, err)
+		original := proto.Clone(v)
+
+		candidates := collect(ShrinkValue(v))
+		// null, two removals, a:false, b:[] and b:[""] from the nested list, b:[null]
+		require.GreaterOrEqual(t, len(candidates), 5)
+		assert.Len(t, candidates[1].GetStructValue().GetFields(), 1)
+		assert.Len(t, candidates[2].GetStructValue().GetFields(), 1)
+		assert.False(t, candidates[3].GetStructValue().GetFields()["a"].GetBoolValue())
+		assert.True(t, proto.Equal(original, v), "shrinking must not modify the input")
+	})
+}
+
+func TestShrinkStruct(t *testing.T) {
+	t.Parallel()
+
+	assert.NotNil(t, ShrinkStruct(nil))
+
+	s, err := structpb.NewStruct(map[string]any{"a": 1, "b": 2})
+	require.NoError(t, err)
+
+	first, ok := ShrinkStruct(s)()
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{"b": float64(2)}, first.(*structpb.Struct).AsMap())
+}
+
+func TestShrinksFailingProperty(t *testing.T) {
+	t.Parallel()
+
+	params := gopter.DefaultTestParametersWithSeed(3)
+	properties := gopter.NewProperties(params)
+	properties.Property("no struct has a numeric field", prop.ForAll(
+		func(s *structpb.Struct) bool {
+			for _, field := range s.GetFields() {
+				if protobaggins.KindOf(field) == protobaggins.KindNumber {
+					return false
+				}
+			}
+			return true
+		},
+		Struct(bagginstest.MaxDepth(1)),
+	))
+
+	reporter := &recordingReporter{}
+	assert.False(t, properties.Run(reporter))
+	require.NotNil(t, reporter.failure)
+	require.Len(t, reporter.failure.Args, 1)
+
+	shrunk := reporter.failure.Args[0].Arg.(*structpb.Struct)
+	require.Len(t, shrunk.GetFields(), 1)
+	for _, field := range shrunk.GetFields() {
+		assert.Zero(t, field.GetNumberValue())
+	}
+}
+
+type recordingReporter struct {
+	failure *gopter.PropResult
+}
+
+func (r *recordingReporter) ReportTestResult(_ string, result *gopter.TestResult) {
+	if !result.Passed() {
+		r.failure = &gopter.PropResult{Args: result.Args}
+	}
+}
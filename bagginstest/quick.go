@@ -0,0 +1,90 @@
+package bagginstest
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// quickMaxSize caps the size hint from testing/quick, whose default of 50 would make
+// nested values grow into the millions of elements
+const quickMaxSize = 5
+
+// QuickValue wraps a *structpb.Value so it can be generated by testing/quick, e.g.
+//
+//	quick.Check(func(v bagginstest.QuickValue) bool { ... }, nil)
+type QuickValue struct {
+	*structpb.Value
+}
+
+var _ quick.Generator = QuickValue{}
+
+// Generate implements quick.Generator using GenerateValue, with size limiting the
+// number of elements in each list and struct
+func (QuickValue) Generate(r *rand.Rand, size int) reflect.Value {
+	v := GenerateValue(r, MaxSize(min(size, quickMaxSize)))
+	return reflect.ValueOf(QuickValue{Value: v})
+}
+
+// QuickStruct wraps a *structpb.Struct so it can be generated by testing/quick
+type QuickStruct struct {
+	*structpb.Struct
+}
+
+var _ quick.Generator = QuickStruct{}
+
+// Generate implements quick.Generator using GenerateStruct, see QuickValue.Generate
+func (QuickStruct) Generate(r *rand.Rand, size int) reflect.Value {
+	s := GenerateStruct(r, MaxSize(min(size, quickMaxSize)))
+	return reflect.ValueOf(QuickStruct{Struct: s})
+}
+
+// AddStructSeeds adds each Struct to the fuzz corpus as protojson bytes, for fuzz
+// targets of the form func(t *testing.T, data []byte). Use StructFromFuzz to decode
+// the input inside the target
+func AddStructSeeds(f *testing.F, structs ...*structpb.Struct) {
+	f.Helper()
+
+	for _, s := range structs {
+		data, err := protojson.Marshal(s)
+		if err != nil {
+			f.Fatalf("marshaling fuzz seed: %v", err)
+		}
+		f.Add(data)
+	}
+}
+
+// AddFixtureSeeds loads every JSON or YAML fixture matching the glob patterns with
+// LoadStructFixture and adds it to the fuzz corpus, see AddStructSeeds
+func AddFixtureSeeds(f *testing.F, patterns ...string) {
+	f.Helper()
+
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			f.Fatalf("matching fixture pattern %q: %v", pattern, err)
+		}
+		if len(paths) == 0 {
+			f.Fatalf("no fixtures match %q", pattern)
+		}
+		for _, path := range paths {
+			AddStructSeeds(f, LoadStructFixture(f, path))
+		}
+	}
+}
+
+// StructFromFuzz decodes fuzz input added by AddStructSeeds
+// Returns false for inputs that are not a valid protojson Struct, which fuzz targets
+// should usually skip
+func StructFromFuzz(data []byte) (*structpb.Struct, bool) {
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, s); err != nil {
+		return nil, false
+	}
+	return s, true
+}
@@ -0,0 +1,65 @@
+package bagginstest
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestQuickValue(t *testing.T) {
+	t.Parallel()
+
+	err := quick.Check(func(v QuickValue) bool {
+		data, err := protojson.Marshal(v.Value)
+		if err != nil {
+			return false
+		}
+		back := &structpb.Value{}
+		return protojson.Unmarshal(data, back) == nil && proto.Equal(v.Value, back)
+	}, nil)
+	assert.NoError(t, err)
+}
+
+func TestQuickStruct(t *testing.T) {
+	t.Parallel()
+
+	err := quick.Check(func(s QuickStruct) bool {
+		depth, size := measure(structpb.NewStructValue(s.Struct))
+		return s.Struct != nil && depth <= 3 && size <= quickMaxSize
+	}, &quick.Config{MaxCount: 50})
+	assert.NoError(t, err)
+}
+
+func TestStructFromFuzz(t *testing.T) {
+	t.Parallel()
+
+	s, ok := StructFromFuzz([]byte(`{"name":"frodo"}`))
+	require.True(t, ok)
+	assert.Equal(t, "frodo", s.GetFields()["name"].GetStringValue())
+
+	_, ok = StructFromFuzz([]byte(`[1, 2]`))
+	assert.False(t, ok)
+}
+
+func FuzzStructSeeds(f *testing.F) {
+	AddStructSeeds(f, &structpb.Struct{}, GenerateStruct(rand.New(rand.NewSource(1))))
+	AddFixtureSeeds(f, "testdata/fixture.*")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		s, ok := StructFromFuzz(data)
+		if !ok {
+			t.Skip()
+		}
+		data, err := protojson.Marshal(s)
+		require.NoError(t, err)
+		back, ok := StructFromFuzz(data)
+		require.True(t, ok)
+		assert.True(t, proto.Equal(s, back))
+	})
+}
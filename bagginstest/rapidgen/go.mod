@@ -0,0 +1,19 @@
+module github.com/robbyt/protobaggins/bagginstest/rapidgen
+
+go 1.25.1
+
+require (
+	github.com/robbyt/protobaggins v0.0.0
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/protobuf v1.36.11
+	pgregory.net/rapid v1.3.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/robbyt/protobaggins => ../../
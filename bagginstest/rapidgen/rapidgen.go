@@ -0,0 +1,41 @@
+// Package rapidgen adapts the bagginstest value generator to pgregory.net/rapid so
+// property tests can draw structpb values and have failing inputs shrunk
+package rapidgen
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/robbyt/protobaggins/bagginstest"
+	"google.golang.org/protobuf/types/known/structpb"
+	"pgregory.net/rapid"
+)
+
+// drawSource is a rand.Source whose output is drawn from rapid, so every random choice
+// made by the generator is visible to rapid and shrinks towards zero. Zero choices pick
+// the first kind (null) and empty containers, so failing values shrink towards small ones
+type drawSource struct {
+	t *rapid.T
+}
+
+var bits = rapid.Int64Range(0, math.MaxInt64)
+
+func (s drawSource) Int63() int64 {
+	return bits.Draw(s.t, "bits")
+}
+
+func (s drawSource) Seed(int64) {}
+
+// Value returns a rapid generator of *structpb.Value built on bagginstest.GenerateValue
+func Value(opts ...bagginstest.GenerateOption) *rapid.Generator[*structpb.Value] {
+	return rapid.Custom(func(t *rapid.T) *structpb.Value {
+		return bagginstest.GenerateValue(rand.New(drawSource{t: t}), opts...)
+	})
+}
+
+// Struct returns a rapid generator of *structpb.Struct built on bagginstest.GenerateStruct
+func Struct(opts ...bagginstest.GenerateOption) *rapid.Generator[*structpb.Struct] {
+	return rapid.Custom(func(t *rapid.T) *structpb.Struct {
+		return bagginstest.GenerateStruct(rand.New(drawSource{t: t}), opts...)
+	})
+}
@@ -0,0 +1,59 @@
+package rapidgen
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/bagginstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"pgregory.net/rapid"
+)
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	rapid.Check(t, func(rt *rapid.T) {
+		v := Value().Draw(rt, "value")
+		data, err := protojson.Marshal(v)
+		require.NoError(rt, err)
+
+		decoded := &structpb.Value{}
+		require.NoError(rt, protojson.Unmarshal(data, decoded))
+		assert.True(rt, proto.Equal(v, decoded))
+	})
+}
+
+func TestStruct(t *testing.T) {
+	t.Parallel()
+
+	rapid.Check(t, func(rt *rapid.T) {
+		s := Struct(bagginstest.MaxDepth(1), bagginstest.MaxSize(3)).Draw(rt, "struct")
+		require.NotNil(rt, s)
+		assert.LessOrEqual(rt, len(s.GetFields()), 3)
+		for _, field := range s.GetFields() {
+			assert.True(rt, protobaggins.KindOf(field).IsScalar())
+		}
+	})
+}
+
+// zeroSource stands in for a fully shrunk rapid draw sequence
+type zeroSource struct{}
+
+func (zeroSource) Int63() int64 { return 0 }
+
+func (zeroSource) Seed(int64) {}
+
+func TestShrinkTarget(t *testing.T) {
+	t.Parallel()
+
+	// shrinking drives every draw towards zero, which must yield the simplest values
+	r := rand.New(zeroSource{})
+	assert.Equal(t, protobaggins.KindNull, protobaggins.KindOf(bagginstest.GenerateValue(r)))
+	assert.Empty(t, bagginstest.GenerateStruct(r).GetFields())
+	assert.Empty(t, bagginstest.GenerateValue(r, bagginstest.Kinds(protobaggins.KindString)).GetStringValue())
+}
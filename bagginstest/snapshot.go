@@ -0,0 +1,125 @@
+package bagginstest
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/protobaggins/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultSnapshotDir is where MatchSnapshot stores snapshots, relative to the package under test
+const DefaultSnapshotDir = ".snapshots"
+
+// SnapshotOption configures MatchSnapshot
+type SnapshotOption func(*snapshotOptions)
+
+type snapshotOptions struct {
+	dir          string
+	name         string
+	failOnCreate bool
+	compare      []assert.Option
+}
+
+// SnapshotDir stores snapshots in dir instead of DefaultSnapshotDir
+func SnapshotDir(dir string) SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.dir = dir
+	}
+}
+
+// SnapshotName appends name to the snapshot file name, for tests that take more than
+// one snapshot
+func SnapshotName(name string) SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.name = name
+	}
+}
+
+// SnapshotIgnore skips differences at the given paths and anywhere beneath them, for
+// values such as timestamps or request IDs that change on every run
+func SnapshotIgnore(paths ...string) SnapshotOption {
+	return SnapshotCompareOptions(assert.IgnorePaths(paths...))
+}
+
+// SnapshotCompareOptions passes additional comparison options to the assertion
+func SnapshotCompareOptions(opts ...assert.Option) SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.compare = append(o.compare, opts...)
+	}
+}
+
+// FailOnNewSnapshot fails the test when a snapshot had to be created, so that CI runs
+// cannot pass by silently recording whatever the code currently produces
+func FailOnNewSnapshot() SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.failOnCreate = true
+	}
+}
+
+// SnapshotPath returns the file MatchSnapshot uses for the current test
+func SnapshotPath(t testing.TB, opts ...SnapshotOption) string {
+	o := newSnapshotOptions(opts)
+	return o.path(t)
+}
+
+// MatchSnapshot compares got against the snapshot stored for the current test. The first
+// run writes the snapshot as indented JSON with sorted keys, later runs compare against it
+// semantically and report a path-based diff on mismatch. When Updating is true the
+// snapshot is rewritten instead. Returns true if got matches or the snapshot was written
+func MatchSnapshot(t testing.TB, got *structpb.Struct, opts ...SnapshotOption) bool {
+	t.Helper()
+
+	o := newSnapshotOptions(opts)
+	path := o.path(t)
+
+	if Updating() {
+		SaveGolden(t, path, got)
+		return true
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		SaveGolden(t, path, got)
+		if o.failOnCreate {
+			t.Errorf("snapshot %s did not exist and was created", path)
+			return false
+		}
+		t.Logf("created snapshot %s", path)
+		return true
+	}
+
+	return assert.AssertStructEqual(t, LoadGolden(t, path), got, o.compare...)
+}
+
+func newSnapshotOptions(opts []SnapshotOption) snapshotOptions {
+	o := snapshotOptions{dir: DefaultSnapshotDir}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o snapshotOptions) path(t testing.TB) string {
+	name := t.Name()
+	if o.name != "" {
+		name += "-" + o.name
+	}
+	return filepath.Join(o.dir, sanitizeSnapshotName(name)+".json")
+}
+
+// sanitizeSnapshotName maps subtest separators and characters that are awkward in file
+// names to underscores
+func sanitizeSnapshotName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '_'
+		default:
+			return r
+		}
+	}, name)
+}
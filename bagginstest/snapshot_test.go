@@ -0,0 +1,71 @@
+package bagginstest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchSnapshot(t *testing.T) {
+	t.Run("creates then compares", func(t *testing.T) {
+		t.Setenv(UpdateEnv, "")
+		dir := t.TempDir()
+		got := mustStruct(t, map[string]any{"name": "frodo", "createdAt": "2024-01-01T00:00:00Z"})
+
+		assert.True(t, MatchSnapshot(t, got, SnapshotDir(dir)))
+		assert.FileExists(t, filepath.Join(dir, "TestMatchSnapshot_creates_then_compares.json"))
+		assert.True(t, MatchSnapshot(t, got, SnapshotDir(dir)))
+
+		changed := mustStruct(t, map[string]any{"name": "sam", "createdAt": "2025-01-01T00:00:00Z"})
+		ft := runFake(t, func(tb testing.TB) {
+			assert.False(t, MatchSnapshot(tb, changed, SnapshotDir(dir)))
+		})
+		require.Len(t, ft.failures, 1)
+		assert.Contains(t, ft.failures[0], `~ name: "frodo" -> "sam"`)
+		assert.Contains(t, ft.failures[0], `~ createdAt`)
+
+		ft = runFake(t, func(tb testing.TB) {
+			assert.False(t, MatchSnapshot(tb, changed, SnapshotDir(dir), SnapshotIgnore("createdAt")))
+		})
+		require.Len(t, ft.failures, 1)
+		assert.NotContains(t, ft.failures[0], "createdAt")
+	})
+
+	t.Run("named snapshots", func(t *testing.T) {
+		t.Setenv(UpdateEnv, "")
+		dir := t.TempDir()
+		assert.True(t, MatchSnapshot(t, mustStruct(t, map[string]any{"a": 1}), SnapshotDir(dir), SnapshotName("first")))
+		assert.True(t, MatchSnapshot(t, mustStruct(t, map[string]any{"b": 2}), SnapshotDir(dir), SnapshotName("second")))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, filepath.Join(dir, "TestMatchSnapshot_named_snapshots-first.json"),
+			SnapshotPath(t, SnapshotDir(dir), SnapshotName("first")))
+	})
+
+	t.Run("fail on new snapshot", func(t *testing.T) {
+		t.Setenv(UpdateEnv, "")
+		dir := t.TempDir()
+		got := mustStruct(t, map[string]any{"a": 1})
+		ft := runFake(t, func(tb testing.TB) {
+			assert.False(t, MatchSnapshot(tb, got, SnapshotDir(dir), FailOnNewSnapshot()))
+		})
+		require.Len(t, ft.failures, 1)
+		assert.Contains(t, ft.failures[0], "was created")
+		assert.True(t, MatchSnapshot(t, got, SnapshotDir(dir), FailOnNewSnapshot()))
+	})
+
+	t.Run("update rewrites", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv(UpdateEnv, "")
+		require.True(t, MatchSnapshot(t, mustStruct(t, map[string]any{"a": 1}), SnapshotDir(dir)))
+
+		t.Setenv(UpdateEnv, "1")
+		assert.True(t, MatchSnapshot(t, mustStruct(t, map[string]any{"a": 2}), SnapshotDir(dir)))
+		assert.InDelta(t, 2, LoadGolden(t, SnapshotPath(t, SnapshotDir(dir))).AsMap()["a"], 0)
+	})
+}
@@ -0,0 +1,175 @@
+package protobaggins
+
+import (
+	"hash/maphash"
+	"reflect"
+	"runtime"
+	"sync"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// minBatchSize is the smallest number of entries worth converting on a goroutine of
+// its own. Smaller inputs are converted on fewer goroutines, or on the calling one
+const minBatchSize = 1024
+
+// BatchOption configures BatchMapToStructValues and BatchSliceToStructValues
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	parallelism int
+	encode      []Option
+}
+
+// BatchParallelism limits the number of goroutines converting at once to n. The
+// default, and the value used when n is not positive, is runtime.GOMAXPROCS(0)
+func BatchParallelism(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.parallelism = max(n, 0)
+	}
+}
+
+// BatchEncoding passes opts to the conversion of each value, as for NewValue
+// WithOnSkip callbacks may run concurrently and must be safe for that, and WithMaxNodes
+// and WithMaxBytes make the conversion run on the calling goroutine
+func BatchEncoding(opts ...Option) BatchOption {
+	return func(o *batchOptions) {
+		o.encode = append(o.encode, opts...)
+	}
+}
+
+// workers returns the number of goroutines to convert n entries on
+func (o *batchOptions) workers(n int, opts options) int {
+	if opts.maxNodes > 0 || opts.maxBytes > 0 {
+		// the limits apply to the whole result
+		return 1
+	}
+	parallelism := o.parallelism
+	if parallelism == 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	return max(min(parallelism, n/minBatchSize), 1)
+}
+
+func newBatchOptions(opts []BatchOption) batchOptions {
+	var o batchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// BatchMapToStructValues converts m like Converter.MapToStructValues, splitting large
+// maps across goroutines. The result and the errors are the same as those of a
+// Converter with the BatchEncoding options
+func BatchMapToStructValues(m map[string]any, opts ...BatchOption) (map[string]*structpb.Value, error) {
+	o := newBatchOptions(opts)
+	encodeOpts := newOptions(o.encode)
+	workers := o.workers(len(m), encodeOpts)
+	if workers == 1 {
+		return NewConverter(o.encode...).MapToStructValues(m)
+	}
+
+	// keys that collide after WithKeyCase must meet on the same goroutine to be detected
+	seed := maphash.MakeSeed()
+	shards := make([][]string, workers)
+	for k := range m {
+		shard := maphash.String(seed, encodeOpts.renameKey(k)) % uint64(workers)
+		shards[shard] = append(shards[shard], k)
+	}
+
+	fields := make([]map[string]*structpb.Value, workers)
+	errs := make([]entryErrors, workers)
+	stops := make([]error, workers)
+	var wg sync.WaitGroup
+	for w, keys := range shards {
+		wg.Go(func() {
+			e := newEncoder(encodeOpts)
+			if err := e.enter(reflect.ValueOf(m)); err != nil {
+				stops[w] = e.conversionError(reflect.TypeOf(m), err)
+				return
+			}
+			fields[w] = make(map[string]*structpb.Value, len(keys))
+			for _, k := range keys {
+				if err := e.encodeEntry(fields[w], &errs[w], k, m[k]); err != nil {
+					stops[w] = err
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	var all entryErrors
+	for w := range workers {
+		if stops[w] != nil {
+			return nil, stops[w]
+		}
+		all = append(all, errs[w]...)
+	}
+	if len(all) > 0 {
+		return nil, all.join(true)
+	}
+	result := make(map[string]*structpb.Value, len(m))
+	for _, shard := range fields {
+		for name, v := range shard {
+			result[name] = v
+		}
+	}
+	return result, nil
+}
+
+// BatchSliceToStructValues converts values like Converter.SliceToStructValues,
+// splitting large slices into contiguous parts converted on separate goroutines. The
+// order of the values is preserved, and the result and the errors are the same as those
+// of a Converter with the BatchEncoding options
+func BatchSliceToStructValues(values []any, opts ...BatchOption) ([]*structpb.Value, error) {
+	o := newBatchOptions(opts)
+	encodeOpts := newOptions(o.encode)
+	workers := o.workers(len(values), encodeOpts)
+	if workers == 1 {
+		return NewConverter(o.encode...).SliceToStructValues(values)
+	}
+
+	parts := make([][]*structpb.Value, workers)
+	errs := make([]entryErrors, workers)
+	stops := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		lo, hi := w*len(values)/workers, (w+1)*len(values)/workers
+		wg.Go(func() {
+			e := newEncoder(encodeOpts)
+			if err := e.enter(reflect.ValueOf(values)); err != nil {
+				stops[w] = e.conversionError(reflect.TypeOf(values), err)
+				return
+			}
+			parts[w] = make([]*structpb.Value, 0, hi-lo)
+			for i := lo; i < hi; i++ {
+				var err error
+				if parts[w], err = e.appendItem(parts[w], &errs[w], i, values[i]); err != nil {
+					stops[w] = err
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	var all entryErrors
+	n := 0
+	for w := range workers {
+		if stops[w] != nil {
+			return nil, stops[w]
+		}
+		all = append(all, errs[w]...)
+		n += len(parts[w])
+	}
+	if len(all) > 0 {
+		return nil, all.join(false)
+	}
+	result := make([]*structpb.Value, 0, n)
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result, nil
+}
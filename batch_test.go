@@ -0,0 +1,128 @@
+package protobaggins
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBatchMapToStructValues(t *testing.T) {
+	t.Parallel()
+
+	large := make(map[string]any, 5000)
+	for i := range 5000 {
+		large[fmt.Sprintf("key%d", i)] = map[string]any{"i": i, "s": []any{"x", i}}
+	}
+
+	t.Run("same result as a converter", func(t *testing.T) {
+		t.Parallel()
+		want, err := NewConverter().MapToStructValues(large)
+		require.NoError(t, err)
+		got, err := BatchMapToStructValues(large, BatchParallelism(4))
+		require.NoError(t, err)
+		require.Len(t, got, len(want))
+		for k, v := range want {
+			assert.True(t, proto.Equal(v, got[k]), k)
+		}
+	})
+
+	t.Run("errors are sorted across goroutines", func(t *testing.T) {
+		t.Parallel()
+		m := make(map[string]any, 4000)
+		for i := range 4000 {
+			m[fmt.Sprintf("%04d", i)] = i
+		}
+		m["0500"] = make(chan int)
+		m["3000"] = func() {}
+		m["0001"] = complex(1, 2)
+		_, err := BatchMapToStructValues(m, BatchParallelism(4))
+		require.Error(t, err)
+		assert.Regexp(t, `^0001: .+\n0500: .+\n3000: .+$`, err.Error())
+
+		got, err := BatchMapToStructValues(m, BatchParallelism(4), BatchEncoding(WithSkipErrors()))
+		require.NoError(t, err)
+		assert.Len(t, got, 3997)
+	})
+
+	t.Run("key collisions are detected", func(t *testing.T) {
+		t.Parallel()
+		m := make(map[string]any, 4000)
+		for i := range 4000 {
+			m[fmt.Sprintf("key_%d", i)] = i
+		}
+		m["key-1234"] = "collides with key_1234 in kebab case"
+		_, err := BatchMapToStructValues(m, BatchParallelism(4), BatchEncoding(WithKeyCase(KeyCaseKebab)))
+		require.ErrorContains(t, err, "collides")
+	})
+
+	t.Run("limits apply to the whole result", func(t *testing.T) {
+		t.Parallel()
+		_, err := BatchMapToStructValues(large, BatchParallelism(4), BatchEncoding(WithMaxNodes(100)))
+		require.ErrorIs(t, err, ErrTooLarge)
+	})
+
+	t.Run("small and nil maps", func(t *testing.T) {
+		t.Parallel()
+		got, err := BatchMapToStructValues(map[string]any{"a": 1})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": 1.0}, StructValuesToMap(got))
+
+		got, err = BatchMapToStructValues(nil)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestBatchSliceToStructValues(t *testing.T) {
+	t.Parallel()
+
+	large := make([]any, 10001)
+	for i := range large {
+		large[i] = i
+	}
+
+	t.Run("preserves order", func(t *testing.T) {
+		t.Parallel()
+		got, err := BatchSliceToStructValues(large, BatchParallelism(3))
+		require.NoError(t, err)
+		require.Len(t, got, len(large))
+		for i, v := range got {
+			require.InDelta(t, float64(i), v.GetNumberValue(), 0)
+		}
+	})
+
+	t.Run("errors keep their index", func(t *testing.T) {
+		t.Parallel()
+		values := append([]any(nil), large...)
+		values[9000] = make(chan int)
+		values[10] = func() {}
+		_, err := BatchSliceToStructValues(values, BatchParallelism(3))
+		require.Error(t, err)
+		assert.Regexp(t, `^\[10\]: .+\n\[9000\]: .+$`, err.Error())
+
+		got, err := BatchSliceToStructValues(values, BatchParallelism(3), BatchEncoding(WithSkipErrors()))
+		require.NoError(t, err)
+		assert.Len(t, got, len(values)-2)
+		assert.InDelta(t, 11.0, got[10].GetNumberValue(), 0)
+	})
+
+	t.Run("encoding options", func(t *testing.T) {
+		t.Parallel()
+		values := append([]any(nil), large...)
+		values[5000] = math.NaN()
+		got, err := BatchSliceToStructValues(values, BatchEncoding(WithNonFinite(NonFiniteNull)))
+		require.NoError(t, err)
+		assert.Equal(t, KindNull, KindOf(got[5000]))
+	})
+
+	t.Run("nil slice", func(t *testing.T) {
+		t.Parallel()
+		got, err := BatchSliceToStructValues(nil)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
@@ -0,0 +1,86 @@
+package protobaggins
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// WithBigNumbersAsNumbers encodes *big.Int and *big.Float values that a float64 holds
+// exactly as numbers. By default they are always encoded as decimal strings, so that
+// all values of a field have the same kind
+func WithBigNumbersAsNumbers() Option {
+	return func(o *options) {
+		o.bigNumbers = true
+	}
+}
+
+func (e *encoder) encodeBigInt(i *big.Int) *structpb.Value {
+	if i == nil {
+		return structpb.NewNullValue()
+	}
+	if e.opts.bigNumbers && i.CmpAbs(big.NewInt(maxSafeInteger)) <= 0 {
+		return structpb.NewNumberValue(float64(i.Int64()))
+	}
+	return structpb.NewStringValue(i.String())
+}
+
+func (e *encoder) encodeBigFloat(f *big.Float) *structpb.Value {
+	if f == nil {
+		return structpb.NewNullValue()
+	}
+	if e.opts.bigNumbers && !f.IsInf() {
+		if f64, accuracy := f.Float64(); accuracy == big.Exact {
+			return structpb.NewNumberValue(f64)
+		}
+	}
+	return structpb.NewStringValue(f.Text('g', -1))
+}
+
+// BigIntFromValue decodes a whole number or a base-10 integer string, as encoded for
+// *big.Int values, to a *big.Int. Fails with ErrNotCoercible for fractional or
+// non-finite numbers and malformed strings, and with ErrUnexpectedKind for other kinds
+func BigIntFromValue(v *structpb.Value) (*big.Int, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		f := kind.NumberValue
+		if math.IsNaN(f) || math.IsInf(f, 0) || f != math.Trunc(f) {
+			return nil, fmt.Errorf("%w: %v is not an integer", ErrNotCoercible, f)
+		}
+		i, _ := big.NewFloat(f).Int(nil)
+		return i, nil
+	case *structpb.Value_StringValue:
+		i, ok := new(big.Int).SetString(kind.StringValue, 10)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not an integer", ErrNotCoercible, kind.StringValue)
+		}
+		return i, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot convert %s to an integer", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+// BigFloatFromValue decodes a number or a decimal string, as encoded for *big.Float
+// values, to a *big.Float of the given precision in bits. A precision of 0 means 53
+// bits for numbers, which is exact, and 64 bits for strings, as for
+// big.Float.SetString. Fails with ErrNotCoercible for NaN and malformed strings, and
+// with ErrUnexpectedKind for other kinds
+func BigFloatFromValue(v *structpb.Value, prec uint) (*big.Float, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		if math.IsNaN(kind.NumberValue) {
+			return nil, fmt.Errorf("%w: NaN is not a big.Float", ErrNotCoercible)
+		}
+		return new(big.Float).SetPrec(prec).SetFloat64(kind.NumberValue), nil
+	case *structpb.Value_StringValue:
+		f, _, err := big.ParseFloat(kind.StringValue, 10, prec, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a number", ErrNotCoercible, kind.StringValue)
+		}
+		return f, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot convert %s to a number", ErrUnexpectedKind, KindOf(v))
+	}
+}
@@ -0,0 +1,101 @@
+package protobaggins
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestBigNumbers(t *testing.T) {
+	t.Parallel()
+
+	huge, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+	require.True(t, ok)
+	in := map[string]any{
+		"amount": big.NewInt(1999),
+		"huge":   huge,
+		"rate":   big.NewFloat(0.25),
+		"pi":     new(big.Float).SetPrec(200).Quo(big.NewFloat(22), big.NewFloat(7)),
+		"inf":    new(big.Float).SetInf(true),
+		"none":   (*big.Int)(nil),
+	}
+
+	t.Run("strings by default", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewConverter().NewStruct(in)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"amount": "1999",
+			"huge":   "123456789012345678901234567890",
+			"rate":   "0.25",
+			"pi":     "3.142857142857142857142857142857142857142857142857142857142857",
+			"inf":    "-Inf",
+			"none":   nil,
+		}, s.AsMap())
+	})
+
+	t.Run("numbers when exact", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewConverter(WithBigNumbersAsNumbers()).NewStruct(in)
+		require.NoError(t, err)
+		fields := s.GetFields()
+		assert.InDelta(t, 1999.0, fields["amount"].GetNumberValue(), 0)
+		assert.InDelta(t, 0.25, fields["rate"].GetNumberValue(), 0)
+		assert.Equal(t, "123456789012345678901234567890", fields["huge"].GetStringValue())
+		assert.NotEmpty(t, fields["pi"].GetStringValue())
+		assert.Equal(t, "-Inf", fields["inf"].GetStringValue())
+	})
+
+	t.Run("big int round trip", func(t *testing.T) {
+		t.Parallel()
+		for _, opts := range [][]Option{nil, {WithBigNumbersAsNumbers()}} {
+			for _, i := range []*big.Int{big.NewInt(0), big.NewInt(-42), huge, new(big.Int).Neg(huge)} {
+				v, err := NewValue(i, opts...)
+				require.NoError(t, err)
+				got, err := BigIntFromValue(v)
+				require.NoError(t, err)
+				assert.Zero(t, i.Cmp(got), "%v != %v", i, got)
+			}
+		}
+
+		got, err := BigIntFromValue(structpb.NewNumberValue(1e30))
+		require.NoError(t, err)
+		assert.Equal(t, "1000000000000000019884624838656", got.String())
+
+		for _, v := range []*structpb.Value{
+			structpb.NewNumberValue(1.5), structpb.NewNumberValue(math.Inf(1)), structpb.NewStringValue("1.0"),
+		} {
+			_, err := BigIntFromValue(v)
+			require.ErrorIs(t, err, ErrNotCoercible, v)
+		}
+		_, err = BigIntFromValue(structpb.NewBoolValue(true))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("big float round trip", func(t *testing.T) {
+		t.Parallel()
+		pi := in["pi"].(*big.Float)
+		v, err := NewValue(pi)
+		require.NoError(t, err)
+		got, err := BigFloatFromValue(v, 200)
+		require.NoError(t, err)
+		assert.Equal(t, pi.Text('g', -1), got.Text('g', -1))
+
+		got, err = BigFloatFromValue(structpb.NewNumberValue(0.1), 0)
+		require.NoError(t, err)
+		f, accuracy := got.Float64()
+		assert.Equal(t, big.Exact, accuracy)
+		assert.InDelta(t, 0.1, f, 0)
+
+		_, err = BigFloatFromValue(structpb.NewNumberValue(math.NaN()), 0)
+		require.ErrorIs(t, err, ErrNotCoercible)
+		_, err = BigFloatFromValue(structpb.NewStringValue("x"), 0)
+		require.ErrorIs(t, err, ErrNotCoercible)
+		_, err = BigFloatFromValue(nil, 0)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+}
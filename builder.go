@@ -0,0 +1,107 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// StructBuilder builds a *structpb.Struct with chained calls, e.g.
+//
+//	s, err := NewStructBuilder().
+//		Set("name", "frodo").
+//		SetPath("address.city", "hobbiton").
+//		AddToList("aliases", "mr. underhill").
+//		Build()
+//
+// Failing calls are recorded and leave the Struct unchanged, so that Build reports all
+// of them at once
+type StructBuilder struct {
+	s    *structpb.Struct
+	opts []Option
+	errs []error
+}
+
+// NewStructBuilder returns an empty StructBuilder that converts values with NewValue
+// and opts, except for a *structpb.Value, which is stored as is
+func NewStructBuilder(opts ...Option) *StructBuilder {
+	return &StructBuilder{
+		s:    &structpb.Struct{Fields: map[string]*structpb.Value{}},
+		opts: opts,
+	}
+}
+
+// Set sets the top-level key to value
+func (b *StructBuilder) Set(key string, value any) *StructBuilder {
+	v, err := b.value(value)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("%s: %w", JoinPathKey("", key), err))
+		return b
+	}
+	b.s.Fields[key] = v
+	return b
+}
+
+// SetPath sets the value at path, see the package-level SetPath
+func (b *StructBuilder) SetPath(path string, value any) *StructBuilder {
+	if err := SetPath(b.s, path, value, SetPathEncoding(b.opts...)); err != nil {
+		b.errs = append(b.errs, err)
+	}
+	return b
+}
+
+// AddToList appends values to the list at path, creating it if it does not exist
+func (b *StructBuilder) AddToList(path string, values ...any) *StructBuilder {
+	items := make([]*structpb.Value, 0, len(values))
+	failed := false
+	for _, value := range values {
+		v, err := b.value(value)
+		if err != nil {
+			// the index the value would have had if the list were new
+			b.errs = append(b.errs, fmt.Errorf("%s: %w", JoinPathIndex(path, len(items)), err))
+			failed = true
+			continue
+		}
+		items = append(items, v)
+	}
+	if failed {
+		return b
+	}
+
+	list, err := LookupPath(structpb.NewStructValue(b.s), path)
+	if errors.Is(err, ErrPathNotFound) {
+		list = structpb.NewListValue(&structpb.ListValue{})
+		err = SetPath(b.s, path, list)
+	}
+	if err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	l, ok := list.GetKind().(*structpb.Value_ListValue)
+	if !ok {
+		b.errs = append(b.errs, fmt.Errorf("%s: %w: cannot append to %s", describePath(path), ErrUnexpectedKind, KindOf(list)))
+		return b
+	}
+	if l.ListValue == nil {
+		l.ListValue = &structpb.ListValue{}
+	}
+	l.ListValue.Values = append(l.ListValue.Values, items...)
+	return b
+}
+
+// Build returns the Struct, or the errors of all failed calls joined together
+// The builder must not be used afterwards, as it shares the Struct with the caller
+func (b *StructBuilder) Build() (*structpb.Struct, error) {
+	if err := errors.Join(b.errs...); err != nil {
+		return nil, err
+	}
+	return b.s, nil
+}
+
+func (b *StructBuilder) value(value any) (*structpb.Value, error) {
+	if v, ok := value.(*structpb.Value); ok {
+		return v, nil
+	}
+	return NewValue(value, b.opts...)
+}
@@ -0,0 +1,68 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestStructBuilder(t *testing.T) {
+	t.Parallel()
+
+	t.Run("builds", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewStructBuilder().
+			Set("name", "frodo").
+			Set("age", structpb.NewNumberValue(50)).
+			SetPath("address.city", "hobbiton").
+			SetPath("address.lines[1]", "bag end").
+			AddToList("aliases", "mr. underhill").
+			AddToList("aliases", "ring-bearer", "baggins").
+			AddToList("quests[0].steps", 1).
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":    "frodo",
+			"age":     50.0,
+			"address": map[string]any{"city": "hobbiton", "lines": []any{nil, "bag end"}},
+			"aliases": []any{"mr. underhill", "ring-bearer", "baggins"},
+			"quests":  []any{map[string]any{"steps": []any{1.0}}},
+		}, s.AsMap())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewStructBuilder().Build()
+		require.NoError(t, err)
+		assert.Empty(t, s.AsMap())
+	})
+
+	t.Run("collects all errors", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewStructBuilder().
+			Set("ok", 1).
+			Set("bad", make(chan int)).
+			SetPath("ok.x", 1).
+			AddToList("ok", 2).
+			AddToList("list", "a", func() {}).
+			Build()
+		require.Error(t, err)
+		assert.Nil(t, s)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		assert.Contains(t, err.Error(), "bad: ")
+		assert.Contains(t, err.Error(), "cannot set key \"x\" in number")
+		assert.Contains(t, err.Error(), "ok: "+ErrUnexpectedKind.Error()+": cannot append to number")
+		assert.Contains(t, err.Error(), "list[1]: ")
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewStructBuilder(WithOmitNulls()).
+			Set("user", map[string]any{"name": "sam", "email": nil}).
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"user": map[string]any{"name": "sam"}}, s.AsMap())
+	})
+}
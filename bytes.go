@@ -0,0 +1,118 @@
+package protobaggins
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// TaggedBytesKey is the single field of the Struct used to mark a base64 encoded
+// []byte value, e.g. {"@bytes": "aGVsbG8="}
+const TaggedBytesKey = "@bytes"
+
+// WithTaggedBytes encodes []byte values as {"@bytes": "<base64>"} Structs instead of
+// plain base64 strings, and decodes such Structs back to []byte, so binary values stay
+// distinguishable from text across a Struct round trip
+func WithTaggedBytes() Option {
+	return func(o *options) {
+		o.taggedBytes = true
+	}
+}
+
+// BytesEncoding selects how []byte values are written as strings
+type BytesEncoding int
+
+const (
+	// BytesBase64 is standard padded base64, as used by structpb.NewValue and protojson
+	BytesBase64 BytesEncoding = iota
+	// BytesBase64URL is unpadded URL-safe base64
+	BytesBase64URL
+	// BytesHex is lower-case hexadecimal
+	BytesHex
+)
+
+// WithBytesEncoding encodes []byte values as strings in enc instead of standard base64.
+// The tagged form of WithTaggedBytes always holds standard base64
+func WithBytesEncoding(enc BytesEncoding) Option {
+	return func(o *options) {
+		o.bytesEncoding = enc
+	}
+}
+
+// EncodeToString encodes b as a string
+func (enc BytesEncoding) EncodeToString(b []byte) string {
+	switch enc {
+	case BytesBase64URL:
+		return base64.RawURLEncoding.EncodeToString(b)
+	case BytesHex:
+		return hex.EncodeToString(b)
+	default:
+		return base64.StdEncoding.EncodeToString(b)
+	}
+}
+
+// DecodeString decodes a string written by EncodeToString
+func (enc BytesEncoding) DecodeString(s string) ([]byte, error) {
+	switch enc {
+	case BytesBase64URL:
+		return base64.RawURLEncoding.DecodeString(s)
+	case BytesHex:
+		return hex.DecodeString(s)
+	default:
+		return base64.StdEncoding.DecodeString(s)
+	}
+}
+
+// BytesToValue converts a []byte to a tagged {"@bytes": "<base64>"} *structpb.Value
+// Returns a null value if b is nil
+func BytesToValue(b []byte) *structpb.Value {
+	if b == nil {
+		return structpb.NewNullValue()
+	}
+	return structpb.NewStructValue(&structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			TaggedBytesKey: structpb.NewStringValue(base64.StdEncoding.EncodeToString(b)),
+		},
+	})
+}
+
+// BytesFromValue decodes a []byte from either the tagged form produced by BytesToValue
+// or a standard base64 string, which is how structpb.NewValue encodes []byte
+// Returns nil without error for nil or null values
+func BytesFromValue(v *structpb.Value) ([]byte, error) {
+	return BytesFromValueEncoding(v, BytesBase64)
+}
+
+// BytesFromValueEncoding is BytesFromValue for strings written in enc, see
+// WithBytesEncoding. Tagged values are always decoded as standard base64
+func BytesFromValueEncoding(v *structpb.Value, enc BytesEncoding) ([]byte, error) {
+	switch kind := v.GetKind().(type) {
+	case nil, *structpb.Value_NullValue:
+		return nil, nil
+	case *structpb.Value_StringValue:
+		return enc.DecodeString(kind.StringValue)
+	case *structpb.Value_StructValue:
+		encoded, ok := taggedBytes(kind.StructValue)
+		if !ok {
+			return nil, fmt.Errorf("%w: struct is not a tagged %q value", ErrUnexpectedKind, TaggedBytesKey)
+		}
+		return base64.StdEncoding.DecodeString(encoded)
+	default:
+		return nil, fmt.Errorf("%w: cannot decode %s as bytes", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+// taggedBytes returns the base64 payload of a tagged bytes Struct
+func taggedBytes(s *structpb.Struct) (string, bool) {
+	fields := s.GetFields()
+	if len(fields) != 1 {
+		return "", false
+	}
+	str, ok := fields[TaggedBytesKey].GetKind().(*structpb.Value_StringValue)
+	if !ok {
+		return "", false
+	}
+	return str.StringValue, true
+}
@@ -0,0 +1,156 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestBytesToValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil bytes", func(t *testing.T) {
+		t.Parallel()
+		_, ok := BytesToValue(nil).Kind.(*structpb.Value_NullValue)
+		assert.True(t, ok)
+	})
+
+	t.Run("tagged form", func(t *testing.T) {
+		t.Parallel()
+		result := BytesToValue([]byte("hello"))
+		fields := result.GetStructValue().GetFields()
+		assert.Len(t, fields, 1)
+		assert.Equal(t, "aGVsbG8=", fields[TaggedBytesKey].GetStringValue())
+	})
+}
+
+func TestBytesFromValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil and null values", func(t *testing.T) {
+		t.Parallel()
+		result, err := BytesFromValue(nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+
+		result, err = BytesFromValue(structpb.NewNullValue())
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("tagged form round trip", func(t *testing.T) {
+		t.Parallel()
+		input := []byte{0x00, 0xff, 0x10}
+		result, err := BytesFromValue(BytesToValue(input))
+		require.NoError(t, err)
+		assert.Equal(t, input, result)
+	})
+
+	t.Run("plain base64 string", func(t *testing.T) {
+		t.Parallel()
+		pbValue, err := structpb.NewValue([]byte("hello"))
+		require.NoError(t, err)
+
+		result, err := BytesFromValue(pbValue)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hello"), result)
+	})
+
+	t.Run("invalid base64", func(t *testing.T) {
+		t.Parallel()
+		_, err := BytesFromValue(structpb.NewStringValue("not base64!"))
+		require.Error(t, err)
+	})
+
+	t.Run("untagged struct", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"data": "aGVsbG8="})
+		require.NoError(t, err)
+
+		_, err = BytesFromValue(structpb.NewStructValue(s))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("unexpected kind", func(t *testing.T) {
+		t.Parallel()
+		_, err := BytesFromValue(structpb.NewBoolValue(true))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+}
+
+func TestTaggedBytesRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"payload": []byte("binary"),
+		"text":    "aGVsbG8=",
+	}
+
+	t.Run("without option bytes become strings", func(t *testing.T) {
+		t.Parallel()
+		pbValue, err := NewValue(input)
+		require.NoError(t, err)
+
+		result, ok := ValueToInterface(pbValue).(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, "YmluYXJ5", result["payload"])
+	})
+
+	t.Run("with option bytes survive", func(t *testing.T) {
+		t.Parallel()
+		pbValue, err := NewValue(input, WithTaggedBytes())
+		require.NoError(t, err)
+
+		result, ok := ValueToInterface(pbValue, WithTaggedBytes()).(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, []byte("binary"), result["payload"])
+		assert.Equal(t, "aGVsbG8=", result["text"])
+	})
+}
+
+func TestBytesEncoding(t *testing.T) {
+	t.Parallel()
+
+	data := []byte{0xfb, 0xff, 0x00, 'h', 'i'}
+	tests := []struct {
+		enc  BytesEncoding
+		want string
+	}{
+		{BytesBase64, "+/8AaGk="},
+		{BytesBase64URL, "-_8AaGk"},
+		{BytesHex, "fbff006869"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+			c := NewConverter(WithBytesEncoding(tt.enc))
+			values, err := c.MapToStructValues(map[string]any{"blob": data, "list": []any{data}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, values["blob"].GetStringValue())
+			assert.Equal(t, tt.want, values["list"].GetListValue().GetValues()[0].GetStringValue())
+
+			got, err := BytesFromValueEncoding(values["blob"], tt.enc)
+			require.NoError(t, err)
+			assert.Equal(t, data, got)
+		})
+	}
+
+	t.Run("tagged bytes stay standard base64", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(data, WithTaggedBytes(), WithBytesEncoding(BytesHex))
+		require.NoError(t, err)
+		got, err := BytesFromValueEncoding(v, BytesHex)
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		t.Parallel()
+		_, err := BytesFromValueEncoding(structpb.NewStringValue("zz"), BytesHex)
+		require.Error(t, err)
+		_, err = BytesFromValueEncoding(structpb.NewStringValue("+/8="), BytesBase64URL)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,120 @@
+package protobaggins
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"math"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Canonicalize returns a deep copy of v in canonical form, so that values that only
+// differ in representation become equal under proto.Equal:
+//
+//   - nil values, values without a kind and null values become NULL_VALUE
+//   - nil structs and lists become empty ones
+//   - -0 becomes 0 and every NaN becomes the NaN returned by math.NaN
+//
+// Struct keys have no order in a *structpb.Struct, see Hash for an order-independent
+// digest. A nil v canonicalizes to null
+func Canonicalize(v *structpb.Value) *structpb.Value {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return structpb.NewBoolValue(kind.BoolValue)
+	case *structpb.Value_NumberValue:
+		return structpb.NewNumberValue(canonicalNumber(kind.NumberValue))
+	case *structpb.Value_StringValue:
+		return structpb.NewStringValue(kind.StringValue)
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		list := &structpb.ListValue{Values: make([]*structpb.Value, len(items))}
+		for i, item := range items {
+			list.Values[i] = Canonicalize(item)
+		}
+		return structpb.NewListValue(list)
+	case *structpb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(fields))}
+		for key, field := range fields {
+			s.Fields[key] = Canonicalize(field)
+		}
+		return structpb.NewStructValue(s)
+	default:
+		return structpb.NewNullValue()
+	}
+}
+
+func canonicalNumber(f float64) float64 {
+	switch {
+	case math.IsNaN(f):
+		return math.NaN()
+	case f == 0:
+		return 0
+	default:
+		return f
+	}
+}
+
+// Hash returns a SHA-256 digest of v that is stable across processes and does not
+// depend on map iteration order. Values hash the same exactly when their canonical
+// forms are equal, see Canonicalize, except that all NaNs hash the same although NaN
+// is not equal to itself
+func Hash(v *structpb.Value) []byte {
+	h := sha256.New()
+	writeCanonical(h, v)
+	return h.Sum(nil)
+}
+
+// HashStruct is Hash for a Struct, equal to the Hash of a Value holding s
+func HashStruct(s *structpb.Struct) []byte {
+	return Hash(structpb.NewStructValue(s))
+}
+
+// writeCanonical writes an unambiguous encoding of v: a kind tag followed by the
+// payload, with lengths before strings, lists and structs and struct keys sorted
+func writeCanonical(h hash.Hash, v *structpb.Value) {
+	var buf [9]byte
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		buf[0] = byte(KindBool)
+		if kind.BoolValue {
+			buf[1] = 1
+		}
+		h.Write(buf[:2])
+	case *structpb.Value_NumberValue:
+		buf[0] = byte(KindNumber)
+		binary.BigEndian.PutUint64(buf[1:], math.Float64bits(canonicalNumber(kind.NumberValue)))
+		h.Write(buf[:])
+	case *structpb.Value_StringValue:
+		writeCanonicalString(h, kind.StringValue)
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		buf[0] = byte(KindList)
+		binary.BigEndian.PutUint64(buf[1:], uint64(len(items)))
+		h.Write(buf[:])
+		for _, item := range items {
+			writeCanonical(h, item)
+		}
+	case *structpb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		buf[0] = byte(KindStruct)
+		binary.BigEndian.PutUint64(buf[1:], uint64(len(fields)))
+		h.Write(buf[:])
+		for _, key := range sortedKeys(kind.StructValue) {
+			writeCanonicalString(h, key)
+			writeCanonical(h, fields[key])
+		}
+	default:
+		buf[0] = byte(KindNull)
+		h.Write(buf[:1])
+	}
+}
+
+func writeCanonicalString(h hash.Hash, s string) {
+	var buf [9]byte
+	buf[0] = byte(KindString)
+	binary.BigEndian.PutUint64(buf[1:], uint64(len(s)))
+	h.Write(buf[:])
+	h.Write([]byte(s))
+}
@@ -0,0 +1,101 @@
+package protobaggins
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCanonicalize(t *testing.T) {
+	t.Parallel()
+
+	v := structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+		nil,
+		{},
+		structpb.NewNullValue(),
+		structpb.NewNumberValue(math.Copysign(0, -1)),
+		structpb.NewNumberValue(math.Float64frombits(0x7ff8000000000001)),
+		{Kind: &structpb.Value_ListValue{}},
+		{Kind: &structpb.Value_StructValue{StructValue: &structpb.Struct{Fields: map[string]*structpb.Value{"a": nil}}}},
+	}})
+
+	got := Canonicalize(v)
+	items := got.GetListValue().GetValues()
+	require.Len(t, items, 7)
+	for _, item := range items[:3] {
+		assert.True(t, proto.Equal(structpb.NewNullValue(), item))
+	}
+	assert.False(t, math.Signbit(items[3].GetNumberValue()))
+	assert.Equal(t, math.Float64bits(math.NaN()), math.Float64bits(items[4].GetNumberValue()))
+	assert.NotNil(t, items[5].GetListValue())
+	assert.True(t, proto.Equal(structpb.NewNullValue(), items[6].GetStructValue().GetFields()["a"]))
+
+	assert.NotSame(t, v.GetListValue(), got.GetListValue())
+	assert.True(t, proto.Equal(structpb.NewNullValue(), Canonicalize(nil)))
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	mustValue := func(t *testing.T, v any) *structpb.Value {
+		t.Helper()
+		value, err := structpb.NewValue(v)
+		require.NoError(t, err)
+		return value
+	}
+
+	t.Run("stable", func(t *testing.T) {
+		t.Parallel()
+		v := mustValue(t, map[string]any{"b": []any{1, "x", nil, true}, "a": map[string]any{"c": 2.5}})
+		want := Hash(v)
+		assert.Len(t, want, 32)
+		for range 20 {
+			assert.Equal(t, want, Hash(proto.Clone(v).(*structpb.Value)))
+		}
+		// the encoding must not change, or stored digests stop matching
+		assert.Equal(t, "26b55c0f98fb5ee91c741a08504b3b2b12f4c7c1e78c7917ad7b1083c23d0d45", hex.EncodeToString(want))
+		assert.Equal(t, want, HashStruct(v.GetStructValue()))
+	})
+
+	t.Run("normalization", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, Hash(structpb.NewNumberValue(0)), Hash(structpb.NewNumberValue(math.Copysign(0, -1))))
+		assert.Equal(t, Hash(structpb.NewNullValue()), Hash(nil))
+		assert.Equal(t, Hash(structpb.NewNullValue()), Hash(&structpb.Value{}))
+		assert.Equal(t, Hash(structpb.NewNumberValue(math.NaN())), Hash(structpb.NewNumberValue(math.Float64frombits(0x7ff8000000000001))))
+	})
+
+	t.Run("distinct", func(t *testing.T) {
+		t.Parallel()
+		values := []*structpb.Value{
+			mustValue(t, nil),
+			mustValue(t, false),
+			mustValue(t, true),
+			mustValue(t, 0),
+			mustValue(t, 1),
+			mustValue(t, ""),
+			mustValue(t, "a"),
+			mustValue(t, []any{}),
+			mustValue(t, []any{"a"}),
+			mustValue(t, []any{"a", "b"}),
+			mustValue(t, []any{"ab"}),
+			mustValue(t, map[string]any{}),
+			mustValue(t, map[string]any{"a": "b"}),
+			mustValue(t, map[string]any{"ab": ""}),
+			mustValue(t, map[string]any{"a": []any{"b"}}),
+		}
+		seen := make(map[string]int)
+		for i, v := range values {
+			key := string(Hash(v))
+			if j, ok := seen[key]; ok {
+				t.Errorf("values %d and %d hash the same", j, i)
+			}
+			seen[key] = i
+		}
+	})
+}
@@ -0,0 +1,538 @@
+// Package cbor converts between structpb values and CBOR (RFC 8949) without third
+// party dependencies.
+//
+// Byte strings decode to the tagged {"@bytes": "<base64>"} form of
+// protobaggins.BytesToValue, which encodes back to a byte string, so binary data
+// survives a round trip. Whole numbers within ±2^53 encode as CBOR integers of the
+// smallest width, and integers decode exactly or not at all.
+package cbor
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/internal/conv"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrMalformed is returned for input that is not well-formed CBOR
+var ErrMalformed = errors.New("malformed CBOR")
+
+// MaxDepth is the nesting limit of Unmarshal, the same as protojson's
+const MaxDepth = protobaggins.DefaultJSONMaxDepth
+
+const (
+	majorUnsigned = 0
+	majorNegative = 1
+	majorBytes    = 2
+	majorText     = 3
+	majorArray    = 4
+	majorMap      = 5
+	majorTag      = 6
+	majorSimple   = 7
+)
+
+const (
+	tagDateTime    = 0
+	tagEpoch       = 1
+	tagPositiveBig = 2
+	tagNegativeBig = 3
+)
+
+// Option configures Unmarshal and UnmarshalStruct
+type Option func(*options)
+
+type options struct {
+	bigIntegersAsStrings bool
+}
+
+// BigIntegersAsStrings decodes integers beyond ±2^53, which a float64 cannot hold
+// exactly, as decimal strings. Without it they fail with protobaggins.ErrNotCoercible
+func BigIntegersAsStrings() Option {
+	return func(o *options) {
+		o.bigIntegersAsStrings = true
+	}
+}
+
+// Marshal encodes v as CBOR. Map keys are sorted as RFC 8949 deterministic encoding
+// requires, so equal values always give the same bytes. A nil Value encodes as null
+func Marshal(v *structpb.Value) []byte {
+	return appendValue(nil, v)
+}
+
+// MarshalStruct encodes s as a CBOR map. A nil Struct encodes as an empty map
+func MarshalStruct(s *structpb.Struct) []byte {
+	return appendStruct(nil, s)
+}
+
+func appendHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), n)
+	}
+}
+
+func appendValue(b []byte, v *structpb.Value) []byte {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return appendStruct(b, kind.StructValue)
+	case *structpb.Value_ListValue:
+		values := kind.ListValue.GetValues()
+		b = appendHead(b, majorArray, uint64(len(values)))
+		for _, item := range values {
+			b = appendValue(b, item)
+		}
+		return b
+	case *structpb.Value_StringValue:
+		b = appendHead(b, majorText, uint64(len(kind.StringValue)))
+		return append(b, kind.StringValue...)
+	case *structpb.Value_NumberValue:
+		return appendNumber(b, kind.NumberValue)
+	case *structpb.Value_BoolValue:
+		if kind.BoolValue {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+	default:
+		return append(b, 0xf6)
+	}
+}
+
+func appendStruct(b []byte, s *structpb.Struct) []byte {
+	fields := s.GetFields()
+	if len(fields) == 1 {
+		if raw, err := protobaggins.BytesFromValue(structpb.NewStructValue(s)); err == nil {
+			b = appendHead(b, majorBytes, uint64(len(raw)))
+			return append(b, raw...)
+		}
+	}
+
+	// deterministic encoding orders keys by their encoded bytes, which for text keys
+	// means shorter keys first, then bytewise
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(a), len(b)), strings.Compare(a, b))
+	})
+
+	b = appendHead(b, majorMap, uint64(len(keys)))
+	for _, k := range keys {
+		b = appendHead(b, majorText, uint64(len(k)))
+		b = append(b, k...)
+		b = appendValue(b, fields[k])
+	}
+	return b
+}
+
+// appendNumber writes whole numbers as integers and others as the shortest float that
+// holds them exactly
+func appendNumber(b []byte, f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, 0xf9, 0x7e, 0x00)
+	case math.IsInf(f, 1):
+		return append(b, 0xf9, 0x7c, 0x00)
+	case math.IsInf(f, -1):
+		return append(b, 0xf9, 0xfc, 0x00)
+	case f == math.Trunc(f) && math.Abs(f) <= conv.MaxSafeInteger && !(f == 0 && math.Signbit(f)):
+		if f >= 0 {
+			return appendHead(b, majorUnsigned, uint64(f))
+		}
+		return appendHead(b, majorNegative, uint64(-f)-1)
+	case float64(float32(f)) == f:
+		return binary.BigEndian.AppendUint32(append(b, 0xfa), math.Float32bits(float32(f)))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(f))
+	}
+}
+
+// Unmarshal decodes a single CBOR data item into a *structpb.Value. Beyond the JSON
+// data model:
+//   - byte strings decode to tagged bytes, see protobaggins.BytesToValue
+//   - integer map keys are stringified, other non-text keys are rejected
+//   - undefined decodes as null
+//   - date/time tags 0 and 1 decode as RFC 3339 strings and bignum tags 2 and 3 as
+//     numbers, other tags are ignored and their content decoded
+//
+// Duplicate map keys, invalid UTF-8 text and trailing data are rejected
+func Unmarshal(data []byte, opts ...Option) (*structpb.Value, error) {
+	d := decoder{data: data}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, d.errorf("unexpected data after the top-level item")
+	}
+	return v, nil
+}
+
+// UnmarshalStruct decodes a CBOR map into a *structpb.Struct, see Unmarshal. Other
+// top-level items fail with protobaggins.ErrUnexpectedKind
+func UnmarshalStruct(data []byte, opts ...Option) (*structpb.Struct, error) {
+	v, err := Unmarshal(data, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.GetKind().(*structpb.Value_StructValue)
+	if !ok {
+		return nil, fmt.Errorf("%w: top level must be a map, got %s", protobaggins.ErrUnexpectedKind, protobaggins.KindOf(v))
+	}
+	return s.StructValue, nil
+}
+
+type decoder struct {
+	data  []byte
+	pos   int
+	depth int
+	opts  options
+}
+
+func (d *decoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrMalformed, d.pos, fmt.Sprintf(format, args...))
+}
+
+// indefinite marks the argument of an indefinite-length string, array or map
+const indefinite = math.MaxUint64
+
+// head reads the initial byte and argument of a data item
+func (d *decoder) head() (major byte, info byte, arg uint64, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, 0, d.errorf("unexpected end of input")
+	}
+	major, info = d.data[d.pos]>>5, d.data[d.pos]&0x1f
+	d.pos++
+
+	var size int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		size = 1 << (info - 24)
+	case info == 31 && major >= majorBytes && major <= majorMap:
+		return major, info, indefinite, nil
+	case info == 31 && major == majorSimple:
+		return 0, 0, 0, d.errorf("unexpected break")
+	default:
+		return 0, 0, 0, d.errorf("reserved additional information %d", info)
+	}
+	if len(d.data)-d.pos < size {
+		return 0, 0, 0, d.errorf("unexpected end of input")
+	}
+	for _, c := range d.data[d.pos : d.pos+size] {
+		arg = arg<<8 | uint64(c)
+	}
+	d.pos += size
+	if arg == indefinite && major >= majorBytes && major <= majorMap {
+		return 0, 0, 0, d.errorf("length %d exceeds the remaining input", arg)
+	}
+	return major, info, arg, nil
+}
+
+// atBreak consumes the break code ending an indefinite-length item
+func (d *decoder) atBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func (d *decoder) value() (*structpb.Value, error) {
+	start := d.pos
+	major, info, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case majorUnsigned:
+		return d.integer(new(big.Int).SetUint64(arg))
+	case majorNegative:
+		n := new(big.Int).SetUint64(arg)
+		return d.integer(n.Neg(n.Add(n, big.NewInt(1))))
+	case majorBytes:
+		raw, err := d.str(majorBytes, arg)
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			raw = []byte{}
+		}
+		return protobaggins.BytesToValue(raw), nil
+	case majorText:
+		raw, err := d.str(majorText, arg)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(raw) {
+			d.pos = start
+			return nil, d.errorf("invalid UTF-8 in text string")
+		}
+		return structpb.NewStringValue(string(raw)), nil
+	case majorArray:
+		l, err := d.array(arg)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewListValue(l), nil
+	case majorMap:
+		s, err := d.object(arg)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStructValue(s), nil
+	case majorTag:
+		return d.tagged(arg)
+	default:
+		return d.simple(info, arg)
+	}
+}
+
+func (d *decoder) integer(n *big.Int) (*structpb.Value, error) {
+	if n.CmpAbs(big.NewInt(conv.MaxSafeInteger)) <= 0 {
+		return structpb.NewNumberValue(float64(n.Int64())), nil
+	}
+	if d.opts.bigIntegersAsStrings {
+		return structpb.NewStringValue(n.String()), nil
+	}
+	return nil, fmt.Errorf("%w: integer %s at offset %d is beyond ±2^53, the safe integer range of numbers",
+		protobaggins.ErrNotCoercible, n, d.pos)
+}
+
+// str reads the content of a byte or text string, joining the chunks of an
+// indefinite-length one
+func (d *decoder) str(major byte, n uint64) ([]byte, error) {
+	if n != indefinite {
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, d.errorf("string length %d exceeds the remaining input", n)
+		}
+		raw := d.data[d.pos : d.pos+int(n)]
+		d.pos += int(n)
+		return raw, nil
+	}
+
+	var joined []byte
+	for !d.atBreak() {
+		chunkMajor, _, chunkLen, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkLen == indefinite {
+			return nil, d.errorf("invalid chunk in indefinite-length string")
+		}
+		chunk, err := d.str(major, chunkLen)
+		if err != nil {
+			return nil, err
+		}
+		joined = append(joined, chunk...)
+	}
+	return joined, nil
+}
+
+func (d *decoder) enter() error {
+	d.depth++
+	if d.depth > MaxDepth {
+		return fmt.Errorf("%w of %d at offset %d", protobaggins.ErrMaxDepth, MaxDepth, d.pos)
+	}
+	return nil
+}
+
+func (d *decoder) array(n uint64) (*structpb.ListValue, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+
+	// every item takes at least one byte, which bounds the allocation
+	if n != indefinite && n > uint64(len(d.data)-d.pos) {
+		return nil, d.errorf("array length %d exceeds the remaining input", n)
+	}
+	var values []*structpb.Value
+	if n != indefinite {
+		values = make([]*structpb.Value, 0, n)
+	}
+	for i := uint64(0); n == indefinite || i < n; i++ {
+		if n == indefinite && d.atBreak() {
+			break
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return &structpb.ListValue{Values: values}, nil
+}
+
+func (d *decoder) object(n uint64) (*structpb.Struct, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+
+	if n != indefinite && n > uint64(len(d.data)-d.pos)/2 {
+		return nil, d.errorf("map length %d exceeds the remaining input", n)
+	}
+	fields := make(map[string]*structpb.Value)
+	for i := uint64(0); n == indefinite || i < n; i++ {
+		if n == indefinite && d.atBreak() {
+			break
+		}
+		keyStart := d.pos
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := fields[key]; dup {
+			d.pos = keyStart
+			return nil, d.errorf("duplicate key %q", key)
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = v
+	}
+	return &structpb.Struct{Fields: fields}, nil
+}
+
+// key reads a map key, stringifying integers
+func (d *decoder) key() (string, error) {
+	start := d.pos
+	major, _, arg, err := d.head()
+	if err != nil {
+		return "", err
+	}
+	switch major {
+	case majorText:
+		raw, err := d.str(majorText, arg)
+		if err != nil {
+			return "", err
+		}
+		if !utf8.Valid(raw) {
+			d.pos = start
+			return "", d.errorf("invalid UTF-8 in map key")
+		}
+		return string(raw), nil
+	case majorUnsigned:
+		return strconv.FormatUint(arg, 10), nil
+	case majorNegative:
+		n := new(big.Int).SetUint64(arg)
+		return n.Neg(n.Add(n, big.NewInt(1))).String(), nil
+	default:
+		d.pos = start
+		return "", fmt.Errorf("%w: map key at offset %d must be a text string or an integer",
+			protobaggins.ErrUnexpectedKind, start)
+	}
+}
+
+func (d *decoder) tagged(tag uint64) (*structpb.Value, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+
+	start := d.pos
+	content, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case tagDateTime:
+		if _, ok := content.GetKind().(*structpb.Value_StringValue); !ok {
+			d.pos = start
+			return nil, d.errorf("date/time tag must enclose a text string")
+		}
+		return content, nil
+	case tagEpoch:
+		n, ok := content.GetKind().(*structpb.Value_NumberValue)
+		if !ok || math.IsNaN(n.NumberValue) || math.IsInf(n.NumberValue, 0) {
+			d.pos = start
+			return nil, d.errorf("epoch tag must enclose a finite number")
+		}
+		sec, frac := math.Modf(n.NumberValue)
+		t := time.Unix(int64(sec), int64(frac*1e9)).UTC()
+		return structpb.NewStringValue(t.Format(time.RFC3339Nano)), nil
+	case tagPositiveBig, tagNegativeBig:
+		raw, err := protobaggins.BytesFromValue(content)
+		if err != nil || content.GetStructValue() == nil {
+			d.pos = start
+			return nil, d.errorf("bignum tag must enclose a byte string")
+		}
+		n := new(big.Int).SetBytes(raw)
+		if tag == tagNegativeBig {
+			n.Neg(n.Add(n, big.NewInt(1)))
+		}
+		return d.integer(n)
+	default:
+		return content, nil
+	}
+}
+
+func (d *decoder) simple(info byte, arg uint64) (*structpb.Value, error) {
+	switch info {
+	case 20:
+		return structpb.NewBoolValue(false), nil
+	case 21:
+		return structpb.NewBoolValue(true), nil
+	case 22, 23:
+		return structpb.NewNullValue(), nil
+	case 25:
+		return structpb.NewNumberValue(halfToFloat64(uint16(arg))), nil
+	case 26:
+		return structpb.NewNumberValue(float64(math.Float32frombits(uint32(arg)))), nil
+	case 27:
+		return structpb.NewNumberValue(math.Float64frombits(arg)), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported simple value %d at offset %d", protobaggins.ErrUnexpectedKind, arg, d.pos)
+	}
+}
+
+// halfToFloat64 converts an IEEE 754 half-precision float
+func halfToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
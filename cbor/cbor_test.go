@@ -0,0 +1,191 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	// vectors from RFC 8949 appendix A
+	tests := []struct {
+		name  string
+		value *structpb.Value
+		want  string
+	}{
+		{"zero", structpb.NewNumberValue(0), "00"},
+		{"small", structpb.NewNumberValue(23), "17"},
+		{"one byte", structpb.NewNumberValue(24), "1818"},
+		{"two bytes", structpb.NewNumberValue(1000), "1903e8"},
+		{"four bytes", structpb.NewNumberValue(1000000), "1a000f4240"},
+		{"max safe", structpb.NewNumberValue(1 << 53), "1b0020000000000000"},
+		{"negative", structpb.NewNumberValue(-1000), "3903e7"},
+		{"negative zero", structpb.NewNumberValue(math.Copysign(0, -1)), "fa80000000"},
+		{"single", structpb.NewNumberValue(100000.5), "fa47c35040"},
+		{"double", structpb.NewNumberValue(1.1), "fb3ff199999999999a"},
+		{"beyond safe", structpb.NewNumberValue(1 << 60), "fa5d800000"},
+		{"nan", structpb.NewNumberValue(math.NaN()), "f97e00"},
+		{"infinity", structpb.NewNumberValue(math.Inf(-1)), "f9fc00"},
+		{"bools", structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			structpb.NewBoolValue(false), structpb.NewBoolValue(true)}}), "82f4f5"},
+		{"null", structpb.NewNullValue(), "f6"},
+		{"nil", nil, "f6"},
+		{"text", structpb.NewStringValue("ü"), "62c3bc"},
+		{"bytes", protobaggins.BytesToValue([]byte{1, 2, 3, 4}), "4401020304"},
+		{"map", structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"aa": structpb.NewNumberValue(3), "b": structpb.NewNumberValue(2), "a": structpb.NewNumberValue(1),
+		}}), "a361610161620262616103"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, hex.EncodeToString(Marshal(tt.value)))
+		})
+	}
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, []byte{0xa0}, MarshalStruct(nil))
+	})
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  *structpb.Value
+	}{
+		{"unsigned", "1903e8", structpb.NewNumberValue(1000)},
+		{"negative", "3903e7", structpb.NewNumberValue(-1000)},
+		{"half", "f93c00", structpb.NewNumberValue(1)},
+		{"half subnormal", "f90001", structpb.NewNumberValue(5.960464477539063e-8)},
+		{"half negative", "f9c400", structpb.NewNumberValue(-4)},
+		{"single", "fa47c35000", structpb.NewNumberValue(100000)},
+		{"undefined", "f7", structpb.NewNullValue()},
+		{"indefinite text", "7f657374726561646d696e67ff", structpb.NewStringValue("streaming")},
+		{"indefinite bytes", "5f42010243030405ff", protobaggins.BytesToValue([]byte{1, 2, 3, 4, 5})},
+		{"empty bytes", "40", protobaggins.BytesToValue([]byte{})},
+		{"indefinite array", "9f018202039f0405ffff", structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			structpb.NewNumberValue(1),
+			structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewNumberValue(2), structpb.NewNumberValue(3)}}),
+			structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewNumberValue(4), structpb.NewNumberValue(5)}}),
+		}})},
+		{"integer keys", "a201020304", structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"1": structpb.NewNumberValue(2), "3": structpb.NewNumberValue(4),
+		}})},
+		{"date/time", "c074323031332d30332d32315432303a30343a30305a", structpb.NewStringValue("2013-03-21T20:04:00Z")},
+		{"epoch", "c1fb41d452d9ec200000", structpb.NewStringValue("2013-03-21T20:04:00.5Z")},
+		{"bignum", "c249010000000000000000", structpb.NewStringValue("18446744073709551616")},
+		{"small bignum", "c34101", structpb.NewNumberValue(-2)},
+		{"other tags", "d9d9f7d82076687474703a2f2f7777772e6578616d706c652e636f6d",
+			structpb.NewStringValue("http://www.example.com")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Unmarshal(mustHex(t, tt.input), BigIntegersAsStrings())
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(tt.want, got), "got %v", got)
+		})
+	}
+
+	t.Run("big integers", func(t *testing.T) {
+		t.Parallel()
+		_, err := Unmarshal(mustHex(t, "1b0020000000000001"))
+		require.ErrorIs(t, err, protobaggins.ErrNotCoercible)
+		assert.Contains(t, err.Error(), "integer 9007199254740993 at offset 9 is beyond ±2^53")
+		_, err = Unmarshal(mustHex(t, "3bffffffffffffffff"))
+		require.ErrorIs(t, err, protobaggins.ErrNotCoercible)
+
+		got, err := Unmarshal(mustHex(t, "3bffffffffffffffff"), BigIntegersAsStrings())
+		require.NoError(t, err)
+		assert.Equal(t, "-18446744073709551616", got.GetStringValue())
+	})
+
+	t.Run("malformed input", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{
+			"",                   // empty
+			"18",                 // missing argument
+			"1c",                 // reserved additional information
+			"62c3",               // short text
+			"9bffffffffffffffff", // huge length
+			"a20102",             // short map
+			"ff",                 // stray break
+			"9f01",               // unterminated array
+			"5f6161ff",           // text chunk in a byte string
+			"61ff",               // invalid UTF-8
+			"a2616101616102",     // duplicate key
+			"0000",               // trailing data
+		} {
+			_, err := Unmarshal(mustHex(t, input))
+			require.ErrorIs(t, err, ErrMalformed, input)
+		}
+	})
+
+	t.Run("unsupported items", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"a1f600", "a1400102", "f0"} {
+			_, err := Unmarshal(mustHex(t, input))
+			require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind, input)
+		}
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		t.Parallel()
+		deep := make([]byte, MaxDepth+1)
+		for i := range deep {
+			deep[i] = 0x81
+		}
+		_, err := Unmarshal(append(deep, 0x00))
+		require.ErrorIs(t, err, protobaggins.ErrMaxDepth)
+	})
+}
+
+func TestUnmarshalStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trips", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"name":  "frodo",
+			"age":   50,
+			"ratio": 0.25,
+			"big":   1e300,
+			"ring":  true,
+			"home":  nil,
+			"past":  []any{map[string]any{"street": "bagshot row"}, -7, "x"},
+			"empty": map[string]any{},
+		})
+		require.NoError(t, err)
+		s.Fields["blob"] = protobaggins.BytesToValue([]byte{0, 0xff})
+
+		got, err := UnmarshalStruct(MarshalStruct(s))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(s, got), "got %v", got)
+	})
+
+	t.Run("rejects non-maps", func(t *testing.T) {
+		t.Parallel()
+		_, err := UnmarshalStruct([]byte{0x80})
+		require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind)
+		assert.Contains(t, err.Error(), "got list")
+	})
+}
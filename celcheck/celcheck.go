@@ -0,0 +1,172 @@
+// Package celcheck checks Structs against constraints written in CEL, the Common
+// Expression Language, such as `this.port >= 1 && this.port <= 65535`. Expressions are
+// evaluated directly on the structpb values, without converting them to maps first,
+// and every constraint that does not hold is reported. Val and FromVal adapt values
+// to and from CEL for programs evaluating their own expressions.
+//
+// It is a module of its own, so that only programs using it depend on cel-go
+package celcheck
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"cel.dev/cel-go/cel"
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/internal/conv"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrInvalidConstraint is returned by NewChecker for expressions that do not compile
+// or do not evaluate to a bool
+var ErrInvalidConstraint = errors.New("invalid constraint")
+
+// Constraint is a CEL expression that must hold for a value
+type Constraint struct {
+	// Path locates the checked value, in the notation of protobaggins.LookupPath, e.g.
+	// "listeners[0]". Empty means the whole Struct. Constraints whose value does not
+	// exist are not checked
+	Path string
+	// Expr is the expression, which sees the checked value as `this` and must evaluate
+	// to a bool
+	Expr string
+	// Message describes a violation of the constraint, the expression by default
+	Message string
+}
+
+// Violation is a constraint that did not hold
+type Violation struct {
+	// Path is the path of the constraint
+	Path string
+	// Expr is the expression of the constraint
+	Expr string
+	// Message is the message of the constraint
+	Message string
+	// Err is set when the expression failed to evaluate, e.g. because it selects a key
+	// the value does not have, rather than evaluating to false
+	Err error
+}
+
+func (v Violation) String() string {
+	path := conv.DescribePath(v.Path)
+	if v.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", path, v.Message, v.Err)
+	}
+	return path + ": " + v.Message
+}
+
+// ValidationError reports every violation found by Check
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = v.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Checker is a set of compiled constraints, safe for concurrent use
+type Checker struct {
+	constraints []compiled
+}
+
+type compiled struct {
+	Constraint
+	program cel.Program
+}
+
+// NewChecker compiles constraints, reporting every one that fails to compile, wrapping
+// ErrInvalidConstraint
+func NewChecker(constraints ...Constraint) (*Checker, error) {
+	env, err := cel.NewEnv(cel.Variable("this", cel.DynType))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Checker{constraints: make([]compiled, 0, len(constraints))}
+	var errs []error
+	for _, constraint := range constraints {
+		program, err := compile(env, constraint.Expr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w %q: %w", ErrInvalidConstraint, constraint.Expr, err))
+			continue
+		}
+		if constraint.Message == "" {
+			constraint.Message = constraint.Expr
+		}
+		c.constraints = append(c.constraints, compiled{Constraint: constraint, program: program})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return c, nil
+}
+
+func compile(env *cel.Env, expr string) (cel.Program, error) {
+	ast, issues := env.Compile(expr)
+	if err := issues.Err(); err != nil {
+		return nil, err
+	}
+	if out := ast.OutputType(); !out.IsExactType(cel.BoolType) && !out.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("evaluates to %s, not bool", out)
+	}
+	return env.Program(ast)
+}
+
+// Check evaluates every constraint against s, returning a *ValidationError listing
+// those that do not hold, in the order they were given, or nil if all hold
+func (c *Checker) Check(s *structpb.Struct) error {
+	return c.CheckValue(structpb.NewStructValue(s))
+}
+
+// CheckValue is Check for any value
+func (c *Checker) CheckValue(v *structpb.Value) error {
+	var violations []Violation
+	for _, constraint := range c.constraints {
+		this, err := protobaggins.LookupPath(v, constraint.Path)
+		if errors.Is(err, protobaggins.ErrPathNotFound) {
+			continue
+		}
+		if err == nil {
+			err = constraint.eval(this)
+		}
+		if err != nil {
+			violations = append(violations, constraint.violation(err))
+		}
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// errViolated is returned by eval for expressions that evaluate to false
+var errViolated = errors.New("constraint violated")
+
+// eval evaluates the constraint with this bound to v
+func (c *compiled) eval(v *structpb.Value) error {
+	out, _, err := c.program.Eval(map[string]any{"this": Val(v)})
+	if err != nil {
+		return err
+	}
+	switch holds, ok := out.Value().(bool); {
+	case !ok:
+		return fmt.Errorf("evaluated to %v, not a bool", out.Value())
+	case !holds:
+		return errViolated
+	default:
+		return nil
+	}
+}
+
+func (c *compiled) violation(err error) Violation {
+	v := Violation{Path: c.Path, Expr: c.Expr, Message: c.Message}
+	if !errors.Is(err, errViolated) {
+		v.Err = err
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+package celcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+
+	config, err := structpb.NewStruct(map[string]any{
+		"name": "shire",
+		"listeners": []any{
+			map[string]any{"port": 8080, "tls": true},
+			map[string]any{"port": 70000},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("violations", func(t *testing.T) {
+		t.Parallel()
+		checker, err := NewChecker(
+			Constraint{Expr: `this.name.size() > 0`},
+			Constraint{Path: "listeners[0]", Expr: `this.port >= 1 && this.port <= 65535`},
+			Constraint{Path: "listeners[1]", Expr: `this.port >= 1 && this.port <= 65535`, Message: "port out of range"},
+			Constraint{Path: "listeners[1]", Expr: `this.tls`},
+			Constraint{Path: "listeners[5]", Expr: `false`},
+		)
+		require.NoError(t, err)
+
+		err = checker.Check(config)
+		var ve *ValidationError
+		require.ErrorAs(t, err, &ve)
+		require.Len(t, ve.Violations, 2)
+		assert.Equal(t, "listeners[1]", ve.Violations[0].Path)
+		assert.Equal(t, "port out of range", ve.Violations[0].Message)
+		require.NoError(t, ve.Violations[0].Err)
+		require.Error(t, ve.Violations[1].Err, "selecting a missing key fails to evaluate")
+		assert.Contains(t, err.Error(), "listeners[1]: port out of range")
+	})
+
+	t.Run("all hold", func(t *testing.T) {
+		t.Parallel()
+		checker, err := NewChecker(Constraint{Expr: `this.listeners.all(l, l.port > 0)`})
+		require.NoError(t, err)
+		require.NoError(t, checker.Check(config))
+	})
+
+	t.Run("invalid constraints", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewChecker(Constraint{Expr: `this.port >=`}, Constraint{Expr: `1 + 1`})
+		require.ErrorIs(t, err, ErrInvalidConstraint)
+		assert.Contains(t, err.Error(), `"1 + 1": evaluates to int, not bool`)
+	})
+}
@@ -0,0 +1,149 @@
+package celcheck
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cel.dev/cel-go/common/types"
+	"cel.dev/cel-go/common/types/ref"
+	"cel.dev/cel-go/common/types/traits"
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/internal/conv"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrUnsupportedVal is returned by FromVal for CEL values that have no structpb form,
+// such as types and unknowns
+var ErrUnsupportedVal = errors.New("unsupported CEL value")
+
+// Val adapts v to a CEL value without copying it, to bind as a variable of type dyn.
+// Structs and lists are wrapped and their items adapted only as expressions select
+// them. A nil or unset Value is null
+func Val(v *structpb.Value) ref.Val {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return types.Bool(kind.BoolValue)
+	case *structpb.Value_NumberValue:
+		return types.Double(kind.NumberValue)
+	case *structpb.Value_StringValue:
+		return types.String(kind.StringValue)
+	case *structpb.Value_ListValue:
+		return ListVal(kind.ListValue)
+	case *structpb.Value_StructValue:
+		return StructVal(kind.StructValue)
+	default:
+		return types.NullValue
+	}
+}
+
+// StructVal is Val for a Struct, a CEL map from strings to dyn that is also a
+// traits.Indexer
+func StructVal(s *structpb.Struct) traits.Mapper {
+	return types.NewJSONStruct(types.DefaultTypeAdapter, s)
+}
+
+// ListVal is Val for a ListValue, a CEL list of dyn that is also a traits.Indexer
+func ListVal(l *structpb.ListValue) traits.Lister {
+	return types.NewJSONList(types.DefaultTypeAdapter, l)
+}
+
+// FromVal converts val, the result of evaluating an expression, to a Value. Structs
+// and lists adapted by Val are returned as they are, without copying. Otherwise:
+//   - ints and uints become numbers, or decimal strings beyond 2^53, see
+//     protobaggins.Int64ToValue
+//   - bytes take the tagged form of protobaggins.BytesToValue
+//   - timestamps become RFC 3339 strings in UTC and durations strings of seconds, e.g.
+//     "90s"
+//   - map keys become strings, see protobaggins.ToString
+//   - optionals become their value, or null when empty
+//
+// Evaluation errors are returned as they are, and any other value wraps
+// ErrUnsupportedVal
+func FromVal(val ref.Val) (*structpb.Value, error) {
+	return fromVal("", val)
+}
+
+func fromVal(path string, val ref.Val) (*structpb.Value, error) {
+	switch v := val.(type) {
+	case nil:
+		return nil, fmt.Errorf("%s: %w: nil", conv.DescribePath(path), ErrUnsupportedVal)
+	case *types.Err:
+		return nil, fmt.Errorf("%s: %w", conv.DescribePath(path), v)
+	case types.Null:
+		return structpb.NewNullValue(), nil
+	case types.Bool:
+		return structpb.NewBoolValue(bool(v)), nil
+	case types.Int:
+		return protobaggins.Int64ToValue(int64(v)), nil
+	case types.Uint:
+		return protobaggins.Uint64ToValue(uint64(v)), nil
+	case types.Double:
+		return structpb.NewNumberValue(float64(v)), nil
+	case types.String:
+		return structpb.NewStringValue(string(v)), nil
+	case types.Bytes:
+		return protobaggins.BytesToValue(v), nil
+	case types.Timestamp:
+		return structpb.NewStringValue(v.UTC().Format(time.RFC3339Nano)), nil
+	case types.Duration:
+		return structpb.NewStringValue(strconv.FormatFloat(v.Seconds(), 'f', -1, 64) + "s"), nil
+	case *types.Optional:
+		if !v.HasValue() {
+			return structpb.NewNullValue(), nil
+		}
+		return fromVal(path, v.GetValue())
+	case traits.Mapper:
+		return fromMapper(path, v)
+	case traits.Lister:
+		return fromLister(path, v)
+	default:
+		return nil, fmt.Errorf("%s: %w: %s", conv.DescribePath(path), ErrUnsupportedVal, val.Type().TypeName())
+	}
+}
+
+func fromMapper(path string, m traits.Mapper) (*structpb.Value, error) {
+	if s, ok := m.Value().(*structpb.Struct); ok {
+		return structpb.NewStructValue(s), nil
+	}
+
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value)}
+	for it := m.Iterator(); it.HasNext() == types.True; {
+		k := it.Next()
+		key, err := fromVal(path, k)
+		if err != nil {
+			return nil, err
+		}
+		name, err := protobaggins.ToString(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %v: %w", conv.DescribePath(path), k.Value(), err)
+		}
+		v, err := fromVal(protobaggins.JoinPathKey(path, name), m.Get(k))
+		if err != nil {
+			return nil, err
+		}
+		s.Fields[name] = v
+	}
+	return structpb.NewStructValue(s), nil
+}
+
+func fromLister(path string, l traits.Lister) (*structpb.Value, error) {
+	if list, ok := l.Value().(*structpb.ListValue); ok {
+		return structpb.NewListValue(list), nil
+	}
+
+	size, ok := l.Size().(types.Int)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: list without a size", conv.DescribePath(path), ErrUnsupportedVal)
+	}
+	list := &structpb.ListValue{Values: make([]*structpb.Value, 0, size)}
+	for i := range int(size) {
+		v, err := fromVal(protobaggins.JoinPathIndex(path, i), l.Get(types.Int(i)))
+		if err != nil {
+			return nil, err
+		}
+		list.Values = append(list.Values, v)
+	}
+	return structpb.NewListValue(list), nil
+}
@@ -0,0 +1,85 @@
+package celcheck
+
+import (
+	"testing"
+
+	"cel.dev/cel-go/cel"
+	"cel.dev/cel-go/common/types"
+	"github.com/robbyt/protobaggins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestVal(t *testing.T) {
+	t.Parallel()
+
+	payload, err := structpb.NewStruct(map[string]any{
+		"user":  map[string]any{"name": "frodo", "age": 50},
+		"roles": []any{"bearer", "hobbit"},
+	})
+	require.NoError(t, err)
+
+	env, err := cel.NewEnv(cel.Variable("this", cel.DynType))
+	require.NoError(t, err)
+	eval := func(t *testing.T, expr string) *structpb.Value {
+		t.Helper()
+		ast, issues := env.Compile(expr)
+		require.NoError(t, issues.Err())
+		program, err := env.Program(ast)
+		require.NoError(t, err)
+		out, _, err := program.Eval(map[string]any{"this": StructVal(payload)})
+		require.NoError(t, err)
+		v, err := FromVal(out)
+		require.NoError(t, err)
+		return v
+	}
+
+	t.Run("scalars", func(t *testing.T) {
+		t.Parallel()
+		assert.True(t, eval(t, `this.user.name == "frodo" && "hobbit" in this.roles`).GetBoolValue())
+		assert.InDelta(t, 51.0, eval(t, `this.user.age + 1.0`).GetNumberValue(), 0)
+		assert.InDelta(t, 2.0, eval(t, `size(this.roles)`).GetNumberValue(), 0)
+		assert.Equal(t, "9007199254740993", eval(t, `9007199254740993`).GetStringValue())
+		assert.Equal(t, "2024-01-02T03:04:05Z", eval(t, `timestamp("2024-01-02T03:04:05Z")`).GetStringValue())
+		assert.Equal(t, "90s", eval(t, `duration("1m30s")`).GetStringValue())
+		assert.True(t, proto.Equal(protobaggins.BytesToValue([]byte("ring")), eval(t, `b"ring"`)))
+	})
+
+	t.Run("selected values are not copied", func(t *testing.T) {
+		t.Parallel()
+		assert.Same(t, payload.GetFields()["user"].GetStructValue(), eval(t, `this.user`).GetStructValue())
+		assert.Same(t, payload.GetFields()["roles"].GetListValue(), eval(t, `this.roles`).GetListValue())
+	})
+
+	t.Run("built values", func(t *testing.T) {
+		t.Parallel()
+		want, err := structpb.NewValue(map[string]any{
+			"name":  "frodo",
+			"roles": []any{"ring bearer", "ring hobbit"},
+			"1":     true,
+		})
+		require.NoError(t, err)
+		got := eval(t, `{"name": this.user.name, "roles": this.roles.map(r, "ring " + r), 1: true}`)
+		assert.True(t, proto.Equal(want, got), "got %v", got)
+	})
+
+	t.Run("null", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, types.NullValue, Val(nil))
+		v, err := FromVal(types.NullValue)
+		require.NoError(t, err)
+		assert.Equal(t, protobaggins.KindNull, protobaggins.KindOf(v))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := FromVal(types.NewErr("no such key: ring"))
+		require.ErrorContains(t, err, "no such key: ring")
+
+		_, err = FromVal(types.NewDynamicList(types.DefaultTypeAdapter, []any{1, types.IntType}))
+		require.ErrorIs(t, err, ErrUnsupportedVal)
+		assert.Contains(t, err.Error(), "[1]")
+	})
+}
@@ -0,0 +1,122 @@
+package protobaggins
+
+import (
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// TransformFunc rewrites a value copied by Clone, found at path in the original.
+// It returns the value to store instead, v itself to keep it, or nil to drop it
+type TransformFunc func(path string, v *structpb.Value) *structpb.Value
+
+// Clone deep copies v in a single pass, applying transforms in order to every copied
+// value, contents before their containers. Transforms only ever see the copy, so they
+// may modify it in place, and a container reaches them with its contents already
+// transformed. Dropped list items shift the following ones down, and a dropped root
+// gives nil. Returns nil for a nil v
+func Clone(v *structpb.Value, transforms ...TransformFunc) *structpb.Value {
+	if v == nil {
+		return nil
+	}
+	return cloneValue("", v, transforms)
+}
+
+// CloneStruct is Clone for a Struct. Returns nil if a transform drops the Struct or
+// replaces it with another kind
+func CloneStruct(s *structpb.Struct, transforms ...TransformFunc) *structpb.Struct {
+	if s == nil {
+		return nil
+	}
+	return cloneValue("", structpb.NewStructValue(s), transforms).GetStructValue()
+}
+
+func cloneValue(path string, v *structpb.Value, transforms []TransformFunc) *structpb.Value {
+	var c *structpb.Value
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NullValue:
+		c = structpb.NewNullValue()
+	case *structpb.Value_BoolValue:
+		c = structpb.NewBoolValue(kind.BoolValue)
+	case *structpb.Value_NumberValue:
+		c = structpb.NewNumberValue(kind.NumberValue)
+	case *structpb.Value_StringValue:
+		c = structpb.NewStringValue(kind.StringValue)
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		list := &structpb.ListValue{Values: make([]*structpb.Value, 0, len(items))}
+		for i, item := range items {
+			if item := cloneValue(joinIndex(path, i), item, transforms); item != nil {
+				list.Values = append(list.Values, item)
+			}
+		}
+		c = structpb.NewListValue(list)
+	case *structpb.Value_StructValue:
+		c = structpb.NewStructValue(cloneStruct(path, kind.StructValue, transforms))
+	default:
+		c = &structpb.Value{}
+	}
+
+	for _, transform := range transforms {
+		if c = transform(path, c); c == nil {
+			return nil
+		}
+	}
+	return c
+}
+
+func cloneStruct(path string, s *structpb.Struct, transforms []TransformFunc) *structpb.Struct {
+	fields := make(map[string]*structpb.Value, len(s.GetFields()))
+	for key, v := range s.GetFields() {
+		if v := cloneValue(joinKey(path, key), v, transforms); v != nil {
+			fields[key] = v
+		}
+	}
+	return &structpb.Struct{Fields: fields}
+}
+
+// TransformKind applies fn to values of kind only
+func TransformKind(kind Kind, fn TransformFunc) TransformFunc {
+	return func(path string, v *structpb.Value) *structpb.Value {
+		if KindOf(v) != kind {
+			return v
+		}
+		return fn(path, v)
+	}
+}
+
+// TransformPaths applies fn to values whose path satisfies all filters only, e.g.
+// a PathFilter comparing the path to `user.email`
+func TransformPaths(fn TransformFunc, filters ...PathFilter) TransformFunc {
+	return func(path string, v *structpb.Value) *structpb.Value {
+		if !matchesAll(path, filters) {
+			return v
+		}
+		return fn(path, v)
+	}
+}
+
+// TransformStrings rewrites strings with fn, e.g. hashing them
+func TransformStrings(fn func(string) string) TransformFunc {
+	return func(_ string, v *structpb.Value) *structpb.Value {
+		if s, ok := v.GetKind().(*structpb.Value_StringValue); ok {
+			s.StringValue = fn(s.StringValue)
+		}
+		return v
+	}
+}
+
+// TransformKeys renames the keys of Structs with rename, e.g. strings.ToLower. Of keys
+// renamed alike, the value of the last one in sorted order is kept
+func TransformKeys(rename func(string) string) TransformFunc {
+	return func(_ string, v *structpb.Value) *structpb.Value {
+		s, ok := v.GetKind().(*structpb.Value_StructValue)
+		if !ok {
+			return v
+		}
+		fields := make(map[string]*structpb.Value, len(s.StructValue.GetFields()))
+		for _, key := range sortedKeys(s.StructValue) {
+			fields[rename(key)] = s.StructValue.GetFields()[key]
+		}
+		s.StructValue.Fields = fields
+		return v
+	}
+}
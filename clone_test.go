@@ -0,0 +1,84 @@
+package protobaggins
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	newPayload := func(t *testing.T) *structpb.Value {
+		t.Helper()
+		v, err := structpb.NewValue(map[string]any{
+			"User": map[string]any{"Email": "Frodo@Shire.example", "Age": 50},
+			"Tags": []any{"ring", nil, "quest"},
+			"Done": false,
+		})
+		require.NoError(t, err)
+		return v
+	}
+
+	t.Run("deep copy", func(t *testing.T) {
+		t.Parallel()
+		v := newPayload(t)
+		c := Clone(v)
+		require.True(t, proto.Equal(v, c))
+		c.GetStructValue().GetFields()["User"].GetStructValue().GetFields()["Age"] = structpb.NewNumberValue(51)
+		assert.InDelta(t, 50.0, v.GetStructValue().GetFields()["User"].GetStructValue().GetFields()["Age"].GetNumberValue(), 0)
+	})
+
+	t.Run("transforms", func(t *testing.T) {
+		t.Parallel()
+		v := newPayload(t)
+		original := proto.Clone(v)
+
+		var paths []string
+		c := Clone(v,
+			func(path string, v *structpb.Value) *structpb.Value {
+				paths = append(paths, path)
+				return v
+			},
+			TransformKind(KindNull, func(string, *structpb.Value) *structpb.Value { return nil }),
+			TransformPaths(TransformStrings(strings.ToLower), func(path string) bool { return path == "User.Email" }),
+			TransformKeys(strings.ToLower),
+		)
+
+		want, err := structpb.NewValue(map[string]any{
+			"user": map[string]any{"email": "frodo@shire.example", "age": 50},
+			"tags": []any{"ring", "quest"},
+			"done": false,
+		})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, c), "got %v", c)
+		assert.True(t, proto.Equal(original, v), "the original is unchanged")
+
+		assert.Equal(t, "", paths[len(paths)-1], "containers come after their contents")
+		assert.Contains(t, paths, "Tags[1]")
+		assert.Contains(t, paths, "User.Email")
+	})
+
+	t.Run("dropped root", func(t *testing.T) {
+		t.Parallel()
+		drop := func(string, *structpb.Value) *structpb.Value { return nil }
+		assert.Nil(t, Clone(newPayload(t), TransformPaths(drop, func(path string) bool { return path == "" })))
+		assert.Nil(t, Clone(nil))
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		t.Parallel()
+		s := newPayload(t).GetStructValue()
+		c := CloneStruct(s, TransformKeys(strings.ToUpper))
+		assert.ElementsMatch(t, []string{"USER", "TAGS", "DONE"}, sortedKeys(c))
+		assert.Contains(t, c.GetFields()["USER"].GetStructValue().GetFields(), "EMAIL")
+
+		toString := func(string, *structpb.Value) *structpb.Value { return structpb.NewStringValue("x") }
+		assert.Nil(t, CloneStruct(s, toString))
+		assert.Nil(t, CloneStruct(nil))
+	})
+}
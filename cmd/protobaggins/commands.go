@@ -0,0 +1,323 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ioFlags are the format flags shared by most commands
+type ioFlags struct {
+	from string
+	to   string
+}
+
+func (f *ioFlags) register(fs *flag.FlagSet, withOutput bool) {
+	fs.StringVar(&f.from, "from", "", "input format, one of "+strings.Join(formats, ", "))
+	if withOutput {
+		fs.StringVar(&f.to, "to", "", "output format, defaults to the input format")
+	}
+}
+
+// read loads and parses a document, "" and "-" meaning standard input
+func (f *ioFlags) read(e *env, path string) (*structpb.Struct, string, error) {
+	format, err := formatFor(f.from, path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := readInput(e, path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s, err := decodeStruct(format, data)
+	if err != nil {
+		if path == "" {
+			path = "stdin"
+		}
+		return nil, "", fmt.Errorf("parsing %s as %s: %w", path, format, err)
+	}
+	return s, format, nil
+}
+
+// readInput reads a file, "" and "-" meaning standard input
+func readInput(e *env, path string) ([]byte, error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// stdinOnce fails if standard input is named more than once in paths, since it can
+// only be read once
+func stdinOnce(paths []string) error {
+	var n int
+	for _, path := range paths {
+		if path == "-" {
+			n++
+		}
+	}
+	if n > 1 {
+		return errors.New(`standard input "-" may only be given once`)
+	}
+	return nil
+}
+
+// write serializes s to standard output in the -to format, or inputFormat if unset
+func (f *ioFlags) write(e *env, inputFormat string, s *structpb.Struct) error {
+	format, err := formatFor(f.to, "")
+	if err != nil {
+		return err
+	}
+	if f.to == "" {
+		format = inputFormat
+	}
+
+	data, err := encodeStruct(format, s)
+	if err != nil {
+		return err
+	}
+	_, err = e.stdout.Write(data)
+	return err
+}
+
+// optionalArg returns the single optional file argument
+func optionalArg(fs *flag.FlagSet) (string, error) {
+	switch fs.NArg() {
+	case 0:
+		return "", nil
+	case 1:
+		return fs.Arg(0), nil
+	default:
+		fs.Usage()
+		return "", errors.New("too many arguments")
+	}
+}
+
+func runConvert(e *env, fs *flag.FlagSet, args []string) error {
+	var f ioFlags
+	f.register(fs, true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	path, err := optionalArg(fs)
+	if err != nil {
+		return err
+	}
+
+	s, format, err := f.read(e, path)
+	if err != nil {
+		return err
+	}
+	return f.write(e, format, s)
+}
+
+func runGet(e *env, fs *flag.FlagSet, args []string) error {
+	var f ioFlags
+	f.register(fs, true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return errors.New("expected a path and at most one file")
+	}
+
+	s, format, err := f.read(e, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	v, err := protobaggins.LookupPath(structpb.NewStructValue(s), fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// non-struct results can only be represented as JSON
+	if st := v.GetStructValue(); st != nil && (f.to != "" || format != formatJSON) {
+		return f.write(e, format, st)
+	}
+	data, err := encodeJSON(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.stdout.Write(data)
+	return err
+}
+
+func runMerge(e *env, fs *flag.FlagSet, args []string) error {
+	var f ioFlags
+	f.register(fs, true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errors.New("expected a base document and at least one patch")
+	}
+	if err := stdinOnce(fs.Args()); err != nil {
+		return err
+	}
+
+	s, format, err := f.read(e, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, path := range fs.Args()[1:] {
+		patch, _, err := f.read(e, path)
+		if err != nil {
+			return err
+		}
+		if s, err = protobaggins.ApplyMergePatch(s, patch); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return f.write(e, format, s)
+}
+
+func runPatch(e *env, fs *flag.FlagSet, args []string) error {
+	var f ioFlags
+	f.register(fs, true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errors.New("expected a base document and at least one patch")
+	}
+	if err := stdinOnce(fs.Args()); err != nil {
+		return err
+	}
+
+	s, format, err := f.read(e, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, path := range fs.Args()[1:] {
+		data, err := readInput(e, path)
+		if err != nil {
+			return err
+		}
+		patch, err := protobaggins.ParseJSONPatch(data)
+		if err != nil {
+			return fmt.Errorf("parsing %s as a JSON patch: %w", path, err)
+		}
+		if s, err = protobaggins.ApplyJSONPatch(s, patch); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return f.write(e, format, s)
+}
+
+func runDiff(e *env, fs *flag.FlagSet, args []string) error {
+	var f ioFlags
+	f.register(fs, false)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected two documents")
+	}
+	if err := stdinOnce(fs.Args()); err != nil {
+		return err
+	}
+
+	a, _, err := f.read(e, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, _, err := f.read(e, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	changes := protobaggins.DiffStructs(a, b)
+	for _, change := range changes {
+		fmt.Fprintln(e.stdout, change)
+	}
+	if len(changes) > 0 {
+		return errDiffer
+	}
+	return nil
+}
+
+// pathList collects repeated -path flags
+type pathList []string
+
+func (p *pathList) String() string     { return strings.Join(*p, ",") }
+func (p *pathList) Set(v string) error { *p = append(*p, v); return nil }
+
+func runRedact(e *env, fs *flag.FlagSet, args []string) error {
+	var f ioFlags
+	var (
+		match string
+		with  string
+		paths pathList
+	)
+	f.register(fs, true)
+	fs.StringVar(&match, "match", "", "regular expression to redact (required)")
+	fs.StringVar(&with, "with", "[REDACTED]", "replacement text")
+	fs.Var(&paths, "path", "only redact at or beneath this path, may be repeated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if match == "" {
+		fs.Usage()
+		return errors.New("-match is required")
+	}
+	pattern, err := regexp.Compile(match)
+	if err != nil {
+		return err
+	}
+	path, err := optionalArg(fs)
+	if err != nil {
+		return err
+	}
+
+	s, format, err := f.read(e, path)
+	if err != nil {
+		return err
+	}
+
+	var filters []protobaggins.PathFilter
+	if len(paths) > 0 {
+		filters = append(filters, func(path string) bool {
+			for _, prefix := range paths {
+				if protobaggins.WithinPath(path, prefix) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	n := protobaggins.TransformMatching(s, pattern, func(string) string { return with }, filters...)
+	fmt.Fprintf(e.stderr, "redacted %d values\n", n)
+	return f.write(e, format, s)
+}
+
+func runProfile(e *env, fs *flag.FlagSet, args []string) error {
+	var f ioFlags
+	f.register(fs, false)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	path, err := optionalArg(fs)
+	if err != nil {
+		return err
+	}
+
+	s, _, err := f.read(e, path)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(e.stdout, protobaggins.Profile(s).String())
+	return err
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	t.Run("yaml file to json", func(t *testing.T) {
+		t.Parallel()
+		path := writeFile(t, "in.yaml", "name: frodo\nitems: [ring, sting]\n")
+		code, stdout, _ := runCLI(t, "", "convert", "-to", "json", path)
+		require.Equal(t, 0, code)
+		assert.Equal(t, "{\n  \"items\": [\n    \"ring\",\n    \"sting\"\n  ],\n  \"name\": \"frodo\"\n}\n", stdout)
+	})
+
+	t.Run("stdin defaults to json in and out", func(t *testing.T) {
+		t.Parallel()
+		code, stdout, _ := runCLI(t, `{"b":1,"a":true}`, "convert")
+		require.Equal(t, 0, code)
+		assert.JSONEq(t, `{"a":true,"b":1}`, stdout)
+	})
+
+	t.Run("binary round trip", func(t *testing.T) {
+		t.Parallel()
+		code, binary, _ := runCLI(t, `{"name":"frodo"}`, "convert", "-to", "binpb")
+		require.Equal(t, 0, code)
+		code, stdout, _ := runCLI(t, binary, "convert", "-from", "binpb", "-to", "json")
+		require.Equal(t, 0, code)
+		assert.JSONEq(t, `{"name":"frodo"}`, stdout)
+	})
+
+	t.Run("too many arguments", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, "", "convert", "a", "b")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "too many arguments")
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	input := `{"items":[{"name":"ring"}],"labels":{"app.kind":"hobbit"}}`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"scalar in list", []string{"items[0].name"}, `"ring"`},
+		{"quoted key", []string{`labels["app.kind"]`}, `"hobbit"`},
+		{"struct", []string{"items[0]"}, `{"name":"ring"}`},
+		{"struct as yaml", []string{"-to", "yaml", "labels"}, "app.kind: hobbit\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			code, stdout, stderr := runCLI(t, input, append([]string{"get"}, tt.args...)...)
+			require.Equal(t, 0, code, stderr)
+			if tt.expected[0] == '"' || tt.expected[0] == '{' {
+				assert.JSONEq(t, tt.expected, stdout)
+			} else {
+				assert.Equal(t, tt.expected, stdout)
+			}
+		})
+	}
+
+	t.Run("missing key", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, input, "get", "items[3]")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "index 3 out of range")
+	})
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	base := writeFile(t, "base.json", `{"name":"frodo","home":{"region":"shire","hole":"bag end"},"age":50}`)
+	patch := writeFile(t, "patch.yaml", "home:\n  hole: null\n  region: mordor\nage: 51\nring: true\n")
+
+	code, stdout, stderr := runCLI(t, "", "merge", base, patch)
+	require.Equal(t, 0, code, stderr)
+	assert.JSONEq(t, `{"name":"frodo","home":{"region":"mordor"},"age":51,"ring":true}`, stdout)
+
+	code, _, stderr = runCLI(t, `{}`, "merge", "-", "-")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `standard input "-" may only be given once`)
+}
+
+func TestPatch(t *testing.T) {
+	t.Parallel()
+
+	base := writeFile(t, "base.yaml", "name: frodo\nitems: [ring]\n")
+	first := writeFile(t, "first.json", `[{"op":"add","path":"/items/-","value":"sting"},{"op":"replace","path":"/name","value":"sam"}]`)
+	second := writeFile(t, "second.json", `[{"op":"test","path":"/name","value":"sam"},{"op":"remove","path":"/items/0"}]`)
+
+	t.Run("in order", func(t *testing.T) {
+		t.Parallel()
+		code, stdout, stderr := runCLI(t, "", "patch", "-to", "json", base, first, second)
+		require.Equal(t, 0, code, stderr)
+		assert.JSONEq(t, `{"name":"sam","items":["sting"]}`, stdout)
+	})
+
+	t.Run("patch from stdin", func(t *testing.T) {
+		t.Parallel()
+		code, stdout, stderr := runCLI(t, `[{"op":"remove","path":"/items"}]`, "patch", base, "-")
+		require.Equal(t, 0, code, stderr)
+		assert.Equal(t, "name: frodo\n", stdout)
+	})
+
+	t.Run("failing operation", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, "", "patch", base, second)
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, second)
+	})
+
+	t.Run("invalid patch", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, `{"op":"add"}`, "patch", base, "-")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "parsing - as a JSON patch")
+	})
+
+	t.Run("stdin twice", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, "", "patch", "-", "-")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "may only be given once")
+	})
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	a := writeFile(t, "a.json", `{"name":"frodo","age":50}`)
+	b := writeFile(t, "b.yaml", "name: sam\nage: 50\nfriend: frodo\n")
+
+	code, stdout, _ := runCLI(t, "", "diff", a, b)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "+ friend: \"frodo\"\n~ name: \"frodo\" -> \"sam\"\n", stdout)
+
+	code, stdout, _ = runCLI(t, "", "diff", a, a)
+	assert.Equal(t, 0, code)
+	assert.Empty(t, stdout)
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	input := `{"email":"frodo@shire.example","notes":["ask sam@shire.example"],"owner":{"contact":"bilbo@shire.example"}}`
+
+	t.Run("everywhere", func(t *testing.T) {
+		t.Parallel()
+		code, stdout, stderr := runCLI(t, input, "redact", "-match", `\S+@\S+`)
+		require.Equal(t, 0, code, stderr)
+		assert.Contains(t, stderr, "redacted 3 values")
+		assert.JSONEq(t, `{"email":"[REDACTED]","notes":["ask [REDACTED]"],"owner":{"contact":"[REDACTED]"}}`, stdout)
+	})
+
+	t.Run("limited to paths", func(t *testing.T) {
+		t.Parallel()
+		code, stdout, stderr := runCLI(t, input, "redact", "-match", `\S+@\S+`, "-with", "x", "-path", "owner", "-path", "notes")
+		require.Equal(t, 0, code, stderr)
+		assert.JSONEq(t, `{"email":"frodo@shire.example","notes":["ask x"],"owner":{"contact":"x"}}`, stdout)
+	})
+
+	t.Run("match is required", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, input, "redact")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "-match is required")
+	})
+}
+
+func TestProfile(t *testing.T) {
+	t.Parallel()
+
+	code, stdout, _ := runCLI(t, `{"a":[1,"x"]}`, "profile")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "values: 3, max depth: 2")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// format names accepted by the -from and -to flags
+const (
+	formatJSON   = "json"
+	formatYAML   = "yaml"
+	formatTOML   = "toml"
+	formatBinary = "binpb"
+	formatText   = "txtpb"
+)
+
+var formats = []string{formatJSON, formatYAML, formatTOML, formatBinary, formatText}
+
+// formatFor returns the explicit format if set, otherwise the format implied by the
+// file extension, defaulting to JSON
+func formatFor(explicit, path string) (string, error) {
+	if explicit != "" {
+		for _, f := range formats {
+			if explicit == f {
+				return f, nil
+			}
+		}
+		return "", fmt.Errorf("unknown format %q, expected one of %s", explicit, strings.Join(formats, ", "))
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return formatYAML, nil
+	case ".toml":
+		return formatTOML, nil
+	case ".binpb", ".pb", ".bin":
+		return formatBinary, nil
+	case ".txtpb", ".textproto", ".prototxt", ".pbtxt":
+		return formatText, nil
+	default:
+		return formatJSON, nil
+	}
+}
+
+// decodeStruct parses data in the given format
+func decodeStruct(format string, data []byte) (*structpb.Struct, error) {
+	switch format {
+	case formatBinary:
+		s := &structpb.Struct{}
+		return s, proto.Unmarshal(data, s)
+	case formatText:
+		s := &structpb.Struct{}
+		return s, prototext.Unmarshal(data, s)
+	case formatJSON:
+		s := &structpb.Struct{}
+		return s, protojson.Unmarshal(data, s)
+	case formatYAML:
+		return protobaggins.YAMLToStruct(data)
+	case formatTOML:
+		return decodeTOML(data)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func decodeTOML(data []byte) (*structpb.Struct, error) {
+	var m map[string]any
+	if err := toml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	v, err := protobaggins.NewValue(normalize(m))
+	if err != nil {
+		return nil, err
+	}
+	return v.GetStructValue(), nil
+}
+
+// normalize converts TOML decoder output into types NewValue accepts: typed slices
+// become []any and local dates and times become strings without a zone
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalize(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	case []map[string]any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = normalize(item)
+		}
+		return result
+	case time.Time:
+		// TOML local dates and times are marked by these zone names
+		switch v.Location().String() {
+		case "date-local":
+			return v.Format(time.DateOnly)
+		case "time-local":
+			return v.Format("15:04:05.999999999")
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999")
+		default:
+			return v.Format(time.RFC3339Nano)
+		}
+	default:
+		return v
+	}
+}
+
+// encodeStruct serializes s in the given format. JSON is indented with sorted keys
+func encodeStruct(format string, s *structpb.Struct) ([]byte, error) {
+	switch format {
+	case formatJSON:
+		return encodeJSON(structpb.NewStructValue(s))
+	case formatYAML:
+		return protobaggins.StructToYAML(s)
+	case formatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(s.AsMap()); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case formatBinary:
+		return proto.MarshalOptions{Deterministic: true}.Marshal(s)
+	case formatText:
+		return prototext.MarshalOptions{Multiline: true}.Marshal(s)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// encodeJSON renders any value as indented JSON with sorted keys and a trailing newline
+func encodeJSON(v *structpb.Value) ([]byte, error) {
+	data, err := json.MarshalIndent(v.AsInterface(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
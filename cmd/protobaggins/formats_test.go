@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFormatFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		explicit string
+		path     string
+		expected string
+	}{
+		{"", "", formatJSON},
+		{"", "a.YML", formatYAML},
+		{"", "a.toml", formatTOML},
+		{"", "a.pb", formatBinary},
+		{"", "a.textproto", formatText},
+		{"yaml", "a.json", formatYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.explicit+tt.path, func(t *testing.T) {
+			t.Parallel()
+			got, err := formatFor(tt.explicit, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	_, err := formatFor("xml", "")
+	assert.ErrorContains(t, err, `unknown format "xml"`)
+}
+
+func TestDecodeStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("toml", func(t *testing.T) {
+		t.Parallel()
+		s, err := decodeStruct(formatTOML, []byte(`
+name = "frodo"
+born = 2968-09-22
+at = 1979-05-27T07:32:00Z
+[[items]]
+name = "ring"
+`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":  "frodo",
+			"born":  "2968-09-22",
+			"at":    "1979-05-27T07:32:00Z",
+			"items": []any{map[string]any{"name": "ring"}},
+		}, s.AsMap())
+	})
+
+	t.Run("yaml keys are stringified", func(t *testing.T) {
+		t.Parallel()
+		s, err := decodeStruct(formatYAML, []byte("1: one\ntrue: yes\n"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"1": "one", "true": "yes"}, s.AsMap())
+	})
+
+	t.Run("empty yaml", func(t *testing.T) {
+		t.Parallel()
+		s, err := decodeStruct(formatYAML, nil)
+		require.NoError(t, err)
+		assert.Empty(t, s.GetFields())
+	})
+
+	t.Run("yaml must be a mapping", func(t *testing.T) {
+		t.Parallel()
+		_, err := decodeStruct(formatYAML, []byte("- a\n"))
+		assert.ErrorContains(t, err, "must be a mapping")
+	})
+}
+
+func TestEncodeStruct(t *testing.T) {
+	t.Parallel()
+
+	s, err := decodeStruct(formatJSON, []byte(`{"name":"frodo","items":["ring"],"age":50}`))
+	require.NoError(t, err)
+
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+			data, err := encodeStruct(format, s)
+			require.NoError(t, err)
+			back, err := decodeStruct(format, data)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(s, back), "%s round trip: %s", format, data)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Command protobaggins converts, queries, merges, patches, diffs and redacts Struct payloads
+// in JSON, YAML, TOML, binary protobuf and prototext form
+//
+// Usage:
+//
+//	protobaggins <command> [flags] [args]
+//
+// Run `protobaggins help` for the list of commands
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+)
+
+// errDiffer is returned by the diff command when the documents differ, which exits
+// with status 1 like diff(1)
+var errDiffer = errors.New("documents differ")
+
+// command is a single subcommand of the CLI
+type command struct {
+	usage string
+	help  string
+	run   func(e *env, fs *flag.FlagSet, args []string) error
+}
+
+var commands = map[string]command{
+	"convert": {
+		usage: "convert [-from format] [-to format] [file]",
+		help:  "convert a document between formats",
+		run:   runConvert,
+	},
+	"get": {
+		usage: "get [-from format] [-to format] path [file]",
+		help:  "print the value at a path such as items[0].name",
+		run:   runGet,
+	},
+	"merge": {
+		usage: "merge [-from format] [-to format] base patch...",
+		help:  "apply JSON merge patches to a document in order",
+		run:   runMerge,
+	},
+	"patch": {
+		usage: "patch [-from format] [-to format] base patch...",
+		help:  "apply JSON patches (RFC 6902) to a document in order",
+		run:   runPatch,
+	},
+	"diff": {
+		usage: "diff [-from format] a b",
+		help:  "print the differences between two documents, exiting 1 if there are any",
+		run:   runDiff,
+	},
+	"redact": {
+		usage: "redact -match regexp [-with replacement] [-path prefix]... [-from format] [-to format] [file]",
+		help:  "replace matching text in string values",
+		run:   runRedact,
+	},
+	"profile": {
+		usage: "profile [-from format] [file]",
+		help:  "summarize kinds, depth, keys and the largest subtrees of a document",
+		run:   runProfile,
+	},
+}
+
+// env holds the process streams so commands can be run in tests
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+// run executes the command line and returns the process exit status
+func run(args []string, e *env) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(e.stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "protobaggins: unknown command %q\n", args[0])
+		printUsage(e.stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: protobaggins %s\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+
+	err := cmd.run(e, fs, args[1:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errDiffer):
+		return 1
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	default:
+		fmt.Fprintf(e.stderr, "protobaggins %s: %v\n", args[0], err)
+		return 2
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: protobaggins <command> [flags] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+
+	for _, name := range slices.Sorted(maps.Keys(commands)) {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].help)
+	}
+	fmt.Fprintf(w, "\nformats: %v, chosen by file extension unless -from or -to is given\n", formats)
+	fmt.Fprintln(w, "files default to standard input when omitted or \"-\"")
+}
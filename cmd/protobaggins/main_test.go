@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCLI runs the command line with stdin and returns the exit status and output
+func runCLI(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = run(args, &env{stdin: strings.NewReader(stdin), stdout: &out, stderr: &errOut})
+	return code, out.String(), errOut.String()
+}
+
+// writeFile creates a file with the given contents in a temporary directory
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no arguments", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, "")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "usage: protobaggins")
+	})
+
+	t.Run("help lists commands", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, "", "help")
+		assert.Equal(t, 0, code)
+		for name := range commands {
+			assert.Contains(t, stderr, "  "+name)
+		}
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, "", "frobnicate")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, `unknown command "frobnicate"`)
+	})
+
+	t.Run("command help", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, "", "convert", "-h")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stderr, "usage: protobaggins convert")
+		assert.Contains(t, stderr, "-from")
+	})
+
+	t.Run("errors are reported", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := runCLI(t, "{", "convert")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "protobaggins convert: parsing stdin as json")
+	})
+}
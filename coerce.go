@@ -0,0 +1,126 @@
+package protobaggins
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The To* functions coerce scalar values across kinds for consumers that receive numbers
+// as strings and vice versa. Null, list and struct values are never coerced and return
+// ErrUnexpectedKind, values that have the right kind but cannot be represented return
+// ErrNotCoercible
+
+// ToInt64 coerces v to an int64:
+//   - numbers must be whole and within range, so 42.0 works but 42.5 does not
+//   - strings are parsed as base-10 integers or whole decimals, "42" and " 42.0 " give 42
+//   - true is 1 and false is 0
+func ToInt64(v *structpb.Value) (int64, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		return floatToInt64(kind.NumberValue)
+	case *structpb.Value_StringValue:
+		s := strings.TrimSpace(kind.StringValue)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q is not a number", ErrNotCoercible, kind.StringValue)
+		}
+		return floatToInt64(f)
+	case *structpb.Value_BoolValue:
+		if kind.BoolValue {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%w: cannot coerce %s to an integer", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+func floatToInt64(f float64) (int64, error) {
+	// float64(math.MaxInt64) rounds up to 2^63, which is already out of range
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %v is not an int64", ErrNotCoercible, f)
+	}
+	return int64(f), nil
+}
+
+// ToFloat64 coerces v to a float64:
+//   - numbers are returned as-is
+//   - strings are parsed with strconv.ParseFloat after trimming spaces, so "1e3" gives
+//     1000 and "NaN" or "Inf" give the special values
+//   - true is 1 and false is 0
+func ToFloat64(v *structpb.Value) (float64, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		return kind.NumberValue, nil
+	case *structpb.Value_StringValue:
+		f, err := strconv.ParseFloat(strings.TrimSpace(kind.StringValue), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q is not a number", ErrNotCoercible, kind.StringValue)
+		}
+		return f, nil
+	case *structpb.Value_BoolValue:
+		if kind.BoolValue {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%w: cannot coerce %s to a number", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+// ToBool coerces v to a bool:
+//   - bools are returned as-is
+//   - numbers are true unless zero, NaN is not coercible
+//   - strings accept the forms of strconv.ParseBool ("1", "t", "true", "0", "f", "false"
+//     in any case) as well as "yes", "no", "on" and "off", ignoring surrounding spaces
+func ToBool(v *structpb.Value) (bool, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return kind.BoolValue, nil
+	case *structpb.Value_NumberValue:
+		if math.IsNaN(kind.NumberValue) {
+			return false, fmt.Errorf("%w: NaN is not a bool", ErrNotCoercible)
+		}
+		return kind.NumberValue != 0, nil
+	case *structpb.Value_StringValue:
+		s := strings.ToLower(strings.TrimSpace(kind.StringValue))
+		switch s {
+		case "yes", "on":
+			return true, nil
+		case "no", "off":
+			return false, nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return false, fmt.Errorf("%w: %q is not a bool", ErrNotCoercible, kind.StringValue)
+		}
+		return b, nil
+	default:
+		return false, fmt.Errorf("%w: cannot coerce %s to a bool", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+// ToString coerces v to a string:
+//   - strings are returned as-is
+//   - numbers use the shortest decimal form without an exponent, 42 gives "42" and 0.5
+//     gives "0.5", while NaN and infinities give "NaN", "+Inf" and "-Inf"
+//   - bools give "true" or "false"
+func ToString(v *structpb.Value) (string, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return kind.StringValue, nil
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(kind.NumberValue, 'f', -1, 64), nil
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(kind.BoolValue), nil
+	default:
+		return "", fmt.Errorf("%w: cannot coerce %s to a string", ErrUnexpectedKind, KindOf(v))
+	}
+}
@@ -0,0 +1,167 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var uncoercibleKinds = []*structpb.Value{
+	nil,
+	structpb.NewNullValue(),
+	structpb.NewListValue(&structpb.ListValue{}),
+	structpb.NewStructValue(&structpb.Struct{}),
+}
+
+func TestToInt64(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    *structpb.Value
+		expected int64
+		err      error
+	}{
+		{"whole number", structpb.NewNumberValue(42), 42, nil},
+		{"negative", structpb.NewNumberValue(-7), -7, nil},
+		{"fraction", structpb.NewNumberValue(42.5), 0, ErrNotCoercible},
+		{"too large", structpb.NewNumberValue(1e19), 0, ErrNotCoercible},
+		{"NaN", structpb.NewNumberValue(math.NaN()), 0, ErrNotCoercible},
+		{"string", structpb.NewStringValue("42"), 42, nil},
+		{"padded decimal string", structpb.NewStringValue(" 42.0 "), 42, nil},
+		{"max int64 string", structpb.NewStringValue("9223372036854775807"), math.MaxInt64, nil},
+		{"fraction string", structpb.NewStringValue("4.2"), 0, ErrNotCoercible},
+		{"word", structpb.NewStringValue("many"), 0, ErrNotCoercible},
+		{"true", structpb.NewBoolValue(true), 1, nil},
+		{"false", structpb.NewBoolValue(false), 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ToInt64(tt.value)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	for _, v := range uncoercibleKinds {
+		_, err := ToInt64(v)
+		assert.ErrorIs(t, err, ErrUnexpectedKind, KindOf(v).String())
+	}
+}
+
+func TestToFloat64(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    *structpb.Value
+		expected float64
+		err      error
+	}{
+		{"number", structpb.NewNumberValue(1.5), 1.5, nil},
+		{"string", structpb.NewStringValue("1e3"), 1000, nil},
+		{"padded string", structpb.NewStringValue(" -2.5\n"), -2.5, nil},
+		{"infinity string", structpb.NewStringValue("Inf"), math.Inf(1), nil},
+		{"word", structpb.NewStringValue("lots"), 0, ErrNotCoercible},
+		{"true", structpb.NewBoolValue(true), 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ToFloat64(tt.value)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	for _, v := range uncoercibleKinds {
+		_, err := ToFloat64(v)
+		assert.ErrorIs(t, err, ErrUnexpectedKind, KindOf(v).String())
+	}
+}
+
+func TestToBool(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    *structpb.Value
+		expected bool
+		err      error
+	}{
+		{"bool", structpb.NewBoolValue(true), true, nil},
+		{"one", structpb.NewNumberValue(1), true, nil},
+		{"zero", structpb.NewNumberValue(0), false, nil},
+		{"negative", structpb.NewNumberValue(-3), true, nil},
+		{"NaN", structpb.NewNumberValue(math.NaN()), false, ErrNotCoercible},
+		{"TRUE", structpb.NewStringValue("TRUE"), true, nil},
+		{"0", structpb.NewStringValue("0"), false, nil},
+		{"yes", structpb.NewStringValue(" Yes "), true, nil},
+		{"off", structpb.NewStringValue("off"), false, nil},
+		{"maybe", structpb.NewStringValue("maybe"), false, ErrNotCoercible},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ToBool(tt.value)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	for _, v := range uncoercibleKinds {
+		_, err := ToBool(v)
+		assert.ErrorIs(t, err, ErrUnexpectedKind, KindOf(v).String())
+	}
+}
+
+func TestToString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    *structpb.Value
+		expected string
+	}{
+		{"string", structpb.NewStringValue("frodo"), "frodo"},
+		{"integer", structpb.NewNumberValue(42), "42"},
+		{"fraction", structpb.NewNumberValue(0.5), "0.5"},
+		{"large", structpb.NewNumberValue(1e21), "1000000000000000000000"},
+		{"NaN", structpb.NewNumberValue(math.NaN()), "NaN"},
+		{"negative infinity", structpb.NewNumberValue(math.Inf(-1)), "-Inf"},
+		{"true", structpb.NewBoolValue(true), "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ToString(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	for _, v := range uncoercibleKinds {
+		_, err := ToString(v)
+		assert.ErrorIs(t, err, ErrUnexpectedKind, KindOf(v).String())
+	}
+}
@@ -0,0 +1,86 @@
+package protobaggins
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// CompareValues returns -1, 0 or +1 depending on whether a sorts before, equal to or
+// after b, under a total order over all values:
+//
+//   - values of different kinds sort by kind: unset < null < bool < number < string < list < struct
+//   - false sorts before true
+//   - numbers sort numerically, NaN before every other number and -0 equal to +0
+//   - strings sort by their UTF-8 bytes
+//   - lists sort element by element, a list sorting before any longer list it is a prefix of
+//   - structs sort as lists of key/value pairs ordered by key, comparing keys before values
+//
+// Values comparing equal are equal under proto.Equal, except that a nil value equals an
+// empty one. It can be used with slices.SortFunc or to key ordered collections of values
+func CompareValues(a, b *structpb.Value) int {
+	if c := cmp.Compare(KindOf(a), KindOf(b)); c != 0 {
+		return c
+	}
+
+	switch a := a.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return compareBools(a.BoolValue, b.GetBoolValue())
+	case *structpb.Value_NumberValue:
+		return cmp.Compare(a.NumberValue, b.GetNumberValue())
+	case *structpb.Value_StringValue:
+		return strings.Compare(a.StringValue, b.GetStringValue())
+	case *structpb.Value_ListValue:
+		return compareLists(a.ListValue.GetValues(), b.GetListValue().GetValues())
+	case *structpb.Value_StructValue:
+		return CompareStructs(a.StructValue, b.GetStructValue())
+	default:
+		return 0
+	}
+}
+
+// CompareStructs orders Structs as CompareValues does
+func CompareStructs(a, b *structpb.Struct) int {
+	aKeys := sortedKeys(a)
+	bKeys := sortedKeys(b)
+	for i := range min(len(aKeys), len(bKeys)) {
+		if c := strings.Compare(aKeys[i], bKeys[i]); c != 0 {
+			return c
+		}
+		if c := CompareValues(a.GetFields()[aKeys[i]], b.GetFields()[bKeys[i]]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(aKeys), len(bKeys))
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+func compareLists(a, b []*structpb.Value) int {
+	for i := range min(len(a), len(b)) {
+		if c := CompareValues(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func sortedKeys(s *structpb.Struct) []string {
+	keys := make([]string, 0, len(s.GetFields()))
+	for k := range s.GetFields() {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,116 @@
+package protobaggins
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCompareValues(t *testing.T) {
+	t.Parallel()
+
+	list := func(values ...any) *structpb.Value {
+		v, err := structpb.NewValue(values)
+		require.NoError(t, err)
+		return v
+	}
+	object := func(m map[string]any) *structpb.Value {
+		v, err := structpb.NewValue(m)
+		require.NoError(t, err)
+		return v
+	}
+
+	// each value sorts strictly before the next
+	ordered := []*structpb.Value{
+		nil,
+		structpb.NewNullValue(),
+		structpb.NewBoolValue(false),
+		structpb.NewBoolValue(true),
+		structpb.NewNumberValue(math.NaN()),
+		structpb.NewNumberValue(math.Inf(-1)),
+		structpb.NewNumberValue(-1),
+		structpb.NewNumberValue(0),
+		structpb.NewNumberValue(2.5),
+		structpb.NewNumberValue(math.Inf(1)),
+		structpb.NewStringValue(""),
+		structpb.NewStringValue("B"),
+		structpb.NewStringValue("a"),
+		structpb.NewStringValue("ab"),
+		list(),
+		list(nil),
+		list(1),
+		list(1, "a"),
+		list(2),
+		list("a"),
+		object(map[string]any{}),
+		object(map[string]any{"a": 1}),
+		object(map[string]any{"a": 1, "b": 1}),
+		object(map[string]any{"a": 2}),
+		object(map[string]any{"b": 0}),
+	}
+
+	t.Run("ordering", func(t *testing.T) {
+		t.Parallel()
+		for i := range ordered {
+			for j := range ordered {
+				want := 0
+				if i < j {
+					want = -1
+				} else if i > j {
+					want = 1
+				}
+				assert.Equal(t, want, CompareValues(ordered[i], ordered[j]), "compare(%d, %d)", i, j)
+			}
+		}
+	})
+
+	t.Run("sorts heterogeneous lists", func(t *testing.T) {
+		t.Parallel()
+		shuffled := slices.Clone(ordered)
+		rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		slices.SortFunc(shuffled, CompareValues)
+		assert.Equal(t, ordered, shuffled)
+	})
+
+	t.Run("sorting makes hashes order independent", func(t *testing.T) {
+		t.Parallel()
+		a := list(map[string]any{"b": 1}, "x", nil, 3, true)
+		b := list(true, 3, "x", map[string]any{"b": 1}, nil)
+		require.NotEqual(t, Hash(a), Hash(b))
+		SortListFunc(a.GetListValue(), CompareValues)
+		SortListFunc(b.GetListValue(), CompareValues)
+		assert.Equal(t, Hash(a), Hash(b))
+	})
+
+	t.Run("equal values", func(t *testing.T) {
+		t.Parallel()
+		assert.Zero(t, CompareValues(structpb.NewNumberValue(math.Copysign(0, -1)), structpb.NewNumberValue(0)))
+		assert.Zero(t, CompareValues(structpb.NewNumberValue(math.NaN()), structpb.NewNumberValue(math.NaN())))
+		assert.Zero(t, CompareValues(&structpb.Value{}, nil))
+		assert.Zero(t, CompareValues(
+			object(map[string]any{"a": []any{1, map[string]any{"b": nil}}}),
+			object(map[string]any{"a": []any{1, map[string]any{"b": nil}}}),
+		))
+	})
+}
+
+func TestCompareStructs(t *testing.T) {
+	t.Parallel()
+
+	a, err := structpb.NewStruct(map[string]any{"a": 1})
+	require.NoError(t, err)
+	b, err := structpb.NewStruct(map[string]any{"a": 1, "b": 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, -1, CompareStructs(a, b))
+	assert.Equal(t, 1, CompareStructs(b, a))
+	assert.Equal(t, -1, CompareStructs(nil, a))
+	assert.Zero(t, CompareStructs(nil, &structpb.Struct{}))
+}
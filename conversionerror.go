@@ -0,0 +1,140 @@
+package protobaggins
+
+import (
+	"cmp"
+	"errors"
+	"reflect"
+	"slices"
+)
+
+// ConversionError reports a value that could not be converted to a protocol buffer
+// value. A conversion that fails at several places reports all of them, combined like
+// errors.Join does into an error with an Unwrap() []error method, in the order of the
+// input and with map keys sorted. Use errors.As to get the first one
+type ConversionError struct {
+	// Path locates the value within the converted one, e.g. "config.limits[3].burst",
+	// in the notation described at PathFilter. It is empty for the converted value itself
+	Path string
+	// Type is the Go type of the value, or nil when the failure concerns its key or
+	// the value is nil
+	Type reflect.Type
+	// Err is the cause of the failure
+	Err error
+}
+
+func (e *ConversionError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// pushKey descends into the map entry or struct field at key
+func (e *encoder) pushKey(key string) {
+	e.path = append(e.path, pathSegment{key: key, index: -1})
+}
+
+// pushIndex descends into the list item at index i
+func (e *encoder) pushIndex(i int) {
+	e.path = append(e.path, pathSegment{index: i})
+}
+
+func (e *encoder) pop() {
+	e.path = e.path[:len(e.path)-1]
+}
+
+// conversionError describes err as the failure of a value of type t at the current
+// path, unless it already describes the failures of nested values
+func (e *encoder) conversionError(t reflect.Type, err error) error {
+	var ce *ConversionError
+	if errors.As(err, &ce) {
+		return err
+	}
+
+	var path string
+	for _, seg := range e.path {
+		if seg.index >= 0 {
+			path = joinIndex(path, seg.index)
+		} else {
+			path = joinKey(path, seg.key)
+		}
+	}
+	return &ConversionError{Path: path, Type: t, Err: err}
+}
+
+// entryErrors collects the failures of the entries of a map, list or struct
+type entryErrors []entryError
+
+type entryError struct {
+	key string
+	err error
+}
+
+// add records err, the failure of the entry at key holding value, unless WithSkipErrors
+// drops the entry. It returns err if the conversion must stop because a resource limit
+// was exceeded or its context is done
+func (errs *entryErrors) add(e *encoder, key string, value any, err error) error {
+	switch {
+	case errors.Is(err, ErrTooLarge), e.ctx != nil && e.ctx.Err() != nil:
+		return err
+	case e.opts.skipErrors:
+		// entries are skipped where they fail, so err is at the entry's path
+		var path string
+		var ce *ConversionError
+		if errors.As(err, &ce) {
+			path = ce.Path
+		}
+		e.opts.skipped(path, value, err)
+	default:
+		*errs = append(*errs, entryError{key: key, err: err})
+	}
+	return nil
+}
+
+// join combines the failures, sorted by key when they were recorded in random order,
+// inlining those of nested containers
+func (errs entryErrors) join(sortByKey bool) error {
+	if sortByKey {
+		slices.SortStableFunc(errs, func(a, b entryError) int {
+			return cmp.Compare(a.key, b.key)
+		})
+	}
+	joined := &conversionErrors{}
+	for _, entry := range errs {
+		var nested *conversionErrors
+		if errors.As(entry.err, &nested) {
+			joined.errs = append(joined.errs, nested.errs...)
+		} else {
+			joined.errs = append(joined.errs, entry.err)
+		}
+	}
+	return joined
+}
+
+// conversionErrors holds the failures within a container, see ConversionError
+type conversionErrors struct {
+	errs []error
+}
+
+func (e *conversionErrors) Error() string {
+	return errors.Join(e.errs...).Error()
+}
+
+func (e *conversionErrors) Unwrap() []error {
+	return e.errs
+}
+
+// skipped reports the value at path, dropped because of err, to the WithOnSkip callback
+// and the Observer
+func (o *options) skipped(path string, value any, err error) {
+	if o.onSkip != nil {
+		o.onSkip(path, value, err)
+	}
+	if o.observer != nil {
+		o.observer.OnSkip(path, value, err)
+	}
+}
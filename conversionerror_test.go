@@ -0,0 +1,107 @@
+package protobaggins
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversionError(t *testing.T) {
+	t.Parallel()
+
+	collect := func(err error) []*ConversionError {
+		var joined interface{ Unwrap() []error }
+		var errs []error
+		if errors.As(err, &joined) {
+			errs = joined.Unwrap()
+		} else {
+			errs = []error{err}
+		}
+		result := make([]*ConversionError, 0, len(errs))
+		for _, err := range errs {
+			var ce *ConversionError
+			if errors.As(err, &ce) {
+				result = append(result, ce)
+			}
+		}
+		return result
+	}
+
+	t.Run("nested path and type", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(map[string]any{
+			"config": map[string]any{
+				"limits": []any{1, 2, 3, map[string]any{"burst": make(chan int)}},
+			},
+		})
+		require.Error(t, err)
+
+		var ce *ConversionError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "config.limits[3].burst", ce.Path)
+		assert.Equal(t, reflect.TypeFor[chan int](), ce.Type)
+		assert.Regexp(t, `^config\.limits\[3\]\.burst: proto:.invalid type: chan int$`, err.Error())
+	})
+
+	t.Run("every failure is reported", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(map[string]any{
+			"b":     []any{func() {}, "ok", make(chan int)},
+			"a":     map[string]any{"x.y": complex(1, 2)},
+			"ok":    1,
+			"z\xff": 1,
+		})
+		require.Error(t, err)
+
+		errs := collect(err)
+		paths := make([]string, len(errs))
+		for i, ce := range errs {
+			paths[i] = ce.Path
+		}
+		assert.Equal(t, []string{`a["x.y"]`, "b[0]", "b[2]", "z\xff"}, paths)
+		assert.Nil(t, errs[3].Type, "key failures have no type")
+		assert.Equal(t, reflect.TypeFor[complex128](), errs[0].Type)
+	})
+
+	t.Run("root value", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(make(chan int))
+		var ce *ConversionError
+		require.ErrorAs(t, err, &ce)
+		assert.Empty(t, ce.Path)
+		assert.Regexp(t, `^proto:.invalid type: chan int$`, err.Error())
+	})
+
+	t.Run("sentinels stay reachable", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(map[string]any{"a": map[string]any{"b": []any{}}}, WithMaxDepth(2))
+		require.ErrorIs(t, err, ErrMaxDepth)
+		var ce *ConversionError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "a.b", ce.Path)
+	})
+
+	t.Run("reflection", func(t *testing.T) {
+		t.Parallel()
+		type limit struct {
+			Burst any `json:"burst"`
+		}
+		type config struct {
+			Limits []limit          `json:"limits"`
+			ByName map[string]limit `json:"by_name"`
+		}
+		_, err := EncodeStruct(config{
+			Limits: []limit{{Burst: 1}, {Burst: make(chan int)}},
+			ByName: map[string]limit{"b": {Burst: func() {}}, "a": {Burst: make(chan bool)}},
+		})
+		errs := collect(err)
+		require.Len(t, errs, 3)
+		assert.Equal(t, "limits[1].burst", errs[0].Path)
+		assert.Equal(t, "by_name.a.burst", errs[1].Path)
+		assert.Equal(t, "by_name.b.burst", errs[2].Path)
+		assert.Equal(t, reflect.TypeFor[chan bool](), errs[1].Type)
+	})
+}
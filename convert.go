@@ -15,39 +15,69 @@ func ConvertProtoValueToInterface(v *structpb.Value) any {
 }
 
 // MapToStructValues converts a Go map[string]any to a map[string]*structpb.Value
-// Silently skips values that cannot be converted to protobuf values
-func MapToStructValues(m map[string]any) map[string]*structpb.Value {
+// Silently skips values that cannot be converted to protobuf values; use WithOnSkip to
+// observe them. The options configure the conversion as for MapToStructValuesE, so key
+// filters, key case, omission and limits apply to the keys of m too
+func MapToStructValues(m map[string]any, opts ...Option) map[string]*structpb.Value {
 	if m == nil {
 		return nil
 	}
 
+	e := newEncoder(newOptions(opts))
 	result := make(map[string]*structpb.Value, len(m))
 	for k, v := range m {
-		pbValue, err := structpb.NewValue(v)
-		if err == nil {
-			result[k] = pbValue
-		}
+		var errs entryErrors
+		err := e.encodeEntry(result, &errs, k, v)
+		e.skipEntry(joinKey("", k), v, errs, err)
 	}
 	return result
 }
 
 // SliceToStructValues converts a slice of any Go values to a slice of protocol buffer values
-// Silently skips values that cannot be converted to protobuf values
-func SliceToStructValues(values []any) []*structpb.Value {
+// Silently skips values that cannot be converted to protobuf values; use WithOnSkip to
+// observe them. The options configure the conversion as for SliceToStructValuesE
+func SliceToStructValues(values []any, opts ...Option) []*structpb.Value {
 	if values == nil {
 		return nil
 	}
 
+	e := newEncoder(newOptions(opts))
 	result := make([]*structpb.Value, 0, len(values))
-	for _, v := range values {
-		pbValue, err := structpb.NewValue(v)
-		if err == nil {
-			result = append(result, pbValue)
-		}
+	for i, v := range values {
+		var errs entryErrors
+		var err error
+		result, err = e.appendItem(result, &errs, i, v)
+		e.skipEntry(joinIndex("", i), v, errs, err)
 	}
 	return result
 }
 
+// skipEntry reports the top-level entry at path holding v as skipped by the lossy
+// conversions if it failed, having been recorded in errs or stopped the conversion
+// with err. Nested failures skip the whole entry unless WithSkipErrors drops them
+func (e *encoder) skipEntry(path string, v any, errs entryErrors, err error) {
+	if err == nil && len(errs) > 0 {
+		err = errs[0].err
+	}
+	if err != nil {
+		e.opts.skipped(path, v, err)
+	}
+}
+
+// MapToStructValuesE converts a Go map[string]any to a map[string]*structpb.Value like
+// MapToStructValues, but fails instead of skipping values that cannot be converted.
+// The error lists every failed key, in sorted order
+func MapToStructValuesE(m map[string]any, opts ...Option) (map[string]*structpb.Value, error) {
+	return NewConverter(opts...).MapToStructValues(m)
+}
+
+// SliceToStructValuesE converts a slice of Go values to protocol buffer values like
+// SliceToStructValues, but fails instead of skipping values that cannot be converted.
+// The error lists every failed index
+func SliceToStructValuesE(values []any, opts ...Option) ([]*structpb.Value, error) {
+	return NewConverter(opts...).SliceToStructValues(values)
+}
+
 // StringFromProto safely converts a protocol buffer string pointer to a Go string
 // Returns an empty string if the pointer is nil
 func StringFromProto(s *string) string {
@@ -63,17 +93,15 @@ func StringToProto(s string) *string {
 	return proto.String(s)
 }
 
-// StructValuesToMap converts a map[string]*structpb.Value to a Go map[string]any
-func StructValuesToMap(m map[string]*structpb.Value) map[string]any {
+// StructValuesToMap converts a map[string]*structpb.Value to a Go map[string]any,
+// honoring the decoding side of the given options
+func StructValuesToMap(m map[string]*structpb.Value, opts ...Option) map[string]any {
 	if m == nil {
 		return nil
 	}
 
-	result := make(map[string]any, len(m))
-	for k, v := range m {
-		result[k] = v.AsInterface()
-	}
-	return result
+	d := decoder{opts: newOptions(opts)}
+	return d.decodeStruct(&structpb.Struct{Fields: m})
 }
 
 // StructValuesToSlice converts a list of protocol buffer values to a slice of Go values
@@ -92,7 +120,7 @@ func StructValuesToSlice(values []*structpb.Value) []any {
 // TryNewStructValue creates a new *structpb.Value from a Go value
 // Returns nil if the value cannot be converted to a protocol buffer value
 func TryNewStructValue(v any) *structpb.Value {
-	pbValue, err := structpb.NewValue(v)
+	pbValue, err := NewValue(v)
 	if err != nil {
 		return nil
 	}
@@ -1,6 +1,9 @@
 package protobaggins
 
 import (
+	"encoding/json"
+	"math/big"
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -158,6 +161,31 @@ func TestMapToStructValues(t *testing.T) {
 		assert.Equal(t, "value", result["valid"].GetStringValue())
 		assert.NotContains(t, result, "invalid")
 	})
+
+	t.Run("options apply to top-level keys", func(t *testing.T) {
+		t.Parallel()
+		input := map[string]any{"UserName": "bilbo", "secret": "ring", "empty": "", "nested": map[string]any{"secret": "x"}}
+
+		result := MapToStructValues(input, WithKeyCase(KeyCaseSnake), WithDenyKeys("secret"), WithOmitEmpty())
+		assert.Equal(t, map[string]any{"user_name": "bilbo", "nested": map[string]any{}},
+			(&structpb.Struct{Fields: result}).AsMap())
+	})
+
+	t.Run("limits count top-level values", func(t *testing.T) {
+		t.Parallel()
+		var skipped []string
+		onSkip := WithOnSkip(func(path string, _ any, err error) {
+			assert.ErrorIs(t, err, ErrTooLarge)
+			skipped = append(skipped, path)
+		})
+
+		result := MapToStructValues(map[string]any{"a": 1, "b": 2, "c": 3}, WithMaxNodes(2), onSkip)
+		assert.Len(t, result, 2)
+		assert.Len(t, skipped, 1)
+
+		result = MapToStructValues(map[string]any{"a": "0123456789"}, WithMaxBytes(5))
+		assert.Empty(t, result)
+	})
 }
 
 func TestSliceToStructValues(t *testing.T) {
@@ -239,6 +267,82 @@ func TestSliceToStructValues(t *testing.T) {
 		assert.Equal(t, "valid", result[0].GetStringValue())
 		assert.InEpsilon(t, float64(42), result[1].GetNumberValue(), 0.001)
 	})
+
+	t.Run("limits count top-level values", func(t *testing.T) {
+		t.Parallel()
+		var skipped []string
+		result := SliceToStructValues([]any{1, 2, 3}, WithMaxNodes(2), WithOnSkip(func(path string, _ any, err error) {
+			assert.ErrorIs(t, err, ErrTooLarge)
+			skipped = append(skipped, path)
+		}))
+		assert.Len(t, result, 2)
+		assert.Equal(t, []string{"[2]"}, skipped)
+	})
+}
+
+func TestMapToStructValuesE(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil map", func(t *testing.T) {
+		t.Parallel()
+		result, err := MapToStructValuesE(nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("all valid", func(t *testing.T) {
+		t.Parallel()
+		result, err := MapToStructValuesE(map[string]any{"string": "value", "list": []any{1, nil}})
+		require.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, "value", result["string"].GetStringValue())
+	})
+
+	t.Run("reports every failed key", func(t *testing.T) {
+		t.Parallel()
+		result, err := MapToStructValuesE(map[string]any{
+			"valid":  "value",
+			"second": func() {},
+			"first":  make(chan int),
+		})
+		require.Error(t, err)
+		assert.Nil(t, result)
+		assert.Regexp(t, `^first: .+\nsecond: .+$`, err.Error())
+	})
+
+	t.Run("options are applied", func(t *testing.T) {
+		t.Parallel()
+		result, err := MapToStructValuesE(map[string]any{"data": []byte("hi")}, WithTaggedBytes())
+		require.NoError(t, err)
+		assert.NotNil(t, result["data"].GetStructValue().GetFields()[TaggedBytesKey])
+	})
+}
+
+func TestSliceToStructValuesE(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil slice", func(t *testing.T) {
+		t.Parallel()
+		result, err := SliceToStructValuesE(nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("all valid", func(t *testing.T) {
+		t.Parallel()
+		result, err := SliceToStructValuesE([]any{"a", 1, true})
+		require.NoError(t, err)
+		assert.Len(t, result, 3)
+		assert.True(t, result[2].GetBoolValue())
+	})
+
+	t.Run("reports every failed index", func(t *testing.T) {
+		t.Parallel()
+		result, err := SliceToStructValuesE([]any{"ok", make(chan int), 1, func() {}})
+		require.Error(t, err)
+		assert.Nil(t, result)
+		assert.Regexp(t, `^\[1\]: .+\n\[3\]: .+$`, err.Error())
+	})
 }
 
 func TestStringFromProto(t *testing.T) {
@@ -478,4 +582,25 @@ func TestTryNewStructValue(t *testing.T) {
 		result := TryNewStructValue(unconvertible{Field: "test"})
 		assert.Nil(t, result)
 	})
+
+	t.Run("json and text marshalers", func(t *testing.T) {
+		t.Parallel()
+
+		ip := net.ParseIP("192.0.2.1")
+		assert.Equal(t, "192.0.2.1", TryNewStructValue(ip).GetStringValue())
+		assert.Equal(t, KindNull, KindOf(TryNewStructValue((*big.Rat)(nil))))
+
+		raw := TryNewStructValue(json.RawMessage(`{"a":[1,true]}`))
+		assert.Equal(t, map[string]any{"a": []any{1.0, true}}, raw.AsInterface())
+
+		values := MapToStructValues(map[string]any{
+			"ips":     []any{ip},
+			"invalid": json.RawMessage(`{`),
+		})
+		assert.Equal(t, []any{"192.0.2.1"}, values["ips"].AsInterface())
+		assert.NotContains(t, values, "invalid")
+
+		_, err := NewValue(json.RawMessage(`{`))
+		require.ErrorContains(t, err, "invalid JSON from MarshalJSON")
+	})
 }
@@ -0,0 +1,166 @@
+package protobaggins
+
+import (
+	"context"
+	"reflect"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Converter converts between Go and protocol buffer values under a fixed policy, so that
+// per-call behavior does not require a variant of every function. By default it is
+// strict: any value that cannot be converted fails the call. The zero value is usable
+// and equivalent to NewConverter(), except that it does not reuse its scratch state
+// between calls. A Converter is safe for concurrent use
+type Converter struct {
+	opts options
+	pool *encoderPool
+}
+
+// NewConverter returns a Converter configured by opts, e.g.
+//
+//	c := NewConverter(WithSkipErrors(), WithOmitNulls(), WithMaxDepth(32))
+func NewConverter(opts ...Option) *Converter {
+	return &Converter{opts: newOptions(opts), pool: &encoderPool{}}
+}
+
+// NewValue converts a Go value to a *structpb.Value, see the package-level NewValue
+func (c *Converter) NewValue(v any) (*structpb.Value, error) {
+	return c.newValue(context.Background(), v)
+}
+
+// newValue is NewValue checking ctx, see NewValueCtx
+func (c *Converter) newValue(ctx context.Context, v any) (*structpb.Value, error) {
+	e := c.pool.get(c.opts)
+	defer c.pool.put(e)
+	e.setContext(ctx)
+	start := c.opts.observeStart()
+	pbValue, err := e.encodeRoot(v)
+	c.opts.observe(start, DirectionToProto, KindOf(pbValue), e.nodes, err)
+	return pbValue, err
+}
+
+// NewStruct converts a Go map to a *structpb.Struct
+func (c *Converter) NewStruct(m map[string]any) (*structpb.Struct, error) {
+	return c.newStruct(context.Background(), m)
+}
+
+func (c *Converter) newStruct(ctx context.Context, m map[string]any) (*structpb.Struct, error) {
+	if m == nil {
+		return &structpb.Struct{Fields: map[string]*structpb.Value{}}, nil
+	}
+	v, err := c.newValue(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	return v.GetStructValue(), nil
+}
+
+// MapToStructValues converts a Go map to a map of protocol buffer values. Unless the
+// Converter skips errors, the error lists every value that failed, as *ConversionError
+// values sorted by key
+func (c *Converter) MapToStructValues(m map[string]any) (map[string]*structpb.Value, error) {
+	return c.mapToStructValues(context.Background(), m)
+}
+
+func (c *Converter) mapToStructValues(ctx context.Context, m map[string]any) (map[string]*structpb.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	result, err := c.mapToStructValuesInto(ctx, make(map[string]*structpb.Value, len(m)), m)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MapToStructValuesInto is MapToStructValues storing the values in dst, which is
+// cleared first, to reuse a map across calls. The values previously in dst are not
+// reused, so they stay valid wherever else they are referenced. Returns dst, whose
+// contents are unspecified on failure
+func (c *Converter) MapToStructValuesInto(dst map[string]*structpb.Value, m map[string]any) (map[string]*structpb.Value, error) {
+	return c.mapToStructValuesInto(context.Background(), dst, m)
+}
+
+func (c *Converter) mapToStructValuesInto(ctx context.Context, dst map[string]*structpb.Value, m map[string]any) (_ map[string]*structpb.Value, err error) {
+	clear(dst)
+	if dst == nil {
+		dst = make(map[string]*structpb.Value, len(m))
+	}
+
+	e := c.pool.get(c.opts)
+	defer c.pool.put(e)
+	e.setContext(ctx)
+	start := c.opts.observeStart()
+	defer func() { c.opts.observe(start, DirectionToProto, KindStruct, e.nodes, err) }()
+	if err := e.enter(reflect.ValueOf(m)); err != nil {
+		return dst, e.conversionError(reflect.TypeOf(m), err)
+	}
+	if _, err := e.encodeFieldsInto(dst, m); err != nil {
+		return dst, err
+	}
+	return dst, nil
+}
+
+// SliceToStructValues converts a slice of Go values to protocol buffer values. Unless
+// the Converter skips errors, the error lists every value that failed, as
+// *ConversionError values in order
+func (c *Converter) SliceToStructValues(values []any) ([]*structpb.Value, error) {
+	return c.sliceToStructValues(context.Background(), values)
+}
+
+func (c *Converter) sliceToStructValues(ctx context.Context, values []any) ([]*structpb.Value, error) {
+	if values == nil {
+		return nil, nil
+	}
+	result, err := c.appendStructValues(ctx, make([]*structpb.Value, 0, len(values)), values)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AppendStructValues is SliceToStructValues appending the values to dst, to reuse a
+// slice across calls with AppendStructValues(dst[:0], values). Like append, it returns
+// the extended slice, and on failure returns dst unchanged, although the part of its
+// array past its length may have been overwritten
+func (c *Converter) AppendStructValues(dst []*structpb.Value, values []any) ([]*structpb.Value, error) {
+	return c.appendStructValues(context.Background(), dst, values)
+}
+
+func (c *Converter) appendStructValues(ctx context.Context, dst []*structpb.Value, values []any) (_ []*structpb.Value, err error) {
+	e := c.pool.get(c.opts)
+	defer c.pool.put(e)
+	e.setContext(ctx)
+	start := c.opts.observeStart()
+	defer func() { c.opts.observe(start, DirectionToProto, KindList, e.nodes, err) }()
+	if err := e.enter(reflect.ValueOf(values)); err != nil {
+		return dst, e.conversionError(reflect.TypeOf(values), err)
+	}
+	result, err := e.appendItems(dst, values)
+	if err != nil {
+		return dst, err
+	}
+	return result, nil
+}
+
+// ToInterface converts a *structpb.Value to a Go value, see ValueToInterface
+func (c *Converter) ToInterface(v *structpb.Value) any {
+	d := decoder{opts: c.opts}
+	start := c.opts.observeStart()
+	result := d.decode(v)
+	c.opts.observe(start, DirectionFromProto, KindOf(v), d.nodes, nil)
+	return result
+}
+
+// StructToMap converts a *structpb.Struct to a Go map
+func (c *Converter) StructToMap(s *structpb.Struct) map[string]any {
+	if s == nil {
+		return nil
+	}
+	d := decoder{opts: c.opts}
+	start := c.opts.observeStart()
+	result := d.decodeStruct(s)
+	c.opts.observe(start, DirectionFromProto, KindStruct, d.nodes, nil)
+	return result
+}
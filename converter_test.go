@@ -0,0 +1,215 @@
+package protobaggins
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestConverter(t *testing.T) {
+	t.Parallel()
+
+	input := func() map[string]any {
+		return map[string]any{
+			"name":    "frodo",
+			"nothing": nil,
+			"_secret": "ring",
+			"bad":     make(chan int),
+			"nested": map[string]any{
+				"bad":     func() {},
+				"list":    []any{1, nil, make(chan int), "x"},
+				"_hidden": true,
+			},
+		}
+	}
+
+	t.Run("strict by default", func(t *testing.T) {
+		t.Parallel()
+		var zero Converter
+		_, err := zero.NewValue(input())
+		require.Error(t, err)
+		_, err = NewConverter().NewStruct(input())
+		require.Error(t, err)
+	})
+
+	t.Run("skip errors recursively", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewConverter(WithSkipErrors()).NewStruct(input())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":    "frodo",
+			"nothing": nil,
+			"_secret": "ring",
+			"nested": map[string]any{
+				"list":    []any{float64(1), nil, "x"},
+				"_hidden": true,
+			},
+		}, s.AsMap())
+	})
+
+	t.Run("omit nulls and filter keys", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(
+			WithSkipErrors(),
+			WithOmitNulls(),
+			WithKeyFilter(func(key string) bool { return !strings.HasPrefix(key, "_") }),
+		)
+		s, err := c.NewStruct(input())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":   "frodo",
+			"nested": map[string]any{"list": []any{float64(1), nil, "x"}},
+		}, s.AsMap())
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		t.Parallel()
+		deep := map[string]any{"a": map[string]any{"b": []any{map[string]any{"c": 1}}}}
+
+		_, err := NewConverter(WithMaxDepth(4)).NewValue(deep)
+		require.NoError(t, err)
+
+		_, err = NewConverter(WithMaxDepth(3)).NewValue(deep)
+		require.ErrorIs(t, err, ErrMaxDepth)
+
+		v, err := NewConverter(WithMaxDepth(3), WithSkipErrors()).NewValue(deep)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": map[string]any{"b": []any{}}}, v.AsInterface())
+	})
+
+	t.Run("map and slice helpers", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithMaxDepth(1))
+		_, err := c.MapToStructValues(map[string]any{"flat": 1, "deep": map[string]any{}})
+		require.ErrorIs(t, err, ErrMaxDepth)
+		assert.Contains(t, err.Error(), "deep: ")
+
+		values, err := NewConverter(WithSkipErrors()).SliceToStructValues([]any{1, make(chan int), "x"})
+		require.NoError(t, err)
+		assert.Len(t, values, 2)
+	})
+
+	t.Run("decoding honors the policy", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"name":    "frodo",
+			"nothing": nil,
+			"_secret": "ring",
+			"list":    []any{nil, map[string]any{"_x": 1, "y": nil}},
+		})
+		require.NoError(t, err)
+
+		c := NewConverter(WithOmitNulls(), WithKeyFilter(func(key string) bool { return !strings.HasPrefix(key, "_") }))
+		assert.Equal(t, map[string]any{
+			"name": "frodo",
+			"list": []any{nil, map[string]any{}},
+		}, c.StructToMap(s))
+		assert.Equal(t, "frodo", c.ToInterface(s.GetFields()["name"]))
+		assert.Nil(t, c.StructToMap(nil))
+	})
+
+	t.Run("nil map", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewConverter().NewStruct(nil)
+		require.NoError(t, err)
+		assert.Empty(t, s.GetFields())
+	})
+}
+
+func TestWithOmitEmpty(t *testing.T) {
+	t.Parallel()
+
+	type inner struct {
+		Note  string
+		Any   any
+		Items []int
+	}
+	var nilPtr *inner
+	in := map[string]any{
+		"name":    "frodo",
+		"empty":   "",
+		"zero":    0,
+		"zeroF":   0.0,
+		"false":   false,
+		"nil":     nil,
+		"nilPtr":  nilPtr,
+		"list":    []any{},
+		"map":     map[string]any{},
+		"strings": []string{},
+		"kept":    []any{0, "", false, nil},
+		"nested":  map[string]any{"a": "", "b": 1},
+		"struct":  inner{Any: "", Items: []int{}},
+	}
+
+	v, err := NewValue(in, WithOmitEmpty(), WithReflection())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":   "frodo",
+		"kept":   []any{0.0, "", false, nil},
+		"nested": map[string]any{"b": 1.0},
+		"struct": map[string]any{},
+	}, v.AsInterface())
+
+	values, err := MapToStructValuesE(map[string]any{"a": "", "b": "x"}, WithOmitEmpty())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"b": "x"}, StructValuesToMap(values))
+
+	v, err = NewValue(map[string]any{"a": ""})
+	require.NoError(t, err)
+	assert.Contains(t, v.GetStructValue().GetFields(), "a", "off by default")
+}
+
+func TestWithOnSkip(t *testing.T) {
+	t.Parallel()
+
+	type skip struct {
+		path  string
+		value any
+	}
+	record := func(skips *[]skip) Option {
+		return WithOnSkip(func(path string, value any, err error) {
+			assert.Error(t, err)
+			*skips = append(*skips, skip{path, value})
+		})
+	}
+	ch := make(chan int)
+
+	t.Run("lossy functions", func(t *testing.T) {
+		t.Parallel()
+		var skips []skip
+		values := MapToStructValues(map[string]any{"ok": 1, "bad": ch}, record(&skips))
+		assert.Len(t, values, 1)
+		assert.Equal(t, []skip{{"bad", ch}}, skips)
+
+		skips = nil
+		list := SliceToStructValues([]any{1, map[string]any{"nested": ch}}, record(&skips))
+		assert.Len(t, list, 1)
+		require.Len(t, skips, 1)
+		assert.Equal(t, "[1]", skips[0].path)
+
+		assert.Empty(t, MapToStructValues(map[string]any{"bad": ch}), "no hook by default")
+	})
+
+	t.Run("skip errors", func(t *testing.T) {
+		t.Parallel()
+		var skips []skip
+		v, err := NewValue(map[string]any{
+			"a": []any{1, ch},
+			"b": map[string]any{"c": ch, "d": "x"},
+		}, WithSkipErrors(), record(&skips))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": []any{1.0}, "b": map[string]any{"d": "x"}}, v.AsInterface())
+		assert.ElementsMatch(t, []skip{{"a[1]", ch}, {"b.c", ch}}, skips)
+	})
+
+	t.Run("strict conversions do not call it", func(t *testing.T) {
+		t.Parallel()
+		var skips []skip
+		_, err := NewValue(map[string]any{"a": ch}, record(&skips))
+		require.Error(t, err)
+		assert.Empty(t, skips)
+	})
+}
@@ -0,0 +1,80 @@
+package protobaggins
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ctxCheckInterval is the number of values converted between checks of the context
+const ctxCheckInterval = 1024
+
+// NewValueCtx is NewValue aborting with ctx.Err() once ctx is done, which is checked
+// before converting and then every 1024 values inside maps and lists, so that large
+// inputs stop converting soon after their request is canceled. The error is never
+// skipped by WithSkipErrors
+func (c *Converter) NewValueCtx(ctx context.Context, v any) (*structpb.Value, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	pbValue, err := c.newValue(ctx, v)
+	if err := contextError(ctx, err); err != nil {
+		return nil, err
+	}
+	return pbValue, nil
+}
+
+// NewStructCtx is NewStruct aborting once ctx is done, see NewValueCtx
+func (c *Converter) NewStructCtx(ctx context.Context, m map[string]any) (*structpb.Struct, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s, err := c.newStruct(ctx, m)
+	if err := contextError(ctx, err); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// MapToStructValuesCtx is MapToStructValues aborting once ctx is done, see NewValueCtx
+func (c *Converter) MapToStructValuesCtx(ctx context.Context, m map[string]any) (map[string]*structpb.Value, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result, err := c.mapToStructValues(ctx, m)
+	if err := contextError(ctx, err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SliceToStructValuesCtx is SliceToStructValues aborting once ctx is done, see
+// NewValueCtx
+func (c *Converter) SliceToStructValuesCtx(ctx context.Context, values []any) ([]*structpb.Value, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result, err := c.sliceToStructValues(ctx, values)
+	if err := contextError(ctx, err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setContext makes e check ctx while converting, unless ctx can never be done, such as
+// context.Background(), so that conversions without a deadline skip the checks
+func (e *encoder) setContext(ctx context.Context) {
+	e.ctx = nil
+	if ctx.Done() != nil {
+		e.ctx = ctx
+	}
+}
+
+// contextError returns ctx.Err() instead of err if the conversion failed because ctx
+// is done
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
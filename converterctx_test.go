@@ -0,0 +1,88 @@
+package protobaggins
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cancelAfter is a context canceled once Err has been called n times, to cancel a
+// conversion midway
+type cancelAfter struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestConverterCtx(t *testing.T) {
+	t.Parallel()
+
+	large := make(map[string]any, 5000)
+	for i := range 5000 {
+		large[strconv.Itoa(i)] = []any{i, "x", map[string]any{"n": i}}
+	}
+	items := make([]any, 5000)
+	for i := range items {
+		items[i] = map[string]any{"n": i}
+	}
+
+	t.Run("completes", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter()
+		m, err := c.MapToStructValuesCtx(t.Context(), large)
+		require.NoError(t, err)
+		assert.Len(t, m, 5000)
+
+		s, err := c.NewStructCtx(t.Context(), map[string]any{"a": 1})
+		require.NoError(t, err)
+		assert.Len(t, s.GetFields(), 1)
+
+		l, err := c.SliceToStructValuesCtx(t.Context(), items)
+		require.NoError(t, err)
+		assert.Len(t, l, 5000)
+	})
+
+	t.Run("already canceled", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		_, err := NewConverter().NewValueCtx(ctx, 1)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("canceled midway", func(t *testing.T) {
+		t.Parallel()
+		for _, c := range []*Converter{NewConverter(), NewConverter(WithSkipErrors()), {}} {
+			ctx := &cancelAfter{Context: t.Context(), n: 2}
+			m, err := c.MapToStructValuesCtx(ctx, large)
+			require.ErrorIs(t, err, context.Canceled)
+			assert.Nil(t, m)
+			assert.Negative(t, ctx.n, "checked while converting")
+
+			ctx = &cancelAfter{Context: t.Context(), n: 2}
+			_, err = c.SliceToStructValuesCtx(ctx, items)
+			require.Equal(t, context.Canceled, err)
+
+			ctx = &cancelAfter{Context: t.Context(), n: 2}
+			_, err = c.NewValueCtx(ctx, map[string]any{"items": items})
+			require.Equal(t, context.Canceled, err)
+		}
+	})
+
+	t.Run("other errors are kept", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewConverter().NewValueCtx(t.Context(), map[string]any{"ch": make(chan int)})
+		var ce *ConversionError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "ch", ce.Path)
+	})
+}
@@ -0,0 +1,145 @@
+package protobaggins
+
+import (
+	"maps"
+	"slices"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// COWStruct is a copy-on-write Struct: it shares a Struct with other readers and only
+// copies the Structs and lists along the paths it modifies, leaving the rest shared.
+// The shared Struct must not be modified while COWStructs use it. A COWStruct is not
+// safe for concurrent use, but any number of them may share a Struct across goroutines
+type COWStruct struct {
+	root *structpb.Struct
+	// owned holds the copied Structs and lists, which are modified in place
+	owned map[any]struct{}
+}
+
+// NewCOWStruct returns a COWStruct sharing s, which may be nil
+func NewCOWStruct(s *structpb.Struct) *COWStruct {
+	if s == nil {
+		s = &structpb.Struct{}
+	}
+	return &COWStruct{root: s, owned: make(map[any]struct{})}
+}
+
+// Struct returns the current Struct, sharing unmodified values with the original.
+// It must not be modified, use Fork to hand it out and keep modifying it
+func (c *COWStruct) Struct() *structpb.Struct {
+	return c.root
+}
+
+// Get returns the value at path, see LookupPath. It must not be modified
+func (c *COWStruct) Get(path string) (*structpb.Value, error) {
+	return LookupPath(structpb.NewStructValue(c.root), path)
+}
+
+// Fork returns a COWStruct sharing the current Struct. Both copy what they modify from
+// then on, so neither sees the changes of the other
+func (c *COWStruct) Fork() *COWStruct {
+	clear(c.owned)
+	return NewCOWStruct(c.root)
+}
+
+// Set sets the value at path, copying the Structs and lists along it first, see SetPath
+func (c *COWStruct) Set(path string, value any, opts ...SetPathOption) error {
+	segments, err := parsePath(path)
+	if err != nil || len(segments) == 0 {
+		return SetPath(c.root, path, value, opts...)
+	}
+	c.own(segments)
+	if err := SetPath(c.root, path, value, opts...); err != nil {
+		return err
+	}
+	// SetPath only descends into owned containers and creates the missing ones
+	c.markOwned(segments)
+	return nil
+}
+
+// Delete removes the value at path, copying the Structs and lists along it first, see
+// DeletePath. Reports whether a value was removed
+func (c *COWStruct) Delete(path string) bool {
+	segments, err := parsePath(path)
+	if err != nil || len(segments) == 0 {
+		return false
+	}
+	if _, err := c.Get(path); err != nil {
+		return false
+	}
+	c.own(segments)
+	return DeletePath(c.root, path)
+}
+
+// own copies the root and the existing containers holding the values along segments,
+// unless they were copied already
+func (c *COWStruct) own(segments []pathSegment) {
+	if _, ok := c.owned[c.root]; !ok {
+		c.root = &structpb.Struct{Fields: maps.Clone(c.root.GetFields())}
+		c.owned[c.root] = struct{}{}
+	}
+
+	container := structpb.NewStructValue(c.root)
+	for _, seg := range segments[:len(segments)-1] {
+		var child *structpb.Value
+		if seg.index >= 0 {
+			items := container.GetListValue().GetValues()
+			if seg.index >= len(items) {
+				return
+			}
+			child = c.ownChild(items[seg.index])
+			items[seg.index] = child
+		} else {
+			fields := container.GetStructValue().GetFields()
+			next, ok := fields[seg.key]
+			if !ok {
+				return
+			}
+			child = c.ownChild(next)
+			fields[seg.key] = child
+		}
+		container = child
+	}
+}
+
+// ownChild returns v if it holds an owned container, a new Value holding a copy of its
+// container otherwise, and v itself if it holds no container
+func (c *COWStruct) ownChild(v *structpb.Value) *structpb.Value {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		if _, ok := c.owned[kind.StructValue]; ok {
+			return v
+		}
+		s := &structpb.Struct{Fields: maps.Clone(kind.StructValue.GetFields())}
+		c.owned[s] = struct{}{}
+		return structpb.NewStructValue(s)
+	case *structpb.Value_ListValue:
+		if _, ok := c.owned[kind.ListValue]; ok {
+			return v
+		}
+		l := &structpb.ListValue{Values: slices.Clone(kind.ListValue.GetValues())}
+		c.owned[l] = struct{}{}
+		return structpb.NewListValue(l)
+	default:
+		return v
+	}
+}
+
+// markOwned records the containers holding the values along segments as owned
+func (c *COWStruct) markOwned(segments []pathSegment) {
+	container := structpb.NewStructValue(c.root)
+	for _, seg := range segments[:len(segments)-1] {
+		if seg.index >= 0 {
+			container = container.GetListValue().GetValues()[seg.index]
+		} else {
+			container = container.GetStructValue().GetFields()[seg.key]
+		}
+		switch kind := container.GetKind().(type) {
+		case *structpb.Value_StructValue:
+			c.owned[kind.StructValue] = struct{}{}
+		case *structpb.Value_ListValue:
+			c.owned[kind.ListValue] = struct{}{}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package protobaggins
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCOWStruct(t *testing.T) {
+	t.Parallel()
+
+	newShared := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(map[string]any{
+			"server":    map[string]any{"host": "shire", "port": 8080},
+			"listeners": []any{map[string]any{"port": 80}, map[string]any{"port": 443}},
+			"limits":    map[string]any{"rps": 100},
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("set copies the touched branch only", func(t *testing.T) {
+		t.Parallel()
+		shared := newShared(t)
+		original := proto.Clone(shared)
+
+		c := NewCOWStruct(shared)
+		require.NoError(t, c.Set("server.port", 9090))
+		require.NoError(t, c.Set("listeners[1].port", 8443))
+		require.NoError(t, c.Set("server.tls.enabled", true))
+		require.NoError(t, c.Set("server.port", 9091))
+
+		assert.True(t, proto.Equal(original, shared), "the shared Struct is unchanged")
+		got := c.Struct()
+		assert.NotSame(t, shared, got)
+		assert.Same(t, shared.GetFields()["limits"], got.GetFields()["limits"], "untouched branches are shared")
+		assert.Same(t, shared.GetFields()["listeners"].GetListValue().GetValues()[0],
+			got.GetFields()["listeners"].GetListValue().GetValues()[0])
+
+		port, err := c.Get("server.port")
+		require.NoError(t, err)
+		assert.InDelta(t, 9091.0, port.GetNumberValue(), 0)
+		tls, err := c.Get("server.tls.enabled")
+		require.NoError(t, err)
+		assert.True(t, tls.GetBoolValue())
+		port, err = c.Get("listeners[1].port")
+		require.NoError(t, err)
+		assert.InDelta(t, 8443.0, port.GetNumberValue(), 0)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+		shared := newShared(t)
+		original := proto.Clone(shared)
+
+		c := NewCOWStruct(shared)
+		assert.True(t, c.Delete("listeners[0]"))
+		assert.True(t, c.Delete("server.host"))
+		assert.False(t, c.Delete("server.missing"))
+		assert.False(t, c.Delete(""))
+
+		assert.True(t, proto.Equal(original, shared))
+		assert.Len(t, c.Struct().GetFields()["listeners"].GetListValue().GetValues(), 1)
+		assert.NotContains(t, c.Struct().GetFields()["server"].GetStructValue().GetFields(), "host")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		c := NewCOWStruct(newShared(t))
+		require.Error(t, c.Set("", 1))
+		require.ErrorIs(t, c.Set("server.port.number", 1), ErrUnexpectedKind)
+		require.ErrorIs(t, c.Set("listeners[5].port", 1, StrictPath()), ErrPathNotFound)
+	})
+
+	t.Run("fork", func(t *testing.T) {
+		t.Parallel()
+		c := NewCOWStruct(nil)
+		require.NoError(t, c.Set("a.b", 1))
+		fork := c.Fork()
+		require.NoError(t, c.Set("a.b", 2))
+		require.NoError(t, fork.Set("a.c", 3))
+
+		b, err := fork.Get("a.b")
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, b.GetNumberValue(), 0)
+		_, err = c.Get("a.c")
+		require.ErrorIs(t, err, ErrPathNotFound)
+	})
+
+	t.Run("concurrent copies", func(t *testing.T) {
+		t.Parallel()
+		shared := newShared(t)
+		original := proto.Clone(shared)
+
+		var wg sync.WaitGroup
+		for i := range 16 {
+			wg.Go(func() {
+				c := NewCOWStruct(shared)
+				assert.NoError(t, c.Set("server.port", i))
+				assert.NoError(t, c.Set("listeners[0].port", i))
+				port, _ := GetNumberPath(structpb.NewStructValue(c.Struct()), "listeners[0].port")
+				assert.InDelta(t, float64(i), port, 0)
+			})
+		}
+		wg.Wait()
+		assert.True(t, proto.Equal(original, shared))
+	})
+}
@@ -0,0 +1,35 @@
+package protobaggins
+
+import "reflect"
+
+// startDetectingCyclesAfter is the nesting depth from which the encoder tracks the
+// containers it is inside of. Cycles are rare and every cycle eventually nests this
+// deep, so shallow values skip the bookkeeping, as in encoding/json
+const startDetectingCyclesAfter = 100
+
+// cycleKey identifies a container being encoded. Slices also need their length, since
+// a slice and its prefix share the same pointer, and the type tells a struct apart from
+// its first field
+type cycleKey struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// newCycleKey reports false for containers that cannot take part in a cycle
+func newCycleKey(rv reflect.Value) (cycleKey, bool) {
+	switch rv.Kind() {
+	case reflect.Map:
+		return cycleKey{typ: rv.Type(), ptr: rv.Pointer()}, !rv.IsNil()
+	case reflect.Slice:
+		return cycleKey{typ: rv.Type(), ptr: rv.Pointer(), len: rv.Len()}, !rv.IsNil()
+	case reflect.Struct, reflect.Array:
+		// only values reached through a pointer can be reached again
+		if !rv.CanAddr() {
+			return cycleKey{}, false
+		}
+		return cycleKey{typ: rv.Type(), ptr: rv.Addr().Pointer()}, true
+	default:
+		return cycleKey{}, false
+	}
+}
@@ -0,0 +1,78 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cycleNode struct {
+	Name string
+	Next *cycleNode
+}
+
+func TestCycleDetection(t *testing.T) {
+	t.Parallel()
+
+	t.Run("map containing itself", func(t *testing.T) {
+		t.Parallel()
+		m := map[string]any{"name": "loop"}
+		m["self"] = map[string]any{"inner": m}
+
+		_, err := NewValue(m)
+		require.ErrorIs(t, err, ErrCycle)
+		assert.Contains(t, err.Error(), "map[string]interface {} contains itself")
+
+		assert.NotContains(t, MapToStructValues(m), "self")
+		_, err = MapToStructValuesE(m)
+		require.ErrorIs(t, err, ErrCycle)
+	})
+
+	t.Run("slice containing itself", func(t *testing.T) {
+		t.Parallel()
+		s := []any{1, nil}
+		s[1] = s
+
+		_, err := NewValue(s)
+		require.ErrorIs(t, err, ErrCycle)
+
+		v, err := NewValue(s, WithSkipErrors())
+		require.NoError(t, err)
+		assert.Equal(t, KindList, KindOf(v))
+	})
+
+	t.Run("struct pointers", func(t *testing.T) {
+		t.Parallel()
+		a := &cycleNode{Name: "a"}
+		a.Next = &cycleNode{Name: "b", Next: a}
+
+		_, err := EncodeStruct(a)
+		require.ErrorIs(t, err, ErrCycle)
+		_, err = NewValue(map[string]any{"list": a}, WithReflection())
+		require.ErrorIs(t, err, ErrCycle)
+	})
+
+	t.Run("shared values are not cycles", func(t *testing.T) {
+		t.Parallel()
+		shared := map[string]any{"x": 1}
+		m := map[string]any{"a": shared, "b": []any{shared, shared}}
+		for range startDetectingCyclesAfter {
+			m = map[string]any{"next": m, "again": shared}
+		}
+		_, err := NewValue(m)
+		require.NoError(t, err)
+
+		list := []any{1, 2, 3}
+		_, err = NewValue([]any{list, list[:2]})
+		require.NoError(t, err)
+	})
+
+	t.Run("max depth still applies first", func(t *testing.T) {
+		t.Parallel()
+		m := map[string]any{}
+		m["self"] = m
+		_, err := NewValue(m, WithMaxDepth(10))
+		require.ErrorIs(t, err, ErrMaxDepth)
+	})
+}
@@ -0,0 +1,79 @@
+package protobaggins
+
+import (
+	"encoding/base64"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ValueToInterface converts a *structpb.Value to a Go value like v.AsInterface(),
+// honoring the decoding side of the given options
+func ValueToInterface(v *structpb.Value, opts ...Option) any {
+	d := decoder{opts: newOptions(opts)}
+	return d.decode(v)
+}
+
+// decoder mirrors encoder for the protocol buffer to Go direction
+type decoder struct {
+	opts options
+	// nodes counts the values decoded inside maps and lists
+	nodes int
+}
+
+func (d *decoder) decode(v *structpb.Value) any {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		if d.opts.taggedBytes {
+			if encoded, ok := taggedBytes(kind.StructValue); ok {
+				if b, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+					return b
+				}
+			}
+		}
+		return d.decodeStruct(kind.StructValue)
+	case *structpb.Value_ListValue:
+		return d.decodeList(kind.ListValue)
+	case *structpb.Value_NumberValue:
+		if d.opts.jsonNumbers {
+			if n, ok := decodeJSONNumber(kind.NumberValue); ok {
+				return n
+			}
+		}
+		return v.AsInterface()
+	case *structpb.Value_StringValue:
+		if d.opts.largeIntegerStrings {
+			if i, ok := decodeLargeInteger(kind.StringValue); ok {
+				return i
+			}
+		}
+		return kind.StringValue
+	default:
+		return v.AsInterface()
+	}
+}
+
+func (d *decoder) decodeStruct(s *structpb.Struct) map[string]any {
+	result := make(map[string]any, len(s.GetFields()))
+	names := d.opts.keyCaseNames(s.GetFields())
+	for k, v := range s.GetFields() {
+		if !d.opts.decodedField(k, v) {
+			continue
+		}
+		name, ok := d.opts.decodedName(names, k)
+		if !ok {
+			continue
+		}
+		d.nodes++
+		result[name] = d.decode(v)
+	}
+	return result
+}
+
+func (d *decoder) decodeList(l *structpb.ListValue) []any {
+	result := make([]any, len(l.GetValues()))
+	d.nodes += len(result)
+	for i, v := range l.GetValues() {
+		result[i] = d.decode(v)
+	}
+	return result
+}
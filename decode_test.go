@@ -0,0 +1,49 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestValueToInterface(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil value", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, ValueToInterface(nil))
+	})
+
+	t.Run("matches AsInterface by default", func(t *testing.T) {
+		t.Parallel()
+		pbValue, err := structpb.NewValue(map[string]any{
+			"string": "value",
+			"number": 42.5,
+			"list":   []any{true, nil, map[string]any{"nested": "value"}},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, pbValue.AsInterface(), ValueToInterface(pbValue))
+	})
+
+	t.Run("tagged bytes inside list", func(t *testing.T) {
+		t.Parallel()
+		pbValue := structpb.NewListValue(&structpb.ListValue{
+			Values: []*structpb.Value{BytesToValue([]byte("hi"))},
+		})
+
+		result := ValueToInterface(pbValue, WithTaggedBytes())
+		assert.Equal(t, []any{[]byte("hi")}, result)
+	})
+
+	t.Run("malformed tagged bytes stay a map", func(t *testing.T) {
+		t.Parallel()
+		pbValue, err := structpb.NewValue(map[string]any{TaggedBytesKey: "not base64!"})
+		require.NoError(t, err)
+
+		result := ValueToInterface(pbValue, WithTaggedBytes())
+		assert.Equal(t, map[string]any{TaggedBytesKey: "not base64!"}, result)
+	})
+}
@@ -0,0 +1,55 @@
+package protobaggins
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultsOption configures ApplyDefaults
+type DefaultsOption func(*defaultsOptions)
+
+type defaultsOptions struct {
+	fillNulls bool
+}
+
+// DefaultsFillNulls treats nulls in the target like missing keys, so they are replaced
+// by defaults. By default a null is an explicit value and is kept
+func DefaultsFillNulls() DefaultsOption {
+	return func(o *defaultsOptions) {
+		o.fillNulls = true
+	}
+}
+
+// ApplyDefaults deep fills target with the keys of defaults it lacks: Structs present
+// in both are filled key by key, and any other value in target, lists included, is
+// kept. Unlike MergeStructs, values in target are never overwritten. target is
+// modified in place and never shares values with defaults. A nil target is left alone
+func ApplyDefaults(target, defaults *structpb.Struct, opts ...DefaultsOption) {
+	if target == nil {
+		return
+	}
+	var o defaultsOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.apply(target, defaults)
+}
+
+func (o *defaultsOptions) apply(target, defaults *structpb.Struct) {
+	if len(defaults.GetFields()) == 0 {
+		return
+	}
+	if target.Fields == nil {
+		target.Fields = make(map[string]*structpb.Value, len(defaults.GetFields()))
+	}
+
+	for key, def := range defaults.GetFields() {
+		current, ok := target.Fields[key]
+		switch {
+		case !ok, o.fillNulls && KindOf(current) == KindNull:
+			target.Fields[key], _ = proto.Clone(def).(*structpb.Value)
+		case KindOf(current) == KindStruct && KindOf(def) == KindStruct:
+			o.apply(current.GetStructValue(), def.GetStructValue())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	t.Parallel()
+
+	newDefaults := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(map[string]any{
+			"server":  map[string]any{"host": "localhost", "port": 8080, "tls": map[string]any{"enabled": false}},
+			"tags":    []any{"default"},
+			"timeout": "30s",
+			"proxy":   "none",
+			"extra":   nil,
+		})
+		require.NoError(t, err)
+		return s
+	}
+	newTarget := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(map[string]any{
+			"server": map[string]any{"port": 9090},
+			"tags":   []any{"custom"},
+			"proxy":  nil,
+			"name":   "shire",
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("fills missing keys only", func(t *testing.T) {
+		t.Parallel()
+		target, defaults := newTarget(t), newDefaults(t)
+		original := proto.Clone(defaults)
+		ApplyDefaults(target, defaults)
+
+		want, err := structpb.NewStruct(map[string]any{
+			"server":  map[string]any{"host": "localhost", "port": 9090, "tls": map[string]any{"enabled": false}},
+			"tags":    []any{"custom"},
+			"timeout": "30s",
+			"proxy":   nil,
+			"extra":   nil,
+			"name":    "shire",
+		})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, target), "got %v", target)
+
+		target.GetFields()["server"].GetStructValue().GetFields()["tls"].GetStructValue().GetFields()["enabled"] = structpb.NewBoolValue(true)
+		assert.True(t, proto.Equal(original, defaults), "target does not share values with defaults")
+	})
+
+	t.Run("fill nulls", func(t *testing.T) {
+		t.Parallel()
+		target := newTarget(t)
+		ApplyDefaults(target, newDefaults(t), DefaultsFillNulls())
+		assert.Equal(t, "none", target.GetFields()["proxy"].GetStringValue())
+	})
+
+	t.Run("nil structs", func(t *testing.T) {
+		t.Parallel()
+		target := &structpb.Struct{}
+		ApplyDefaults(target, newDefaults(t))
+		assert.Len(t, target.GetFields(), 5)
+		ApplyDefaults(target, nil)
+		assert.Len(t, target.GetFields(), 5)
+		assert.NotPanics(t, func() { ApplyDefaults(nil, newDefaults(t)) })
+	})
+}
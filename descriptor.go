@@ -0,0 +1,282 @@
+package protobaggins
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// CoerceToDescriptor normalizes s, a free-form Struct, against the message d. Fields
+// are matched by their JSON name or their name in the .proto file and keyed by their
+// JSON name, or their .proto name with MessageProtoNames, in the result. Values are
+// coerced like MapToMessage does and written back in the form of protojson:
+//   - integers must be whole and fit their declared kind, 64-bit ones beyond 2^53
+//     become decimal strings, see Int64ToValue
+//   - enums must name or number a value and become its name, or its number with
+//     MessageEnumNumbers
+//   - bytes must be standard base64, Timestamps RFC 3339 strings and Durations strings
+//     such as "1.5s", which are written as seconds, e.g. "90s"; FieldMasks may be lists
+//     of paths, which are joined with commas
+//   - nested messages, lists, maps, wrappers and Anys are coerced likewise
+//
+// Nulls are kept. Unknown fields are dropped with MessageUnknownKeys(UnknownKeysIgnore)
+// and otherwise reported in an *UnknownKeysError. Every failure is reported, as a
+// *ConversionError locating it, see ConversionError, and no Struct is returned then.
+// Fails with ErrNilMessage for a nil descriptor
+func CoerceToDescriptor(s *structpb.Struct, d protoreflect.MessageDescriptor, opts ...MessageOption) (*structpb.Struct, error) {
+	if d == nil {
+		return nil, fmt.Errorf("%w: cannot coerce to a nil descriptor", ErrNilMessage)
+	}
+	c := schemaCoercer{opts: messageOptions{resolver: protoregistry.GlobalTypes}}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+
+	result := c.message("", s, d)
+	if len(c.unknown) > 0 {
+		c.errs = append(c.errs, &UnknownKeysError{Paths: c.unknown})
+	}
+	if len(c.errs) > 0 {
+		return nil, &conversionErrors{errs: c.errs}
+	}
+	return result, nil
+}
+
+// schemaCoercer collects the failures of CoerceToDescriptor
+type schemaCoercer struct {
+	opts    messageOptions
+	errs    []error
+	unknown []string
+}
+
+func (c *schemaCoercer) fail(path string, err error) {
+	c.errs = append(c.errs, &ConversionError{Path: path, Err: err})
+}
+
+// message coerces s, a message of type md at path
+func (c *schemaCoercer) message(path string, s *structpb.Struct, md protoreflect.MessageDescriptor) *structpb.Struct {
+	fds := md.Fields()
+	result := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(s.GetFields()))}
+	keys := make(map[protoreflect.FieldDescriptor]string, len(s.GetFields()))
+	for _, key := range sortedKeys(s) {
+		fieldPath := joinKey(path, key)
+		fd := fds.ByJSONName(key)
+		if fd == nil {
+			fd = fds.ByName(protoreflect.Name(key))
+		}
+		if fd == nil {
+			if c.opts.unknownKeys != UnknownKeysIgnore {
+				c.unknown = append(c.unknown, fieldPath)
+			}
+			continue
+		}
+		if other, dup := keys[fd]; dup {
+			c.fail(fieldPath, fmt.Errorf("field %s is also set as %q", fd.Name(), other))
+			continue
+		}
+		keys[fd] = key
+
+		if v := c.field(fieldPath, fd, s.GetFields()[key]); v != nil {
+			result.Fields[c.opts.fieldName(fd)] = v
+		}
+	}
+	return result
+}
+
+// field coerces v, the value of the field fd at path, returning nil on failure
+func (c *schemaCoercer) field(path string, fd protoreflect.FieldDescriptor, v *structpb.Value) *structpb.Value {
+	switch {
+	case KindOf(v) == KindNull:
+		return v
+	case fd.IsList():
+		if KindOf(v) != KindList {
+			c.fail(path, fmt.Errorf("%w: expected a list, got %s", ErrUnexpectedKind, KindOf(v)))
+			return nil
+		}
+		items := v.GetListValue().GetValues()
+		result := &structpb.ListValue{Values: make([]*structpb.Value, 0, len(items))}
+		for i, item := range items {
+			if item := c.singular(joinIndex(path, i), fd, item); item != nil {
+				result.Values = append(result.Values, item)
+			}
+		}
+		return structpb.NewListValue(result)
+	case fd.IsMap():
+		if KindOf(v) != KindStruct {
+			c.fail(path, fmt.Errorf("%w: expected a struct, got %s", ErrUnexpectedKind, KindOf(v)))
+			return nil
+		}
+		entries := v.GetStructValue()
+		result := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(entries.GetFields()))}
+		for _, key := range sortedKeys(entries) {
+			entryPath := joinKey(path, key)
+			k, err := scalarValue(fd.MapKey(), key)
+			if err != nil {
+				c.fail(entryPath, fmt.Errorf("key: %w", err))
+				continue
+			}
+			if value := c.singular(entryPath, fd.MapValue(), entries.GetFields()[key]); value != nil {
+				result.Fields[k.MapKey().String()] = value
+			}
+		}
+		return structpb.NewStructValue(result)
+	default:
+		return c.singular(path, fd, v)
+	}
+}
+
+// singular coerces v to the type of fd, which is not a list or map
+func (c *schemaCoercer) singular(path string, fd protoreflect.FieldDescriptor, v *structpb.Value) *structpb.Value {
+	if fd.Message() != nil {
+		return c.wellKnown(path, fd.Message(), v)
+	}
+
+	pv, err := scalarValue(fd, v.AsInterface())
+	if err != nil {
+		c.fail(path, err)
+		return nil
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return structpb.NewBoolValue(pv.Bool())
+	case protoreflect.StringKind:
+		return structpb.NewStringValue(pv.String())
+	case protoreflect.BytesKind:
+		return structpb.NewStringValue(base64.StdEncoding.EncodeToString(pv.Bytes()))
+	case protoreflect.EnumKind:
+		switch e := c.opts.enum(fd.Enum(), pv.Enum()).(type) {
+		case string:
+			return structpb.NewStringValue(e)
+		case int32:
+			return structpb.NewNumberValue(float64(e))
+		default:
+			return structpb.NewNullValue()
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return Int64ToValue(pv.Int())
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return Uint64ToValue(pv.Uint())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return structpb.NewNumberValue(float64(pv.Int()))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return structpb.NewNumberValue(float64(pv.Uint()))
+	default:
+		return structpb.NewNumberValue(pv.Float())
+	}
+}
+
+// wellKnown coerces v to the message md, handling the well-known types
+func (c *schemaCoercer) wellKnown(path string, md protoreflect.MessageDescriptor, v *structpb.Value) *structpb.Value {
+	expect := func(kind Kind) bool {
+		if KindOf(v) == kind {
+			return true
+		}
+		c.fail(path, fmt.Errorf("%w: expected a %s for %s, got %s", ErrUnexpectedKind, kind, md.FullName(), KindOf(v)))
+		return false
+	}
+
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		if !expect(KindString) {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, v.GetStringValue())
+		if err != nil {
+			c.fail(path, fmt.Errorf("%w: %q is not an RFC 3339 time", ErrNotCoercible, v.GetStringValue()))
+			return nil
+		}
+		return structpb.NewStringValue(t.UTC().Format(time.RFC3339Nano))
+	case "google.protobuf.Duration":
+		if !expect(KindString) {
+			return nil
+		}
+		d, err := time.ParseDuration(v.GetStringValue())
+		if err != nil {
+			c.fail(path, fmt.Errorf("%w: %q is not a duration", ErrNotCoercible, v.GetStringValue()))
+			return nil
+		}
+		return structpb.NewStringValue(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s")
+	case "google.protobuf.FieldMask":
+		if KindOf(v) != KindList {
+			if !expect(KindString) {
+				return nil
+			}
+			return v
+		}
+		paths := make([]string, 0, len(v.GetListValue().GetValues()))
+		for i, p := range v.GetListValue().GetValues() {
+			if KindOf(p) != KindString {
+				c.fail(joinIndex(path, i), fmt.Errorf("%w: expected a string, got %s", ErrUnexpectedKind, KindOf(p)))
+				return nil
+			}
+			paths = append(paths, p.GetStringValue())
+		}
+		return structpb.NewStringValue(strings.Join(paths, ","))
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return c.singular(path, md.Fields().ByNumber(1), v)
+	case "google.protobuf.Value":
+		return v
+	case "google.protobuf.ListValue":
+		if !expect(KindList) {
+			return nil
+		}
+		return v
+	case "google.protobuf.Struct":
+		if !expect(KindStruct) {
+			return nil
+		}
+		return v
+	case "google.protobuf.Any":
+		if !expect(KindStruct) {
+			return nil
+		}
+		return c.anyValue(path, v.GetStructValue())
+	default:
+		if !expect(KindStruct) {
+			return nil
+		}
+		return structpb.NewStructValue(c.message(path, v.GetStructValue(), md))
+	}
+}
+
+// anyValue coerces s, an Any with its type URL under "@type", to the packed type
+func (c *schemaCoercer) anyValue(path string, s *structpb.Struct) *structpb.Value {
+	typeURL := s.GetFields()["@type"]
+	if KindOf(typeURL) != KindString {
+		c.fail(path, fmt.Errorf("%w: Any has no \"@type\" string", ErrNotCoercible))
+		return nil
+	}
+	mt, err := c.opts.resolver.FindMessageByURL(typeURL.GetStringValue())
+	if err != nil {
+		c.fail(path, fmt.Errorf("resolving %q: %w", typeURL.GetStringValue(), err))
+		return nil
+	}
+
+	md := mt.Descriptor()
+	fields := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(s.GetFields()))}
+	for k, v := range s.GetFields() {
+		if k != "@type" {
+			fields.Fields[k] = v
+		}
+	}
+	if inner, wrapped := fields.Fields["value"]; wrapped && len(fields.Fields) == 1 && isWellKnown(md.FullName()) {
+		value := c.wellKnown(joinKey(path, "value"), md, inner)
+		if value == nil {
+			return nil
+		}
+		fields.Fields["value"] = value
+	} else {
+		fields = c.message(path, fields, md)
+	}
+	fields.Fields["@type"] = typeURL
+	return structpb.NewStructValue(fields)
+}
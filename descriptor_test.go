@@ -0,0 +1,118 @@
+package protobaggins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCoerceToDescriptor(t *testing.T) {
+	t.Parallel()
+
+	md := hobbitDescriptor(t)
+	newStruct := func(t *testing.T, m map[string]any) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("normalizes values", func(t *testing.T) {
+		t.Parallel()
+		s := newStruct(t, map[string]any{
+			"user_name": "frodo",
+			"age":       "9007199254740993",
+			"tags":      []any{"ring-bearer", 1.0},
+			"scores":    map[string]any{"riddles": "3"},
+			"kind":      1.0,
+			"arrows":    3.0,
+			"born":      "2968-09-22T02:00:00+02:00",
+			"nap":       "1h30m",
+			"extra":     map[string]any{"pipe": "old toby"},
+			"packed":    map[string]any{"@type": "type.googleapis.com/google.protobuf.Int32Value", "value": "111"},
+			"nickname":  true,
+			"ring":      "AQ==",
+			"friend":    map[string]any{"userName": "sam", "kind": "KIND_BAGGINS", "weight": nil},
+			"mask":      "a.b,c",
+			"weight":    "1.5",
+		})
+		got, err := CoerceToDescriptor(s, md)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"userName": "frodo",
+			"age":      "9007199254740993",
+			"tags":     []any{"ring-bearer", "1"},
+			"scores":   map[string]any{"riddles": 3.0},
+			"kind":     "KIND_BAGGINS",
+			"arrows":   3.0,
+			"born":     "2968-09-22T00:00:00Z",
+			"nap":      "5400s",
+			"extra":    map[string]any{"pipe": "old toby"},
+			"packed":   map[string]any{"@type": "type.googleapis.com/google.protobuf.Int32Value", "value": 111.0},
+			"nickname": "true",
+			"ring":     "AQ==",
+			"friend":   map[string]any{"userName": "sam", "kind": "KIND_BAGGINS", "weight": nil},
+			"mask":     "a.b,c",
+			"weight":   1.5,
+		}, got.AsMap())
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		s := newStruct(t, map[string]any{"userName": "frodo", "kind": "KIND_BAGGINS", "height": 3})
+		got, err := CoerceToDescriptor(s, md,
+			MessageProtoNames(), MessageEnumNumbers(), MessageUnknownKeys(UnknownKeysIgnore))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"user_name": "frodo", "kind": 1.0}, got.AsMap())
+
+		got, err = CoerceToDescriptor(newStruct(t, map[string]any{"label": "LABEL_OPTIONAL"}),
+			(&descriptorpb.FieldDescriptorProto{}).ProtoReflect().Descriptor())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"label": "LABEL_OPTIONAL"}, got.AsMap())
+	})
+
+	t.Run("reports every failure", func(t *testing.T) {
+		t.Parallel()
+		s := newStruct(t, map[string]any{
+			"age":       42.5,
+			"arrows":    1e10,
+			"kind":      "KIND_TOOK",
+			"tags":      "a",
+			"friend":    map[string]any{"born": 3, "hair": "curly"},
+			"packed":    map[string]any{"value": 1},
+			"height":    3,
+			"userName":  "a",
+			"user_name": "b",
+		})
+		_, err := CoerceToDescriptor(s, md)
+		require.Error(t, err)
+
+		var paths []string
+		var unknown *UnknownKeysError
+		var joined interface{ Unwrap() []error }
+		require.ErrorAs(t, err, &joined)
+		for _, err := range joined.Unwrap() {
+			var ce *ConversionError
+			switch {
+			case errors.As(err, &ce):
+				paths = append(paths, ce.Path)
+			case errors.As(err, &unknown):
+			}
+		}
+		assert.Equal(t, []string{"age", "arrows", "friend.born", "kind", "packed", "tags", "user_name"}, paths)
+		require.NotNil(t, unknown)
+		assert.Equal(t, []string{"friend.hair", "height"}, unknown.Paths)
+		require.ErrorIs(t, err, ErrNotCoercible)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("nil descriptor", func(t *testing.T) {
+		t.Parallel()
+		_, err := CoerceToDescriptor(&structpb.Struct{}, nil)
+		require.ErrorIs(t, err, ErrNilMessage)
+	})
+}
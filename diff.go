@@ -0,0 +1,157 @@
+package protobaggins
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ChangeType identifies the kind of a Change reported by Diff
+type ChangeType int
+
+const (
+	// ChangeAdded means the path exists only in the new value
+	ChangeAdded ChangeType = iota + 1
+	// ChangeRemoved means the path exists only in the old value
+	ChangeRemoved
+	// ChangeModified means the path exists in both values with different contents
+	ChangeModified
+)
+
+// String returns "added", "removed" or "modified"
+func (c ChangeType) String() string {
+	switch c {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	default:
+		return fmt.Sprintf("ChangeType(%d)", int(c))
+	}
+}
+
+// Change is a single difference between two values
+// Old is nil for additions and New is nil for removals
+type Change struct {
+	Type ChangeType
+	Path string
+	Old  *structpb.Value
+	New  *structpb.Value
+}
+
+// String renders the change on one line, e.g. `~ spec.replicas: 2 -> 3`
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "(root)"
+	}
+	switch c.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", path, formatValue(c.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", path, formatValue(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", path, formatValue(c.Old), formatValue(c.New))
+	}
+}
+
+// Diff compares two values and returns the changes needed to turn a into b, ordered by
+// path. Structs are compared key by key and lists index by index; a value whose kind
+// differs is reported as a single modification rather than descended into
+func Diff(a, b *structpb.Value) []Change {
+	var changes []Change
+	diffValue("", a, b, &changes)
+	return changes
+}
+
+// DiffStructs is Diff for two Structs
+func DiffStructs(a, b *structpb.Struct) []Change {
+	var changes []Change
+	diffStruct("", a, b, &changes)
+	return changes
+}
+
+func diffValue(path string, a, b *structpb.Value, changes *[]Change) {
+	switch {
+	case a == nil && b == nil:
+		return
+	case a == nil:
+		*changes = append(*changes, Change{Type: ChangeAdded, Path: path, New: b})
+		return
+	case b == nil:
+		*changes = append(*changes, Change{Type: ChangeRemoved, Path: path, Old: a})
+		return
+	}
+
+	switch aKind := a.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		if bKind, ok := b.GetKind().(*structpb.Value_StructValue); ok {
+			diffStruct(path, aKind.StructValue, bKind.StructValue, changes)
+			return
+		}
+	case *structpb.Value_ListValue:
+		if bKind, ok := b.GetKind().(*structpb.Value_ListValue); ok {
+			diffList(path, aKind.ListValue, bKind.ListValue, changes)
+			return
+		}
+	}
+
+	if !proto.Equal(a, b) {
+		*changes = append(*changes, Change{Type: ChangeModified, Path: path, Old: a, New: b})
+	}
+}
+
+func diffStruct(path string, a, b *structpb.Struct, changes *[]Change) {
+	aFields, bFields := a.GetFields(), b.GetFields()
+	keys := make([]string, 0, len(aFields)+len(bFields))
+	for k := range aFields {
+		keys = append(keys, k)
+	}
+	for k := range bFields {
+		if _, ok := aFields[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		diffValue(joinKey(path, k), aFields[k], bFields[k], changes)
+	}
+}
+
+func diffList(path string, a, b *structpb.ListValue, changes *[]Change) {
+	aValues, bValues := a.GetValues(), b.GetValues()
+	for i := range max(len(aValues), len(bValues)) {
+		var aItem, bItem *structpb.Value
+		if i < len(aValues) {
+			aItem = aValues[i]
+		}
+		if i < len(bValues) {
+			bItem = bValues[i]
+		}
+		diffValue(joinIndex(path, i), aItem, bItem, changes)
+	}
+}
+
+// formatValue renders v as compact JSON with sorted keys for use in messages
+func formatValue(v *structpb.Value) string {
+	if v == nil {
+		return "<missing>"
+	}
+	if n, ok := v.GetKind().(*structpb.Value_NumberValue); ok && (math.IsNaN(n.NumberValue) || math.IsInf(n.NumberValue, 0)) {
+		// AsInterface would render these as strings, hiding that they are numbers
+		return strconv.FormatFloat(n.NumberValue, 'g', -1, 64)
+	}
+	b, err := json.Marshal(v.AsInterface())
+	if err != nil {
+		return fmt.Sprintf("%v", v.AsInterface())
+	}
+	return string(b)
+}
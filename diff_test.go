@@ -0,0 +1,143 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestChangeType(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "added", ChangeAdded.String())
+	assert.Equal(t, "removed", ChangeRemoved.String())
+	assert.Equal(t, "modified", ChangeModified.String())
+	assert.Equal(t, "ChangeType(9)", ChangeType(9).String())
+}
+
+func TestChangeString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		change   Change
+		expected string
+	}{
+		{
+			"added",
+			Change{Type: ChangeAdded, Path: "a", New: structpb.NewStringValue("x")},
+			`+ a: "x"`,
+		},
+		{
+			"removed",
+			Change{Type: ChangeRemoved, Path: "a[0]", Old: structpb.NewBoolValue(true)},
+			`- a[0]: true`,
+		},
+		{
+			"modified root",
+			Change{Type: ChangeModified, Old: structpb.NewNumberValue(1), New: structpb.NewNullValue()},
+			`~ (root): 1 -> null`,
+		},
+		{
+			"non-finite number",
+			Change{Type: ChangeAdded, Path: "n", New: structpb.NewNumberValue(math.NaN())},
+			`+ n: NaN`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, tt.change.String())
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	mustValue := func(t *testing.T, v any) *structpb.Value {
+		t.Helper()
+		pbValue, err := structpb.NewValue(v)
+		require.NoError(t, err)
+		return pbValue
+	}
+
+	t.Run("nil values", func(t *testing.T) {
+		t.Parallel()
+		assert.Empty(t, Diff(nil, nil))
+
+		changes := Diff(nil, structpb.NewBoolValue(true))
+		require.Len(t, changes, 1)
+		assert.Equal(t, ChangeAdded, changes[0].Type)
+
+		changes = Diff(structpb.NewBoolValue(true), nil)
+		require.Len(t, changes, 1)
+		assert.Equal(t, ChangeRemoved, changes[0].Type)
+	})
+
+	t.Run("equal values", func(t *testing.T) {
+		t.Parallel()
+		a := mustValue(t, map[string]any{"a": []any{1, "two"}, "b": map[string]any{"c": nil}})
+		b := mustValue(t, map[string]any{"a": []any{1, "two"}, "b": map[string]any{"c": nil}})
+		assert.Empty(t, Diff(a, b))
+	})
+
+	t.Run("nested changes ordered by path", func(t *testing.T) {
+		t.Parallel()
+		a := mustValue(t, map[string]any{
+			"name":    "frodo",
+			"removed": true,
+			"items":   []any{"ring", "sting", "mithril"},
+			"home":    map[string]any{"region": "shire"},
+		})
+		b := mustValue(t, map[string]any{
+			"name":  "samwise",
+			"added": 1,
+			"items": []any{"ring", "rope"},
+			"home":  "bag end",
+		})
+
+		changes := Diff(a, b)
+		rendered := make([]string, len(changes))
+		for i, c := range changes {
+			rendered[i] = c.String()
+		}
+
+		assert.Equal(t, []string{
+			`+ added: 1`,
+			`~ home: {"region":"shire"} -> "bag end"`,
+			`~ items[1]: "sting" -> "rope"`,
+			`- items[2]: "mithril"`,
+			`~ name: "frodo" -> "samwise"`,
+			`- removed: true`,
+		}, rendered)
+	})
+
+	t.Run("list growth", func(t *testing.T) {
+		t.Parallel()
+		changes := Diff(mustValue(t, []any{1}), mustValue(t, []any{1, 2}))
+		require.Len(t, changes, 1)
+		assert.Equal(t, ChangeAdded, changes[0].Type)
+		assert.Equal(t, "[1]", changes[0].Path)
+		assert.InEpsilon(t, 2.0, changes[0].New.GetNumberValue(), 0.001)
+	})
+}
+
+func TestDiffStructs(t *testing.T) {
+	t.Parallel()
+
+	a, err := structpb.NewStruct(map[string]any{"key with.dot": 1})
+	require.NoError(t, err)
+	b, err := structpb.NewStruct(map[string]any{"key with.dot": 2})
+	require.NoError(t, err)
+
+	changes := DiffStructs(a, b)
+	require.Len(t, changes, 1)
+	assert.Equal(t, `["key with.dot"]`, changes[0].Path)
+
+	assert.Empty(t, DiffStructs(nil, nil))
+	assert.Len(t, DiffStructs(nil, a), 1)
+}
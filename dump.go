@@ -0,0 +1,229 @@
+package protobaggins
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultDumpMaxItems is the number of list items Dump prints unless DumpMaxItems
+// sets another
+const DefaultDumpMaxItems = 100
+
+// DumpOption configures Dump and Fdump
+type DumpOption func(*dumpOptions)
+
+type dumpOptions struct {
+	indent    string
+	color     bool
+	maxItems  int
+	maxString int
+}
+
+// DumpIndent sets the indentation of each level, two spaces by default
+func DumpIndent(indent string) DumpOption {
+	return func(o *dumpOptions) {
+		o.indent = indent
+	}
+}
+
+// DumpColor highlights keys and values with ANSI escape codes, for terminals
+func DumpColor() DumpOption {
+	return func(o *dumpOptions) {
+		o.color = true
+	}
+}
+
+// DumpMaxItems prints only the first n items of each list followed by the number of
+// items left out. Zero means no limit
+func DumpMaxItems(n int) DumpOption {
+	return func(o *dumpOptions) {
+		o.maxItems = max(n, 0)
+	}
+}
+
+// DumpMaxString shortens strings longer than n runes, marking them with "..." and
+// their length. Zero means no limit, which is the default
+func DumpMaxString(n int) DumpOption {
+	return func(o *dumpOptions) {
+		o.maxString = max(n, 0)
+	}
+}
+
+// ANSI escape codes of DumpColor
+const (
+	dumpReset  = "\x1b[0m"
+	dumpKey    = "\x1b[36m"
+	dumpType   = "\x1b[2m"
+	dumpString = "\x1b[32m"
+	dumpNumber = "\x1b[33m"
+	dumpBool   = "\x1b[35m"
+)
+
+// Dump formats v for reading while debugging, one value per line and each annotated
+// with its kind, e.g.
+//
+//	struct {
+//	  name: string "frodo"
+//	  tags: list(3) [
+//	    string "ring"
+//	    ... 2 more items
+//	  ]
+//	}
+//
+// Keys are sorted and quoted when they are not plain words. Lists longer than
+// DefaultDumpMaxItems are truncated, see DumpMaxItems. The output is not meant to be
+// parsed, use StructToJSON for that
+func Dump(v *structpb.Value, opts ...DumpOption) string {
+	var b strings.Builder
+	d := newDumper(&b, opts)
+	d.value(v)
+	d.w.WriteByte('\n')
+	_ = d.w.Flush()
+	return b.String()
+}
+
+// Fdump writes the output of Dump to w
+func Fdump(w io.Writer, v *structpb.Value, opts ...DumpOption) error {
+	d := newDumper(w, opts)
+	d.value(v)
+	d.w.WriteByte('\n')
+	return d.w.Flush()
+}
+
+type dumper struct {
+	w     *bufio.Writer
+	opts  dumpOptions
+	depth int
+}
+
+func newDumper(w io.Writer, opts []DumpOption) *dumper {
+	d := &dumper{w: bufio.NewWriter(w), opts: dumpOptions{indent: "  ", maxItems: DefaultDumpMaxItems}}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+	return d
+}
+
+// colored writes s, highlighted with code under DumpColor
+func (d *dumper) colored(code, s string) {
+	if d.opts.color {
+		d.w.WriteString(code)
+		d.w.WriteString(s)
+		d.w.WriteString(dumpReset)
+		return
+	}
+	d.w.WriteString(s)
+}
+
+func (d *dumper) newline() {
+	d.w.WriteByte('\n')
+	for range d.depth {
+		d.w.WriteString(d.opts.indent)
+	}
+}
+
+func (d *dumper) value(v *structpb.Value) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		d.structValue(kind.StructValue)
+	case *structpb.Value_ListValue:
+		d.list(kind.ListValue)
+	case *structpb.Value_StringValue:
+		d.colored(dumpType, "string ")
+		d.colored(dumpString, d.quote(kind.StringValue))
+	case *structpb.Value_NumberValue:
+		d.colored(dumpType, "number ")
+		d.colored(dumpNumber, strconv.FormatFloat(kind.NumberValue, 'g', -1, 64))
+	case *structpb.Value_BoolValue:
+		d.colored(dumpType, "bool ")
+		d.colored(dumpBool, strconv.FormatBool(kind.BoolValue))
+	case *structpb.Value_NullValue:
+		d.colored(dumpType, "null")
+	default:
+		d.colored(dumpType, "unset")
+	}
+}
+
+func (d *dumper) structValue(s *structpb.Struct) {
+	keys := sortedKeys(s)
+	if len(keys) == 0 {
+		d.colored(dumpType, "struct")
+		d.w.WriteString(" {}")
+		return
+	}
+
+	d.colored(dumpType, "struct")
+	d.w.WriteString(" {")
+	d.depth++
+	for _, key := range keys {
+		d.newline()
+		label := key
+		if !isPlainKey(key) {
+			label = strconv.Quote(key)
+		}
+		d.colored(dumpKey, label)
+		d.w.WriteString(": ")
+		d.value(s.GetFields()[key])
+	}
+	d.depth--
+	d.newline()
+	d.w.WriteByte('}')
+}
+
+func (d *dumper) list(l *structpb.ListValue) {
+	items := l.GetValues()
+	d.colored(dumpType, "list("+strconv.Itoa(len(items))+")")
+	if len(items) == 0 {
+		d.w.WriteString(" []")
+		return
+	}
+
+	d.w.WriteString(" [")
+	d.depth++
+	shown := items
+	if d.opts.maxItems > 0 && len(items) > d.opts.maxItems {
+		shown = items[:d.opts.maxItems]
+	}
+	for _, item := range shown {
+		d.newline()
+		d.value(item)
+	}
+	if omitted := len(items) - len(shown); omitted > 0 {
+		d.newline()
+		d.colored(dumpType, "... "+strconv.Itoa(omitted)+" more items")
+	}
+	d.depth--
+	d.newline()
+	d.w.WriteByte(']')
+}
+
+// quote quotes s, shortened to DumpMaxString runes
+func (d *dumper) quote(s string) string {
+	if d.opts.maxString == 0 {
+		return strconv.Quote(s)
+	}
+	runes := []rune(s)
+	if len(runes) <= d.opts.maxString {
+		return strconv.Quote(s)
+	}
+	return strconv.Quote(string(runes[:d.opts.maxString])) + "... (" + strconv.Itoa(len(runes)) + " runes)"
+}
+
+// isPlainKey reports whether key can be printed without quotes
+func isPlainKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '_', r == '-', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
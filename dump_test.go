@@ -0,0 +1,92 @@
+package protobaggins
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDump(t *testing.T) {
+	t.Parallel()
+
+	v, err := structpb.NewValue(map[string]any{
+		"name":     "frodo",
+		"age":      50.0,
+		"ring":     true,
+		"gone":     nil,
+		"tags":     []any{"a", 1.0, []any{}},
+		"house":    map[string]any{},
+		"odd key":  1e21,
+		"infinite": math.Inf(1),
+	})
+	require.NoError(t, err)
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+		want := `struct {
+  age: number 50
+  gone: null
+  house: struct {}
+  infinite: number +Inf
+  name: string "frodo"
+  "odd key": number 1e+21
+  ring: bool true
+  tags: list(3) [
+    string "a"
+    number 1
+    list(0) []
+  ]
+}
+`
+		assert.Equal(t, want, Dump(v))
+	})
+
+	t.Run("truncation", func(t *testing.T) {
+		t.Parallel()
+		list, err := structpb.NewValue([]any{"abcdef", "b", "c", "d"})
+		require.NoError(t, err)
+		want := `list(4) [
+	string "abc"... (6 runes)
+	string "b"
+	... 2 more items
+]
+`
+		assert.Equal(t, want, Dump(list, DumpMaxItems(2), DumpMaxString(3), DumpIndent("\t")))
+
+		long := make([]any, DefaultDumpMaxItems+1)
+		for i := range long {
+			long[i] = float64(i)
+		}
+		list, err = structpb.NewValue(long)
+		require.NoError(t, err)
+		assert.Contains(t, Dump(list), "... 1 more items")
+		assert.NotContains(t, Dump(list, DumpMaxItems(0)), "more items")
+	})
+
+	t.Run("color", func(t *testing.T) {
+		t.Parallel()
+		out := Dump(structpb.NewStringValue("x"), DumpColor())
+		assert.Equal(t, "\x1b[2mstring \x1b[0m\x1b[32m\"x\"\x1b[0m\n", out)
+	})
+
+	t.Run("scalars and nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "unset\n", Dump(nil))
+		assert.Equal(t, "bool false\n", Dump(structpb.NewBoolValue(false)))
+	})
+}
+
+func TestFdump(t *testing.T) {
+	t.Parallel()
+
+	v, err := structpb.NewValue(map[string]any{"a": strings.Repeat("x", 5000)})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, Fdump(&buf, v))
+	assert.Equal(t, Dump(v), buf.String())
+}
@@ -0,0 +1,55 @@
+package protobaggins
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DurationFormat selects how time.Duration values are converted to protocol buffer values
+type DurationFormat int
+
+const (
+	durationUnsupported DurationFormat = iota
+	// DurationNanoseconds encodes durations as numbers of nanoseconds, 90m becomes 5.4e12
+	DurationNanoseconds
+	// DurationString encodes durations in the form of time.Duration.String, e.g. "1h30m0s"
+	DurationString
+)
+
+// WithDurations converts time.Duration and *durationpb.Duration values in the given
+// format. Without this option they are not supported by NewValue, and are dropped by
+// the lossy MapToStructValues and SliceToStructValues like other unsupported types
+func WithDurations(format DurationFormat) Option {
+	return func(o *options) {
+		o.durations = format
+	}
+}
+
+// DurationToProto converts a time.Duration to a *durationpb.Duration
+func DurationToProto(d time.Duration) *durationpb.Duration {
+	return durationpb.New(d)
+}
+
+// DurationFromProto converts a *durationpb.Duration to a time.Duration
+// Returns 0 for nil, and saturates at the limits of time.Duration like AsDuration
+func DurationFromProto(d *durationpb.Duration) time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.AsDuration()
+}
+
+// encodeDuration converts d in the configured format
+// Returns false if durations are not supported
+func (e *encoder) encodeDuration(d time.Duration) (*structpb.Value, bool) {
+	switch e.opts.durations {
+	case DurationNanoseconds:
+		return structpb.NewNumberValue(float64(d)), true
+	case DurationString:
+		return structpb.NewStringValue(d.String()), true
+	default:
+		return nil, false
+	}
+}
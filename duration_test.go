@@ -0,0 +1,89 @@
+package protobaggins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestDurationToProto(t *testing.T) {
+	t.Parallel()
+
+	d := DurationToProto(90*time.Minute + 5)
+	assert.Equal(t, int64(5400), d.GetSeconds())
+	assert.Equal(t, int32(5), d.GetNanos())
+}
+
+func TestDurationFromProto(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil is zero", func(t *testing.T) {
+		t.Parallel()
+		assert.Zero(t, DurationFromProto(nil))
+	})
+
+	t.Run("round trips", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, -3*time.Second, DurationFromProto(DurationToProto(-3*time.Second)))
+	})
+}
+
+func TestDurationValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unsupported by default", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(time.Second)
+		require.Error(t, err)
+		assert.Empty(t, MapToStructValues(map[string]any{"nap": time.Second}))
+	})
+
+	t.Run("nanoseconds", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(90*time.Minute, WithDurations(DurationNanoseconds))
+		require.NoError(t, err)
+		assert.InDelta(t, 5.4e12, v.GetNumberValue(), 0)
+	})
+
+	t.Run("strings", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(map[string]any{
+			"nap":   90 * time.Minute,
+			"proto": durationpb.New(time.Second),
+			"nil":   (*durationpb.Duration)(nil),
+		}, WithDurations(DurationString))
+		require.NoError(t, err)
+		fields := v.GetStructValue().GetFields()
+		assert.Equal(t, "1h30m0s", fields["nap"].GetStringValue())
+		assert.Equal(t, "1s", fields["proto"].GetStringValue())
+		assert.Equal(t, KindNull, KindOf(fields["nil"]))
+	})
+
+	t.Run("round trip through structs", func(t *testing.T) {
+		t.Parallel()
+		type timer struct {
+			Nap   time.Duration        `json:"nap"`
+			Proto *durationpb.Duration `json:"proto"`
+		}
+		for _, format := range []DurationFormat{DurationNanoseconds, DurationString} {
+			in := timer{Nap: 90 * time.Minute, Proto: durationpb.New(time.Second)}
+			s, err := EncodeStruct(in, WithDurations(format))
+			require.NoError(t, err)
+
+			var out timer
+			require.NoError(t, DecodeStruct(s, &out))
+			assert.Equal(t, in.Nap, out.Nap)
+			assert.Equal(t, time.Second, out.Proto.AsDuration())
+		}
+	})
+
+	t.Run("structs keep nanoseconds without the option", func(t *testing.T) {
+		t.Parallel()
+		s, err := EncodeStruct(struct{ Nap time.Duration }{time.Second})
+		require.NoError(t, err)
+		assert.InDelta(t, 1e9, s.GetFields()["Nap"].GetNumberValue(), 0)
+	})
+}
@@ -0,0 +1,36 @@
+package protobaggins
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// StructToDynamic builds a dynamic message of type md from s, for descriptors only
+// known at runtime. s is read like MapToMessage reads a map, so it accepts the JSON
+// form of the message as written by DynamicToStruct, and opts are the same
+func StructToDynamic(s *structpb.Struct, md protoreflect.MessageDescriptor, opts ...MessageOption) (*dynamicpb.Message, error) {
+	m := dynamicpb.NewMessage(md)
+	if err := MapToMessage(s.AsMap(), m, opts...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DynamicToStruct converts m, typically a *dynamicpb.Message, to a Struct in the JSON
+// form of protojson, which StructToDynamic reads back: 64-bit integers beyond 2^53 are
+// decimal strings, bytes are base64, Timestamps RFC 3339 strings and Durations strings
+// of seconds. opts are those of MessageToMap
+func DynamicToStruct(m proto.Message, opts ...MessageOption) (*structpb.Struct, error) {
+	fields, err := MessageToMap(m, opts...)
+	if err != nil {
+		return nil, err
+	}
+	v, err := NewValue(fields, WithDurations(DurationString), WithLargeIntegerStrings(), WithReflection())
+	if err != nil {
+		return nil, err
+	}
+	// normalizes the forms NewValue chose for well-known types, e.g. durations
+	return CoerceToDescriptor(v.GetStructValue(), m.ProtoReflect().Descriptor(), opts...)
+}
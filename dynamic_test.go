@@ -0,0 +1,86 @@
+package protobaggins
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestDynamicStruct(t *testing.T) {
+	t.Parallel()
+
+	md := hobbitDescriptor(t)
+	s, err := structpb.NewStruct(map[string]any{
+		"userName": "frodo",
+		"age":      "9007199254740993",
+		"tags":     []any{"ring-bearer"},
+		"scores":   map[string]any{"riddles": 3.0},
+		"kind":     "KIND_BAGGINS",
+		"sword":    "sting",
+		"born":     "2968-09-22T00:00:00Z",
+		"nap":      "5400s",
+		"extra":    map[string]any{"pipe": "old toby"},
+		"packed":   map[string]any{"@type": "type.googleapis.com/google.protobuf.Int32Value", "value": 111.0},
+		"nickname": "mr. underhill",
+		"ring":     "AQ==",
+		"friend":   map[string]any{"userName": "sam"},
+		"mask":     "a.b,c",
+		"weight":   1.5,
+	})
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		m, err := StructToDynamic(s, md)
+		require.NoError(t, err)
+		get := func(name string) protoreflect.Value {
+			return m.Get(md.Fields().ByName(protoreflect.Name(name)))
+		}
+		assert.Equal(t, int64(9007199254740993), get("age").Int())
+		assert.True(t, proto.Equal(durationpb.New(90*time.Minute), get("nap").Message().Interface()))
+		assert.True(t, proto.Equal(&fieldmaskpb.FieldMask{Paths: []string{"a.b", "c"}}, get("mask").Message().Interface()))
+
+		back, err := DynamicToStruct(m)
+		require.NoError(t, err)
+		assert.Equal(t, s.AsMap(), back.AsMap())
+	})
+
+	t.Run("generated messages", func(t *testing.T) {
+		t.Parallel()
+		packed, err := anypb.New(timestamppb.New(time.Unix(1, 500).UTC()))
+		require.NoError(t, err)
+		got, err := DynamicToStruct(&structpb.Value{Kind: &structpb.Value_ListValue{}}, MessageProtoNames())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"list_value": []any{}}, got.AsMap())
+
+		got, err = DynamicToStruct(packed)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"typeUrl": packed.GetTypeUrl(), "value": base64.StdEncoding.EncodeToString(packed.GetValue())}, got.AsMap())
+
+		got, err = DynamicToStruct(wrapperspb.UInt64(1<<60), MessageEmitUnpopulated())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"value": "1152921504606846976"}, got.AsMap())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		bad, err := structpb.NewStruct(map[string]any{"age": 1.5, "height": 3})
+		require.NoError(t, err)
+		_, err = StructToDynamic(bad, md)
+		require.ErrorIs(t, err, ErrNotCoercible)
+
+		_, err = DynamicToStruct(nil)
+		require.ErrorIs(t, err, ErrNilMessage)
+	})
+}
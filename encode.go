@@ -0,0 +1,492 @@
+package protobaggins
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"reflect"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Option configures how values are converted between Go and protocol buffer values
+type Option func(*options)
+
+type options struct {
+	explodeURLs         bool
+	taggedBytes         bool
+	bytesEncoding       BytesEncoding
+	skipErrors          bool
+	omitNulls           bool
+	omitEmpty           bool
+	unixTimes           bool
+	durations           DurationFormat
+	nonFinite           NonFinitePolicy
+	largeIntegerStrings bool
+	jsonNumbers         bool
+	bigNumbers          bool
+	reflection          bool
+	enumNames           bool
+	arena               bool
+	intern              *internCache
+	maxDepth            int
+	maxNodes            int
+	maxBytes            int
+	keyFilters          []func(key string) bool
+	keyCase             KeyCase
+	onSkip              func(path string, value any, err error)
+	observer            Observer
+	valueConverters     map[reflect.Type]ValueConverterFunc
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithExplodedURLs encodes *url.URL values as nested Structs (see URLToStruct)
+// instead of as plain strings
+func WithExplodedURLs() Option {
+	return func(o *options) {
+		o.explodeURLs = true
+	}
+}
+
+// WithSkipErrors drops map entries and list elements that cannot be converted, at any
+// depth, instead of failing the whole conversion. This is the policy of the lossy
+// MapToStructValues and SliceToStructValues, applied recursively
+func WithSkipErrors() Option {
+	return func(o *options) {
+		o.skipErrors = true
+	}
+}
+
+// WithOnSkip calls fn for every value dropped by WithSkipErrors or by the lossy
+// MapToStructValues and SliceToStructValues, with the path of the value, in the notation
+// described at PathFilter, the value and the reason, so that data loss can be logged or
+// counted without failing conversions
+func WithOnSkip(fn func(path string, value any, err error)) Option {
+	return func(o *options) {
+		o.onSkip = fn
+	}
+}
+
+// WithOmitNulls drops map entries and struct fields whose value is nil or null, in both
+// directions. Nulls inside lists are kept so that element positions do not shift
+func WithOmitNulls() Option {
+	return func(o *options) {
+		o.omitNulls = true
+	}
+}
+
+// WithOmitEmpty drops map entries and struct fields whose value is nil, false, 0, an
+// empty string or an empty list or map, like the omitempty option of encoding/json but
+// for every key, when converting to protocol buffer values. Interfaces are judged by
+// the value they hold, and list items are always kept
+func WithOmitEmpty() Option {
+	return func(o *options) {
+		o.omitEmpty = true
+	}
+}
+
+// WithMaxDepth fails conversions of values that nest maps and lists more than depth
+// levels deep, the top-level container being level 1. Zero means no limit
+// Values that contain themselves fail with ErrCycle whether or not a limit is set
+func WithMaxDepth(depth int) Option {
+	return func(o *options) {
+		o.maxDepth = max(depth, 0)
+	}
+}
+
+// WithKeyFilter keeps only map entries and struct fields whose key satisfies keep, in
+// both directions and at any depth. Multiple filters must all be satisfied
+func WithKeyFilter(keep func(key string) bool) Option {
+	return func(o *options) {
+		if keep != nil {
+			o.keyFilters = append(o.keyFilters, keep)
+		}
+	}
+}
+
+// omitEntry reports whether a map entry or struct field holding v is dropped by
+// WithOmitNulls or WithOmitEmpty
+func (o *options) omitEntry(v any) bool {
+	if v == nil {
+		return o.omitNulls || o.omitEmpty
+	}
+	return o.omitEmpty && isEmptyValue(reflect.ValueOf(v))
+}
+
+// omitReflectEntry is omitEntry for values reached by reflection
+func (o *options) omitReflectEntry(rv reflect.Value) bool {
+	if o.omitNulls && isNil(rv) {
+		return true
+	}
+	if !o.omitEmpty {
+		return false
+	}
+	if rv.Kind() == reflect.Interface && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return isEmptyValue(rv)
+}
+
+// keepKey reports whether a map entry or struct field passes the key filters
+func (o *options) keepKey(key string) bool {
+	for _, keep := range o.keyFilters {
+		if !keep(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewValue converts a Go value to a *structpb.Value
+// It accepts everything structpb.NewValue does, plus the additional types supported by this package
+// time.Time and *timestamppb.Timestamp become RFC 3339 strings unless WithUnixTimes is given
+// *url.URL and *time.Location become strings, see URLToValue and LocationToValue
+// Other values that implement json.Marshaler or encoding.TextMarshaler, such as net.IP,
+// are converted through their JSON or text form
+func NewValue(v any, opts ...Option) (*structpb.Value, error) {
+	e := newEncoder(newOptions(opts))
+	return e.encodeRoot(v)
+}
+
+// encoder walks Go values recursively so that types unknown to structpb can be
+// handled at any depth, not only at the top level
+type encoder struct {
+	opts options
+	// ctx aborts the conversion once done, unless it is nil, see NewValueCtx
+	ctx   context.Context
+	depth int
+	seen  map[cycleKey]struct{}
+	nodes int
+	bytes int
+	path  []pathSegment
+	arena *arena
+}
+
+func (e *encoder) encode(v any) (*structpb.Value, error) {
+	pbValue, err := e.encodeValue(v)
+	if err != nil {
+		return nil, e.conversionError(reflect.TypeOf(v), err)
+	}
+	return pbValue, nil
+}
+
+func (e *encoder) encodeValue(v any) (*structpb.Value, error) {
+	if fn, ok := e.opts.lookupValueConverter(v); ok {
+		return convertCustom(fn, v)
+	}
+	if m, ok := v.(ValueMarshaler); ok {
+		return marshalValue(m)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		container := reflect.ValueOf(v)
+		if err := e.enter(container); err != nil {
+			return nil, err
+		}
+		defer e.leave(container)
+		return e.encodeMap(v)
+	case []any:
+		container := reflect.ValueOf(v)
+		if err := e.enter(container); err != nil {
+			return nil, err
+		}
+		defer e.leave(container)
+		return e.encodeSlice(v)
+	case nil:
+		return e.newNull(), nil
+	case bool:
+		return e.newBool(v), nil
+	case string:
+		if !utf8.ValidString(v) {
+			return nil, fmt.Errorf("invalid UTF-8 in string: %q", v)
+		}
+		return e.newString(v), nil
+	case int:
+		if e.opts.largeIntegerStrings {
+			return Int64ToValue(int64(v)), nil
+		}
+		return e.newNumber(float64(v)), nil
+	case int32:
+		return e.newNumber(float64(v)), nil
+	case int64:
+		if e.opts.largeIntegerStrings {
+			return Int64ToValue(v), nil
+		}
+		return e.newNumber(float64(v)), nil
+	case uint:
+		if e.opts.largeIntegerStrings {
+			return Uint64ToValue(uint64(v)), nil
+		}
+		return e.newNumber(float64(v)), nil
+	case uint32:
+		return e.newNumber(float64(v)), nil
+	case uint64:
+		if e.opts.largeIntegerStrings {
+			return Uint64ToValue(v), nil
+		}
+		return e.newNumber(float64(v)), nil
+	case *big.Int:
+		return e.encodeBigInt(v), nil
+	case *big.Float:
+		return e.encodeBigFloat(v), nil
+	case json.Number:
+		return e.encodeJSONNumber(v)
+	case float64:
+		return e.encodeFloat(v)
+	case float32:
+		return e.encodeFloat(float64(v))
+	case []byte:
+		switch {
+		case e.opts.taggedBytes:
+			return BytesToValue(v), nil
+		case v != nil && e.opts.bytesEncoding != BytesBase64:
+			return structpb.NewStringValue(e.opts.bytesEncoding.EncodeToString(v)), nil
+		}
+	case *url.URL:
+		return e.encodeURL(v), nil
+	case url.URL:
+		return e.encodeURL(&v), nil
+	case *time.Location:
+		return LocationToValue(v), nil
+	case time.Location:
+		return LocationToValue(&v), nil
+	case time.Time:
+		return e.encodeTime(v), nil
+	case *time.Time:
+		if v == nil {
+			return structpb.NewNullValue(), nil
+		}
+		return e.encodeTime(*v), nil
+	case *timestamppb.Timestamp:
+		if v == nil {
+			return structpb.NewNullValue(), nil
+		}
+		return e.encodeTime(v.AsTime()), nil
+	case time.Duration:
+		if pbValue, ok := e.encodeDuration(v); ok {
+			return pbValue, nil
+		}
+	case *durationpb.Duration:
+		if v == nil {
+			return structpb.NewNullValue(), nil
+		}
+		if pbValue, ok := e.encodeDuration(v.AsDuration()); ok {
+			return pbValue, nil
+		}
+	}
+
+	if pbValue, ok := e.encodeEnum(v); ok {
+		return pbValue, nil
+	}
+	pbValue, err := structpb.NewValue(v)
+	if err != nil {
+		if fallback, ok, ferr := encodeMarshaler(v); ok {
+			return fallback, ferr
+		}
+	}
+	if err != nil && e.opts.reflection {
+		return e.encodeReflect(reflect.ValueOf(v))
+	}
+	return pbValue, err
+}
+
+// encodeMarshaler converts values that structpb does not accept but that know how to
+// marshal themselves as JSON or text, such as net.IP. Reports false for other values
+func encodeMarshaler(v any) (*structpb.Value, bool, error) {
+	switch m := v.(type) {
+	case json.Marshaler:
+		if isNilPointer(m) {
+			return structpb.NewNullValue(), true, nil
+		}
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return nil, true, fmt.Errorf("%T: %w", v, err)
+		}
+		pbValue := &structpb.Value{}
+		if err := protojson.Unmarshal(data, pbValue); err != nil {
+			return nil, true, fmt.Errorf("%T: invalid JSON from MarshalJSON: %w", v, err)
+		}
+		return pbValue, true, nil
+	case encoding.TextMarshaler:
+		if isNilPointer(m) {
+			return structpb.NewNullValue(), true, nil
+		}
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil, true, fmt.Errorf("%T: %w", v, err)
+		}
+		if !utf8.Valid(text) {
+			return nil, true, fmt.Errorf("%T: invalid UTF-8 from MarshalText", v)
+		}
+		return structpb.NewStringValue(string(text)), true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// enter descends into container, enforcing the depth limit and, once values nest
+// deeply, rejecting containers that are already being encoded further up
+func (e *encoder) enter(container reflect.Value) error {
+	if e.opts.maxDepth > 0 && e.depth >= e.opts.maxDepth {
+		return fmt.Errorf("%w of %d", ErrMaxDepth, e.opts.maxDepth)
+	}
+	if e.depth >= startDetectingCyclesAfter {
+		if key, ok := newCycleKey(container); ok {
+			if _, seen := e.seen[key]; seen {
+				return fmt.Errorf("%w: %s contains itself", ErrCycle, container.Type())
+			}
+			if e.seen == nil {
+				e.seen = make(map[cycleKey]struct{})
+			}
+			e.seen[key] = struct{}{}
+		}
+	}
+	e.depth++
+	return nil
+}
+
+func (e *encoder) leave(container reflect.Value) {
+	e.depth--
+	if e.depth >= startDetectingCyclesAfter {
+		if key, ok := newCycleKey(container); ok {
+			delete(e.seen, key)
+		}
+	}
+}
+
+func (e *encoder) encodeMap(m map[string]any) (*structpb.Value, error) {
+	fields, err := e.encodeFields(m)
+	if err != nil {
+		return nil, err
+	}
+	return structpb.NewStructValue(&structpb.Struct{Fields: fields}), nil
+}
+
+// encodeFields converts the entries of m, reporting every failure
+func (e *encoder) encodeFields(m map[string]any) (map[string]*structpb.Value, error) {
+	return e.encodeFieldsInto(make(map[string]*structpb.Value, len(m)), m)
+}
+
+// encodeFieldsInto is encodeFields storing the entries in fields
+func (e *encoder) encodeFieldsInto(fields map[string]*structpb.Value, m map[string]any) (map[string]*structpb.Value, error) {
+	var errs entryErrors
+	for k, v := range m {
+		if err := e.encodeEntry(fields, &errs, k, v); err != nil {
+			return nil, err
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs.join(true)
+	}
+	return fields, nil
+}
+
+// encodeEntry converts the entry k of a map into fields, recording its failure in errs
+// Returns an error if the conversion must stop, see entryErrors.add
+func (e *encoder) encodeEntry(fields map[string]*structpb.Value, errs *entryErrors, k string, v any) error {
+	if !e.opts.keepKey(k) || e.opts.omitEntry(v) {
+		return nil
+	}
+	pbValue, name, err := e.encodeField(fields, k, func() (*structpb.Value, error) {
+		return e.encode(v)
+	})
+	if err != nil {
+		return errs.add(e, k, v, err)
+	}
+	fields[name] = pbValue
+	return nil
+}
+
+// encodeField converts the map entry or struct field key with encode, returning the key
+// it is stored under in fields
+func (e *encoder) encodeField(fields map[string]*structpb.Value, key string, encode func() (*structpb.Value, error)) (*structpb.Value, string, error) {
+	e.pushKey(key)
+	defer e.pop()
+
+	if !utf8.ValidString(key) {
+		return nil, "", e.conversionError(nil, fmt.Errorf("invalid UTF-8 in key: %q", key))
+	}
+	name, err := e.renameField(fields, key)
+	if err != nil {
+		return nil, "", e.conversionError(nil, err)
+	}
+	pbValue, err := encode()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := e.count(name, pbValue); err != nil {
+		return nil, "", err
+	}
+	return pbValue, name, nil
+}
+
+func (e *encoder) encodeSlice(s []any) (*structpb.Value, error) {
+	values, err := e.encodeItems(s)
+	if err != nil {
+		return nil, err
+	}
+	return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+}
+
+// encodeItems converts the items of s, reporting every failure
+func (e *encoder) encodeItems(s []any) ([]*structpb.Value, error) {
+	return e.appendItems(make([]*structpb.Value, 0, len(s)), s)
+}
+
+// appendItems is encodeItems appending the items to values
+func (e *encoder) appendItems(values []*structpb.Value, s []any) ([]*structpb.Value, error) {
+	var errs entryErrors
+	for i, v := range s {
+		var err error
+		if values, err = e.appendItem(values, &errs, i, v); err != nil {
+			return nil, err
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs.join(false)
+	}
+	return values, nil
+}
+
+// appendItem appends item i of a list to values, recording its failure in errs
+// Returns an error if the conversion must stop, see entryErrors.add
+func (e *encoder) appendItem(values []*structpb.Value, errs *entryErrors, i int, v any) ([]*structpb.Value, error) {
+	e.pushIndex(i)
+	pbValue, err := e.encode(v)
+	if err == nil {
+		err = e.count("", pbValue)
+	}
+	e.pop()
+	if err != nil {
+		return values, errs.add(e, "", v, err)
+	}
+	return append(values, pbValue), nil
+}
+
+func (e *encoder) encodeURL(u *url.URL) *structpb.Value {
+	if u != nil && e.opts.explodeURLs {
+		return structpb.NewStructValue(URLToStruct(u))
+	}
+	return URLToValue(u)
+}
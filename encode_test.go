@@ -0,0 +1,78 @@
+package protobaggins
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestNewValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("primitive values", func(t *testing.T) {
+		t.Parallel()
+		result, err := NewValue("test")
+		require.NoError(t, err)
+		assert.Equal(t, "test", result.GetStringValue())
+
+		result, err = NewValue(42)
+		require.NoError(t, err)
+		assert.InEpsilon(t, float64(42), result.GetNumberValue(), 0.001)
+	})
+
+	t.Run("nested url values", func(t *testing.T) {
+		t.Parallel()
+		u, err := url.Parse("https://example.com/path")
+		require.NoError(t, err)
+
+		result, err := NewValue(map[string]any{
+			"links": []any{u},
+		})
+		require.NoError(t, err)
+
+		links := result.GetStructValue().GetFields()["links"].GetListValue().GetValues()
+		require.Len(t, links, 1)
+		assert.Equal(t, "https://example.com/path", links[0].GetStringValue())
+	})
+
+	t.Run("exploded url option", func(t *testing.T) {
+		t.Parallel()
+		u, err := url.Parse("https://example.com/path")
+		require.NoError(t, err)
+
+		result, err := NewValue(map[string]any{"link": u}, WithExplodedURLs())
+		require.NoError(t, err)
+
+		link := result.GetStructValue().GetFields()["link"].GetStructValue().GetFields()
+		assert.Equal(t, "https", link["scheme"].GetStringValue())
+		assert.Equal(t, "example.com", link["host"].GetStringValue())
+	})
+
+	t.Run("invalid utf8 key", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(map[string]any{"\xff": "value"})
+		require.Error(t, err)
+	})
+
+	t.Run("unconvertible nested value", func(t *testing.T) {
+		t.Parallel()
+
+		type unconvertible struct {
+			Field string
+		}
+
+		_, err := NewValue([]any{"valid", unconvertible{Field: "test"}})
+		require.Error(t, err)
+	})
+
+	t.Run("nil value", func(t *testing.T) {
+		t.Parallel()
+		result, err := NewValue(nil)
+		require.NoError(t, err)
+		_, ok := result.Kind.(*structpb.Value_NullValue)
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,58 @@
+package protobaggins
+
+import (
+	"strconv"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Enum is satisfied by generated enum types, such as descriptorpb.FieldDescriptorProto_Type
+type Enum interface {
+	~int32
+	protoreflect.Enum
+}
+
+// EnumToString returns the name of the value e, e.g. "TYPE_STRING". Of several aliases
+// for a number, the first declared is returned, and numbers that name no value, which
+// open enums allow, are returned in decimal like protojson does
+func EnumToString[E protoreflect.Enum](e E) string {
+	if value := e.Descriptor().Values().ByNumber(e.Number()); value != nil {
+		return string(value.Name())
+	}
+	return strconv.Itoa(int(e.Number()))
+}
+
+// EnumFromString parses the name of a value of E, any of its aliases, or its number in
+// decimal. Numbers that name no value are accepted for open enums only. Fails with
+// ErrNotCoercible otherwise
+func EnumFromString[E Enum](s string) (E, error) {
+	var zero E
+	v, err := enumValue(zero.Descriptor(), s)
+	if err != nil {
+		return zero, err
+	}
+	return E(v.Enum()), nil
+}
+
+// WithEnumNames converts protocol buffer enum values held in maps, lists and structs
+// to the names of their values, see EnumToString, instead of failing or, with
+// WithReflection, converting them to their numbers
+func WithEnumNames() Option {
+	return func(o *options) {
+		o.enumNames = true
+	}
+}
+
+// encodeEnum converts v to its name under WithEnumNames
+// Returns false if v is not an enum value or the option is not set
+func (e *encoder) encodeEnum(v any) (*structpb.Value, bool) {
+	if !e.opts.enumNames {
+		return nil, false
+	}
+	enum, ok := v.(protoreflect.Enum)
+	if !ok {
+		return nil, false
+	}
+	return e.newString(EnumToString(enum)), true
+}
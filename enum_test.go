@@ -0,0 +1,74 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestEnumToString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "TYPE_STRING", EnumToString(descriptorpb.FieldDescriptorProto_TYPE_STRING))
+	assert.Equal(t, "NULL_VALUE", EnumToString(structpb.NullValue_NULL_VALUE))
+	assert.Equal(t, "7", EnumToString(structpb.NullValue(7)))
+}
+
+func TestEnumFromString(t *testing.T) {
+	t.Parallel()
+
+	t.Run("names and numbers", func(t *testing.T) {
+		t.Parallel()
+		typ, err := EnumFromString[descriptorpb.FieldDescriptorProto_Type]("TYPE_STRING")
+		require.NoError(t, err)
+		assert.Equal(t, descriptorpb.FieldDescriptorProto_TYPE_STRING, typ)
+
+		typ, err = EnumFromString[descriptorpb.FieldDescriptorProto_Type]("9")
+		require.NoError(t, err)
+		assert.Equal(t, descriptorpb.FieldDescriptorProto_TYPE_STRING, typ)
+	})
+
+	t.Run("unknown values", func(t *testing.T) {
+		t.Parallel()
+		null, err := EnumFromString[structpb.NullValue]("7")
+		require.NoError(t, err, "open enums accept any number")
+		assert.Equal(t, structpb.NullValue(7), null)
+
+		_, err = EnumFromString[descriptorpb.FieldDescriptorProto_Type]("99")
+		require.ErrorIs(t, err, ErrNotCoercible)
+		require.ErrorContains(t, err, "closed enum")
+
+		_, err = EnumFromString[descriptorpb.FieldDescriptorProto_Type]("type_string")
+		require.ErrorIs(t, err, ErrNotCoercible)
+	})
+}
+
+func TestWithEnumNames(t *testing.T) {
+	t.Parallel()
+
+	in := map[string]any{
+		"type":  descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+		"types": []descriptorpb.FieldDescriptorProto_Type{descriptorpb.FieldDescriptorProto_TYPE_INT32},
+		"label": map[string]descriptorpb.FieldDescriptorProto_Label{"x": descriptorpb.FieldDescriptorProto_LABEL_REPEATED},
+	}
+	v, err := NewValue(in, WithEnumNames(), WithReflection())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"type":  "TYPE_BOOL",
+		"types": []any{"TYPE_INT32"},
+		"label": map[string]any{"x": "LABEL_REPEATED"},
+	}, v.AsInterface())
+
+	v, err = NewValue(in, WithReflection())
+	require.NoError(t, err)
+	assert.Equal(t, 8.0, v.GetStructValue().GetFields()["type"].GetNumberValue())
+
+	type field struct {
+		Type descriptorpb.FieldDescriptorProto_Type `json:"type"`
+	}
+	s, err := EncodeStruct(field{Type: descriptorpb.FieldDescriptorProto_TYPE_BYTES}, WithEnumNames())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"type": "TYPE_BYTES"}, s.AsMap())
+}
@@ -0,0 +1,106 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrEnvConflict is returned by EnvToStruct for variables that set the same key twice,
+// such as APP_DB holding a value and APP_DB_HOST a nested key
+var ErrEnvConflict = errors.New("conflicting environment variables")
+
+// StructToEnv flattens s into environment variables named after the path of each
+// leaf, so {"db": {"host": "x"}} gives APP_DB_HOST=x with the prefix "APP". Keys are
+// converted to upper snake case, "maxConns" becoming MAX_CONNS, with characters other
+// than letters, digits and underscores replaced by underscores. Scalars are formatted
+// with ToString and lists as JSON, where NaN and infinities become null. Nulls and
+// empty Structs are omitted. An empty prefix gives names without one
+func StructToEnv(s *structpb.Struct, prefix string) map[string]string {
+	env := make(map[string]string)
+	addEnvStruct(env, strings.TrimSuffix(prefix, "_"), s)
+	return env
+}
+
+func addEnvStruct(env map[string]string, name string, s *structpb.Struct) {
+	for key, v := range s.GetFields() {
+		addEnvValue(env, joinEnvName(name, key), v)
+	}
+}
+
+func addEnvValue(env map[string]string, name string, v *structpb.Value) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		addEnvStruct(env, name, kind.StructValue)
+	case *structpb.Value_ListValue:
+		w := jsonWriter{opts: newJSONOptions(nil), nonFiniteNull: true}
+		// cannot fail with nonFiniteNull
+		_ = w.writeList("", kind.ListValue)
+		env[name] = string(w.buf)
+	case *structpb.Value_NullValue, nil:
+	default:
+		env[name], _ = ToString(v)
+	}
+}
+
+// joinEnvName appends key, in upper snake case, to the variable name
+func joinEnvName(name, key string) string {
+	key = strings.Map(func(r rune) rune {
+		switch {
+		case 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.ToUpper(KeyCaseSnake.Convert(key)))
+	if name == "" {
+		return key
+	}
+	return name + "_" + key
+}
+
+// EnvToStruct is the reverse of StructToEnv. Every variable whose name starts with the
+// prefix and an underscore, ignoring case, is nested at the underscores of the rest of
+// its name, lower-cased, so APP_DB_HOST=x gives {"db": {"host": "x"}}. An empty prefix
+// takes every variable. As underscores always nest, keys of several words, which
+// StructToEnv writes as MAX_CONNS, come back nested as max and conns.
+//
+// Values are typed like QueryToStruct types query parameters, and those that are JSON
+// arrays or objects are parsed. Fails with ErrEnvConflict when a key is set both to a
+// value and to nested keys
+func EnvToStruct(env map[string]string, prefix string) (*structpb.Struct, error) {
+	prefix = strings.ToUpper(strings.TrimSuffix(prefix, "_"))
+	if prefix != "" {
+		prefix += "_"
+	}
+
+	root := &structpb.Struct{Fields: make(map[string]*structpb.Value)}
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		if len(name) < len(prefix) || !strings.EqualFold(name[:len(prefix)], prefix) {
+			continue
+		}
+		keys := strings.FieldsFunc(strings.ToLower(name[len(prefix):]), func(r rune) bool { return r == '_' })
+		if len(keys) == 0 {
+			continue
+		}
+		if err := setNestedValue(root, keys, inferEnvValue(env[name]), ErrEnvConflict); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return root, nil
+}
+
+// inferEnvValue is inferScalar, also parsing JSON arrays and objects
+func inferEnvValue(s string) *structpb.Value {
+	if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		v := &structpb.Value{}
+		if err := UnmarshalJSON([]byte(trimmed), v); err == nil {
+			return v
+		}
+	}
+	return inferScalar(s)
+}
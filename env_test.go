@@ -0,0 +1,103 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestStructToEnv(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"db": map[string]any{
+			"host":     "localhost",
+			"port":     5432.0,
+			"maxConns": 10.0,
+			"tls":      true,
+		},
+		"hosts":     []any{"a", 1.0, math.NaN()},
+		"log-level": "debug",
+		"unset":     nil,
+		"empty":     map[string]any{},
+	})
+	require.NoError(t, err)
+
+	want := map[string]string{
+		"APP_DB_HOST":      "localhost",
+		"APP_DB_PORT":      "5432",
+		"APP_DB_MAX_CONNS": "10",
+		"APP_DB_TLS":       "true",
+		"APP_HOSTS":        `["a",1,null]`,
+		"APP_LOG_LEVEL":    "debug",
+	}
+	assert.Equal(t, want, StructToEnv(s, "APP"))
+	assert.Equal(t, want, StructToEnv(s, "APP_"))
+
+	assert.Equal(t, "localhost", StructToEnv(s, "")["DB_HOST"])
+	assert.Empty(t, StructToEnv(nil, "APP"))
+}
+
+func TestEnvToStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nests and infers types", func(t *testing.T) {
+		t.Parallel()
+		got, err := EnvToStruct(map[string]string{
+			"APP_DB_HOST":   "localhost",
+			"APP_DB_PORT":   "5432",
+			"app_db_tls":    "true",
+			"APP_ZIP":       "007",
+			"APP_HOSTS":     `["a", 1]`,
+			"APP_LIMITS":    `{"burst": 5}`,
+			"APP_BROKEN":    `[not json`,
+			"APP_":          "ignored",
+			"OTHER_DB_HOST": "elsewhere",
+			"APPLE":         "ignored",
+		}, "APP")
+		require.NoError(t, err)
+
+		want, err := structpb.NewStruct(map[string]any{
+			"db":     map[string]any{"host": "localhost", "port": 5432.0, "tls": true},
+			"zip":    "007",
+			"hosts":  []any{"a", 1.0},
+			"limits": map[string]any{"burst": 5.0},
+			"broken": "[not json",
+		})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, got), "%v", got)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"db":    map[string]any{"host": "localhost", "port": 5432.0},
+			"hosts": []any{"a", "b"},
+		})
+		require.NoError(t, err)
+		got, err := EnvToStruct(StructToEnv(s, "APP"), "app")
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(s, got), "%v", got)
+	})
+
+	t.Run("no prefix", func(t *testing.T) {
+		t.Parallel()
+		got, err := EnvToStruct(map[string]string{"HOME": "/root"}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "/root", got.GetFields()["home"].GetStringValue())
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		t.Parallel()
+		_, err := EnvToStruct(map[string]string{"APP_DB": "x", "APP_DB_HOST": "y"}, "APP")
+		require.ErrorIs(t, err, ErrEnvConflict)
+		assert.ErrorContains(t, err, "APP_DB_HOST")
+
+		_, err = EnvToStruct(map[string]string{"APP_DB": "x", "app_db": "y"}, "APP")
+		require.ErrorIs(t, err, ErrEnvConflict)
+	})
+}
@@ -0,0 +1,158 @@
+package protobaggins
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// EqualOption configures Equal
+type EqualOption func(*equalOptions)
+
+type equalOptions struct {
+	epsilon       float64
+	nullIsMissing bool
+	unordered     bool
+}
+
+// EqualEpsilon treats numbers as equal when they differ by at most epsilon, which
+// absorbs rounding from float64 round-trips
+func EqualEpsilon(epsilon float64) EqualOption {
+	return func(o *equalOptions) {
+		o.epsilon = epsilon
+	}
+}
+
+// EqualNullMissing treats a struct field holding null as equal to a missing field
+func EqualNullMissing() EqualOption {
+	return func(o *equalOptions) {
+		o.nullIsMissing = true
+	}
+}
+
+// EqualIgnoreListOrder compares lists as multisets, equal when their items can be
+// paired up one to one with every pair equal
+func EqualIgnoreListOrder() EqualOption {
+	return func(o *equalOptions) {
+		o.unordered = true
+	}
+}
+
+// Equal reports whether a and b hold the same value. Without options it agrees with
+// proto.Equal, except that a nil value equals one without a kind; the options relax
+// the comparison for JSON-like data that went through lossy conversions
+func Equal(a, b *structpb.Value, opts ...EqualOption) bool {
+	var o equalOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.equal(a, b)
+}
+
+// EqualStructs is Equal for Structs
+func EqualStructs(a, b *structpb.Struct, opts ...EqualOption) bool {
+	var o equalOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.equalStructs(a, b)
+}
+
+func (o *equalOptions) equal(a, b *structpb.Value) bool {
+	if KindOf(a) != KindOf(b) {
+		return false
+	}
+
+	switch a := a.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return a.BoolValue == b.GetBoolValue()
+	case *structpb.Value_NumberValue:
+		x, y := a.NumberValue, b.GetNumberValue()
+		return x == y || math.Abs(x-y) <= o.epsilon
+	case *structpb.Value_StringValue:
+		return a.StringValue == b.GetStringValue()
+	case *structpb.Value_ListValue:
+		return o.equalLists(a.ListValue.GetValues(), b.GetListValue().GetValues())
+	case *structpb.Value_StructValue:
+		return o.equalStructs(a.StructValue, b.GetStructValue())
+	default:
+		return true
+	}
+}
+
+func (o *equalOptions) equalStructs(a, b *structpb.Struct) bool {
+	if !o.nullIsMissing && len(a.GetFields()) != len(b.GetFields()) {
+		return false
+	}
+	for key, av := range a.GetFields() {
+		bv, ok := b.GetFields()[key]
+		switch {
+		case ok && !o.equal(av, bv):
+			return false
+		case !ok && !(o.nullIsMissing && KindOf(av) == KindNull):
+			return false
+		}
+	}
+	if o.nullIsMissing {
+		for key, bv := range b.GetFields() {
+			if _, ok := a.GetFields()[key]; !ok && KindOf(bv) != KindNull {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (o *equalOptions) equalLists(a, b []*structpb.Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if !o.unordered {
+		for i := range a {
+			if !o.equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// with an epsilon equality is not transitive, so pairing the first equal item
+	// found can fail where another pairing succeeds; find a perfect matching instead
+	edges := make([][]int, len(a))
+	for i := range a {
+		for j := range b {
+			if o.equal(a[i], b[j]) {
+				edges[i] = append(edges[i], j)
+			}
+		}
+		if len(edges[i]) == 0 {
+			return false
+		}
+	}
+	pairedWith := make([]int, len(b))
+	for j := range pairedWith {
+		pairedWith[j] = -1
+	}
+	for i := range a {
+		if !augment(i, edges, pairedWith, make([]bool, len(b))) {
+			return false
+		}
+	}
+	return true
+}
+
+// augment looks for an augmenting path from item i of the first list, reporting whether
+// i could be paired, possibly by re-pairing earlier items
+func augment(i int, edges [][]int, pairedWith []int, visited []bool) bool {
+	for _, j := range edges[i] {
+		if visited[j] {
+			continue
+		}
+		visited[j] = true
+		if pairedWith[j] < 0 || augment(pairedWith[j], edges, pairedWith, visited) {
+			pairedWith[j] = i
+			return true
+		}
+	}
+	return false
+}
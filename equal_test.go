@@ -0,0 +1,80 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	mustValue := func(t *testing.T, v any) *structpb.Value {
+		t.Helper()
+		value, err := structpb.NewValue(v)
+		require.NoError(t, err)
+		return value
+	}
+
+	tests := []struct {
+		name string
+		a, b any
+		opts []EqualOption
+		want bool
+	}{
+		{"same struct", map[string]any{"a": []any{1, "x"}}, map[string]any{"a": []any{1, "x"}}, nil, true},
+		{"different number", 0.3, 0.30000000000000004, nil, false},
+		{"number within epsilon", 0.3, 0.30000000000000004, []EqualOption{EqualEpsilon(1e-9)}, true},
+		{"number outside epsilon", 1.0, 1.1, []EqualOption{EqualEpsilon(0.01)}, false},
+		{"different kinds", 1, "1", nil, false},
+		{"null vs missing", map[string]any{"a": 1, "b": nil}, map[string]any{"a": 1}, nil, false},
+		{"null as missing", map[string]any{"a": 1, "b": nil}, map[string]any{"a": 1}, []EqualOption{EqualNullMissing()}, true},
+		{"null as missing reversed", map[string]any{"a": 1}, map[string]any{"a": 1, "b": nil}, []EqualOption{EqualNullMissing()}, true},
+		{"null as missing nested", map[string]any{"a": map[string]any{"b": nil}}, map[string]any{"a": map[string]any{}}, []EqualOption{EqualNullMissing()}, true},
+		{"missing non-null", map[string]any{"a": 1, "b": 2}, map[string]any{"a": 1}, []EqualOption{EqualNullMissing()}, false},
+		{"list order", []any{1, 2, 2}, []any{2, 1, 2}, nil, false},
+		{"list order ignored", []any{1, 2, 2}, []any{2, 1, 2}, []EqualOption{EqualIgnoreListOrder()}, true},
+		{"list multiset", []any{1, 1, 2}, []any{1, 2, 2}, []EqualOption{EqualIgnoreListOrder()}, false},
+		{"list length", []any{1}, []any{1, 1}, []EqualOption{EqualIgnoreListOrder()}, false},
+		{
+			"list needs matching", []any{1.0, 1.5}, []any{1.4, 0.9},
+			[]EqualOption{EqualIgnoreListOrder(), EqualEpsilon(0.5)}, true,
+		},
+		{
+			"nested unordered", []any{map[string]any{"l": []any{"b", "a"}}, "x"}, []any{"x", map[string]any{"l": []any{"a", "b"}}},
+			[]EqualOption{EqualIgnoreListOrder()}, true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a, b := mustValue(t, tt.a), mustValue(t, tt.b)
+			assert.Equal(t, tt.want, Equal(a, b, tt.opts...))
+			assert.Equal(t, tt.want, Equal(b, a, tt.opts...))
+			if tt.opts == nil {
+				assert.Equal(t, proto.Equal(a, b), Equal(a, b))
+			}
+		})
+	}
+
+	t.Run("unset and nan", func(t *testing.T) {
+		t.Parallel()
+		assert.True(t, Equal(nil, &structpb.Value{}))
+		assert.False(t, Equal(nil, structpb.NewNullValue()))
+		nan := structpb.NewNumberValue(math.NaN())
+		assert.False(t, Equal(nan, nan, EqualEpsilon(1)))
+	})
+
+	t.Run("structs", func(t *testing.T) {
+		t.Parallel()
+		a := mustValue(t, map[string]any{"a": 1.0, "b": nil}).GetStructValue()
+		b := mustValue(t, map[string]any{"a": 1.0000001}).GetStructValue()
+		assert.False(t, EqualStructs(a, b))
+		assert.True(t, EqualStructs(a, b, EqualNullMissing(), EqualEpsilon(1e-6)))
+		assert.True(t, EqualStructs(nil, &structpb.Struct{}))
+	})
+}
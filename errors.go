@@ -0,0 +1,38 @@
+package protobaggins
+
+import "errors"
+
+// ErrUnexpectedKind is returned when a *structpb.Value holds a different kind than required
+var ErrUnexpectedKind = errors.New("unexpected value kind")
+
+// ErrUndefinedVariable is returned when a template placeholder names an unknown variable
+var ErrUndefinedVariable = errors.New("undefined variable")
+
+// ErrNotCoercible is returned when a value of a supported kind cannot be converted,
+// such as a string that is not a number or a fractional number requested as an integer
+var ErrNotCoercible = errors.New("value not coercible")
+
+// ErrMaxDepth is returned when a value nests deeper than the configured limit
+var ErrMaxDepth = errors.New("maximum depth exceeded")
+
+// ErrTypeMismatch is returned when a message has a different type than required, such
+// as an Any holding another message than the one it is unpacked to
+var ErrTypeMismatch = errors.New("message type mismatch")
+
+// ErrNilMessage is returned when a message is required but nil was given
+var ErrNilMessage = errors.New("nil message")
+
+// ErrPathNotFound is returned when a path does not resolve to a value
+var ErrPathNotFound = errors.New("path not found")
+
+// ErrNonFinite is returned when a NaN or infinite number is converted under
+// NonFiniteError
+var ErrNonFinite = errors.New("non-finite number")
+
+// ErrCycle is returned when a Go value contains itself, such as a map stored in one of
+// its own entries
+var ErrCycle = errors.New("cycle detected")
+
+// ErrNotStable is returned when decoding bytes that MarshalStable would not have
+// produced, such as unsorted keys or a number in non-canonical form
+var ErrNotStable = errors.New("not a stable encoding")
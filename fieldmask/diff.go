@@ -0,0 +1,173 @@
+package fieldmask
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// DiffOption configures Diff
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	mapKeys     bool
+	ignoreOrder bool
+}
+
+// MapKeys names changed map entries individually, as "labels.env", instead of naming the
+// whole map field. Maps with a key that cannot be a path segment, because it is empty
+// or contains a dot, are still named as a whole
+func MapKeys() DiffOption {
+	return func(o *diffOptions) {
+		o.mapKeys = true
+	}
+}
+
+// IgnoreOrder compares repeated fields as multisets, so that reordering their elements
+// is not a change
+func IgnoreOrder() DiffOption {
+	return func(o *diffOptions) {
+		o.ignoreOrder = true
+	}
+}
+
+// Diff returns a mask of the paths that differ between original and updated, which
+// must be of the same type. Applying the mask with Apply(original, updated, mask) makes
+// original equal to updated. Singular message fields set in both are descended into,
+// while repeated fields, and map fields unless MapKeys is given, are named as a whole.
+// Paths are in field declaration order
+func Diff(original, updated proto.Message, opts ...DiffOption) (*fieldmaskpb.FieldMask, error) {
+	if original == nil || updated == nil {
+		return nil, errors.New("cannot diff a nil message")
+	}
+	a, b := original.ProtoReflect(), updated.ProtoReflect()
+	if a.Descriptor().FullName() != b.Descriptor().FullName() {
+		return nil, fmt.Errorf("%w: cannot diff %s against %s",
+			ErrMessageMismatch, a.Descriptor().FullName(), b.Descriptor().FullName())
+	}
+
+	var o diffOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	mask := &fieldmaskpb.FieldMask{}
+	o.diffMessage("", a, b, &mask.Paths)
+	return mask, nil
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func (o *diffOptions) diffMessage(prefix string, a, b protoreflect.Message, paths *[]string) {
+	fields := a.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		path := joinPath(prefix, string(fd.Name()))
+		hasA, hasB := a.Has(fd), b.Has(fd)
+
+		switch {
+		case !hasA && !hasB:
+			continue
+		case fd.IsMap():
+			o.diffMap(path, a.Get(fd).Map(), b.Get(fd).Map(), paths)
+		case fd.IsList():
+			if !o.listsEqual(a.Get(fd).List(), b.Get(fd).List()) {
+				*paths = append(*paths, path)
+			}
+		case fd.Message() != nil && hasA && hasB:
+			o.diffMessage(path, a.Get(fd).Message(), b.Get(fd).Message(), paths)
+		case hasA != hasB || !a.Get(fd).Equal(b.Get(fd)):
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+func (o *diffOptions) listsEqual(a, b protoreflect.List) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+	if !o.ignoreOrder {
+		for i := range a.Len() {
+			if !a.Get(i).Equal(b.Get(i)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	matched := make([]bool, b.Len())
+	for i := range a.Len() {
+		found := false
+		for j := range b.Len() {
+			if !matched[j] && a.Get(i).Equal(b.Get(j)) {
+				matched[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *diffOptions) diffMap(path string, a, b protoreflect.Map, paths *[]string) {
+	var changed []protoreflect.MapKey
+	a.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+		if !b.Has(k) || !v.Equal(b.Get(k)) {
+			changed = append(changed, k)
+		}
+		return true
+	})
+	b.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		if !a.Has(k) {
+			changed = append(changed, k)
+		}
+		return true
+	})
+	if len(changed) == 0 {
+		return
+	}
+
+	wholeMap := !o.mapKeys
+	for _, k := range changed {
+		if s := k.String(); s == "" || strings.Contains(s, ".") {
+			wholeMap = true
+		}
+	}
+	if wholeMap {
+		*paths = append(*paths, path)
+		return
+	}
+
+	slices.SortFunc(changed, compareMapKeys)
+	for _, k := range changed {
+		*paths = append(*paths, joinPath(path, k.String()))
+	}
+}
+
+// compareMapKeys orders keys of the same map, numerically for integer keys
+func compareMapKeys(a, b protoreflect.MapKey) int {
+	switch av := a.Interface().(type) {
+	case int32:
+		return cmp.Compare(av, b.Interface().(int32))
+	case int64:
+		return cmp.Compare(av, b.Interface().(int64))
+	case uint32:
+		return cmp.Compare(av, b.Interface().(uint32))
+	case uint64:
+		return cmp.Compare(av, b.Interface().(uint64))
+	default:
+		return strings.Compare(a.String(), b.String())
+	}
+}
@@ -0,0 +1,106 @@
+package fieldmask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("identical messages", func(t *testing.T) {
+		t.Parallel()
+		mask, err := Diff(shire(), shire())
+		require.NoError(t, err)
+		assert.Empty(t, mask.GetPaths())
+	})
+
+	t.Run("nested, cleared and repeated fields", func(t *testing.T) {
+		t.Parallel()
+		original := shire()
+		original.Dependency = []string{"a.proto", "b.proto"}
+
+		updated := shire()
+		updated.Package = nil
+		updated.Syntax = proto.String("proto3")
+		updated.Options.GoPackage = proto.String("example.com/bree")
+		updated.MessageType[0].Name = proto.String("Dwarf")
+		updated.Dependency = []string{"b.proto", "a.proto"}
+		updated.SourceCodeInfo = &descriptorpb.SourceCodeInfo{}
+
+		mask, err := Diff(original, updated)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"package", "dependency", "message_type", "options.go_package", "source_code_info", "syntax"}, mask.GetPaths())
+
+		require.NoError(t, Apply(original, updated, mask))
+		assert.True(t, proto.Equal(updated, original))
+	})
+
+	t.Run("ignoring order", func(t *testing.T) {
+		t.Parallel()
+		original, updated := shire(), shire()
+		original.Dependency = []string{"a.proto", "b.proto", "a.proto"}
+		updated.Dependency = []string{"a.proto", "a.proto", "b.proto"}
+
+		mask, err := Diff(original, updated, IgnoreOrder())
+		require.NoError(t, err)
+		assert.Empty(t, mask.GetPaths())
+
+		updated.Dependency = []string{"a.proto", "b.proto", "b.proto"}
+		mask, err = Diff(original, updated, IgnoreOrder())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dependency"}, mask.GetPaths())
+	})
+
+	t.Run("maps as a whole", func(t *testing.T) {
+		t.Parallel()
+		original, err := structpb.NewStruct(map[string]any{"a": 1, "b": 2})
+		require.NoError(t, err)
+		updated, err := structpb.NewStruct(map[string]any{"a": 1, "b": 3})
+		require.NoError(t, err)
+
+		mask, err := Diff(original, updated)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fields"}, mask.GetPaths())
+	})
+
+	t.Run("map keys", func(t *testing.T) {
+		t.Parallel()
+		original, err := structpb.NewStruct(map[string]any{"a": 1, "b": 2, "c": 3})
+		require.NoError(t, err)
+		updated, err := structpb.NewStruct(map[string]any{"a": 1, "b": 4, "d": 5})
+		require.NoError(t, err)
+
+		mask, err := Diff(original, updated, MapKeys())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fields.b", "fields.c", "fields.d"}, mask.GetPaths())
+
+		require.NoError(t, Apply(original, updated, mask))
+		assert.True(t, proto.Equal(updated, original))
+	})
+
+	t.Run("map keys that are not path segments", func(t *testing.T) {
+		t.Parallel()
+		original := &structpb.Struct{}
+		updated, err := structpb.NewStruct(map[string]any{"a.b": 1})
+		require.NoError(t, err)
+
+		mask, err := Diff(original, updated, MapKeys())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fields"}, mask.GetPaths())
+	})
+
+	t.Run("rejects different types", func(t *testing.T) {
+		t.Parallel()
+		_, err := Diff(shire(), wrapperspb.String("x"))
+		require.ErrorIs(t, err, ErrMessageMismatch)
+		_, err = Diff(nil, shire())
+		require.Error(t, err)
+	})
+}
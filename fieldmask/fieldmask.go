@@ -0,0 +1,278 @@
+// Package fieldmask builds and validates google.protobuf.FieldMask values against
+// message descriptors, and applies them to copy, clear or filter message fields
+//
+// Paths are dotted proto field names such as "spec.replicas". Every segment but the
+// last must name a singular message field, with one exception: the last segment may be
+// a key of a map field, as in "labels.env". Repeated fields can be masked as a whole but
+// not descended into
+package fieldmask
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// ErrInvalidPath is returned when a mask path does not resolve against a message
+var ErrInvalidPath = errors.New("invalid field mask path")
+
+// ErrMessageMismatch is returned when messages of different types are combined
+var ErrMessageMismatch = errors.New("message type mismatch")
+
+// New builds a mask from paths, failing if any of them does not resolve against md
+func New(md protoreflect.MessageDescriptor, paths ...string) (*fieldmaskpb.FieldMask, error) {
+	mask := &fieldmaskpb.FieldMask{Paths: slices.Clone(paths)}
+	if err := Validate(md, mask); err != nil {
+		return nil, err
+	}
+	return mask, nil
+}
+
+// Parse builds a mask from a comma-separated list of paths, e.g. "name, spec.replicas"
+// An empty string gives an empty mask
+func Parse(md protoreflect.MessageDescriptor, s string) (*fieldmaskpb.FieldMask, error) {
+	if strings.TrimSpace(s) == "" {
+		return &fieldmaskpb.FieldMask{}, nil
+	}
+	paths := strings.Split(s, ",")
+	for i, path := range paths {
+		paths[i] = strings.TrimSpace(path)
+	}
+	return New(md, paths...)
+}
+
+// Validate checks every path of mask against md
+// The error lists every invalid path
+func Validate(md protoreflect.MessageDescriptor, mask *fieldmaskpb.FieldMask) error {
+	var errs []error
+	for _, path := range mask.GetPaths() {
+		if _, err := resolve(md, path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fieldPath is a resolved mask path: the fields along it, and for paths ending in a
+// map key, that key of the last field
+type fieldPath struct {
+	fields []protoreflect.FieldDescriptor
+	key    protoreflect.MapKey
+	hasKey bool
+}
+
+// resolve returns the field descriptors along path
+func resolve(md protoreflect.MessageDescriptor, path string) (fieldPath, error) {
+	if path == "" {
+		return fieldPath{}, fmt.Errorf("%w: empty path", ErrInvalidPath)
+	}
+
+	segments := strings.Split(path, ".")
+	var fp fieldPath
+	current := md
+	for i, name := range segments {
+		if current == nil {
+			parent := fp.fields[i-1]
+			if parent.IsMap() && i == len(segments)-1 {
+				key, err := parseMapKey(parent.MapKey(), name)
+				if err != nil {
+					return fieldPath{}, fmt.Errorf("%w: %q: %w", ErrInvalidPath, path, err)
+				}
+				fp.key, fp.hasKey = key, true
+				return fp, nil
+			}
+			return fieldPath{}, fmt.Errorf("%w: %q: cannot descend into %s field %s",
+				ErrInvalidPath, path, describeField(parent), parent.Name())
+		}
+		fd := current.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return fieldPath{}, fmt.Errorf("%w: %q: %s has no field %q", ErrInvalidPath, path, current.FullName(), name)
+		}
+		fp.fields = append(fp.fields, fd)
+
+		current = nil
+		if fd.Message() != nil && fd.Cardinality() != protoreflect.Repeated {
+			current = fd.Message()
+		}
+	}
+	return fp, nil
+}
+
+func describeField(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsMap():
+		return "map"
+	case fd.IsList():
+		return "repeated"
+	default:
+		return fd.Kind().String()
+	}
+}
+
+// parseMapKey converts a path segment to a key of the map key field fd
+func parseMapKey(fd protoreflect.FieldDescriptor, s string) (protoreflect.MapKey, error) {
+	var (
+		v   protoreflect.Value
+		err error
+	)
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		v = protoreflect.ValueOfString(s)
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, 32)
+		v = protoreflect.ValueOfInt32(int32(i))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, 64)
+		v = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var u uint64
+		u, err = strconv.ParseUint(s, 10, 32)
+		v = protoreflect.ValueOfUint32(uint32(u))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var u uint64
+		u, err = strconv.ParseUint(s, 10, 64)
+		v = protoreflect.ValueOfUint64(u)
+	default:
+		return protoreflect.MapKey{}, fmt.Errorf("unsupported map key kind %s", fd.Kind())
+	}
+	if err != nil {
+		return protoreflect.MapKey{}, fmt.Errorf("%q is not a valid %s map key", s, fd.Kind())
+	}
+	return v.MapKey(), nil
+}
+
+// resolveAll validates mask against m and returns every resolved path
+func resolveAll(m protoreflect.Message, mask *fieldmaskpb.FieldMask) ([]fieldPath, error) {
+	if err := Validate(m.Descriptor(), mask); err != nil {
+		return nil, err
+	}
+	all := make([]fieldPath, len(mask.GetPaths()))
+	for i, path := range mask.GetPaths() {
+		all[i], _ = resolve(m.Descriptor(), path)
+	}
+	return all, nil
+}
+
+// Apply copies the masked fields from src to dst, which must be of the same type.
+// A masked field that is unset in src is cleared in dst, so Apply implements the
+// update semantics of an API taking an update_mask. Values are deep copied
+func Apply(dst, src proto.Message, mask *fieldmaskpb.FieldMask) error {
+	dstMsg, srcMsg := dst.ProtoReflect(), src.ProtoReflect()
+	if dstMsg.Descriptor().FullName() != srcMsg.Descriptor().FullName() {
+		return fmt.Errorf("%w: cannot apply %s to %s",
+			ErrMessageMismatch, srcMsg.Descriptor().FullName(), dstMsg.Descriptor().FullName())
+	}
+	all, err := resolveAll(dstMsg, mask)
+	if err != nil {
+		return err
+	}
+	for _, fp := range all {
+		copyPath(dstMsg, srcMsg, fp, 0)
+	}
+	return nil
+}
+
+func copyPath(dst, src protoreflect.Message, fp fieldPath, i int) {
+	fd := fp.fields[i]
+	if i == len(fp.fields)-1 {
+		switch {
+		case fp.hasKey:
+			copyMapEntry(dst, src, fd, fp.key)
+		case !src.Has(fd):
+			dst.Clear(fd)
+		default:
+			dst.Set(fd, cloneField(src, fd))
+		}
+		return
+	}
+
+	if !src.Has(fd) {
+		if dst.Has(fd) {
+			clearPath(dst.Mutable(fd).Message(), fp, i+1)
+		}
+		return
+	}
+	copyPath(dst.Mutable(fd).Message(), src.Get(fd).Message(), fp, i+1)
+}
+
+func copyMapEntry(dst, src protoreflect.Message, fd protoreflect.FieldDescriptor, key protoreflect.MapKey) {
+	srcMap := src.Get(fd).Map()
+	if !srcMap.Has(key) {
+		if dst.Has(fd) {
+			dst.Mutable(fd).Map().Clear(key)
+		}
+		return
+	}
+	value := srcMap.Get(key)
+	if fd.MapValue().Message() != nil {
+		value = protoreflect.ValueOfMessage(proto.Clone(value.Message().Interface()).ProtoReflect())
+	}
+	dst.Mutable(fd).Map().Set(key, value)
+}
+
+// cloneField returns a deep copy of the value of fd in m
+func cloneField(m protoreflect.Message, fd protoreflect.FieldDescriptor) protoreflect.Value {
+	// cloning through a message holding only fd deep copies lists, maps and messages
+	tmp := m.New()
+	tmp.Set(fd, m.Get(fd))
+	return proto.Clone(tmp.Interface()).ProtoReflect().Get(fd)
+}
+
+// Prune clears the masked fields of m, leaving everything else untouched
+func Prune(m proto.Message, mask *fieldmaskpb.FieldMask) error {
+	msg := m.ProtoReflect()
+	all, err := resolveAll(msg, mask)
+	if err != nil {
+		return err
+	}
+	for _, fp := range all {
+		clearPath(msg, fp, 0)
+	}
+	return nil
+}
+
+func clearPath(m protoreflect.Message, fp fieldPath, i int) {
+	fd := fp.fields[i]
+	if i == len(fp.fields)-1 {
+		switch {
+		case !fp.hasKey:
+			m.Clear(fd)
+		case m.Has(fd):
+			m.Mutable(fd).Map().Clear(fp.key)
+		}
+		return
+	}
+	if m.Has(fd) {
+		clearPath(m.Mutable(fd).Message(), fp, i+1)
+	}
+}
+
+// Filter clears every field of m that is not masked, the inverse of Prune, e.g. to
+// return only the fields a caller asked for through a read_mask
+func Filter(m proto.Message, mask *fieldmaskpb.FieldMask) error {
+	msg := m.ProtoReflect()
+	all, err := resolveAll(msg, mask)
+	if err != nil {
+		return err
+	}
+
+	kept := msg.New()
+	for _, fp := range all {
+		copyPath(kept, msg, fp, 0)
+	}
+	proto.Reset(m)
+	proto.Merge(m, kept.Interface())
+	return nil
+}
@@ -0,0 +1,205 @@
+package fieldmask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+var fileDescriptor = (&descriptorpb.FileDescriptorProto{}).ProtoReflect().Descriptor()
+
+func shire() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shire.proto"),
+		Package: proto.String("shire"),
+		Options: &descriptorpb.FileOptions{
+			GoPackage:   proto.String("example.com/shire"),
+			JavaPackage: proto.String("com.example.shire"),
+		},
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Hobbit")}},
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid paths", func(t *testing.T) {
+		t.Parallel()
+		mask, err := New(fileDescriptor, "name", "options.go_package", "message_type")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"name", "options.go_package", "message_type"}, mask.GetPaths())
+	})
+
+	t.Run("reports every invalid path", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(fileDescriptor, "name", "", "nope", "options.nope", "message_type.name", "name.x")
+		require.ErrorIs(t, err, ErrInvalidPath)
+		assert.Equal(t, `invalid field mask path: empty path
+invalid field mask path: "nope": google.protobuf.FileDescriptorProto has no field "nope"
+invalid field mask path: "options.nope": google.protobuf.FileOptions has no field "nope"
+invalid field mask path: "message_type.name": cannot descend into repeated field message_type
+invalid field mask path: "name.x": cannot descend into string field name`, err.Error())
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("splits and trims", func(t *testing.T) {
+		t.Parallel()
+		mask, err := Parse(fileDescriptor, "name, options.go_package")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"name", "options.go_package"}, mask.GetPaths())
+	})
+
+	t.Run("empty string", func(t *testing.T) {
+		t.Parallel()
+		mask, err := Parse(fileDescriptor, " ")
+		require.NoError(t, err)
+		assert.Empty(t, mask.GetPaths())
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		t.Parallel()
+		_, err := Parse(fileDescriptor, "name,,package")
+		require.ErrorIs(t, err, ErrInvalidPath)
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("copies masked fields only", func(t *testing.T) {
+		t.Parallel()
+		dst := &descriptorpb.FileDescriptorProto{
+			Name:    proto.String("old.proto"),
+			Package: proto.String("old"),
+			Options: &descriptorpb.FileOptions{JavaPackage: proto.String("com.example.old")},
+		}
+		src := shire()
+		mask := &fieldmaskpb.FieldMask{Paths: []string{"name", "options.go_package", "message_type"}}
+		require.NoError(t, Apply(dst, src, mask))
+
+		want := &descriptorpb.FileDescriptorProto{
+			Name:    proto.String("shire.proto"),
+			Package: proto.String("old"),
+			Options: &descriptorpb.FileOptions{
+				GoPackage:   proto.String("example.com/shire"),
+				JavaPackage: proto.String("com.example.old"),
+			},
+			MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Hobbit")}},
+		}
+		assert.True(t, proto.Equal(want, dst), "got %v", dst)
+
+		src.MessageType[0].Name = proto.String("Changed")
+		assert.Equal(t, "Hobbit", dst.GetMessageType()[0].GetName(), "values are deep copied")
+	})
+
+	t.Run("unset source fields clear the destination", func(t *testing.T) {
+		t.Parallel()
+		dst := shire()
+		src := &descriptorpb.FileDescriptorProto{}
+		mask := &fieldmaskpb.FieldMask{Paths: []string{"package", "options.go_package"}}
+		require.NoError(t, Apply(dst, src, mask))
+
+		assert.Nil(t, dst.Package)
+		assert.Nil(t, dst.GetOptions().GoPackage)
+		assert.Equal(t, "com.example.shire", dst.GetOptions().GetJavaPackage())
+	})
+
+	t.Run("rejects different types", func(t *testing.T) {
+		t.Parallel()
+		err := Apply(shire(), wrapperspb.String("x"), &fieldmaskpb.FieldMask{})
+		require.ErrorIs(t, err, ErrMessageMismatch)
+	})
+
+	t.Run("rejects invalid masks", func(t *testing.T) {
+		t.Parallel()
+		dst := shire()
+		err := Apply(dst, &descriptorpb.FileDescriptorProto{}, &fieldmaskpb.FieldMask{Paths: []string{"name", "nope"}})
+		require.ErrorIs(t, err, ErrInvalidPath)
+		assert.Equal(t, "shire.proto", dst.GetName(), "nothing is applied")
+	})
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	m := shire()
+	require.NoError(t, Prune(m, &fieldmaskpb.FieldMask{Paths: []string{"name", "options.go_package", "source_code_info.location"}}))
+
+	want := shire()
+	want.Name = nil
+	want.Options.GoPackage = nil
+	assert.True(t, proto.Equal(want, m), "got %v", m)
+	assert.Nil(t, m.GetSourceCodeInfo(), "unset parents are not created")
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("keeps masked fields only", func(t *testing.T) {
+		t.Parallel()
+		m := shire()
+		require.NoError(t, Filter(m, &fieldmaskpb.FieldMask{Paths: []string{"package", "options.java_package", "syntax"}}))
+
+		want := &descriptorpb.FileDescriptorProto{
+			Package: proto.String("shire"),
+			Options: &descriptorpb.FileOptions{JavaPackage: proto.String("com.example.shire")},
+		}
+		assert.True(t, proto.Equal(want, m), "got %v", m)
+	})
+
+	t.Run("empty mask clears everything", func(t *testing.T) {
+		t.Parallel()
+		m := shire()
+		require.NoError(t, Filter(m, &fieldmaskpb.FieldMask{}))
+		assert.True(t, proto.Equal(&descriptorpb.FileDescriptorProto{}, m))
+	})
+}
+
+func TestMapKeyPaths(t *testing.T) {
+	t.Parallel()
+
+	structDescriptor := (&structpb.Struct{}).ProtoReflect().Descriptor()
+	newStruct := func(m map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("validates the key as the last segment only", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(structDescriptor, "fields.env")
+		require.NoError(t, err)
+		_, err = New(structDescriptor, "fields.env.x")
+		require.ErrorIs(t, err, ErrInvalidPath)
+	})
+
+	t.Run("apply copies and deletes entries", func(t *testing.T) {
+		t.Parallel()
+		dst := newStruct(map[string]any{"a": 1, "b": 2, "c": 3})
+		src := newStruct(map[string]any{"a": 9, "b": 8})
+		require.NoError(t, Apply(dst, src, &fieldmaskpb.FieldMask{Paths: []string{"fields.b", "fields.c"}}))
+		assert.Equal(t, map[string]any{"a": 1.0, "b": 8.0}, dst.AsMap())
+	})
+
+	t.Run("prune and filter", func(t *testing.T) {
+		t.Parallel()
+		mask := &fieldmaskpb.FieldMask{Paths: []string{"fields.a"}}
+
+		pruned := newStruct(map[string]any{"a": 1, "b": 2})
+		require.NoError(t, Prune(pruned, mask))
+		assert.Equal(t, map[string]any{"b": 2.0}, pruned.AsMap())
+
+		filtered := newStruct(map[string]any{"a": 1, "b": 2})
+		require.NoError(t, Filter(filtered, mask))
+		assert.Equal(t, map[string]any{"a": 1.0}, filtered.AsMap())
+	})
+}
@@ -0,0 +1,76 @@
+package protobaggins
+
+import (
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Match is a value found by FindAll together with its path
+type Match struct {
+	Path  string
+	Value *structpb.Value
+}
+
+// FindOption configures FindAll
+type FindOption func(*findOptions)
+
+type findOptions struct {
+	first bool
+}
+
+// FindFirst stops the search at the first match
+func FindFirst() FindOption {
+	return func(o *findOptions) {
+		o.first = true
+	}
+}
+
+// FindAll returns every value in v, including v itself at the empty path, for which
+// pred returns true. Values are visited in pre-order with struct keys in sorted order,
+// so results are deterministic and a container is reported before its contents.
+// The returned values are the nodes of v, not copies
+func FindAll(v *structpb.Value, pred func(path string, v *structpb.Value) bool, opts ...FindOption) []Match {
+	var o findOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	f := finder{pred: pred, first: o.first}
+	f.find("", v)
+	return f.matches
+}
+
+type finder struct {
+	pred    func(path string, v *structpb.Value) bool
+	first   bool
+	matches []Match
+}
+
+// find reports whether the search should stop
+func (f *finder) find(path string, v *structpb.Value) bool {
+	if v == nil {
+		return false
+	}
+	if f.pred(path, v) {
+		f.matches = append(f.matches, Match{Path: path, Value: v})
+		if f.first {
+			return true
+		}
+	}
+
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		for _, key := range sortedKeys(kind.StructValue) {
+			if f.find(joinKey(path, key), fields[key]) {
+				return true
+			}
+		}
+	case *structpb.Value_ListValue:
+		for i, item := range kind.ListValue.GetValues() {
+			if f.find(joinIndex(path, i), item) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package protobaggins
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFindAll(t *testing.T) {
+	t.Parallel()
+
+	doc, err := structpb.NewValue(map[string]any{
+		"owner": map[string]any{"email": "frodo@shire.example", "name": "frodo"},
+		"notes": []any{"call sam@shire.example", 42, nil},
+		"email": "bilbo@shire.example",
+	})
+	require.NoError(t, err)
+
+	email := regexp.MustCompile(`\S+@\S+`)
+	containsEmail := func(_ string, v *structpb.Value) bool {
+		return email.MatchString(v.GetStringValue())
+	}
+
+	paths := func(matches []Match) []string {
+		var out []string
+		for _, m := range matches {
+			out = append(out, m.Path)
+		}
+		return out
+	}
+
+	t.Run("all matches in sorted pre-order", func(t *testing.T) {
+		t.Parallel()
+		matches := FindAll(doc, containsEmail)
+		assert.Equal(t, []string{"email", "notes[0]", "owner.email"}, paths(matches))
+		assert.Equal(t, "call sam@shire.example", matches[1].Value.GetStringValue())
+	})
+
+	t.Run("first match", func(t *testing.T) {
+		t.Parallel()
+		matches := FindAll(doc, containsEmail, FindFirst())
+		assert.Equal(t, []string{"email"}, paths(matches))
+	})
+
+	t.Run("by path", func(t *testing.T) {
+		t.Parallel()
+		matches := FindAll(doc, func(path string, _ *structpb.Value) bool { return path == "owner" || path == "" })
+		assert.Equal(t, []string{"", "owner"}, paths(matches))
+		assert.Same(t, doc, matches[0].Value)
+	})
+
+	t.Run("by kind", func(t *testing.T) {
+		t.Parallel()
+		matches := FindAll(doc, func(_ string, v *structpb.Value) bool { return KindOf(v) == KindNull })
+		assert.Equal(t, []string{"notes[2]"}, paths(matches))
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		t.Parallel()
+		assert.Empty(t, FindAll(doc, func(string, *structpb.Value) bool { return false }))
+		assert.Empty(t, FindAll(nil, func(string, *structpb.Value) bool { return true }))
+	})
+}
@@ -0,0 +1,60 @@
+package protobaggins
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// FlagSetToStruct captures the flags of fs keyed by name, all of them or, with
+// onlyChanged, those set on the command line. Values keep their type, so bool flags
+// become bools and numeric flags numbers, through Int64ToValue and Uint64ToValue for
+// 64-bit integers. Durations and flags of other types, such as those of flag.Func or
+// flag.TextVar, are written as their String form, which fs.Set accepts back when the
+// invocation is replayed. Fails for a nil FlagSet
+func FlagSetToStruct(fs *flag.FlagSet, onlyChanged bool) (*structpb.Struct, error) {
+	if fs == nil {
+		return nil, errors.New("nil FlagSet")
+	}
+
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value)}
+	capture := func(f *flag.Flag) {
+		s.Fields[f.Name] = flagValue(f)
+	}
+	if onlyChanged {
+		fs.Visit(capture)
+	} else {
+		fs.VisitAll(capture)
+	}
+	return s, nil
+}
+
+// flagValue converts the value of f by the type its flag.Getter returns
+func flagValue(f *flag.Flag) *structpb.Value {
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return structpb.NewStringValue(f.Value.String())
+	}
+	switch v := getter.Get().(type) {
+	case bool:
+		return structpb.NewBoolValue(v)
+	case int:
+		return Int64ToValue(int64(v))
+	case int64:
+		return Int64ToValue(v)
+	case uint:
+		return Uint64ToValue(uint64(v))
+	case uint64:
+		return Uint64ToValue(v)
+	case float64:
+		return structpb.NewNumberValue(v)
+	case string:
+		return structpb.NewStringValue(v)
+	case time.Duration:
+		return structpb.NewStringValue(v.String())
+	default:
+		return structpb.NewStringValue(f.Value.String())
+	}
+}
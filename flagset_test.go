@@ -0,0 +1,102 @@
+package protobaggins
+
+import (
+	"flag"
+	"io"
+	"math"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// stringValue is a flag.Value that is not a flag.Getter
+type stringValue string
+
+func (s *stringValue) String() string     { return string(*s) }
+func (s *stringValue) Set(v string) error { *s = stringValue(v); return nil }
+
+func newTestFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("hobbit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Bool("verbose", false, "")
+	fs.Int("count", 1, "")
+	fs.Int64("big", 0, "")
+	fs.Uint("small", 2, "")
+	fs.Uint64("huge", 0, "")
+	fs.Float64("ratio", 0.5, "")
+	fs.String("name", "frodo", "")
+	fs.Duration("timeout", time.Second, "")
+	fs.TextVar(&netip.Addr{}, "addr", netip.MustParseAddr("127.0.0.1"), "")
+	fs.Func("hook", "", func(string) error { return nil })
+	fs.Var(new(stringValue), "raw", "")
+	return fs
+}
+
+func TestFlagSetToStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("all flags", func(t *testing.T) {
+		t.Parallel()
+		fs := newTestFlagSet()
+		require.NoError(t, fs.Parse([]string{"-verbose", "-big=9007199254740993", "-huge=18446744073709551615", "-timeout=1m30s", "-raw=x"}))
+
+		got, err := FlagSetToStruct(fs, false)
+		require.NoError(t, err)
+		want := &structpb.Struct{Fields: map[string]*structpb.Value{
+			"verbose": structpb.NewBoolValue(true),
+			"count":   structpb.NewNumberValue(1),
+			"big":     structpb.NewStringValue("9007199254740993"),
+			"small":   structpb.NewNumberValue(2),
+			"huge":    structpb.NewStringValue("18446744073709551615"),
+			"ratio":   structpb.NewNumberValue(0.5),
+			"name":    structpb.NewStringValue("frodo"),
+			"timeout": structpb.NewStringValue("1m30s"),
+			"addr":    structpb.NewStringValue("127.0.0.1"),
+			"hook":    structpb.NewStringValue(""),
+			"raw":     structpb.NewStringValue("x"),
+		}}
+		assert.True(t, proto.Equal(want, got), "%v", got)
+	})
+
+	t.Run("only changed", func(t *testing.T) {
+		t.Parallel()
+		fs := newTestFlagSet()
+		require.NoError(t, fs.Parse([]string{"-count=3", "-ratio=NaN", "-addr=::1"}))
+
+		got, err := FlagSetToStruct(fs, true)
+		require.NoError(t, err)
+		assert.Len(t, got.GetFields(), 3)
+		assert.InDelta(t, 3.0, got.GetFields()["count"].GetNumberValue(), 0)
+		assert.True(t, math.IsNaN(got.GetFields()["ratio"].GetNumberValue()))
+		assert.Equal(t, "::1", got.GetFields()["addr"].GetStringValue())
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		t.Parallel()
+		fs := newTestFlagSet()
+		require.NoError(t, fs.Parse([]string{"-verbose", "-timeout=2h", "-name=sam"}))
+		got, err := FlagSetToStruct(fs, true)
+		require.NoError(t, err)
+
+		replayed := newTestFlagSet()
+		for name, v := range got.GetFields() {
+			str, err := ToString(v)
+			require.NoError(t, err)
+			require.NoError(t, replayed.Set(name, str))
+		}
+		again, err := FlagSetToStruct(replayed, true)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(got, again))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		_, err := FlagSetToStruct(nil, false)
+		require.Error(t, err)
+	})
+}
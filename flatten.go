@@ -0,0 +1,103 @@
+package protobaggins
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Flatten returns the leaves of s keyed by their path, such as "a.b[0].c", with keys
+// joined by sep and list indexes in brackets. Keys that are empty or contain sep,
+// brackets or quotes are written as ["key"]. An empty sep means ".", which gives the
+// notation described at PathFilter. Values are converted with AsInterface, and empty
+// structs and lists are kept as empty maps and slices so Unflatten restores them
+func Flatten(s *structpb.Struct, sep string) map[string]any {
+	if sep == "" {
+		sep = "."
+	}
+	flat := make(map[string]any)
+	for key, field := range s.GetFields() {
+		flattenValue(joinKeySep("", key, sep), field, sep, flat)
+	}
+	return flat
+}
+
+func flattenValue(path string, v *structpb.Value, sep string, flat map[string]any) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		if len(kind.StructValue.GetFields()) == 0 {
+			flat[path] = map[string]any{}
+		}
+		for key, field := range kind.StructValue.GetFields() {
+			flattenValue(joinKeySep(path, key, sep), field, sep, flat)
+		}
+	case *structpb.Value_ListValue:
+		if len(kind.ListValue.GetValues()) == 0 {
+			flat[path] = []any{}
+		}
+		for i, item := range kind.ListValue.GetValues() {
+			flattenValue(joinIndex(path, i), item, sep, flat)
+		}
+	default:
+		flat[path] = v.AsInterface()
+	}
+}
+
+// Unflatten is the inverse of Flatten, it nests the values of flat at their paths.
+// Values are converted with NewValue using opts. Lists are padded with nulls up to the
+// highest index given. Fails with ErrUnexpectedKind when paths conflict, such as "a"
+// holding a number and "a.b" a nested key
+func Unflatten(flat map[string]any, sep string, opts ...Option) (*structpb.Struct, error) {
+	if sep == "" {
+		sep = "."
+	}
+
+	type entry struct {
+		key      string
+		segments []pathSegment
+	}
+	entries := make([]entry, 0, len(flat))
+	for key := range flat {
+		segments, err := parsePathSep(key, sep)
+		if err != nil {
+			return nil, err
+		}
+		if len(segments) == 0 {
+			return nil, fmt.Errorf("%q: empty path", key)
+		}
+		entries = append(entries, entry{key: key, segments: segments})
+	}
+	// visit parents before their contents and list items in index order, so padding
+	// never stands in for a container that a later path descends into
+	slices.SortFunc(entries, func(a, b entry) int {
+		return slices.CompareFunc(a.segments, b.segments, comparePathSegments)
+	})
+
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(flat))}
+	var o setPathOptions
+	for _, e := range entries {
+		v, err := NewValue(flat[e.key], opts...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.key, err)
+		}
+		if err := o.set(s, e.segments, v); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// comparePathSegments orders indexes numerically and before keys
+func comparePathSegments(a, b pathSegment) int {
+	switch {
+	case a.index >= 0 && b.index >= 0:
+		return cmp.Compare(a.index, b.index)
+	case a.index >= 0:
+		return -1
+	case b.index >= 0:
+		return 1
+	}
+	return cmp.Compare(a.key, b.key)
+}
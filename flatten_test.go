@@ -0,0 +1,94 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFlatten(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"a":      map[string]any{"b": []any{map[string]any{"c": 1}, "x"}},
+		"labels": map[string]any{"app.name": "web", "tier": nil},
+		"empty":  map[string]any{},
+		"none":   []any{},
+		"":       true,
+	})
+	require.NoError(t, err)
+
+	want := map[string]any{
+		"a.b[0].c":           1.0,
+		"a.b[1]":             "x",
+		`labels["app.name"]`: "web",
+		"labels.tier":        nil,
+		"empty":              map[string]any{},
+		"none":               []any{},
+		`[""]`:               true,
+	}
+	assert.Equal(t, want, Flatten(s, ""))
+	assert.Equal(t, want, Flatten(s, "."))
+
+	assert.Equal(t, map[string]any{
+		"a/b[0]/c":        1.0,
+		"a/b[1]":          "x",
+		"labels/app.name": "web",
+		"labels/tier":     nil,
+		"empty":           map[string]any{},
+		"none":            []any{},
+		`[""]`:            true,
+	}, Flatten(s, "/"))
+
+	assert.Empty(t, Flatten(nil, "."))
+}
+
+func TestUnflatten(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"a":      map[string]any{"b": []any{map[string]any{"c": 1.0}, "x"}},
+			"labels": map[string]any{"app.name": "web", "tier": nil},
+			"empty":  map[string]any{},
+			"none":   []any{},
+		})
+		require.NoError(t, err)
+
+		for _, sep := range []string{".", "/", "__"} {
+			got, err := Unflatten(Flatten(s, sep), sep)
+			require.NoError(t, err)
+			assert.Equal(t, s.AsMap(), got.AsMap(), sep)
+		}
+	})
+
+	t.Run("list indexes", func(t *testing.T) {
+		t.Parallel()
+		got, err := Unflatten(map[string]any{"l[10]": 10, "l[2].x": 2, "l[0]": 0}, ".")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"l": []any{
+			0.0, nil, map[string]any{"x": 2.0}, nil, nil, nil, nil, nil, nil, nil, 10.0,
+		}}, got.AsMap())
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		t.Parallel()
+		_, err := Unflatten(map[string]any{"a": 1, "a.b": 2}, ".")
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		_, err = Unflatten(map[string]any{"a.b": 1, "a[0]": 2}, ".")
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := Unflatten(map[string]any{"a..b": 1}, ".")
+		require.Error(t, err)
+		_, err = Unflatten(map[string]any{"": 1}, ".")
+		require.Error(t, err)
+		_, err = Unflatten(map[string]any{"a": make(chan int)}, ".")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,68 @@
+package protobaggins
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The Get* functions read a field of a Struct, returning def when s is nil, the key is
+// missing or its value has another kind. Values are not coerced across kinds, see the
+// To* functions for that
+
+// GetString returns the string field key of s, or def
+func GetString(s *structpb.Struct, key, def string) string {
+	if v, ok := s.GetFields()[key].GetKind().(*structpb.Value_StringValue); ok {
+		return v.StringValue
+	}
+	return def
+}
+
+// GetFloat returns the number field key of s, or def
+func GetFloat(s *structpb.Struct, key string, def float64) float64 {
+	if v, ok := s.GetFields()[key].GetKind().(*structpb.Value_NumberValue); ok {
+		return v.NumberValue
+	}
+	return def
+}
+
+// GetInt returns the number field key of s, or def if it is not a whole number within
+// the range of int
+func GetInt(s *structpb.Struct, key string, def int) int {
+	v, ok := s.GetFields()[key].GetKind().(*structpb.Value_NumberValue)
+	if !ok {
+		return def
+	}
+	i, err := floatToInt64(v.NumberValue)
+	if err != nil || i < math.MinInt || i > math.MaxInt {
+		return def
+	}
+	return int(i)
+}
+
+// GetBool returns the bool field key of s, or def
+func GetBool(s *structpb.Struct, key string, def bool) bool {
+	if v, ok := s.GetFields()[key].GetKind().(*structpb.Value_BoolValue); ok {
+		return v.BoolValue
+	}
+	return def
+}
+
+// GetStringSlice returns the list field key of s, or def if it is not a list of strings
+// An empty list returns an empty, non-nil slice
+func GetStringSlice(s *structpb.Struct, key string, def []string) []string {
+	list, ok := s.GetFields()[key].GetKind().(*structpb.Value_ListValue)
+	if !ok {
+		return def
+	}
+	items := list.ListValue.GetValues()
+	result := make([]string, len(items))
+	for i, item := range items {
+		str, ok := item.GetKind().(*structpb.Value_StringValue)
+		if !ok {
+			return def
+		}
+		result[i] = str.StringValue
+	}
+	return result
+}
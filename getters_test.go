@@ -0,0 +1,58 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestGetters(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"name":    "frodo",
+		"age":     50,
+		"height":  1.2,
+		"huge":    math.MaxFloat64,
+		"ringer":  true,
+		"aliases": []any{"mr. underhill", "ring-bearer"},
+		"mixed":   []any{"a", 1},
+		"empty":   []any{},
+		"nothing": nil,
+	})
+	require.NoError(t, err)
+
+	t.Run("present", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "frodo", GetString(s, "name", "x"))
+		assert.Equal(t, 50, GetInt(s, "age", -1))
+		assert.InDelta(t, 1.2, GetFloat(s, "height", 0), 0)
+		assert.True(t, GetBool(s, "ringer", false))
+		assert.Equal(t, []string{"mr. underhill", "ring-bearer"}, GetStringSlice(s, "aliases", nil))
+		assert.Equal(t, []string{}, GetStringSlice(s, "empty", nil))
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "x", GetString(s, "age", "x"))
+		assert.Equal(t, "x", GetString(s, "missing", "x"))
+		assert.Equal(t, "x", GetString(s, "nothing", "x"))
+		assert.Equal(t, -1, GetInt(s, "height", -1), "fractional")
+		assert.Equal(t, -1, GetInt(s, "huge", -1), "out of range")
+		assert.Equal(t, -1, GetInt(s, "name", -1))
+		assert.InDelta(t, 7.0, GetFloat(s, "name", 7), 0)
+		assert.True(t, GetBool(s, "name", true))
+		assert.Equal(t, []string{"d"}, GetStringSlice(s, "mixed", []string{"d"}))
+		assert.Equal(t, []string{"d"}, GetStringSlice(s, "name", []string{"d"}))
+	})
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "x", GetString(nil, "name", "x"))
+		assert.Equal(t, 3, GetInt(nil, "age", 3))
+		assert.Nil(t, GetStringSlice(nil, "aliases", nil))
+	})
+}
@@ -0,0 +1,188 @@
+// Package gojaconv converts between structpb values and goja JavaScript values
+// directly, without a JSON.stringify and JSON.parse round trip.
+//
+// Conversions follow JSON.stringify where JavaScript has no Struct equivalent: Dates
+// become ISO 8601 strings, and undefined values, functions and symbols are omitted from
+// objects and become null in arrays. A nil Value and undefined convert to each other,
+// telling a missing value apart from null.
+//
+// It is a module of its own, so that only programs using it depend on goja
+package gojaconv
+
+import (
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/internal/conv"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ToGojaValue converts v to a value of rt: null, a boolean, a number, a string, an
+// array or a plain object whose properties are set in sorted key order. A nil Value
+// converts to undefined
+func ToGojaValue(rt *goja.Runtime, v *structpb.Value) goja.Value {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return structToObject(rt, kind.StructValue)
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		elems := make([]any, len(items))
+		for i, item := range items {
+			elems[i] = ToGojaValue(rt, item)
+		}
+		return rt.NewArray(elems...)
+	case *structpb.Value_StringValue:
+		return rt.ToValue(kind.StringValue)
+	case *structpb.Value_NumberValue:
+		return rt.ToValue(kind.NumberValue)
+	case *structpb.Value_BoolValue:
+		return rt.ToValue(kind.BoolValue)
+	case *structpb.Value_NullValue:
+		return goja.Null()
+	default:
+		return goja.Undefined()
+	}
+}
+
+func structToObject(rt *goja.Runtime, s *structpb.Struct) *goja.Object {
+	obj := rt.NewObject()
+	for _, key := range slices.Sorted(maps.Keys(s.GetFields())) {
+		// cannot fail on a new plain object
+		_ = obj.Set(key, ToGojaValue(rt, s.GetFields()[key]))
+	}
+	return obj
+}
+
+// FromGojaValue converts v to a Value, the reverse of ToGojaValue. Undefined gives a nil
+// Value. Arrays, and objects by their own enumerable properties, are converted at any
+// depth; Dates become RFC 3339 strings in UTC, or null when invalid. BigInts must be
+// within ±2^53 and fail with protobaggins.ErrNotCoercible otherwise. Fails with
+// protobaggins.ErrCycle for objects that contain themselves
+func FromGojaValue(v goja.Value) (*structpb.Value, error) {
+	c := converter{visiting: make(map[*goja.Object]bool)}
+	result, _, err := c.value("", v)
+	return result, err
+}
+
+type converter struct {
+	// visiting holds the objects being converted, to detect cycles
+	visiting map[*goja.Object]bool
+}
+
+// value converts v, reporting false for undefined values, functions and symbols, which
+// have no Value
+func (c *converter) value(path string, v goja.Value) (*structpb.Value, bool, error) {
+	if v == nil || goja.IsUndefined(v) {
+		return nil, false, nil
+	}
+	if goja.IsNull(v) {
+		return structpb.NewNullValue(), true, nil
+	}
+	if _, isFunc := goja.AssertFunction(v); isFunc {
+		return nil, false, nil
+	}
+
+	switch v := v.(type) {
+	case *goja.Symbol:
+		return nil, false, nil
+	case *goja.Object:
+		return c.object(path, v)
+	}
+
+	switch x := v.Export().(type) {
+	case bool:
+		return structpb.NewBoolValue(x), true, nil
+	case string:
+		return structpb.NewStringValue(x), true, nil
+	case int64:
+		return structpb.NewNumberValue(float64(x)), true, nil
+	case float64:
+		return structpb.NewNumberValue(x), true, nil
+	case *big.Int:
+		if !x.IsInt64() || x.Int64() < -conv.MaxSafeInteger || x.Int64() > conv.MaxSafeInteger {
+			return nil, false, fmt.Errorf("%s: %w: BigInt %s is beyond ±2^53", conv.DescribePath(path), protobaggins.ErrNotCoercible, x)
+		}
+		return structpb.NewNumberValue(float64(x.Int64())), true, nil
+	default:
+		return structpb.NewStringValue(v.String()), true, nil
+	}
+}
+
+func (c *converter) object(path string, obj *goja.Object) (*structpb.Value, bool, error) {
+	switch obj.ClassName() {
+	case "Date":
+		t, ok := obj.Export().(time.Time)
+		if !ok {
+			return structpb.NewNullValue(), true, nil
+		}
+		return structpb.NewStringValue(t.UTC().Format(time.RFC3339Nano)), true, nil
+	case "Array":
+		list, err := c.array(path, obj)
+		if err != nil {
+			return nil, false, err
+		}
+		return structpb.NewListValue(list), true, nil
+	default:
+		s, err := c.structValue(path, obj)
+		if err != nil {
+			return nil, false, err
+		}
+		return structpb.NewStructValue(s), true, nil
+	}
+}
+
+// enter marks obj as being converted, failing if it already is
+func (c *converter) enter(path string, obj *goja.Object) error {
+	if c.visiting[obj] {
+		return fmt.Errorf("%s: %w", conv.DescribePath(path), protobaggins.ErrCycle)
+	}
+	c.visiting[obj] = true
+	return nil
+}
+
+func (c *converter) array(path string, obj *goja.Object) (*structpb.ListValue, error) {
+	if err := c.enter(path, obj); err != nil {
+		return nil, err
+	}
+	defer delete(c.visiting, obj)
+
+	n := obj.Get("length").ToInteger()
+	list := &structpb.ListValue{Values: make([]*structpb.Value, n)}
+	for i := range list.Values {
+		v, ok, err := c.value(protobaggins.JoinPathIndex(path, i), obj.Get(strconv.Itoa(i)))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			v = structpb.NewNullValue()
+		}
+		list.Values[i] = v
+	}
+	return list, nil
+}
+
+func (c *converter) structValue(path string, obj *goja.Object) (*structpb.Struct, error) {
+	if err := c.enter(path, obj); err != nil {
+		return nil, err
+	}
+	defer delete(c.visiting, obj)
+
+	keys := obj.Keys()
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(keys))}
+	for _, key := range keys {
+		v, ok, err := c.value(protobaggins.JoinPathKey(path, key), obj.Get(key))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			s.Fields[key] = v
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,112 @@
+package gojaconv
+
+import (
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/robbyt/protobaggins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestToGojaValue(t *testing.T) {
+	t.Parallel()
+
+	v, err := structpb.NewValue(map[string]any{
+		"name": "frodo",
+		"age":  50.0,
+		"ring": true,
+		"gone": nil,
+		"tags": []any{"a", 1.5},
+		"home": map[string]any{"door": "green"},
+	})
+	require.NoError(t, err)
+
+	rt := goja.New()
+	require.NoError(t, rt.Set("input", ToGojaValue(rt, v)))
+	out, err := rt.RunString(`JSON.stringify(input)`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"age":50,"gone":null,"home":{"door":"green"},"name":"frodo","ring":true,"tags":["a",1.5]}`, out.String())
+
+	out, err = rt.RunString(`input.age + input.tags.length + (input.gone === null ? 1 : 0)`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(53), out.ToInteger())
+
+	assert.True(t, goja.IsUndefined(ToGojaValue(rt, nil)))
+	assert.True(t, goja.IsNull(ToGojaValue(rt, structpb.NewNullValue())))
+}
+
+func TestFromGojaValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("script result", func(t *testing.T) {
+		t.Parallel()
+		rt := goja.New()
+		result, err := rt.RunString(`({
+			allow: true,
+			score: 3,
+			ratio: 0.25,
+			reasons: ["ok", null, undefined, function() {}],
+			nested: {deep: -7, skipped: undefined, fn: function() {}},
+			when: new Date(Date.UTC(2024, 4, 6, 7, 8, 9)),
+			never: new Date(NaN),
+		})`)
+		require.NoError(t, err)
+
+		got, err := FromGojaValue(result)
+		require.NoError(t, err)
+		want, err := structpb.NewValue(map[string]any{
+			"allow":   true,
+			"score":   3.0,
+			"ratio":   0.25,
+			"reasons": []any{"ok", nil, nil, nil},
+			"nested":  map[string]any{"deep": -7.0},
+			"when":    "2024-05-06T07:08:09Z",
+			"never":   nil,
+		})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, got), "%v", got)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		v, err := structpb.NewValue(map[string]any{"a": []any{1.0, 1.5, "x", nil, false}, "b": map[string]any{}})
+		require.NoError(t, err)
+		rt := goja.New()
+		got, err := FromGojaValue(ToGojaValue(rt, v))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(v, got))
+	})
+
+	t.Run("undefined", func(t *testing.T) {
+		t.Parallel()
+		got, err := FromGojaValue(goja.Undefined())
+		require.NoError(t, err)
+		assert.Nil(t, got)
+
+		got, err = FromGojaValue(goja.Null())
+		require.NoError(t, err)
+		assert.Equal(t, protobaggins.KindNull, protobaggins.KindOf(got))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		rt := goja.New()
+		cyclic, err := rt.RunString(`var a = {}; a.self = a; a`)
+		require.NoError(t, err)
+		_, err = FromGojaValue(cyclic)
+		require.ErrorIs(t, err, protobaggins.ErrCycle)
+
+		shared, err := rt.RunString(`var s = [1]; [s, s]`)
+		require.NoError(t, err)
+		_, err = FromGojaValue(shared)
+		require.NoError(t, err)
+
+		big, err := rt.RunString(`2n ** 60n`)
+		require.NoError(t, err)
+		_, err = FromGojaValue(big)
+		require.ErrorIs(t, err, protobaggins.ErrNotCoercible)
+	})
+}
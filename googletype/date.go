@@ -0,0 +1,101 @@
+package googletype
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/genproto/googleapis/type/date"
+)
+
+// ErrInvalidDate is returned when a date or its representation is malformed
+var ErrInvalidDate = errors.New("invalid date")
+
+const dateLayout = "2006-01-02"
+
+// ValidateDate checks that d is a full date that exists in the calendar, or one of the
+// partial forms google.type.Date allows: a year alone, a year and month, or a month
+// and day with a zero year
+func ValidateDate(d *date.Date) error {
+	if d == nil {
+		return fmt.Errorf("%w: nil date", ErrInvalidDate)
+	}
+	year, month, day := d.GetYear(), d.GetMonth(), d.GetDay()
+	if year < 0 || year > 9999 {
+		return fmt.Errorf("%w: year %d out of range", ErrInvalidDate, year)
+	}
+	if month < 0 || month > 12 {
+		return fmt.Errorf("%w: month %d out of range", ErrInvalidDate, month)
+	}
+	if month == 0 {
+		if year == 0 || day != 0 {
+			return fmt.Errorf("%w: a date without a month must have only a year", ErrInvalidDate)
+		}
+		return nil
+	}
+	if day == 0 {
+		if year == 0 {
+			return fmt.Errorf("%w: a date without a day must have a year", ErrInvalidDate)
+		}
+		return nil
+	}
+
+	// a zero year is checked against a leap year so that February 29 is accepted
+	checkYear := int(year)
+	if checkYear == 0 {
+		checkYear = 2000
+	}
+	if day < 1 || int(day) > daysIn(time.Month(month), checkYear) {
+		return fmt.Errorf("%w: day %d out of range for month %d", ErrInvalidDate, day, month)
+	}
+	return nil
+}
+
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// isFullDate reports whether d has a year, month and day
+func isFullDate(d *date.Date) bool {
+	return d.GetYear() != 0 && d.GetMonth() != 0 && d.GetDay() != 0
+}
+
+// DateToString formats a full date as "2024-05-01"
+// Returns an error for invalid or partial dates
+func DateToString(d *date.Date) (string, error) {
+	t, err := DateToTime(d, time.UTC)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(dateLayout), nil
+}
+
+// ParseDate parses a "2024-05-01" civil date string into a *date.Date
+func ParseDate(s string) (*date.Date, error) {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDate, err)
+	}
+	return DateFromTime(t), nil
+}
+
+// DateToTime returns midnight at the start of a full date in loc, which defaults to UTC
+// Returns an error for invalid or partial dates
+func DateToTime(d *date.Date, loc *time.Location) (time.Time, error) {
+	if err := ValidateDate(d); err != nil {
+		return time.Time{}, err
+	}
+	if !isFullDate(d) {
+		return time.Time{}, fmt.Errorf("%w: partial date cannot be converted to a time", ErrInvalidDate)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Date(int(d.GetYear()), time.Month(d.GetMonth()), int(d.GetDay()), 0, 0, 0, 0, loc), nil
+}
+
+// DateFromTime returns the calendar date of t in its own location
+func DateFromTime(t time.Time) *date.Date {
+	year, month, day := t.Date()
+	return &date.Date{Year: int32(year), Month: int32(month), Day: int32(day)}
+}
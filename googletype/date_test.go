@@ -0,0 +1,105 @@
+package googletype
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/date"
+)
+
+func TestValidateDate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		date    *date.Date
+		wantErr bool
+	}{
+		{"nil", nil, true},
+		{"full date", &date.Date{Year: 2024, Month: 5, Day: 1}, false},
+		{"leap day", &date.Date{Year: 2024, Month: 2, Day: 29}, false},
+		{"non leap day", &date.Date{Year: 2023, Month: 2, Day: 29}, true},
+		{"year only", &date.Date{Year: 2024}, false},
+		{"year and month", &date.Date{Year: 2024, Month: 5}, false},
+		{"anniversary", &date.Date{Month: 2, Day: 29}, false},
+		{"month only", &date.Date{Month: 5}, true},
+		{"day without month", &date.Date{Year: 2024, Day: 5}, true},
+		{"empty", &date.Date{}, true},
+		{"month out of range", &date.Date{Year: 2024, Month: 13, Day: 1}, true},
+		{"day out of range", &date.Date{Year: 2024, Month: 4, Day: 31}, true},
+		{"year out of range", &date.Date{Year: 10000, Month: 1, Day: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateDate(tt.date)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidDate)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDateString(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		d, err := ParseDate("2024-05-01")
+		require.NoError(t, err)
+		assert.Equal(t, int32(2024), d.GetYear())
+		assert.Equal(t, int32(5), d.GetMonth())
+		assert.Equal(t, int32(1), d.GetDay())
+
+		s, err := DateToString(d)
+		require.NoError(t, err)
+		assert.Equal(t, "2024-05-01", s)
+	})
+
+	t.Run("partial date cannot be formatted", func(t *testing.T) {
+		t.Parallel()
+		_, err := DateToString(&date.Date{Year: 2024})
+		require.ErrorIs(t, err, ErrInvalidDate)
+	})
+
+	t.Run("parse errors", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"", "2024-5-1", "2024-02-30", "01/05/2024"} {
+			_, err := ParseDate(input)
+			require.ErrorIs(t, err, ErrInvalidDate, input)
+		}
+	})
+}
+
+func TestDateTime(t *testing.T) {
+	t.Parallel()
+
+	t.Run("to time defaults to utc", func(t *testing.T) {
+		t.Parallel()
+		result, err := DateToTime(&date.Date{Year: 2024, Month: 5, Day: 1}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), result)
+	})
+
+	t.Run("to time in location", func(t *testing.T) {
+		t.Parallel()
+		loc := time.FixedZone("shire", 3600)
+		result, err := DateToTime(&date.Date{Year: 2024, Month: 5, Day: 1}, loc)
+		require.NoError(t, err)
+		assert.Equal(t, loc, result.Location())
+		assert.Equal(t, 1, result.Day())
+	})
+
+	t.Run("from time uses its location", func(t *testing.T) {
+		t.Parallel()
+		loc := time.FixedZone("ahead", 10*3600)
+		instant := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC).In(loc)
+		d := DateFromTime(instant)
+		assert.Equal(t, int32(2), d.GetDay())
+	})
+}
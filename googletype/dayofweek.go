@@ -0,0 +1,55 @@
+package googletype
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/type/dayofweek"
+)
+
+// ErrInvalidDayOfWeek is returned when a day of the week cannot be parsed or converted
+var ErrInvalidDayOfWeek = errors.New("invalid day of week")
+
+// DayOfWeekToString returns the enum name of d, e.g. "MONDAY"
+func DayOfWeekToString(d dayofweek.DayOfWeek) string {
+	return d.String()
+}
+
+// ParseDayOfWeek parses an enum name such as "MONDAY", case-insensitively, or a
+// three letter abbreviation such as "mon"
+// The unspecified value is rejected
+func ParseDayOfWeek(s string) (dayofweek.DayOfWeek, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	for number, enumName := range dayofweek.DayOfWeek_name {
+		if number == int32(dayofweek.DayOfWeek_DAY_OF_WEEK_UNSPECIFIED) {
+			continue
+		}
+		if name == enumName || (len(name) == 3 && strings.HasPrefix(enumName, name)) {
+			return dayofweek.DayOfWeek(number), nil
+		}
+	}
+	return dayofweek.DayOfWeek_DAY_OF_WEEK_UNSPECIFIED, fmt.Errorf("%w: %q", ErrInvalidDayOfWeek, s)
+}
+
+// DayOfWeekFromWeekday converts a time.Weekday to the matching enum value
+func DayOfWeekFromWeekday(w time.Weekday) dayofweek.DayOfWeek {
+	if w == time.Sunday {
+		return dayofweek.DayOfWeek_SUNDAY
+	}
+	return dayofweek.DayOfWeek(w)
+}
+
+// DayOfWeekToWeekday converts an enum value to the matching time.Weekday
+// Returns an error for the unspecified value and unknown numbers
+func DayOfWeekToWeekday(d dayofweek.DayOfWeek) (time.Weekday, error) {
+	switch {
+	case d == dayofweek.DayOfWeek_SUNDAY:
+		return time.Sunday, nil
+	case d >= dayofweek.DayOfWeek_MONDAY && d < dayofweek.DayOfWeek_SUNDAY:
+		return time.Weekday(d), nil
+	default:
+		return 0, fmt.Errorf("%w: %v", ErrInvalidDayOfWeek, d)
+	}
+}
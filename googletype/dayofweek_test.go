@@ -0,0 +1,78 @@
+package googletype
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/dayofweek"
+)
+
+func TestDayOfWeekToString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "MONDAY", DayOfWeekToString(dayofweek.DayOfWeek_MONDAY))
+	assert.Equal(t, "DAY_OF_WEEK_UNSPECIFIED", DayOfWeekToString(dayofweek.DayOfWeek_DAY_OF_WEEK_UNSPECIFIED))
+}
+
+func TestParseDayOfWeek(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  dayofweek.DayOfWeek
+	}{
+		{"MONDAY", dayofweek.DayOfWeek_MONDAY},
+		{"sunday", dayofweek.DayOfWeek_SUNDAY},
+		{" Wednesday ", dayofweek.DayOfWeek_WEDNESDAY},
+		{"fri", dayofweek.DayOfWeek_FRIDAY},
+		{"SAT", dayofweek.DayOfWeek_SATURDAY},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			result, err := ParseDayOfWeek(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"", "DAY_OF_WEEK_UNSPECIFIED", "day", "mo", "funday"} {
+			_, err := ParseDayOfWeek(input)
+			require.ErrorIs(t, err, ErrInvalidDayOfWeek, input)
+		}
+	})
+}
+
+func TestDayOfWeekWeekday(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		for w := time.Sunday; w <= time.Saturday; w++ {
+			d := DayOfWeekFromWeekday(w)
+			assert.Equal(t, w.String(), titleCase(d.String()))
+
+			result, err := DayOfWeekToWeekday(d)
+			require.NoError(t, err)
+			assert.Equal(t, w, result)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := DayOfWeekToWeekday(dayofweek.DayOfWeek_DAY_OF_WEEK_UNSPECIFIED)
+		require.ErrorIs(t, err, ErrInvalidDayOfWeek)
+
+		_, err = DayOfWeekToWeekday(dayofweek.DayOfWeek(8))
+		require.ErrorIs(t, err, ErrInvalidDayOfWeek)
+	})
+}
+
+func titleCase(s string) string {
+	return s[:1] + strings.ToLower(s[1:])
+}
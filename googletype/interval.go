@@ -0,0 +1,132 @@
+package googletype
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/genproto/googleapis/type/interval"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrInvalidInterval is returned when an interval or its representation is malformed
+var ErrInvalidInterval = errors.New("invalid interval")
+
+// ValidateInterval checks that any set timestamps are valid and that the start is not
+// after the end. Either bound may be unset, meaning the interval is open on that side
+func ValidateInterval(iv *interval.Interval) error {
+	if iv == nil {
+		return fmt.Errorf("%w: nil interval", ErrInvalidInterval)
+	}
+	if err := checkTimestamp("start", iv.GetStartTime()); err != nil {
+		return err
+	}
+	if err := checkTimestamp("end", iv.GetEndTime()); err != nil {
+		return err
+	}
+	if iv.GetStartTime() != nil && iv.GetEndTime() != nil &&
+		iv.GetStartTime().AsTime().After(iv.GetEndTime().AsTime()) {
+		return fmt.Errorf("%w: start is after end", ErrInvalidInterval)
+	}
+	return nil
+}
+
+func checkTimestamp(name string, ts *timestamppb.Timestamp) error {
+	if ts == nil {
+		return nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		return fmt.Errorf("%w: %s time: %w", ErrInvalidInterval, name, err)
+	}
+	return nil
+}
+
+// IntervalToTimes returns the bounds of iv, using the zero time.Time for unset bounds
+func IntervalToTimes(iv *interval.Interval) (start, end time.Time, err error) {
+	if err := ValidateInterval(iv); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if ts := iv.GetStartTime(); ts != nil {
+		start = ts.AsTime()
+	}
+	if ts := iv.GetEndTime(); ts != nil {
+		end = ts.AsTime()
+	}
+	return start, end, nil
+}
+
+// IntervalFromTimes builds an interval from two times, leaving a bound unset when its
+// time is the zero time.Time
+func IntervalFromTimes(start, end time.Time) (*interval.Interval, error) {
+	iv := &interval.Interval{}
+	if !start.IsZero() {
+		iv.StartTime = timestamppb.New(start)
+	}
+	if !end.IsZero() {
+		iv.EndTime = timestamppb.New(end)
+	}
+	if err := ValidateInterval(iv); err != nil {
+		return nil, err
+	}
+	return iv, nil
+}
+
+// IntervalToStruct converts iv to its protojson form with RFC 3339 timestamps:
+// {"startTime": "2024-05-01T09:00:00Z", "endTime": "2024-05-01T17:00:00Z"}
+// Unset bounds are omitted
+func IntervalToStruct(iv *interval.Interval) *structpb.Struct {
+	if iv == nil {
+		return nil
+	}
+	fields := make(map[string]*structpb.Value, 2)
+	if ts := iv.GetStartTime(); ts != nil {
+		fields["startTime"] = structpb.NewStringValue(ts.AsTime().Format(time.RFC3339Nano))
+	}
+	if ts := iv.GetEndTime(); ts != nil {
+		fields["endTime"] = structpb.NewStringValue(ts.AsTime().Format(time.RFC3339Nano))
+	}
+	return &structpb.Struct{Fields: fields}
+}
+
+// IntervalFromStruct converts a Struct in protojson form back to a validated interval
+// The snake_case names "start_time" and "end_time" are accepted as well
+func IntervalFromStruct(s *structpb.Struct) (*interval.Interval, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%w: nil struct", ErrInvalidInterval)
+	}
+
+	start, err := timestampField(s, "startTime", "start_time")
+	if err != nil {
+		return nil, err
+	}
+	end, err := timestampField(s, "endTime", "end_time")
+	if err != nil {
+		return nil, err
+	}
+
+	iv := &interval.Interval{StartTime: start, EndTime: end}
+	if err := ValidateInterval(iv); err != nil {
+		return nil, err
+	}
+	return iv, nil
+}
+
+func timestampField(s *structpb.Struct, names ...string) (*timestamppb.Timestamp, error) {
+	for _, name := range names {
+		v, ok := s.GetFields()[name]
+		if !ok {
+			continue
+		}
+		str, ok := v.GetKind().(*structpb.Value_StringValue)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s must be an RFC 3339 string", ErrInvalidInterval, name)
+		}
+		t, err := time.Parse(time.RFC3339Nano, str.StringValue)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidInterval, name, err)
+		}
+		return timestamppb.New(t), nil
+	}
+	return nil, nil
+}
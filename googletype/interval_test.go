@@ -0,0 +1,148 @@
+package googletype
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/interval"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestValidateInterval(t *testing.T) {
+	t.Parallel()
+
+	start := timestamppb.New(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	end := timestamppb.New(time.Date(2024, 5, 1, 17, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name    string
+		iv      *interval.Interval
+		wantErr bool
+	}{
+		{"nil", nil, true},
+		{"unbounded", &interval.Interval{}, false},
+		{"bounded", &interval.Interval{StartTime: start, EndTime: end}, false},
+		{"empty", &interval.Interval{StartTime: start, EndTime: start}, false},
+		{"open end", &interval.Interval{StartTime: start}, false},
+		{"reversed", &interval.Interval{StartTime: end, EndTime: start}, true},
+		{"invalid timestamp", &interval.Interval{StartTime: &timestamppb.Timestamp{Nanos: -1}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateInterval(tt.iv)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidInterval)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIntervalTimes(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 1, 17, 0, 0, 0, time.UTC)
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		iv, err := IntervalFromTimes(start, end)
+		require.NoError(t, err)
+
+		gotStart, gotEnd, err := IntervalToTimes(iv)
+		require.NoError(t, err)
+		assert.True(t, start.Equal(gotStart))
+		assert.True(t, end.Equal(gotEnd))
+	})
+
+	t.Run("zero times leave bounds unset", func(t *testing.T) {
+		t.Parallel()
+		iv, err := IntervalFromTimes(time.Time{}, end)
+		require.NoError(t, err)
+		assert.Nil(t, iv.GetStartTime())
+
+		gotStart, _, err := IntervalToTimes(iv)
+		require.NoError(t, err)
+		assert.True(t, gotStart.IsZero())
+	})
+
+	t.Run("reversed", func(t *testing.T) {
+		t.Parallel()
+		_, err := IntervalFromTimes(end, start)
+		require.ErrorIs(t, err, ErrInvalidInterval)
+
+		_, _, err = IntervalToTimes(nil)
+		require.ErrorIs(t, err, ErrInvalidInterval)
+	})
+}
+
+func TestIntervalStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, IntervalToStruct(nil))
+		_, err := IntervalFromStruct(nil)
+		require.ErrorIs(t, err, ErrInvalidInterval)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		iv, err := IntervalFromTimes(
+			time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 1, 17, 0, 0, 500, time.UTC),
+		)
+		require.NoError(t, err)
+
+		s := IntervalToStruct(iv)
+		assert.Equal(t, "2024-05-01T09:00:00Z", s.GetFields()["startTime"].GetStringValue())
+		assert.Equal(t, "2024-05-01T17:00:00.0000005Z", s.GetFields()["endTime"].GetStringValue())
+
+		result, err := IntervalFromStruct(s)
+		require.NoError(t, err)
+		assert.True(t, iv.GetStartTime().AsTime().Equal(result.GetStartTime().AsTime()))
+		assert.True(t, iv.GetEndTime().AsTime().Equal(result.GetEndTime().AsTime()))
+	})
+
+	t.Run("open interval omits bounds", func(t *testing.T) {
+		t.Parallel()
+		s := IntervalToStruct(&interval.Interval{})
+		assert.Empty(t, s.GetFields())
+
+		result, err := IntervalFromStruct(s)
+		require.NoError(t, err)
+		assert.Nil(t, result.GetStartTime())
+		assert.Nil(t, result.GetEndTime())
+	})
+
+	t.Run("snake case names", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"start_time": "2024-05-01T09:00:00+02:00"})
+		require.NoError(t, err)
+
+		result, err := IntervalFromStruct(s)
+		require.NoError(t, err)
+		assert.Equal(t, 7, result.GetStartTime().AsTime().Hour())
+	})
+
+	t.Run("invalid structs", func(t *testing.T) {
+		t.Parallel()
+		inputs := []map[string]any{
+			{"startTime": 12},
+			{"endTime": "yesterday"},
+			{"startTime": "2024-05-02T00:00:00Z", "endTime": "2024-05-01T00:00:00Z"},
+		}
+		for _, input := range inputs {
+			s, err := structpb.NewStruct(input)
+			require.NoError(t, err)
+			_, err = IntervalFromStruct(s)
+			require.ErrorIs(t, err, ErrInvalidInterval, input)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package googletype
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/type/latlng"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrInvalidLatLng is returned when a coordinate or its representation is malformed
+var ErrInvalidLatLng = errors.New("invalid lat/lng")
+
+// ValidateLatLng checks that latitude is within [-90, 90] and longitude within [-180, 180]
+func ValidateLatLng(ll *latlng.LatLng) error {
+	if ll == nil {
+		return fmt.Errorf("%w: nil coordinate", ErrInvalidLatLng)
+	}
+	lat, lng := ll.GetLatitude(), ll.GetLongitude()
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return fmt.Errorf("%w: latitude %v out of range", ErrInvalidLatLng, lat)
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return fmt.Errorf("%w: longitude %v out of range", ErrInvalidLatLng, lng)
+	}
+	return nil
+}
+
+// LatLngToStruct converts ll to a {"lat": .., "lng": ..} Struct
+func LatLngToStruct(ll *latlng.LatLng) *structpb.Struct {
+	if ll == nil {
+		return nil
+	}
+	return &structpb.Struct{Fields: map[string]*structpb.Value{
+		"lat": structpb.NewNumberValue(ll.GetLatitude()),
+		"lng": structpb.NewNumberValue(ll.GetLongitude()),
+	}}
+}
+
+// LatLngFromStruct converts a {"lat": .., "lng": ..} Struct to a validated *latlng.LatLng
+// The protojson names "latitude" and "longitude" are accepted as well
+func LatLngFromStruct(s *structpb.Struct) (*latlng.LatLng, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%w: nil struct", ErrInvalidLatLng)
+	}
+
+	lat, err := coordinateField(s, "lat", "latitude")
+	if err != nil {
+		return nil, err
+	}
+	lng, err := coordinateField(s, "lng", "longitude")
+	if err != nil {
+		return nil, err
+	}
+
+	ll := &latlng.LatLng{Latitude: lat, Longitude: lng}
+	if err := ValidateLatLng(ll); err != nil {
+		return nil, err
+	}
+	return ll, nil
+}
+
+func coordinateField(s *structpb.Struct, names ...string) (float64, error) {
+	for _, name := range names {
+		v, ok := s.GetFields()[name]
+		if !ok {
+			continue
+		}
+		num, ok := v.GetKind().(*structpb.Value_NumberValue)
+		if !ok {
+			return 0, fmt.Errorf("%w: %s must be a number", ErrInvalidLatLng, name)
+		}
+		return num.NumberValue, nil
+	}
+	return 0, fmt.Errorf("%w: missing %s", ErrInvalidLatLng, names[0])
+}
+
+// LatLngToString formats ll as "lat,lng", e.g. "51.4769,-0.0005"
+func LatLngToString(ll *latlng.LatLng) string {
+	if ll == nil {
+		return ""
+	}
+	return strconv.FormatFloat(ll.GetLatitude(), 'f', -1, 64) + "," +
+		strconv.FormatFloat(ll.GetLongitude(), 'f', -1, 64)
+}
+
+// ParseLatLng parses a "lat,lng" string into a validated *latlng.LatLng
+// Whitespace around either coordinate is ignored
+func ParseLatLng(s string) (*latlng.LatLng, error) {
+	latStr, lngStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not in lat,lng form", ErrInvalidLatLng, s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: latitude: %w", ErrInvalidLatLng, err)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: longitude: %w", ErrInvalidLatLng, err)
+	}
+
+	ll := &latlng.LatLng{Latitude: lat, Longitude: lng}
+	if err := ValidateLatLng(ll); err != nil {
+		return nil, err
+	}
+	return ll, nil
+}
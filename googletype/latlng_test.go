@@ -0,0 +1,116 @@
+package googletype
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/latlng"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestValidateLatLng(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ll      *latlng.LatLng
+		wantErr bool
+	}{
+		{"nil", nil, true},
+		{"origin", &latlng.LatLng{}, false},
+		{"bounds", &latlng.LatLng{Latitude: -90, Longitude: 180}, false},
+		{"latitude too large", &latlng.LatLng{Latitude: 90.1}, true},
+		{"longitude too small", &latlng.LatLng{Longitude: -180.5}, true},
+		{"nan latitude", &latlng.LatLng{Latitude: math.NaN()}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateLatLng(tt.ll)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidLatLng)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLatLngStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, LatLngToStruct(nil))
+		_, err := LatLngFromStruct(nil)
+		require.ErrorIs(t, err, ErrInvalidLatLng)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		ll := &latlng.LatLng{Latitude: 51.4769, Longitude: -0.0005}
+		s := LatLngToStruct(ll)
+		assert.InEpsilon(t, 51.4769, s.GetFields()["lat"].GetNumberValue(), 0.0001)
+
+		result, err := LatLngFromStruct(s)
+		require.NoError(t, err)
+		assert.InEpsilon(t, ll.GetLatitude(), result.GetLatitude(), 0.0001)
+		assert.InEpsilon(t, ll.GetLongitude(), result.GetLongitude(), 0.0001)
+	})
+
+	t.Run("protojson names", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"latitude": 10, "longitude": 20})
+		require.NoError(t, err)
+
+		result, err := LatLngFromStruct(s)
+		require.NoError(t, err)
+		assert.InEpsilon(t, 10.0, result.GetLatitude(), 0.0001)
+		assert.InEpsilon(t, 20.0, result.GetLongitude(), 0.0001)
+	})
+
+	t.Run("invalid structs", func(t *testing.T) {
+		t.Parallel()
+		inputs := []map[string]any{
+			{"lat": 10},
+			{"lng": 10},
+			{"lat": "10", "lng": 20},
+			{"lat": 100, "lng": 20},
+		}
+		for _, input := range inputs {
+			s, err := structpb.NewStruct(input)
+			require.NoError(t, err)
+			_, err = LatLngFromStruct(s)
+			require.ErrorIs(t, err, ErrInvalidLatLng, input)
+		}
+	})
+}
+
+func TestLatLngString(t *testing.T) {
+	t.Parallel()
+
+	t.Run("format", func(t *testing.T) {
+		t.Parallel()
+		assert.Empty(t, LatLngToString(nil))
+		assert.Equal(t, "51.4769,-0.0005", LatLngToString(&latlng.LatLng{Latitude: 51.4769, Longitude: -0.0005}))
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		t.Parallel()
+		result, err := ParseLatLng(" 51.4769 , -0.0005 ")
+		require.NoError(t, err)
+		assert.InEpsilon(t, 51.4769, result.GetLatitude(), 0.0001)
+		assert.InEpsilon(t, -0.0005, result.GetLongitude(), 0.0001)
+	})
+
+	t.Run("parse errors", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"", "51.4769", "north,0", "0,east", "91,0"} {
+			_, err := ParseLatLng(input)
+			require.ErrorIs(t, err, ErrInvalidLatLng, input)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+// Package googletype converts the google.type common protocol buffer types to and
+// from strings and *structpb.Struct representations for use in dynamic payloads
+package googletype
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const nanosPerUnit = 1_000_000_000
+
+// ErrInvalidMoney is returned when a money value or its representation is malformed
+var ErrInvalidMoney = errors.New("invalid money")
+
+// ValidateMoney checks that m has a three letter upper-case currency code, nanos within
+// ±999,999,999, and units and nanos that do not have opposite signs
+func ValidateMoney(m *money.Money) error {
+	if m == nil {
+		return fmt.Errorf("%w: nil money", ErrInvalidMoney)
+	}
+	if err := validateCurrency(m.GetCurrencyCode()); err != nil {
+		return err
+	}
+	units, nanos := m.GetUnits(), m.GetNanos()
+	if nanos <= -nanosPerUnit || nanos >= nanosPerUnit {
+		return fmt.Errorf("%w: nanos %d out of range", ErrInvalidMoney, nanos)
+	}
+	if (units > 0 && nanos < 0) || (units < 0 && nanos > 0) {
+		return fmt.Errorf("%w: units %d and nanos %d have opposite signs", ErrInvalidMoney, units, nanos)
+	}
+	return nil
+}
+
+// validateCurrency checks the ISO 4217 code format, not membership in the current code list
+func validateCurrency(code string) error {
+	if len(code) != 3 {
+		return fmt.Errorf("%w: currency code %q must be three letters", ErrInvalidMoney, code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return fmt.Errorf("%w: currency code %q must be upper-case letters", ErrInvalidMoney, code)
+		}
+	}
+	return nil
+}
+
+// NewMoney builds a normalized *money.Money from units and a nanos amount that may
+// exceed a single unit or carry the opposite sign, e.g. (1, -250000000) becomes 0.75
+// Returns an error if the currency code is malformed or the units overflow
+func NewMoney(currencyCode string, units, nanos int64) (*money.Money, error) {
+	if err := validateCurrency(currencyCode); err != nil {
+		return nil, err
+	}
+
+	carry := nanos / nanosPerUnit
+	nanos %= nanosPerUnit
+	if (carry > 0 && units > math.MaxInt64-carry) || (carry < 0 && units < math.MinInt64-carry) {
+		return nil, fmt.Errorf("%w: units overflow", ErrInvalidMoney)
+	}
+	units += carry
+
+	switch {
+	case units > 0 && nanos < 0:
+		units--
+		nanos += nanosPerUnit
+	case units < 0 && nanos > 0:
+		units++
+		nanos -= nanosPerUnit
+	}
+
+	return &money.Money{CurrencyCode: currencyCode, Units: units, Nanos: int32(nanos)}, nil
+}
+
+// NormalizeMoney returns a normalized copy of m, see NewMoney
+func NormalizeMoney(m *money.Money) (*money.Money, error) {
+	if m == nil {
+		return nil, fmt.Errorf("%w: nil money", ErrInvalidMoney)
+	}
+	return NewMoney(m.GetCurrencyCode(), m.GetUnits(), int64(m.GetNanos()))
+}
+
+// MoneyFromDecimal parses a decimal amount such as "-12.345" into a *money.Money
+// The amount is parsed exactly, without going through float64, and may have at most
+// nine fractional digits
+func MoneyFromDecimal(currencyCode, amount string) (*money.Money, error) {
+	if err := validateCurrency(currencyCode); err != nil {
+		return nil, err
+	}
+
+	negative := strings.HasPrefix(amount, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(amount, "-"), "+")
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" {
+		return nil, fmt.Errorf("%w: empty amount %q", ErrInvalidMoney, amount)
+	}
+	if len(fraction) > 9 {
+		return nil, fmt.Errorf("%w: amount %q has more than nine fractional digits", ErrInvalidMoney, amount)
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("%w: malformed amount %q", ErrInvalidMoney, amount)
+	}
+
+	var units int64
+	if whole != "" {
+		if negative {
+			// parse with the sign so that math.MinInt64 is representable
+			whole = "-" + whole
+		}
+		var err error
+		units, err = strconv.ParseInt(whole, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: amount %q: %w", ErrInvalidMoney, amount, err)
+		}
+	}
+	var nanos int64
+	if fraction != "" {
+		n, err := strconv.ParseInt(fraction+strings.Repeat("0", 9-len(fraction)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: amount %q: %w", ErrInvalidMoney, amount, err)
+		}
+		nanos = n
+	}
+
+	if negative {
+		nanos = -nanos
+	}
+	return &money.Money{CurrencyCode: currencyCode, Units: units, Nanos: int32(nanos)}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MoneyToDecimal formats the amount of m as a decimal string such as "-12.345"
+// Trailing fractional zeros are dropped. Returns an error if m is invalid
+func MoneyToDecimal(m *money.Money) (string, error) {
+	if err := ValidateMoney(m); err != nil {
+		return "", err
+	}
+
+	units, nanos := m.GetUnits(), int64(m.GetNanos())
+	negative := units < 0 || nanos < 0
+	// uint64 conversion keeps math.MinInt64 correct after negation
+	absUnits := uint64(units)
+	if units < 0 {
+		absUnits = uint64(-units)
+	}
+	if nanos < 0 {
+		nanos = -nanos
+	}
+
+	var b strings.Builder
+	if negative {
+		b.WriteByte('-')
+	}
+	b.WriteString(strconv.FormatUint(absUnits, 10))
+	if nanos != 0 {
+		fraction := fmt.Sprintf("%09d", nanos)
+		b.WriteByte('.')
+		b.WriteString(strings.TrimRight(fraction, "0"))
+	}
+	return b.String(), nil
+}
+
+// MoneyToStruct converts m to a Struct in its protojson form:
+// {"currencyCode": "USD", "units": "12", "nanos": 500000000}
+// Units are encoded as a string because int64 does not fit a JSON number
+func MoneyToStruct(m *money.Money) *structpb.Struct {
+	if m == nil {
+		return nil
+	}
+	return &structpb.Struct{Fields: map[string]*structpb.Value{
+		"currencyCode": structpb.NewStringValue(m.GetCurrencyCode()),
+		"units":        structpb.NewStringValue(strconv.FormatInt(m.GetUnits(), 10)),
+		"nanos":        structpb.NewNumberValue(float64(m.GetNanos())),
+	}}
+}
+
+// MoneyFromStruct converts a Struct in protojson form back to a validated *money.Money
+// It accepts "currencyCode" or "currency_code", units as a string or number, and
+// alternatively an "amount" decimal string in place of units and nanos
+func MoneyFromStruct(s *structpb.Struct) (*money.Money, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%w: nil struct", ErrInvalidMoney)
+	}
+
+	fields := s.GetFields()
+	code := fields["currencyCode"].GetStringValue()
+	if code == "" {
+		code = fields["currency_code"].GetStringValue()
+	}
+
+	if amount, ok := fields["amount"]; ok {
+		str, isString := amount.GetKind().(*structpb.Value_StringValue)
+		if !isString {
+			return nil, fmt.Errorf("%w: amount must be a decimal string", ErrInvalidMoney)
+		}
+		return MoneyFromDecimal(code, str.StringValue)
+	}
+
+	units, err := int64Field(fields["units"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: units: %w", ErrInvalidMoney, err)
+	}
+	nanos, err := int64Field(fields["nanos"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: nanos: %w", ErrInvalidMoney, err)
+	}
+	if nanos < math.MinInt32 || nanos > math.MaxInt32 {
+		return nil, fmt.Errorf("%w: nanos %d out of range", ErrInvalidMoney, nanos)
+	}
+
+	m := &money.Money{CurrencyCode: code, Units: units, Nanos: int32(nanos)}
+	if err := ValidateMoney(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// int64Field reads an integer from a string or whole number value, treating absence as zero
+func int64Field(v *structpb.Value) (int64, error) {
+	switch kind := v.GetKind().(type) {
+	case nil:
+		return 0, nil
+	case *structpb.Value_StringValue:
+		return strconv.ParseInt(kind.StringValue, 10, 64)
+	case *structpb.Value_NumberValue:
+		n := kind.NumberValue
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v is not an integer", n)
+		}
+		return int64(n), nil
+	default:
+		return 0, errors.New("must be a string or number")
+	}
+}
@@ -0,0 +1,210 @@
+package googletype
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestValidateMoney(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		money   *money.Money
+		wantErr bool
+	}{
+		{"nil money", nil, true},
+		{"valid", &money.Money{CurrencyCode: "USD", Units: 1, Nanos: 500000000}, false},
+		{"valid negative", &money.Money{CurrencyCode: "EUR", Units: -1, Nanos: -5}, false},
+		{"lower-case currency", &money.Money{CurrencyCode: "usd", Units: 1}, true},
+		{"short currency", &money.Money{CurrencyCode: "US", Units: 1}, true},
+		{"nanos out of range", &money.Money{CurrencyCode: "USD", Nanos: 1000000000}, true},
+		{"opposite signs", &money.Money{CurrencyCode: "USD", Units: 1, Nanos: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateMoney(tt.money)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidMoney)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewMoney(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		units     int64
+		nanos     int64
+		wantUnits int64
+		wantNanos int32
+	}{
+		{"already normal", 1, 500000000, 1, 500000000},
+		{"nanos carry into units", 1, 2500000000, 3, 500000000},
+		{"negative carry", -1, -1500000000, -2, -500000000},
+		{"positive units negative nanos", 1, -250000000, 0, 750000000},
+		{"negative units positive nanos", -1, 250000000, 0, -750000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m, err := NewMoney("USD", tt.units, tt.nanos)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUnits, m.GetUnits())
+			assert.Equal(t, tt.wantNanos, m.GetNanos())
+			require.NoError(t, ValidateMoney(m))
+		})
+	}
+
+	t.Run("overflow", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewMoney("USD", math.MaxInt64, 1500000000)
+		require.ErrorIs(t, err, ErrInvalidMoney)
+	})
+
+	t.Run("invalid currency", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewMoney("dollars", 1, 0)
+		require.ErrorIs(t, err, ErrInvalidMoney)
+	})
+}
+
+func TestNormalizeMoney(t *testing.T) {
+	t.Parallel()
+
+	_, err := NormalizeMoney(nil)
+	require.ErrorIs(t, err, ErrInvalidMoney)
+
+	m, err := NormalizeMoney(&money.Money{CurrencyCode: "USD", Units: 2, Nanos: -1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), m.GetUnits())
+	assert.Equal(t, int32(999999999), m.GetNanos())
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount    string
+		wantUnits int64
+		wantNanos int32
+		formatted string
+	}{
+		{"12.345", 12, 345000000, "12.345"},
+		{"-12.5", -12, -500000000, "-12.5"},
+		{"-0.000000001", 0, -1, "-0.000000001"},
+		{"+7", 7, 0, "7"},
+		{".25", 0, 250000000, "0.25"},
+		{"3.10", 3, 100000000, "3.1"},
+		{"-9223372036854775808", math.MinInt64, 0, "-9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			t.Parallel()
+			m, err := MoneyFromDecimal("USD", tt.amount)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUnits, m.GetUnits())
+			assert.Equal(t, tt.wantNanos, m.GetNanos())
+
+			formatted, err := MoneyToDecimal(m)
+			require.NoError(t, err)
+			assert.Equal(t, tt.formatted, formatted)
+		})
+	}
+
+	t.Run("invalid amounts", func(t *testing.T) {
+		t.Parallel()
+		for _, amount := range []string{"", "-", ".", "1.2.3", "abc", "1e5", "0.1234567891", "99999999999999999999"} {
+			_, err := MoneyFromDecimal("USD", amount)
+			require.ErrorIs(t, err, ErrInvalidMoney, amount)
+		}
+	})
+
+	t.Run("format invalid money", func(t *testing.T) {
+		t.Parallel()
+		_, err := MoneyToDecimal(&money.Money{CurrencyCode: "USD", Units: 1, Nanos: -1})
+		require.ErrorIs(t, err, ErrInvalidMoney)
+	})
+}
+
+func TestMoneyStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil money", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, MoneyToStruct(nil))
+		_, err := MoneyFromStruct(nil)
+		require.ErrorIs(t, err, ErrInvalidMoney)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		m := &money.Money{CurrencyCode: "JPY", Units: math.MaxInt64, Nanos: 1}
+		s := MoneyToStruct(m)
+		assert.Equal(t, "9223372036854775807", s.GetFields()["units"].GetStringValue())
+
+		result, err := MoneyFromStruct(s)
+		require.NoError(t, err)
+		assert.Equal(t, m.GetUnits(), result.GetUnits())
+		assert.Equal(t, m.GetNanos(), result.GetNanos())
+		assert.Equal(t, "JPY", result.GetCurrencyCode())
+	})
+
+	t.Run("snake case and numeric units", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"currency_code": "USD",
+			"units":         12,
+			"nanos":         5,
+		})
+		require.NoError(t, err)
+
+		result, err := MoneyFromStruct(s)
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), result.GetUnits())
+		assert.Equal(t, int32(5), result.GetNanos())
+	})
+
+	t.Run("amount form", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"currencyCode": "USD", "amount": "1.05"})
+		require.NoError(t, err)
+
+		result, err := MoneyFromStruct(s)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.GetUnits())
+		assert.Equal(t, int32(50000000), result.GetNanos())
+	})
+
+	t.Run("invalid structs", func(t *testing.T) {
+		t.Parallel()
+		inputs := []map[string]any{
+			{"currencyCode": "USD", "amount": 1.05},
+			{"currencyCode": "USD", "units": 1.5},
+			{"currencyCode": "USD", "units": "twelve"},
+			{"currencyCode": "USD", "units": true},
+			{"currencyCode": "USD", "nanos": 1e12},
+			{"currencyCode": "USD", "units": 1, "nanos": -1},
+			{"units": 1},
+		}
+		for _, input := range inputs {
+			s, err := structpb.NewStruct(input)
+			require.NoError(t, err)
+			_, err = MoneyFromStruct(s)
+			require.ErrorIs(t, err, ErrInvalidMoney, input)
+		}
+	})
+}
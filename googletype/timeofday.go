@@ -0,0 +1,106 @@
+package googletype
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/timeofday"
+)
+
+// ErrInvalidTimeOfDay is returned when a time of day or its representation is malformed
+var ErrInvalidTimeOfDay = errors.New("invalid time of day")
+
+// ValidateTimeOfDay checks the ranges of each component of tod. As allowed by
+// google.type.TimeOfDay, "24:00:00" is accepted for closing times and a seconds value
+// of 60 for leap seconds
+func ValidateTimeOfDay(tod *timeofday.TimeOfDay) error {
+	if tod == nil {
+		return fmt.Errorf("%w: nil time of day", ErrInvalidTimeOfDay)
+	}
+	hours, minutes, seconds, nanos := tod.GetHours(), tod.GetMinutes(), tod.GetSeconds(), tod.GetNanos()
+	switch {
+	case hours == 24 && (minutes != 0 || seconds != 0 || nanos != 0):
+		return fmt.Errorf("%w: only 24:00:00 may use hour 24", ErrInvalidTimeOfDay)
+	case hours < 0 || hours > 24:
+		return fmt.Errorf("%w: hours %d out of range", ErrInvalidTimeOfDay, hours)
+	case minutes < 0 || minutes > 59:
+		return fmt.Errorf("%w: minutes %d out of range", ErrInvalidTimeOfDay, minutes)
+	case seconds < 0 || seconds > 60:
+		return fmt.Errorf("%w: seconds %d out of range", ErrInvalidTimeOfDay, seconds)
+	case nanos < 0 || nanos > 999_999_999:
+		return fmt.Errorf("%w: nanos %d out of range", ErrInvalidTimeOfDay, nanos)
+	}
+	return nil
+}
+
+// TimeOfDayToString formats tod as "13:45:00", adding a fractional part such as
+// "13:45:00.25" only when nanos are set
+func TimeOfDayToString(tod *timeofday.TimeOfDay) (string, error) {
+	if err := ValidateTimeOfDay(tod); err != nil {
+		return "", err
+	}
+	s := fmt.Sprintf("%02d:%02d:%02d", tod.GetHours(), tod.GetMinutes(), tod.GetSeconds())
+	if nanos := tod.GetNanos(); nanos != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%09d", nanos), "0")
+	}
+	return s, nil
+}
+
+// ParseTimeOfDay parses "13:45", "13:45:00" or "13:45:00.123456789" into a *timeofday.TimeOfDay
+func ParseTimeOfDay(s string) (*timeofday.TimeOfDay, error) {
+	var hours, minutes, seconds, nanos int32
+
+	main, fraction, hasFraction := strings.Cut(s, ".")
+	parts := strings.Split(main, ":")
+	if len(parts) < 2 || len(parts) > 3 || (hasFraction && len(parts) != 3) {
+		return nil, fmt.Errorf("%w: %q is not in HH:MM[:SS[.fraction]] form", ErrInvalidTimeOfDay, s)
+	}
+	targets := []*int32{&hours, &minutes, &seconds}
+	for i, part := range parts {
+		if len(part) != 2 || !isDigits(part) {
+			return nil, fmt.Errorf("%w: %q is not in HH:MM[:SS[.fraction]] form", ErrInvalidTimeOfDay, s)
+		}
+		*targets[i] = int32(part[0]-'0')*10 + int32(part[1]-'0')
+	}
+	if hasFraction {
+		if fraction == "" || len(fraction) > 9 || !isDigits(fraction) {
+			return nil, fmt.Errorf("%w: malformed fractional seconds in %q", ErrInvalidTimeOfDay, s)
+		}
+		for _, r := range fraction + strings.Repeat("0", 9-len(fraction)) {
+			nanos = nanos*10 + (r - '0')
+		}
+	}
+
+	tod := &timeofday.TimeOfDay{Hours: hours, Minutes: minutes, Seconds: seconds, Nanos: nanos}
+	if err := ValidateTimeOfDay(tod); err != nil {
+		return nil, err
+	}
+	return tod, nil
+}
+
+// TimeOfDayFromTime returns the wall clock time of t in its own location
+func TimeOfDayFromTime(t time.Time) *timeofday.TimeOfDay {
+	return &timeofday.TimeOfDay{
+		Hours:   int32(t.Hour()),
+		Minutes: int32(t.Minute()),
+		Seconds: int32(t.Second()),
+		Nanos:   int32(t.Nanosecond()),
+	}
+}
+
+// DateTimeOfDayToTime combines a full date and a time of day into a time.Time in loc,
+// which defaults to UTC. "24:00:00" resolves to midnight at the end of the date
+func DateTimeOfDayToTime(d *date.Date, tod *timeofday.TimeOfDay, loc *time.Location) (time.Time, error) {
+	day, err := DateToTime(d, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := ValidateTimeOfDay(tod); err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(),
+		int(tod.GetHours()), int(tod.GetMinutes()), int(tod.GetSeconds()), int(tod.GetNanos()), day.Location()), nil
+}
@@ -0,0 +1,128 @@
+package googletype
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/timeofday"
+)
+
+func TestValidateTimeOfDay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		tod     *timeofday.TimeOfDay
+		wantErr bool
+	}{
+		{"nil", nil, true},
+		{"midnight", &timeofday.TimeOfDay{}, false},
+		{"afternoon", &timeofday.TimeOfDay{Hours: 13, Minutes: 45}, false},
+		{"closing time", &timeofday.TimeOfDay{Hours: 24}, false},
+		{"after closing time", &timeofday.TimeOfDay{Hours: 24, Minutes: 1}, true},
+		{"leap second", &timeofday.TimeOfDay{Hours: 23, Minutes: 59, Seconds: 60}, false},
+		{"hours out of range", &timeofday.TimeOfDay{Hours: 25}, true},
+		{"minutes out of range", &timeofday.TimeOfDay{Minutes: 60}, true},
+		{"seconds out of range", &timeofday.TimeOfDay{Seconds: 61}, true},
+		{"negative nanos", &timeofday.TimeOfDay{Nanos: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateTimeOfDay(tt.tod)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidTimeOfDay)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTimeOfDayString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input     string
+		want      *timeofday.TimeOfDay
+		formatted string
+	}{
+		{"13:45", &timeofday.TimeOfDay{Hours: 13, Minutes: 45}, "13:45:00"},
+		{"13:45:00", &timeofday.TimeOfDay{Hours: 13, Minutes: 45}, "13:45:00"},
+		{"07:05:09.25", &timeofday.TimeOfDay{Hours: 7, Minutes: 5, Seconds: 9, Nanos: 250000000}, "07:05:09.25"},
+		{"24:00:00", &timeofday.TimeOfDay{Hours: 24}, "24:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			tod, err := ParseTimeOfDay(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.GetHours(), tod.GetHours())
+			assert.Equal(t, tt.want.GetMinutes(), tod.GetMinutes())
+			assert.Equal(t, tt.want.GetSeconds(), tod.GetSeconds())
+			assert.Equal(t, tt.want.GetNanos(), tod.GetNanos())
+
+			s, err := TimeOfDayToString(tod)
+			require.NoError(t, err)
+			assert.Equal(t, tt.formatted, s)
+		})
+	}
+
+	t.Run("parse errors", func(t *testing.T) {
+		t.Parallel()
+		inputs := []string{"", "13", "1:45", "13:45:00:00", "13:45.5", "13:45:00.", "13:4a", "25:00", "13:45:00.1234567890"}
+		for _, input := range inputs {
+			_, err := ParseTimeOfDay(input)
+			require.ErrorIs(t, err, ErrInvalidTimeOfDay, input)
+		}
+	})
+
+	t.Run("format invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := TimeOfDayToString(&timeofday.TimeOfDay{Hours: 30})
+		require.ErrorIs(t, err, ErrInvalidTimeOfDay)
+	})
+}
+
+func TestTimeOfDayTime(t *testing.T) {
+	t.Parallel()
+
+	t.Run("from time", func(t *testing.T) {
+		t.Parallel()
+		tod := TimeOfDayFromTime(time.Date(2024, 5, 1, 13, 45, 30, 500, time.UTC))
+		assert.Equal(t, int32(13), tod.GetHours())
+		assert.Equal(t, int32(45), tod.GetMinutes())
+		assert.Equal(t, int32(30), tod.GetSeconds())
+		assert.Equal(t, int32(500), tod.GetNanos())
+	})
+
+	t.Run("combine with date", func(t *testing.T) {
+		t.Parallel()
+		d := &date.Date{Year: 2024, Month: 5, Day: 1}
+		result, err := DateTimeOfDayToTime(d, &timeofday.TimeOfDay{Hours: 13, Minutes: 45}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 5, 1, 13, 45, 0, 0, time.UTC), result)
+	})
+
+	t.Run("closing time rolls to next day", func(t *testing.T) {
+		t.Parallel()
+		d := &date.Date{Year: 2024, Month: 5, Day: 31}
+		result, err := DateTimeOfDayToTime(d, &timeofday.TimeOfDay{Hours: 24}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), result)
+	})
+
+	t.Run("invalid inputs", func(t *testing.T) {
+		t.Parallel()
+		_, err := DateTimeOfDayToTime(&date.Date{Year: 2024}, &timeofday.TimeOfDay{}, nil)
+		require.ErrorIs(t, err, ErrInvalidDate)
+
+		_, err = DateTimeOfDayToTime(&date.Date{Year: 2024, Month: 1, Day: 1}, &timeofday.TimeOfDay{Hours: -1}, nil)
+		require.ErrorIs(t, err, ErrInvalidTimeOfDay)
+	})
+}
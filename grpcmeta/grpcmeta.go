@@ -0,0 +1,168 @@
+// Package grpcmeta converts gRPC metadata to and from maps and Structs, for carrying
+// request metadata inside Struct-typed payloads such as audit events.
+//
+// Values of binary keys, those with the "-bin" suffix, hold raw bytes in metadata.MD
+// and are base64 encoded in maps and Structs, where every value must be a string.
+package grpcmeta
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrInvalidMetadata is returned for maps and Structs that do not describe metadata
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// MultiValue is the policy for keys with several values in ToMap and ToStruct
+type MultiValue int
+
+const (
+	// MultiValueList writes every key as a list of strings, even with one value
+	MultiValueList MultiValue = iota
+	// MultiValueAuto writes keys with one value as a string and others as lists
+	MultiValueAuto
+	// MultiValueFirst keeps the first value of every key
+	MultiValueFirst
+	// MultiValueLast keeps the last value of every key
+	MultiValueLast
+	// MultiValueJoin joins the values of every key with ", ", which FromMap does not
+	// split again
+	MultiValueJoin
+)
+
+// Option configures ToMap and ToStruct
+type Option func(*options)
+
+type options struct {
+	multiValue MultiValue
+}
+
+// WithMultiValue sets the policy for keys with several values, MultiValueList by default
+func WithMultiValue(policy MultiValue) Option {
+	return func(o *options) {
+		o.multiValue = policy
+	}
+}
+
+// IsBinaryKey reports whether the values of key are binary, per its "-bin" suffix
+func IsBinaryKey(key string) bool {
+	return strings.HasSuffix(strings.ToLower(key), "-bin")
+}
+
+// ToMap converts md to a map of strings or lists of strings, as chosen by
+// WithMultiValue, with binary values in standard base64. Keys without values are
+// omitted, except with MultiValueList where they are empty lists
+func ToMap(md metadata.MD, opts ...Option) map[string]any {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	m := make(map[string]any, len(md))
+	for key, values := range md {
+		if IsBinaryKey(key) {
+			encoded := make([]string, len(values))
+			for i, v := range values {
+				encoded[i] = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			values = encoded
+		}
+
+		switch {
+		case o.multiValue == MultiValueList, o.multiValue == MultiValueAuto && len(values) > 1:
+			list := make([]any, len(values))
+			for i, v := range values {
+				list[i] = v
+			}
+			m[key] = list
+		case len(values) == 0:
+		case o.multiValue == MultiValueLast:
+			m[key] = values[len(values)-1]
+		case o.multiValue == MultiValueJoin:
+			m[key] = strings.Join(values, ", ")
+		default:
+			m[key] = values[0]
+		}
+	}
+	return m
+}
+
+// ToStruct is ToMap returning a Struct
+func ToStruct(md metadata.MD, opts ...Option) *structpb.Struct {
+	m := ToMap(md, opts...)
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(m))}
+	for key, v := range m {
+		switch v := v.(type) {
+		case string:
+			s.Fields[key] = structpb.NewStringValue(v)
+		case []any:
+			list := &structpb.ListValue{Values: make([]*structpb.Value, len(v))}
+			for i, item := range v {
+				list.Values[i] = structpb.NewStringValue(item.(string))
+			}
+			s.Fields[key] = structpb.NewListValue(list)
+		}
+	}
+	return s
+}
+
+// FromMap converts m, whose values are strings or lists of strings, to metadata.
+// Keys are lower-cased as gRPC requires and values of binary keys are decoded from
+// standard base64, padded or not. Fails with ErrInvalidMetadata for other values
+func FromMap(m map[string]any) (metadata.MD, error) {
+	md := make(metadata.MD, len(m))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		var values []string
+		switch v := m[key].(type) {
+		case string:
+			values = []string{v}
+		case []string:
+			values = v
+		case []any:
+			values = make([]string, len(v))
+			for i, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: %s[%d] is a %T, not a string", ErrInvalidMetadata, key, i, item)
+				}
+				values[i] = s
+			}
+		default:
+			return nil, fmt.Errorf("%w: %s is a %T, not a string or list of strings", ErrInvalidMetadata, key, v)
+		}
+
+		if IsBinaryKey(key) {
+			decoded := make([]string, len(values))
+			for i, v := range values {
+				b, err := decodeBinary(v)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s is not base64: %w", ErrInvalidMetadata, key, err)
+				}
+				decoded[i] = string(b)
+			}
+			values = decoded
+		}
+		md.Append(key, values...)
+	}
+	return md, nil
+}
+
+// FromStruct is FromMap for a Struct
+func FromStruct(s *structpb.Struct) (metadata.MD, error) {
+	return FromMap(s.AsMap())
+}
+
+// decodeBinary decodes v like gRPC decodes binary headers, with or without padding
+func decodeBinary(v string) ([]byte, error) {
+	if len(v)%4 == 0 {
+		return base64.StdEncoding.DecodeString(v)
+	}
+	return base64.RawStdEncoding.DecodeString(v)
+}
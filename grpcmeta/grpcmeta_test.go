@@ -0,0 +1,129 @@
+package grpcmeta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func testMD() metadata.MD {
+	return metadata.Pairs(
+		"user-agent", "shire/1.0",
+		"x-hop", "bree",
+		"x-hop", "rivendell",
+		"trace-bin", "\x00\xff",
+	)
+}
+
+func TestToMap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy MultiValue
+		want   map[string]any
+	}{
+		{
+			name:   "list",
+			policy: MultiValueList,
+			want: map[string]any{
+				"user-agent": []any{"shire/1.0"},
+				"x-hop":      []any{"bree", "rivendell"},
+				"trace-bin":  []any{"AP8="},
+			},
+		},
+		{
+			name:   "auto",
+			policy: MultiValueAuto,
+			want: map[string]any{
+				"user-agent": "shire/1.0",
+				"x-hop":      []any{"bree", "rivendell"},
+				"trace-bin":  "AP8=",
+			},
+		},
+		{
+			name:   "first",
+			policy: MultiValueFirst,
+			want:   map[string]any{"user-agent": "shire/1.0", "x-hop": "bree", "trace-bin": "AP8="},
+		},
+		{
+			name:   "last",
+			policy: MultiValueLast,
+			want:   map[string]any{"user-agent": "shire/1.0", "x-hop": "rivendell", "trace-bin": "AP8="},
+		},
+		{
+			name:   "join",
+			policy: MultiValueJoin,
+			want:   map[string]any{"user-agent": "shire/1.0", "x-hop": "bree, rivendell", "trace-bin": "AP8="},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, ToMap(testMD(), WithMultiValue(tt.policy)))
+
+			s := ToStruct(testMD(), WithMultiValue(tt.policy))
+			want, err := structpb.NewStruct(tt.want)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(want, s), "%v", s)
+		})
+	}
+
+	t.Run("keys without values", func(t *testing.T) {
+		t.Parallel()
+		md := metadata.MD{"empty": nil}
+		assert.Equal(t, map[string]any{"empty": []any{}}, ToMap(md))
+		assert.Empty(t, ToMap(md, WithMultiValue(MultiValueFirst)))
+	})
+}
+
+func TestFromMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		for _, policy := range []MultiValue{MultiValueList, MultiValueAuto} {
+			md, err := FromMap(ToMap(testMD(), WithMultiValue(policy)))
+			require.NoError(t, err)
+			assert.Equal(t, testMD(), md)
+
+			md, err = FromStruct(ToStruct(testMD(), WithMultiValue(policy)))
+			require.NoError(t, err)
+			assert.Equal(t, testMD(), md)
+		}
+	})
+
+	t.Run("normalizes keys and accepts unpadded base64", func(t *testing.T) {
+		t.Parallel()
+		md, err := FromMap(map[string]any{
+			"X-Hop":     []string{"bree"},
+			"Trace-Bin": "AP8",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, metadata.MD{"x-hop": {"bree"}, "trace-bin": {"\x00\xff"}}, md)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		for name, m := range map[string]map[string]any{
+			"number":       {"x-count": 3.0},
+			"list of maps": {"x-hop": []any{"bree", map[string]any{}}},
+			"bad base64":   {"trace-bin": "not base64!"},
+		} {
+			_, err := FromMap(m)
+			require.ErrorIs(t, err, ErrInvalidMetadata, name)
+		}
+	})
+}
+
+func TestIsBinaryKey(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsBinaryKey("trace-bin"))
+	assert.True(t, IsBinaryKey("Trace-BIN"))
+	assert.False(t, IsBinaryKey("bin"))
+	assert.False(t, IsBinaryKey("trace-binary"))
+}
@@ -0,0 +1,280 @@
+// Package grpcreflect builds dynamic gRPC requests from Structs using server reflection,
+// for grpcurl-like tools that call methods without compiled-in stubs
+package grpcreflect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrNotFound is returned when the server does not know a requested service or method
+var ErrNotFound = errors.New("not found")
+
+// Client resolves descriptors through the v1 gRPC server reflection service and
+// caches every file it has fetched
+type Client struct {
+	conn grpc.ClientConnInterface
+
+	mu    sync.Mutex
+	files map[string]*descriptorpb.FileDescriptorProto
+}
+
+// NewClient returns a Client that queries the reflection service on conn
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{
+		conn:  conn,
+		files: make(map[string]*descriptorpb.FileDescriptorProto),
+	}
+}
+
+// ListServices returns the full names of the services registered on the server
+func (c *Client) ListServices(ctx context.Context) ([]string, error) {
+	resp, err := c.roundTrip(ctx, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	services := resp.GetListServicesResponse().GetService()
+	names := make([]string, len(services))
+	for i, service := range services {
+		names[i] = service.GetName()
+	}
+	return names, nil
+}
+
+// ResolveMethod fetches the descriptor of a method, given as "pkg.Service/Method",
+// "/pkg.Service/Method" or "pkg.Service.Method"
+func (c *Client) ResolveMethod(ctx context.Context, method string) (protoreflect.MethodDescriptor, error) {
+	service, name, err := splitMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := c.filesFor(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s: %w", service, ErrNotFound)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("method %s/%s: %w", service, name, ErrNotFound)
+	}
+	return md, nil
+}
+
+// Invoke calls a unary method with a request built from req and returns the response
+// as a Struct, see NewRequest and ResponseToStruct
+func (c *Client) Invoke(ctx context.Context, method string, req *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	md, err := c.ResolveMethod(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is streaming, only unary methods can be invoked", md.FullName())
+	}
+
+	in, err := NewRequest(md, req)
+	if err != nil {
+		return nil, err
+	}
+	out := dynamicpb.NewMessage(md.Output())
+	fullMethod := "/" + string(md.Parent().FullName()) + "/" + string(md.Name())
+	if err := c.conn.Invoke(ctx, fullMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return ResponseToStruct(out)
+}
+
+// NewRequest converts s into a dynamic message of the method's input type. Field names
+// may use either the JSON or the proto form, and values follow the protojson mapping,
+// e.g. enums by name, 64-bit integers as strings and timestamps in RFC 3339
+func NewRequest(md protoreflect.MethodDescriptor, s *structpb.Struct) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(md.Input())
+	if s == nil {
+		return msg, nil
+	}
+
+	data, err := protojson.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	opts := protojson.UnmarshalOptions{Resolver: typesFor(md.ParentFile())}
+	if err := opts.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("building %s: %w", md.Input().FullName(), err)
+	}
+	return msg, nil
+}
+
+// ResponseToStruct converts any message, typically a dynamic response, into a Struct
+// using the protojson mapping with proto field names
+func ResponseToStruct(m proto.Message) (*structpb.Struct, error) {
+	opts := protojson.MarshalOptions{UseProtoNames: true}
+	if dm, ok := m.(*dynamicpb.Message); ok {
+		opts.Resolver = typesFor(dm.Descriptor().ParentFile())
+	}
+	data, err := opts.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// typesFor resolves message types from the file's imports, falling back to the
+// types linked into the binary, so Any fields can be expanded
+func typesFor(fd protoreflect.FileDescriptor) *dynamicpb.Types {
+	files := &protoregistry.Files{}
+	var register func(fd protoreflect.FileDescriptor)
+	register = func(fd protoreflect.FileDescriptor) {
+		if _, err := files.FindFileByPath(fd.Path()); err == nil {
+			return
+		}
+		_ = files.RegisterFile(fd)
+		imports := fd.Imports()
+		for i := range imports.Len() {
+			register(imports.Get(i).FileDescriptor)
+		}
+	}
+	register(fd)
+	return dynamicpb.NewTypes(files)
+}
+
+// splitMethod separates a method name into its service and method parts
+func splitMethod(method string) (service, name string, err error) {
+	method = strings.TrimPrefix(method, "/")
+	i := strings.LastIndexByte(method, '/')
+	if i < 0 {
+		i = strings.LastIndexByte(method, '.')
+	}
+	if i <= 0 || i == len(method)-1 {
+		return "", "", fmt.Errorf("invalid method name %q, expected pkg.Service/Method", method)
+	}
+	return method[:i], method[i+1:], nil
+}
+
+// filesFor fetches the file declaring symbol and all of its dependencies and
+// returns them as a registry
+func (c *Client) filesFor(ctx context.Context, symbol string) (*protoregistry.Files, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp, err := c.roundTrip(ctx, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("symbol %s: %w", symbol, err)
+	}
+	root, err := c.addFiles(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// servers usually send the transitive dependencies along, fetch any that are missing
+	pending := []string{root}
+	seen := make(map[string]bool)
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		fdp, ok := c.files[name]
+		if !ok {
+			resp, err := c.roundTrip(ctx, &rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("file %s: %w", name, err)
+			}
+			if _, err := c.addFiles(resp); err != nil {
+				return nil, err
+			}
+			if fdp, ok = c.files[name]; !ok {
+				return nil, fmt.Errorf("file %s: %w", name, ErrNotFound)
+			}
+		}
+		pending = append(pending, fdp.GetDependency()...)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for name := range seen {
+		set.File = append(set.File, c.files[name])
+	}
+	return protodesc.NewFiles(set)
+}
+
+// addFiles caches the files in a FileDescriptorResponse and returns the name of the
+// first, which is the one that was asked for
+func (c *Client) addFiles(resp *rpb.ServerReflectionResponse) (string, error) {
+	var first string
+	for i, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fdp := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(raw, fdp); err != nil {
+			return "", fmt.Errorf("decoding file descriptor: %w", err)
+		}
+		if i == 0 {
+			first = fdp.GetName()
+		}
+		c.files[fdp.GetName()] = fdp
+	}
+	if first == "" {
+		return "", errors.New("empty file descriptor response")
+	}
+	return first, nil
+}
+
+// roundTrip sends a single reflection request on a new stream
+func (c *Client) roundTrip(ctx context.Context, req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := rpb.NewServerReflectionClient(c.conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	_ = stream.CloseSend()
+
+	if e := resp.GetErrorResponse(); e != nil {
+		if codes.Code(e.GetErrorCode()) == codes.NotFound {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, e.GetErrorMessage())
+		}
+		return nil, fmt.Errorf("reflection error %d: %s", e.GetErrorCode(), e.GetErrorMessage())
+	}
+	return resp, nil
+}
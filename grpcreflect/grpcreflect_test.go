@@ -0,0 +1,131 @@
+package grpcreflect
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// newTestClient serves the health and reflection services over an in-memory listener
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("shire", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return NewClient(conn)
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t)
+	ctx := t.Context()
+
+	t.Run("list services", func(t *testing.T) {
+		services, err := c.ListServices(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, services, "grpc.health.v1.Health")
+	})
+
+	t.Run("resolve method", func(t *testing.T) {
+		for _, name := range []string{"grpc.health.v1.Health/Check", "/grpc.health.v1.Health/Check", "grpc.health.v1.Health.Check"} {
+			md, err := c.ResolveMethod(ctx, name)
+			require.NoError(t, err, name)
+			assert.Equal(t, "grpc.health.v1.HealthCheckRequest", string(md.Input().FullName()))
+		}
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		_, err := c.ResolveMethod(ctx, "mordor.Tower/Watch")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		_, err := c.ResolveMethod(ctx, "grpc.health.v1.Health/Explode")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("invoke", func(t *testing.T) {
+		resp, err := c.Invoke(ctx, "grpc.health.v1.Health/Check",
+			&structpb.Struct{Fields: map[string]*structpb.Value{"service": structpb.NewStringValue("shire")}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"status": "SERVING"}, resp.AsMap())
+	})
+
+	t.Run("invoke rejects streaming", func(t *testing.T) {
+		_, err := c.Invoke(ctx, "grpc.health.v1.Health/Watch", nil)
+		assert.ErrorContains(t, err, "streaming")
+	})
+}
+
+func TestNewRequest(t *testing.T) {
+	t.Parallel()
+
+	md := healthpb.File_grpc_health_v1_health_proto.Services().ByName("Health").Methods().ByName("Check")
+
+	t.Run("fields", func(t *testing.T) {
+		t.Parallel()
+		msg, err := NewRequest(md, &structpb.Struct{Fields: map[string]*structpb.Value{
+			"service": structpb.NewStringValue("shire"),
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "shire", msg.Get(md.Input().Fields().ByName("service")).String())
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewRequest(md, &structpb.Struct{Fields: map[string]*structpb.Value{
+			"ring": structpb.NewBoolValue(true),
+		}})
+		assert.ErrorContains(t, err, "building grpc.health.v1.HealthCheckRequest")
+	})
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		msg, err := NewRequest(md, nil)
+		require.NoError(t, err)
+		assert.Equal(t, md.Input(), msg.Descriptor())
+	})
+}
+
+func TestResponseToStruct(t *testing.T) {
+	t.Parallel()
+
+	s, err := ResponseToStruct(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"status": "NOT_SERVING"}, s.AsMap())
+}
+
+func TestSplitMethod(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"", "Check", "/", "pkg.Service/", "/Method"} {
+		_, _, err := splitMethod(name)
+		assert.Error(t, err, name)
+	}
+}
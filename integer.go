@@ -0,0 +1,99 @@
+package protobaggins
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/robbyt/protobaggins/internal/conv"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxSafeInteger is the largest magnitude up to which every integer is a float64
+const maxSafeInteger = conv.MaxSafeInteger
+
+// WithLargeIntegerStrings encodes int, int64, uint and uint64 values beyond ±2^53,
+// which a float64 number cannot hold exactly, as decimal strings, see Int64ToValue.
+// When decoding, strings holding such an integer in canonical decimal form become
+// int64, or uint64 above math.MaxInt64, so the values survive a round trip. Strings
+// that merely look like large integers are decoded the same way
+func WithLargeIntegerStrings() Option {
+	return func(o *options) {
+		o.largeIntegerStrings = true
+	}
+}
+
+// Int64ToValue converts i to a number if a float64 holds it exactly, and otherwise to
+// its decimal string, so no precision is lost
+func Int64ToValue(i int64) *structpb.Value {
+	if i < -maxSafeInteger || i > maxSafeInteger {
+		return structpb.NewStringValue(strconv.FormatInt(i, 10))
+	}
+	return structpb.NewNumberValue(float64(i))
+}
+
+// Uint64ToValue is Int64ToValue for unsigned integers
+func Uint64ToValue(u uint64) *structpb.Value {
+	if u > maxSafeInteger {
+		return structpb.NewStringValue(strconv.FormatUint(u, 10))
+	}
+	return structpb.NewNumberValue(float64(u))
+}
+
+// Int64FromValue is the lossless inverse of Int64ToValue. It accepts whole numbers of
+// at most 2^53 in magnitude, beyond which a number may already have been rounded, and
+// base-10 integer strings. Fails with ErrNotCoercible for values that are not exact
+// integers or overflow an int64, and with ErrUnexpectedKind for other kinds
+func Int64FromValue(v *structpb.Value) (int64, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		f := kind.NumberValue
+		if f != math.Trunc(f) || math.Abs(f) > maxSafeInteger {
+			return 0, fmt.Errorf("%w: %v is not an exact integer", ErrNotCoercible, f)
+		}
+		return int64(f), nil
+	case *structpb.Value_StringValue:
+		i, err := strconv.ParseInt(kind.StringValue, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q is not an int64", ErrNotCoercible, kind.StringValue)
+		}
+		return i, nil
+	default:
+		return 0, fmt.Errorf("%w: cannot convert %s to an integer", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+// Uint64FromValue is Int64FromValue for unsigned integers
+func Uint64FromValue(v *structpb.Value) (uint64, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		f := kind.NumberValue
+		if f != math.Trunc(f) || f < 0 || f > maxSafeInteger {
+			return 0, fmt.Errorf("%w: %v is not an exact unsigned integer", ErrNotCoercible, f)
+		}
+		return uint64(f), nil
+	case *structpb.Value_StringValue:
+		u, err := strconv.ParseUint(kind.StringValue, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q is not a uint64", ErrNotCoercible, kind.StringValue)
+		}
+		return u, nil
+	default:
+		return 0, fmt.Errorf("%w: cannot convert %s to an integer", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+// decodeLargeInteger recognizes the strings produced for large integers under
+// WithLargeIntegerStrings
+func decodeLargeInteger(s string) (any, bool) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if (i < -maxSafeInteger || i > maxSafeInteger) && strconv.FormatInt(i, 10) == s {
+			return i, true
+		}
+		return nil, false
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil && strconv.FormatUint(u, 10) == s {
+		return u, true
+	}
+	return nil, false
+}
@@ -0,0 +1,107 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestInt64ToValue(t *testing.T) {
+	t.Parallel()
+
+	for _, i := range []int64{0, -1, 1 << 53, -(1 << 53), 1<<53 + 1, -(1<<53 + 1), math.MaxInt64, math.MinInt64} {
+		v := Int64ToValue(i)
+		got, err := Int64FromValue(v)
+		require.NoError(t, err, i)
+		assert.Equal(t, i, got)
+	}
+	assert.InDelta(t, float64(1<<53), Int64ToValue(1<<53).GetNumberValue(), 0)
+	assert.Equal(t, "9007199254740993", Int64ToValue(1<<53+1).GetStringValue())
+
+	for _, u := range []uint64{0, 1 << 53, 1<<53 + 1, math.MaxUint64} {
+		got, err := Uint64FromValue(Uint64ToValue(u))
+		require.NoError(t, err, u)
+		assert.Equal(t, u, got)
+	}
+	assert.Equal(t, "18446744073709551615", Uint64ToValue(math.MaxUint64).GetStringValue())
+}
+
+func TestInt64FromValue(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []*structpb.Value{
+		structpb.NewNumberValue(1.5),
+		structpb.NewNumberValue(1 << 54),
+		structpb.NewNumberValue(math.NaN()),
+		structpb.NewStringValue("9223372036854775808"),
+		structpb.NewStringValue("1.0"),
+		structpb.NewStringValue(" 1"),
+	} {
+		_, err := Int64FromValue(v)
+		require.ErrorIs(t, err, ErrNotCoercible, v)
+	}
+	_, err := Int64FromValue(structpb.NewBoolValue(true))
+	require.ErrorIs(t, err, ErrUnexpectedKind)
+
+	for _, v := range []*structpb.Value{
+		structpb.NewNumberValue(-1),
+		structpb.NewStringValue("-1"),
+		structpb.NewStringValue("18446744073709551616"),
+	} {
+		_, err := Uint64FromValue(v)
+		require.ErrorIs(t, err, ErrNotCoercible, v)
+	}
+	_, err = Uint64FromValue(nil)
+	require.ErrorIs(t, err, ErrUnexpectedKind)
+}
+
+func TestLargeIntegerStrings(t *testing.T) {
+	t.Parallel()
+
+	in := map[string]any{
+		"small":    int64(42),
+		"id":       int64(1<<62 + 1),
+		"negative": -(1<<53 + 1),
+		"unsigned": uint64(math.MaxUint64),
+		"list":     []any{uint(1<<60 + 3)},
+		"text":     "12345",
+	}
+
+	t.Run("lossy by default", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(in)
+		require.NoError(t, err)
+		assert.NotEqual(t, int64(1<<62+1), int64(v.GetStructValue().GetFields()["id"].GetNumberValue()))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithLargeIntegerStrings())
+		s, err := c.NewStruct(in)
+		require.NoError(t, err)
+
+		fields := s.GetFields()
+		assert.InDelta(t, 42.0, fields["small"].GetNumberValue(), 0)
+		assert.Equal(t, "4611686018427387905", fields["id"].GetStringValue())
+		assert.Equal(t, "-9007199254740993", fields["negative"].GetStringValue())
+
+		assert.Equal(t, map[string]any{
+			"small":    42.0,
+			"id":       int64(1<<62 + 1),
+			"negative": int64(-(1<<53 + 1)),
+			"unsigned": uint64(math.MaxUint64),
+			"list":     []any{int64(1<<60 + 3)},
+			"text":     "12345",
+		}, c.StructToMap(s))
+	})
+
+	t.Run("decoding only canonical strings", func(t *testing.T) {
+		t.Parallel()
+		for _, s := range []string{"+9007199254740993", "09007199254740993", "9007199254740993.0", "99999999999999999999"} {
+			assert.Equal(t, s, ValueToInterface(structpb.NewStringValue(s), WithLargeIntegerStrings()))
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package protobaggins
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxInternedString is the length of the longest string WithInterning shares
+const maxInternedString = 64
+
+// WithInterning makes conversions return shared values for null, booleans and up to
+// maxValues distinct numbers and strings of at most 64 bytes, instead of allocating a
+// value for each occurrence. With a Converter the values are shared by all of its
+// conversions, which saves memory and allocations when the same values recur often.
+//
+// Shared values must never be modified, which includes merging into or setting paths
+// within a result, as the change would show through every other occurrence. Clone
+// results that need to be modified with proto.Clone
+func WithInterning(maxValues int) Option {
+	return func(o *options) {
+		o.intern = newInternCache(maxValues)
+	}
+}
+
+// internCache holds the shared values of WithInterning, safe for concurrent use
+type internCache struct {
+	nullValue  *structpb.Value
+	trueValue  *structpb.Value
+	falseValue *structpb.Value
+	strings    sync.Map // string to *structpb.Value
+	numbers    sync.Map // the bits of a float64 to *structpb.Value
+	size       atomic.Int64
+	limit      int64
+}
+
+func newInternCache(limit int) *internCache {
+	return &internCache{
+		nullValue:  structpb.NewNullValue(),
+		trueValue:  structpb.NewBoolValue(true),
+		falseValue: structpb.NewBoolValue(false),
+		limit:      int64(max(limit, 0)),
+	}
+}
+
+func (c *internCache) boolValue(b bool) *structpb.Value {
+	if b {
+		return c.trueValue
+	}
+	return c.falseValue
+}
+
+// stringValue returns the shared value for s, or nil if it is not shared
+func (c *internCache) stringValue(s string) *structpb.Value {
+	if len(s) > maxInternedString {
+		return nil
+	}
+	if v, ok := c.strings.Load(s); ok {
+		return v.(*structpb.Value)
+	}
+	if !c.reserve() {
+		return nil
+	}
+	v, loaded := c.strings.LoadOrStore(s, structpb.NewStringValue(s))
+	if loaded {
+		c.size.Add(-1)
+	}
+	return v.(*structpb.Value)
+}
+
+// numberValue returns the shared value for f, or nil if it is not shared
+func (c *internCache) numberValue(f float64) *structpb.Value {
+	bits := math.Float64bits(f)
+	if v, ok := c.numbers.Load(bits); ok {
+		return v.(*structpb.Value)
+	}
+	if !c.reserve() {
+		return nil
+	}
+	v, loaded := c.numbers.LoadOrStore(bits, structpb.NewNumberValue(f))
+	if loaded {
+		c.size.Add(-1)
+	}
+	return v.(*structpb.Value)
+}
+
+// reserve reports whether there is room for another value
+func (c *internCache) reserve() bool {
+	if c.size.Add(1) > c.limit {
+		c.size.Add(-1)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package protobaggins
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWithInterning(t *testing.T) {
+	t.Parallel()
+
+	t.Run("shares repeated values", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithInterning(100))
+		first, err := c.SliceToStructValues([]any{"active", 1, true, nil, "active", 1.0})
+		require.NoError(t, err)
+		second, err := c.MapToStructValues(map[string]any{"status": "active", "count": int64(1), "ok": true, "none": nil})
+		require.NoError(t, err)
+
+		assert.Same(t, first[0], first[4])
+		assert.Same(t, first[1], first[5])
+		assert.Same(t, first[0], second["status"])
+		assert.Same(t, first[1], second["count"])
+		assert.Same(t, first[2], second["ok"])
+		assert.Same(t, first[3], second["none"])
+		assert.Equal(t, []any{"active", 1.0, true, nil, "active", 1.0}, StructValuesToSlice(first))
+	})
+
+	t.Run("same result", func(t *testing.T) {
+		t.Parallel()
+		input := map[string]any{"a": []any{"x", -0.0, 0, 2.5, false}, "b": map[string]any{"c": "x"}}
+		want, err := NewValue(input)
+		require.NoError(t, err)
+		got, err := NewValue(input, WithInterning(10), WithArena())
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, got))
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithInterning(2))
+		values, err := c.SliceToStructValues([]any{"a", "b", "c", "c", "a"})
+		require.NoError(t, err)
+		assert.Same(t, values[0], values[4])
+		assert.NotSame(t, values[2], values[3], "beyond the limit")
+
+		long := strings.Repeat("x", 65)
+		values, err = NewConverter(WithInterning(10)).SliceToStructValues([]any{long, long})
+		require.NoError(t, err)
+		assert.NotSame(t, values[0], values[1], "long strings are not shared")
+
+		values, err = NewConverter(WithInterning(0)).SliceToStructValues([]any{"a", "a", true, true})
+		require.NoError(t, err)
+		assert.NotSame(t, values[0], values[1])
+		assert.Same(t, values[2], values[3], "booleans are always shared")
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithInterning(50))
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for i := range 100 {
+					v, err := c.NewValue([]any{i % 60, "shared"})
+					if assert.NoError(t, err) {
+						assert.Equal(t, []any{float64(i % 60), "shared"}, v.AsInterface())
+					}
+				}
+			})
+		}
+		wg.Wait()
+	})
+}
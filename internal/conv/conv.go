@@ -0,0 +1,16 @@
+// Package conv holds helpers shared by protobaggins and its conversion subpackages,
+// which are not part of the public API
+package conv
+
+// MaxSafeInteger is 2^53, the largest magnitude up to which a float64 holds every
+// integer exactly. Integers beyond ±MaxSafeInteger are kept as strings or rejected,
+// even those that happen to be exact, such as 2^60, since neighbouring integers are not
+const MaxSafeInteger = 1 << 53
+
+// DescribePath names path in errors, "(root)" for the converted value itself
+func DescribePath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
@@ -0,0 +1,14 @@
+package conv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribePath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "(root)", DescribePath(""))
+	assert.Equal(t, "a.b[0]", DescribePath("a.b[0]"))
+}
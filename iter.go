@@ -0,0 +1,63 @@
+package protobaggins
+
+import (
+	"iter"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Fields iterates over the fields of s in sorted key order
+func Fields(s *structpb.Struct) iter.Seq2[string, *structpb.Value] {
+	return func(yield func(string, *structpb.Value) bool) {
+		fields := s.GetFields()
+		for _, key := range sortedKeys(s) {
+			if !yield(key, fields[key]) {
+				return
+			}
+		}
+	}
+}
+
+// Values iterates over the items of l with their indexes
+func Values(l *structpb.ListValue) iter.Seq2[int, *structpb.Value] {
+	return func(yield func(int, *structpb.Value) bool) {
+		for i, item := range l.GetValues() {
+			if !yield(i, item) {
+				return
+			}
+		}
+	}
+}
+
+// Leaves iterates over every value in v that is not a struct or list, with its path,
+// in pre-order with struct keys in sorted order. Empty structs and lists yield nothing.
+// A v that is not a container is yielded itself, at the empty path
+func Leaves(v *structpb.Value) iter.Seq2[string, *structpb.Value] {
+	return func(yield func(string, *structpb.Value) bool) {
+		leaves("", v, yield)
+	}
+}
+
+// leaves reports whether the iteration should continue
+func leaves(path string, v *structpb.Value, yield func(string, *structpb.Value) bool) bool {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		for key, field := range Fields(kind.StructValue) {
+			if !leaves(joinKey(path, key), field, yield) {
+				return false
+			}
+		}
+		return true
+	case *structpb.Value_ListValue:
+		for i, item := range kind.ListValue.GetValues() {
+			if !leaves(joinIndex(path, i), item, yield) {
+				return false
+			}
+		}
+		return true
+	}
+	if v == nil {
+		return true
+	}
+	return yield(path, v)
+}
@@ -0,0 +1,93 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFields(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{"c": 3, "a": 1, "b": 2})
+	require.NoError(t, err)
+
+	var keys []string
+	var sum float64
+	for key, v := range Fields(s) {
+		keys = append(keys, key)
+		sum += v.GetNumberValue()
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+	assert.InDelta(t, 6.0, sum, 0)
+
+	keys = nil
+	for key := range Fields(s) {
+		keys = append(keys, key)
+		if key == "b" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	for range Fields(nil) {
+		t.Fatal("nil struct yielded a field")
+	}
+}
+
+func TestValues(t *testing.T) {
+	t.Parallel()
+
+	l, err := structpb.NewList([]any{"x", "y", "z"})
+	require.NoError(t, err)
+
+	var got []string
+	for i, v := range Values(l) {
+		if i == 2 {
+			break
+		}
+		got = append(got, v.GetStringValue())
+	}
+	assert.Equal(t, []string{"x", "y"}, got)
+
+	for range Values(nil) {
+		t.Fatal("nil list yielded a value")
+	}
+}
+
+func TestLeaves(t *testing.T) {
+	t.Parallel()
+
+	v, err := structpb.NewValue(map[string]any{
+		"name":  "frodo",
+		"items": []any{"ring", map[string]any{"kind": "sword"}, []any{}},
+		"empty": map[string]any{},
+		"none":  nil,
+	})
+	require.NoError(t, err)
+
+	var paths []string
+	for path := range Leaves(v) {
+		paths = append(paths, path)
+	}
+	assert.Equal(t, []string{"items[0]", "items[1].kind", "name", "none"}, paths)
+
+	paths = nil
+	for path := range Leaves(v) {
+		paths = append(paths, path)
+		if len(paths) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"items[0]", "items[1].kind"}, paths)
+
+	for path, leaf := range Leaves(structpb.NewStringValue("x")) {
+		assert.Empty(t, path)
+		assert.Equal(t, "x", leaf.GetStringValue())
+	}
+	for range Leaves(nil) {
+		t.Fatal("nil value yielded a leaf")
+	}
+}
@@ -0,0 +1,414 @@
+package protobaggins
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/robbyt/protobaggins/internal/conv"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultJSONMaxDepth is the nesting limit of JSONToStruct, the same as protojson's
+const DefaultJSONMaxDepth = 10000
+
+// JSONOption configures JSONToStruct and StructToJSON, and MarshalJSON and
+// UnmarshalJSON for messages
+type JSONOption func(*jsonOptions)
+
+type jsonOptions struct {
+	maxSize         int
+	maxDepth        int
+	indent          string
+	emitUnpopulated bool
+	discardUnknown  bool
+	protoNames      bool
+}
+
+// JSONMaxSize makes JSONToStruct reject inputs longer than n bytes. Zero means no limit,
+// which is the default
+func JSONMaxSize(n int) JSONOption {
+	return func(o *jsonOptions) {
+		o.maxSize = max(n, 0)
+	}
+}
+
+// JSONMaxDepth makes JSONToStruct reject inputs that nest objects and arrays more than
+// depth levels deep, the top-level object being level 1. The default is
+// DefaultJSONMaxDepth, which is also the limit of encoding/json, so zero and larger
+// values do not allow deeper inputs
+func JSONMaxDepth(depth int) JSONOption {
+	return func(o *jsonOptions) {
+		o.maxDepth = max(depth, 0)
+	}
+}
+
+// JSONIndent makes StructToJSON write one value per line, each level indented by
+// indent. Without it the output is compact
+func JSONIndent(indent string) JSONOption {
+	return func(o *jsonOptions) {
+		o.indent = indent
+	}
+}
+
+func newJSONOptions(opts []JSONOption) jsonOptions {
+	o := jsonOptions{maxDepth: DefaultJSONMaxDepth}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ErrTooLarge is returned when an input or a conversion result exceeds a configured
+// size limit
+var ErrTooLarge = errors.New("input too large")
+
+// JSONToStruct parses a JSON object directly into a *structpb.Struct, without going
+// through map[string]any. Duplicate keys are rejected, as by protojson, and errors name
+// the path at which they occurred
+func JSONToStruct(data []byte, opts ...JSONOption) (*structpb.Struct, error) {
+	return parseJSONObject(data, newJSONOptions(opts), nil)
+}
+
+// parseJSONObject parses data as in JSONToStruct. Unless order is nil, the keys of each
+// object are recorded in it under the path of the object, in the order they appear
+func parseJSONObject(data []byte, o jsonOptions, order map[string][]string) (*structpb.Struct, error) {
+	if o.maxSize > 0 && len(data) > o.maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrTooLarge, len(data), o.maxSize)
+	}
+
+	return readJSONObject(bytes.NewReader(data), o, order)
+}
+
+// readJSONObject is parseJSONObject reading from r, which it does not check the size of
+func readJSONObject(r io.Reader, o jsonOptions, order map[string][]string) (*structpb.Struct, error) {
+	p := jsonParser{dec: json.NewDecoder(r), opts: o, order: order}
+	tok, err := p.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("%w: top level must be an object, got %s", ErrUnexpectedKind, describeToken(tok))
+	}
+	s, err := p.parseObject("")
+	if err != nil {
+		return nil, err
+	}
+	if err := p.end("object"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// end checks that nothing but whitespace follows the top-level object or array
+func (p *jsonParser) end(what string) error {
+	_, err := p.dec.Token()
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil
+	case errors.Is(err, ErrTooLarge):
+		return err
+	default:
+		return fmt.Errorf("unexpected data after the top-level %s", what)
+	}
+}
+
+type jsonParser struct {
+	dec   *json.Decoder
+	opts  jsonOptions
+	depth int
+	order map[string][]string
+}
+
+func (p *jsonParser) enter(path string) error {
+	p.depth++
+	if p.opts.maxDepth > 0 && p.depth > p.opts.maxDepth {
+		return fmt.Errorf("%s: %w of %d", describePath(path), ErrMaxDepth, p.opts.maxDepth)
+	}
+	return nil
+}
+
+// parseObject reads the members of an object whose opening brace has been consumed
+func (p *jsonParser) parseObject(path string) (*structpb.Struct, error) {
+	if err := p.enter(path); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	fields := make(map[string]*structpb.Value)
+	for p.dec.More() {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		keyPath := joinKey(path, key)
+		if _, dup := fields[key]; dup {
+			return nil, fmt.Errorf("%s: duplicate key", keyPath)
+		}
+		v, err := p.parseValue(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = v
+		if p.order != nil {
+			p.order[path] = append(p.order[path], key)
+		}
+	}
+	if _, err := p.dec.Token(); err != nil {
+		return nil, err
+	}
+	return &structpb.Struct{Fields: fields}, nil
+}
+
+// parseArray reads the elements of an array whose opening bracket has been consumed
+func (p *jsonParser) parseArray(path string) (*structpb.ListValue, error) {
+	if err := p.enter(path); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	var values []*structpb.Value
+	for p.dec.More() {
+		v, err := p.parseValue(joinIndex(path, len(values)))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	if _, err := p.dec.Token(); err != nil {
+		return nil, err
+	}
+	return &structpb.ListValue{Values: values}, nil
+}
+
+func (p *jsonParser) parseValue(path string) (*structpb.Value, error) {
+	tok, err := p.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case nil:
+		return structpb.NewNullValue(), nil
+	case bool:
+		return structpb.NewBoolValue(tok), nil
+	case float64:
+		return structpb.NewNumberValue(tok), nil
+	case string:
+		return structpb.NewStringValue(tok), nil
+	case json.Delim:
+		if tok == '{' {
+			s, err := p.parseObject(path)
+			if err != nil {
+				return nil, err
+			}
+			return structpb.NewStructValue(s), nil
+		}
+		l, err := p.parseArray(path)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewListValue(l), nil
+	default:
+		return nil, fmt.Errorf("%s: unexpected token %v", describePath(path), tok)
+	}
+}
+
+func describeToken(tok json.Token) string {
+	switch tok.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	default:
+		if tok == json.Delim('{') {
+			return "object"
+		}
+		return "array"
+	}
+}
+
+func describePath(path string) string {
+	return conv.DescribePath(path)
+}
+
+// StructToJSON serializes s as JSON with sorted keys, so the output is deterministic,
+// and without escaping HTML characters. NaN and infinite numbers cannot be represented
+// in JSON and fail with ErrNotCoercible. A nil Struct gives {}
+func StructToJSON(s *structpb.Struct, opts ...JSONOption) ([]byte, error) {
+	w := jsonWriter{opts: newJSONOptions(opts)}
+	if err := w.writeStruct("", s); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+type jsonWriter struct {
+	opts  jsonOptions
+	buf   []byte
+	depth int
+	// keys returns the keys of the Struct at path in the order to write them, sorted
+	// when it is nil
+	keys func(path string, s *structpb.Struct) []string
+	// nonFiniteNull writes NaN and infinities as null instead of failing
+	nonFiniteNull bool
+}
+
+// newline starts a new line at the current depth when indenting
+func (w *jsonWriter) newline() {
+	if w.opts.indent == "" {
+		return
+	}
+	w.buf = append(w.buf, '\n')
+	for range w.depth {
+		w.buf = append(w.buf, w.opts.indent...)
+	}
+}
+
+func (w *jsonWriter) writeStruct(path string, s *structpb.Struct) error {
+	var keys []string
+	if w.keys != nil {
+		keys = w.keys(path, s)
+	} else {
+		keys = sortedKeys(s)
+	}
+	if len(keys) == 0 {
+		w.buf = append(w.buf, "{}"...)
+		return nil
+	}
+
+	w.buf = append(w.buf, '{')
+	w.depth++
+	for i, key := range keys {
+		if i > 0 {
+			w.buf = append(w.buf, ',')
+		}
+		w.newline()
+		w.buf = appendJSONString(w.buf, key)
+		w.buf = append(w.buf, ':')
+		if w.opts.indent != "" {
+			w.buf = append(w.buf, ' ')
+		}
+		if err := w.writeValue(joinKey(path, key), s.GetFields()[key]); err != nil {
+			return err
+		}
+	}
+	w.depth--
+	w.newline()
+	w.buf = append(w.buf, '}')
+	return nil
+}
+
+func (w *jsonWriter) writeList(path string, l *structpb.ListValue) error {
+	if len(l.GetValues()) == 0 {
+		w.buf = append(w.buf, "[]"...)
+		return nil
+	}
+
+	w.buf = append(w.buf, '[')
+	w.depth++
+	for i, v := range l.GetValues() {
+		if i > 0 {
+			w.buf = append(w.buf, ',')
+		}
+		w.newline()
+		if err := w.writeValue(joinIndex(path, i), v); err != nil {
+			return err
+		}
+	}
+	w.depth--
+	w.newline()
+	w.buf = append(w.buf, ']')
+	return nil
+}
+
+func (w *jsonWriter) writeValue(path string, v *structpb.Value) error {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return w.writeStruct(path, kind.StructValue)
+	case *structpb.Value_ListValue:
+		return w.writeList(path, kind.ListValue)
+	case *structpb.Value_StringValue:
+		w.buf = appendJSONString(w.buf, kind.StringValue)
+	case *structpb.Value_NumberValue:
+		f := kind.NumberValue
+		if (math.IsNaN(f) || math.IsInf(f, 0)) && w.nonFiniteNull {
+			w.buf = append(w.buf, "null"...)
+			return nil
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%s: %w: %v cannot be represented in JSON", describePath(path), ErrNotCoercible, f)
+		}
+		w.buf = appendJSONNumber(w.buf, f)
+	case *structpb.Value_BoolValue:
+		w.buf = strconv.AppendBool(w.buf, kind.BoolValue)
+	default:
+		w.buf = append(w.buf, "null"...)
+	}
+	return nil
+}
+
+// appendJSONNumber formats f like encoding/json does
+func appendJSONNumber(b []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+// appendJSONString quotes s, escaping only what JSON requires plus U+2028 and U+2029
+// Invalid UTF-8 is replaced with U+FFFD
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b = append(b, '\\', c)
+			case c == '\n':
+				b = append(b, '\\', 'n')
+			case c == '\r':
+				b = append(b, '\\', 'r')
+			case c == '\t':
+				b = append(b, '\\', 't')
+			case c < 0x20:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				b = append(b, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b = append(b, "\ufffd"...)
+		case r == '\u2028' || r == '\u2029':
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xf])
+		default:
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(b, '"')
+}
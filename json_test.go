@@ -0,0 +1,153 @@
+package protobaggins
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestJSONToStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("matches protojson", func(t *testing.T) {
+		t.Parallel()
+		data := []byte(`{"name": "frodo", "age": 50, "ring": true, "home": null,
+			"past": [{"street": "bagshot row"}, 1.5e3, "x"], "empty": {}, "none": []}`)
+		got, err := JSONToStruct(data)
+		require.NoError(t, err)
+
+		want := &structpb.Struct{}
+		require.NoError(t, protojson.Unmarshal(data, want))
+		assert.True(t, proto.Equal(want, got), "got %v", got)
+	})
+
+	t.Run("rejects non-objects", func(t *testing.T) {
+		t.Parallel()
+		_, err := JSONToStruct([]byte(`[1]`))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		assert.Contains(t, err.Error(), "got array")
+	})
+
+	t.Run("rejects trailing data", func(t *testing.T) {
+		t.Parallel()
+		_, err := JSONToStruct([]byte(`{} {}`))
+		require.Error(t, err)
+	})
+
+	t.Run("rejects malformed input", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{``, `{`, `{"a": }`, `{"a": 1,}`} {
+			_, err := JSONToStruct([]byte(input))
+			require.Error(t, err, input)
+		}
+	})
+
+	t.Run("rejects duplicate keys", func(t *testing.T) {
+		t.Parallel()
+		_, err := JSONToStruct([]byte(`{"a": {"b": 1, "b": 2}}`))
+		require.EqualError(t, err, "a.b: duplicate key")
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		t.Parallel()
+		_, err := JSONToStruct([]byte(`{"a": 1}`), JSONMaxSize(7))
+		require.ErrorIs(t, err, ErrTooLarge)
+		_, err = JSONToStruct([]byte(`{"a": 1}`), JSONMaxSize(8))
+		require.NoError(t, err)
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		t.Parallel()
+		data := []byte(`{"a": [{"b": 1}]}`)
+		_, err := JSONToStruct(data, JSONMaxDepth(2))
+		require.ErrorIs(t, err, ErrMaxDepth)
+		assert.Contains(t, err.Error(), "a[0]")
+		_, err = JSONToStruct(data, JSONMaxDepth(3))
+		require.NoError(t, err)
+	})
+
+	t.Run("encoding/json limits depth regardless", func(t *testing.T) {
+		t.Parallel()
+		deep := `{"a":` + strings.Repeat("[", DefaultJSONMaxDepth) + strings.Repeat("]", DefaultJSONMaxDepth) + `}`
+		_, err := JSONToStruct([]byte(deep))
+		require.Error(t, err)
+		_, err = JSONToStruct([]byte(deep), JSONMaxDepth(0))
+		require.Error(t, err)
+	})
+}
+
+func TestStructToJSON(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"name":  "<frodo> & \"sam\"\n\u2028",
+		"age":   50,
+		"tiny":  1e-7,
+		"huge":  1e21,
+		"ring":  true,
+		"home":  nil,
+		"past":  []any{map[string]any{"b": 1, "a": 2}, "x"},
+		"empty": map[string]any{},
+		"none":  []any{},
+	})
+	require.NoError(t, err)
+
+	t.Run("compact with sorted keys", func(t *testing.T) {
+		t.Parallel()
+		got, err := StructToJSON(s)
+		require.NoError(t, err)
+		assert.Equal(t, `{"age":50,"empty":{},"home":null,"huge":1e+21,"name":"<frodo> & \"sam\"\n\u2028",`+
+			`"none":[],"past":[{"a":2,"b":1},"x"],"ring":true,"tiny":1e-7}`, string(got))
+		assert.True(t, json.Valid(got))
+	})
+
+	t.Run("indented", func(t *testing.T) {
+		t.Parallel()
+		small, err := structpb.NewStruct(map[string]any{"a": []any{1, map[string]any{"b": nil}}, "c": map[string]any{}})
+		require.NoError(t, err)
+		got, err := StructToJSON(small, JSONIndent("  "))
+		require.NoError(t, err)
+		assert.Equal(t, `{
+  "a": [
+    1,
+    {
+      "b": null
+    }
+  ],
+  "c": {}
+}`, string(got))
+	})
+
+	t.Run("round trips", func(t *testing.T) {
+		t.Parallel()
+		data, err := StructToJSON(s, JSONIndent("\t"))
+		require.NoError(t, err)
+		back, err := JSONToStruct(data)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(s, back))
+	})
+
+	t.Run("rejects non-finite numbers", func(t *testing.T) {
+		t.Parallel()
+		bad := &structpb.Struct{Fields: map[string]*structpb.Value{
+			"a": structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewNumberValue(math.Inf(1))}}),
+		}}
+		_, err := StructToJSON(bad)
+		require.ErrorIs(t, err, ErrNotCoercible)
+		assert.Contains(t, err.Error(), "a[0]")
+	})
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		got, err := StructToJSON(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "{}", string(got))
+	})
+}
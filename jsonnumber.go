@@ -0,0 +1,61 @@
+package protobaggins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// WithJSONNumbers decodes numbers as json.Number instead of float64, formatted like
+// encoding/json formats floats so whole numbers have no fraction, e.g. "42". NaN and
+// infinities, which JSON cannot hold, are decoded as usual.
+// json.Number values are always accepted when encoding, see NewValue
+func WithJSONNumbers() Option {
+	return func(o *options) {
+		o.jsonNumbers = true
+	}
+}
+
+// encodeJSONNumber converts a json.Number, as produced by a json.Decoder with
+// UseNumber, to a number. Integers that a float64 cannot hold exactly become decimal
+// strings under WithLargeIntegerStrings, like other integers
+func (e *encoder) encodeJSONNumber(n json.Number) (*structpb.Value, error) {
+	s := string(n)
+	if !isJSONNumber(s) {
+		return nil, fmt.Errorf("%w: %q is not a JSON number", ErrNotCoercible, s)
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if e.opts.largeIntegerStrings {
+			return Int64ToValue(i), nil
+		}
+		return structpb.NewNumberValue(float64(i)), nil
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil && e.opts.largeIntegerStrings {
+		return Uint64ToValue(u), nil
+	}
+	// numbers beyond the float64 range parse as infinities with a range error, which
+	// the non-finite policy then applies to
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return nil, fmt.Errorf("%w: %q is not a JSON number", ErrNotCoercible, s)
+	}
+	return e.encodeFloat(f)
+}
+
+// isJSONNumber reports whether s is in the JSON number syntax, which is stricter than
+// strconv's, e.g. it rejects "+1", ".5" and "0x10"
+func isJSONNumber(s string) bool {
+	return s != "" && (s[0] == '-' || s[0] >= '0' && s[0] <= '9') && json.Valid([]byte(s))
+}
+
+// decodeJSONNumber formats f as a json.Number
+func decodeJSONNumber(f float64) (json.Number, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", false
+	}
+	return json.Number(appendJSONNumber(nil, f)), true
+}
@@ -0,0 +1,71 @@
+package protobaggins
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestJSONNumbers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("encode", func(t *testing.T) {
+		t.Parallel()
+		dec := json.NewDecoder(strings.NewReader(`{"int": 42, "float": 1.5, "exp": -2e3, "big": 9007199254740993, "list": [7]}`))
+		dec.UseNumber()
+		var m map[string]any
+		require.NoError(t, dec.Decode(&m))
+
+		s, err := NewConverter().NewStruct(m)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"int": 42.0, "float": 1.5, "exp": -2000.0, "big": 9007199254740992.0, "list": []any{7.0},
+		}, s.AsMap())
+
+		v, err := NewValue(m["big"], WithLargeIntegerStrings())
+		require.NoError(t, err)
+		assert.Equal(t, "9007199254740993", v.GetStringValue())
+		v, err = NewValue(json.Number("18446744073709551615"), WithLargeIntegerStrings())
+		require.NoError(t, err)
+		assert.Equal(t, "18446744073709551615", v.GetStringValue())
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(json.Number("1e400"))
+		require.NoError(t, err)
+		assert.True(t, math.IsInf(v.GetNumberValue(), 1))
+		_, err = NewValue(json.Number("-1e400"), WithNonFinite(NonFiniteError))
+		require.ErrorIs(t, err, ErrNonFinite)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		for _, n := range []string{"", "abc", "+1", ".5", "0x10", "1_000", "NaN", `"1"`, "1 "} {
+			_, err := NewValue(json.Number(n))
+			require.ErrorIs(t, err, ErrNotCoercible, n)
+		}
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"int": 42, "float": 1.5, "tiny": 1e-7, "nested": []any{map[string]any{"n": -3}}, "text": "42",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"int":    json.Number("42"),
+			"float":  json.Number("1.5"),
+			"tiny":   json.Number("1e-7"),
+			"nested": []any{map[string]any{"n": json.Number("-3")}},
+			"text":   "42",
+		}, NewConverter(WithJSONNumbers()).StructToMap(s))
+
+		assert.Equal(t, "NaN", ValueToInterface(structpb.NewNumberValue(math.NaN()), WithJSONNumbers()))
+	})
+}
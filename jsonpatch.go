@@ -0,0 +1,519 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrInvalidPatch is returned for malformed JSON Patch documents and operations
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// ErrTestFailed is returned when a JSON Patch test operation does not match
+var ErrTestFailed = errors.New("test operation failed")
+
+// JSON Patch operation names
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// JSONPatchOp is a single JSON Patch (RFC 6902) operation. Path and From are JSON
+// Pointers (RFC 6901), From is only used by move and copy and Value only by add,
+// replace and test
+type JSONPatchOp struct {
+	Op    string
+	Path  string
+	From  string
+	Value *structpb.Value
+}
+
+// JSONPatch is a sequence of operations applied in order, see ApplyJSONPatch
+type JSONPatch []JSONPatchOp
+
+// ParseJSONPatch parses a JSON Patch document, a JSON array of operation objects
+func ParseJSONPatch(data []byte) (JSONPatch, error) {
+	v := &structpb.Value{}
+	if err := protojson.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	return JSONPatchFromValue(v)
+}
+
+// JSONPatchFromValue reads a JSON Patch held in a list value, as stored in a Struct.
+// Members other than op, path, from and value are ignored
+func JSONPatchFromValue(v *structpb.Value) (JSONPatch, error) {
+	list, ok := v.GetKind().(*structpb.Value_ListValue)
+	if !ok {
+		return nil, fmt.Errorf("%w: patch must be a list, got %s", ErrInvalidPatch, KindOf(v))
+	}
+
+	patch := make(JSONPatch, 0, len(list.ListValue.GetValues()))
+	for i, item := range list.ListValue.GetValues() {
+		fields := item.GetStructValue().GetFields()
+		if fields == nil && KindOf(item) != KindStruct {
+			return nil, fmt.Errorf("operation %d: %w: expected a struct, got %s", i, ErrInvalidPatch, KindOf(item))
+		}
+
+		var op JSONPatchOp
+		for _, member := range []struct {
+			name string
+			dst  *string
+		}{{"op", &op.Op}, {"path", &op.Path}, {"from", &op.From}} {
+			field, ok := fields[member.name]
+			if !ok {
+				continue
+			}
+			s, ok := field.GetKind().(*structpb.Value_StringValue)
+			if !ok {
+				return nil, fmt.Errorf("operation %d: %w: %s must be a string, got %s",
+					i, ErrInvalidPatch, member.name, KindOf(field))
+			}
+			*member.dst = s.StringValue
+		}
+		if value, ok := fields["value"]; ok {
+			op.Value = value
+		}
+		if _, ok := fields["path"]; !ok {
+			return nil, fmt.Errorf("operation %d: %w: missing path", i, ErrInvalidPatch)
+		}
+		if _, ok := fields["from"]; !ok && (op.Op == PatchMove || op.Op == PatchCopy) {
+			return nil, fmt.Errorf("operation %d: %w: missing from", i, ErrInvalidPatch)
+		}
+		if err := op.validate(); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		patch = append(patch, op)
+	}
+	return patch, nil
+}
+
+func (op JSONPatchOp) validate() error {
+	switch op.Op {
+	case PatchAdd, PatchReplace, PatchTest:
+		if op.Value == nil {
+			return fmt.Errorf("%w: %s requires a value", ErrInvalidPatch, op.Op)
+		}
+	case PatchMove, PatchCopy:
+		if _, err := parsePointer(op.From); err != nil {
+			return err
+		}
+	case PatchRemove:
+	case "":
+		return fmt.Errorf("%w: missing op", ErrInvalidPatch)
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+	_, err := parsePointer(op.Path)
+	return err
+}
+
+// ToValue returns the patch as a list value in the JSON Patch document layout
+func (p JSONPatch) ToValue() *structpb.Value {
+	values := make([]*structpb.Value, len(p))
+	for i, op := range p {
+		fields := map[string]*structpb.Value{
+			"op":   structpb.NewStringValue(op.Op),
+			"path": structpb.NewStringValue(op.Path),
+		}
+		if op.Op == PatchMove || op.Op == PatchCopy {
+			fields["from"] = structpb.NewStringValue(op.From)
+		}
+		if op.Value != nil {
+			fields["value"] = op.Value
+		}
+		values[i] = structpb.NewStructValue(&structpb.Struct{Fields: fields})
+	}
+	return structpb.NewListValue(&structpb.ListValue{Values: values})
+}
+
+// MarshalJSON encodes the patch as a JSON Patch document with sorted member names
+func (p JSONPatch) MarshalJSON() ([]byte, error) {
+	w := jsonWriter{opts: newJSONOptions(nil)}
+	if err := w.writeValue("", p.ToValue()); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// UnmarshalJSON parses a JSON Patch document, see ParseJSONPatch
+func (p *JSONPatch) UnmarshalJSON(data []byte) error {
+	patch, err := ParseJSONPatch(data)
+	if err != nil {
+		return err
+	}
+	*p = patch
+	return nil
+}
+
+// ApplyJSONPatch applies patch to a copy of target and returns it, leaving target
+// unchanged. The patch is applied atomically: if any operation fails, the error names
+// it and no result is returned. A nil target is treated as empty. Patches that replace
+// the whole document with something other than a Struct fail with ErrUnexpectedKind
+func ApplyJSONPatch(target *structpb.Struct, patch JSONPatch) (*structpb.Struct, error) {
+	if target == nil {
+		target = &structpb.Struct{}
+	}
+	v, err := ApplyJSONPatchValue(structpb.NewStructValue(target), patch)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.GetKind().(*structpb.Value_StructValue)
+	if !ok {
+		return nil, fmt.Errorf("%w: patched document is a %s, not a struct", ErrUnexpectedKind, KindOf(v))
+	}
+	return s.StructValue, nil
+}
+
+// ApplyJSONPatchValue is ApplyJSONPatch for any value. A nil value is treated as null
+func ApplyJSONPatchValue(v *structpb.Value, patch JSONPatch) (*structpb.Value, error) {
+	doc := structpb.NewNullValue()
+	if v != nil {
+		doc = proto.Clone(v).(*structpb.Value)
+	}
+	p := patcher{root: doc}
+	for i, op := range patch {
+		if err := p.apply(op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return p.root, nil
+}
+
+// patcher applies operations to a document it owns, mutating it in place
+type patcher struct {
+	root *structpb.Value
+}
+
+func (p *patcher) apply(op JSONPatchOp) error {
+	if err := op.validate(); err != nil {
+		return err
+	}
+	path, _ := parsePointer(op.Path)
+	from, _ := parsePointer(op.From)
+
+	switch op.Op {
+	case PatchAdd:
+		return p.add(path, proto.Clone(op.Value).(*structpb.Value))
+	case PatchRemove:
+		_, err := p.remove(path)
+		return err
+	case PatchReplace:
+		return p.replace(path, proto.Clone(op.Value).(*structpb.Value))
+	case PatchMove:
+		if len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+			return fmt.Errorf("%w: cannot move %s into its own child", ErrInvalidPatch, op.From)
+		}
+		v, err := p.remove(from)
+		if err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+		return p.add(path, v)
+	case PatchCopy:
+		v, err := p.get(from)
+		if err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+		return p.add(path, proto.Clone(v).(*structpb.Value))
+	default:
+		v, err := p.get(path)
+		if err != nil {
+			return err
+		}
+		if !proto.Equal(v, op.Value) {
+			return fmt.Errorf("%w: found %s", ErrTestFailed, formatValue(v))
+		}
+		return nil
+	}
+}
+
+// get resolves a parsed pointer
+func (p *patcher) get(tokens []string) (*structpb.Value, error) {
+	v := p.root
+	for _, tok := range tokens {
+		switch kind := v.GetKind().(type) {
+		case *structpb.Value_StructValue:
+			child, ok := kind.StructValue.GetFields()[tok]
+			if !ok {
+				return nil, fmt.Errorf("%w: no key %q", ErrPathNotFound, tok)
+			}
+			v = child
+		case *structpb.Value_ListValue:
+			i, err := listIndex(tok, len(kind.ListValue.GetValues()), false)
+			if err != nil {
+				return nil, err
+			}
+			v = kind.ListValue.Values[i]
+		default:
+			return nil, fmt.Errorf("%w: cannot index a %s with %q", ErrPathNotFound, KindOf(v), tok)
+		}
+	}
+	return v, nil
+}
+
+func (p *patcher) add(tokens []string, v *structpb.Value) error {
+	if len(tokens) == 0 {
+		p.root = v
+		return nil
+	}
+	parent, err := p.get(tokens[:len(tokens)-1])
+	if err != nil {
+		return err
+	}
+	tok := tokens[len(tokens)-1]
+
+	switch kind := parent.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		if kind.StructValue.Fields == nil {
+			kind.StructValue.Fields = make(map[string]*structpb.Value)
+		}
+		kind.StructValue.Fields[tok] = v
+	case *structpb.Value_ListValue:
+		i, err := listIndex(tok, len(kind.ListValue.GetValues()), true)
+		if err != nil {
+			return err
+		}
+		kind.ListValue.Values = slices.Insert(kind.ListValue.Values, i, v)
+	default:
+		return fmt.Errorf("%w: cannot add %q to a %s", ErrPathNotFound, tok, KindOf(parent))
+	}
+	return nil
+}
+
+// remove deletes the value at a parsed pointer and returns it
+func (p *patcher) remove(tokens []string) (*structpb.Value, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	v, err := p.get(tokens)
+	if err != nil {
+		return nil, err
+	}
+	parent, _ := p.get(tokens[:len(tokens)-1])
+	tok := tokens[len(tokens)-1]
+
+	switch kind := parent.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		delete(kind.StructValue.Fields, tok)
+	case *structpb.Value_ListValue:
+		i, _ := listIndex(tok, len(kind.ListValue.Values), false)
+		kind.ListValue.Values = slices.Delete(kind.ListValue.Values, i, i+1)
+	}
+	return v, nil
+}
+
+func (p *patcher) replace(tokens []string, v *structpb.Value) error {
+	if _, err := p.get(tokens); err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		p.root = v
+		return nil
+	}
+	parent, _ := p.get(tokens[:len(tokens)-1])
+	tok := tokens[len(tokens)-1]
+
+	switch kind := parent.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		kind.StructValue.Fields[tok] = v
+	case *structpb.Value_ListValue:
+		i, _ := listIndex(tok, len(kind.ListValue.Values), false)
+		kind.ListValue.Values[i] = v
+	}
+	return nil
+}
+
+// listIndex parses a pointer token as an index into a list of n items. The index n,
+// written as itself or as "-", is only allowed when adding
+func listIndex(tok string, n int, adding bool) (int, error) {
+	if tok == "-" {
+		if adding {
+			return n, nil
+		}
+		return 0, fmt.Errorf("%w: index - refers past the end of the list", ErrPathNotFound)
+	}
+	if tok == "" || (tok[0] == '0' && len(tok) > 1) || strings.TrimLeft(tok, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q is not a list index", ErrInvalidPatch, tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i > n || (i == n && !adding) {
+		return 0, fmt.Errorf("%w: index %s out of range for a list of %d items", ErrPathNotFound, tok, n)
+	}
+	return i, nil
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens
+func parsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, tok := range tokens {
+		for j := 0; j < len(tok); j++ {
+			if tok[j] != '~' {
+				continue
+			}
+			if j+1 == len(tok) || (tok[j+1] != '0' && tok[j+1] != '1') {
+				return nil, fmt.Errorf("%w: pointer %q has an invalid ~ escape", ErrInvalidPatch, ptr)
+			}
+			j++
+		}
+		tokens[i] = pointerUnescaper.Replace(tok)
+	}
+	return tokens, nil
+}
+
+// appendPointer adds an escaped reference token to a JSON Pointer
+func appendPointer(ptr, tok string) string {
+	return ptr + "/" + pointerEscaper.Replace(tok)
+}
+
+// CreateJSONPatch returns a patch that turns a into b. Structs are compared key by key
+// in sorted order and lists index by index, with items added or removed at the end;
+// values of different kinds are replaced as a whole
+func CreateJSONPatch(a, b *structpb.Value) JSONPatch {
+	patch := JSONPatch{}
+	patch.diff("", a, b)
+	return patch
+}
+
+// CreateJSONPatchStructs is CreateJSONPatch for two Structs
+func CreateJSONPatchStructs(a, b *structpb.Struct) JSONPatch {
+	return CreateJSONPatch(structpb.NewStructValue(a), structpb.NewStructValue(b))
+}
+
+func (p *JSONPatch) diff(ptr string, a, b *structpb.Value) {
+	switch aKind := a.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		if bKind, ok := b.GetKind().(*structpb.Value_StructValue); ok {
+			p.diffStruct(ptr, aKind.StructValue, bKind.StructValue)
+			return
+		}
+	case *structpb.Value_ListValue:
+		if bKind, ok := b.GetKind().(*structpb.Value_ListValue); ok {
+			p.diffList(ptr, aKind.ListValue.GetValues(), bKind.ListValue.GetValues())
+			return
+		}
+	}
+	if !proto.Equal(a, b) {
+		*p = append(*p, JSONPatchOp{Op: PatchReplace, Path: ptr, Value: cloneOrNull(b)})
+	}
+}
+
+func (p *JSONPatch) diffStruct(ptr string, a, b *structpb.Struct) {
+	for _, key := range sortedKeys(a) {
+		if _, ok := b.GetFields()[key]; !ok {
+			*p = append(*p, JSONPatchOp{Op: PatchRemove, Path: appendPointer(ptr, key)})
+		}
+	}
+	for _, key := range sortedKeys(b) {
+		aValue, ok := a.GetFields()[key]
+		if !ok {
+			*p = append(*p, JSONPatchOp{Op: PatchAdd, Path: appendPointer(ptr, key), Value: cloneOrNull(b.GetFields()[key])})
+			continue
+		}
+		p.diff(appendPointer(ptr, key), aValue, b.GetFields()[key])
+	}
+}
+
+func (p *JSONPatch) diffList(ptr string, a, b []*structpb.Value) {
+	for i := range min(len(a), len(b)) {
+		p.diff(appendPointer(ptr, strconv.Itoa(i)), a[i], b[i])
+	}
+	for i := len(a); i < len(b); i++ {
+		*p = append(*p, JSONPatchOp{Op: PatchAdd, Path: appendPointer(ptr, strconv.Itoa(i)), Value: cloneOrNull(b[i])})
+	}
+	// remove from the end so earlier indexes stay valid
+	for i := len(a) - 1; i >= len(b); i-- {
+		*p = append(*p, JSONPatchOp{Op: PatchRemove, Path: appendPointer(ptr, strconv.Itoa(i))})
+	}
+}
+
+func cloneOrNull(v *structpb.Value) *structpb.Value {
+	if v == nil {
+		return structpb.NewNullValue()
+	}
+	return proto.Clone(v).(*structpb.Value)
+}
+
+// ChangesToJSONPatch renders changes, as returned by Diff, as a JSON Patch that
+// applies them. Consecutive removals of items from the same list are emitted from the
+// highest index down so that earlier indexes stay valid. Fails if a path is malformed
+func ChangesToJSONPatch(changes []Change) (JSONPatch, error) {
+	patch := make(JSONPatch, 0, len(changes))
+	for i := 0; i < len(changes); i++ {
+		run := i + 1
+		if parent, ok := listItemParent(changes[i]); ok {
+			for run < len(changes) {
+				next, ok := listItemParent(changes[run])
+				if !ok || next != parent {
+					break
+				}
+				run++
+			}
+		}
+		for j := run - 1; j >= i; j-- {
+			op, err := changeToPatchOp(changes[j])
+			if err != nil {
+				return nil, err
+			}
+			patch = append(patch, op)
+		}
+		i = run - 1
+	}
+	return patch, nil
+}
+
+// listItemParent returns the path of the list that a removal of a list item removes from
+func listItemParent(c Change) (string, bool) {
+	if c.Type != ChangeRemoved || !strings.HasSuffix(c.Path, "]") {
+		return "", false
+	}
+	segments, err := parsePath(c.Path)
+	if err != nil || len(segments) == 0 || segments[len(segments)-1].index < 0 {
+		return "", false
+	}
+	return c.Path[:strings.LastIndexByte(c.Path, '[')], true
+}
+
+func changeToPatchOp(c Change) (JSONPatchOp, error) {
+	segments, err := parsePath(c.Path)
+	if err != nil {
+		return JSONPatchOp{}, err
+	}
+	ptr := ""
+	for _, seg := range segments {
+		if seg.index >= 0 {
+			ptr = appendPointer(ptr, strconv.Itoa(seg.index))
+		} else {
+			ptr = appendPointer(ptr, seg.key)
+		}
+	}
+
+	switch {
+	case c.Type == ChangeAdded:
+		return JSONPatchOp{Op: PatchAdd, Path: ptr, Value: cloneOrNull(c.New)}, nil
+	case c.Type == ChangeRemoved && ptr != "":
+		return JSONPatchOp{Op: PatchRemove, Path: ptr}, nil
+	default:
+		// the whole document cannot be removed, so it is replaced by null instead
+		return JSONPatchOp{Op: PatchReplace, Path: ptr, Value: cloneOrNull(c.New)}, nil
+	}
+}
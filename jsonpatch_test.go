@@ -0,0 +1,237 @@
+package protobaggins
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	t.Parallel()
+
+	parseValue := func(t *testing.T, data string) *structpb.Value {
+		t.Helper()
+		v := &structpb.Value{}
+		require.NoError(t, protojson.Unmarshal([]byte(data), v))
+		return v
+	}
+
+	// the examples from RFC 6902 appendix A
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add a member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add a list item", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"remove a member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove a list item", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move a member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move a list item", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`},
+		{"test", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{"add a nested member", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			`{"foo":"bar","child":{"grandchild":{}}}`},
+		{"ignore unknown members", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"foo":"bar","baz":"qux"}`},
+		{"escaped pointers", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"copy","from":"/~1","path":"/a~0b"}]`,
+			`{"/":9,"~1":10,"a~b":9}`},
+		{"append a list item", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			`{"foo":["bar",["abc","def"]]}`},
+		{"replace the document", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":{"baz":1}}]`, `{"baz":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			patch, err := ParseJSONPatch([]byte(tt.patch))
+			require.NoError(t, err)
+			doc := parseValue(t, tt.doc)
+			got, err := ApplyJSONPatch(doc.GetStructValue(), patch)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(parseValue(t, tt.want).GetStructValue(), got), "got %v", got)
+			assert.True(t, proto.Equal(parseValue(t, tt.doc), doc), "target must not change")
+		})
+	}
+
+	errorTests := []struct {
+		name, doc, patch string
+		err              error
+	}{
+		{"missing target", `{"baz":"qux"}`, `[{"op":"remove","path":"/foo"}]`, ErrPathNotFound},
+		{"missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ErrPathNotFound},
+		{"index out of range", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":1}]`, ErrPathNotFound},
+		{"leading zero", `{"foo":["bar"]}`, `[{"op":"replace","path":"/foo/00","value":1}]`, ErrInvalidPatch},
+		{"failed test", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ErrTestFailed},
+		{"move into a child", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, ErrInvalidPatch},
+		{"remove the document", `{}`, `[{"op":"remove","path":""}]`, ErrInvalidPatch},
+		{"not a struct", `{}`, `[{"op":"replace","path":"","value":[1]}]`, ErrUnexpectedKind},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			patch, err := ParseJSONPatch([]byte(tt.patch))
+			require.NoError(t, err)
+			_, err = ApplyJSONPatch(parseValue(t, tt.doc).GetStructValue(), patch)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	t.Run("atomic", func(t *testing.T) {
+		t.Parallel()
+		doc := parseValue(t, `{"a":1}`)
+		patch := JSONPatch{
+			{Op: PatchAdd, Path: "/b", Value: structpb.NewNumberValue(2)},
+			{Op: PatchRemove, Path: "/c"},
+		}
+		_, err := ApplyJSONPatch(doc.GetStructValue(), patch)
+		require.EqualError(t, err, `operation 1 (remove /c): path not found: no key "c"`)
+		assert.True(t, proto.Equal(parseValue(t, `{"a":1}`), doc))
+	})
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+		patch := JSONPatch{{Op: PatchAdd, Path: "/0", Value: structpb.NewStringValue("x")}}
+		got, err := ApplyJSONPatchValue(parseValue(t, `["y"]`), patch)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(parseValue(t, `["x","y"]`), got))
+	})
+}
+
+func TestParseJSONPatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rejects malformed patches", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{
+			`{"op":"add"}`,
+			`[1]`,
+			`[{"path":"/a"}]`,
+			`[{"op":"jump","path":"/a"}]`,
+			`[{"op":"add","path":"/a"}]`,
+			`[{"op":"remove"}]`,
+			`[{"op":"remove","path":"a"}]`,
+			`[{"op":"remove","path":"/a~2"}]`,
+			`[{"op":"move","path":"/a"}]`,
+			`[{"op":1,"path":"/a"}]`,
+			`[`,
+		} {
+			_, err := ParseJSONPatch([]byte(input))
+			require.ErrorIs(t, err, ErrInvalidPatch, input)
+		}
+	})
+
+	t.Run("round trips through JSON", func(t *testing.T) {
+		t.Parallel()
+		patch := JSONPatch{
+			{Op: PatchTest, Path: "/a", Value: structpb.NewNullValue()},
+			{Op: PatchMove, From: "/b", Path: "/c"},
+			{Op: PatchRemove, Path: "/d~1e"},
+		}
+		data, err := json.Marshal(patch)
+		require.NoError(t, err)
+		assert.Equal(t, `[{"op":"test","path":"/a","value":null},{"from":"/b","op":"move","path":"/c"},`+
+			`{"op":"remove","path":"/d~1e"}]`, string(data))
+
+		var back JSONPatch
+		require.NoError(t, json.Unmarshal(data, &back))
+		assert.Equal(t, patch.ToValue().AsInterface(), back.ToValue().AsInterface())
+	})
+}
+
+func TestCreateJSONPatch(t *testing.T) {
+	t.Parallel()
+
+	a, err := structpb.NewStruct(map[string]any{
+		"name":  "frodo",
+		"items": []any{"ring", "sting", "mithril", "bread"},
+		"home":  map[string]any{"street": "bagshot row", "no": 3},
+		"a/b":   1,
+		"kind":  []any{1},
+	})
+	require.NoError(t, err)
+	b, err := structpb.NewStruct(map[string]any{
+		"name":  "frodo",
+		"items": []any{"ring", "phial"},
+		"home":  map[string]any{"street": "bagshot row", "town": "hobbiton"},
+		"age":   50,
+		"kind":  map[string]any{"x": 1},
+	})
+	require.NoError(t, err)
+
+	patch := CreateJSONPatchStructs(a, b)
+	data, err := json.Marshal(patch)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"op":"remove","path":"/a~1b"},{"op":"add","path":"/age","value":50},`+
+		`{"op":"remove","path":"/home/no"},{"op":"add","path":"/home/town","value":"hobbiton"},`+
+		`{"op":"replace","path":"/items/1","value":"phial"},{"op":"remove","path":"/items/3"},`+
+		`{"op":"remove","path":"/items/2"},{"op":"replace","path":"/kind","value":{"x":1}}]`, string(data))
+
+	got, err := ApplyJSONPatch(a, patch)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(b, got), "got %v", got)
+
+	assert.Empty(t, CreateJSONPatchStructs(a, a))
+}
+
+func TestChangesToJSONPatch(t *testing.T) {
+	t.Parallel()
+
+	mustValue := func(t *testing.T, v any) *structpb.Value {
+		t.Helper()
+		value, err := structpb.NewValue(v)
+		require.NoError(t, err)
+		return value
+	}
+
+	tests := []struct {
+		name string
+		a, b any
+	}{
+		{"structs", map[string]any{
+			"items": []any{"ring", "sting", "mithril", "bread", map[string]any{"x": 1}},
+			"a/b":   1,
+			"nest":  map[string]any{"l": []any{1, 2, 3}, "k.v": []any{1, 2}},
+		}, map[string]any{
+			"items": []any{"ring", "phial"},
+			"age":   50,
+			"nest":  map[string]any{"l": []any{1}, "k.v": []any{}, "new": true},
+		}},
+		{"lists", []any{1, []any{1, 2}, 3}, []any{1, []any{2}, 3, 4}},
+		{"kind change", map[string]any{"a": 1}, []any{1}},
+		{"nested list removals", []any{[]any{1, 2}, []any{3, 4}}, []any{[]any{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a, b := mustValue(t, tt.a), mustValue(t, tt.b)
+			patch, err := ChangesToJSONPatch(Diff(a, b))
+			require.NoError(t, err)
+			got, err := ApplyJSONPatchValue(a, patch)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(b, got), "got %v", got)
+		})
+	}
+
+	t.Run("root", func(t *testing.T) {
+		t.Parallel()
+		patch, err := ChangesToJSONPatch(Diff(structpb.NewStringValue("x"), nil))
+		require.NoError(t, err)
+		require.Len(t, patch, 1)
+		assert.Equal(t, PatchReplace, patch[0].Op)
+		assert.Empty(t, patch[0].Path)
+		assert.True(t, proto.Equal(structpb.NewNullValue(), patch[0].Value))
+	})
+
+	t.Run("malformed path", func(t *testing.T) {
+		t.Parallel()
+		_, err := ChangesToJSONPatch([]Change{{Type: ChangeAdded, Path: "a..b", New: structpb.NewBoolValue(true)}})
+		require.Error(t, err)
+	})
+}
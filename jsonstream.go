@@ -0,0 +1,88 @@
+package protobaggins
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DecodeJSONStream parses a JSON object from r like JSONToStruct, reading it as it
+// goes instead of loading the whole document first, so only the resulting Struct is
+// held in memory. JSONMaxSize limits the number of bytes read
+func DecodeJSONStream(r io.Reader, opts ...JSONOption) (*structpb.Struct, error) {
+	o := newJSONOptions(opts)
+	return readJSONObject(limitJSONReader(r, o), o, nil)
+}
+
+// DecodeJSONStreamElements parses a top-level JSON array from r, yielding each element
+// as soon as it has been read, so that arrays much larger than memory can be processed
+// one element at a time. Each element is subject to the JSONMaxDepth limit, the array
+// itself being level 1, and JSONMaxSize limits the number of bytes read. Iteration
+// stops after the first error, which is yielded with a nil value
+func DecodeJSONStreamElements(r io.Reader, opts ...JSONOption) iter.Seq2[*structpb.Value, error] {
+	return func(yield func(*structpb.Value, error) bool) {
+		o := newJSONOptions(opts)
+		p := jsonParser{dec: json.NewDecoder(limitJSONReader(r, o)), opts: o}
+		tok, err := p.dec.Token()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if tok != json.Delim('[') {
+			yield(nil, fmt.Errorf("%w: top level must be an array, got %s", ErrUnexpectedKind, describeToken(tok)))
+			return
+		}
+		p.depth++
+		for i := 0; p.dec.More(); i++ {
+			v, err := p.parseValue(joinIndex("", i))
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if _, err := p.dec.Token(); err != nil {
+			yield(nil, err)
+			return
+		}
+		if err := p.end("array"); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// limitJSONReader returns r, failing with ErrTooLarge after JSONMaxSize bytes
+func limitJSONReader(r io.Reader, o jsonOptions) io.Reader {
+	if o.maxSize == 0 {
+		return r
+	}
+	return &sizeLimitedReader{r: r, remaining: o.maxSize, limit: o.maxSize}
+}
+
+type sizeLimitedReader struct {
+	r         io.Reader
+	remaining int
+	limit     int
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// a byte past the limit tells a longer input apart from one of exactly the limit
+		var probe [1]byte
+		if n, err := l.r.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: input exceeds the limit of %d bytes", ErrTooLarge, l.limit)
+	}
+	if len(p) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= n
+	return n, err
+}
@@ -0,0 +1,147 @@
+package protobaggins
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDecodeJSONStream(t *testing.T) {
+	t.Parallel()
+
+	t.Run("object", func(t *testing.T) {
+		t.Parallel()
+		s, err := DecodeJSONStream(strings.NewReader(`{"a": [1, {"b": null}], "c": "d"}`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": []any{1.0, map[string]any{"b": nil}}, "c": "d"}, s.AsMap())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := DecodeJSONStream(strings.NewReader(`[1]`))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		_, err = DecodeJSONStream(strings.NewReader(`{"a": 1, "a": 2}`))
+		require.ErrorContains(t, err, "a: duplicate key")
+		_, err = DecodeJSONStream(strings.NewReader(`{} {}`))
+		require.ErrorContains(t, err, "unexpected data after the top-level object")
+		_, err = DecodeJSONStream(strings.NewReader(`{"a": [[1]]}`), JSONMaxDepth(2))
+		require.ErrorIs(t, err, ErrMaxDepth)
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		t.Parallel()
+		doc := `{"a": "bcdef"}`
+		_, err := DecodeJSONStream(strings.NewReader(doc), JSONMaxSize(len(doc)))
+		require.NoError(t, err)
+		_, err = DecodeJSONStream(strings.NewReader(doc+" "), JSONMaxSize(len(doc)))
+		require.ErrorIs(t, err, ErrTooLarge)
+		_, err = DecodeJSONStream(strings.NewReader(doc), JSONMaxSize(5))
+		require.ErrorIs(t, err, ErrTooLarge)
+	})
+}
+
+func TestDecodeJSONStreamElements(t *testing.T) {
+	t.Parallel()
+
+	collect := func(r io.Reader, opts ...JSONOption) ([]any, error) {
+		var items []any
+		for v, err := range DecodeJSONStreamElements(r, opts...) {
+			if err != nil {
+				return items, err
+			}
+			items = append(items, v.AsInterface())
+		}
+		return items, nil
+	}
+
+	t.Run("elements", func(t *testing.T) {
+		t.Parallel()
+		items, err := collect(strings.NewReader(`[{"id": 1}, "x", [true], null]`))
+		require.NoError(t, err)
+		assert.Equal(t, []any{map[string]any{"id": 1.0}, "x", []any{true}, nil}, items)
+
+		items, err = collect(strings.NewReader(` [ ] `))
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("reads incrementally", func(t *testing.T) {
+		t.Parallel()
+		pr, pw := io.Pipe()
+		first := make(chan struct{})
+		go func() {
+			_, _ = io.WriteString(pw, `[{"id": 0}, `)
+			// the rest is only written once the first element has been yielded
+			<-first
+			_, _ = io.WriteString(pw, `{"id": 1}]`)
+			_ = pw.Close()
+		}()
+		var ids []float64
+		for v, err := range DecodeJSONStreamElements(pr) {
+			require.NoError(t, err)
+			ids = append(ids, v.GetStructValue().GetFields()["id"].GetNumberValue())
+			if len(ids) == 1 {
+				close(first)
+			}
+		}
+		assert.Equal(t, []float64{0, 1}, ids)
+	})
+
+	t.Run("large arrays", func(t *testing.T) {
+		t.Parallel()
+		var b strings.Builder
+		b.WriteString("[")
+		for i := range 10000 {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, `{"i": %d}`, i)
+		}
+		b.WriteString("]")
+		n := 0
+		for v, err := range DecodeJSONStreamElements(strings.NewReader(b.String())) {
+			require.NoError(t, err)
+			require.InDelta(t, float64(n), v.GetStructValue().GetFields()["i"].GetNumberValue(), 0)
+			n++
+		}
+		assert.Equal(t, 10000, n)
+	})
+
+	t.Run("stops early", func(t *testing.T) {
+		t.Parallel()
+		var seen []*structpb.Value
+		for v, err := range DecodeJSONStreamElements(strings.NewReader(`[1, 2, 3`)) {
+			require.NoError(t, err)
+			seen = append(seen, v)
+			if len(seen) == 2 {
+				break
+			}
+		}
+		assert.Len(t, seen, 2)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		items, err := collect(strings.NewReader(`[1, {"a": 1, "a": 2}, 3]`))
+		require.ErrorContains(t, err, `[1].a: duplicate key`)
+		assert.Equal(t, []any{1.0}, items)
+
+		_, err = collect(strings.NewReader(`{"a": 1}`))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		require.ErrorContains(t, err, "got object")
+
+		_, err = collect(strings.NewReader(`[1] 2`))
+		require.ErrorContains(t, err, "unexpected data after the top-level array")
+
+		_, err = collect(strings.NewReader(`[[1]]`), JSONMaxDepth(1))
+		require.ErrorIs(t, err, ErrMaxDepth)
+
+		_, err = collect(strings.NewReader(`[1, 2, 3]`), JSONMaxSize(4))
+		require.ErrorIs(t, err, ErrTooLarge)
+	})
+}
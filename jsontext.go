@@ -0,0 +1,186 @@
+//go:build goexperiment.jsonv2
+
+package protobaggins
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The functions in this file stream values through encoding/json/jsontext, which is
+// available from Go 1.25 under GOEXPERIMENT=jsonv2, and enabled by default from Go 1.27.
+// jsonEncoder and jsonDecoder are jsontext.Encoder and jsontext.Decoder, aliased per Go
+// version in jsontext_go125.go and jsontext_go127.go
+
+// Token kinds, as the characters jsontext.Kind documents, since the named constants
+// were only added after Go 1.25
+const (
+	jsonKindInvalid     jsonKind = 0
+	jsonKindNull        jsonKind = 'n'
+	jsonKindFalse       jsonKind = 'f'
+	jsonKindTrue        jsonKind = 't'
+	jsonKindString      jsonKind = '"'
+	jsonKindNumber      jsonKind = '0'
+	jsonKindBeginObject jsonKind = '{'
+	jsonKindEndObject   jsonKind = '}'
+	jsonKindBeginArray  jsonKind = '['
+	jsonKindEndArray    jsonKind = ']'
+)
+
+// EncodeValueTokens writes v to the *jsontext.Encoder enc token by token, without building an intermediate
+// tree or byte slice. Because enc tracks its position, v may be written anywhere a value
+// is expected in a larger stream, e.g. after an object name written by the caller.
+// Struct keys are written in sorted order. Non-finite numbers are written as the strings
+// "NaN", "Infinity" and "-Infinity", matching structpb's AsInterface
+func EncodeValueTokens(enc *jsonEncoder, v *structpb.Value) error {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NullValue:
+		return enc.WriteToken(jsonNull)
+	case *structpb.Value_BoolValue:
+		return enc.WriteToken(jsonBool(kind.BoolValue))
+	case *structpb.Value_NumberValue:
+		return enc.WriteToken(jsonFloat(kind.NumberValue))
+	case *structpb.Value_StringValue:
+		return enc.WriteToken(jsonString(kind.StringValue))
+	case *structpb.Value_ListValue:
+		return encodeListTokens(enc, kind.ListValue)
+	case *structpb.Value_StructValue:
+		return EncodeStructTokens(enc, kind.StructValue)
+	default:
+		return fmt.Errorf("%w: cannot encode %s value as JSON", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+// EncodeStructTokens writes s to enc as a JSON object, see EncodeValueTokens
+// A nil Struct is written as an empty object
+func EncodeStructTokens(enc *jsonEncoder, s *structpb.Struct) error {
+	if err := enc.WriteToken(jsonBeginObject); err != nil {
+		return err
+	}
+
+	fields := s.GetFields()
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if err := enc.WriteToken(jsonString(k)); err != nil {
+			return err
+		}
+		if err := EncodeValueTokens(enc, fields[k]); err != nil {
+			return err
+		}
+	}
+	return enc.WriteToken(jsonEndObject)
+}
+
+func encodeListTokens(enc *jsonEncoder, l *structpb.ListValue) error {
+	if err := enc.WriteToken(jsonBeginArray); err != nil {
+		return err
+	}
+	for _, item := range l.GetValues() {
+		if err := EncodeValueTokens(enc, item); err != nil {
+			return err
+		}
+	}
+	return enc.WriteToken(jsonEndArray)
+}
+
+// DecodeValueTokens reads the next JSON value from the *jsontext.Decoder dec. The decoder may be positioned
+// anywhere inside a larger stream, e.g. after the caller has read an object name, and is
+// left positioned after the value
+func DecodeValueTokens(dec *jsonDecoder) (*structpb.Value, error) {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok.Kind() {
+	case jsonKindNull:
+		return structpb.NewNullValue(), nil
+	case jsonKindFalse, jsonKindTrue:
+		return structpb.NewBoolValue(tok.Bool()), nil
+	case jsonKindNumber:
+		// String gives the number as written, Float differs in signature across versions
+		n, err := strconv.ParseFloat(tok.String(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("decoding number at %s: %w", dec.StackPointer(), err)
+		}
+		return structpb.NewNumberValue(n), nil
+	case jsonKindString:
+		return structpb.NewStringValue(tok.String()), nil
+	case jsonKindBeginArray:
+		l, err := decodeListTokens(dec)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewListValue(l), nil
+	case jsonKindBeginObject:
+		s, err := decodeObjectTokens(dec)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStructValue(s), nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON token %s at %s", tok.Kind(), dec.StackPointer())
+	}
+}
+
+// DecodeStructTokens reads the next JSON value from dec, which must be an object
+func DecodeStructTokens(dec *jsonDecoder) (*structpb.Struct, error) {
+	if kind := dec.PeekKind(); kind != jsonKindBeginObject {
+		if kind == jsonKindInvalid {
+			// surface the underlying syntax or I/O error
+			_, err := dec.ReadToken()
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: expected JSON object at %s, got %s", ErrUnexpectedKind, dec.StackPointer(), kind)
+	}
+	if _, err := dec.ReadToken(); err != nil {
+		return nil, err
+	}
+	return decodeObjectTokens(dec)
+}
+
+// decodeObjectTokens reads the members of an object whose opening brace was consumed
+func decodeObjectTokens(dec *jsonDecoder) (*structpb.Struct, error) {
+	fields := make(map[string]*structpb.Value)
+	for dec.PeekKind() != jsonKindEndObject {
+		tok, err := dec.ReadToken()
+		if err != nil {
+			return nil, err
+		}
+		// the token is invalidated by the next read, so copy the name out first
+		name := tok.String()
+		value, err := DecodeValueTokens(dec)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+	if _, err := dec.ReadToken(); err != nil {
+		return nil, err
+	}
+	return &structpb.Struct{Fields: fields}, nil
+}
+
+// decodeListTokens reads the elements of an array whose opening bracket was consumed
+func decodeListTokens(dec *jsonDecoder) (*structpb.ListValue, error) {
+	var values []*structpb.Value
+	for dec.PeekKind() != jsonKindEndArray {
+		value, err := DecodeValueTokens(dec)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if _, err := dec.ReadToken(); err != nil {
+		return nil, err
+	}
+	return &structpb.ListValue{Values: values}, nil
+}
@@ -0,0 +1,27 @@
+//go:build goexperiment.jsonv2 && !go1.27
+
+package protobaggins
+
+import "encoding/json/jsontext"
+
+// Before Go 1.27 encoding/json/jsontext is only available under GOEXPERIMENT=jsonv2.
+// jsontext.go reaches it through these names, declared alike for each Go version
+
+type (
+	jsonEncoder = jsontext.Encoder
+	jsonDecoder = jsontext.Decoder
+	jsonKind    = jsontext.Kind
+)
+
+var (
+	jsonNewEncoder  = jsontext.NewEncoder
+	jsonNewDecoder  = jsontext.NewDecoder
+	jsonNull        = jsontext.Null
+	jsonBool        = jsontext.Bool
+	jsonFloat       = jsontext.Float
+	jsonString      = jsontext.String
+	jsonBeginObject = jsontext.BeginObject
+	jsonEndObject   = jsontext.EndObject
+	jsonBeginArray  = jsontext.BeginArray
+	jsonEndArray    = jsontext.EndArray
+)
@@ -0,0 +1,27 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+package protobaggins
+
+import "encoding/json/jsontext"
+
+// Go 1.27 added encoding/json/jsontext to the API, so only files for Go 1.27 may use it.
+// jsontext.go reaches it through these names, declared alike for each Go version
+
+type (
+	jsonEncoder = jsontext.Encoder
+	jsonDecoder = jsontext.Decoder
+	jsonKind    = jsontext.Kind
+)
+
+var (
+	jsonNewEncoder  = jsontext.NewEncoder
+	jsonNewDecoder  = jsontext.NewDecoder
+	jsonNull        = jsontext.Null
+	jsonBool        = jsontext.Bool
+	jsonFloat       = jsontext.Float
+	jsonString      = jsontext.String
+	jsonBeginObject = jsontext.BeginObject
+	jsonEndObject   = jsontext.EndObject
+	jsonBeginArray  = jsontext.BeginArray
+	jsonEndArray    = jsontext.EndArray
+)
@@ -0,0 +1,116 @@
+//go:build goexperiment.jsonv2
+
+package protobaggins
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestEncodeValueTokens(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sorted keys", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"name":  "frodo",
+			"age":   50,
+			"items": []any{"ring", true, nil, map[string]any{"b": 1, "a": 2}},
+		})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, EncodeStructTokens(jsonNewEncoder(&buf), s))
+		assert.Equal(t, `{"age":50,"items":["ring",true,null,{"a":2,"b":1}],"name":"frodo"}`+"\n", buf.String())
+	})
+
+	t.Run("embedded in a larger stream", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		enc := jsonNewEncoder(&buf)
+		require.NoError(t, enc.WriteToken(jsonBeginObject))
+		require.NoError(t, enc.WriteToken(jsonString("payload")))
+		require.NoError(t, EncodeValueTokens(enc, structpb.NewNumberValue(1.5)))
+		require.NoError(t, enc.WriteToken(jsonString("nan")))
+		require.NoError(t, EncodeValueTokens(enc, structpb.NewNumberValue(math.NaN())))
+		require.NoError(t, enc.WriteToken(jsonEndObject))
+		assert.Equal(t, `{"payload":1.5,"nan":"NaN"}`+"\n", buf.String())
+	})
+
+	t.Run("unset value", func(t *testing.T) {
+		t.Parallel()
+		err := EncodeValueTokens(jsonNewEncoder(io.Discard), &structpb.Value{})
+		assert.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, EncodeStructTokens(jsonNewEncoder(&buf), nil))
+		assert.Equal(t, "{}\n", buf.String())
+	})
+}
+
+func TestDecodeValueTokens(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		input := `{"name":"frodo","age":50,"items":["ring",true,null,{"a":[]}],"empty":{}}`
+		got, err := DecodeStructTokens(jsonNewDecoder(strings.NewReader(input)))
+		require.NoError(t, err)
+
+		want := &structpb.Struct{}
+		require.NoError(t, protojson.Unmarshal([]byte(input), want))
+		assert.True(t, proto.Equal(want, got), "got %v", got)
+	})
+
+	t.Run("inside a larger stream", func(t *testing.T) {
+		t.Parallel()
+		dec := jsonNewDecoder(strings.NewReader(`{"meta":1,"payload":{"a":[1,2]},"after":true}`))
+		_, err := dec.ReadToken()
+		require.NoError(t, err)
+		require.NoError(t, dec.SkipValue()) // name
+		require.NoError(t, dec.SkipValue()) // value
+		name, err := dec.ReadToken()
+		require.NoError(t, err)
+		require.Equal(t, "payload", name.String())
+
+		v, err := DecodeValueTokens(dec)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": []any{float64(1), float64(2)}}, v.AsInterface())
+
+		name, err = dec.ReadToken()
+		require.NoError(t, err)
+		assert.Equal(t, "after", name.String())
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		t.Parallel()
+		_, err := DecodeStructTokens(jsonNewDecoder(strings.NewReader(`[1]`)))
+		assert.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		t.Parallel()
+		_, err := DecodeStructTokens(jsonNewDecoder(strings.NewReader(`{"a":}`)))
+		assert.Error(t, err)
+		_, err = DecodeStructTokens(jsonNewDecoder(strings.NewReader(`nope`)))
+		assert.Error(t, err)
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		t.Parallel()
+		_, err := DecodeStructTokens(jsonNewDecoder(strings.NewReader(`{"a":1,"a":2}`)))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,154 @@
+package protobaggins
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// KeyCase selects how map keys and struct field names are rewritten by WithKeyCase
+type KeyCase int
+
+const (
+	keyCaseUnchanged KeyCase = iota
+	// KeyCaseSnake rewrites keys as snake_case, "userID" becomes "user_id"
+	KeyCaseSnake
+	// KeyCaseKebab rewrites keys as kebab-case, "userID" becomes "user-id"
+	KeyCaseKebab
+	// KeyCaseCamel rewrites keys as camelCase, "user_id" becomes "userId"
+	KeyCaseCamel
+	// KeyCasePascal rewrites keys as PascalCase, "user_id" becomes "UserId"
+	KeyCasePascal
+)
+
+// WithKeyCase rewrites the keys of the maps and Structs produced by a conversion, in
+// either direction and at any depth. Key filters see the original keys. Keys that
+// collide after rewriting fail the conversion to protocol buffer values, while in the
+// other direction one of their values is kept: that of the key already in the case,
+// such as "userName" over "user_name" for KeyCaseCamel, or else that of the first key
+// in sorted order
+func WithKeyCase(c KeyCase) Option {
+	return func(o *options) {
+		o.keyCase = c
+	}
+}
+
+// Convert rewrites key in the case c. Words are split at underscores, hyphens, spaces
+// and changes from lower to upper case, keeping initialisms such as "HTTP" together
+// Digits stay with the word before them. Keys without words, such as "_", are
+// returned unchanged
+func (c KeyCase) Convert(key string) string {
+	if c == keyCaseUnchanged {
+		return key
+	}
+
+	words := splitWords(key)
+	if len(words) == 0 {
+		return key
+	}
+	var b strings.Builder
+	b.Grow(len(key) + len(words))
+	for i, word := range words {
+		switch c {
+		case KeyCaseSnake:
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteString(strings.ToLower(word))
+		case KeyCaseKebab:
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteString(strings.ToLower(word))
+		case KeyCaseCamel, KeyCasePascal:
+			if i == 0 && c == KeyCaseCamel {
+				b.WriteString(strings.ToLower(word))
+				continue
+			}
+			runes := []rune(strings.ToLower(word))
+			runes[0] = unicode.ToUpper(runes[0])
+			b.WriteString(string(runes))
+		}
+	}
+	return b.String()
+}
+
+// splitWords splits key into its words, see KeyCase.Convert
+func splitWords(key string) []string {
+	var words []string
+	runes := []rune(key)
+	start := 0
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// "userID" splits before "I", "HTTPServer" before "S"
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// renameKey rewrites a key under WithKeyCase
+func (o *options) renameKey(key string) string {
+	return o.keyCase.Convert(key)
+}
+
+// renameField returns the key that a value stored under key in fields is stored under
+// after rewriting, failing if another key was already rewritten to it
+func (e *encoder) renameField(fields map[string]*structpb.Value, key string) (string, error) {
+	name := e.opts.renameKey(key)
+	if _, dup := fields[name]; dup {
+		return "", fmt.Errorf("key %q collides with another key as %q", key, name)
+	}
+	return name, nil
+}
+
+// decodedField reports whether the Struct field key holding v is decoded, rather than
+// dropped by the key filters or WithOmitNulls
+func (o *options) decodedField(key string, v *structpb.Value) bool {
+	return o.keepKey(key) && (!o.omitNulls || KindOf(v) != KindNull)
+}
+
+// keyCaseNames maps each name that the decoded fields are rewritten to under
+// WithKeyCase to the key whose value it takes, see WithKeyCase. Returns nil without
+// WithKeyCase, as every key then keeps its name
+func (o *options) keyCaseNames(fields map[string]*structpb.Value) map[string]string {
+	if o.keyCase == keyCaseUnchanged {
+		return nil
+	}
+	names := make(map[string]string, len(fields))
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		if !o.decodedField(key, fields[key]) {
+			continue
+		}
+		name := o.renameKey(key)
+		if _, ok := names[name]; !ok || key == name {
+			names[name] = key
+		}
+	}
+	return names
+}
+
+// decodedName returns the name the field key is decoded under, and false if the field
+// is dropped because another key takes that name, see keyCaseNames
+func (o *options) decodedName(names map[string]string, key string) (string, bool) {
+	name := o.renameKey(key)
+	return name, names == nil || names[name] == key
+}
@@ -0,0 +1,144 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestKeyCaseConvert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in                          string
+		snake, kebab, camel, pascal string
+	}{
+		{"userID", "user_id", "user-id", "userId", "UserId"},
+		{"user_id", "user_id", "user-id", "userId", "UserId"},
+		{"user-id", "user_id", "user-id", "userId", "UserId"},
+		{"UserName", "user_name", "user-name", "userName", "UserName"},
+		{"HTTPServer", "http_server", "http-server", "httpServer", "HttpServer"},
+		{"ipv4Address", "ipv4_address", "ipv4-address", "ipv4Address", "Ipv4Address"},
+		{"max_retries_2x", "max_retries_2x", "max-retries-2x", "maxRetries2x", "MaxRetries2x"},
+		{"__leading__", "leading", "leading", "leading", "Leading"},
+		{"name", "name", "name", "name", "Name"},
+		{"_", "_", "_", "_", "_"},
+		{"--", "--", "--", "--", "--"},
+		{"", "", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.snake, KeyCaseSnake.Convert(tt.in))
+			assert.Equal(t, tt.kebab, KeyCaseKebab.Convert(tt.in))
+			assert.Equal(t, tt.camel, KeyCaseCamel.Convert(tt.in))
+			assert.Equal(t, tt.pascal, KeyCasePascal.Convert(tt.in))
+		})
+	}
+	assert.Equal(t, "userID", KeyCase(0).Convert("userID"))
+}
+
+func TestWithKeyCase(t *testing.T) {
+	t.Parallel()
+
+	t.Run("encoding", func(t *testing.T) {
+		t.Parallel()
+		values, err := MapToStructValuesE(map[string]any{
+			"userID":  1,
+			"address": map[string]any{"streetName": "bagshot row"},
+			"aliases": []any{map[string]any{"firstName": "mr. underhill"}},
+		}, WithKeyCase(KeyCaseSnake))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"user_id": 1.0,
+			"address": map[string]any{"street_name": "bagshot row"},
+			"aliases": []any{map[string]any{"first_name": "mr. underhill"}},
+		}, StructValuesToMap(values))
+	})
+
+	t.Run("decoding", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"user_id": 1,
+			"address": map[string]any{"street_name": "bagshot row"},
+		})
+		require.NoError(t, err)
+		want := map[string]any{
+			"userId":  1.0,
+			"address": map[string]any{"streetName": "bagshot row"},
+		}
+		assert.Equal(t, want, StructValuesToMap(s.GetFields(), WithKeyCase(KeyCaseCamel)))
+		assert.Equal(t, want, NewConverter(WithKeyCase(KeyCaseCamel)).StructToMap(s))
+	})
+
+	t.Run("structs", func(t *testing.T) {
+		t.Parallel()
+		type address struct {
+			StreetName string
+		}
+		type user struct {
+			UserID  int
+			Address address
+		}
+		s, err := EncodeStruct(user{UserID: 7, Address: address{StreetName: "x"}}, WithKeyCase(KeyCaseKebab))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"user-id": 7.0, "address": map[string]any{"street-name": "x"}}, s.AsMap())
+
+		var out user
+		require.NoError(t, DecodeStruct(s, &out, WithKeyCase(KeyCasePascal)))
+		assert.Equal(t, user{UserID: 7, Address: address{StreetName: "x"}}, out)
+	})
+
+	t.Run("collisions", func(t *testing.T) {
+		t.Parallel()
+		in := map[string]any{"user_id": 1, "userId": 2, "name": "x"}
+		_, err := NewValue(in, WithKeyCase(KeyCaseSnake))
+		require.ErrorContains(t, err, `as "user_id"`)
+
+		v, err := NewValue(in, WithKeyCase(KeyCaseSnake), WithSkipErrors())
+		require.NoError(t, err)
+		assert.Len(t, v.GetStructValue().GetFields(), 2)
+	})
+
+	t.Run("decoding collisions", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"user_name": 1, "userName": 2,
+			"last_name": 3, "last-name": 4,
+			"_": 5,
+		})
+		require.NoError(t, err)
+		// the key already in the case wins, otherwise the first in sorted order
+		want := map[string]any{"userName": 2.0, "lastName": 4.0, "_": 5.0}
+		for range 20 {
+			assert.Equal(t, want, StructValuesToMap(s.GetFields(), WithKeyCase(KeyCaseCamel)))
+		}
+
+		typed, err := StructValuesToMapT[float64](s.GetFields(), WithKeyCase(KeyCaseCamel))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]float64{"userName": 2, "lastName": 4, "_": 5}, typed)
+
+		var out map[string]int
+		require.NoError(t, DecodeStruct(s, &out, WithKeyCase(KeyCaseCamel)))
+		assert.Equal(t, map[string]int{"userName": 2, "lastName": 4, "_": 5}, out)
+
+		var user struct{ UserName, LastName int }
+		require.NoError(t, DecodeStruct(s, &user, WithKeyCase(KeyCasePascal)))
+		assert.Equal(t, 2, user.UserName, "no key is in PascalCase, and userName sorts first")
+		assert.Equal(t, 4, user.LastName)
+
+		values, err := MapToStructValuesE(map[string]any{"_": 1}, WithKeyCase(KeyCaseSnake))
+		require.NoError(t, err)
+		assert.Contains(t, values, "_")
+	})
+
+	t.Run("filters see original keys", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(map[string]any{"userID": 1, "secretKey": "x"},
+			WithKeyCase(KeyCaseSnake), WithKeyFilter(func(key string) bool { return key != "secretKey" }))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"user_id": 1.0}, v.AsInterface())
+	})
+}
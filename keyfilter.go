@@ -0,0 +1,57 @@
+package protobaggins
+
+// WithAllowKeys keeps only map entries and struct fields whose key matches one of
+// patterns, in both directions and at any depth, so untrusted input is reduced to a
+// known key set while it is converted. The keys of nested maps must match as well.
+// A pattern is an exact key, or a glob in which * matches any run of characters, such
+// as `user_*` for a prefix. No patterns allow no keys. See WithKeyFilter
+func WithAllowKeys(patterns ...string) Option {
+	return WithKeyFilter(func(key string) bool {
+		return matchesAnyKey(key, patterns)
+	})
+}
+
+// WithDenyKeys drops map entries and struct fields whose key matches one of patterns,
+// in both directions and at any depth, see WithAllowKeys for the patterns
+func WithDenyKeys(patterns ...string) Option {
+	return WithKeyFilter(func(key string) bool {
+		return !matchesAnyKey(key, patterns)
+	})
+}
+
+func matchesAnyKey(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchKey(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKey reports whether key matches pattern, where * matches any run of characters
+// and everything else matches itself
+func matchKey(pattern, key string) bool {
+	// star and retry remember the last * and the key position it was tried at, so a
+	// mismatch lets that * absorb one more character instead of backtracking further
+	star, retry := -1, 0
+	p, k := 0, 0
+	for k < len(key) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, retry = p, k
+			p++
+		case p < len(pattern) && pattern[p] == key[k]:
+			p++
+			k++
+		case star >= 0:
+			retry++
+			p, k = star+1, retry
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,90 @@
+package protobaggins
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"name", "name", true},
+		{"name", "names", false},
+		{"user_*", "user_id", true},
+		{"user_*", "user_", true},
+		{"user_*", "admin_id", false},
+		{"*_id", "user_id", true},
+		{"*_id", "user_ids", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxcyyb", false},
+		{"*", "", true},
+		{"", "", true},
+		{"", "a", false},
+		{"**", "any.thing", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchKey(tt.pattern, tt.key), "%q ~ %q", tt.pattern, tt.key)
+	}
+}
+
+func TestAllowDenyKeys(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"name":     "Frodo",
+		"user_id":  7,
+		"password": "mellon",
+		"address":  map[string]any{"name": "Bag End", "secret_door": true},
+		"friends":  []any{map[string]any{"name": "Sam", "password": "potatoes"}},
+	}
+
+	t.Run("allow", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithAllowKeys("name", "user_*", "friends"))
+		s, err := c.MapToStructValues(input)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"name", "user_id", "friends"}, slices.Collect(maps.Keys(s)))
+		friend := s["friends"].GetListValue().GetValues()[0].GetStructValue()
+		assert.Equal(t, []string{"name"}, sortedKeys(friend), "nested keys are filtered too")
+
+		assert.Equal(t, map[string]any{"name": "Frodo"}, StructValuesToMap(s, WithAllowKeys("name")))
+	})
+
+	t.Run("deny", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithDenyKeys("password", "secret_*"))
+		s, err := c.MapToStructValues(input)
+		require.NoError(t, err)
+		m := StructValuesToMap(s)
+		assert.NotContains(t, m, "password")
+		assert.Equal(t, map[string]any{"name": "Bag End"}, m["address"])
+		assert.Equal(t, []any{map[string]any{"name": "Sam"}}, m["friends"])
+
+		all, err := NewConverter().MapToStructValues(input)
+		require.NoError(t, err)
+		m = StructValuesToMap(all, WithDenyKeys("password", "secret_*"))
+		assert.NotContains(t, m, "password")
+		assert.Contains(t, m, "user_id")
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		t.Parallel()
+		c := NewConverter(WithAllowKeys("*"), WithDenyKeys("pass*"))
+		s, err := c.MapToStructValues(input)
+		require.NoError(t, err)
+		assert.NotContains(t, s, "password")
+		assert.Len(t, s, 4)
+
+		s, err = NewConverter(WithAllowKeys()).MapToStructValues(input)
+		require.NoError(t, err)
+		assert.Empty(t, s)
+	})
+}
@@ -0,0 +1,72 @@
+package protobaggins
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Kind identifies which of the six JSON-like kinds a *structpb.Value holds
+// The declaration order is the order used when comparing values of different kinds
+type Kind int
+
+const (
+	// KindUnset is reported for nil values and values without a kind
+	KindUnset Kind = iota
+	KindNull
+	KindBool
+	KindNumber
+	KindString
+	KindList
+	KindStruct
+)
+
+// AllKinds lists every kind a set value can hold, in comparison order
+var AllKinds = []Kind{KindNull, KindBool, KindNumber, KindString, KindList, KindStruct}
+
+// KindOf returns the kind held by v
+func KindOf(v *structpb.Value) Kind {
+	switch v.GetKind().(type) {
+	case *structpb.Value_NullValue:
+		return KindNull
+	case *structpb.Value_BoolValue:
+		return KindBool
+	case *structpb.Value_NumberValue:
+		return KindNumber
+	case *structpb.Value_StringValue:
+		return KindString
+	case *structpb.Value_ListValue:
+		return KindList
+	case *structpb.Value_StructValue:
+		return KindStruct
+	default:
+		return KindUnset
+	}
+}
+
+// String returns the lower-case name of the kind, e.g. "number"
+func (k Kind) String() string {
+	switch k {
+	case KindUnset:
+		return "unset"
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindList:
+		return "list"
+	case KindStruct:
+		return "struct"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// IsScalar reports whether the kind is null, bool, number or string
+func (k Kind) IsScalar() bool {
+	return k >= KindNull && k <= KindString
+}
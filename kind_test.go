@@ -0,0 +1,53 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestKindOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    *structpb.Value
+		expected Kind
+	}{
+		{"nil value", nil, KindUnset},
+		{"empty value", &structpb.Value{}, KindUnset},
+		{"null value", structpb.NewNullValue(), KindNull},
+		{"bool value", structpb.NewBoolValue(true), KindBool},
+		{"number value", structpb.NewNumberValue(1), KindNumber},
+		{"string value", structpb.NewStringValue("x"), KindString},
+		{"list value", structpb.NewListValue(&structpb.ListValue{}), KindList},
+		{"struct value", structpb.NewStructValue(&structpb.Struct{}), KindStruct},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, KindOf(tt.value))
+		})
+	}
+}
+
+func TestKind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("string", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "number", KindNumber.String())
+		assert.Equal(t, "unset", KindUnset.String())
+		assert.Equal(t, "Kind(42)", Kind(42).String())
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		t.Parallel()
+		for _, k := range AllKinds {
+			assert.Equal(t, k != KindList && k != KindStruct, k.IsScalar(), k.String())
+		}
+		assert.False(t, KindUnset.IsScalar())
+	})
+}
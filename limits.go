@@ -0,0 +1,82 @@
+package protobaggins
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// WithMaxNodes fails conversions that produce more than n values inside maps and lists,
+// counting nested containers and their contents at any depth. The error wraps
+// ErrTooLarge and is never skipped by WithSkipErrors. Zero means no limit
+func WithMaxNodes(n int) Option {
+	return func(o *options) {
+		o.maxNodes = max(n, 0)
+	}
+}
+
+// WithMaxBytes fails conversions whose result holds more than n bytes of data, counting
+// keys and strings by their length, numbers as 8 bytes and booleans and nulls as 1.
+// This approximates the encoded size without encoding anything. The error wraps
+// ErrTooLarge and is never skipped by WithSkipErrors. Zero means no limit
+func WithMaxBytes(n int) Option {
+	return func(o *options) {
+		o.maxBytes = max(n, 0)
+	}
+}
+
+// count accounts for v being stored under key in a map, or in a list when key is empty,
+// failing once a resource limit is exceeded. The contents of containers were counted
+// when they were added to them, and the current path must be that of v
+func (e *encoder) count(key string, v *structpb.Value) error {
+	return e.charge(key, scalarBytes(v))
+}
+
+// scalarBytes is the size of v under WithMaxBytes, zero for containers
+func scalarBytes(v *structpb.Value) int {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return len(kind.StringValue)
+	case *structpb.Value_NumberValue:
+		return 8
+	case *structpb.Value_BoolValue, *structpb.Value_NullValue:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// encodeRoot is encode for a top-level value, which is not stored in a map or list and
+// so not counted by count. A scalar result is charged against WithMaxBytes, but is not
+// one of the values WithMaxNodes limits
+func (e *encoder) encodeRoot(v any) (*structpb.Value, error) {
+	pbValue, err := e.encode(v)
+	if err != nil || e.opts.maxBytes == 0 {
+		return pbValue, err
+	}
+	e.bytes += scalarBytes(pbValue)
+	if e.bytes > e.opts.maxBytes {
+		return nil, e.conversionError(reflect.TypeOf(v), fmt.Errorf("%w: more than %d bytes", ErrTooLarge, e.opts.maxBytes))
+	}
+	return pbValue, nil
+}
+
+// charge is count for a value of size bytes
+func (e *encoder) charge(key string, size int) error {
+	e.nodes++
+	if e.ctx != nil && e.nodes%ctxCheckInterval == 0 {
+		if err := e.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if e.opts.maxNodes > 0 && e.nodes > e.opts.maxNodes {
+		return e.conversionError(nil, fmt.Errorf("%w: more than %d values", ErrTooLarge, e.opts.maxNodes))
+	}
+
+	e.bytes += len(key) + size
+	if e.opts.maxBytes > 0 && e.bytes > e.opts.maxBytes {
+		return e.conversionError(nil, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, e.opts.maxBytes))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package protobaggins
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceLimits(t *testing.T) {
+	t.Parallel()
+
+	// 6 values: a, b, b[0], b[1], c and c.d, holding 1+3 + 1 + 8 + 1 + 1 + 1+5 = 21 bytes
+	in := map[string]any{
+		"a": "xyz",
+		"b": []any{1, true},
+		"c": map[string]any{"d": "hello"},
+	}
+
+	t.Run("max nodes", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(in, WithMaxNodes(6))
+		require.NoError(t, err)
+
+		_, err = NewValue(in, WithMaxNodes(5))
+		require.ErrorIs(t, err, ErrTooLarge)
+		assert.Contains(t, err.Error(), "more than 5 values")
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(in, WithMaxBytes(21))
+		require.NoError(t, err)
+
+		_, err = NewValue(in, WithMaxBytes(20))
+		require.ErrorIs(t, err, ErrTooLarge)
+		assert.Contains(t, err.Error(), "more than 20 bytes")
+
+		_, err = NewValue(strings.Repeat("x", 100), WithMaxBytes(5))
+		require.ErrorIs(t, err, ErrTooLarge)
+		_, err = NewConverter(WithMaxBytes(5)).NewValue(strings.Repeat("x", 100))
+		require.ErrorIs(t, err, ErrTooLarge)
+		_, err = NewValue("xyz", WithMaxBytes(3), WithMaxNodes(1))
+		require.NoError(t, err)
+		_, err = NewValue(1.5, WithMaxBytes(7))
+		require.ErrorIs(t, err, ErrTooLarge)
+	})
+
+	t.Run("not skipped", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(in, WithMaxNodes(2), WithSkipErrors())
+		require.ErrorIs(t, err, ErrTooLarge)
+
+		_, err = NewConverter(WithMaxBytes(10), WithSkipErrors()).MapToStructValues(map[string]any{
+			"big":   strings.Repeat("x", 100),
+			"small": "x",
+		})
+		require.ErrorIs(t, err, ErrTooLarge)
+		assert.Contains(t, err.Error(), "big: ")
+
+		_, err = SliceToStructValuesE([]any{1, 2, 3}, WithMaxNodes(2))
+		require.ErrorIs(t, err, ErrTooLarge)
+	})
+
+	t.Run("reflection", func(t *testing.T) {
+		t.Parallel()
+		type row struct {
+			Names []string
+		}
+		_, err := EncodeStruct(row{Names: []string{"a", "b", "c"}}, WithMaxNodes(2))
+		require.ErrorIs(t, err, ErrTooLarge)
+		assert.Contains(t, err.Error(), "Names[2]: ")
+
+		_, err = EncodeStruct(row{Names: []string{"a", "b", "c"}}, WithMaxNodes(4))
+		require.NoError(t, err)
+	})
+}
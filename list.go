@@ -0,0 +1,78 @@
+package protobaggins
+
+import (
+	"fmt"
+	"slices"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The list helpers modify lists in place and return them, like SortListFunc. Values are
+// never copied, so a value stored in several lists is shared between them
+
+// Append appends values to l, returning a new list if l is nil
+func Append(l *structpb.ListValue, values ...*structpb.Value) *structpb.ListValue {
+	if l == nil {
+		l = &structpb.ListValue{}
+	}
+	l.Values = append(l.Values, values...)
+	return l
+}
+
+// Concat returns a new list holding the values of lists in order. Nil lists are empty
+func Concat(lists ...*structpb.ListValue) *structpb.ListValue {
+	n := 0
+	for _, l := range lists {
+		n += len(l.GetValues())
+	}
+	values := make([]*structpb.Value, 0, n)
+	for _, l := range lists {
+		values = append(values, l.GetValues()...)
+	}
+	return &structpb.ListValue{Values: values}
+}
+
+// Insert inserts values into l before index i, which may be the length of l to append
+// them. Fails with ErrPathNotFound if i is out of range
+func Insert(l *structpb.ListValue, i int, values ...*structpb.Value) (*structpb.ListValue, error) {
+	if i < 0 || i > len(l.GetValues()) {
+		return l, fmt.Errorf("%w: index %d out of range for list of length %d", ErrPathNotFound, i, len(l.GetValues()))
+	}
+	if l == nil {
+		l = &structpb.ListValue{}
+	}
+	l.Values = slices.Insert(l.Values, i, values...)
+	return l, nil
+}
+
+// RemoveAt removes the value at index i from l
+// Fails with ErrPathNotFound if i is out of range
+func RemoveAt(l *structpb.ListValue, i int) (*structpb.ListValue, error) {
+	if i < 0 || i >= len(l.GetValues()) {
+		return l, fmt.Errorf("%w: index %d out of range for list of length %d", ErrPathNotFound, i, len(l.GetValues()))
+	}
+	l.Values = slices.Delete(l.Values, i, i+1)
+	return l, nil
+}
+
+// FilterList removes the values of l for which keep returns false
+func FilterList(l *structpb.ListValue, keep func(*structpb.Value) bool) *structpb.ListValue {
+	if l == nil {
+		return nil
+	}
+	l.Values = slices.DeleteFunc(l.Values, func(v *structpb.Value) bool {
+		return !keep(v)
+	})
+	return l
+}
+
+// MapList replaces each value of l with the result of fn
+func MapList(l *structpb.ListValue, fn func(*structpb.Value) *structpb.Value) *structpb.ListValue {
+	if l == nil {
+		return nil
+	}
+	for i, v := range l.Values {
+		l.Values[i] = fn(v)
+	}
+	return l
+}
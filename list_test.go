@@ -0,0 +1,96 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestListHelpers(t *testing.T) {
+	t.Parallel()
+
+	newList := func(t *testing.T, items ...any) *structpb.ListValue {
+		t.Helper()
+		l, err := structpb.NewList(items)
+		require.NoError(t, err)
+		return l
+	}
+
+	t.Run("Append", func(t *testing.T) {
+		t.Parallel()
+		l := newList(t, "a")
+		assert.Same(t, l, Append(l, structpb.NewStringValue("b"), structpb.NewNumberValue(1)))
+		assert.Equal(t, []any{"a", "b", 1.0}, l.AsSlice())
+		assert.Equal(t, []any{"x"}, Append(nil, structpb.NewStringValue("x")).AsSlice())
+	})
+
+	t.Run("Concat", func(t *testing.T) {
+		t.Parallel()
+		a, b := newList(t, "a", "b"), newList(t, "c")
+		got := Concat(a, nil, b)
+		assert.Equal(t, []any{"a", "b", "c"}, got.AsSlice())
+		assert.Same(t, a.Values[0], got.Values[0])
+		assert.Len(t, a.Values, 2, "inputs are unchanged")
+		assert.Empty(t, Concat().GetValues())
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+		t.Parallel()
+		l := newList(t, "a", "d")
+		got, err := Insert(l, 1, structpb.NewStringValue("b"), structpb.NewStringValue("c"))
+		require.NoError(t, err)
+		assert.Same(t, l, got)
+		assert.Equal(t, []any{"a", "b", "c", "d"}, l.AsSlice())
+
+		_, err = Insert(l, 4, structpb.NewStringValue("e"))
+		require.NoError(t, err)
+		assert.Equal(t, []any{"a", "b", "c", "d", "e"}, l.AsSlice())
+
+		got, err = Insert(nil, 0, structpb.NewStringValue("x"))
+		require.NoError(t, err)
+		assert.Equal(t, []any{"x"}, got.AsSlice())
+
+		_, err = Insert(l, 6, structpb.NewStringValue("z"))
+		require.ErrorIs(t, err, ErrPathNotFound)
+		_, err = Insert(l, -1, structpb.NewStringValue("z"))
+		require.ErrorIs(t, err, ErrPathNotFound)
+	})
+
+	t.Run("RemoveAt", func(t *testing.T) {
+		t.Parallel()
+		l := newList(t, "a", "b", "c")
+		got, err := RemoveAt(l, 1)
+		require.NoError(t, err)
+		assert.Same(t, l, got)
+		assert.Equal(t, []any{"a", "c"}, l.AsSlice())
+
+		_, err = RemoveAt(l, 2)
+		require.ErrorIs(t, err, ErrPathNotFound)
+		_, err = RemoveAt(nil, 0)
+		require.ErrorIs(t, err, ErrPathNotFound)
+	})
+
+	t.Run("FilterList", func(t *testing.T) {
+		t.Parallel()
+		l := newList(t, 1, "a", 2, nil)
+		got := FilterList(l, func(v *structpb.Value) bool {
+			return KindOf(v) == KindNumber
+		})
+		assert.Same(t, l, got)
+		assert.Equal(t, []any{1.0, 2.0}, l.AsSlice())
+		assert.Nil(t, FilterList(nil, func(*structpb.Value) bool { return true }))
+	})
+
+	t.Run("MapList", func(t *testing.T) {
+		t.Parallel()
+		l := newList(t, 1, 2)
+		got := MapList(l, func(v *structpb.Value) *structpb.Value {
+			return structpb.NewNumberValue(v.GetNumberValue() * 10)
+		})
+		assert.Same(t, l, got)
+		assert.Equal(t, []any{10.0, 20.0}, l.AsSlice())
+		assert.Nil(t, MapList(nil, func(v *structpb.Value) *structpb.Value { return v }))
+	})
+}
@@ -0,0 +1,239 @@
+// Package luaconv converts between structpb values and gopher-lua values, for Lua
+// scripts that read and produce Struct data.
+//
+// Structs convert to tables with string keys and lists to tables with keys 1 to n.
+// Lua has no null distinct from nil, so null fields are absent from tables and null
+// list items leave holes, which come back as nulls unless they are at the end of the
+// list. Tables convert back to lists when their keys are the integers from 1 to n,
+// allowing holes as long as at least half of the keys up to the largest are present,
+// or the largest is at most 10, and to Structs otherwise.
+package luaconv
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/internal/conv"
+	lua "github.com/yuin/gopher-lua"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxDenseIndex is the largest index up to which a table with holes is a list
+// regardless of how many keys it has, as in lua-cjson
+const maxDenseIndex = 10
+
+// Option configures FromLua and TableToStruct
+type Option func(*options)
+
+type options struct {
+	nonFinite   protobaggins.NonFinitePolicy
+	emptyTables bool
+}
+
+// WithNonFinite converts NaN and infinite numbers, which Lua produces for 0/0 or 1/0,
+// under policy, see protobaggins.WithNonFinite. They are kept as numbers by default
+func WithNonFinite(policy protobaggins.NonFinitePolicy) Option {
+	return func(o *options) {
+		o.nonFinite = policy
+	}
+}
+
+// EmptyTablesAsLists converts empty tables to empty lists instead of empty Structs
+func EmptyTablesAsLists() Option {
+	return func(o *options) {
+		o.emptyTables = true
+	}
+}
+
+// ToLua converts v to a Lua value of L: nil, a boolean, a number, a string or a table
+func ToLua(L *lua.LState, v *structpb.Value) lua.LValue {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return StructToTable(L, kind.StructValue)
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		tb := L.CreateTable(len(items), 0)
+		for i, item := range items {
+			tb.RawSetInt(i+1, ToLua(L, item))
+		}
+		return tb
+	case *structpb.Value_StringValue:
+		return lua.LString(kind.StringValue)
+	case *structpb.Value_NumberValue:
+		return lua.LNumber(kind.NumberValue)
+	case *structpb.Value_BoolValue:
+		return lua.LBool(kind.BoolValue)
+	default:
+		return lua.LNil
+	}
+}
+
+// StructToTable converts s to a table of L, see ToLua
+func StructToTable(L *lua.LState, s *structpb.Struct) *lua.LTable {
+	tb := L.CreateTable(0, len(s.GetFields()))
+	for key, v := range s.GetFields() {
+		tb.RawSetString(key, ToLua(L, v))
+	}
+	return tb
+}
+
+// FromLua converts v to a Value. Tables become lists or Structs, see the package
+// documentation; the keys of Structs must be strings or numbers, which are formatted
+// like protobaggins.ToString formats them, "1" for 1. Fails with
+// protobaggins.ErrUnexpectedKind for functions, userdata, threads and channels and
+// tables with other keys, and with protobaggins.ErrCycle for tables that contain
+// themselves
+func FromLua(v lua.LValue, opts ...Option) (*structpb.Value, error) {
+	c := newConverter(opts)
+	return c.value("", v)
+}
+
+// TableToStruct converts tb to a Struct like FromLua does, even if it could be a list,
+// in which case its keys are "1" to "n"
+func TableToStruct(tb *lua.LTable, opts ...Option) (*structpb.Struct, error) {
+	c := newConverter(opts)
+	if err := c.enter("", tb); err != nil {
+		return nil, err
+	}
+	return c.structValue("", tb)
+}
+
+type converter struct {
+	opts options
+	// visiting holds the tables being converted, to detect cycles
+	visiting map[*lua.LTable]bool
+}
+
+func newConverter(opts []Option) *converter {
+	c := &converter{visiting: make(map[*lua.LTable]bool)}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
+}
+
+func (c *converter) value(path string, v lua.LValue) (*structpb.Value, error) {
+	switch v := v.(type) {
+	case *lua.LNilType:
+		return structpb.NewNullValue(), nil
+	case lua.LBool:
+		return structpb.NewBoolValue(bool(v)), nil
+	case lua.LString:
+		return structpb.NewStringValue(string(v)), nil
+	case lua.LNumber:
+		f := float64(v)
+		if !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return structpb.NewNumberValue(f), nil
+		}
+		result, err := protobaggins.NewValue(f, protobaggins.WithNonFinite(c.opts.nonFinite))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", conv.DescribePath(path), err)
+		}
+		return result, nil
+	case *lua.LTable:
+		return c.table(path, v)
+	default:
+		return nil, fmt.Errorf("%s: %w: cannot convert a Lua %s", conv.DescribePath(path), protobaggins.ErrUnexpectedKind, v.Type())
+	}
+}
+
+func (c *converter) table(path string, tb *lua.LTable) (*structpb.Value, error) {
+	if err := c.enter(path, tb); err != nil {
+		return nil, err
+	}
+	defer delete(c.visiting, tb)
+
+	n, isList := listLength(tb)
+	if isList && (n > 0 || c.opts.emptyTables) {
+		list := &structpb.ListValue{Values: make([]*structpb.Value, n)}
+		for i := range list.Values {
+			v, err := c.value(protobaggins.JoinPathIndex(path, i), tb.RawGetInt(i+1))
+			if err != nil {
+				return nil, err
+			}
+			list.Values[i] = v
+		}
+		return structpb.NewListValue(list), nil
+	}
+
+	s, err := c.structValue(path, tb)
+	if err != nil {
+		return nil, err
+	}
+	return structpb.NewStructValue(s), nil
+}
+
+// structValue converts tb, which enter has marked, to a Struct
+func (c *converter) structValue(path string, tb *lua.LTable) (*structpb.Struct, error) {
+	type entry struct {
+		key   string
+		value lua.LValue
+	}
+	var entries []entry
+	var keyErr error
+	tb.ForEach(func(k, v lua.LValue) {
+		switch k := k.(type) {
+		case lua.LString:
+			entries = append(entries, entry{string(k), v})
+		case lua.LNumber:
+			str, _ := protobaggins.ToString(structpb.NewNumberValue(float64(k)))
+			entries = append(entries, entry{str, v})
+		default:
+			if keyErr == nil {
+				keyErr = fmt.Errorf("%s: %w: cannot convert a table with a %s key", conv.DescribePath(path), protobaggins.ErrUnexpectedKind, k.Type())
+			}
+		}
+	})
+	if keyErr != nil {
+		return nil, keyErr
+	}
+
+	// convert in key order so that errors are deterministic
+	slices.SortFunc(entries, func(a, b entry) int {
+		return strings.Compare(a.key, b.key)
+	})
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(entries))}
+	for i, e := range entries {
+		keyPath := protobaggins.JoinPathKey(path, e.key)
+		if i > 0 && entries[i-1].key == e.key {
+			return nil, fmt.Errorf("%s: %w: the key is given both as a number and as a string", keyPath, protobaggins.ErrUnexpectedKind)
+		}
+		v, err := c.value(keyPath, e.value)
+		if err != nil {
+			return nil, err
+		}
+		s.Fields[e.key] = v
+	}
+	return s, nil
+}
+
+// listLength returns the largest key of tb if its keys are positive integers, dense
+// enough for tb to be a list
+func listLength(tb *lua.LTable) (int, bool) {
+	count, largest, isList := 0, 0, true
+	tb.ForEach(func(k, _ lua.LValue) {
+		n, ok := k.(lua.LNumber)
+		if !ok || n < 1 || float64(n) != math.Trunc(float64(n)) || n > lua.LNumber(lua.MaxArrayIndex) {
+			isList = false
+			return
+		}
+		count++
+		largest = max(largest, int(n))
+	})
+	if !isList || largest > maxDenseIndex && largest > 2*count {
+		return 0, false
+	}
+	return largest, true
+}
+
+// enter marks tb as being converted, failing if it already is
+func (c *converter) enter(path string, tb *lua.LTable) error {
+	if c.visiting[tb] {
+		return fmt.Errorf("%s: %w", conv.DescribePath(path), protobaggins.ErrCycle)
+	}
+	c.visiting[tb] = true
+	return nil
+}
@@ -0,0 +1,137 @@
+package luaconv
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// run executes script with input as a global and returns the global result
+func run(t *testing.T, script string, input lua.LValue) lua.LValue {
+	t.Helper()
+	L := lua.NewState()
+	t.Cleanup(L.Close)
+	L.SetGlobal("input", input)
+	require.NoError(t, L.DoString(script))
+	return L.GetGlobal("result")
+}
+
+func TestToLua(t *testing.T) {
+	t.Parallel()
+
+	v, err := structpb.NewValue(map[string]any{
+		"name": "frodo",
+		"age":  50.0,
+		"ring": true,
+		"gone": nil,
+		"tags": []any{"a", nil, 1.5},
+		"home": map[string]any{"door": "green"},
+	})
+	require.NoError(t, err)
+
+	L := lua.NewState()
+	defer L.Close()
+	result := run(t, `
+result = input.name .. " " .. input.age .. " " .. tostring(input.ring) .. " " ..
+	tostring(input.gone) .. " " .. input.tags[1] .. " " .. tostring(input.tags[2]) .. " " ..
+	input.tags[3] .. " " .. input.home.door
+`, ToLua(L, v))
+	assert.Equal(t, lua.LString("frodo 50 true nil a nil 1.5 green"), result)
+
+	assert.Equal(t, lua.LNil, ToLua(L, nil))
+}
+
+func TestFromLua(t *testing.T) {
+	t.Parallel()
+
+	t.Run("script result", func(t *testing.T) {
+		t.Parallel()
+		result := run(t, `
+result = {
+	allow = true,
+	score = 3,
+	ratio = 0.25,
+	reasons = {"ok", nil, "late"},
+	nested = {deep = -7, [1] = "one"},
+	empty = {},
+}
+`, lua.LNil)
+
+		got, err := FromLua(result)
+		require.NoError(t, err)
+		want, err := structpb.NewValue(map[string]any{
+			"allow":   true,
+			"score":   3.0,
+			"ratio":   0.25,
+			"reasons": []any{"ok", nil, "late"},
+			"nested":  map[string]any{"deep": -7.0, "1": "one"},
+			"empty":   map[string]any{},
+		})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, got), "%v", got)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": []any{1.0, 1.5, "x", nil, false}, "b": map[string]any{"c": []any{}}})
+		require.NoError(t, err)
+		L := lua.NewState()
+		defer L.Close()
+
+		got, err := TableToStruct(StructToTable(L, s), EmptyTablesAsLists())
+		require.NoError(t, err)
+		want, err := structpb.NewStruct(map[string]any{"a": []any{1.0, 1.5, "x", nil, false}, "b": map[string]any{"c": []any{}}})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, got), "%v", got)
+	})
+
+	t.Run("sparse tables", func(t *testing.T) {
+		t.Parallel()
+		result := run(t, `result = {dense = {[1] = "a", [10] = "j"}, sparse = {[1] = "a", [100] = "z"}}`, lua.LNil)
+		got, err := FromLua(result)
+		require.NoError(t, err)
+		fields := got.GetStructValue().GetFields()
+		assert.Len(t, fields["dense"].GetListValue().GetValues(), 10)
+		assert.Equal(t, map[string]any{"1": "a", "100": "z"}, fields["sparse"].GetStructValue().AsMap())
+	})
+
+	t.Run("non-finite numbers", func(t *testing.T) {
+		t.Parallel()
+		result := run(t, `result = 1/0`, lua.LNil)
+		got, err := FromLua(result)
+		require.NoError(t, err)
+		assert.True(t, math.IsInf(got.GetNumberValue(), 1))
+
+		got, err = FromLua(result, WithNonFinite(protobaggins.NonFiniteString))
+		require.NoError(t, err)
+		assert.Equal(t, "Infinity", got.GetStringValue())
+
+		_, err = FromLua(run(t, `result = {x = 0/0}`, lua.LNil), WithNonFinite(protobaggins.NonFiniteError))
+		require.ErrorIs(t, err, protobaggins.ErrNonFinite)
+		assert.ErrorContains(t, err, "x: ")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := FromLua(run(t, `result = {f = print}`, lua.LNil))
+		require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind)
+
+		_, err = FromLua(run(t, `result = {[true] = 1}`, lua.LNil))
+		require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind)
+
+		_, err = FromLua(run(t, `result = {[1] = "a", ["1"] = "b", x = 1}`, lua.LNil))
+		require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind)
+
+		_, err = FromLua(run(t, `result = {}; result.self = result`, lua.LNil))
+		require.ErrorIs(t, err, protobaggins.ErrCycle)
+
+		_, err = FromLua(run(t, `local s = {1}; result = {s, s}`, lua.LNil))
+		require.NoError(t, err)
+	})
+}
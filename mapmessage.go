@@ -0,0 +1,490 @@
+package protobaggins
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// UnknownKeys selects what MapToMessage does with keys that name no field
+type UnknownKeys int
+
+const (
+	// UnknownKeysFail fails on the first unknown key, the default
+	UnknownKeysFail UnknownKeys = iota
+	// UnknownKeysIgnore drops unknown keys
+	UnknownKeysIgnore
+	// UnknownKeysCollect fills all known fields, then fails with an *UnknownKeysError
+	// listing every unknown key
+	UnknownKeysCollect
+)
+
+// MessageUnknownKeys sets what MapToMessage does with keys that name no field
+func MessageUnknownKeys(policy UnknownKeys) MessageOption {
+	return func(o *messageOptions) {
+		o.unknownKeys = policy
+	}
+}
+
+// UnknownKeysError reports the keys given to MapToMessage that name no field
+type UnknownKeysError struct {
+	// Paths locates the keys in the converted map, sorted, e.g. "config.retries"
+	Paths []string
+}
+
+func (e *UnknownKeysError) Error() string {
+	return "unknown fields: " + strings.Join(e.Paths, ", ")
+}
+
+// MapToMessage sets the fields of dst from m, the reverse of MessageToMap. Keys match
+// fields by their JSON name or their name in the .proto file, and values are coerced
+// to the type of the field:
+//   - numbers of any Go type, numeric strings and bools are accepted for numeric
+//     fields, as long as they fit, e.g. 42.0 and "42" for an int32 but not 42.5
+//   - strings, numbers and bools are accepted for string and bool fields like ToString
+//     and ToBool do, bytes accept []byte or standard base64 strings
+//   - enums accept their name or number
+//   - repeated fields accept any slice and maps any map, with keys coerced likewise
+//   - Timestamp accepts time.Time and RFC 3339 strings, Duration time.Duration and
+//     strings such as "1.5s", FieldMask a []string or comma-separated string and
+//     wrappers their value; Struct, Value and ListValue accept what NewValue does
+//   - Any accepts a map with the type URL under "@type", as written by MessageToMap
+//   - message fields also accept a message of their type
+//
+// Fields named in m are replaced and the others are kept. A nil value clears its field,
+// except for google.protobuf.Value where it means null. Keys that name no field are
+// handled as set by MessageUnknownKeys. Fails with ErrNilMessage for a nil dst
+func MapToMessage(m map[string]any, dst proto.Message, opts ...MessageOption) error {
+	if dst == nil || !dst.ProtoReflect().IsValid() {
+		return fmt.Errorf("%w: cannot populate a nil message", ErrNilMessage)
+	}
+	o := messageOptions{resolver: protoregistry.GlobalTypes}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var unknown []string
+	if err := o.fill("", dst.ProtoReflect(), m, &unknown); err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return &UnknownKeysError{Paths: unknown}
+	}
+	return nil
+}
+
+// fill sets the fields of msg, a message at path, from values, appending the paths of
+// unknown keys to unknown under UnknownKeysCollect
+func (o *messageOptions) fill(path string, msg protoreflect.Message, values map[string]any, unknown *[]string) error {
+	fds := msg.Descriptor().Fields()
+	keys := make(map[protoreflect.FieldDescriptor]string, len(values))
+	oneofs := make(map[protoreflect.OneofDescriptor]string)
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		fieldPath := joinKey(path, key)
+		fd := fds.ByJSONName(key)
+		if fd == nil {
+			fd = fds.ByName(protoreflect.Name(key))
+		}
+		if fd == nil {
+			switch o.unknownKeys {
+			case UnknownKeysIgnore:
+			case UnknownKeysCollect:
+				*unknown = append(*unknown, fieldPath)
+			default:
+				return &UnknownKeysError{Paths: []string{fieldPath}}
+			}
+			continue
+		}
+
+		if other, dup := keys[fd]; dup {
+			return fmt.Errorf("%s: field %s is also set as %q", fieldPath, fd.Name(), other)
+		}
+		keys[fd] = key
+		if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() {
+			if other, dup := oneofs[od]; dup {
+				return fmt.Errorf("%s: oneof %s is also set as %q", fieldPath, od.Name(), other)
+			}
+			oneofs[od] = key
+		}
+
+		v := values[key]
+		if v == nil && !isNullable(fd) {
+			msg.Clear(fd)
+			continue
+		}
+		value, err := o.fieldValue(fieldPath, msg, fd, v, unknown)
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, value)
+	}
+	return nil
+}
+
+// isNullable reports whether fd holds a google.protobuf.Value or NullValue, for which
+// nil means null
+func isNullable(fd protoreflect.FieldDescriptor) bool {
+	switch {
+	case fd.IsList() || fd.IsMap():
+		return false
+	case fd.Message() != nil:
+		return fd.Message().FullName() == "google.protobuf.Value"
+	case fd.Enum() != nil:
+		return fd.Enum().FullName() == "google.protobuf.NullValue"
+	default:
+		return false
+	}
+}
+
+// fieldValue converts v to a value of the field fd of msg
+func (o *messageOptions) fieldValue(path string, msg protoreflect.Message, fd protoreflect.FieldDescriptor, v any, unknown *[]string) (protoreflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case fd.IsList():
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return protoreflect.Value{}, fmt.Errorf("%s: %w: cannot set %T as a repeated field", describePath(path), ErrUnexpectedKind, v)
+		}
+		field := msg.NewField(fd)
+		list := field.List()
+		for i := range rv.Len() {
+			item, err := o.singularValue(joinIndex(path, i), fd, rv.Index(i).Interface(), list.NewElement, unknown)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			list.Append(item)
+		}
+		return field, nil
+	case fd.IsMap():
+		if rv.Kind() != reflect.Map {
+			return protoreflect.Value{}, fmt.Errorf("%s: %w: cannot set %T as a map field", describePath(path), ErrUnexpectedKind, v)
+		}
+		field := msg.NewField(fd)
+		entries := field.Map()
+		iter := rv.MapRange()
+		for iter.Next() {
+			entryPath := joinKey(path, fmt.Sprint(iter.Key().Interface()))
+			key, err := scalarField(entryPath, fd.MapKey(), iter.Key().Interface())
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			value, err := o.singularValue(entryPath, fd.MapValue(), iter.Value().Interface(), entries.NewValue, unknown)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			entries.Set(key.MapKey(), value)
+		}
+		return field, nil
+	default:
+		return o.singularValue(path, fd, v, func() protoreflect.Value { return msg.NewField(fd) }, unknown)
+	}
+}
+
+// singularValue converts v to a value of the type of fd that is not a list or map,
+// creating messages with newValue
+func (o *messageOptions) singularValue(path string, fd protoreflect.FieldDescriptor, v any, newValue func() protoreflect.Value, unknown *[]string) (protoreflect.Value, error) {
+	if fd.Message() == nil {
+		return scalarField(path, fd, v)
+	}
+	value := newValue()
+	if err := o.fillMessage(path, value.Message(), v, unknown); err != nil {
+		return protoreflect.Value{}, err
+	}
+	return value, nil
+}
+
+// fillMessage sets the empty message msg at path from v, handling the well-known types
+func (o *messageOptions) fillMessage(path string, msg protoreflect.Message, v any, unknown *[]string) error {
+	name := msg.Descriptor().FullName()
+	if src, ok := v.(proto.Message); ok && src.ProtoReflect().Descriptor().FullName() == name {
+		proto.Merge(msg.Interface(), src)
+		return nil
+	}
+
+	fields := msg.Descriptor().Fields()
+	switch name {
+	case "google.protobuf.Timestamp":
+		t, ok := v.(time.Time)
+		if s, isString := v.(string); isString {
+			var err error
+			if t, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("%s: %w: %q is not an RFC 3339 time", describePath(path), ErrNotCoercible, s)
+			}
+			ok = true
+		}
+		if !ok {
+			return fmt.Errorf("%s: %w: cannot set %T as a timestamp", describePath(path), ErrUnexpectedKind, v)
+		}
+		msg.Set(fields.ByNumber(1), protoreflect.ValueOfInt64(t.Unix()))
+		msg.Set(fields.ByNumber(2), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+	case "google.protobuf.Duration":
+		d, ok := v.(time.Duration)
+		if s, isString := v.(string); isString {
+			var err error
+			if d, err = time.ParseDuration(s); err != nil {
+				return fmt.Errorf("%s: %w: %q is not a duration", describePath(path), ErrNotCoercible, s)
+			}
+			ok = true
+		}
+		if !ok {
+			return fmt.Errorf("%s: %w: cannot set %T as a duration", describePath(path), ErrUnexpectedKind, v)
+		}
+		msg.Set(fields.ByNumber(1), protoreflect.ValueOfInt64(int64(d/time.Second)))
+		msg.Set(fields.ByNumber(2), protoreflect.ValueOfInt32(int32(d%time.Second)))
+	case "google.protobuf.FieldMask":
+		if s, ok := v.(string); ok {
+			v = strings.Split(s, ",")
+			if s == "" {
+				v = []string{}
+			}
+		}
+		value, err := o.fieldValue(path, msg, fields.ByNumber(1), v, unknown)
+		if err != nil {
+			return err
+		}
+		msg.Set(fields.ByNumber(1), value)
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		value, err := scalarField(path, fields.ByNumber(1), v)
+		if err != nil {
+			return err
+		}
+		msg.Set(fields.ByNumber(1), value)
+	case "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.ListValue":
+		return fillStruct(path, msg, v)
+	case "google.protobuf.Any":
+		return o.fillAny(path, msg, v, unknown)
+	default:
+		values, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: %w: cannot set %T as message %s", describePath(path), ErrUnexpectedKind, v, name)
+		}
+		return o.fill(path, msg, values, unknown)
+	}
+	return nil
+}
+
+// fillStruct sets the Struct, Value or ListValue msg from v
+func fillStruct(path string, msg protoreflect.Message, v any) error {
+	value, err := NewValue(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", describePath(path), err)
+	}
+	var src proto.Message = value
+	switch msg.Descriptor().FullName() {
+	case "google.protobuf.Struct":
+		src = value.GetStructValue()
+	case "google.protobuf.ListValue":
+		src = value.GetListValue()
+	}
+	if !src.ProtoReflect().IsValid() {
+		return fmt.Errorf("%s: %w: cannot set %s as %s", describePath(path), ErrUnexpectedKind, KindOf(value), msg.Descriptor().Name())
+	}
+	// dynamic messages are merged by full name like generated ones
+	proto.Merge(msg.Interface(), src)
+	return nil
+}
+
+// fillAny packs the message described by v, a map with its type URL under "@type",
+// into the Any msg
+func (o *messageOptions) fillAny(path string, msg protoreflect.Message, v any, unknown *[]string) error {
+	values, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s: %w: cannot set %T as an Any", describePath(path), ErrUnexpectedKind, v)
+	}
+	url, ok := values["@type"].(string)
+	if !ok {
+		return fmt.Errorf("%s: %w: Any has no \"@type\" string", describePath(path), ErrNotCoercible)
+	}
+	mt, err := o.resolver.FindMessageByURL(url)
+	if err != nil {
+		return fmt.Errorf("%s: resolving %q: %w", describePath(path), url, err)
+	}
+
+	packed := mt.New()
+	fields := maps.Clone(values)
+	delete(fields, "@type")
+	var content any = fields
+	if inner, wrapped := values["value"]; wrapped && len(fields) == 1 && isWellKnown(packed.Descriptor().FullName()) {
+		content = inner
+	}
+	if err := o.fillMessage(path, packed, content, unknown); err != nil {
+		return err
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(packed.Interface())
+	if err != nil {
+		return fmt.Errorf("%s: packing %q: %w", describePath(path), url, err)
+	}
+	anyFields := msg.Descriptor().Fields()
+	msg.Set(anyFields.ByNumber(1), protoreflect.ValueOfString(url))
+	msg.Set(anyFields.ByNumber(2), protoreflect.ValueOfBytes(b))
+	return nil
+}
+
+// isWellKnown reports whether messages of type name are not converted to maps by
+// MessageToMap, except for Struct
+func isWellKnown(name protoreflect.FullName) bool {
+	return name.Parent() == "google.protobuf" && name != "google.protobuf.Struct" && name != "google.protobuf.Empty"
+}
+
+// scalarField coerces v to a value of the field fd, which is not a message
+func scalarField(path string, fd protoreflect.FieldDescriptor, v any) (protoreflect.Value, error) {
+	value, err := scalarValue(fd, v)
+	if err != nil {
+		return protoreflect.Value{}, fmt.Errorf("%s: %w", describePath(path), err)
+	}
+	return value, nil
+}
+
+func scalarValue(fd protoreflect.FieldDescriptor, v any) (protoreflect.Value, error) {
+	if b, ok := v.([]byte); ok {
+		if fd.Kind() != protoreflect.BytesKind {
+			return protoreflect.Value{}, fmt.Errorf("%w: cannot set []byte as a %s field", ErrUnexpectedKind, fd.Kind())
+		}
+		return protoreflect.ValueOfBytes(b), nil
+	}
+	if fd.Kind() == protoreflect.EnumKind {
+		return enumValue(fd.Enum(), v)
+	}
+
+	exact, sv, ok := scalarInput(v)
+	if !ok {
+		return protoreflect.Value{}, fmt.Errorf("%w: cannot set %T as a %s field", ErrUnexpectedKind, v, fd.Kind())
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := ToBool(sv)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.StringKind:
+		s, err := ToString(sv)
+		return protoreflect.ValueOfString(s), err
+	case protoreflect.BytesKind:
+		b, err := BytesFromValue(sv)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("%w: %w", ErrNotCoercible, err)
+		}
+		return protoreflect.ValueOfBytes(b), nil
+	case protoreflect.FloatKind:
+		f, err := ToFloat64(sv)
+		if err == nil && !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+			err = fmt.Errorf("%w: %v overflows a float", ErrNotCoercible, f)
+		}
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := ToFloat64(sv)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := integerInput(exact, sv, math.MinInt32, math.MaxInt32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := integerInput(exact, sv, math.MinInt64, math.MaxInt64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := unsignedInput(exact, sv, math.MaxUint32)
+		return protoreflect.ValueOfUint32(uint32(u)), err
+	default:
+		u, err := unsignedInput(exact, sv, math.MaxUint64)
+		return protoreflect.ValueOfUint64(u), err
+	}
+}
+
+// scalarInput returns v as an int64, uint64 or string when it is an integer or a
+// string, which are coerced exactly, and as a *structpb.Value for the To* functions
+func scalarInput(v any) (exact any, sv *structpb.Value, ok bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return nil, structpb.NewBoolValue(rv.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), structpb.NewNumberValue(float64(rv.Int())), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), structpb.NewNumberValue(float64(rv.Uint())), true
+	case reflect.Float32, reflect.Float64:
+		return nil, structpb.NewNumberValue(rv.Float()), true
+	case reflect.String:
+		return strings.TrimSpace(rv.String()), structpb.NewStringValue(rv.String()), true
+	default:
+		return nil, nil, false
+	}
+}
+
+// integerInput coerces a scalar input to an integer within [lo, hi]
+func integerInput(exact any, sv *structpb.Value, lo, hi int64) (int64, error) {
+	var i int64
+	var err error
+	switch x := exact.(type) {
+	case int64:
+		i = x
+	case uint64:
+		if x > math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %d overflows an int64", ErrNotCoercible, x)
+		}
+		i = int64(x)
+	default:
+		i, err = ToInt64(sv)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if i < lo || i > hi {
+		return 0, fmt.Errorf("%w: %d is out of range", ErrNotCoercible, i)
+	}
+	return i, nil
+}
+
+// unsignedInput coerces a scalar input to an unsigned integer of at most hi
+func unsignedInput(exact any, sv *structpb.Value, hi uint64) (uint64, error) {
+	u, ok := exact.(uint64)
+	if s, isString := exact.(string); isString {
+		parsed, err := strconv.ParseUint(s, 10, 64)
+		u, ok = parsed, err == nil
+	}
+	if !ok {
+		i, err := integerInput(exact, sv, 0, math.MaxInt64)
+		if err != nil {
+			return 0, err
+		}
+		u = uint64(i)
+	}
+	if u > hi {
+		return 0, fmt.Errorf("%w: %d is out of range", ErrNotCoercible, u)
+	}
+	return u, nil
+}
+
+// enumValue coerces v, the name or number of a value of ed, to an enum value. Unknown
+// numbers are accepted for open enums only
+func enumValue(ed protoreflect.EnumDescriptor, v any) (protoreflect.Value, error) {
+	if ed.FullName() == "google.protobuf.NullValue" && v == nil {
+		return protoreflect.ValueOfEnum(0), nil
+	}
+	if name, ok := v.(string); ok {
+		if value := ed.Values().ByName(protoreflect.Name(name)); value != nil {
+			return protoreflect.ValueOfEnum(value.Number()), nil
+		}
+	}
+	exact, sv, ok := scalarInput(v)
+	if _, isBool := sv.GetKind().(*structpb.Value_BoolValue); !ok || isBool {
+		return protoreflect.Value{}, fmt.Errorf("%w: cannot set %T as enum %s", ErrUnexpectedKind, v, ed.FullName())
+	}
+	n, err := integerInput(exact, sv, math.MinInt32, math.MaxInt32)
+	if err != nil {
+		return protoreflect.Value{}, fmt.Errorf("%w: %v is not a value of enum %s", ErrNotCoercible, v, ed.FullName())
+	}
+	number := protoreflect.EnumNumber(n)
+	if ed.Values().ByNumber(number) == nil && ed.IsClosed() {
+		return protoreflect.Value{}, fmt.Errorf("%w: %d is not a value of closed enum %s", ErrNotCoercible, n, ed.FullName())
+	}
+	return protoreflect.ValueOfEnum(number), nil
+}
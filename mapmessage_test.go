@@ -0,0 +1,163 @@
+package protobaggins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestMapToMessage(t *testing.T) {
+	t.Parallel()
+
+	md := hobbitDescriptor(t)
+	born := time.Date(2968, 9, 22, 0, 0, 0, 0, time.UTC)
+
+	t.Run("coerces values", func(t *testing.T) {
+		t.Parallel()
+		frodo := dynamicpb.NewMessage(md)
+		require.NoError(t, MapToMessage(map[string]any{
+			"user_name": "frodo",
+			"age":       "50",
+			"tags":      []string{"ring-bearer"},
+			"scores":    map[string]int{"riddles": 3},
+			"kind":      "KIND_BAGGINS",
+			"arrows":    3.0,
+			"born":      "2968-09-22T00:00:00Z",
+			"nap":       "90m",
+			"extra":     map[string]any{"pipe": "old toby"},
+			"packed":    map[string]any{"@type": "type.googleapis.com/google.protobuf.Int32Value", "value": 111},
+			"nickname":  "mr. underhill",
+			"ring":      "AQ==",
+			"friend":    map[string]any{"userName": "sam", "nap": 30 * time.Minute},
+			"mask":      "a.b,c",
+			"weight":    1.5,
+		}, frodo))
+
+		got, err := MessageToMap(frodo)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"userName": "frodo",
+			"age":      int64(50),
+			"tags":     []any{"ring-bearer"},
+			"scores":   map[string]any{"riddles": int32(3)},
+			"kind":     "KIND_BAGGINS",
+			"arrows":   int32(3),
+			"born":     born,
+			"nap":      90 * time.Minute,
+			"extra":    map[string]any{"pipe": "old toby"},
+			"packed":   map[string]any{"@type": "type.googleapis.com/google.protobuf.Int32Value", "value": int32(111)},
+			"nickname": "mr. underhill",
+			"ring":     []byte{1},
+			"friend":   map[string]any{"userName": "sam", "nap": 30 * time.Minute},
+			"mask":     []string{"a.b", "c"},
+			"weight":   float32(1.5),
+		}, got)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		original := dynamicpb.NewMessage(md)
+		require.NoError(t, MapToMessage(map[string]any{
+			"userName": "frodo",
+			"born":     timestamppb.New(born),
+			"kind":     1,
+			"scores":   map[string]any{"riddles": int64(3)},
+			"extra":    map[string]any{"list": []any{1.0, nil}},
+		}, original))
+
+		m, err := MessageToMap(original)
+		require.NoError(t, err)
+		copied := dynamicpb.NewMessage(md)
+		require.NoError(t, MapToMessage(m, copied))
+		assert.True(t, proto.Equal(original, copied))
+	})
+
+	t.Run("fields not in the map are kept", func(t *testing.T) {
+		t.Parallel()
+		field := &descriptorpb.FieldDescriptorProto{Name: proto.String("id"), Number: proto.Int32(1)}
+		require.NoError(t, MapToMessage(map[string]any{"number": "3", "jsonName": nil}, field))
+		assert.Equal(t, "id", field.GetName())
+		assert.Equal(t, int32(3), field.GetNumber())
+
+		require.NoError(t, MapToMessage(map[string]any{"name": nil}, field))
+		assert.Nil(t, field.Name)
+	})
+
+	t.Run("unknown keys", func(t *testing.T) {
+		t.Parallel()
+		in := map[string]any{"userName": "frodo", "height": 3, "friend": map[string]any{"hair": "curly"}}
+
+		var unknown *UnknownKeysError
+		err := MapToMessage(in, dynamicpb.NewMessage(md))
+		require.ErrorAs(t, err, &unknown)
+		assert.Equal(t, []string{"friend.hair"}, unknown.Paths)
+
+		hobbit := dynamicpb.NewMessage(md)
+		require.NoError(t, MapToMessage(in, hobbit, MessageUnknownKeys(UnknownKeysIgnore)))
+		assert.Equal(t, "frodo", hobbit.Get(md.Fields().ByName("user_name")).String())
+
+		hobbit = dynamicpb.NewMessage(md)
+		err = MapToMessage(in, hobbit, MessageUnknownKeys(UnknownKeysCollect))
+		require.ErrorAs(t, err, &unknown)
+		assert.Equal(t, []string{"friend.hair", "height"}, unknown.Paths)
+		assert.EqualError(t, err, "unknown fields: friend.hair, height")
+		assert.Equal(t, "frodo", hobbit.Get(md.Fields().ByName("user_name")).String())
+	})
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+		v := &structpb.Value{}
+		require.NoError(t, MapToMessage(map[string]any{"nullValue": nil}, v))
+		assert.True(t, proto.Equal(structpb.NewNullValue(), v))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			name string
+			in   map[string]any
+			err  error
+			msg  string
+		}{
+			{"fraction", map[string]any{"age": 42.5}, ErrNotCoercible, "age: "},
+			{"int32 overflow", map[string]any{"arrows": int64(1) << 40}, ErrNotCoercible, "arrows: "},
+			{"unknown enum name", map[string]any{"kind": "KIND_TOOK"}, ErrNotCoercible, "kind: "},
+			{"bool enum", map[string]any{"kind": true}, ErrUnexpectedKind, "kind: "},
+			{"not a message", map[string]any{"friend": "sam"}, ErrUnexpectedKind, "friend: "},
+			{"not a list", map[string]any{"tags": "a"}, ErrUnexpectedKind, "tags: "},
+			{"nested", map[string]any{"friend": map[string]any{"tags": []any{"a", 1.5, nil}}}, ErrUnexpectedKind, "friend.tags[2]: "},
+			{"map value", map[string]any{"scores": map[string]any{"riddles": "many"}}, ErrNotCoercible, "scores.riddles: "},
+			{"timestamp", map[string]any{"born": "yesterday"}, ErrNotCoercible, "born: "},
+			{"any without type", map[string]any{"packed": map[string]any{"value": 1}}, ErrNotCoercible, "packed: "},
+			{"unresolvable any", map[string]any{"packed": map[string]any{"@type": "shire.Unknown"}}, protoregistry.NotFound, "packed: "},
+			{"oneof", map[string]any{"sword": "sting", "arrows": 3}, nil, "sword: oneof weapon is also set as \"arrows\""},
+			{"duplicate", map[string]any{"userName": "a", "user_name": "b"}, nil, "user_name: field user_name is also set as \"userName\""},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+				err := MapToMessage(tt.in, dynamicpb.NewMessage(md))
+				require.Error(t, err)
+				if tt.err != nil {
+					require.ErrorIs(t, err, tt.err)
+				}
+				assert.Contains(t, err.Error(), tt.msg)
+			})
+		}
+
+		err := MapToMessage(map[string]any{"label": 9}, &descriptorpb.FieldDescriptorProto{})
+		require.ErrorIs(t, err, ErrNotCoercible)
+		require.ErrorContains(t, err, "closed enum")
+
+		require.ErrorIs(t, MapToMessage(nil, nil), ErrNilMessage)
+		require.ErrorIs(t, MapToMessage(nil, (*structpb.Struct)(nil)), ErrNilMessage)
+	})
+}
@@ -0,0 +1,126 @@
+package protobaggins
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ListStrategy selects how MergeStructs combines a list in src with a list in dst
+type ListStrategy int
+
+const (
+	// ListReplace replaces the dst list with the src list
+	ListReplace ListStrategy = iota
+	// ListAppend appends the src items to the dst list
+	ListAppend
+	// ListDedupe appends like ListAppend, then drops items equal to an earlier one
+	ListDedupe
+)
+
+// NullStrategy selects how MergeStructs treats a null in src
+type NullStrategy int
+
+const (
+	// NullAssign sets the dst key to null like any other value
+	NullAssign NullStrategy = iota
+	// NullDelete deletes the key from dst, as JSON Merge Patch does
+	NullDelete
+)
+
+// MergeOption configures MergeStructs
+type MergeOption func(*mergeOptions)
+
+type mergeOptions struct {
+	lists ListStrategy
+	nulls NullStrategy
+}
+
+// MergeLists sets how lists present in both Structs are combined, ListReplace by default
+func MergeLists(strategy ListStrategy) MergeOption {
+	return func(o *mergeOptions) {
+		o.lists = strategy
+	}
+}
+
+// MergeNulls sets how nulls in src are applied, NullAssign by default
+func MergeNulls(strategy NullStrategy) MergeOption {
+	return func(o *mergeOptions) {
+		o.nulls = strategy
+	}
+}
+
+// MergeStructs deep merges src into dst: Structs present in both are merged key by key,
+// lists are combined according to MergeLists and any other src value replaces the dst
+// value. dst is modified in place and never shares values with src. A nil dst is left
+// alone
+func MergeStructs(dst, src *structpb.Struct, opts ...MergeOption) {
+	if dst == nil {
+		return
+	}
+	var o mergeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.merge(dst, src)
+}
+
+func (o *mergeOptions) merge(dst, src *structpb.Struct) {
+	if len(src.GetFields()) == 0 {
+		return
+	}
+	if dst.Fields == nil {
+		dst.Fields = make(map[string]*structpb.Value, len(src.GetFields()))
+	}
+
+	for key, value := range src.GetFields() {
+		switch kind := value.GetKind().(type) {
+		case *structpb.Value_NullValue:
+			if o.nulls == NullDelete {
+				delete(dst.Fields, key)
+				continue
+			}
+			dst.Fields[key] = structpb.NewNullValue()
+		case *structpb.Value_StructValue:
+			// merging into an empty Struct, rather than cloning, applies the null
+			// strategy to new keys too
+			target := dst.Fields[key].GetStructValue()
+			if target == nil {
+				target = &structpb.Struct{}
+				dst.Fields[key] = structpb.NewStructValue(target)
+			}
+			o.merge(target, kind.StructValue)
+		case *structpb.Value_ListValue:
+			target := dst.Fields[key].GetListValue()
+			if target == nil || o.lists == ListReplace {
+				dst.Fields[key] = proto.Clone(value).(*structpb.Value)
+				continue
+			}
+			for _, item := range kind.ListValue.GetValues() {
+				target.Values = append(target.Values, proto.Clone(item).(*structpb.Value))
+			}
+			if o.lists == ListDedupe {
+				target.Values = dedupeValues(target.Values)
+			}
+		default:
+			dst.Fields[key] = proto.Clone(value).(*structpb.Value)
+		}
+	}
+}
+
+// dedupeValues drops values equal to an earlier one, keeping the order
+func dedupeValues(values []*structpb.Value) []*structpb.Value {
+	kept := values[:0]
+	for _, v := range values {
+		duplicate := false
+		for _, k := range kept {
+			if proto.Equal(k, v) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,113 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestMergeStructs(t *testing.T) {
+	t.Parallel()
+
+	newStruct := func(t *testing.T, m map[string]any) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+	defaults := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		return newStruct(t, map[string]any{
+			"name":   "shire",
+			"limits": map[string]any{"cpu": 1, "memory": "1Gi"},
+			"tags":   []any{"a", "b"},
+			"region": "eu",
+		})
+	}
+	tenant := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		return newStruct(t, map[string]any{
+			"limits": map[string]any{"cpu": 2, "gpu": nil},
+			"tags":   []any{"b", "c"},
+			"region": nil,
+			"owner":  map[string]any{"name": "frodo", "email": nil},
+		})
+	}
+
+	tests := []struct {
+		name string
+		opts []MergeOption
+		want map[string]any
+	}{
+		{
+			name: "defaults",
+			want: map[string]any{
+				"name":   "shire",
+				"limits": map[string]any{"cpu": 2.0, "memory": "1Gi", "gpu": nil},
+				"tags":   []any{"b", "c"},
+				"region": nil,
+				"owner":  map[string]any{"name": "frodo", "email": nil},
+			},
+		},
+		{
+			name: "append lists and delete nulls",
+			opts: []MergeOption{MergeLists(ListAppend), MergeNulls(NullDelete)},
+			want: map[string]any{
+				"name":   "shire",
+				"limits": map[string]any{"cpu": 2.0, "memory": "1Gi"},
+				"tags":   []any{"a", "b", "b", "c"},
+				"owner":  map[string]any{"name": "frodo"},
+			},
+		},
+		{
+			name: "dedupe lists",
+			opts: []MergeOption{MergeLists(ListDedupe)},
+			want: map[string]any{
+				"name":   "shire",
+				"limits": map[string]any{"cpu": 2.0, "memory": "1Gi", "gpu": nil},
+				"tags":   []any{"a", "b", "c"},
+				"region": nil,
+				"owner":  map[string]any{"name": "frodo", "email": nil},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dst, src := defaults(t), tenant(t)
+			MergeStructs(dst, src, tt.opts...)
+			assert.Equal(t, tt.want, dst.AsMap())
+			assert.Equal(t, tenant(t).AsMap(), src.AsMap(), "src must not change")
+		})
+	}
+
+	t.Run("does not share values with src", func(t *testing.T) {
+		t.Parallel()
+		dst := &structpb.Struct{}
+		src := newStruct(t, map[string]any{"owner": map[string]any{"name": "frodo"}, "tags": []any{"a"}})
+		MergeStructs(dst, src, MergeLists(ListAppend))
+
+		dst.Fields["owner"].GetStructValue().Fields["name"] = structpb.NewStringValue("sam")
+		dst.Fields["tags"].GetListValue().Values[0] = structpb.NewStringValue("b")
+		assert.Equal(t, map[string]any{"owner": map[string]any{"name": "frodo"}, "tags": []any{"a"}}, src.AsMap())
+	})
+
+	t.Run("replaces mismatched kinds", func(t *testing.T) {
+		t.Parallel()
+		dst := newStruct(t, map[string]any{"a": []any{1}, "b": map[string]any{"c": 1}})
+		src := newStruct(t, map[string]any{"a": map[string]any{"x": 1}, "b": "flat"})
+		MergeStructs(dst, src, MergeLists(ListAppend))
+		assert.Equal(t, map[string]any{"a": map[string]any{"x": 1.0}, "b": "flat"}, dst.AsMap())
+	})
+
+	t.Run("nil structs", func(t *testing.T) {
+		t.Parallel()
+		assert.NotPanics(t, func() { MergeStructs(nil, &structpb.Struct{}) })
+
+		dst := newStruct(t, map[string]any{"a": 1})
+		MergeStructs(dst, nil)
+		assert.Equal(t, map[string]any{"a": 1.0}, dst.AsMap())
+	})
+}
@@ -0,0 +1,39 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7386) to target and returns the
+// result, leaving both arguments unchanged: nulls in patch delete keys, Structs merge
+// recursively and any other value, lists included, replaces the target value.
+// A nil target is treated as empty. Patches holding values without a kind fail with
+// ErrUnexpectedKind
+func ApplyMergePatch(target, patch *structpb.Struct) (*structpb.Struct, error) {
+	var unset []string
+	walkStruct("", patch, func(path string, v *structpb.Value) {
+		if KindOf(v) == KindUnset {
+			unset = append(unset, path)
+		}
+	})
+	if len(unset) > 0 {
+		slices.Sort(unset)
+		errs := make([]error, len(unset))
+		for i, path := range unset {
+			errs[i] = fmt.Errorf("%s: %w: value has no kind", path, ErrUnexpectedKind)
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	result := &structpb.Struct{}
+	if target != nil {
+		result = proto.Clone(target).(*structpb.Struct)
+	}
+	MergeStructs(result, patch, MergeNulls(NullDelete))
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	t.Parallel()
+
+	parse := func(t *testing.T, data string) *structpb.Struct {
+		t.Helper()
+		s := &structpb.Struct{}
+		require.NoError(t, protojson.Unmarshal([]byte(data), s))
+		return s
+	}
+
+	// the object cases from RFC 7386 appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			t.Parallel()
+			target := parse(t, tt.target)
+			got, err := ApplyMergePatch(target, parse(t, tt.patch))
+			require.NoError(t, err)
+			assert.Equal(t, parse(t, tt.want).AsMap(), got.AsMap())
+			assert.Equal(t, parse(t, tt.target).AsMap(), target.AsMap(), "target must not change")
+		})
+	}
+
+	t.Run("nil target", func(t *testing.T) {
+		t.Parallel()
+		got, err := ApplyMergePatch(nil, parse(t, `{"a":1,"b":null}`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": 1.0}, got.AsMap())
+	})
+
+	t.Run("rejects values without a kind", func(t *testing.T) {
+		t.Parallel()
+		patch := parse(t, `{"a":{"b":1}}`)
+		patch.Fields["a"].GetStructValue().Fields["c"] = &structpb.Value{}
+		patch.Fields["d"] = &structpb.Value{}
+		_, err := ApplyMergePatch(&structpb.Struct{}, patch)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		assert.Equal(t, "a.c: unexpected value kind: value has no kind\nd: unexpected value kind: value has no kind", err.Error())
+	})
+}
@@ -0,0 +1,284 @@
+package protobaggins
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MessageOption configures MessageToMap and MapToMessage
+type MessageOption func(*messageOptions)
+
+type messageOptions struct {
+	protoNames      bool
+	emitUnpopulated bool
+	enumNumbers     bool
+	resolver        protoregistry.MessageTypeResolver
+	unknownKeys     UnknownKeys
+}
+
+// MessageProtoNames keys fields by their name in the .proto file, e.g. "user_id",
+// instead of their JSON name, e.g. "userId"
+func MessageProtoNames() MessageOption {
+	return func(o *messageOptions) {
+		o.protoNames = true
+	}
+}
+
+// MessageEmitUnpopulated includes fields that are not set, with their default value,
+// an empty list or map, or nil for messages. Members of oneofs and optional fields
+// are still left out
+func MessageEmitUnpopulated() MessageOption {
+	return func(o *messageOptions) {
+		o.emitUnpopulated = true
+	}
+}
+
+// MessageEnumNumbers converts enum values to their int32 number instead of their name
+func MessageEnumNumbers() MessageOption {
+	return func(o *messageOptions) {
+		o.enumNumbers = true
+	}
+}
+
+// MessageResolver resolves the types of the messages packed in Any fields with r,
+// instead of protoregistry.GlobalTypes
+func MessageResolver(r protoregistry.MessageTypeResolver) MessageOption {
+	return func(o *messageOptions) {
+		o.resolver = r
+	}
+}
+
+// MessageToMap converts any message to a map keyed by field names, walking it with
+// protoreflect instead of marshaling it to JSON and back. Only populated fields are
+// included, see MessageEmitUnpopulated, and values have natural Go types:
+//   - scalars keep their type, e.g. int64 and uint32, bytes become []byte
+//   - enums become their name as a string, google.protobuf.NullValue becomes nil
+//   - repeated fields become []any and maps become map[string]any, with keys formatted
+//     in decimal or as "true" and "false"
+//   - Timestamp becomes time.Time, Duration time.Duration, FieldMask []string and
+//     wrappers their value; Struct, Value and ListValue become what AsInterface gives
+//   - Any becomes the map of the packed message with its type URL under "@type", or
+//     its value under "value" if it is a well-known type that is not a map
+//
+// Extensions are keyed by their full name in brackets, e.g. "[pkg.ext]". Fails with
+// ErrNilMessage for a nil message, and when the type of an Any cannot be resolved
+func MessageToMap(m proto.Message, opts ...MessageOption) (map[string]any, error) {
+	if m == nil || !m.ProtoReflect().IsValid() {
+		return nil, fmt.Errorf("%w: cannot convert a nil message", ErrNilMessage)
+	}
+	o := messageOptions{resolver: protoregistry.GlobalTypes}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.fields("", m.ProtoReflect())
+}
+
+// fields converts the fields of m, a message at path
+func (o *messageOptions) fields(path string, m protoreflect.Message) (map[string]any, error) {
+	result := make(map[string]any)
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := o.fieldName(fd)
+		result[name], err = o.field(joinKey(path, name), fd, v)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if o.emitUnpopulated {
+		fds := m.Descriptor().Fields()
+		for i := range fds.Len() {
+			fd := fds.Get(i)
+			name := o.fieldName(fd)
+			if _, set := result[name]; set || fd.ContainingOneof() != nil {
+				continue
+			}
+			switch {
+			case fd.IsList():
+				result[name] = []any{}
+			case fd.IsMap():
+				result[name] = map[string]any{}
+			case fd.Message() != nil:
+				result[name] = nil
+			default:
+				if result[name], err = o.singular(joinKey(path, name), fd, fd.Default()); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func (o *messageOptions) fieldName(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsExtension():
+		return "[" + string(fd.FullName()) + "]"
+	case o.protoNames:
+		return string(fd.Name())
+	default:
+		return fd.JSONName()
+	}
+}
+
+// field converts the value v of the field fd at path
+func (o *messageOptions) field(path string, fd protoreflect.FieldDescriptor, v protoreflect.Value) (any, error) {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		items := make([]any, list.Len())
+		for i := range list.Len() {
+			item, err := o.singular(joinIndex(path, i), fd, list.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	case fd.IsMap():
+		entries := make(map[string]any, v.Map().Len())
+		var err error
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			key := k.String()
+			entries[key], err = o.singular(joinKey(path, key), fd.MapValue(), v)
+			return err == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return entries, nil
+	default:
+		return o.singular(path, fd, v)
+	}
+}
+
+// singular converts a value of the type of fd that is not a list or map
+func (o *messageOptions) singular(path string, fd protoreflect.FieldDescriptor, v protoreflect.Value) (any, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return v.Bool(), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return int32(v.Int()), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int(), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return uint32(v.Uint()), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint(), nil
+	case protoreflect.FloatKind:
+		return float32(v.Float()), nil
+	case protoreflect.DoubleKind:
+		return v.Float(), nil
+	case protoreflect.StringKind:
+		return v.String(), nil
+	case protoreflect.BytesKind:
+		return bytes.Clone(v.Bytes()), nil
+	case protoreflect.EnumKind:
+		return o.enum(fd.Enum(), v.Enum()), nil
+	default:
+		return o.message(path, v.Message())
+	}
+}
+
+func (o *messageOptions) enum(ed protoreflect.EnumDescriptor, n protoreflect.EnumNumber) any {
+	if ed.FullName() == "google.protobuf.NullValue" {
+		return nil
+	}
+	if o.enumNumbers {
+		return int32(n)
+	}
+	if value := ed.Values().ByNumber(n); value != nil {
+		return string(value.Name())
+	}
+	// unknown values of open enums have no name
+	return int32(n)
+}
+
+// message converts m, handling the well-known types
+func (o *messageOptions) message(path string, m protoreflect.Message) (any, error) {
+	fields := m.Descriptor().Fields()
+	switch name := m.Descriptor().FullName(); name {
+	case "google.protobuf.Timestamp":
+		seconds, nanos := m.Get(fields.ByNumber(1)).Int(), m.Get(fields.ByNumber(2)).Int()
+		return time.Unix(seconds, nanos).UTC(), nil
+	case "google.protobuf.Duration":
+		seconds, nanos := m.Get(fields.ByNumber(1)).Int(), m.Get(fields.ByNumber(2)).Int()
+		return time.Duration(seconds)*time.Second + time.Duration(nanos), nil
+	case "google.protobuf.FieldMask":
+		list := m.Get(fields.ByNumber(1)).List()
+		paths := make([]string, list.Len())
+		for i := range list.Len() {
+			paths[i] = list.Get(i).String()
+		}
+		return paths, nil
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		fd := fields.ByNumber(1)
+		return o.singular(path, fd, m.Get(fd))
+	case "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.ListValue":
+		return structInterface(path, m)
+	case "google.protobuf.Any":
+		return o.anyMessage(path, m)
+	default:
+		return o.fields(path, m)
+	}
+}
+
+// structInterface converts a Struct, Value or ListValue
+func structInterface(path string, m protoreflect.Message) (any, error) {
+	switch msg := m.Interface().(type) {
+	case *structpb.Struct:
+		return msg.AsMap(), nil
+	case *structpb.ListValue:
+		return msg.AsSlice(), nil
+	case *structpb.Value:
+		return msg.AsInterface(), nil
+	}
+
+	// dynamic messages are converted through the generated type
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(m.Descriptor().FullName())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", describePath(path), err)
+	}
+	b, err := proto.Marshal(m.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", describePath(path), err)
+	}
+	generated := mt.New()
+	if err := proto.Unmarshal(b, generated.Interface()); err != nil {
+		return nil, fmt.Errorf("%s: %w", describePath(path), err)
+	}
+	return structInterface(path, generated)
+}
+
+// anyMessage converts the message packed in the Any m
+func (o *messageOptions) anyMessage(path string, m protoreflect.Message) (any, error) {
+	fields := m.Descriptor().Fields()
+	url := m.Get(fields.ByNumber(1)).String()
+	mt, err := o.resolver.FindMessageByURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("%s: resolving %q: %w", describePath(path), url, err)
+	}
+	packed := mt.New()
+	if err := proto.Unmarshal(m.Get(fields.ByNumber(2)).Bytes(), packed.Interface()); err != nil {
+		return nil, fmt.Errorf("%s: unpacking %q: %w", describePath(path), url, err)
+	}
+	v, err := o.message(path, packed)
+	if err != nil {
+		return nil, err
+	}
+	if fields, ok := v.(map[string]any); ok && packed.Descriptor().FullName() != "google.protobuf.Struct" {
+		fields["@type"] = url
+		return fields, nil
+	}
+	return map[string]any{"@type": url, "value": v}, nil
+}
@@ -0,0 +1,217 @@
+package protobaggins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// hobbitDescriptor describes a message using every kind of field MessageToMap handles
+func hobbitDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(KeyCaseCamel.Convert(name)),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		str   = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		msg   = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+		enum  = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+		int32 = descriptorpb.FieldDescriptorProto_TYPE_INT32
+	)
+
+	fields := []*descriptorpb.FieldDescriptorProto{
+		field("user_name", 1, str, ""),
+		field("age", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+		field("tags", 3, str, ""),
+		field("scores", 4, msg, ".shire.Hobbit.ScoresEntry"),
+		field("kind", 5, enum, ".shire.Kind"),
+		field("sword", 6, str, ""),
+		field("arrows", 7, int32, ""),
+		field("born", 8, msg, ".google.protobuf.Timestamp"),
+		field("nap", 9, msg, ".google.protobuf.Duration"),
+		field("extra", 10, msg, ".google.protobuf.Struct"),
+		field("packed", 11, msg, ".google.protobuf.Any"),
+		field("nickname", 12, msg, ".google.protobuf.StringValue"),
+		field("ring", 13, descriptorpb.FieldDescriptorProto_TYPE_BYTES, ""),
+		field("friend", 14, msg, ".shire.Hobbit"),
+		field("mask", 15, msg, ".google.protobuf.FieldMask"),
+		field("weight", 16, descriptorpb.FieldDescriptorProto_TYPE_FLOAT, ""),
+		field("nothing", 17, enum, ".google.protobuf.NullValue"),
+	}
+	fields[2].Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	fields[3].Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	fields[5].OneofIndex = proto.Int32(0)
+	fields[6].OneofIndex = proto.Int32(0)
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shire/hobbit.proto"),
+		Package: proto.String("shire"),
+		Syntax:  proto.String("proto3"),
+		Dependency: []string{
+			"google/protobuf/any.proto", "google/protobuf/duration.proto", "google/protobuf/field_mask.proto",
+			"google/protobuf/struct.proto", "google/protobuf/timestamp.proto", "google/protobuf/wrappers.proto",
+		},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Kind"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("KIND_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("KIND_BAGGINS"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:      proto.String("Hobbit"),
+			Field:     fields,
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("weapon")}},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("ScoresEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("key", 1, str, ""),
+					field("value", 2, int32, ""),
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	return fd.Messages().Get(0)
+}
+
+func TestMessageToMap(t *testing.T) {
+	t.Parallel()
+
+	md := hobbitDescriptor(t)
+	set := func(m *dynamicpb.Message, name string, v protoreflect.Value) {
+		m.Set(md.Fields().ByName(protoreflect.Name(name)), v)
+	}
+	msgValue := func(m proto.Message) protoreflect.Value {
+		return protoreflect.ValueOfMessage(m.ProtoReflect())
+	}
+
+	born := time.Date(2968, 9, 22, 0, 0, 0, 0, time.UTC)
+	extra, err := structpb.NewStruct(map[string]any{"pipe": "old toby"})
+	require.NoError(t, err)
+	packed, err := anypb.New(wrapperspb.Int32(111))
+	require.NoError(t, err)
+
+	frodo := dynamicpb.NewMessage(md)
+	set(frodo, "user_name", protoreflect.ValueOfString("frodo"))
+	set(frodo, "age", protoreflect.ValueOfInt64(50))
+	tags := frodo.Mutable(md.Fields().ByName("tags")).List()
+	tags.Append(protoreflect.ValueOfString("ring-bearer"))
+	scores := frodo.Mutable(md.Fields().ByName("scores")).Map()
+	scores.Set(protoreflect.ValueOfString("riddles").MapKey(), protoreflect.ValueOfInt32(3))
+	set(frodo, "kind", protoreflect.ValueOfEnum(1))
+	set(frodo, "sword", protoreflect.ValueOfString("sting"))
+	set(frodo, "born", msgValue(timestamppb.New(born)))
+	set(frodo, "nap", msgValue(durationpb.New(90*time.Minute)))
+	set(frodo, "extra", msgValue(extra))
+	set(frodo, "packed", msgValue(packed))
+	set(frodo, "nickname", msgValue(wrapperspb.String("mr. underhill")))
+	set(frodo, "ring", protoreflect.ValueOfBytes([]byte{1}))
+	set(frodo, "mask", msgValue(&fieldmaskpb.FieldMask{Paths: []string{"a.b", "c"}}))
+	set(frodo, "weight", protoreflect.ValueOfFloat32(1.5))
+	friend := dynamicpb.NewMessage(md)
+	set(friend, "user_name", protoreflect.ValueOfString("sam"))
+	set(friend, "arrows", protoreflect.ValueOfInt32(0))
+	set(frodo, "friend", protoreflect.ValueOfMessage(friend))
+
+	t.Run("every kind of field", func(t *testing.T) {
+		t.Parallel()
+		got, err := MessageToMap(frodo)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"userName": "frodo",
+			"age":      int64(50),
+			"tags":     []any{"ring-bearer"},
+			"scores":   map[string]any{"riddles": int32(3)},
+			"kind":     "KIND_BAGGINS",
+			"sword":    "sting",
+			"born":     born,
+			"nap":      90 * time.Minute,
+			"extra":    map[string]any{"pipe": "old toby"},
+			"packed":   map[string]any{"@type": "type.googleapis.com/google.protobuf.Int32Value", "value": int32(111)},
+			"nickname": "mr. underhill",
+			"ring":     []byte{1},
+			"mask":     []string{"a.b", "c"},
+			"weight":   float32(1.5),
+			"friend":   map[string]any{"userName": "sam", "arrows": int32(0)},
+		}, got)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		got, err := MessageToMap(friend, MessageProtoNames(), MessageEmitUnpopulated(), MessageEnumNumbers())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"user_name": "sam",
+			"age":       int64(0),
+			"tags":      []any{},
+			"scores":    map[string]any{},
+			"kind":      int32(0),
+			"arrows":    int32(0),
+			"born":      nil,
+			"nap":       nil,
+			"extra":     nil,
+			"packed":    nil,
+			"nickname":  nil,
+			"ring":      []byte(nil),
+			"friend":    nil,
+			"mask":      nil,
+			"weight":    float32(0),
+			"nothing":   nil,
+		}, got)
+	})
+
+	t.Run("generated messages", func(t *testing.T) {
+		t.Parallel()
+		got, err := MessageToMap(&descriptorpb.FieldDescriptorProto{
+			Name:  proto.String("id"),
+			Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		}, MessageProtoNames())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"name": "id", "label": "LABEL_REPEATED"}, got)
+
+		got, err = MessageToMap(extra)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"fields": map[string]any{"pipe": "old toby"}}, got)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := MessageToMap(nil)
+		require.ErrorIs(t, err, ErrNilMessage)
+		_, err = MessageToMap((*structpb.Struct)(nil))
+		require.ErrorIs(t, err, ErrNilMessage)
+
+		broken := dynamicpb.NewMessage(md)
+		set(broken, "packed", msgValue(&anypb.Any{TypeUrl: "type.googleapis.com/shire.Unknown"}))
+		_, err = MessageToMap(broken)
+		require.ErrorContains(t, err, `packed: resolving "type.googleapis.com/shire.Unknown"`)
+		require.ErrorIs(t, err, protoregistry.NotFound)
+	})
+}
@@ -0,0 +1,487 @@
+// Package msgpack converts between structpb values and MessagePack without third
+// party dependencies.
+//
+// Binary values decode to the tagged {"@bytes": "<base64>"} form of
+// protobaggins.BytesToValue, which encodes back to bin, so binary data survives a round
+// trip. Whole numbers within ±2^53 encode as integers of the smallest width, and
+// integers decode exactly or not at all.
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/internal/conv"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrMalformed is returned for input that is not well-formed MessagePack
+var ErrMalformed = errors.New("malformed MessagePack")
+
+// MaxDepth is the nesting limit of Unmarshal, the same as protojson's
+const MaxDepth = protobaggins.DefaultJSONMaxDepth
+
+// extTimestamp is the extension type of the predefined timestamp
+const extTimestamp = -1
+
+// Option configures Unmarshal and UnmarshalStruct
+type Option func(*options)
+
+type options struct {
+	bigIntegersAsStrings bool
+}
+
+// BigIntegersAsStrings decodes integers beyond ±2^53, which a float64 cannot hold
+// exactly, as decimal strings. Without it they fail with protobaggins.ErrNotCoercible
+func BigIntegersAsStrings() Option {
+	return func(o *options) {
+		o.bigIntegersAsStrings = true
+	}
+}
+
+// Marshal encodes v as MessagePack. Map keys are sorted, so equal values always give
+// the same bytes. A nil Value encodes as nil
+func Marshal(v *structpb.Value) []byte {
+	return appendValue(nil, v)
+}
+
+// MarshalStruct encodes s as a MessagePack map. A nil Struct encodes as an empty map
+func MarshalStruct(s *structpb.Struct) []byte {
+	return appendStruct(nil, s)
+}
+
+// appendLength writes the header of a str, bin, array or map, using the fix form when
+// fixMax allows it
+func appendLength(b []byte, n int, fix byte, fixMax int, code8, code16, code32 byte) []byte {
+	switch {
+	case n <= fixMax:
+		return append(b, fix|byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		return append(b, code8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, code32), uint32(n))
+	}
+}
+
+func appendValue(b []byte, v *structpb.Value) []byte {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return appendStruct(b, kind.StructValue)
+	case *structpb.Value_ListValue:
+		values := kind.ListValue.GetValues()
+		b = appendLength(b, len(values), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, item := range values {
+			b = appendValue(b, item)
+		}
+		return b
+	case *structpb.Value_StringValue:
+		return appendString(b, kind.StringValue)
+	case *structpb.Value_NumberValue:
+		return appendNumber(b, kind.NumberValue)
+	case *structpb.Value_BoolValue:
+		if kind.BoolValue {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	default:
+		return append(b, 0xc0)
+	}
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendLength(b, len(s), 0xa0, 31, 0xd9, 0xda, 0xdb)
+	return append(b, s...)
+}
+
+func appendStruct(b []byte, s *structpb.Struct) []byte {
+	fields := s.GetFields()
+	if len(fields) == 1 {
+		if raw, err := protobaggins.BytesFromValue(structpb.NewStructValue(s)); err == nil {
+			b = appendLength(b, len(raw), 0, -1, 0xc4, 0xc5, 0xc6)
+			return append(b, raw...)
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	b = appendLength(b, len(keys), 0x80, 15, 0, 0xde, 0xdf)
+	for _, k := range keys {
+		b = appendString(b, k)
+		b = appendValue(b, fields[k])
+	}
+	return b
+}
+
+// appendNumber writes whole numbers as integers and others as the shortest float that
+// holds them exactly
+func appendNumber(b []byte, f float64) []byte {
+	switch {
+	case f == math.Trunc(f) && math.Abs(f) <= conv.MaxSafeInteger && !(f == 0 && math.Signbit(f)):
+		return appendInt(b, int64(f))
+	case float64(float32(f)) == f || math.IsNaN(f):
+		return binary.BigEndian.AppendUint32(append(b, 0xca), math.Float32bits(float32(f)))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f))
+	}
+}
+
+func appendInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n <= math.MaxInt8:
+		return append(b, byte(n))
+	case n >= -32 && n < 0:
+		return append(b, byte(n))
+	case n > 0 && n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n > 0 && n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(n))
+	case n > 0 && n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(n))
+	case n > 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(n))
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+	}
+}
+
+// Unmarshal decodes a single MessagePack object into a *structpb.Value. Beyond the JSON
+// data model:
+//   - bin values decode to tagged bytes, see protobaggins.BytesToValue
+//   - integer map keys are stringified, other non-string keys are rejected
+//   - timestamp extensions decode as RFC 3339 strings, other extensions are rejected
+//
+// Duplicate map keys, invalid UTF-8 strings and trailing data are rejected
+func Unmarshal(data []byte, opts ...Option) (*structpb.Value, error) {
+	d := decoder{data: data}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, d.errorf("unexpected data after the top-level object")
+	}
+	return v, nil
+}
+
+// UnmarshalStruct decodes a MessagePack map into a *structpb.Struct, see Unmarshal.
+// Other top-level objects fail with protobaggins.ErrUnexpectedKind
+func UnmarshalStruct(data []byte, opts ...Option) (*structpb.Struct, error) {
+	v, err := Unmarshal(data, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.GetKind().(*structpb.Value_StructValue)
+	if !ok {
+		return nil, fmt.Errorf("%w: top level must be a map, got %s", protobaggins.ErrUnexpectedKind, protobaggins.KindOf(v))
+	}
+	return s.StructValue, nil
+}
+
+type decoder struct {
+	data  []byte
+	pos   int
+	depth int
+	opts  options
+}
+
+func (d *decoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrMalformed, d.pos, fmt.Sprintf(format, args...))
+}
+
+// next consumes n bytes
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, d.errorf("unexpected end of input")
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes
+func (d *decoder) uint(size int) (uint64, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+// length reads a str, bin, array or map length of size bytes
+func (d *decoder) length(size int) (int, error) {
+	n, err := d.uint(size)
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func (d *decoder) value() (*structpb.Value, error) {
+	if d.pos >= len(d.data) {
+		return nil, d.errorf("unexpected end of input")
+	}
+	start := d.pos
+	c := d.data[d.pos]
+	d.pos++
+
+	switch {
+	case c <= 0x7f:
+		return structpb.NewNumberValue(float64(c)), nil
+	case c >= 0xe0:
+		return structpb.NewNumberValue(float64(int8(c))), nil
+	case c <= 0x8f:
+		return d.object(int(c & 0x0f))
+	case c <= 0x9f:
+		return d.array(int(c & 0x0f))
+	case c <= 0xbf:
+		return d.str(start, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return structpb.NewNullValue(), nil
+	case 0xc2:
+		return structpb.NewBoolValue(false), nil
+	case 0xc3:
+		return structpb.NewBoolValue(true), nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return protobaggins.BytesToValue(append([]byte{}, raw...)), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(start, n)
+	case 0xca:
+		n, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewNumberValue(float64(math.Float32frombits(uint32(n)))), nil
+	case 0xcb:
+		n, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewNumberValue(math.Float64frombits(n)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > conv.MaxSafeInteger {
+			return d.bigInteger(start, strconv.FormatUint(n, 10))
+		}
+		return structpb.NewNumberValue(float64(n)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// sign-extend from the encoded width
+		i := int64(n<<(64-8*size)) >> (64 - 8*size)
+		if i > conv.MaxSafeInteger || i < -conv.MaxSafeInteger {
+			return d.bigInteger(start, strconv.FormatInt(i, 10))
+		}
+		return structpb.NewNumberValue(float64(i)), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(start, 1<<(c-0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(start, n)
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.object(n)
+	default:
+		d.pos = start
+		return nil, d.errorf("reserved type byte 0x%02x", c)
+	}
+}
+
+func (d *decoder) bigInteger(start int, n string) (*structpb.Value, error) {
+	if d.opts.bigIntegersAsStrings {
+		return structpb.NewStringValue(n), nil
+	}
+	return nil, fmt.Errorf("%w: integer %s at offset %d is beyond ±2^53, the safe integer range of numbers",
+		protobaggins.ErrNotCoercible, n, start)
+}
+
+func (d *decoder) str(start, n int) (*structpb.Value, error) {
+	raw, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(raw) {
+		d.pos = start
+		return nil, d.errorf("invalid UTF-8 in string")
+	}
+	return structpb.NewStringValue(string(raw)), nil
+}
+
+func (d *decoder) enter() error {
+	d.depth++
+	if d.depth > MaxDepth {
+		return fmt.Errorf("%w of %d at offset %d", protobaggins.ErrMaxDepth, MaxDepth, d.pos)
+	}
+	return nil
+}
+
+func (d *decoder) array(n int) (*structpb.Value, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+
+	// every element takes at least one byte, which bounds the allocation
+	if n > len(d.data)-d.pos {
+		return nil, d.errorf("array length %d exceeds the remaining input", n)
+	}
+	values := make([]*structpb.Value, n)
+	for i := range values {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+}
+
+func (d *decoder) object(n int) (*structpb.Value, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+
+	if n > (len(d.data)-d.pos)/2 {
+		return nil, d.errorf("map length %d exceeds the remaining input", n)
+	}
+	fields := make(map[string]*structpb.Value, n)
+	for range n {
+		keyStart := d.pos
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := fields[key]; dup {
+			d.pos = keyStart
+			return nil, d.errorf("duplicate key %q", key)
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = v
+	}
+	return structpb.NewStructValue(&structpb.Struct{Fields: fields}), nil
+}
+
+// key reads a map key, stringifying integers
+func (d *decoder) key() (string, error) {
+	start := d.pos
+	// big integers are fine as keys, since they are not converted to numbers
+	opts := d.opts
+	d.opts.bigIntegersAsStrings = true
+	v, err := d.value()
+	d.opts = opts
+	if err != nil {
+		return "", err
+	}
+
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return kind.StringValue, nil
+	case *structpb.Value_NumberValue:
+		if c := d.data[start]; c <= 0x7f || c >= 0xcc && c <= 0xd3 || c >= 0xe0 {
+			return strconv.FormatFloat(kind.NumberValue, 'f', -1, 64), nil
+		}
+	}
+	d.pos = start
+	return "", fmt.Errorf("%w: map key at offset %d must be a string or an integer",
+		protobaggins.ErrUnexpectedKind, start)
+}
+
+// ext decodes an extension with a payload of n bytes, whose type byte is next
+func (d *decoder) ext(start, n int) (*structpb.Value, error) {
+	typ, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ[0]) != extTimestamp {
+		d.pos = start
+		return nil, fmt.Errorf("%w: unsupported extension type %d at offset %d",
+			protobaggins.ErrUnexpectedKind, int8(typ[0]), start)
+	}
+
+	var sec int64
+	var nsec uint32
+	switch n {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(payload))
+	case 8:
+		v := binary.BigEndian.Uint64(payload)
+		sec, nsec = int64(v&(1<<34-1)), uint32(v>>34)
+	case 12:
+		nsec = binary.BigEndian.Uint32(payload)
+		sec = int64(binary.BigEndian.Uint64(payload[4:]))
+	default:
+		d.pos = start
+		return nil, d.errorf("timestamp extension of %d bytes", n)
+	}
+	if nsec >= 1e9 {
+		d.pos = start
+		return nil, d.errorf("timestamp nanoseconds %d out of range", nsec)
+	}
+	return structpb.NewStringValue(time.Unix(sec, int64(nsec)).UTC().Format(time.RFC3339Nano)), nil
+}
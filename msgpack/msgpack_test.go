@@ -0,0 +1,192 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value *structpb.Value
+		want  string
+	}{
+		{"positive fixint", structpb.NewNumberValue(127), "7f"},
+		{"negative fixint", structpb.NewNumberValue(-32), "e0"},
+		{"uint8", structpb.NewNumberValue(200), "ccc8"},
+		{"uint16", structpb.NewNumberValue(1000), "cd03e8"},
+		{"uint32", structpb.NewNumberValue(1 << 31), "ce80000000"},
+		{"uint64", structpb.NewNumberValue(1 << 53), "cf0020000000000000"},
+		{"int8", structpb.NewNumberValue(-33), "d0df"},
+		{"int16", structpb.NewNumberValue(-1000), "d1fc18"},
+		{"int32", structpb.NewNumberValue(-1 << 31), "d280000000"},
+		{"int64", structpb.NewNumberValue(-1 << 53), "d3ffe0000000000000"},
+		{"negative zero", structpb.NewNumberValue(math.Copysign(0, -1)), "ca80000000"},
+		{"float32", structpb.NewNumberValue(0.5), "ca3f000000"},
+		{"float64", structpb.NewNumberValue(1.1), "cb3ff199999999999a"},
+		{"bools", structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			structpb.NewBoolValue(false), structpb.NewBoolValue(true)}}), "92c2c3"},
+		{"nil", nil, "c0"},
+		{"fixstr", structpb.NewStringValue("ü"), "a2c3bc"},
+		{"str8", structpb.NewStringValue(strings.Repeat("a", 32)), "d920" + strings.Repeat("61", 32)},
+		{"bin", protobaggins.BytesToValue([]byte{1, 2}), "c4020102"},
+		{"map", structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"b": structpb.NewNumberValue(2), "a": structpb.NewNumberValue(1),
+		}}), "82a16101a16202"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, hex.EncodeToString(Marshal(tt.value)))
+		})
+	}
+
+	t.Run("long list", func(t *testing.T) {
+		t.Parallel()
+		values := make([]*structpb.Value, 16)
+		for i := range values {
+			values[i] = structpb.NewNullValue()
+		}
+		got := Marshal(structpb.NewListValue(&structpb.ListValue{Values: values}))
+		assert.Equal(t, "dc0010", hex.EncodeToString(got[:3]))
+	})
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, []byte{0x80}, MarshalStruct(nil))
+	})
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  *structpb.Value
+	}{
+		{"negative fixint", "ff", structpb.NewNumberValue(-1)},
+		{"uint16", "cd03e8", structpb.NewNumberValue(1000)},
+		{"int32", "d2fffffc18", structpb.NewNumberValue(-1000)},
+		{"float32", "ca3f000000", structpb.NewNumberValue(0.5)},
+		{"str16", "da0002c3bc", structpb.NewStringValue("ü")},
+		{"bin16", "c500020102", protobaggins.BytesToValue([]byte{1, 2})},
+		{"empty bin", "c400", protobaggins.BytesToValue([]byte{})},
+		{"map32", "df00000001a161c0", structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"a": structpb.NewNullValue(),
+		}})},
+		{"integer keys", "82010203d0fe", structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"1": structpb.NewNumberValue(2), "3": structpb.NewNumberValue(-2),
+		}})},
+		{"big integer key", "81cfffffffffffffffffc3", structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"18446744073709551615": structpb.NewBoolValue(true),
+		}})},
+		{"timestamp32", "d6ff5149e820", structpb.NewStringValue("2013-03-20T16:47:28Z")},
+		{"timestamp64", "d7ff773594005149e820", structpb.NewStringValue("2013-03-20T16:47:28.5Z")},
+		{"timestamp96", "c70cff1dcd6500ffffffffffffffff", structpb.NewStringValue("1969-12-31T23:59:59.5Z")},
+		{"big integer", "cfffffffffffffffff", structpb.NewStringValue("18446744073709551615")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Unmarshal(mustHex(t, tt.input), BigIntegersAsStrings())
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(tt.want, got), "got %v", got)
+		})
+	}
+
+	t.Run("big integers", func(t *testing.T) {
+		t.Parallel()
+		_, err := Unmarshal(mustHex(t, "cf0020000000000001"))
+		require.ErrorIs(t, err, protobaggins.ErrNotCoercible)
+		_, err = Unmarshal(mustHex(t, "d38000000000000000"))
+		require.ErrorIs(t, err, protobaggins.ErrNotCoercible)
+		assert.EqualError(t, err, "value not coercible: integer -9223372036854775808 at offset 0 is beyond ±2^53, the safe integer range of numbers")
+	})
+
+	t.Run("malformed input", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{
+			"",               // empty
+			"cd03",           // short integer
+			"a2c3",           // short string
+			"dd7fffffff",     // huge length
+			"82a161c0",       // short map
+			"c1",             // reserved type byte
+			"a1ff",           // invalid UTF-8
+			"82a16101a16102", // duplicate key
+			"d6ff00000000ff", // trailing data
+			"d5ff0000",       // bad timestamp size
+		} {
+			_, err := Unmarshal(mustHex(t, input))
+			require.ErrorIs(t, err, ErrMalformed, input)
+		}
+	})
+
+	t.Run("unsupported objects", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"81c001", "81c40001", "81ca3f00000001", "d40100"} {
+			_, err := Unmarshal(mustHex(t, input))
+			require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind, input)
+		}
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		t.Parallel()
+		deep := make([]byte, MaxDepth+1)
+		for i := range deep {
+			deep[i] = 0x91
+		}
+		_, err := Unmarshal(append(deep, 0x00))
+		require.ErrorIs(t, err, protobaggins.ErrMaxDepth)
+	})
+}
+
+func TestUnmarshalStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trips", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"name":  "frodo",
+			"age":   50,
+			"ratio": 0.25,
+			"big":   1e300,
+			"ring":  true,
+			"home":  nil,
+			"past":  []any{map[string]any{"street": "bagshot row"}, -7, "x"},
+			"empty": map[string]any{},
+			"long":  strings.Repeat("x", 70000),
+		})
+		require.NoError(t, err)
+		s.Fields["blob"] = protobaggins.BytesToValue([]byte{0, 0xff})
+
+		got, err := UnmarshalStruct(MarshalStruct(s))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(s, got), "got %v", got)
+	})
+
+	t.Run("rejects non-maps", func(t *testing.T) {
+		t.Parallel()
+		_, err := UnmarshalStruct([]byte{0x90})
+		require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind)
+		assert.Contains(t, err.Error(), "got list")
+	})
+}
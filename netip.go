@@ -0,0 +1,58 @@
+package protobaggins
+
+import (
+	"fmt"
+	"net/netip"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// AddrToValue converts addr to its text form, such as "10.0.0.1" or "::1"
+// Returns a null value for the zero Addr
+func AddrToValue(addr netip.Addr) *structpb.Value {
+	if !addr.IsValid() {
+		return structpb.NewNullValue()
+	}
+	return structpb.NewStringValue(addr.String())
+}
+
+// AddrFromValue parses the address in v, the decode hook for AddrToValue
+// Returns the zero Addr without error for nil or null values and the empty string,
+// which NewValue produces for it through MarshalText
+func AddrFromValue(v *structpb.Value) (netip.Addr, error) {
+	return parseNetip(v, "address", netip.ParseAddr)
+}
+
+// PrefixToValue converts prefix to its CIDR form, such as "10.0.0.0/8"
+// Returns a null value for the zero Prefix
+func PrefixToValue(prefix netip.Prefix) *structpb.Value {
+	if !prefix.IsValid() {
+		return structpb.NewNullValue()
+	}
+	return structpb.NewStringValue(prefix.String())
+}
+
+// PrefixFromValue parses the CIDR prefix in v, the decode hook for PrefixToValue
+// Returns the zero Prefix without error for nil or null values and the empty string
+func PrefixFromValue(v *structpb.Value) (netip.Prefix, error) {
+	return parseNetip(v, "prefix", netip.ParsePrefix)
+}
+
+func parseNetip[T any](v *structpb.Value, what string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	switch kind := v.GetKind().(type) {
+	case nil, *structpb.Value_NullValue:
+		return zero, nil
+	case *structpb.Value_StringValue:
+		if kind.StringValue == "" {
+			return zero, nil
+		}
+		t, err := parse(kind.StringValue)
+		if err != nil {
+			return zero, fmt.Errorf("%w: %w", ErrNotCoercible, err)
+		}
+		return t, nil
+	default:
+		return zero, fmt.Errorf("%w: cannot decode %s as %s", ErrUnexpectedKind, KindOf(v), what)
+	}
+}
@@ -0,0 +1,66 @@
+package protobaggins
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestNetipValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("addr", func(t *testing.T) {
+		t.Parallel()
+		addr := netip.MustParseAddr("2001:db8::1")
+		v := AddrToValue(addr)
+		assert.Equal(t, "2001:db8::1", v.GetStringValue())
+		got, err := AddrFromValue(v)
+		require.NoError(t, err)
+		assert.Equal(t, addr, got)
+
+		assert.Equal(t, KindNull, KindOf(AddrToValue(netip.Addr{})))
+		for _, zero := range []*structpb.Value{nil, structpb.NewNullValue(), structpb.NewStringValue("")} {
+			got, err := AddrFromValue(zero)
+			require.NoError(t, err)
+			assert.False(t, got.IsValid())
+		}
+
+		_, err = AddrFromValue(structpb.NewStringValue("10.0.0.300"))
+		require.ErrorIs(t, err, ErrNotCoercible)
+		_, err = AddrFromValue(structpb.NewBoolValue(true))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		t.Parallel()
+		prefix := netip.MustParsePrefix("10.0.0.0/8")
+		v := PrefixToValue(prefix)
+		assert.Equal(t, "10.0.0.0/8", v.GetStringValue())
+		got, err := PrefixFromValue(v)
+		require.NoError(t, err)
+		assert.Equal(t, prefix, got)
+
+		assert.Equal(t, KindNull, KindOf(PrefixToValue(netip.Prefix{})))
+		_, err = PrefixFromValue(structpb.NewStringValue("10.0.0.0/33"))
+		require.ErrorIs(t, err, ErrNotCoercible)
+	})
+
+	t.Run("converters", func(t *testing.T) {
+		t.Parallel()
+		type peer struct {
+			Addr   netip.Addr
+			Subnet netip.Prefix
+		}
+		in := peer{Addr: netip.MustParseAddr("10.1.2.3"), Subnet: netip.MustParsePrefix("10.1.0.0/16")}
+		v, err := NewValue(in, WithReflection())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"Addr": "10.1.2.3", "Subnet": "10.1.0.0/16"}, v.AsInterface())
+
+		var out peer
+		require.NoError(t, DecodeStruct(v.GetStructValue(), &out))
+		assert.Equal(t, in, out)
+	})
+}
@@ -0,0 +1,69 @@
+package protobaggins
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// NonFinitePolicy selects how NaN and infinite floats are converted to protocol buffer
+// values. structpb stores them as numbers, but they cannot be marshaled to JSON
+type NonFinitePolicy int
+
+const (
+	// NonFiniteKeep stores NaN and infinities as numbers, like structpb.NewValue
+	NonFiniteKeep NonFinitePolicy = iota
+	// NonFiniteString encodes them as the strings "NaN", "Infinity" and "-Infinity",
+	// matching structpb's AsInterface and protojson
+	NonFiniteString
+	// NonFiniteNull encodes them as null
+	NonFiniteNull
+	// NonFiniteClamp encodes infinities as the largest finite numbers of the same sign
+	// and NaN as null
+	NonFiniteClamp
+	// NonFiniteError fails the conversion with ErrNonFinite, so the lossy
+	// MapToStructValues and SliceToStructValues and WithSkipErrors drop the value
+	NonFiniteError
+)
+
+// WithNonFinite converts NaN and infinite float64 and float32 values under policy
+func WithNonFinite(policy NonFinitePolicy) Option {
+	return func(o *options) {
+		o.nonFinite = policy
+	}
+}
+
+// encodeFloat converts f under the configured NonFinitePolicy
+func (e *encoder) encodeFloat(f float64) (*structpb.Value, error) {
+	if !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return e.newNumber(f), nil
+	}
+
+	switch e.opts.nonFinite {
+	case NonFiniteString:
+		switch {
+		case math.IsNaN(f):
+			return e.newString("NaN"), nil
+		case f > 0:
+			return e.newString("Infinity"), nil
+		default:
+			return e.newString("-Infinity"), nil
+		}
+	case NonFiniteNull:
+		return e.newNull(), nil
+	case NonFiniteClamp:
+		switch {
+		case math.IsNaN(f):
+			return e.newNull(), nil
+		case f > 0:
+			return e.newNumber(math.MaxFloat64), nil
+		default:
+			return e.newNumber(-math.MaxFloat64), nil
+		}
+	case NonFiniteError:
+		return nil, fmt.Errorf("%w: %v", ErrNonFinite, f)
+	default:
+		return e.newNumber(f), nil
+	}
+}
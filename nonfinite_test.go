@@ -0,0 +1,79 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestNonFinite(t *testing.T) {
+	t.Parallel()
+
+	inputs := []any{math.NaN(), math.Inf(1), float32(math.Inf(-1)), 1.5}
+	tests := []struct {
+		name   string
+		policy NonFinitePolicy
+		want   []*structpb.Value
+	}{
+		{"string", NonFiniteString, []*structpb.Value{
+			structpb.NewStringValue("NaN"), structpb.NewStringValue("Infinity"),
+			structpb.NewStringValue("-Infinity"), structpb.NewNumberValue(1.5),
+		}},
+		{"null", NonFiniteNull, []*structpb.Value{
+			structpb.NewNullValue(), structpb.NewNullValue(), structpb.NewNullValue(), structpb.NewNumberValue(1.5),
+		}},
+		{"clamp", NonFiniteClamp, []*structpb.Value{
+			structpb.NewNullValue(), structpb.NewNumberValue(math.MaxFloat64),
+			structpb.NewNumberValue(-math.MaxFloat64), structpb.NewNumberValue(1.5),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			for i, in := range inputs {
+				got, err := NewValue(in, WithNonFinite(tt.policy))
+				require.NoError(t, err)
+				assert.True(t, proto.Equal(tt.want[i], got), "%v: got %v", in, got)
+			}
+		})
+	}
+
+	t.Run("keep by default", func(t *testing.T) {
+		t.Parallel()
+		got, err := NewValue(math.Inf(-1))
+		require.NoError(t, err)
+		assert.True(t, math.IsInf(got.GetNumberValue(), -1))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue([]any{1, math.NaN()}, WithNonFinite(NonFiniteError))
+		require.ErrorIs(t, err, ErrNonFinite)
+
+		values, err := NewConverter(WithNonFinite(NonFiniteError)).MapToStructValues(map[string]any{"a": 1.0, "b": math.Inf(1)})
+		require.ErrorIs(t, err, ErrNonFinite)
+		assert.ErrorContains(t, err, "b: non-finite number")
+		assert.Nil(t, values)
+
+		values, err = NewConverter(WithNonFinite(NonFiniteError), WithSkipErrors()).MapToStructValues(map[string]any{"a": 1.0, "b": math.Inf(1)})
+		require.NoError(t, err)
+		assert.Len(t, values, 1)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+		got, err := NewConverter(WithNonFinite(NonFiniteString)).NewStruct(map[string]any{
+			"metrics": map[string]any{"ratio": math.NaN(), "samples": []any{math.Inf(1)}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"metrics": map[string]any{"ratio": "NaN", "samples": []any{"Infinity"}},
+		}, got.AsMap())
+		_, err = StructToJSON(got)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,82 @@
+package protobaggins
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConversionDirection tells which way a conversion goes
+type ConversionDirection int
+
+const (
+	// DirectionToProto converts Go values to protocol buffer values
+	DirectionToProto ConversionDirection = iota
+	// DirectionFromProto converts protocol buffer values to Go values
+	DirectionFromProto
+)
+
+func (d ConversionDirection) String() string {
+	switch d {
+	case DirectionToProto:
+		return "to_proto"
+	case DirectionFromProto:
+		return "from_proto"
+	default:
+		return fmt.Sprintf("ConversionDirection(%d)", int(d))
+	}
+}
+
+// ConversionEvent describes a conversion made by a Converter, see Observer
+type ConversionEvent struct {
+	Direction ConversionDirection
+	// Kind is the kind of the protocol buffer value produced or converted, KindStruct
+	// for maps, KindList for slices and KindUnset when the conversion failed
+	Kind Kind
+	// Nodes is the number of values converted inside maps and lists
+	Nodes int
+	// Duration is how long the conversion took
+	Duration time.Duration
+}
+
+// Observer is notified of the conversions made by a Converter, e.g. to export metrics
+// on conversion volume and data loss. It is called synchronously, from any goroutine
+// using the Converter
+type Observer interface {
+	// OnConvert is called after every successful conversion
+	OnConvert(event ConversionEvent)
+	// OnSkip is called for every value dropped by WithSkipErrors, like the WithOnSkip
+	// callback, by any function given WithObserver
+	OnSkip(path string, value any, err error)
+	// OnError is called after every failed conversion
+	OnError(event ConversionEvent, err error)
+}
+
+// WithObserver notifies o of the conversions of a Converter and of skipped values
+func WithObserver(o Observer) Option {
+	return func(opts *options) {
+		opts.observer = o
+	}
+}
+
+// observeStart returns the start time of a conversion, the zero time if nothing
+// observes it
+func (o *options) observeStart() time.Time {
+	if o.observer == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// observe notifies the observer of a conversion started at start
+func (o *options) observe(start time.Time, direction ConversionDirection, kind Kind, nodes int, err error) {
+	if o.observer == nil {
+		return
+	}
+	event := ConversionEvent{Direction: direction, Kind: kind, Nodes: nodes, Duration: time.Since(start)}
+	if err != nil {
+		event.Kind = KindUnset
+		o.observer.OnError(event, err)
+		return
+	}
+	o.observer.OnConvert(event)
+}
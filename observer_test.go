@@ -0,0 +1,99 @@
+package protobaggins
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// recordingObserver records every notification
+type recordingObserver struct {
+	mu        sync.Mutex
+	converted []ConversionEvent
+	skipped   []string
+	failed    []ConversionEvent
+}
+
+func (r *recordingObserver) OnConvert(event ConversionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.converted = append(r.converted, event)
+}
+
+func (r *recordingObserver) OnSkip(path string, _ any, _ error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.skipped = append(r.skipped, path)
+}
+
+func (r *recordingObserver) OnError(event ConversionEvent, _ error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = append(r.failed, event)
+}
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	payload := map[string]any{"name": "frodo", "tags": []any{"a", "b"}}
+
+	t.Run("conversions", func(t *testing.T) {
+		t.Parallel()
+		obs := &recordingObserver{}
+		c := NewConverter(WithObserver(obs))
+
+		s, err := c.NewStruct(payload)
+		require.NoError(t, err)
+		_, err = c.MapToStructValues(payload)
+		require.NoError(t, err)
+		_, err = c.SliceToStructValues([]any{1, "x"})
+		require.NoError(t, err)
+		c.StructToMap(s)
+		c.ToInterface(structpb.NewStringValue("x"))
+
+		require.Len(t, obs.converted, 5)
+		assert.Equal(t, ConversionEvent{Direction: DirectionToProto, Kind: KindStruct, Nodes: 4}, withoutDuration(obs.converted[0]))
+		assert.Equal(t, ConversionEvent{Direction: DirectionToProto, Kind: KindStruct, Nodes: 4}, withoutDuration(obs.converted[1]))
+		assert.Equal(t, ConversionEvent{Direction: DirectionToProto, Kind: KindList, Nodes: 2}, withoutDuration(obs.converted[2]))
+		assert.Equal(t, ConversionEvent{Direction: DirectionFromProto, Kind: KindStruct, Nodes: 4}, withoutDuration(obs.converted[3]))
+		assert.Equal(t, ConversionEvent{Direction: DirectionFromProto, Kind: KindString}, withoutDuration(obs.converted[4]))
+		assert.Equal(t, "from_proto", obs.converted[4].Direction.String())
+		assert.Empty(t, obs.failed)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		obs := &recordingObserver{}
+		c := NewConverter(WithObserver(obs))
+		_, err := c.MapToStructValues(map[string]any{"ch": make(chan int)})
+		require.Error(t, err)
+		_, err = c.NewValue(make(chan int))
+		require.Error(t, err)
+		require.Len(t, obs.failed, 2)
+		assert.Equal(t, KindUnset, obs.failed[0].Kind)
+		assert.Equal(t, DirectionToProto, obs.failed[1].Direction)
+		assert.Empty(t, obs.converted)
+	})
+
+	t.Run("skips", func(t *testing.T) {
+		t.Parallel()
+		obs := &recordingObserver{}
+		c := NewConverter(WithObserver(obs), WithSkipErrors())
+		m, err := c.MapToStructValues(map[string]any{"ok": 1, "ch": make(chan int)})
+		require.NoError(t, err)
+		assert.Len(t, m, 1)
+		assert.Equal(t, []string{"ch"}, obs.skipped)
+		assert.Len(t, obs.converted, 1)
+
+		MapToStructValues(map[string]any{"fn": func() {}}, WithObserver(obs))
+		assert.Equal(t, []string{"ch", "fn"}, obs.skipped)
+	})
+}
+
+func withoutDuration(event ConversionEvent) ConversionEvent {
+	event.Duration = 0
+	return event
+}
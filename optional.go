@@ -0,0 +1,124 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// OptionalState tells an absent key, an explicit null and a set value apart
+type OptionalState int
+
+const (
+	// OptionalAbsent means the key is missing
+	OptionalAbsent OptionalState = iota
+	// OptionalNull means the key is set to null
+	OptionalNull
+	// OptionalSet means the key holds a value
+	OptionalSet
+)
+
+func (s OptionalState) String() string {
+	switch s {
+	case OptionalAbsent:
+		return "absent"
+	case OptionalNull:
+		return "null"
+	case OptionalSet:
+		return "set"
+	default:
+		return fmt.Sprintf("OptionalState(%d)", int(s))
+	}
+}
+
+// Optional is a Struct field that keeps a missing key and an explicit null distinct,
+// as PATCH semantics require. The zero Optional is absent
+type Optional[T any] struct {
+	// Value is the value when State is OptionalSet, and the zero T otherwise
+	Value T
+	State OptionalState
+}
+
+// Some returns an Optional holding v
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, State: OptionalSet}
+}
+
+// Null returns an explicitly null Optional
+func Null[T any]() Optional[T] {
+	return Optional[T]{State: OptionalNull}
+}
+
+// Get returns the value and whether it is set
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.State == OptionalSet
+}
+
+// IsSet reports whether the Optional holds a value
+func (o Optional[T]) IsSet() bool {
+	return o.State == OptionalSet
+}
+
+// IsNull reports whether the Optional is explicitly null
+func (o Optional[T]) IsNull() bool {
+	return o.State == OptionalNull
+}
+
+// IsAbsent reports whether the Optional is missing
+func (o Optional[T]) IsAbsent() bool {
+	return o.State == OptionalAbsent
+}
+
+// OptionalFromValue converts v to an Optional: nil is absent, a null or unset Value is
+// null and anything else is converted with As
+func OptionalFromValue[T any](v *structpb.Value, opts ...Option) (Optional[T], error) {
+	switch {
+	case v == nil:
+		return Optional[T]{}, nil
+	case KindOf(v) == KindNull || KindOf(v) == KindUnset:
+		return Null[T](), nil
+	}
+	t, err := As[T](v, opts...)
+	if err != nil {
+		return Optional[T]{}, err
+	}
+	return Some(t), nil
+}
+
+// GetOptional reads the field key of s into an Optional, see OptionalFromValue
+func GetOptional[T any](s *structpb.Struct, key string, opts ...Option) (Optional[T], error) {
+	o, err := OptionalFromValue[T](s.GetFields()[key], opts...)
+	if err != nil {
+		return o, fmt.Errorf("%s: %w", joinKey("", key), err)
+	}
+	return o, nil
+}
+
+// SetOptional writes o to the field key of s: a set value is converted with NewValue,
+// a null one stored as null and an absent one deletes the key
+func SetOptional[T any](s *structpb.Struct, key string, o Optional[T], opts ...Option) error {
+	if s == nil {
+		return errors.New("cannot set a field of a nil struct")
+	}
+	var v *structpb.Value
+	switch o.State {
+	case OptionalAbsent:
+		delete(s.Fields, key)
+		return nil
+	case OptionalNull:
+		v = structpb.NewNullValue()
+	case OptionalSet:
+		var err error
+		if v, err = NewValue(o.Value, opts...); err != nil {
+			return fmt.Errorf("%s: %w", joinKey("", key), err)
+		}
+	default:
+		return fmt.Errorf("%s: invalid state %s", joinKey("", key), o.State)
+	}
+	if s.Fields == nil {
+		s.Fields = make(map[string]*structpb.Value)
+	}
+	s.Fields[key] = v
+	return nil
+}
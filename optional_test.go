@@ -0,0 +1,65 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestOptional(t *testing.T) {
+	t.Parallel()
+
+	patch, err := structpb.NewStruct(map[string]any{"name": "frodo", "age": 50, "email": nil})
+	require.NoError(t, err)
+
+	t.Run("get", func(t *testing.T) {
+		t.Parallel()
+		name, err := GetOptional[string](patch, "name")
+		require.NoError(t, err)
+		assert.Equal(t, Some("frodo"), name)
+
+		age, err := GetOptional[int](patch, "age")
+		require.NoError(t, err)
+		v, ok := age.Get()
+		assert.True(t, ok)
+		assert.Equal(t, 50, v)
+
+		email, err := GetOptional[string](patch, "email")
+		require.NoError(t, err)
+		assert.True(t, email.IsNull())
+
+		phone, err := GetOptional[string](patch, "phone")
+		require.NoError(t, err)
+		assert.True(t, phone.IsAbsent())
+		assert.Equal(t, "absent", phone.State.String())
+
+		_, err = GetOptional[int](patch, "name")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "name: ")
+	})
+
+	t.Run("set round trips", func(t *testing.T) {
+		t.Parallel()
+		s := &structpb.Struct{}
+		require.NoError(t, SetOptional(s, "name", Some("sam")))
+		require.NoError(t, SetOptional(s, "email", Null[string]()))
+		require.NoError(t, SetOptional(s, "phone", Optional[string]{}))
+		assert.Equal(t, map[string]any{"name": "sam", "email": nil}, s.AsMap())
+
+		require.NoError(t, SetOptional(s, "name", Optional[string]{}))
+		assert.NotContains(t, s.GetFields(), "name")
+
+		email, err := GetOptional[string](s, "email")
+		require.NoError(t, err)
+		assert.Equal(t, Null[string](), email)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		require.Error(t, SetOptional(nil, "name", Some("sam")))
+		require.Error(t, SetOptional(&structpb.Struct{}, "ch", Some(make(chan int))))
+		require.Error(t, SetOptional(&structpb.Struct{}, "x", Optional[int]{State: 7}))
+	})
+}
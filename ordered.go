@@ -0,0 +1,156 @@
+package protobaggins
+
+import (
+	"iter"
+	"maps"
+	"slices"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// OrderedStruct is a *structpb.Struct that remembers the order of its keys, at any
+// depth, so that it can be written back as JSON in the order it was read or built
+// Protocol buffers never preserve the order of map entries, so the order is kept
+// alongside the Struct and is lost when only the Struct is sent. Keys without a known
+// order, such as those added through Struct, follow the ordered ones in sorted order
+// The zero value is an empty OrderedStruct ready to use
+type OrderedStruct struct {
+	s *structpb.Struct
+	// order holds the keys of the Struct at each path, in order
+	order map[string][]string
+}
+
+// NewOrderedStruct returns an OrderedStruct holding s, whose keys are in sorted order
+// until they are set again. A nil s gives an empty OrderedStruct
+func NewOrderedStruct(s *structpb.Struct) *OrderedStruct {
+	return &OrderedStruct{s: s}
+}
+
+// OrderedStructFromJSON parses a JSON object like JSONToStruct, recording the order of
+// the keys of every object in it
+func OrderedStructFromJSON(data []byte, opts ...JSONOption) (*OrderedStruct, error) {
+	order := make(map[string][]string)
+	s, err := parseJSONObject(data, newJSONOptions(opts), order)
+	if err != nil {
+		return nil, err
+	}
+	return &OrderedStruct{s: s, order: order}, nil
+}
+
+// Struct returns the underlying Struct, which may be modified
+func (o *OrderedStruct) Struct() *structpb.Struct {
+	if o.s == nil {
+		o.s = &structpb.Struct{}
+	}
+	if o.s.Fields == nil {
+		o.s.Fields = make(map[string]*structpb.Value)
+	}
+	return o.s
+}
+
+// Len returns the number of top-level keys
+func (o *OrderedStruct) Len() int {
+	return len(o.s.GetFields())
+}
+
+// Get returns the value of the top-level key
+func (o *OrderedStruct) Get(key string) (*structpb.Value, bool) {
+	v, ok := o.s.GetFields()[key]
+	return v, ok
+}
+
+// Set sets the top-level key to v. A new key is ordered after the existing ones, while
+// an existing key keeps its place. The order of the keys within v is sorted
+func (o *OrderedStruct) Set(key string, v *structpb.Value) {
+	fields := o.Struct().Fields
+	if _, exists := fields[key]; !exists {
+		if o.order == nil {
+			o.order = make(map[string][]string)
+		}
+		o.order[""] = append(o.keys("", o.s), key)
+	}
+	o.forget(joinKey("", key))
+	fields[key] = v
+}
+
+// Delete removes the top-level key
+func (o *OrderedStruct) Delete(key string) {
+	if _, exists := o.s.GetFields()[key]; !exists {
+		return
+	}
+	delete(o.s.Fields, key)
+	o.forget(joinKey("", key))
+	if keys, ok := o.order[""]; ok {
+		o.order[""] = slices.DeleteFunc(keys, func(k string) bool { return k == key })
+	}
+}
+
+// Keys returns the top-level keys in order
+func (o *OrderedStruct) Keys() []string {
+	return o.keys("", o.s)
+}
+
+// All iterates over the top-level keys and values in order
+func (o *OrderedStruct) All() iter.Seq2[string, *structpb.Value] {
+	return func(yield func(string, *structpb.Value) bool) {
+		for _, key := range o.Keys() {
+			if !yield(key, o.s.GetFields()[key]) {
+				return
+			}
+		}
+	}
+}
+
+// ToJSON serializes the OrderedStruct like StructToJSON, but writes the keys of every
+// object in order
+func (o *OrderedStruct) ToJSON(opts ...JSONOption) ([]byte, error) {
+	w := jsonWriter{opts: newJSONOptions(opts), keys: o.keys}
+	if err := w.writeStruct("", o.s); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// MarshalJSON implements json.Marshaler, see ToJSON
+func (o *OrderedStruct) MarshalJSON() ([]byte, error) {
+	return o.ToJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see OrderedStructFromJSON
+func (o *OrderedStruct) UnmarshalJSON(data []byte) error {
+	parsed, err := OrderedStructFromJSON(data)
+	if err != nil {
+		return err
+	}
+	*o = *parsed
+	return nil
+}
+
+// keys returns the keys of s, the Struct at path, with the recorded ones that still
+// exist first and the others after them in sorted order
+func (o *OrderedStruct) keys(path string, s *structpb.Struct) []string {
+	fields := s.GetFields()
+	keys := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, key := range o.order[path] {
+		if _, exists := fields[key]; exists && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	if len(keys) == len(fields) {
+		return keys
+	}
+	rest := slices.Sorted(maps.Keys(fields))
+	rest = slices.DeleteFunc(rest, func(key string) bool { return seen[key] })
+	return append(keys, rest...)
+}
+
+// forget drops the recorded order of the value at path and the values nested within it
+func (o *OrderedStruct) forget(path string) {
+	for recorded := range o.order {
+		if WithinPath(recorded, path) {
+			delete(o.order, recorded)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package protobaggins
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestOrderedStruct(t *testing.T) {
+	t.Parallel()
+
+	const doc = `{"name":"web","spec":{"replicas":2,"image":"nginx","ports":[{"port":80,"name":"http"}]},"labels":{"z":"1","a":"2"}}`
+
+	t.Run("round trips JSON in order", func(t *testing.T) {
+		t.Parallel()
+		o, err := OrderedStructFromJSON([]byte(doc))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"name", "spec", "labels"}, o.Keys())
+
+		out, err := o.ToJSON()
+		require.NoError(t, err)
+		assert.Equal(t, doc, string(out))
+
+		plain, err := StructToJSON(o.Struct())
+		require.NoError(t, err)
+		assert.NotEqual(t, doc, string(plain))
+	})
+
+	t.Run("set and delete", func(t *testing.T) {
+		t.Parallel()
+		o, err := OrderedStructFromJSON([]byte(doc))
+		require.NoError(t, err)
+
+		o.Set("version", structpb.NewNumberValue(1))
+		o.Set("name", structpb.NewStringValue("api"))
+		o.Delete("spec")
+		o.Delete("missing")
+		assert.Equal(t, []string{"name", "labels", "version"}, o.Keys())
+		assert.Equal(t, 3, o.Len())
+
+		v, ok := o.Get("name")
+		require.True(t, ok)
+		assert.Equal(t, "api", v.GetStringValue())
+
+		labels, err := structpb.NewStruct(map[string]any{"b": "x", "a": "y"})
+		require.NoError(t, err)
+		o.Set("labels", structpb.NewStructValue(labels))
+		out, err := o.ToJSON()
+		require.NoError(t, err)
+		assert.Equal(t, `{"name":"api","labels":{"a":"y","b":"x"},"version":1}`, string(out))
+
+		var keys []string
+		for key := range o.All() {
+			keys = append(keys, key)
+		}
+		assert.Equal(t, o.Keys(), keys)
+	})
+
+	t.Run("plain structs", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"b": 1, "a": 2})
+		require.NoError(t, err)
+		o := NewOrderedStruct(s)
+		o.Set("c", structpb.NewNumberValue(3))
+		o.Struct().Fields["0"] = structpb.NewNumberValue(0)
+		assert.Equal(t, []string{"a", "b", "c", "0"}, o.Keys())
+
+		var zero OrderedStruct
+		zero.Set("x", structpb.NewBoolValue(true))
+		assert.Equal(t, []string{"x"}, zero.Keys())
+	})
+
+	t.Run("encoding/json", func(t *testing.T) {
+		t.Parallel()
+		var wrapper struct {
+			Config *OrderedStruct `json:"config"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(`{"config":{"b":1,"a":{"y":true,"x":null}}}`), &wrapper))
+		out, err := json.Marshal(wrapper)
+		require.NoError(t, err)
+		assert.Equal(t, `{"config":{"b":1,"a":{"y":true,"x":null}}}`, string(out))
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		t.Parallel()
+		_, err := OrderedStructFromJSON([]byte(`[1]`))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+}
@@ -0,0 +1,234 @@
+package protobaggins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// PathFilter reports whether the value at the given path should be considered
+// Paths use a JSON-style notation such as `spec.containers[0].image`, with keys that
+// are not plain identifiers quoted in brackets, e.g. `labels["app.kubernetes.io/name"]`
+type PathFilter func(path string) bool
+
+// joinKey appends a struct field key to a path
+func joinKey(path, key string) string {
+	return joinKeySep(path, key, ".")
+}
+
+// joinKeySep appends a struct field key to a path whose keys are separated by sep
+func joinKeySep(path, key, sep string) string {
+	if key == "" || strings.Contains(key, sep) || strings.ContainsAny(key, `[]"`) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + sep + key
+}
+
+// joinIndex appends a list index to a path
+func joinIndex(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// JoinPathKey appends a struct key to a path in the notation described at PathFilter,
+// quoting it when it is not a plain identifier
+func JoinPathKey(path, key string) string {
+	return joinKey(path, key)
+}
+
+// JoinPathIndex appends a list index to a path in the notation described at PathFilter
+func JoinPathIndex(path string, i int) string {
+	return joinIndex(path, i)
+}
+
+// WithinPath reports whether path, in the notation described at PathFilter, equals
+// prefix or is nested beneath it. Every path is within the empty prefix, the root
+func WithinPath(path, prefix string) bool {
+	if prefix == "" || path == prefix {
+		return true
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	next := path[len(prefix)]
+	return next == '.' || next == '['
+}
+
+// matchesAll reports whether path satisfies every filter
+func matchesAll(path string, filters []PathFilter) bool {
+	for _, filter := range filters {
+		if filter != nil && !filter(path) {
+			return false
+		}
+	}
+	return true
+}
+
+// pathSegment is a struct key, or a list index when index is not negative
+type pathSegment struct {
+	key   string
+	index int
+}
+
+// parsePath splits a path written in the notation of joinKey and joinIndex into its
+// segments. A leading dot is allowed, the empty path has no segments
+func parsePath(path string) ([]pathSegment, error) {
+	return parsePathSep(path, ".")
+}
+
+// parsePathSep is parsePath for paths whose keys are separated by sep
+func parsePathSep(path, sep string) ([]pathSegment, error) {
+	var segments []pathSegment
+	rest := strings.TrimPrefix(path, sep)
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, `["`):
+			end := quotedKeyEnd(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted key in %q", path)
+			}
+			key, err := strconv.Unquote(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted key in %q: %w", path, err)
+			}
+			segments = append(segments, pathSegment{key: key, index: -1})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in %q", path)
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil || i < 0 || strings.HasPrefix(rest[1:end], "+") {
+				return nil, fmt.Errorf("invalid index %q in %q", rest[1:end], path)
+			}
+			segments = append(segments, pathSegment{index: i})
+			rest = rest[end+1:]
+		default:
+			end := strings.IndexByte(rest, '[')
+			if end < 0 {
+				end = len(rest)
+			}
+			if i := strings.Index(rest[:end], sep); i >= 0 {
+				end = i
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in %q", path)
+			}
+			segments = append(segments, pathSegment{key: rest[:end], index: -1})
+			rest = rest[end:]
+		}
+		if strings.HasPrefix(rest, sep) {
+			rest = rest[len(sep):]
+			if rest == "" {
+				return nil, fmt.Errorf("trailing separator in %q", path)
+			}
+		}
+	}
+	return segments, nil
+}
+
+// quotedKeyEnd returns the index of the closing bracket of the quoted key that s starts
+// with, or -1
+func quotedKeyEnd(s string) int {
+	for i := 2; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			if i+1 < len(s) && s[i+1] == ']' {
+				return i + 1
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// LookupPath returns the value at path within v, in the notation described at
+// PathFilter, e.g. `spec.containers[0].image`. The empty path refers to v itself.
+// Fails with ErrPathNotFound if a key or index does not exist, and with
+// ErrUnexpectedKind if a segment would descend into a value that is not a container
+func LookupPath(v *structpb.Value, path string) (*structpb.Value, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%w: nil value", ErrPathNotFound)
+	}
+
+	current := ""
+	for _, seg := range segments {
+		if seg.index >= 0 {
+			list, ok := v.GetKind().(*structpb.Value_ListValue)
+			if !ok {
+				return nil, fmt.Errorf("%s: %w: cannot index %s", describePath(current), ErrUnexpectedKind, KindOf(v))
+			}
+			items := list.ListValue.GetValues()
+			if seg.index >= len(items) {
+				return nil, fmt.Errorf("%s: %w: index %d out of range for list of length %d",
+					describePath(current), ErrPathNotFound, seg.index, len(items))
+			}
+			current = joinIndex(current, seg.index)
+			v = items[seg.index]
+			continue
+		}
+
+		s, ok := v.GetKind().(*structpb.Value_StructValue)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: cannot look up key %q in %s", describePath(current), ErrUnexpectedKind, seg.key, KindOf(v))
+		}
+		current = joinKey(current, seg.key)
+		next, ok := s.StructValue.GetFields()[seg.key]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", current, ErrPathNotFound)
+		}
+		v = next
+	}
+	return v, nil
+}
+
+// GetPath returns the value at path within v, see LookupPath. Reports false if the path
+// is malformed or does not resolve
+func GetPath(v *structpb.Value, path string) (*structpb.Value, bool) {
+	found, err := LookupPath(v, path)
+	return found, err == nil
+}
+
+// GetStringPath returns the string at path within v
+// Reports false if the path does not resolve to a string value
+func GetStringPath(v *structpb.Value, path string) (string, bool) {
+	found, ok := GetPath(v, path)
+	s, isString := found.GetKind().(*structpb.Value_StringValue)
+	if !ok || !isString {
+		return "", false
+	}
+	return s.StringValue, true
+}
+
+// GetNumberPath returns the number at path within v
+// Reports false if the path does not resolve to a number value
+func GetNumberPath(v *structpb.Value, path string) (float64, bool) {
+	found, ok := GetPath(v, path)
+	n, isNumber := found.GetKind().(*structpb.Value_NumberValue)
+	if !ok || !isNumber {
+		return 0, false
+	}
+	return n.NumberValue, true
+}
+
+// GetBoolPath returns the bool at path within v
+// Reports false if the path does not resolve to a bool value
+func GetBoolPath(v *structpb.Value, path string) (bool, bool) {
+	found, ok := GetPath(v, path)
+	b, isBool := found.GetKind().(*structpb.Value_BoolValue)
+	if !ok || !isBool {
+		return false, false
+	}
+	return b.BoolValue, true
+}
@@ -0,0 +1,191 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestJoinKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		path     string
+		key      string
+		expected string
+	}{
+		{"root key", "", "spec", "spec"},
+		{"nested key", "spec", "image", "spec.image"},
+		{"key with dot", "labels", "app.name", `labels["app.name"]`},
+		{"key with bracket", "", "a[0]", `["a[0]"]`},
+		{"key with quote", "a", `say "hi"`, `a["say \"hi\""]`},
+		{"empty key", "a", "", `a[""]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, joinKey(tt.path, tt.key))
+		})
+	}
+}
+
+func TestJoinIndex(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "[3]", joinIndex("", 3))
+	assert.Equal(t, "spec.containers[0]", joinIndex("spec.containers", 0))
+}
+
+func TestJoinPath(t *testing.T) {
+	t.Parallel()
+	path := JoinPathIndex(JoinPathKey(JoinPathKey("", "spec"), "app.name"), 2)
+	assert.Equal(t, `spec["app.name"][2]`, path)
+
+	v, err := structpb.NewValue(map[string]any{"spec": map[string]any{"app.name": []any{0, 1, "two"}}})
+	require.NoError(t, err)
+	found, ok := GetStringPath(v, path)
+	assert.True(t, ok)
+	assert.Equal(t, "two", found)
+}
+
+func TestWithinPath(t *testing.T) {
+	t.Parallel()
+	assert.True(t, WithinPath("a", ""))
+	assert.True(t, WithinPath("", ""))
+	assert.True(t, WithinPath("a", "a"))
+	assert.True(t, WithinPath("a.b", "a"))
+	assert.True(t, WithinPath("a[0]", "a"))
+	assert.True(t, WithinPath(`a["b.c"]`, "a"))
+	assert.False(t, WithinPath("ab", "a"))
+	assert.False(t, WithinPath("b", "a"))
+	assert.False(t, WithinPath("a", "a.b"))
+}
+
+func TestMatchesAll(t *testing.T) {
+	t.Parallel()
+
+	isSpec := func(path string) bool { return path == "spec" }
+
+	assert.True(t, matchesAll("anything", nil))
+	assert.True(t, matchesAll("spec", []PathFilter{isSpec, nil}))
+	assert.False(t, matchesAll("status", []PathFilter{isSpec}))
+}
+
+func TestParsePath(t *testing.T) {
+	t.Parallel()
+
+	// every path joinKey and joinIndex produce parses back into its segments
+	path := joinIndex(joinKey(joinKey(joinKey("", "spec"), `a["b"].c`), ""), 12)
+	segments, err := parsePath(path)
+	require.NoError(t, err)
+	assert.Equal(t, []pathSegment{{"spec", -1}, {`a["b"].c`, -1}, {"", -1}, {"", 12}}, segments)
+
+	for _, path := range []string{"a..b", "a.", "a[", "a[+1]", `a["b`, `a["b"`, `a["\q"]`} {
+		_, err := parsePath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"a":   map[string]any{"b": []any{"x", map[string]any{`say "hi"`: 1}}},
+		"":    "empty",
+		"x.y": []any{[]any{true}},
+	})
+	require.NoError(t, err)
+	root := structpb.NewStructValue(s)
+
+	tests := []struct {
+		path     string
+		expected any
+	}{
+		{"", s.AsMap()},
+		{"a.b[0]", "x"},
+		{".a.b[0]", "x"},
+		{`a.b[1]["say \"hi\""]`, float64(1)},
+		{`[""]`, "empty"},
+		{`["x.y"][0][0]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			v, err := LookupPath(root, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, v.AsInterface())
+		})
+	}
+
+	errorTests := []struct {
+		path string
+		err  error
+		msg  string
+	}{
+		{"missing", ErrPathNotFound, "missing: path not found"},
+		{"a.b[2]", ErrPathNotFound, "a.b: path not found: index 2 out of range for list of length 2"},
+		{"a[0]", ErrUnexpectedKind, "a: unexpected value kind: cannot index struct"},
+		{"a.b.c", ErrUnexpectedKind, `a.b: unexpected value kind: cannot look up key "c" in list`},
+		{"[0]", ErrUnexpectedKind, "(root): unexpected value kind: cannot index struct"},
+	}
+	for _, tt := range errorTests {
+		t.Run("invalid "+tt.path, func(t *testing.T) {
+			t.Parallel()
+			_, err := LookupPath(root, tt.path)
+			require.ErrorIs(t, err, tt.err)
+			assert.EqualError(t, err, tt.msg)
+		})
+	}
+
+	for _, path := range []string{"a.b[x]", "a.b[-1]", `["unterminated`} {
+		t.Run("malformed "+path, func(t *testing.T) {
+			t.Parallel()
+			_, err := LookupPath(root, path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetPath(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"spec": map[string]any{
+			"containers": []any{map[string]any{"image": "nginx", "replicas": 3, "privileged": false}},
+		},
+	})
+	require.NoError(t, err)
+	root := structpb.NewStructValue(s)
+
+	v, ok := GetPath(root, "spec.containers[0]")
+	require.True(t, ok)
+	assert.Len(t, v.GetStructValue().GetFields(), 3)
+
+	_, ok = GetPath(root, "spec.containers[1]")
+	assert.False(t, ok)
+	_, ok = GetPath(root, "spec..containers")
+	assert.False(t, ok)
+	_, ok = GetPath(nil, "")
+	assert.False(t, ok)
+
+	image, ok := GetStringPath(root, "spec.containers[0].image")
+	assert.True(t, ok)
+	assert.Equal(t, "nginx", image)
+	_, ok = GetStringPath(root, "spec.containers[0].replicas")
+	assert.False(t, ok)
+
+	replicas, ok := GetNumberPath(root, "spec.containers[0].replicas")
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, replicas)
+	_, ok = GetNumberPath(root, "spec.containers[0].missing")
+	assert.False(t, ok)
+
+	privileged, ok := GetBoolPath(root, "spec.containers[0].privileged")
+	assert.True(t, ok)
+	assert.False(t, privileged)
+	_, ok = GetBoolPath(root, "spec.containers[0].image")
+	assert.False(t, ok)
+}
@@ -0,0 +1,151 @@
+package protobaggins
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Pick returns a copy of s holding only the values at paths matching patterns, in the
+// notation of Redact, e.g. Pick(s, "id", "author.name", "comments[*].body") for a
+// sparse fieldset. Structs and lists on the way to a picked value keep only what leads
+// to one, so lists keep their matching items in order, without gaps. Fails if a
+// pattern is malformed
+func Pick(s *structpb.Struct, patterns ...string) (*structpb.Struct, error) {
+	set := make(patternSet, 0, len(patterns))
+	for _, pattern := range patterns {
+		segments, err := parsePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, segments)
+	}
+
+	picked := set.pickStruct(s, set.start())
+	if picked == nil {
+		picked = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	return picked, nil
+}
+
+// Omit returns a copy of s without the values at paths matching patterns, see Redact
+// with RedactRemove. Fails if a pattern is malformed
+func Omit(s *structpb.Struct, patterns ...string) (*structpb.Struct, error) {
+	omitted, _ := proto.Clone(s).(*structpb.Struct)
+	if omitted == nil {
+		omitted = &structpb.Struct{}
+	}
+	if _, err := Redact(omitted, patterns, RedactRemove()); err != nil {
+		return nil, err
+	}
+	if omitted.Fields == nil {
+		omitted.Fields = map[string]*structpb.Value{}
+	}
+	return omitted, nil
+}
+
+// patternSet matches paths against several patterns at once
+type patternSet [][]patternSegment
+
+// patternState is the position in a pattern of a patternSet: segment at of pattern
+// is the next to match
+type patternState struct {
+	pattern, at int
+}
+
+// start returns the states before anything is matched
+func (ps patternSet) start() []patternState {
+	states := make([]patternState, len(ps))
+	for i := range ps {
+		states[i] = patternState{pattern: i}
+	}
+	return states
+}
+
+// advance matches step, a key or an index, against states. Returns the states left to
+// match beneath it, each at most once so that ** segments cannot multiply them, and
+// whether a pattern matched it completely
+func (ps patternSet) advance(states []patternState, step patternSegment) (next []patternState, whole bool) {
+	seen := make(map[patternState]bool)
+	add := func(state patternState) {
+		if !seen[state] {
+			seen[state] = true
+			next = append(next, state)
+		}
+	}
+	for _, state := range states {
+		segments := ps[state.pattern]
+		// ** matches step and stays, or matches nothing, never ending a pattern
+		for segments[state.at].kind == patternAnyDepth {
+			add(state)
+			state.at++
+		}
+		if !segmentMatches(segments[state.at], step) {
+			continue
+		}
+		if state.at+1 == len(segments) {
+			whole = true
+		} else {
+			add(patternState{pattern: state.pattern, at: state.at + 1})
+		}
+	}
+	return next, whole
+}
+
+// segmentMatches reports whether seg, which is not **, matches step
+func segmentMatches(seg, step patternSegment) bool {
+	switch seg.kind {
+	case patternAnyKey:
+		return step.kind == patternKey
+	case patternAnyIndex:
+		return step.kind == patternIndex
+	case patternKey:
+		return step.kind == patternKey && seg.key == step.key
+	default:
+		return step.kind == patternIndex && seg.index == step.index
+	}
+}
+
+// pickStruct returns the fields of s picked by states, or nil if there are none
+func (ps patternSet) pickStruct(s *structpb.Struct, states []patternState) *structpb.Struct {
+	var picked *structpb.Struct
+	for key, field := range s.GetFields() {
+		next, whole := ps.advance(states, patternSegment{kind: patternKey, key: key})
+		if v := ps.pickValue(field, next, whole); v != nil {
+			if picked == nil {
+				picked = &structpb.Struct{Fields: make(map[string]*structpb.Value)}
+			}
+			picked.Fields[key] = v
+		}
+	}
+	return picked
+}
+
+// pickValue returns a copy of v if whole, and otherwise the part of v picked by states,
+// or nil if there is none
+func (ps patternSet) pickValue(v *structpb.Value, states []patternState, whole bool) *structpb.Value {
+	if whole {
+		return proto.Clone(v).(*structpb.Value)
+	}
+	if len(states) == 0 {
+		return nil
+	}
+
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		if s := ps.pickStruct(kind.StructValue, states); s != nil {
+			return structpb.NewStructValue(s)
+		}
+	case *structpb.Value_ListValue:
+		var items []*structpb.Value
+		for i, item := range kind.ListValue.GetValues() {
+			next, whole := ps.advance(states, patternSegment{kind: patternIndex, index: i})
+			if item := ps.pickValue(item, next, whole); item != nil {
+				items = append(items, item)
+			}
+		}
+		if items != nil {
+			return structpb.NewListValue(&structpb.ListValue{Values: items})
+		}
+	}
+	return nil
+}
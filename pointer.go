@@ -0,0 +1,151 @@
+package protobaggins
+
+// Ptr returns a pointer to a copy of v, for setting proto3 optional fields of any type
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value p points to, or the zero value of T if p is nil
+func Deref[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
+
+// DerefOr returns the value p points to, or fallback if p is nil
+func DerefOr[T any](p *T, fallback T) T {
+	if p == nil {
+		return fallback
+	}
+	return *p
+}
+
+// The typed wrappers below pair with StringToProto and StringFromProto so that call
+// sites read the same for every proto3 optional scalar
+
+// StringFromProtoOr converts a protocol buffer string pointer to a Go string
+// Returns fallback if the pointer is nil
+func StringFromProtoOr(s *string, fallback string) string {
+	return DerefOr(s, fallback)
+}
+
+// Int32ToProto converts a Go int32 to a protocol buffer int32 pointer
+func Int32ToProto(v int32) *int32 {
+	return &v
+}
+
+// Int32FromProto converts a protocol buffer int32 pointer to a Go int32
+// Returns 0 if the pointer is nil
+func Int32FromProto(p *int32) int32 {
+	return Deref(p)
+}
+
+// Int32FromProtoOr converts a protocol buffer int32 pointer to a Go int32
+// Returns fallback if the pointer is nil
+func Int32FromProtoOr(p *int32, fallback int32) int32 {
+	return DerefOr(p, fallback)
+}
+
+// Int64ToProto converts a Go int64 to a protocol buffer int64 pointer
+func Int64ToProto(v int64) *int64 {
+	return &v
+}
+
+// Int64FromProto converts a protocol buffer int64 pointer to a Go int64
+// Returns 0 if the pointer is nil
+func Int64FromProto(p *int64) int64 {
+	return Deref(p)
+}
+
+// Int64FromProtoOr converts a protocol buffer int64 pointer to a Go int64
+// Returns fallback if the pointer is nil
+func Int64FromProtoOr(p *int64, fallback int64) int64 {
+	return DerefOr(p, fallback)
+}
+
+// Uint32ToProto converts a Go uint32 to a protocol buffer uint32 pointer
+func Uint32ToProto(v uint32) *uint32 {
+	return &v
+}
+
+// Uint32FromProto converts a protocol buffer uint32 pointer to a Go uint32
+// Returns 0 if the pointer is nil
+func Uint32FromProto(p *uint32) uint32 {
+	return Deref(p)
+}
+
+// Uint32FromProtoOr converts a protocol buffer uint32 pointer to a Go uint32
+// Returns fallback if the pointer is nil
+func Uint32FromProtoOr(p *uint32, fallback uint32) uint32 {
+	return DerefOr(p, fallback)
+}
+
+// Uint64ToProto converts a Go uint64 to a protocol buffer uint64 pointer
+func Uint64ToProto(v uint64) *uint64 {
+	return &v
+}
+
+// Uint64FromProto converts a protocol buffer uint64 pointer to a Go uint64
+// Returns 0 if the pointer is nil
+func Uint64FromProto(p *uint64) uint64 {
+	return Deref(p)
+}
+
+// Uint64FromProtoOr converts a protocol buffer uint64 pointer to a Go uint64
+// Returns fallback if the pointer is nil
+func Uint64FromProtoOr(p *uint64, fallback uint64) uint64 {
+	return DerefOr(p, fallback)
+}
+
+// Float32ToProto converts a Go float32 to a protocol buffer float pointer
+func Float32ToProto(v float32) *float32 {
+	return &v
+}
+
+// Float32FromProto converts a protocol buffer float pointer to a Go float32
+// Returns 0 if the pointer is nil
+func Float32FromProto(p *float32) float32 {
+	return Deref(p)
+}
+
+// Float32FromProtoOr converts a protocol buffer float pointer to a Go float32
+// Returns fallback if the pointer is nil
+func Float32FromProtoOr(p *float32, fallback float32) float32 {
+	return DerefOr(p, fallback)
+}
+
+// Float64ToProto converts a Go float64 to a protocol buffer double pointer
+func Float64ToProto(v float64) *float64 {
+	return &v
+}
+
+// Float64FromProto converts a protocol buffer double pointer to a Go float64
+// Returns 0 if the pointer is nil
+func Float64FromProto(p *float64) float64 {
+	return Deref(p)
+}
+
+// Float64FromProtoOr converts a protocol buffer double pointer to a Go float64
+// Returns fallback if the pointer is nil
+func Float64FromProtoOr(p *float64, fallback float64) float64 {
+	return DerefOr(p, fallback)
+}
+
+// BoolToProto converts a Go bool to a protocol buffer bool pointer
+func BoolToProto(v bool) *bool {
+	return &v
+}
+
+// BoolFromProto converts a protocol buffer bool pointer to a Go bool
+// Returns false if the pointer is nil
+func BoolFromProto(p *bool) bool {
+	return Deref(p)
+}
+
+// BoolFromProtoOr converts a protocol buffer bool pointer to a Go bool
+// Returns fallback if the pointer is nil
+func BoolFromProtoOr(p *bool, fallback bool) bool {
+	return DerefOr(p, fallback)
+}
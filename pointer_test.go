@@ -0,0 +1,103 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPtr(t *testing.T) {
+	t.Parallel()
+
+	t.Run("points to a copy", func(t *testing.T) {
+		t.Parallel()
+		v := 3
+		p := Ptr(v)
+		require.NotNil(t, p)
+		*p = 4
+		assert.Equal(t, 3, v)
+	})
+
+	t.Run("zero values are not nil", func(t *testing.T) {
+		t.Parallel()
+		p := Ptr("")
+		require.NotNil(t, p)
+		assert.Empty(t, *p)
+	})
+}
+
+func TestDeref(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil gives the zero value", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, 0, Deref[int](nil))
+		assert.Empty(t, Deref[string](nil))
+		assert.Nil(t, Deref[[]int](nil))
+	})
+
+	t.Run("non-nil gives the value", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, 7, Deref(Ptr(7)))
+	})
+}
+
+func TestDerefOr(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil gives the fallback", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, 9, DerefOr(nil, 9))
+	})
+
+	t.Run("zero value is not replaced", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, 0, DerefOr(Ptr(0), 9))
+	})
+}
+
+func TestTypedPointerHelpers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trips", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, int32(-3), Int32FromProto(Int32ToProto(-3)))
+		assert.Equal(t, int64(1<<40), Int64FromProto(Int64ToProto(1<<40)))
+		assert.Equal(t, uint32(3), Uint32FromProto(Uint32ToProto(3)))
+		assert.Equal(t, uint64(1<<63), Uint64FromProto(Uint64ToProto(1<<63)))
+		assert.InDelta(t, float32(1.5), Float32FromProto(Float32ToProto(1.5)), 0)
+		assert.InDelta(t, 2.5, Float64FromProto(Float64ToProto(2.5)), 0)
+		assert.True(t, BoolFromProto(BoolToProto(true)))
+	})
+
+	t.Run("nil gives the zero value", func(t *testing.T) {
+		t.Parallel()
+		assert.Zero(t, Int32FromProto(nil))
+		assert.Zero(t, Int64FromProto(nil))
+		assert.Zero(t, Uint32FromProto(nil))
+		assert.Zero(t, Uint64FromProto(nil))
+		assert.Zero(t, Float32FromProto(nil))
+		assert.Zero(t, Float64FromProto(nil))
+		assert.False(t, BoolFromProto(nil))
+	})
+
+	t.Run("nil gives the fallback", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "x", StringFromProtoOr(nil, "x"))
+		assert.Equal(t, int32(1), Int32FromProtoOr(nil, 1))
+		assert.Equal(t, int64(2), Int64FromProtoOr(nil, 2))
+		assert.Equal(t, uint32(3), Uint32FromProtoOr(nil, 3))
+		assert.Equal(t, uint64(4), Uint64FromProtoOr(nil, 4))
+		assert.InDelta(t, float32(5), Float32FromProtoOr(nil, 5), 0)
+		assert.InDelta(t, 6.0, Float64FromProtoOr(nil, 6), 0)
+		assert.True(t, BoolFromProtoOr(nil, true))
+	})
+
+	t.Run("set values win over the fallback", func(t *testing.T) {
+		t.Parallel()
+		assert.Empty(t, StringFromProtoOr(StringToProto(""), "x"))
+		assert.Equal(t, int32(0), Int32FromProtoOr(Int32ToProto(0), 1))
+		assert.False(t, BoolFromProtoOr(BoolToProto(false), true))
+	})
+}
@@ -0,0 +1,111 @@
+package protobaggins
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// profileLargest is the number of subtrees kept in ProfileReport.Largest
+const profileLargest = 10
+
+// ProfileReport summarizes the shape of a Struct, see Profile
+type ProfileReport struct {
+	// Size is the serialized size of the Struct in bytes, in protobuf wire format
+	Size int
+	// Values is the total number of values beneath the root, of all kinds
+	Values int
+	// Kinds counts values by kind
+	Kinds map[Kind]int
+	// MaxDepth is the length of the longest path, top-level fields having depth 1
+	MaxDepth int
+	// Keys counts how often each struct key occurs anywhere in the document
+	Keys map[string]int
+	// Largest lists the biggest subtrees by serialized size, largest first
+	Largest []Subtree
+}
+
+// Subtree identifies a value inside a profiled Struct
+type Subtree struct {
+	Path string
+	Kind Kind
+	Size int
+}
+
+// Profile walks s and reports kind counts, the maximum depth, key cardinality and the
+// largest subtrees, which helps to find the source of payload bloat and to pick limits
+func Profile(s *structpb.Struct) *ProfileReport {
+	r := &ProfileReport{
+		Size:  proto.Size(s),
+		Kinds: make(map[Kind]int),
+		Keys:  make(map[string]int),
+	}
+	r.profileStruct("", s, 1)
+
+	slices.SortFunc(r.Largest, func(a, b Subtree) int {
+		if c := cmp.Compare(b.Size, a.Size); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	if len(r.Largest) > profileLargest {
+		r.Largest = r.Largest[:profileLargest]
+	}
+	return r
+}
+
+func (r *ProfileReport) profileStruct(path string, s *structpb.Struct, depth int) {
+	for key, field := range s.GetFields() {
+		r.Keys[key]++
+		r.profileValue(joinKey(path, key), field, depth)
+	}
+}
+
+func (r *ProfileReport) profileValue(path string, v *structpb.Value, depth int) {
+	kind := KindOf(v)
+	r.Values++
+	r.Kinds[kind]++
+	r.MaxDepth = max(r.MaxDepth, depth)
+	r.Largest = append(r.Largest, Subtree{Path: path, Kind: kind, Size: proto.Size(v)})
+
+	switch v := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		r.profileStruct(path, v.StructValue, depth+1)
+	case *structpb.Value_ListValue:
+		for i, item := range v.ListValue.GetValues() {
+			r.profileValue(joinIndex(path, i), item, depth+1)
+		}
+	}
+}
+
+// DistinctKeys returns the number of different struct keys in the document
+func (r *ProfileReport) DistinctKeys() int {
+	return len(r.Keys)
+}
+
+// String renders the report as a short human-readable summary
+func (r *ProfileReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "size: %d bytes, values: %d, max depth: %d, distinct keys: %d\n",
+		r.Size, r.Values, r.MaxDepth, r.DistinctKeys())
+
+	b.WriteString("kinds:")
+	for _, kind := range AllKinds {
+		if n := r.Kinds[kind]; n > 0 {
+			fmt.Fprintf(&b, " %s=%d", kind, n)
+		}
+	}
+	b.WriteString("\n")
+
+	if len(r.Largest) > 0 {
+		b.WriteString("largest:\n")
+		for _, sub := range r.Largest {
+			fmt.Fprintf(&b, "  %s (%s): %d bytes\n", sub.Path, sub.Kind, sub.Size)
+		}
+	}
+	return b.String()
+}
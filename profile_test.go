@@ -0,0 +1,77 @@
+package protobaggins
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestProfile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("counts", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"name": "frodo",
+			"age":  50,
+			"items": []any{
+				map[string]any{"name": "ring", "weight": nil},
+				map[string]any{"name": "sting", "sharp": true},
+			},
+			"blob": strings.Repeat("x", 500),
+		})
+		require.NoError(t, err)
+
+		r := Profile(s)
+		assert.Equal(t, proto.Size(s), r.Size)
+		assert.Equal(t, 10, r.Values)
+		assert.Equal(t, map[Kind]int{
+			KindNull: 1, KindBool: 1, KindNumber: 1, KindString: 4, KindList: 1, KindStruct: 2,
+		}, r.Kinds)
+		assert.Equal(t, 3, r.MaxDepth)
+		assert.Equal(t, map[string]int{"name": 3, "age": 1, "items": 1, "weight": 1, "sharp": 1, "blob": 1}, r.Keys)
+		assert.Equal(t, 6, r.DistinctKeys())
+
+		require.NotEmpty(t, r.Largest)
+		assert.Equal(t, Subtree{Path: "blob", Kind: KindString, Size: proto.Size(s.GetFields()["blob"])}, r.Largest[0])
+		assert.Equal(t, "items", r.Largest[1].Path)
+	})
+
+	t.Run("largest is capped", func(t *testing.T) {
+		t.Parallel()
+		fields := make(map[string]any)
+		for i := range 30 {
+			fields[fmt.Sprintf("k%02d", i)] = strings.Repeat("x", i)
+		}
+		s, err := structpb.NewStruct(fields)
+		require.NoError(t, err)
+
+		r := Profile(s)
+		require.Len(t, r.Largest, profileLargest)
+		assert.Equal(t, "k29", r.Largest[0].Path)
+		assert.Equal(t, "k20", r.Largest[profileLargest-1].Path)
+	})
+
+	t.Run("string", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": []any{1, "x"}})
+		require.NoError(t, err)
+		out := Profile(s).String()
+		assert.Contains(t, out, "values: 3, max depth: 2, distinct keys: 1")
+		assert.Contains(t, out, "kinds: number=1 string=1 list=1")
+		assert.Contains(t, out, "  a (list): ")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		r := Profile(nil)
+		assert.Zero(t, r.Size)
+		assert.Zero(t, r.Values)
+		assert.Empty(t, r.Largest)
+	})
+}
@@ -0,0 +1,81 @@
+package protobaggins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// JSONEmitUnpopulated makes MarshalJSON write fields that are not set, with their
+// default value
+func JSONEmitUnpopulated() JSONOption {
+	return func(o *jsonOptions) {
+		o.emitUnpopulated = true
+	}
+}
+
+// JSONDiscardUnknown makes UnmarshalJSON ignore fields that the message does not
+// declare instead of failing
+func JSONDiscardUnknown() JSONOption {
+	return func(o *jsonOptions) {
+		o.discardUnknown = true
+	}
+}
+
+// JSONProtoNames makes MarshalJSON key fields by their name in the .proto file, e.g.
+// "user_id", instead of their JSON name, e.g. "userId"
+func JSONProtoNames() JSONOption {
+	return func(o *jsonOptions) {
+		o.protoNames = true
+	}
+}
+
+// MarshalJSON serializes m with protojson, configured by JSONEmitUnpopulated,
+// JSONProtoNames and JSONIndent. Unlike protojson, whose whitespace varies on purpose,
+// the output is stable: compact, or indented with JSONIndent. Fails with ErrNilMessage
+// for a nil message
+func MarshalJSON(m proto.Message, opts ...JSONOption) ([]byte, error) {
+	if m == nil || !m.ProtoReflect().IsValid() {
+		return nil, fmt.Errorf("%w: cannot marshal a nil message", ErrNilMessage)
+	}
+	o := newJSONOptions(opts)
+	data, err := protojson.MarshalOptions{
+		EmitUnpopulated: o.emitUnpopulated,
+		UseProtoNames:   o.protoNames,
+	}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if o.indent != "" {
+		err = json.Indent(&buf, data, "", o.indent)
+	} else {
+		err = json.Compact(&buf, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON parses data into m with protojson, replacing its contents, configured
+// by JSONDiscardUnknown, JSONMaxSize and JSONMaxDepth, which limits the nesting of
+// messages. Fields are accepted by their JSON name or their name in the .proto file
+// Fails with ErrNilMessage for a nil message
+func UnmarshalJSON(data []byte, m proto.Message, opts ...JSONOption) error {
+	if m == nil || !m.ProtoReflect().IsValid() {
+		return fmt.Errorf("%w: cannot unmarshal into a nil message", ErrNilMessage)
+	}
+	o := newJSONOptions(opts)
+	if o.maxSize > 0 && len(data) > o.maxSize {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrTooLarge, len(data), o.maxSize)
+	}
+	return protojson.UnmarshalOptions{
+		DiscardUnknown: o.discardUnknown,
+		RecursionLimit: o.maxDepth,
+	}.Unmarshal(data, m)
+}
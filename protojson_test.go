@@ -0,0 +1,90 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	field := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("id"),
+		JsonName: proto.String("id"),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+
+	t.Run("compact", func(t *testing.T) {
+		t.Parallel()
+		data, err := MarshalJSON(field)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"id","label":"LABEL_OPTIONAL","jsonName":"id"}`, string(data))
+		assert.NotContains(t, string(data), " ")
+
+		again, err := MarshalJSON(field)
+		require.NoError(t, err)
+		assert.Equal(t, data, again)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		data, err := MarshalJSON(field, JSONProtoNames(), JSONIndent("  "))
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"name\": \"id\",\n  \"label\": \"LABEL_OPTIONAL\",\n  \"json_name\": \"id\"\n}", string(data))
+
+		data, err = MarshalJSON(wrapperspb.String(""), JSONEmitUnpopulated())
+		require.NoError(t, err)
+		assert.JSONEq(t, `""`, string(data))
+	})
+
+	t.Run("nil message", func(t *testing.T) {
+		t.Parallel()
+		_, err := MarshalJSON(nil)
+		require.ErrorIs(t, err, ErrNilMessage)
+		_, err = MarshalJSON((*structpb.Struct)(nil))
+		require.ErrorIs(t, err, ErrNilMessage)
+	})
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("names", func(t *testing.T) {
+		t.Parallel()
+		var field descriptorpb.FieldDescriptorProto
+		require.NoError(t, UnmarshalJSON([]byte(`{"name":"id","json_name":"x","oneofIndex":1}`), &field))
+		assert.Equal(t, "x", field.GetJsonName())
+		assert.Equal(t, int32(1), field.GetOneofIndex())
+	})
+
+	t.Run("unknown fields", func(t *testing.T) {
+		t.Parallel()
+		data := []byte(`{"name":"id","color":"green"}`)
+		var field descriptorpb.FieldDescriptorProto
+		require.Error(t, UnmarshalJSON(data, &field))
+		require.NoError(t, UnmarshalJSON(data, &field, JSONDiscardUnknown()))
+		assert.Equal(t, "id", field.GetName())
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		t.Parallel()
+		var field descriptorpb.FieldDescriptorProto
+		require.ErrorIs(t, UnmarshalJSON([]byte(`{"name":"identifier"}`), &field, JSONMaxSize(10)), ErrTooLarge)
+
+		var file descriptorpb.FileDescriptorProto
+		nested := []byte(`{"messageType":[{"nestedType":[{"nestedType":[{}]}]}]}`)
+		require.Error(t, UnmarshalJSON(nested, &file, JSONMaxDepth(2)))
+		require.NoError(t, UnmarshalJSON(nested, &file, JSONMaxDepth(5)))
+	})
+
+	t.Run("nil message", func(t *testing.T) {
+		t.Parallel()
+		require.ErrorIs(t, UnmarshalJSON([]byte(`{}`), nil), ErrNilMessage)
+	})
+}
@@ -0,0 +1,83 @@
+package protobaggins
+
+import (
+	"slices"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DeletePath removes the value at path within s, in the notation described at
+// PathFilter. Deleting a list item shifts the following items down. Reports whether a
+// value was removed, false if the path is malformed, empty or does not resolve
+func DeletePath(s *structpb.Struct, path string) bool {
+	segments, err := parsePath(path)
+	if err != nil || len(segments) == 0 || s == nil {
+		return false
+	}
+
+	container := structpb.NewStructValue(s)
+	for _, seg := range segments[:len(segments)-1] {
+		if seg.index >= 0 {
+			items := container.GetListValue().GetValues()
+			if seg.index >= len(items) {
+				return false
+			}
+			container = items[seg.index]
+			continue
+		}
+		next, ok := container.GetStructValue().GetFields()[seg.key]
+		if !ok {
+			return false
+		}
+		container = next
+	}
+
+	last := segments[len(segments)-1]
+	if last.index >= 0 {
+		list := container.GetListValue()
+		if last.index >= len(list.GetValues()) {
+			return false
+		}
+		list.Values = slices.Delete(list.Values, last.index, last.index+1)
+		return true
+	}
+	fields := container.GetStructValue().GetFields()
+	if _, ok := fields[last.key]; !ok {
+		return false
+	}
+	delete(fields, last.key)
+	return true
+}
+
+// PruneKeys removes every field, at any depth including inside lists, whose key
+// satisfies remove. Removed values are not descended into. The Struct is modified in
+// place. Returns the sorted paths of the removed fields
+func PruneKeys(s *structpb.Struct, remove func(key string) bool) []string {
+	var removed []string
+	pruneStruct("", s, remove, &removed)
+	slices.Sort(removed)
+	return removed
+}
+
+func pruneStruct(path string, s *structpb.Struct, remove func(key string) bool, removed *[]string) {
+	for key, v := range s.GetFields() {
+		keyPath := joinKey(path, key)
+		if remove(key) {
+			delete(s.Fields, key)
+			*removed = append(*removed, keyPath)
+			continue
+		}
+		pruneValue(keyPath, v, remove, removed)
+	}
+}
+
+func pruneValue(path string, v *structpb.Value, remove func(key string) bool, removed *[]string) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		pruneStruct(path, kind.StructValue, remove, removed)
+	case *structpb.Value_ListValue:
+		for i, item := range kind.ListValue.GetValues() {
+			pruneValue(joinIndex(path, i), item, remove, removed)
+		}
+	}
+}
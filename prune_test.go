@@ -0,0 +1,88 @@
+package protobaggins
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDeletePath(t *testing.T) {
+	t.Parallel()
+
+	newStruct := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(map[string]any{
+			"spec":   map[string]any{"containers": []any{map[string]any{"image": "nginx", "debug": true}, "b", "c"}},
+			"labels": map[string]any{"app.name": "web"},
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	tests := []struct {
+		path    string
+		deleted bool
+		want    map[string]any
+	}{
+		{"spec.containers[0].debug", true, map[string]any{
+			"spec":   map[string]any{"containers": []any{map[string]any{"image": "nginx"}, "b", "c"}},
+			"labels": map[string]any{"app.name": "web"},
+		}},
+		{"spec.containers[1]", true, map[string]any{
+			"spec":   map[string]any{"containers": []any{map[string]any{"image": "nginx", "debug": true}, "c"}},
+			"labels": map[string]any{"app.name": "web"},
+		}},
+		{`labels["app.name"]`, true, map[string]any{
+			"spec":   map[string]any{"containers": []any{map[string]any{"image": "nginx", "debug": true}, "b", "c"}},
+			"labels": map[string]any{},
+		}},
+		{"spec", true, map[string]any{"labels": map[string]any{"app.name": "web"}}},
+		{"spec.containers[3]", false, nil},
+		{"spec.missing.x", false, nil},
+		{"spec.containers.image", false, nil},
+		{"labels[0]", false, nil},
+		{"", false, nil},
+		{"spec..containers", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			s := newStruct(t)
+			assert.Equal(t, tt.deleted, DeletePath(s, tt.path))
+			if tt.want == nil {
+				tt.want = newStruct(t).AsMap()
+			}
+			assert.Equal(t, tt.want, s.AsMap())
+		})
+	}
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		assert.False(t, DeletePath(nil, "a"))
+	})
+}
+
+func TestPruneKeys(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"name":    "frodo",
+		"_debug":  map[string]any{"trace": "x"},
+		"items":   []any{map[string]any{"id": 1, "_internal": true}, []any{map[string]any{"_x": 1}}},
+		"profile": map[string]any{"_secret": "ring", "home": "shire"},
+	})
+	require.NoError(t, err)
+
+	removed := PruneKeys(s, func(key string) bool { return strings.HasPrefix(key, "_") })
+	assert.Equal(t, []string{"_debug", "items[0]._internal", "items[1][0]._x", "profile._secret"}, removed)
+	assert.Equal(t, map[string]any{
+		"name":    "frodo",
+		"items":   []any{map[string]any{"id": 1.0}, []any{map[string]any{}}},
+		"profile": map[string]any{"home": "shire"},
+	}, s.AsMap())
+
+	assert.Empty(t, PruneKeys(nil, func(string) bool { return true }))
+}
@@ -0,0 +1,566 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrSyntax is returned by Compile for malformed expressions
+var ErrSyntax = errors.New("invalid JSONPath")
+
+// maxInteger bounds indexes and slice bounds, as RFC 9535 requires
+const maxInteger = 1<<53 - 1
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrSyntax, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) consume(prefix string) bool {
+	if strings.HasPrefix(p.s[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\n\r", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// segments parses the segments following $ or @
+func (p *parser) segments() ([]segment, error) {
+	var segs []segment
+	for {
+		start := p.pos
+		p.skipSpace()
+		switch {
+		case p.consume(".."):
+			seg, err := p.childSegment()
+			if err != nil {
+				return nil, err
+			}
+			seg.descendant = true
+			segs = append(segs, seg)
+		case p.consume("."):
+			if p.peek() == '[' {
+				return nil, p.errorf("unexpected [ after .")
+			}
+			seg, err := p.childSegment()
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, seg)
+		case p.peek() == '[':
+			seg, err := p.childSegment()
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, seg)
+		default:
+			p.pos = start
+			return segs, nil
+		}
+	}
+}
+
+// childSegment parses a wildcard, a member name shorthand or a bracketed selection
+func (p *parser) childSegment() (segment, error) {
+	switch {
+	case p.consume("*"):
+		return segment{selectors: []selector{{kind: selectWildcard}}}, nil
+	case p.peek() == '[':
+		return p.bracketed()
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) {
+		r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		if !isNameChar(r) || (p.pos == start && r >= '0' && r <= '9') {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		return segment{}, p.errorf("expected a member name")
+	}
+	return segment{selectors: []selector{{kind: selectName, name: p.s[start:p.pos]}}}, nil
+}
+
+func isNameChar(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+		(r >= 0x80 && r != utf8.RuneError)
+}
+
+func (p *parser) bracketed() (segment, error) {
+	p.pos++ // [
+	var seg segment
+	for {
+		p.skipSpace()
+		sel, err := p.selector()
+		if err != nil {
+			return segment{}, err
+		}
+		seg.selectors = append(seg.selectors, sel)
+		p.skipSpace()
+		if p.consume("]") {
+			return seg, nil
+		}
+		if !p.consume(",") {
+			return segment{}, p.errorf("expected , or ]")
+		}
+	}
+}
+
+func (p *parser) selector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.stringLiteral()
+		if err != nil {
+			return selector{}, err
+		}
+		return selector{kind: selectName, name: name}, nil
+	case c == '*':
+		p.pos++
+		return selector{kind: selectWildcard}, nil
+	case c == '?':
+		p.pos++
+		p.skipSpace()
+		filter, err := p.logicalOr()
+		if err != nil {
+			return selector{}, err
+		}
+		return selector{kind: selectFilter, filter: filter}, nil
+	}
+
+	var bounds [3]*int
+	for i := range bounds {
+		p.skipSpace()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			n, err := p.integer()
+			if err != nil {
+				return selector{}, err
+			}
+			bounds[i] = &n
+		}
+		p.skipSpace()
+		if i == 2 || !p.consume(":") {
+			if i == 0 {
+				if bounds[0] == nil {
+					return selector{}, p.errorf("expected a selector")
+				}
+				return selector{kind: selectIndex, index: *bounds[0]}, nil
+			}
+			break
+		}
+	}
+	return selector{kind: selectSlice, slice: bounds}, nil
+}
+
+func (p *parser) integer() (int, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	text := p.s[start:p.pos]
+	if p.pos == digits || (p.s[digits] == '0' && (p.pos > digits+1 || digits > start)) {
+		p.pos = start
+		return 0, p.errorf("invalid integer %q", text)
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n > maxInteger || n < -maxInteger {
+		p.pos = start
+		return 0, p.errorf("integer %s out of range", text)
+	}
+	return n, nil
+}
+
+// stringLiteral parses a single- or double-quoted string with JSON escapes
+func (p *parser) stringLiteral() (string, error) {
+	quote := p.s[p.pos]
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.s) {
+			p.pos = start
+			return "", p.errorf("unterminated string")
+		}
+		c := p.s[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c < 0x20:
+			return "", p.errorf("control character in string")
+		case c != '\\':
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		p.pos++
+		esc := p.peek()
+		p.pos++
+		switch esc {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '/', '\\':
+			b.WriteByte(esc)
+		case '\'', '"':
+			if esc != quote {
+				return "", p.errorf("invalid escape \\%c", esc)
+			}
+			b.WriteByte(esc)
+		case 'u':
+			r, err := p.unicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		default:
+			return "", p.errorf("invalid escape")
+		}
+	}
+}
+
+// unicodeEscape parses the hex digits of a \u escape, combining surrogate pairs
+func (p *parser) unicodeEscape() (rune, error) {
+	hex := func() (rune, bool) {
+		if p.pos+4 > len(p.s) {
+			return 0, false
+		}
+		n, err := strconv.ParseUint(p.s[p.pos:p.pos+4], 16, 16)
+		if err != nil {
+			return 0, false
+		}
+		p.pos += 4
+		return rune(n), true
+	}
+	r, ok := hex()
+	if !ok {
+		return 0, p.errorf("invalid \\u escape")
+	}
+	if !utf16.IsSurrogate(r) {
+		return r, nil
+	}
+	if r >= 0xdc00 || !p.consume(`\u`) {
+		return 0, p.errorf("unpaired surrogate in \\u escape")
+	}
+	low, ok := hex()
+	if !ok || low < 0xdc00 || low > 0xdfff {
+		return 0, p.errorf("unpaired surrogate in \\u escape")
+	}
+	return utf16.DecodeRune(r, low), nil
+}
+
+func (p *parser) logicalOr() (logical, error) {
+	left, err := p.logicalAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		start := p.pos
+		p.skipSpace()
+		if !p.consume("||") {
+			p.pos = start
+			return left, nil
+		}
+		p.skipSpace()
+		right, err := p.logicalAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+}
+
+func (p *parser) logicalAnd() (logical, error) {
+	left, err := p.basic()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		start := p.pos
+		p.skipSpace()
+		if !p.consume("&&") {
+			p.pos = start
+			return left, nil
+		}
+		p.skipSpace()
+		right, err := p.basic()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+}
+
+// basic parses a parenthesized expression, a comparison or a test, each optionally negated
+func (p *parser) basic() (logical, error) {
+	if p.consume("!") {
+		p.skipSpace()
+		if p.peek() != '(' && p.peek() != '@' && p.peek() != '$' && !p.atFunction() {
+			return nil, p.errorf("expected (, a query or a function after !")
+		}
+		inner, err := p.basic()
+		if err != nil {
+			return nil, err
+		}
+		if _, isComparison := inner.(compareExpr); isComparison {
+			return nil, p.errorf("a comparison cannot be negated without parentheses")
+		}
+		return notExpr{inner}, nil
+	}
+	if p.consume("(") {
+		p.skipSpace()
+		inner, err := p.logicalOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("expected )")
+		}
+		return inner, nil
+	}
+
+	start := p.pos
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	opStart := p.pos
+	p.skipSpace()
+	op := p.comparisonOp()
+	if op == "" {
+		p.pos = opStart
+		switch left := left.(type) {
+		case queryOperand:
+			return existsExpr{left.query}, nil
+		case funcCall:
+			if left.fn.result != logicalType {
+				p.pos = start
+				return nil, p.errorf("%s() does not return a logical value", left.fn.name)
+			}
+			return funcTest{left}, nil
+		default:
+			p.pos = start
+			return nil, p.errorf("a literal is not a test")
+		}
+	}
+	p.skipSpace()
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for _, side := range []operand{left, right} {
+		if err := p.checkComparable(start, side); err != nil {
+			return nil, err
+		}
+	}
+	return compareExpr{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) comparisonOp() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// checkComparable enforces that comparisons only use singular queries and functions
+// returning values
+func (p *parser) checkComparable(start int, o operand) error {
+	switch o := o.(type) {
+	case queryOperand:
+		if !o.query.singular() {
+			p.pos = start
+			return p.errorf("comparisons require singular queries")
+		}
+	case funcCall:
+		if o.fn.result != valueType {
+			p.pos = start
+			return p.errorf("%s() does not return a comparable value", o.fn.name)
+		}
+	}
+	return nil
+}
+
+func (p *parser) atFunction() bool {
+	c := p.peek()
+	if c < 'a' || c > 'z' {
+		return false
+	}
+	end := p.pos
+	for end < len(p.s) && (p.s[end] == '_' || (p.s[end] >= 'a' && p.s[end] <= 'z') || (p.s[end] >= '0' && p.s[end] <= '9')) {
+		end++
+	}
+	return end < len(p.s) && p.s[end] == '('
+}
+
+// operand parses a literal, a query or a function call
+func (p *parser) operand() (operand, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segs, err := p.segments()
+		if err != nil {
+			return nil, err
+		}
+		return queryOperand{query{relative: c == '@', segments: segs}}, nil
+	case c == '\'' || c == '"':
+		s, err := p.stringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return literal{structpb.NewStringValue(s)}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case p.atFunction():
+		return p.function()
+	case p.consume("true"):
+		return literal{structpb.NewBoolValue(true)}, nil
+	case p.consume("false"):
+		return literal{structpb.NewBoolValue(false)}, nil
+	case p.consume("null"):
+		return literal{structpb.NewNullValue()}, nil
+	default:
+		return nil, p.errorf("expected a literal, a query or a function")
+	}
+}
+
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?`)
+
+func (p *parser) number() (operand, error) {
+	text := numberPattern.FindString(p.s[p.pos:])
+	if text == "" || text == "-" {
+		return nil, p.errorf("invalid number")
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", text)
+	}
+	p.pos += len(text)
+	return literal{structpb.NewNumberValue(f)}, nil
+}
+
+func (p *parser) function() (operand, error) {
+	start := p.pos
+	open := strings.IndexByte(p.s[p.pos:], '(')
+	name := p.s[p.pos : p.pos+open]
+	fn, ok := functions[name]
+	if !ok {
+		return nil, p.errorf("unknown function %s()", name)
+	}
+	p.pos += open + 1
+
+	call := funcCall{fn: fn}
+	for i, param := range fn.params {
+		p.skipSpace()
+		if i > 0 && !p.consume(",") {
+			return nil, p.errorf("%s() takes %d arguments", name, len(fn.params))
+		}
+		p.skipSpace()
+		arg, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkArgument(start, fn, param, arg); err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+	}
+	p.skipSpace()
+	if !p.consume(")") {
+		return nil, p.errorf("%s() takes %d arguments", name, len(fn.params))
+	}
+
+	// compile literal patterns once
+	if fn.name == "match" || fn.name == "search" {
+		if pattern, ok := call.args[1].(literal); ok {
+			if s, ok := pattern.v.GetKind().(*structpb.Value_StringValue); ok {
+				call.pattern, _ = compilePattern(s.StringValue, fn.name == "match")
+			}
+		}
+	}
+	return call, nil
+}
+
+func (p *parser) checkArgument(start int, fn *function, param paramType, arg operand) error {
+	var ok bool
+	switch arg := arg.(type) {
+	case queryOperand:
+		ok = param == nodesType || arg.query.singular()
+	case funcCall:
+		ok = param == valueType && arg.fn.result == valueType
+	case literal:
+		ok = param == valueType
+	}
+	if !ok {
+		p.pos = start
+		return p.errorf("invalid argument for %s()", fn.name)
+	}
+	return nil
+}
+
+// compilePattern converts an I-regexp (RFC 9485) to a Go regexp, anchored for match().
+// In an I-regexp . matches any character except line breaks
+func compilePattern(pattern string, anchored bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			b.WriteString(pattern[i : i+2])
+			i++
+			continue
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '.' && !inClass:
+			b.WriteString(`[^\n\r]`)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	expr := b.String()
+	if anchored {
+		expr = `^(?:` + expr + `)$`
+	}
+	return regexp.Compile(expr)
+}
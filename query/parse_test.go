@@ -0,0 +1,91 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{
+		"$",
+		"$.a.b",
+		"$ .a [0] ..b",
+		"$[ 'a' , \"b\" ]",
+		`$["é𝄞\n'"]`,
+		`$['it\'s']`,
+		"$[-1]",
+		"$[1:2:3]",
+		"$[::]",
+		"$.ünïcode_1",
+		"$[?@.a==1]",
+		"$[? (@.a) && !(@.b) ]",
+		"$[?@.a == -1.5e3]",
+		"$[?length(@) == count(@.*)]",
+		"$[?value(@..a) == 1]",
+		`$[?match(@.a, '[a-z]+')]`,
+		`$[?match(@.a, '[')]`,
+		"$[?@[0] == $.x['y']]",
+	}
+	for _, expr := range valid {
+		t.Run(expr, func(t *testing.T) {
+			t.Parallel()
+			_, err := Compile(expr)
+			assert.NoError(t, err)
+		})
+	}
+
+	invalid := []string{
+		"",
+		"a",
+		" $",
+		"$ ",
+		"$.",
+		"$..",
+		"$.[0]",
+		"$[",
+		"$[]",
+		"$[0",
+		"$[01]",
+		"$[-0]",
+		"$[+1]",
+		"$[9007199254740992]",
+		"$[1:2:3:4]",
+		`$["a]`,
+		`$['a\"']`,
+		`$["\uD834"]`,
+		"$[?@.a=1]",
+		"$[?1]",
+		"$[?!@.a == 1]",
+		"$[?@.* == 1]",
+		"$[?@..a == 1]",
+		"$[?count(@.*)]",
+		"$[?length(@.*) == 1]",
+		"$[?match(@.a)]",
+		"$[?unknown(@.a)]",
+		"$[?length(@.a)]",
+		"$[?count(1) == 1]",
+		"$[?@.a == 01]",
+	}
+	for _, expr := range invalid {
+		t.Run(expr, func(t *testing.T) {
+			t.Parallel()
+			_, err := Compile(expr)
+			require.ErrorIs(t, err, ErrSyntax)
+		})
+	}
+
+	t.Run("error offset", func(t *testing.T) {
+		t.Parallel()
+		_, err := Compile("$.a[?@.b=1]")
+		assert.ErrorContains(t, err, "invalid JSONPath at offset 8")
+	})
+
+	t.Run("must compile", func(t *testing.T) {
+		t.Parallel()
+		assert.Panics(t, func() { MustCompile("$[") })
+	})
+}
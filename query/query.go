@@ -0,0 +1,473 @@
+// Package query evaluates JSONPath (RFC 9535) expressions directly against
+// *structpb.Value trees, without converting them to map[string]any first.
+//
+// The full RFC syntax is supported: name, wildcard, index, slice and filter selectors,
+// descendant segments and the length, count, match, search and value functions.
+// Object members are visited in sorted key order, so results are deterministic.
+package query
+
+import (
+	"regexp"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Query is a compiled JSONPath expression, safe for concurrent use
+type Query struct {
+	expr  string
+	query query
+}
+
+// Compile parses a JSONPath expression, which must start with $
+func Compile(expr string) (*Query, error) {
+	p := parser{s: expr}
+	if !p.consume("$") {
+		return nil, p.errorf("expression must start with $")
+	}
+	segs, err := p.segments()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return &Query{expr: expr, query: query{segments: segs}}, nil
+}
+
+// MustCompile is Compile for expressions known to be valid, it panics on errors
+func MustCompile(expr string) *Query {
+	q, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// String returns the expression the query was compiled from
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Eval returns the values selected by the query, in order, with their paths in the
+// notation of protobaggins.GetPath. The values are the nodes of v, not copies
+func (q *Query) Eval(v *structpb.Value) []protobaggins.Match {
+	if v == nil {
+		return nil
+	}
+	return q.query.eval(v, node{Value: v})
+}
+
+// First returns the first value selected by the query
+// Reports false if nothing was selected
+func (q *Query) First(v *structpb.Value) (protobaggins.Match, bool) {
+	matches := q.Eval(v)
+	if len(matches) == 0 {
+		return protobaggins.Match{}, false
+	}
+	return matches[0], true
+}
+
+// Eval compiles expr and evaluates it against v, see Query.Eval
+func Eval(expr string, v *structpb.Value) ([]protobaggins.Match, error) {
+	q, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.Eval(v), nil
+}
+
+type node = protobaggins.Match
+
+// query is a sequence of segments applied to the root or, in filters, the current node
+type query struct {
+	relative bool
+	segments []segment
+}
+
+// singular reports whether the query selects at most one node
+func (q query) singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		if kind := seg.selectors[0].kind; kind != selectName && kind != selectIndex {
+			return false
+		}
+	}
+	return true
+}
+
+func (q query) eval(root *structpb.Value, start node) []node {
+	nodes := []node{start}
+	for _, seg := range q.segments {
+		var next []node
+		for _, n := range nodes {
+			if seg.descendant {
+				descend(n, func(d node) {
+					next = seg.apply(root, d, next)
+				})
+			} else {
+				next = seg.apply(root, n, next)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+func (seg segment) apply(root *structpb.Value, n node, out []node) []node {
+	for _, sel := range seg.selectors {
+		out = sel.apply(root, n, out)
+	}
+	return out
+}
+
+// descend visits n and its descendants in pre-order
+func descend(n node, fn func(node)) {
+	fn(n)
+	children(n, func(child node) bool {
+		descend(child, fn)
+		return true
+	})
+}
+
+// children visits the members of a struct in sorted key order or the items of a list
+func children(n node, fn func(node) bool) {
+	switch kind := n.Value.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			if !fn(node{Path: protobaggins.JoinPathKey(n.Path, k), Value: fields[k]}) {
+				return
+			}
+		}
+	case *structpb.Value_ListValue:
+		for i, item := range kind.ListValue.GetValues() {
+			if !fn(node{Path: protobaggins.JoinPathIndex(n.Path, i), Value: item}) {
+				return
+			}
+		}
+	}
+}
+
+type selectorKind int
+
+const (
+	selectName selectorKind = iota
+	selectWildcard
+	selectIndex
+	selectSlice
+	selectFilter
+)
+
+type selector struct {
+	kind   selectorKind
+	name   string
+	index  int
+	slice  [3]*int
+	filter logical
+}
+
+func (sel selector) apply(root *structpb.Value, n node, out []node) []node {
+	switch sel.kind {
+	case selectName:
+		if child, ok := n.Value.GetStructValue().GetFields()[sel.name]; ok {
+			out = append(out, node{Path: protobaggins.JoinPathKey(n.Path, sel.name), Value: child})
+		}
+	case selectWildcard:
+		children(n, func(child node) bool {
+			out = append(out, child)
+			return true
+		})
+	case selectIndex:
+		items := n.Value.GetListValue().GetValues()
+		i := sel.index
+		if i < 0 {
+			i += len(items)
+		}
+		if i >= 0 && i < len(items) {
+			out = append(out, node{Path: protobaggins.JoinPathIndex(n.Path, i), Value: items[i]})
+		}
+	case selectSlice:
+		items := n.Value.GetListValue().GetValues()
+		for _, i := range sliceIndexes(sel.slice, len(items)) {
+			out = append(out, node{Path: protobaggins.JoinPathIndex(n.Path, i), Value: items[i]})
+		}
+	case selectFilter:
+		children(n, func(child node) bool {
+			if sel.filter.test(root, child) {
+				out = append(out, child)
+			}
+			return true
+		})
+	}
+	return out
+}
+
+// sliceIndexes returns the indexes selected by start:end:step in a list of n items
+func sliceIndexes(bounds [3]*int, n int) []int {
+	step := 1
+	if bounds[2] != nil {
+		step = *bounds[2]
+	}
+	if step == 0 {
+		return nil
+	}
+
+	normalize := func(i int) int {
+		if i < 0 {
+			return n + i
+		}
+		return i
+	}
+	var start, end int
+	if step > 0 {
+		start, end = 0, n
+	} else {
+		start, end = n-1, -n-1
+	}
+	if bounds[0] != nil {
+		start = normalize(*bounds[0])
+	}
+	if bounds[1] != nil {
+		end = normalize(*bounds[1])
+	}
+
+	var indexes []int
+	if step > 0 {
+		for i := max(start, 0); i < min(end, n); i += step {
+			indexes = append(indexes, i)
+		}
+	} else {
+		for i := min(start, n-1); i > max(end, -1); i += step {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// logical is a filter expression
+type logical interface {
+	test(root *structpb.Value, current node) bool
+}
+
+type orExpr struct{ left, right logical }
+
+func (e orExpr) test(root *structpb.Value, n node) bool {
+	return e.left.test(root, n) || e.right.test(root, n)
+}
+
+type andExpr struct{ left, right logical }
+
+func (e andExpr) test(root *structpb.Value, n node) bool {
+	return e.left.test(root, n) && e.right.test(root, n)
+}
+
+type notExpr struct{ inner logical }
+
+func (e notExpr) test(root *structpb.Value, n node) bool {
+	return !e.inner.test(root, n)
+}
+
+// existsExpr is true when the query selects at least one node
+type existsExpr struct{ query query }
+
+func (e existsExpr) test(root *structpb.Value, n node) bool {
+	return len(e.query.nodes(root, n)) > 0
+}
+
+func (q query) nodes(root *structpb.Value, current node) []node {
+	if q.relative {
+		return q.eval(root, current)
+	}
+	return q.eval(root, node{Value: root})
+}
+
+// funcTest is a function returning a logical value used as a test
+type funcTest struct{ call funcCall }
+
+func (e funcTest) test(root *structpb.Value, n node) bool {
+	v, ok := e.call.value(root, n)
+	return ok && v.GetBoolValue()
+}
+
+type compareExpr struct {
+	op          string
+	left, right operand
+}
+
+func (e compareExpr) test(root *structpb.Value, n node) bool {
+	a, aOK := e.left.value(root, n)
+	b, bOK := e.right.value(root, n)
+	switch e.op {
+	case "==":
+		return equal(a, aOK, b, bOK)
+	case "!=":
+		return !equal(a, aOK, b, bOK)
+	case "<":
+		return less(a, aOK, b, bOK)
+	case "<=":
+		return less(a, aOK, b, bOK) || equal(a, aOK, b, bOK)
+	case ">":
+		return less(b, bOK, a, aOK)
+	default:
+		return less(b, bOK, a, aOK) || equal(a, aOK, b, bOK)
+	}
+}
+
+// equal compares two operand values, where a missing value only equals another
+func equal(a *structpb.Value, aOK bool, b *structpb.Value, bOK bool) bool {
+	if !aOK || !bOK {
+		return aOK == bOK
+	}
+	return proto.Equal(a, b)
+}
+
+// less orders numbers and strings, values of other or mixed kinds are not ordered
+func less(a *structpb.Value, aOK bool, b *structpb.Value, bOK bool) bool {
+	if !aOK || !bOK {
+		return false
+	}
+	switch av := a.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		bv, ok := b.GetKind().(*structpb.Value_NumberValue)
+		return ok && av.NumberValue < bv.NumberValue
+	case *structpb.Value_StringValue:
+		bv, ok := b.GetKind().(*structpb.Value_StringValue)
+		return ok && av.StringValue < bv.StringValue
+	}
+	return false
+}
+
+// operand is a literal, singular query or function call, which yields a value or,
+// when it reports false, nothing
+type operand interface {
+	value(root *structpb.Value, current node) (*structpb.Value, bool)
+}
+
+type literal struct{ v *structpb.Value }
+
+func (l literal) value(*structpb.Value, node) (*structpb.Value, bool) {
+	return l.v, true
+}
+
+type queryOperand struct{ query query }
+
+func (o queryOperand) value(root *structpb.Value, n node) (*structpb.Value, bool) {
+	nodes := o.query.nodes(root, n)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0].Value, true
+}
+
+type paramType int
+
+const (
+	valueType paramType = iota
+	logicalType
+	nodesType
+)
+
+type function struct {
+	name   string
+	params []paramType
+	result paramType
+	call   func(args []evaluated, pattern *regexp.Regexp) (*structpb.Value, bool)
+}
+
+// evaluated is a function argument, a value for value parameters and nodes otherwise
+type evaluated struct {
+	value *structpb.Value
+	ok    bool
+	nodes []node
+}
+
+var functions = map[string]*function{}
+
+func init() {
+	for _, fn := range []*function{
+		{name: "length", params: []paramType{valueType}, result: valueType, call: fnLength},
+		{name: "count", params: []paramType{nodesType}, result: valueType, call: fnCount},
+		{name: "value", params: []paramType{nodesType}, result: valueType, call: fnValue},
+		{name: "match", params: []paramType{valueType, valueType}, result: logicalType, call: fnMatch(true)},
+		{name: "search", params: []paramType{valueType, valueType}, result: logicalType, call: fnMatch(false)},
+	} {
+		functions[fn.name] = fn
+	}
+}
+
+type funcCall struct {
+	fn      *function
+	args    []operand
+	pattern *regexp.Regexp
+}
+
+func (c funcCall) value(root *structpb.Value, n node) (*structpb.Value, bool) {
+	args := make([]evaluated, len(c.args))
+	for i, arg := range c.args {
+		if c.fn.params[i] == nodesType {
+			args[i].nodes = arg.(queryOperand).query.nodes(root, n)
+			continue
+		}
+		args[i].value, args[i].ok = arg.value(root, n)
+	}
+	return c.fn.call(args, c.pattern)
+}
+
+func fnLength(args []evaluated, _ *regexp.Regexp) (*structpb.Value, bool) {
+	switch kind := args[0].value.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return structpb.NewNumberValue(float64(utf8.RuneCountInString(kind.StringValue))), true
+	case *structpb.Value_ListValue:
+		return structpb.NewNumberValue(float64(len(kind.ListValue.GetValues()))), true
+	case *structpb.Value_StructValue:
+		return structpb.NewNumberValue(float64(len(kind.StructValue.GetFields()))), true
+	}
+	return nil, false
+}
+
+func fnCount(args []evaluated, _ *regexp.Regexp) (*structpb.Value, bool) {
+	return structpb.NewNumberValue(float64(len(args[0].nodes))), true
+}
+
+func fnValue(args []evaluated, _ *regexp.Regexp) (*structpb.Value, bool) {
+	if len(args[0].nodes) != 1 {
+		return nil, false
+	}
+	return args[0].nodes[0].Value, true
+}
+
+func fnMatch(anchored bool) func([]evaluated, *regexp.Regexp) (*structpb.Value, bool) {
+	return func(args []evaluated, pattern *regexp.Regexp) (*structpb.Value, bool) {
+		s, ok := args[0].value.GetKind().(*structpb.Value_StringValue)
+		if !ok {
+			return structpb.NewBoolValue(false), true
+		}
+		if pattern == nil {
+			p, ok := args[1].value.GetKind().(*structpb.Value_StringValue)
+			if !ok {
+				return structpb.NewBoolValue(false), true
+			}
+			var err error
+			if pattern, err = compilePattern(p.StringValue, anchored); err != nil {
+				return structpb.NewBoolValue(false), true
+			}
+		}
+		return structpb.NewBoolValue(pattern.MatchString(s.StringValue)), true
+	}
+}
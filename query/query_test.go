@@ -0,0 +1,199 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func mustValue(t *testing.T, doc string) *structpb.Value {
+	t.Helper()
+	var raw any
+	require.NoError(t, json.Unmarshal([]byte(doc), &raw))
+	v, err := structpb.NewValue(raw)
+	require.NoError(t, err)
+	return v
+}
+
+// store is the example document from RFC 9535 section 1.5
+const store = `{"store": {
+	"book": [
+		{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+		{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+		{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+		{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+	],
+	"bicycle": {"color": "red", "price": 399}
+}}`
+
+func paths(t *testing.T, expr string, v *structpb.Value) []string {
+	t.Helper()
+	matches, err := Eval(expr, v)
+	require.NoError(t, err)
+	out := []string{}
+	for _, m := range matches {
+		out = append(out, m.Path)
+	}
+	return out
+}
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	doc := mustValue(t, store)
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"$", []string{""}},
+		{"$.store.book[*].author", []string{
+			"store.book[0].author", "store.book[1].author", "store.book[2].author", "store.book[3].author",
+		}},
+		{"$..author", []string{
+			"store.book[0].author", "store.book[1].author", "store.book[2].author", "store.book[3].author",
+		}},
+		{"$.store.*", []string{"store.bicycle", "store.book"}},
+		{"$.store..price", []string{
+			"store.bicycle.price",
+			"store.book[0].price", "store.book[1].price", "store.book[2].price", "store.book[3].price",
+		}},
+		{"$..book[2]", []string{"store.book[2]"}},
+		{"$..book[-1]", []string{"store.book[3]"}},
+		{"$..book[0,1]", []string{"store.book[0]", "store.book[1]"}},
+		{"$..book[:2]", []string{"store.book[0]", "store.book[1]"}},
+		{"$..book[?@.isbn]", []string{"store.book[2]", "store.book[3]"}},
+		{"$..book[?@.price<10]", []string{"store.book[0]", "store.book[2]"}},
+		{"$.store.book[?@.price > $.store.bicycle.price]", []string{}},
+		{`$.store.book[?@.author == "Herman Melville"].title`, []string{"store.book[2].title"}},
+		{`$.store.book[?!@.isbn && @.category != 'reference']`, []string{"store.book[1]"}},
+		{`$.store.book[?(@.price < 9 || @.price > 20) && @.isbn]`, []string{"store.book[2]", "store.book[3]"}},
+		{`$.store.book[?length(@.title) == 21].title`, []string{"store.book[3].title"}},
+		{`$.store.book[?match(@.author, "J.*")]`, []string{"store.book[3]"}},
+		{`$.store.book[?match(@.author, "[")]`, []string{}},
+		{`$.store.book[?search(@.title, "of")]`, []string{"store.book[0]", "store.book[1]", "store.book[3]"}},
+		{`$.store[?count(@.*) == 2]`, []string{"store.bicycle"}},
+		{`$.store.book[?value(@..isbn) == "0-553-21311-3"]`, []string{"store.book[2]"}},
+		{`$["store"]['bicycle']["color"]`, []string{"store.bicycle.color"}},
+		{"$.missing", []string{}},
+		{"$.store.book.author", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, paths(t, tt.expr, doc))
+		})
+	}
+}
+
+func TestEvalValues(t *testing.T) {
+	t.Parallel()
+
+	doc := mustValue(t, `{"labels": {"app.name": "web", "tier": "front"}}`)
+	matches, err := Eval(`$.labels["app.name"]`, doc)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, `labels["app.name"]`, matches[0].Path)
+	assert.Same(t, doc.GetStructValue().GetFields()["labels"].GetStructValue().GetFields()["app.name"], matches[0].Value)
+
+	q := MustCompile("$.labels.tier")
+	assert.Equal(t, "$.labels.tier", q.String())
+	first, ok := q.First(doc)
+	require.True(t, ok)
+	assert.Equal(t, "front", first.Value.GetStringValue())
+	_, ok = MustCompile("$.nope").First(doc)
+	assert.False(t, ok)
+
+	assert.Empty(t, q.Eval(nil))
+}
+
+func TestSlices(t *testing.T) {
+	t.Parallel()
+
+	doc := mustValue(t, `["a", "b", "c", "d", "e", "f", "g"]`)
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"$[1:3]", []string{"[1]", "[2]"}},
+		{"$[5:]", []string{"[5]", "[6]"}},
+		{"$[1:5:2]", []string{"[1]", "[3]"}},
+		{"$[5:1:-2]", []string{"[5]", "[3]"}},
+		{"$[::-1]", []string{"[6]", "[5]", "[4]", "[3]", "[2]", "[1]", "[0]"}},
+		{"$[-2:]", []string{"[5]", "[6]"}},
+		{"$[-100:2]", []string{"[0]", "[1]"}},
+		{"$[::0]", []string{}},
+		{"$[7]", []string{}},
+		{"$[-8]", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, paths(t, tt.expr, doc))
+		})
+	}
+}
+
+func TestComparisons(t *testing.T) {
+	t.Parallel()
+
+	// examples from RFC 9535 section 2.3.5.3
+	doc := mustValue(t, `{"obj": {"x": "y"}, "arr": [2, 3]}`)
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"$.absent1 == $.absent2", true},
+		{"$.absent1 <= $.absent2", true},
+		{`$.absent == 'g'`, false},
+		{"$.absent1 != $.absent2", false},
+		{`$.absent != 'g'`, true},
+		{"1 <= 2", true},
+		{"1 > 2", false},
+		{"13 == '13'", false},
+		{"'a' <= 'b'", true},
+		{"'a' > 'b'", false},
+		{"$.obj == $.arr", false},
+		{"$.obj != $.arr", true},
+		{"$.obj == $.obj", true},
+		{"$.obj != $.obj", false},
+		{"$.arr == $.arr", true},
+		{"$.arr != $.arr", false},
+		{"$.obj == 17", false},
+		{"$.obj != 17", true},
+		{"$.obj <= $.arr", false},
+		{"$.obj < $.arr", false},
+		{"$.obj <= $.obj", true},
+		{"$.arr <= $.arr", true},
+		{"1 <= $.arr", false},
+		{"1 >= $.arr", false},
+		{"1 > $.arr", false},
+		{"1 < $.arr", false},
+		{"true <= true", true},
+		{"true > true", false},
+		{"1 == 1.0", true},
+		{"null == null", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+			// the comparison is evaluated once per child of the root object
+			got := len(paths(t, "$[?"+tt.expr+"]", doc)) == 2
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	t.Parallel()
+
+	doc := mustValue(t, `{"o": {"j": 1, "k": 2}, "a": [5, 3, [{"j": 4}, {"k": 6}]]}`)
+	assert.Equal(t, []string{"a[2][0].j", "o.j"}, paths(t, "$..j", doc))
+	assert.Equal(t, []string{"o", "a[2][0]", "a[2][1]"}, paths(t, "$..[?@.j || @.k == 6]", doc))
+	assert.Equal(t, []string{
+		"a", "o", "a[0]", "a[1]", "a[2]", "a[2][0]", "a[2][1]", "a[2][0].j", "a[2][1].k", "o.j", "o.k",
+	}, paths(t, "$..*", doc))
+	assert.Equal(t, []string{"a[2]", "a[2][1]"}, paths(t, "$..[-1]", doc))
+}
@@ -0,0 +1,223 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrQueryConflict is returned by QueryToStruct for parameters that set the same key
+// twice, e.g. to a value and to nested keys
+var ErrQueryConflict = errors.New("conflicting query parameters")
+
+// QueryOption configures QueryToStruct and StructToQuery
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	commaLists bool
+	strings    bool
+}
+
+// QueryCommaLists splits parameter values at commas into lists, so "tags=a,b" gives
+// ["a", "b"], and makes StructToQuery join lists with commas likewise
+func QueryCommaLists() QueryOption {
+	return func(o *queryOptions) {
+		o.commaLists = true
+	}
+}
+
+// QueryStrings keeps every parameter value a string instead of inferring its type
+func QueryStrings() QueryOption {
+	return func(o *queryOptions) {
+		o.strings = true
+	}
+}
+
+// QueryToStruct converts query parameters to a Struct:
+//   - "true" and "false" become bools, and numbers in JSON syntax become numbers, except
+//     integers beyond ±2^53, so "007", "1e400" or "+1" stay strings; see QueryStrings
+//   - repeated parameters become lists, as do those named with a "[]" suffix, e.g.
+//     "tags[]=a", and those holding commas with QueryCommaLists
+//   - bracketed names nest, "filter[status]=open" gives {"filter": {"status": "open"}};
+//     names with unbalanced brackets are kept as they are
+//
+// Fails with ErrQueryConflict when names such as "filter" and "filter[status]" set a key
+// both to a value and to nested keys, or to two values as "tags" and "tags[]" do
+func QueryToStruct(values url.Values, opts ...QueryOption) (*structpb.Struct, error) {
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	root := &structpb.Struct{Fields: make(map[string]*structpb.Value)}
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		keys, list := parseQueryName(name)
+		var items []*structpb.Value
+		for _, raw := range values[name] {
+			parts := []string{raw}
+			if o.commaLists && strings.Contains(raw, ",") {
+				parts = strings.Split(raw, ",")
+				list = true
+			}
+			for _, part := range parts {
+				items = append(items, o.infer(part))
+			}
+		}
+
+		var v *structpb.Value
+		switch {
+		case list || len(items) > 1:
+			v = structpb.NewListValue(&structpb.ListValue{Values: items})
+		case len(items) == 1:
+			v = items[0]
+		default:
+			continue
+		}
+		if err := setNestedValue(root, keys, v, ErrQueryConflict); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return root, nil
+}
+
+// parseQueryName splits "a[b][c][]" into the keys a, b and c, reporting whether it ends
+// with "[]"
+func parseQueryName(name string) ([]string, bool) {
+	open := strings.IndexByte(name, '[')
+	if open <= 0 {
+		return []string{name}, false
+	}
+	keys := []string{name[:open]}
+	list := false
+	for rest := name[open:]; rest != ""; {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 || list {
+			return []string{name}, false
+		}
+		if key := rest[1:end]; key == "" {
+			list = true
+		} else {
+			keys = append(keys, key)
+		}
+		rest = rest[end+1:]
+	}
+	return keys, list
+}
+
+// infer converts a parameter value to a bool, number or string
+func (o queryOptions) infer(s string) *structpb.Value {
+	if o.strings {
+		return structpb.NewStringValue(s)
+	}
+	return inferScalar(s)
+}
+
+// inferScalar converts "true" and "false" to bools and numbers in JSON syntax to
+// numbers, unless they are integers beyond ±2^53, and anything else to a string
+func inferScalar(s string) *structpb.Value {
+	switch s {
+	case "true":
+		return structpb.NewBoolValue(true)
+	case "false":
+		return structpb.NewBoolValue(false)
+	}
+	if isJSONNumber(s) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err == nil && (f != math.Trunc(f) || -maxSafeInteger <= f && f <= maxSafeInteger) {
+			return structpb.NewNumberValue(f)
+		}
+	}
+	return structpb.NewStringValue(s)
+}
+
+// setNestedValue stores v under the nested keys of s, creating Structs along the way,
+// and fails with conflict when a key is already set to something else
+func setNestedValue(s *structpb.Struct, keys []string, v *structpb.Value, conflict error) error {
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := s.Fields[key]
+		if !ok {
+			next = structpb.NewStructValue(&structpb.Struct{Fields: make(map[string]*structpb.Value)})
+			s.Fields[key] = next
+		}
+		if KindOf(next) != KindStruct {
+			return fmt.Errorf("%w: %s has a value and nested keys", conflict, key)
+		}
+		s = next.GetStructValue()
+	}
+	key := keys[len(keys)-1]
+	if _, ok := s.Fields[key]; ok {
+		return fmt.Errorf("%w: %s is set more than once", conflict, key)
+	}
+	s.Fields[key] = v
+	return nil
+}
+
+// StructToQuery converts s to query parameters, the reverse of QueryToStruct. Nested
+// keys are bracketed, lists become repeated parameters, or one joined with commas with
+// QueryCommaLists, and scalars are formatted with ToString. Nulls, empty lists and
+// empty Structs are omitted, so a list of one item comes back as that item. Fails with
+// ErrUnexpectedKind for lists holding lists or Structs
+func StructToQuery(s *structpb.Struct, opts ...QueryOption) (url.Values, error) {
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	values := make(url.Values)
+	if err := o.addStruct(values, "", s); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (o queryOptions) addStruct(values url.Values, prefix string, s *structpb.Struct) error {
+	for _, key := range sortedKeys(s) {
+		name := key
+		if prefix != "" {
+			name = prefix + "[" + key + "]"
+		}
+		if err := o.addValue(values, name, s.GetFields()[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o queryOptions) addValue(values url.Values, name string, v *structpb.Value) error {
+	switch KindOf(v) {
+	case KindNull, KindUnset:
+		return nil
+	case KindStruct:
+		return o.addStruct(values, name, v.GetStructValue())
+	case KindList:
+		items := make([]string, 0, len(v.GetListValue().GetValues()))
+		for i, item := range v.GetListValue().GetValues() {
+			if k := KindOf(item); k == KindList || k == KindStruct {
+				return fmt.Errorf("%s[%d]: %w: cannot write a %s as a query parameter", name, i, ErrUnexpectedKind, k)
+			}
+			if KindOf(item) != KindNull {
+				str, _ := ToString(item)
+				items = append(items, str)
+			}
+		}
+		switch {
+		case len(items) == 0:
+		case o.commaLists:
+			values.Add(name, strings.Join(items, ","))
+		default:
+			values[name] = append(values[name], items...)
+		}
+		return nil
+	default:
+		str, _ := ToString(v)
+		values.Add(name, str)
+		return nil
+	}
+}
@@ -0,0 +1,142 @@
+package protobaggins
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestQueryToStruct(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query string
+		opts  []QueryOption
+		want  map[string]any
+	}{
+		{
+			name:  "inference",
+			query: "age=50&ratio=0.5&neg=-3&ring=true&lost=false&zip=007&plus=%2B1&huge=12345678901234567890&word=shire&empty=",
+			want: map[string]any{
+				"age": 50.0, "ratio": 0.5, "neg": -3.0, "ring": true, "lost": false,
+				"zip": "007", "plus": "+1", "huge": "12345678901234567890", "word": "shire", "empty": "",
+			},
+		},
+		{
+			name:  "strings",
+			query: "age=50&ring=true",
+			opts:  []QueryOption{QueryStrings()},
+			want:  map[string]any{"age": "50", "ring": "true"},
+		},
+		{
+			name:  "repeated keys",
+			query: "tag=a&tag=2&one[]=x&csv=a,b",
+			want: map[string]any{
+				"tag": []any{"a", 2.0},
+				"one": []any{"x"},
+				"csv": "a,b",
+			},
+		},
+		{
+			name:  "comma lists",
+			query: "csv=a,1&csv=b&plain=c",
+			opts:  []QueryOption{QueryCommaLists()},
+			want:  map[string]any{"csv": []any{"a", 1.0, "b"}, "plain": "c"},
+		},
+		{
+			name:  "nested keys",
+			query: "filter[status]=open&filter[owner][name]=frodo&filter[tags][]=ring&sort=age",
+			want: map[string]any{
+				"filter": map[string]any{
+					"status": "open",
+					"owner":  map[string]any{"name": "frodo"},
+					"tags":   []any{"ring"},
+				},
+				"sort": "age",
+			},
+		},
+		{
+			name:  "malformed brackets are literal",
+			query: "a[b=1&a]b=2&[c]=3&d[]x=4",
+			want:  map[string]any{"a[b": 1.0, "a]b": 2.0, "[c]": 3.0, "d[]x": 4.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			values, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			got, err := QueryToStruct(values, tt.opts...)
+			require.NoError(t, err)
+			want, err := structpb.NewStruct(tt.want)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(want, got), "%v", got)
+		})
+	}
+
+	t.Run("conflicts", func(t *testing.T) {
+		t.Parallel()
+		for _, query := range []string{
+			"filter=x&filter[status]=open",
+			"tags=a&tags[]=b",
+		} {
+			values, err := url.ParseQuery(query)
+			require.NoError(t, err)
+			_, err = QueryToStruct(values)
+			require.ErrorIs(t, err, ErrQueryConflict, query)
+		}
+	})
+}
+
+func TestStructToQuery(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"age":    50.0,
+		"ring":   true,
+		"gone":   nil,
+		"tags":   []any{"a", 2.0, nil},
+		"none":   []any{},
+		"filter": map[string]any{"status": "open", "owner": map[string]any{"name": "frodo"}},
+	})
+	require.NoError(t, err)
+
+	t.Run("repeated keys", func(t *testing.T) {
+		t.Parallel()
+		values, err := StructToQuery(s)
+		require.NoError(t, err)
+		assert.Equal(t, "age=50&filter%5Bowner%5D%5Bname%5D=frodo&filter%5Bstatus%5D=open&ring=true&tags=a&tags=2", values.Encode())
+
+		back, err := QueryToStruct(values)
+		require.NoError(t, err)
+		want, err := structpb.NewStruct(map[string]any{
+			"age":    50.0,
+			"ring":   true,
+			"tags":   []any{"a", 2.0},
+			"filter": map[string]any{"status": "open", "owner": map[string]any{"name": "frodo"}},
+		})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, back), "%v", back)
+	})
+
+	t.Run("comma lists", func(t *testing.T) {
+		t.Parallel()
+		values, err := StructToQuery(s, QueryCommaLists())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a,2"}, values["tags"])
+	})
+
+	t.Run("nested lists", func(t *testing.T) {
+		t.Parallel()
+		nested, err := structpb.NewStruct(map[string]any{"rows": []any{[]any{1.0}}})
+		require.NoError(t, err)
+		_, err = StructToQuery(nested)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		assert.ErrorContains(t, err, "rows[0]")
+	})
+}
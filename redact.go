@@ -0,0 +1,208 @@
+package protobaggins
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultRedactionPlaceholder replaces values redacted by Redact
+const DefaultRedactionPlaceholder = "[REDACTED]"
+
+// RedactOption configures Redact
+type RedactOption func(*redactOptions)
+
+type redactOptions struct {
+	placeholder string
+	remove      bool
+}
+
+// RedactPlaceholder sets the string that replaces redacted values
+func RedactPlaceholder(placeholder string) RedactOption {
+	return func(o *redactOptions) {
+		o.placeholder = placeholder
+	}
+}
+
+// RedactRemove removes redacted fields and list items instead of replacing them.
+// Removing a list item shifts the following items down
+func RedactRemove() RedactOption {
+	return func(o *redactOptions) {
+		o.remove = true
+	}
+}
+
+// Redact replaces every value in s whose path matches one of patterns with a
+// placeholder, see RedactPlaceholder and RedactRemove. Patterns use the notation
+// described at PathFilter, where a `*` key matches any key, `[*]` any list index and a
+// `**` key any number of nested keys and indexes, including none, e.g.
+// `users[*].password` or `**.token`. Structs and lists are redacted as a whole.
+// The Struct is modified in place. Returns the sorted paths of the redacted values,
+// and fails without modifying s if a pattern is malformed
+func Redact(s *structpb.Struct, patterns []string, opts ...RedactOption) ([]string, error) {
+	compiled := make(patternSet, 0, len(patterns))
+	for _, pattern := range patterns {
+		segments, err := parsePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, segments)
+	}
+	if s == nil {
+		return nil, nil
+	}
+
+	o := redactOptions{placeholder: DefaultRedactionPlaceholder}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// collect every target before changing anything, so removals do not shift the
+	// indexes of later matches
+	targets := make(map[string]redactTarget)
+	compiled.collectRedactions("", structpb.NewStructValue(s), compiled.start(), targets)
+
+	paths := make([]string, 0, len(targets))
+	removals := make(map[*structpb.ListValue][]int)
+	for path, target := range targets {
+		paths = append(paths, path)
+		switch {
+		case !o.remove && target.list != nil:
+			target.list.Values[target.index] = structpb.NewStringValue(o.placeholder)
+		case !o.remove:
+			target.parent.Fields[target.key] = structpb.NewStringValue(o.placeholder)
+		case target.list != nil:
+			removals[target.list] = append(removals[target.list], target.index)
+		default:
+			delete(target.parent.Fields, target.key)
+		}
+	}
+	for list, indexes := range removals {
+		slices.Sort(indexes)
+		for _, i := range slices.Backward(indexes) {
+			list.Values = slices.Delete(list.Values, i, i+1)
+		}
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// redactTarget is a struct field or list item to redact
+type redactTarget struct {
+	parent *structpb.Struct
+	key    string
+	list   *structpb.ListValue
+	index  int
+}
+
+// collectRedactions adds the fields and items beneath v matched by states to targets.
+// Every state is tracked once per value, so patterns with several ** stay linear
+func (ps patternSet) collectRedactions(path string, v *structpb.Value, states []patternState, targets map[string]redactTarget) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		for key, field := range kind.StructValue.GetFields() {
+			keyPath := joinKey(path, key)
+			next, whole := ps.advance(states, patternSegment{kind: patternKey, key: key})
+			if whole {
+				targets[keyPath] = redactTarget{parent: kind.StructValue, key: key}
+			}
+			if len(next) > 0 {
+				ps.collectRedactions(keyPath, field, next, targets)
+			}
+		}
+	case *structpb.Value_ListValue:
+		for i, item := range kind.ListValue.GetValues() {
+			itemPath := joinIndex(path, i)
+			next, whole := ps.advance(states, patternSegment{kind: patternIndex, index: i})
+			if whole {
+				targets[itemPath] = redactTarget{list: kind.ListValue, index: i}
+			}
+			if len(next) > 0 {
+				ps.collectRedactions(itemPath, item, next, targets)
+			}
+		}
+	}
+}
+
+type patternKind int
+
+const (
+	patternKey patternKind = iota
+	patternIndex
+	patternAnyKey
+	patternAnyIndex
+	patternAnyDepth
+)
+
+// patternSegment is a path segment that may be a wildcard
+type patternSegment struct {
+	kind  patternKind
+	key   string
+	index int
+}
+
+// parsePattern splits a path pattern into its segments, see Redact
+func parsePattern(pattern string) ([]patternSegment, error) {
+	var segments []patternSegment
+	rest := strings.TrimPrefix(pattern, ".")
+	for rest != "" {
+		// the length of the next segment in the notation parsePath understands
+		var n int
+		switch {
+		case strings.HasPrefix(rest, "[*]"):
+			n = 3
+		case strings.HasPrefix(rest, `["`):
+			n = quotedKeyEnd(rest) + 1
+		case strings.HasPrefix(rest, "["):
+			n = strings.IndexByte(rest, ']') + 1
+		default:
+			n = strings.IndexAny(rest, ".[")
+			if n < 0 {
+				n = len(rest)
+			}
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid pattern %q", pattern)
+		}
+
+		token := rest[:n]
+		switch token {
+		case "[*]":
+			segments = append(segments, patternSegment{kind: patternAnyIndex})
+		case "*":
+			segments = append(segments, patternSegment{kind: patternAnyKey})
+		case "**":
+			// ** matches any number of segments, so a run of them is the same as one
+			if len(segments) == 0 || segments[len(segments)-1].kind != patternAnyDepth {
+				segments = append(segments, patternSegment{kind: patternAnyDepth})
+			}
+		default:
+			parsed, err := parsePath(token)
+			if err != nil || len(parsed) != 1 {
+				return nil, fmt.Errorf("invalid pattern %q", pattern)
+			}
+			if parsed[0].index >= 0 {
+				segments = append(segments, patternSegment{kind: patternIndex, index: parsed[0].index})
+			} else {
+				segments = append(segments, patternSegment{kind: patternKey, key: parsed[0].key})
+			}
+		}
+
+		rest = rest[n:]
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return nil, fmt.Errorf("invalid pattern %q: trailing separator", pattern)
+			}
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid pattern %q: empty", pattern)
+	}
+	if segments[len(segments)-1].kind == patternAnyDepth {
+		return nil, fmt.Errorf("invalid pattern %q: ** must be followed by a key or index", pattern)
+	}
+	return segments, nil
+}
@@ -0,0 +1,118 @@
+package protobaggins
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	newStruct := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(map[string]any{
+			"users": []any{
+				map[string]any{"name": "frodo", "password": "ring"},
+				map[string]any{"name": "sam", "password": "taters", "token": "t1"},
+			},
+			"auth":   map[string]any{"token": "t0", "credentials": map[string]any{"key": "k"}},
+			"labels": map[string]any{"app.secret": "x", "*": "star"},
+			"tags":   []any{"a", "b", "c"},
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("placeholder", func(t *testing.T) {
+		t.Parallel()
+		s := newStruct(t)
+		paths, err := Redact(s, []string{"users[*].password", "auth.credentials", `labels["app.secret"]`, "tags[1]"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"auth.credentials", `labels["app.secret"]`, "tags[1]", "users[0].password", "users[1].password",
+		}, paths)
+		assert.Equal(t, map[string]any{
+			"users": []any{
+				map[string]any{"name": "frodo", "password": "[REDACTED]"},
+				map[string]any{"name": "sam", "password": "[REDACTED]", "token": "t1"},
+			},
+			"auth":   map[string]any{"token": "t0", "credentials": "[REDACTED]"},
+			"labels": map[string]any{"app.secret": "[REDACTED]", "*": "star"},
+			"tags":   []any{"a", "[REDACTED]", "c"},
+		}, s.AsMap())
+	})
+
+	t.Run("wildcards", func(t *testing.T) {
+		t.Parallel()
+		s := newStruct(t)
+		paths, err := Redact(s, []string{"**.token", "labels.*"}, RedactPlaceholder("***"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"auth.token", "labels.*", `labels["app.secret"]`, "users[1].token"}, paths)
+		assert.Equal(t, "***", s.GetFields()["auth"].GetStructValue().GetFields()["token"].GetStringValue())
+
+		paths, err = Redact(newStruct(t), []string{`labels["*"]`})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"labels.*"}, paths)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		t.Parallel()
+		s := newStruct(t)
+		paths, err := Redact(s, []string{"users[*].password", "tags[0]", "tags[2]", "auth"}, RedactRemove())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"auth", "tags[0]", "tags[2]", "users[0].password", "users[1].password"}, paths)
+		assert.Equal(t, map[string]any{
+			"users": []any{
+				map[string]any{"name": "frodo"},
+				map[string]any{"name": "sam", "token": "t1"},
+			},
+			"labels": map[string]any{"app.secret": "x", "*": "star"},
+			"tags":   []any{"b"},
+		}, s.AsMap())
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		t.Parallel()
+		s := newStruct(t)
+		paths, err := Redact(s, []string{"missing", "users.password", "tags[9]"})
+		require.NoError(t, err)
+		assert.Empty(t, paths)
+		assert.Equal(t, newStruct(t).AsMap(), s.AsMap())
+
+		paths, err = Redact(nil, []string{"a"})
+		require.NoError(t, err)
+		assert.Empty(t, paths)
+	})
+
+	t.Run("many any depth segments on a deep struct", func(t *testing.T) {
+		t.Parallel()
+		s := deepStruct(t, 40)
+		paths, err := Redact(s, []string{"**.**.**.**.**.**.x", "**.a.**.a.**.a.**.missing"})
+		require.NoError(t, err)
+		require.Len(t, paths, 1)
+		assert.True(t, strings.HasSuffix(paths[0], "a.a.x"), paths[0])
+	})
+
+	t.Run("repeated any depth segments collapse", func(t *testing.T) {
+		t.Parallel()
+		segments, err := parsePattern("**.**.a.**.**.**.b")
+		require.NoError(t, err)
+		assert.Equal(t, []patternSegment{
+			{kind: patternAnyDepth}, {kind: patternKey, key: "a"}, {kind: patternAnyDepth}, {kind: patternKey, key: "b"},
+		}, segments)
+	})
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		t.Parallel()
+		for _, pattern := range []string{"", "a..b", "a.", "a[x]", `a["b`, "a[", "a.**"} {
+			s := newStruct(t)
+			_, err := Redact(s, []string{"users[*].password", pattern})
+			require.Error(t, err, pattern)
+			assert.Equal(t, newStruct(t).AsMap(), s.AsMap())
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultRequestMaxSize is the body size limit of ReadRequestStruct unless JSONMaxSize
+// sets another
+const DefaultRequestMaxSize = 1 << 20
+
+// ErrUnsupportedMediaType is returned when a request body is not declared as JSON
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// RequestError reports a request body that ReadRequestStruct rejected. Its message
+// describes what the client must fix and is safe to send back to it
+type RequestError struct {
+	// StatusCode is the HTTP status to respond with: 415 for a body that is not
+	// declared as JSON, 413 for one over the size limit and 400 otherwise
+	StatusCode int
+	// Err is the cause of the failure
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// ReadRequestStruct parses the body of r, a JSON object, like DecodeJSONStream. The
+// Content-Type must be application/json or another JSON media type such as
+// application/merge-patch+json. The body is limited to DefaultRequestMaxSize bytes
+// unless JSONMaxSize sets another limit, zero meaning none, and to the JSONMaxDepth
+// nesting limit. Every failure is a *RequestError
+func ReadRequestStruct(r *http.Request, opts ...JSONOption) (*structpb.Struct, error) {
+	o := jsonOptions{maxSize: DefaultRequestMaxSize, maxDepth: DefaultJSONMaxDepth}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := checkJSONContentType(r.Header.Get("Content-Type")); err != nil {
+		return nil, &RequestError{StatusCode: http.StatusUnsupportedMediaType, Err: err}
+	}
+	if r.Body == nil {
+		return nil, &RequestError{StatusCode: http.StatusBadRequest, Err: errors.New("request body is empty")}
+	}
+	if o.maxSize > 0 && r.ContentLength > int64(o.maxSize) {
+		err := fmt.Errorf("%w: request body of %d bytes exceeds the limit of %d", ErrTooLarge, r.ContentLength, o.maxSize)
+		return nil, &RequestError{StatusCode: http.StatusRequestEntityTooLarge, Err: err}
+	}
+
+	s, err := readJSONObject(limitJSONReader(r.Body, o), o, nil)
+	switch {
+	case err == nil:
+		return s, nil
+	case errors.Is(err, ErrTooLarge):
+		return nil, &RequestError{StatusCode: http.StatusRequestEntityTooLarge, Err: err}
+	case errors.Is(err, io.EOF):
+		// the decoder reports a body that ends inside a member like an empty one
+		return nil, &RequestError{StatusCode: http.StatusBadRequest, Err: errors.New("request body is empty or incomplete")}
+	default:
+		return nil, &RequestError{StatusCode: http.StatusBadRequest, Err: fmt.Errorf("invalid JSON body: %w", err)}
+	}
+}
+
+// checkJSONContentType accepts application/json and the application/*+json types
+func checkJSONContentType(contentType string) error {
+	if contentType == "" {
+		return fmt.Errorf("%w: missing Content-Type, expected application/json", ErrUnsupportedMediaType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "application/json" ||
+		strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+	return fmt.Errorf("%w: Content-Type %q, expected application/json", ErrUnsupportedMediaType, contentType)
+}
@@ -0,0 +1,132 @@
+package protobaggins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func newJSONRequest(body, contentType string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/hobbits", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func TestReadRequestStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("parses the body", func(t *testing.T) {
+		t.Parallel()
+		for _, contentType := range []string{
+			"application/json",
+			"application/json; charset=utf-8",
+			"Application/JSON",
+			"application/merge-patch+json",
+		} {
+			s, err := ReadRequestStruct(newJSONRequest(`{"name":"Frodo","age":50}`, contentType))
+			require.NoError(t, err, contentType)
+			want, err := structpb.NewStruct(map[string]any{"name": "Frodo", "age": 50.0})
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(want, s))
+		}
+	})
+
+	tests := []struct {
+		name        string
+		request     *http.Request
+		opts        []JSONOption
+		status      int
+		errContains string
+	}{
+		{
+			name:        "missing content type",
+			request:     newJSONRequest(`{}`, ""),
+			status:      http.StatusUnsupportedMediaType,
+			errContains: "missing Content-Type",
+		},
+		{
+			name:        "wrong content type",
+			request:     newJSONRequest(`{}`, "text/plain"),
+			status:      http.StatusUnsupportedMediaType,
+			errContains: `Content-Type "text/plain"`,
+		},
+		{
+			name:        "declared length over the limit",
+			request:     newJSONRequest(`{"a":"bcdefgh"}`, "application/json"),
+			opts:        []JSONOption{JSONMaxSize(8)},
+			status:      http.StatusRequestEntityTooLarge,
+			errContains: "15 bytes exceeds the limit of 8",
+		},
+		{
+			name: "streamed body over the limit",
+			request: func() *http.Request {
+				r := newJSONRequest(`{"a":"bcdefgh"}`, "application/json")
+				r.ContentLength = -1
+				return r
+			}(),
+			opts:   []JSONOption{JSONMaxSize(8)},
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "too deep",
+			request:     newJSONRequest(`{"a":{"b":{}}}`, "application/json"),
+			opts:        []JSONOption{JSONMaxDepth(2)},
+			status:      http.StatusBadRequest,
+			errContains: "a.b: maximum depth exceeded",
+		},
+		{
+			name:        "empty body",
+			request:     newJSONRequest(``, "application/json"),
+			status:      http.StatusBadRequest,
+			errContains: "empty",
+		},
+		{
+			name:        "not an object",
+			request:     newJSONRequest(`[1]`, "application/json"),
+			status:      http.StatusBadRequest,
+			errContains: "top level must be an object",
+		},
+		{
+			name:        "malformed",
+			request:     newJSONRequest(`{"a":tru}`, "application/json"),
+			status:      http.StatusBadRequest,
+			errContains: "invalid JSON body",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ReadRequestStruct(tt.request, tt.opts...)
+			var re *RequestError
+			require.ErrorAs(t, err, &re)
+			assert.Equal(t, tt.status, re.StatusCode)
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+			}
+		})
+	}
+
+	t.Run("default size limit", func(t *testing.T) {
+		t.Parallel()
+		body := `{"a":"` + strings.Repeat("x", DefaultRequestMaxSize) + `"}`
+		_, err := ReadRequestStruct(newJSONRequest(body, "application/json"))
+		require.ErrorIs(t, err, ErrTooLarge)
+
+		_, err = ReadRequestStruct(newJSONRequest(body, "application/json"), JSONMaxSize(0))
+		require.NoError(t, err)
+	})
+
+	t.Run("sentinels", func(t *testing.T) {
+		t.Parallel()
+		_, err := ReadRequestStruct(newJSONRequest(`{}`, "text/html"))
+		require.ErrorIs(t, err, ErrUnsupportedMediaType)
+	})
+}
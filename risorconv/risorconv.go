@@ -0,0 +1,223 @@
+// Package risorconv converts between structpb values and Risor objects, for handing
+// Struct payloads to Risor scripts and reading back their results.
+//
+// Structs convert to maps, lists to lists, and whole numbers within ±2^53 to ints,
+// other numbers to floats. In the other direction times become RFC 3339 strings, byte
+// slices the tagged {"@bytes": "<base64>"} form of protobaggins.BytesToValue, sets
+// sorted lists, and ints beyond ±2^53 are handled as WithBigIntegers selects.
+package risorconv
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/internal/conv"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// BigIntegers is the policy for Risor ints beyond ±2^53, which a float64 number cannot
+// hold exactly
+type BigIntegers int
+
+const (
+	// BigIntegersFail fails the conversion with protobaggins.ErrNotCoercible
+	BigIntegersFail BigIntegers = iota
+	// BigIntegersAsStrings converts them to decimal strings, see protobaggins.Int64ToValue
+	BigIntegersAsStrings
+	// BigIntegersAsFloats rounds them to the nearest float64
+	BigIntegersAsFloats
+)
+
+// Option configures the conversions
+type Option func(*options)
+
+type options struct {
+	bigIntegers     BigIntegers
+	numbersAsFloats bool
+}
+
+// WithBigIntegers sets the policy for ints beyond ±2^53, BigIntegersFail by default
+func WithBigIntegers(policy BigIntegers) Option {
+	return func(o *options) {
+		o.bigIntegers = policy
+	}
+}
+
+// NumbersAsFloats converts every number to a Risor float, even whole ones
+func NumbersAsFloats() Option {
+	return func(o *options) {
+		o.numbersAsFloats = true
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ToObject converts v to a Risor object: nil, a bool, an int or float, a string, a list
+// or a map
+func ToObject(v *structpb.Value, opts ...Option) object.Object {
+	return newOptions(opts).toObject(v)
+}
+
+// StructToMap converts s to a Risor map, see ToObject
+func StructToMap(s *structpb.Struct, opts ...Option) *object.Map {
+	return newOptions(opts).toMap(s)
+}
+
+func (o options) toObject(v *structpb.Value) object.Object {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return o.toMap(kind.StructValue)
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		objs := make([]object.Object, len(items))
+		for i, item := range items {
+			objs[i] = o.toObject(item)
+		}
+		return object.NewList(objs)
+	case *structpb.Value_StringValue:
+		return object.NewString(kind.StringValue)
+	case *structpb.Value_NumberValue:
+		f := kind.NumberValue
+		if !o.numbersAsFloats && f == math.Trunc(f) && math.Abs(f) <= conv.MaxSafeInteger {
+			return object.NewInt(int64(f))
+		}
+		return object.NewFloat(f)
+	case *structpb.Value_BoolValue:
+		return object.NewBool(kind.BoolValue)
+	default:
+		return object.Nil
+	}
+}
+
+func (o options) toMap(s *structpb.Struct) *object.Map {
+	items := make(map[string]object.Object, len(s.GetFields()))
+	for key, v := range s.GetFields() {
+		items[key] = o.toObject(v)
+	}
+	return object.NewMap(items)
+}
+
+// FromObject converts obj to a Value. Fails with protobaggins.ErrUnexpectedKind for
+// objects of other types, such as functions, with protobaggins.ErrCycle for lists and
+// maps that contain themselves, and with the error itself for Risor errors
+func FromObject(obj object.Object, opts ...Option) (*structpb.Value, error) {
+	c := converter{opts: newOptions(opts), visiting: make(map[object.Object]bool)}
+	return c.fromObject("", obj)
+}
+
+// MapToStruct converts m to a Struct, see FromObject
+func MapToStruct(m *object.Map, opts ...Option) (*structpb.Struct, error) {
+	c := converter{opts: newOptions(opts), visiting: make(map[object.Object]bool)}
+	return c.structValue("", m)
+}
+
+type converter struct {
+	opts options
+	// visiting holds the lists and maps being converted, to detect cycles
+	visiting map[object.Object]bool
+}
+
+func (c *converter) fromObject(path string, obj object.Object) (*structpb.Value, error) {
+	switch obj := obj.(type) {
+	case nil, *object.NilType:
+		return structpb.NewNullValue(), nil
+	case *object.Bool:
+		return structpb.NewBoolValue(obj.Value()), nil
+	case *object.String:
+		return structpb.NewStringValue(obj.Value()), nil
+	case *object.Float:
+		return structpb.NewNumberValue(obj.Value()), nil
+	case *object.Int:
+		return c.int(path, obj.Value())
+	case *object.Time:
+		return structpb.NewStringValue(obj.Value().Format(time.RFC3339Nano)), nil
+	case *object.ByteSlice:
+		return protobaggins.BytesToValue(obj.Value()), nil
+	case *object.Map:
+		s, err := c.structValue(path, obj)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStructValue(s), nil
+	case *object.List:
+		return c.list(path, obj, obj.Value())
+	case *object.Set:
+		return c.list(path, nil, obj.SortedItems())
+	case *object.Error:
+		return nil, fmt.Errorf("%s: %w", conv.DescribePath(path), obj.Value())
+	default:
+		return nil, fmt.Errorf("%s: %w: cannot convert a Risor %s", conv.DescribePath(path), protobaggins.ErrUnexpectedKind, obj.Type())
+	}
+}
+
+func (c *converter) int(path string, i int64) (*structpb.Value, error) {
+	if -conv.MaxSafeInteger <= i && i <= conv.MaxSafeInteger {
+		return structpb.NewNumberValue(float64(i)), nil
+	}
+	switch c.opts.bigIntegers {
+	case BigIntegersAsStrings:
+		return protobaggins.Int64ToValue(i), nil
+	case BigIntegersAsFloats:
+		return structpb.NewNumberValue(float64(i)), nil
+	default:
+		return nil, fmt.Errorf("%s: %w: %d is beyond ±2^53", conv.DescribePath(path), protobaggins.ErrNotCoercible, i)
+	}
+}
+
+// enter marks the list or map obj as being converted, failing if it already is
+func (c *converter) enter(path string, obj object.Object) error {
+	if c.visiting[obj] {
+		return fmt.Errorf("%s: %w", conv.DescribePath(path), protobaggins.ErrCycle)
+	}
+	c.visiting[obj] = true
+	return nil
+}
+
+func (c *converter) structValue(path string, m *object.Map) (*structpb.Struct, error) {
+	if err := c.enter(path, m); err != nil {
+		return nil, err
+	}
+	defer delete(c.visiting, m)
+
+	items := m.Value()
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(items))}
+	for _, key := range slices.Sorted(maps.Keys(items)) {
+		v, err := c.fromObject(protobaggins.JoinPathKey(path, key), items[key])
+		if err != nil {
+			return nil, err
+		}
+		s.Fields[key] = v
+	}
+	return s, nil
+}
+
+// list converts items, those of a list or set; list is the list itself, if any
+func (c *converter) list(path string, list *object.List, items []object.Object) (*structpb.Value, error) {
+	if list != nil {
+		if err := c.enter(path, list); err != nil {
+			return nil, err
+		}
+		defer delete(c.visiting, list)
+	}
+
+	values := make([]*structpb.Value, len(items))
+	for i, item := range items {
+		v, err := c.fromObject(protobaggins.JoinPathIndex(path, i), item)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+}
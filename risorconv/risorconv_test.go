@@ -0,0 +1,144 @@
+package risorconv
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/object"
+	"github.com/robbyt/protobaggins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestToObject(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"name":  "frodo",
+		"age":   50.0,
+		"ratio": 0.5,
+		"ring":  true,
+		"gone":  nil,
+		"tags":  []any{"a", 1.0},
+		"house": map[string]any{"door": "green"},
+	})
+	require.NoError(t, err)
+
+	t.Run("map", func(t *testing.T) {
+		t.Parallel()
+		m := StructToMap(s)
+		assert.Equal(t, `{"age": 50, "gone": nil, "house": {"door": "green"}, "name": "frodo", "ratio": 0.5, "ring": true, "tags": ["a", 1]}`, m.Inspect())
+		assert.Equal(t, m.Inspect(), ToObject(structpb.NewStructValue(s)).Inspect())
+	})
+
+	t.Run("numbers as floats", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, object.NewFloat(50), ToObject(structpb.NewNumberValue(50), NumbersAsFloats()))
+		assert.Equal(t, object.NewInt(50), ToObject(structpb.NewNumberValue(50)))
+		assert.Equal(t, object.NewFloat(1e20), ToObject(structpb.NewNumberValue(1e20)))
+	})
+
+	t.Run("usable from scripts", func(t *testing.T) {
+		t.Parallel()
+		result, err := risor.Eval(context.Background(), `input["age"] + len(input["tags"])`,
+			risor.WithGlobal("input", StructToMap(s)))
+		require.NoError(t, err)
+		assert.Equal(t, object.NewInt(52), result)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, object.Nil, ToObject(nil))
+		assert.Empty(t, StructToMap(nil).Value())
+	})
+}
+
+func TestFromObject(t *testing.T) {
+	t.Parallel()
+
+	t.Run("script result", func(t *testing.T) {
+		t.Parallel()
+		result, err := risor.Eval(context.Background(), `{
+			"allow": true,
+			"score": 3,
+			"ratio": 0.25,
+			"reasons": ["ok", nil],
+			"nested": {"deep": -7},
+		}`)
+		require.NoError(t, err)
+
+		got, err := FromObject(result)
+		require.NoError(t, err)
+		want, err := structpb.NewValue(map[string]any{
+			"allow":   true,
+			"score":   3.0,
+			"ratio":   0.25,
+			"reasons": []any{"ok", nil},
+			"nested":  map[string]any{"deep": -7.0},
+		})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, got), "%v", got)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": []any{1.0, 1.5, "x", nil, false}, "b": map[string]any{}})
+		require.NoError(t, err)
+		got, err := MapToStruct(StructToMap(s))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(s, got))
+	})
+
+	t.Run("other types", func(t *testing.T) {
+		t.Parallel()
+		when := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+		v, err := FromObject(object.NewTime(when))
+		require.NoError(t, err)
+		assert.Equal(t, "2024-05-06T07:08:09Z", v.GetStringValue())
+
+		v, err = FromObject(object.NewByteSlice([]byte("hi")))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(protobaggins.BytesToValue([]byte("hi")), v))
+
+		v, err = FromObject(object.NewSet([]object.Object{object.NewInt(2), object.NewInt(1)}))
+		require.NoError(t, err)
+		assert.Equal(t, []any{1.0, 2.0}, v.GetListValue().AsSlice())
+	})
+
+	t.Run("big integers", func(t *testing.T) {
+		t.Parallel()
+		big := object.NewInt(math.MaxInt64)
+		_, err := FromObject(big)
+		require.ErrorIs(t, err, protobaggins.ErrNotCoercible)
+
+		v, err := FromObject(big, WithBigIntegers(BigIntegersAsStrings))
+		require.NoError(t, err)
+		assert.Equal(t, "9223372036854775807", v.GetStringValue())
+
+		v, err = FromObject(big, WithBigIntegers(BigIntegersAsFloats))
+		require.NoError(t, err)
+		assert.InDelta(t, float64(math.MaxInt64), v.GetNumberValue(), 0)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		cause := errors.New("denied")
+		_, err := FromObject(object.NewList([]object.Object{object.NewError(cause)}))
+		require.ErrorIs(t, err, cause)
+		assert.ErrorContains(t, err, "[0]: denied")
+
+		_, err = FromObject(object.NewBuiltin("fn", nil))
+		require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind)
+
+		l := object.NewList(nil)
+		l.Append(l)
+		_, err = FromObject(l)
+		require.ErrorIs(t, err, protobaggins.ErrCycle)
+	})
+}
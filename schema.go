@@ -0,0 +1,132 @@
+package protobaggins
+
+import (
+	"maps"
+	"math"
+	"slices"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// SchemaDialect is the JSON Schema dialect declared by InferSchema under "$schema"
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// InferSchema describes the Structs in values, samples of the same payload, with a JSON
+// Schema (draft 2020-12), itself a Struct that StructToJSON serializes:
+//   - "type" lists every JSON type observed at a path, as a string if there is one.
+//     Numbers are "integer" while every observed number is whole
+//   - objects list their keys under "properties" and those present in every sample
+//     under "required"
+//   - arrays describe all their items, across samples, under "items"
+//
+// No samples give a schema that only declares an object type
+func InferSchema(values ...*structpb.Struct) *structpb.Struct {
+	root := &schemaNode{}
+	for _, s := range values {
+		root.observeStruct(s)
+	}
+	schema := root.schema()
+	if len(values) == 0 {
+		schema.Fields["type"] = structpb.NewStringValue("object")
+	}
+	schema.Fields["$schema"] = structpb.NewStringValue(SchemaDialect)
+	return schema
+}
+
+// schemaNode accumulates the values observed at a path
+type schemaNode struct {
+	null, boolean, number, fraction, str bool
+	// objects counts the objects observed, so keys seen as often are required
+	objects int
+	props   map[string]*schemaNode
+	seen    map[string]int
+	arrays  bool
+	items   *schemaNode
+}
+
+func (n *schemaNode) observe(v *structpb.Value) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NullValue:
+		n.null = true
+	case *structpb.Value_BoolValue:
+		n.boolean = true
+	case *structpb.Value_NumberValue:
+		n.number = true
+		if f := kind.NumberValue; f != math.Trunc(f) || math.IsInf(f, 0) {
+			n.fraction = true
+		}
+	case *structpb.Value_StringValue:
+		n.str = true
+	case *structpb.Value_ListValue:
+		n.arrays = true
+		for _, item := range kind.ListValue.GetValues() {
+			if n.items == nil {
+				n.items = &schemaNode{}
+			}
+			n.items.observe(item)
+		}
+	case *structpb.Value_StructValue:
+		n.observeStruct(kind.StructValue)
+	}
+}
+
+func (n *schemaNode) observeStruct(s *structpb.Struct) {
+	n.objects++
+	if n.props == nil {
+		n.props = make(map[string]*schemaNode)
+		n.seen = make(map[string]int)
+	}
+	for k, v := range s.GetFields() {
+		prop, ok := n.props[k]
+		if !ok {
+			prop = &schemaNode{}
+			n.props[k] = prop
+		}
+		prop.observe(v)
+		n.seen[k]++
+	}
+}
+
+// schema returns the JSON Schema of the observed values
+func (n *schemaNode) schema() *structpb.Struct {
+	var types []*structpb.Value
+	add := func(observed bool, name string) {
+		if observed {
+			types = append(types, structpb.NewStringValue(name))
+		}
+	}
+	add(n.null, "null")
+	add(n.boolean, "boolean")
+	add(n.number && !n.fraction, "integer")
+	add(n.fraction, "number")
+	add(n.str, "string")
+	add(n.arrays, "array")
+	add(n.objects > 0, "object")
+
+	fields := make(map[string]*structpb.Value)
+	switch {
+	case len(types) == 1:
+		fields["type"] = types[0]
+	case len(types) > 1:
+		fields["type"] = structpb.NewListValue(&structpb.ListValue{Values: types})
+	}
+
+	if n.items != nil {
+		fields["items"] = structpb.NewStructValue(n.items.schema())
+	}
+	if n.objects > 0 {
+		props := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(n.props))}
+		var required []*structpb.Value
+		for _, k := range slices.Sorted(maps.Keys(n.props)) {
+			props.Fields[k] = structpb.NewStructValue(n.props[k].schema())
+			if n.seen[k] == n.objects {
+				required = append(required, structpb.NewStringValue(k))
+			}
+		}
+		fields["properties"] = structpb.NewStructValue(props)
+		if len(required) > 0 {
+			fields["required"] = structpb.NewListValue(&structpb.ListValue{Values: required})
+		}
+	}
+	return &structpb.Struct{Fields: fields}
+}
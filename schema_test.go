@@ -0,0 +1,84 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestInferSchema(t *testing.T) {
+	t.Parallel()
+
+	sample := func(t *testing.T, m map[string]any) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("samples", func(t *testing.T) {
+		t.Parallel()
+		schema := InferSchema(
+			sample(t, map[string]any{
+				"name":    "frodo",
+				"age":     50,
+				"height":  1.2,
+				"tags":    []any{"hobbit", 1},
+				"address": map[string]any{"street": "bagshot row"},
+				"ring":    nil,
+			}),
+			sample(t, map[string]any{
+				"name":    "sam",
+				"age":     38,
+				"height":  1,
+				"tags":    []any{},
+				"address": map[string]any{"street": "bagshot row", "number": 3},
+				"ring":    true,
+				"empty":   []any{},
+			}),
+		)
+		assert.Equal(t, map[string]any{
+			"$schema": SchemaDialect,
+			"type":    "object",
+			"properties": map[string]any{
+				"name":   map[string]any{"type": "string"},
+				"age":    map[string]any{"type": "integer"},
+				"height": map[string]any{"type": "number"},
+				"tags": map[string]any{
+					"type":  "array",
+					"items": map[string]any{"type": []any{"integer", "string"}},
+				},
+				"address": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"street": map[string]any{"type": "string"},
+						"number": map[string]any{"type": "integer"},
+					},
+					"required": []any{"street"},
+				},
+				"ring":  map[string]any{"type": []any{"null", "boolean"}},
+				"empty": map[string]any{"type": "array"},
+			},
+			"required": []any{"address", "age", "height", "name", "ring", "tags"},
+		}, schema.AsMap())
+	})
+
+	t.Run("no samples", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, map[string]any{"$schema": SchemaDialect, "type": "object"}, InferSchema().AsMap())
+	})
+
+	t.Run("serializes", func(t *testing.T) {
+		t.Parallel()
+		data, err := StructToJSON(InferSchema(sample(t, map[string]any{"a": 1})))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {"a": {"type": "integer"}},
+			"required": ["a"]
+		}`, string(data))
+	})
+}
@@ -0,0 +1,154 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxPathPadding is the number of nulls SetPath pads a list with at most, so that a
+// path such as `a[1000000000]` cannot make it allocate without bound
+const maxPathPadding = 1024
+
+// SetPathOption configures SetPath
+type SetPathOption func(*setPathOptions)
+
+type setPathOptions struct {
+	strict bool
+	encode []Option
+}
+
+// StrictPath makes SetPath fail with ErrPathNotFound instead of creating missing
+// intermediate structs and lists or padding lists. The last key may still be new, and
+// the last index may be the length of the list to append to it
+func StrictPath() SetPathOption {
+	return func(o *setPathOptions) {
+		o.strict = true
+	}
+}
+
+// SetPathEncoding passes opts to NewValue when converting the value set by SetPath
+func SetPathEncoding(opts ...Option) SetPathOption {
+	return func(o *setPathOptions) {
+		o.encode = append(o.encode, opts...)
+	}
+}
+
+// SetPath sets the value at path within s, in the notation described at PathFilter,
+// e.g. `spec.containers[0].image`. value is converted with NewValue, except for a
+// *structpb.Value, which is stored as is. Missing intermediate nodes are created, as
+// a Struct when the next segment is a key and a list when it is an index, and lists
+// shorter than an index are padded with nulls; see StrictPath. Indexes more than 1024
+// past the end of a list fail with ErrPathNotFound. Existing values of the wrong kind
+// are never replaced, and fail with ErrUnexpectedKind
+func SetPath(s *structpb.Struct, path string, value any, opts ...SetPathOption) error {
+	if s == nil {
+		return errors.New("cannot set a path in a nil struct")
+	}
+	segments, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return errors.New("cannot set the empty path")
+	}
+
+	var o setPathOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	v, ok := value.(*structpb.Value)
+	if !ok {
+		if v, err = NewValue(value, o.encode...); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return o.set(s, segments, v)
+}
+
+// set stores v at the non-empty segments within s
+func (o *setPathOptions) set(s *structpb.Struct, segments []pathSegment, v *structpb.Value) error {
+	container := structpb.NewStructValue(s)
+	current := ""
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		create := v
+		if !last {
+			create = newContainerFor(segments[i+1])
+		}
+
+		slot, err := o.slot(current, container, seg, create, last)
+		if err != nil {
+			return err
+		}
+		if seg.index >= 0 {
+			current = joinIndex(current, seg.index)
+		} else {
+			current = joinKey(current, seg.key)
+		}
+		container = slot
+	}
+	return nil
+}
+
+// newContainerFor returns an empty container that seg can descend into
+func newContainerFor(seg pathSegment) *structpb.Value {
+	if seg.index >= 0 {
+		return structpb.NewListValue(&structpb.ListValue{})
+	}
+	return structpb.NewStructValue(&structpb.Struct{})
+}
+
+// slot returns the child of container at seg. For the last segment the child is
+// replaced with create, otherwise create is only stored if the child is missing
+func (o *setPathOptions) slot(path string, container *structpb.Value, seg pathSegment, create *structpb.Value, last bool) (*structpb.Value, error) {
+	if seg.index >= 0 {
+		list, ok := container.GetKind().(*structpb.Value_ListValue)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: cannot index %s", describePath(path), ErrUnexpectedKind, KindOf(container))
+		}
+		if list.ListValue == nil {
+			list.ListValue = &structpb.ListValue{}
+		}
+		items := list.ListValue.Values
+		switch {
+		case seg.index < len(items) && !last:
+			return items[seg.index], nil
+		case seg.index < len(items):
+			items[seg.index] = create
+			return create, nil
+		case o.strict && (!last || seg.index > len(items)):
+			return nil, fmt.Errorf("%s: %w: index %d out of range for list of length %d",
+				describePath(path), ErrPathNotFound, seg.index, len(items))
+		case seg.index-len(items) > maxPathPadding:
+			return nil, fmt.Errorf("%s: %w: index %d is more than %d past the end of a list of length %d",
+				describePath(path), ErrPathNotFound, seg.index, maxPathPadding, len(items))
+		}
+		for len(list.ListValue.Values) < seg.index {
+			list.ListValue.Values = append(list.ListValue.Values, structpb.NewNullValue())
+		}
+		list.ListValue.Values = append(list.ListValue.Values, create)
+		return create, nil
+	}
+
+	st, ok := container.GetKind().(*structpb.Value_StructValue)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: cannot set key %q in %s", describePath(path), ErrUnexpectedKind, seg.key, KindOf(container))
+	}
+	if st.StructValue == nil {
+		st.StructValue = &structpb.Struct{}
+	}
+	if st.StructValue.Fields == nil {
+		st.StructValue.Fields = make(map[string]*structpb.Value)
+	}
+	if existing, ok := st.StructValue.Fields[seg.key]; ok && !last {
+		return existing, nil
+	}
+	if o.strict && !last {
+		return nil, fmt.Errorf("%s: %w", joinKey(path, seg.key), ErrPathNotFound)
+	}
+	st.StructValue.Fields[seg.key] = create
+	return create, nil
+}
@@ -0,0 +1,105 @@
+package protobaggins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSetPath(t *testing.T) {
+	t.Parallel()
+
+	t.Run("creates intermediate nodes", func(t *testing.T) {
+		t.Parallel()
+		s := &structpb.Struct{}
+		require.NoError(t, SetPath(s, "spec.containers[0].image", "nginx"))
+		require.NoError(t, SetPath(s, "spec.containers[0].ports[2]", 80))
+		require.NoError(t, SetPath(s, `metadata.labels["app.kubernetes.io/name"]`, "web"))
+		require.NoError(t, SetPath(s, "spec.replicas", structpb.NewNumberValue(3)))
+
+		assert.Equal(t, map[string]any{
+			"spec": map[string]any{
+				"containers": []any{map[string]any{"image": "nginx", "ports": []any{nil, nil, 80.0}}},
+				"replicas":   3.0,
+			},
+			"metadata": map[string]any{"labels": map[string]any{"app.kubernetes.io/name": "web"}},
+		}, s.AsMap())
+	})
+
+	t.Run("replaces and appends", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": []any{1, 2}, "b": map[string]any{"c": 1}})
+		require.NoError(t, err)
+		require.NoError(t, SetPath(s, "a[1]", "two"))
+		require.NoError(t, SetPath(s, "a[2]", 3))
+		require.NoError(t, SetPath(s, "b", []any{"x"}))
+		assert.Equal(t, map[string]any{"a": []any{1.0, "two", 3.0}, "b": []any{"x"}}, s.AsMap())
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": map[string]any{"b": []any{1}}})
+		require.NoError(t, err)
+
+		require.NoError(t, SetPath(s, "a.c", 2, StrictPath()))
+		require.NoError(t, SetPath(s, "a.b[1]", 2, StrictPath()))
+		assert.Equal(t, map[string]any{"a": map[string]any{"b": []any{1.0, 2.0}, "c": 2.0}}, s.AsMap())
+
+		err = SetPath(s, "a.x.y", 1, StrictPath())
+		require.ErrorIs(t, err, ErrPathNotFound)
+		assert.EqualError(t, err, "a.x: path not found")
+		err = SetPath(s, "a.b[3]", 1, StrictPath())
+		require.ErrorIs(t, err, ErrPathNotFound)
+		err = SetPath(s, "a.b[2].c", 1, StrictPath())
+		require.ErrorIs(t, err, ErrPathNotFound)
+		assert.Equal(t, map[string]any{"a": map[string]any{"b": []any{1.0, 2.0}, "c": 2.0}}, s.AsMap())
+	})
+
+	t.Run("bounded padding", func(t *testing.T) {
+		t.Parallel()
+		s := &structpb.Struct{}
+		require.NoError(t, SetPath(s, "a[1024]", 1))
+		assert.Len(t, s.GetFields()["a"].GetListValue().GetValues(), 1025)
+
+		err := SetPath(s, "a[2050]", 1)
+		require.ErrorIs(t, err, ErrPathNotFound)
+		assert.Contains(t, err.Error(), "more than 1024 past the end")
+		err = SetPath(s, "b[1000000000].c", 1)
+		require.ErrorIs(t, err, ErrPathNotFound)
+		assert.Len(t, s.GetFields()["a"].GetListValue().GetValues(), 1025)
+	})
+
+	t.Run("does not replace values of another kind", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": "flat", "b": []any{1}})
+		require.NoError(t, err)
+
+		err = SetPath(s, "a.b", 1)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		assert.EqualError(t, err, `a: unexpected value kind: cannot set key "b" in string`)
+		err = SetPath(s, "b.c", 1)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		err = SetPath(s, "[0]", 1)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("conversion", func(t *testing.T) {
+		t.Parallel()
+		s := &structpb.Struct{}
+		require.Error(t, SetPath(s, "a", make(chan int)))
+
+		require.NoError(t, SetPath(s, "a", []byte("hi"), SetPathEncoding(WithTaggedBytes())))
+		require.NoError(t, SetPath(s, "b", time.Second, SetPathEncoding(WithDurations(DurationString))))
+		assert.Equal(t, map[string]any{"a": map[string]any{TaggedBytesKey: "aGk="}, "b": "1s"}, s.AsMap())
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		t.Parallel()
+		require.Error(t, SetPath(nil, "a", 1))
+		require.Error(t, SetPath(&structpb.Struct{}, "", 1))
+		require.Error(t, SetPath(&structpb.Struct{}, "a..b", 1))
+	})
+}
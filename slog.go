@@ -0,0 +1,119 @@
+package protobaggins
+
+import (
+	"fmt"
+	"log/slog"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// LogStruct wraps a Struct so that it logs as a group of attributes, e.g.
+// slog.Any("payload", LogStruct{Struct: s}), instead of in the protobuf text format
+type LogStruct struct {
+	Struct *structpb.Struct
+}
+
+// LogValue implements slog.LogValuer
+func (l LogStruct) LogValue() slog.Value {
+	return slog.GroupValue(StructToAttrs(l.Struct)...)
+}
+
+// StructToAttrs converts the fields of s to attributes sorted by key, see SlogValue
+func StructToAttrs(s *structpb.Struct) []slog.Attr {
+	keys := sortedKeys(s)
+	attrs := make([]slog.Attr, len(keys))
+	for i, key := range keys {
+		attrs[i] = slog.Attr{Key: key, Value: SlogValue(s.GetFields()[key])}
+	}
+	return attrs
+}
+
+// SlogValue converts v to a slog.Value. Structs become groups, lists a []any as
+// returned by AsSlice, and nulls and nil a nil any
+func SlogValue(v *structpb.Value) slog.Value {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return slog.GroupValue(StructToAttrs(kind.StructValue)...)
+	case *structpb.Value_ListValue:
+		return slog.AnyValue(kind.ListValue.AsSlice())
+	case *structpb.Value_StringValue:
+		return slog.StringValue(kind.StringValue)
+	case *structpb.Value_NumberValue:
+		return slog.Float64Value(kind.NumberValue)
+	case *structpb.Value_BoolValue:
+		return slog.BoolValue(kind.BoolValue)
+	default:
+		return slog.AnyValue(nil)
+	}
+}
+
+// AttrsToStruct converts attrs to a Struct, the reverse of StructToAttrs, for
+// shipping log records. LogValuers are resolved and groups become nested Structs,
+// except those with an empty key, whose attributes are inlined as slog handlers do.
+// Attributes with an empty key and value are ignored, and of duplicate keys the last
+// one wins. Integers go through Int64ToValue and Uint64ToValue, errors become their
+// message, and times, durations and other values are converted with NewValue using
+// opts, after WithDurations(DurationString) and WithReflection so that durations,
+// slices and structs are supported by default
+func AttrsToStruct(attrs []slog.Attr, opts ...Option) (*structpb.Struct, error) {
+	opts = append([]Option{WithDurations(DurationString), WithReflection()}, opts...)
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(attrs))}
+	if err := addAttrs(s, "", attrs, opts); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func addAttrs(s *structpb.Struct, path string, attrs []slog.Attr, opts []Option) error {
+	for _, attr := range attrs {
+		attr.Value = attr.Value.Resolve()
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+		if attr.Value.Kind() == slog.KindGroup && attr.Key == "" {
+			if err := addAttrs(s, path, attr.Value.Group(), opts); err != nil {
+				return err
+			}
+			continue
+		}
+
+		attrPath := joinKey(path, attr.Key)
+		v, err := attrValue(attrPath, attr.Value, opts)
+		if err != nil {
+			return err
+		}
+		s.Fields[attr.Key] = v
+	}
+	return nil
+}
+
+func attrValue(path string, v slog.Value, opts []Option) (*structpb.Value, error) {
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(v.Group()))}
+		if err := addAttrs(group, path, v.Group(), opts); err != nil {
+			return nil, err
+		}
+		return structpb.NewStructValue(group), nil
+	case slog.KindString:
+		return structpb.NewStringValue(v.String()), nil
+	case slog.KindInt64:
+		return Int64ToValue(v.Int64()), nil
+	case slog.KindUint64:
+		return Uint64ToValue(v.Uint64()), nil
+	case slog.KindFloat64:
+		return structpb.NewNumberValue(v.Float64()), nil
+	case slog.KindBool:
+		return structpb.NewBoolValue(v.Bool()), nil
+	}
+
+	a := v.Any()
+	if err, ok := a.(error); ok {
+		return structpb.NewStringValue(err.Error()), nil
+	}
+	result, err := NewValue(a, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package protobaggins
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ringValuer resolves to a group, for testing that LogValuers are resolved
+type ringValuer struct{}
+
+func (ringValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("bearer", "frodo"))
+}
+
+func TestStructToAttrs(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"name":  "frodo",
+		"age":   50.0,
+		"ring":  true,
+		"gone":  nil,
+		"tags":  []any{"a", 1.0},
+		"house": map[string]any{"name": "bag end"},
+	})
+	require.NoError(t, err)
+
+	attrs := StructToAttrs(s)
+	require.Len(t, attrs, 6)
+	assert.Equal(t, "age", attrs[0].Key)
+	assert.Equal(t, "name", attrs[3].Key)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("hi", "payload", LogStruct{Struct: s})
+	assert.Equal(t, "level=INFO msg=hi payload.age=50 payload.gone=<nil> payload.house.name=\"bag end\" payload.name=frodo payload.ring=true payload.tags=\"[a 1]\"\n", buf.String())
+
+	assert.Empty(t, StructToAttrs(nil))
+	assert.Equal(t, slog.KindAny, SlogValue(nil).Kind())
+}
+
+func TestAttrsToStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("converts attributes", func(t *testing.T) {
+		t.Parallel()
+		got, err := AttrsToStruct([]slog.Attr{
+			slog.String("name", "frodo"),
+			slog.Int64("big", 1<<62),
+			slog.Uint64("small", 3),
+			slog.Float64("ratio", 0.5),
+			slog.Bool("ring", true),
+			slog.Duration("nap", 90*time.Second),
+			slog.Time("born", time.Date(2968, 9, 22, 0, 0, 0, 0, time.UTC)),
+			slog.Any("err", errors.New("lost")),
+			slog.Any("tags", []string{"a"}),
+			slog.Any("owner", ringValuer{}),
+			slog.Group("house", slog.String("name", "bag end")),
+			slog.Group("", slog.Int("inlined", 1)),
+			{},
+			slog.String("name", "sam"),
+		})
+		require.NoError(t, err)
+
+		want := &structpb.Struct{Fields: map[string]*structpb.Value{
+			"name":    structpb.NewStringValue("sam"),
+			"big":     structpb.NewStringValue("4611686018427387904"),
+			"small":   structpb.NewNumberValue(3),
+			"ratio":   structpb.NewNumberValue(0.5),
+			"ring":    structpb.NewBoolValue(true),
+			"nap":     structpb.NewStringValue("1m30s"),
+			"born":    structpb.NewStringValue("2968-09-22T00:00:00Z"),
+			"err":     structpb.NewStringValue("lost"),
+			"tags":    structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("a")}}),
+			"owner":   structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"bearer": structpb.NewStringValue("frodo")}}),
+			"house":   structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"name": structpb.NewStringValue("bag end")}}),
+			"inlined": structpb.NewNumberValue(1),
+		}}
+		assert.True(t, proto.Equal(want, got), "%v", got)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": 1.0, "b": map[string]any{"c": "d"}, "e": []any{true}})
+		require.NoError(t, err)
+		got, err := AttrsToStruct(StructToAttrs(s))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(s, got), "%v", got)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := AttrsToStruct([]slog.Attr{slog.Group("house", slog.Any("door", make(chan int)))})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "house.door")
+
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		got, err := AttrsToStruct([]slog.Attr{slog.Duration("nap", time.Second)}, WithDurations(DurationNanoseconds))
+		require.NoError(t, err)
+		assert.InDelta(t, 1e9, got.GetFields()["nap"].GetNumberValue(), 0)
+	})
+}
@@ -0,0 +1,88 @@
+package protobaggins
+
+import (
+	"fmt"
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// SortOption configures SortListFunc
+type SortOption func(*sortOptions)
+
+type sortOptions struct {
+	copy bool
+}
+
+// SortCopy sorts a deep copy of the list and leaves the original untouched
+func SortCopy() SortOption {
+	return func(o *sortOptions) {
+		o.copy = true
+	}
+}
+
+// SortListFunc stably sorts the values of lv by cmp, which returns a negative number
+// when a sorts before b, a positive number when it sorts after, and zero to keep the
+// original relative order. CompareValues is a suitable default. The list is sorted in
+// place unless SortCopy is given. Returns the sorted list
+func SortListFunc(lv *structpb.ListValue, cmp func(a, b *structpb.Value) int, opts ...SortOption) *structpb.ListValue {
+	var o sortOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if lv == nil {
+		return nil
+	}
+	if o.copy {
+		lv, _ = proto.Clone(lv).(*structpb.ListValue)
+	}
+	slices.SortStableFunc(lv.Values, cmp)
+	return lv
+}
+
+// SortList stably sorts the values of l in place, so that a comes before b when
+// less(a, b) reports true. Returns l
+func SortList(l *structpb.ListValue, less func(a, b *structpb.Value) bool) *structpb.ListValue {
+	return SortListFunc(l, func(a, b *structpb.Value) int {
+		switch {
+		case less(a, b):
+			return -1
+		case less(b, a):
+			return 1
+		default:
+			return 0
+		}
+	})
+}
+
+// SortListByPath stably sorts the list at listPath within s by the value at keyPath
+// within each item, compared with CompareValues, e.g. sorting "users" by "name", from
+// the greatest key to the smallest if desc. Paths use the notation described at
+// PathFilter, and an empty keyPath sorts by the items themselves. Items without a
+// value at keyPath sort last, in either direction. The list is sorted in place.
+// Returns the sorted list, and fails with ErrPathNotFound or ErrUnexpectedKind if
+// there is no list at listPath
+func SortListByPath(s *structpb.Struct, listPath, keyPath string, desc bool) (*structpb.ListValue, error) {
+	found, err := LookupPath(structpb.NewStructValue(s), listPath)
+	if err != nil {
+		return nil, err
+	}
+	if KindOf(found) != KindList {
+		return nil, fmt.Errorf("%s: %w: expected a list, got %s", describePath(listPath), ErrUnexpectedKind, KindOf(found))
+	}
+
+	return SortListFunc(found.GetListValue(), func(a, b *structpb.Value) int {
+		aKey, aOK := GetPath(a, keyPath)
+		bKey, bOK := GetPath(b, keyPath)
+		switch {
+		case !aOK || !bOK:
+			return compareBools(bOK, aOK)
+		case desc:
+			return CompareValues(bKey, aKey)
+		default:
+			return CompareValues(aKey, bKey)
+		}
+	}), nil
+}
@@ -0,0 +1,148 @@
+package protobaggins
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSortListFunc(t *testing.T) {
+	t.Parallel()
+
+	newList := func(t *testing.T) *structpb.ListValue {
+		t.Helper()
+		lv, err := structpb.NewList([]any{
+			map[string]any{"name": "sam", "age": 38},
+			map[string]any{"name": "frodo", "age": 50},
+			map[string]any{"name": "merry", "age": 36},
+			map[string]any{"name": "pippin", "age": 28},
+			map[string]any{"name": "bilbo", "age": 111},
+		})
+		require.NoError(t, err)
+		return lv
+	}
+	names := func(lv *structpb.ListValue) []string {
+		var out []string
+		for _, v := range lv.GetValues() {
+			out = append(out, v.GetStructValue().GetFields()["name"].GetStringValue())
+		}
+		return out
+	}
+	// orders by name length only, so ties reveal whether the sort is stable
+	byNameLength := func(a, b *structpb.Value) int {
+		return len(a.GetStructValue().GetFields()["name"].GetStringValue()) -
+			len(b.GetStructValue().GetFields()["name"].GetStringValue())
+	}
+
+	t.Run("stable in place", func(t *testing.T) {
+		t.Parallel()
+		lv := newList(t)
+		got := SortListFunc(lv, byNameLength)
+		assert.Same(t, lv, got)
+		assert.Equal(t, []string{"sam", "frodo", "merry", "bilbo", "pippin"}, names(lv))
+	})
+
+	t.Run("copy leaves original untouched", func(t *testing.T) {
+		t.Parallel()
+		lv := newList(t)
+		got := SortListFunc(lv, func(a, b *structpb.Value) int {
+			return strings.Compare(
+				a.GetStructValue().GetFields()["name"].GetStringValue(),
+				b.GetStructValue().GetFields()["name"].GetStringValue(),
+			)
+		}, SortCopy())
+		assert.NotSame(t, lv, got)
+		assert.Equal(t, []string{"bilbo", "frodo", "merry", "pippin", "sam"}, names(got))
+		assert.Equal(t, []string{"sam", "frodo", "merry", "pippin", "bilbo"}, names(lv))
+
+		got.GetValues()[0].GetStructValue().GetFields()["name"] = structpb.NewStringValue("changed")
+		assert.Equal(t, "bilbo", lv.GetValues()[4].GetStructValue().GetFields()["name"].GetStringValue())
+	})
+
+	t.Run("with CompareValues", func(t *testing.T) {
+		t.Parallel()
+		lv, err := structpb.NewList([]any{"b", 2, nil, true, "a", 1})
+		require.NoError(t, err)
+		SortListFunc(lv, CompareValues)
+		assert.Equal(t, []any{nil, true, float64(1), float64(2), "a", "b"}, lv.AsSlice())
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, SortListFunc(nil, CompareValues, SortCopy()))
+	})
+}
+
+func TestSortList(t *testing.T) {
+	t.Parallel()
+
+	lv, err := structpb.NewList([]any{"ccc", "a", "bb", "d", "ee"})
+	require.NoError(t, err)
+	got := SortList(lv, func(a, b *structpb.Value) bool {
+		return len(a.GetStringValue()) < len(b.GetStringValue())
+	})
+	assert.Same(t, lv, got)
+	assert.Equal(t, []any{"a", "d", "bb", "ee", "ccc"}, lv.AsSlice(), "stable")
+	assert.Nil(t, SortList(nil, func(_, _ *structpb.Value) bool { return false }))
+}
+
+func TestSortListByPath(t *testing.T) {
+	t.Parallel()
+
+	newStruct := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(map[string]any{
+			"party": map[string]any{"members": []any{
+				map[string]any{"name": "sam", "profile": map[string]any{"age": 38}},
+				map[string]any{"name": "gandalf"},
+				map[string]any{"name": "frodo", "profile": map[string]any{"age": 50}},
+				map[string]any{"name": "pippin", "profile": map[string]any{"age": 28}},
+			}},
+			"tags": []any{"b", "c", "a"},
+			"name": "fellowship",
+		})
+		require.NoError(t, err)
+		return s
+	}
+	names := func(lv *structpb.ListValue) []string {
+		var out []string
+		for _, v := range lv.GetValues() {
+			out = append(out, v.GetStructValue().GetFields()["name"].GetStringValue())
+		}
+		return out
+	}
+
+	t.Run("ascending with missing keys last", func(t *testing.T) {
+		t.Parallel()
+		s := newStruct(t)
+		lv, err := SortListByPath(s, "party.members", "profile.age", false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pippin", "sam", "frodo", "gandalf"}, names(lv))
+		assert.Same(t, s.GetFields()["party"].GetStructValue().GetFields()["members"].GetListValue(), lv, "sorted in place")
+	})
+
+	t.Run("descending with missing keys last", func(t *testing.T) {
+		t.Parallel()
+		lv, err := SortListByPath(newStruct(t), "party.members", "profile.age", true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"frodo", "sam", "pippin", "gandalf"}, names(lv))
+	})
+
+	t.Run("by the items themselves", func(t *testing.T) {
+		t.Parallel()
+		lv, err := SortListByPath(newStruct(t), "tags", "", false)
+		require.NoError(t, err)
+		assert.Equal(t, []any{"a", "b", "c"}, lv.AsSlice())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := SortListByPath(newStruct(t), "party.guests", "name", false)
+		require.ErrorIs(t, err, ErrPathNotFound)
+		_, err = SortListByPath(newStruct(t), "name", "", false)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+}
@@ -0,0 +1,95 @@
+package protobaggins
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// JSONB stores a Struct in a JSON or JSONB database column, as a driver.Valuer and
+// sql.Scanner. A nil Struct is stored as SQL NULL, and both SQL NULL and a JSON null
+// scan as a nil Struct
+type JSONB struct {
+	Struct *structpb.Struct
+}
+
+// Value serializes the Struct with StructToJSON. The JSON is returned as a string,
+// which drivers send as text, as JSON columns expect, unlike []byte
+func (j JSONB) Value() (driver.Value, error) {
+	if j.Struct == nil {
+		return nil, nil
+	}
+	data, err := StructToJSON(j.Struct)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan parses a JSON object from a []byte or string column value with JSONToStruct
+func (j *JSONB) Scan(src any) error {
+	data, ok, err := scanJSON(src, "JSONB")
+	if err != nil || !ok || isJSONNull(data) {
+		j.Struct = nil
+		return err
+	}
+	s, err := JSONToStruct(data)
+	if err != nil {
+		return err
+	}
+	j.Struct = s
+	return nil
+}
+
+// JSONBValue is JSONB for any value, such as a list. A nil value is stored as SQL
+// NULL and scanned from it, while a null value is stored as JSON null
+type JSONBValue struct {
+	V *structpb.Value
+}
+
+// Value serializes the value as compact JSON
+func (j JSONBValue) Value() (driver.Value, error) {
+	if j.V == nil {
+		return nil, nil
+	}
+	data, err := MarshalJSON(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan parses a JSON value from a []byte or string column value
+func (j *JSONBValue) Scan(src any) error {
+	data, ok, err := scanJSON(src, "JSONBValue")
+	if err != nil || !ok {
+		j.V = nil
+		return err
+	}
+	v := &structpb.Value{}
+	if err := UnmarshalJSON(data, v); err != nil {
+		return err
+	}
+	j.V = v
+	return nil
+}
+
+// scanJSON returns the JSON text of a column value, or false for SQL NULL
+func scanJSON(src any, into string) ([]byte, bool, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, false, nil
+	case []byte:
+		return src, true, nil
+	case string:
+		return []byte(src), true, nil
+	default:
+		return nil, false, fmt.Errorf("cannot scan %T into a %s", src, into)
+	}
+}
+
+func isJSONNull(data []byte) bool {
+	return string(bytes.TrimSpace(data)) == "null"
+}
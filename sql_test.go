@@ -0,0 +1,102 @@
+package protobaggins
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var (
+	_ driver.Valuer = JSONB{}
+	_ sql.Scanner   = (*JSONB)(nil)
+	_ driver.Valuer = JSONBValue{}
+	_ sql.Scanner   = (*JSONBValue)(nil)
+)
+
+func TestJSONB(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"b": []any{1.0, nil}, "a": "x"})
+		require.NoError(t, err)
+		value, err := JSONB{Struct: s}.Value()
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":"x","b":[1,null]}`, value)
+
+		for _, src := range []any{value, []byte(value.(string))} {
+			var j JSONB
+			require.NoError(t, j.Scan(src))
+			assert.True(t, proto.Equal(s, j.Struct))
+		}
+	})
+
+	t.Run("nulls", func(t *testing.T) {
+		t.Parallel()
+		value, err := JSONB{}.Value()
+		require.NoError(t, err)
+		assert.Nil(t, value)
+
+		j := JSONB{Struct: &structpb.Struct{}}
+		require.NoError(t, j.Scan(nil))
+		assert.Nil(t, j.Struct)
+
+		j = JSONB{Struct: &structpb.Struct{}}
+		require.NoError(t, j.Scan(" null "))
+		assert.Nil(t, j.Struct)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		var j JSONB
+		require.ErrorContains(t, j.Scan(42), "cannot scan int into a JSONB")
+		require.ErrorIs(t, j.Scan("[1]"), ErrUnexpectedKind)
+		require.Error(t, j.Scan(`{"a":`))
+	})
+}
+
+func TestJSONBValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		for _, v := range []*structpb.Value{
+			structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("a")}}),
+			structpb.NewNumberValue(1.5),
+			structpb.NewNullValue(),
+		} {
+			value, err := JSONBValue{V: v}.Value()
+			require.NoError(t, err)
+			var j JSONBValue
+			require.NoError(t, j.Scan(value))
+			assert.True(t, proto.Equal(v, j.V), "%v", value)
+		}
+	})
+
+	t.Run("nulls", func(t *testing.T) {
+		t.Parallel()
+		value, err := JSONBValue{V: structpb.NewNullValue()}.Value()
+		require.NoError(t, err)
+		assert.Equal(t, "null", value)
+
+		value, err = JSONBValue{}.Value()
+		require.NoError(t, err)
+		assert.Nil(t, value)
+
+		j := JSONBValue{V: structpb.NewBoolValue(true)}
+		require.NoError(t, j.Scan(nil))
+		assert.Nil(t, j.V)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		var j JSONBValue
+		require.ErrorContains(t, j.Scan(1.5), "cannot scan float64 into a JSONBValue")
+		require.Error(t, j.Scan([]byte("{")))
+	})
+}
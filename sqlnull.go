@@ -0,0 +1,169 @@
+package protobaggins
+
+import (
+	"database/sql"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The Null* functions convert the database/sql null types to proto3 optional
+// pointers, Timestamps for NullTime, and to Values. Invalid, i.e. NULL, values become
+// nil pointers and null Values, and both nil pointers and null or nil Values convert
+// back to invalid ones. The FromValue functions coerce like the To* functions do, see
+// ToInt64, and accept times as RFC 3339 strings or Unix seconds
+
+// nullFromValue converts v with coerce unless it is null or nil
+func nullFromValue[T any](v *structpb.Value, coerce func(*structpb.Value) (T, error)) (T, bool, error) {
+	var zero T
+	if k := KindOf(v); k == KindNull || k == KindUnset {
+		return zero, false, nil
+	}
+	t, err := coerce(v)
+	if err != nil {
+		return zero, false, err
+	}
+	return t, true, nil
+}
+
+// NullStringToProto converts a sql.NullString to a protocol buffer string pointer
+// Returns nil if n is not valid
+func NullStringToProto(n sql.NullString) *string {
+	if !n.Valid {
+		return nil
+	}
+	return &n.String
+}
+
+// NullStringFromProto converts a protocol buffer string pointer to a sql.NullString
+func NullStringFromProto(p *string) sql.NullString {
+	return sql.NullString{String: Deref(p), Valid: p != nil}
+}
+
+// NullStringToValue converts a sql.NullString to a string or null Value
+func NullStringToValue(n sql.NullString) *structpb.Value {
+	if !n.Valid {
+		return structpb.NewNullValue()
+	}
+	return structpb.NewStringValue(n.String)
+}
+
+// NullStringFromValue converts a Value to a sql.NullString with ToString
+func NullStringFromValue(v *structpb.Value) (sql.NullString, error) {
+	s, valid, err := nullFromValue(v, ToString)
+	return sql.NullString{String: s, Valid: valid}, err
+}
+
+// NullInt64ToProto converts a sql.NullInt64 to a protocol buffer int64 pointer
+// Returns nil if n is not valid
+func NullInt64ToProto(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+// NullInt64FromProto converts a protocol buffer int64 pointer to a sql.NullInt64
+func NullInt64FromProto(p *int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: Deref(p), Valid: p != nil}
+}
+
+// NullInt64ToValue converts a sql.NullInt64 to a null Value or one made by
+// Int64ToValue, which keeps integers beyond 2^53 exact as strings
+func NullInt64ToValue(n sql.NullInt64) *structpb.Value {
+	if !n.Valid {
+		return structpb.NewNullValue()
+	}
+	return Int64ToValue(n.Int64)
+}
+
+// NullInt64FromValue converts a Value to a sql.NullInt64 with ToInt64
+func NullInt64FromValue(v *structpb.Value) (sql.NullInt64, error) {
+	i, valid, err := nullFromValue(v, ToInt64)
+	return sql.NullInt64{Int64: i, Valid: valid}, err
+}
+
+// NullFloat64ToProto converts a sql.NullFloat64 to a protocol buffer double pointer
+// Returns nil if n is not valid
+func NullFloat64ToProto(n sql.NullFloat64) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Float64
+}
+
+// NullFloat64FromProto converts a protocol buffer double pointer to a sql.NullFloat64
+func NullFloat64FromProto(p *float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: Deref(p), Valid: p != nil}
+}
+
+// NullFloat64ToValue converts a sql.NullFloat64 to a number or null Value
+func NullFloat64ToValue(n sql.NullFloat64) *structpb.Value {
+	if !n.Valid {
+		return structpb.NewNullValue()
+	}
+	return structpb.NewNumberValue(n.Float64)
+}
+
+// NullFloat64FromValue converts a Value to a sql.NullFloat64 with ToFloat64
+func NullFloat64FromValue(v *structpb.Value) (sql.NullFloat64, error) {
+	f, valid, err := nullFromValue(v, ToFloat64)
+	return sql.NullFloat64{Float64: f, Valid: valid}, err
+}
+
+// NullBoolToProto converts a sql.NullBool to a protocol buffer bool pointer
+// Returns nil if n is not valid
+func NullBoolToProto(n sql.NullBool) *bool {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Bool
+}
+
+// NullBoolFromProto converts a protocol buffer bool pointer to a sql.NullBool
+func NullBoolFromProto(p *bool) sql.NullBool {
+	return sql.NullBool{Bool: Deref(p), Valid: p != nil}
+}
+
+// NullBoolToValue converts a sql.NullBool to a bool or null Value
+func NullBoolToValue(n sql.NullBool) *structpb.Value {
+	if !n.Valid {
+		return structpb.NewNullValue()
+	}
+	return structpb.NewBoolValue(n.Bool)
+}
+
+// NullBoolFromValue converts a Value to a sql.NullBool with ToBool
+func NullBoolFromValue(v *structpb.Value) (sql.NullBool, error) {
+	b, valid, err := nullFromValue(v, ToBool)
+	return sql.NullBool{Bool: b, Valid: valid}, err
+}
+
+// NullTimeToProto converts a sql.NullTime to a *timestamppb.Timestamp
+// Returns nil if n is not valid. Unlike TimeToProto, a valid zero time is kept
+func NullTimeToProto(n sql.NullTime) *timestamppb.Timestamp {
+	if !n.Valid {
+		return nil
+	}
+	return timestamppb.New(n.Time)
+}
+
+// NullTimeFromProto converts a *timestamppb.Timestamp to a sql.NullTime in UTC
+func NullTimeFromProto(ts *timestamppb.Timestamp) sql.NullTime {
+	return sql.NullTime{Time: TimeFromProto(ts), Valid: ts != nil}
+}
+
+// NullTimeToValue converts a sql.NullTime to an RFC 3339 string or null Value
+func NullTimeToValue(n sql.NullTime) *structpb.Value {
+	if !n.Valid {
+		return structpb.NewNullValue()
+	}
+	return structpb.NewStringValue(n.Time.Format(time.RFC3339Nano))
+}
+
+// NullTimeFromValue converts an RFC 3339 string or Unix seconds Value to a sql.NullTime
+func NullTimeFromValue(v *structpb.Value) (sql.NullTime, error) {
+	t, valid, err := nullFromValue(v, decodeTime)
+	return sql.NullTime{Time: t, Valid: valid}, err
+}
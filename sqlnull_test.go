@@ -0,0 +1,141 @@
+package protobaggins
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestNullProto(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid values", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, Ptr("a"), NullStringToProto(sql.NullString{String: "a", Valid: true}))
+		assert.Equal(t, Ptr(int64(7)), NullInt64ToProto(sql.NullInt64{Int64: 7, Valid: true}))
+		assert.Equal(t, Ptr(1.5), NullFloat64ToProto(sql.NullFloat64{Float64: 1.5, Valid: true}))
+		assert.Equal(t, Ptr(false), NullBoolToProto(sql.NullBool{Valid: true}))
+
+		assert.Equal(t, sql.NullString{String: "", Valid: true}, NullStringFromProto(Ptr("")))
+		assert.Equal(t, sql.NullInt64{Int64: 7, Valid: true}, NullInt64FromProto(Ptr(int64(7))))
+		assert.Equal(t, sql.NullFloat64{Float64: 1.5, Valid: true}, NullFloat64FromProto(Ptr(1.5)))
+		assert.Equal(t, sql.NullBool{Bool: true, Valid: true}, NullBoolFromProto(Ptr(true)))
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, NullStringToProto(sql.NullString{String: "ignored"}))
+		assert.Nil(t, NullInt64ToProto(sql.NullInt64{}))
+		assert.Nil(t, NullFloat64ToProto(sql.NullFloat64{}))
+		assert.Nil(t, NullBoolToProto(sql.NullBool{}))
+		assert.Nil(t, NullTimeToProto(sql.NullTime{}))
+
+		assert.Equal(t, sql.NullString{}, NullStringFromProto(nil))
+		assert.Equal(t, sql.NullInt64{}, NullInt64FromProto(nil))
+		assert.Equal(t, sql.NullFloat64{}, NullFloat64FromProto(nil))
+		assert.Equal(t, sql.NullBool{}, NullBoolFromProto(nil))
+		assert.Equal(t, sql.NullTime{}, NullTimeFromProto(nil))
+	})
+
+	t.Run("times", func(t *testing.T) {
+		t.Parallel()
+		when := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+		ts := NullTimeToProto(sql.NullTime{Time: when, Valid: true})
+		assert.True(t, proto.Equal(timestamppb.New(when), ts))
+		assert.Equal(t, sql.NullTime{Time: when, Valid: true}, NullTimeFromProto(ts))
+
+		zero := NullTimeToProto(sql.NullTime{Valid: true})
+		require.NotNil(t, zero)
+		assert.Equal(t, sql.NullTime{Valid: true}, NullTimeFromProto(zero))
+	})
+}
+
+func TestNullValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		s, err := NullStringFromValue(NullStringToValue(sql.NullString{String: "a", Valid: true}))
+		require.NoError(t, err)
+		assert.Equal(t, sql.NullString{String: "a", Valid: true}, s)
+
+		big := sql.NullInt64{Int64: 1<<62 + 1, Valid: true}
+		assert.Equal(t, "4611686018427387905", NullInt64ToValue(big).GetStringValue())
+		i, err := NullInt64FromValue(NullInt64ToValue(big))
+		require.NoError(t, err)
+		assert.Equal(t, big, i)
+
+		f, err := NullFloat64FromValue(NullFloat64ToValue(sql.NullFloat64{Float64: 1.5, Valid: true}))
+		require.NoError(t, err)
+		assert.Equal(t, sql.NullFloat64{Float64: 1.5, Valid: true}, f)
+
+		b, err := NullBoolFromValue(NullBoolToValue(sql.NullBool{Bool: true, Valid: true}))
+		require.NoError(t, err)
+		assert.Equal(t, sql.NullBool{Bool: true, Valid: true}, b)
+
+		when := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+		tv := NullTimeToValue(sql.NullTime{Time: when, Valid: true})
+		assert.Equal(t, "2024-05-06T07:08:09.00000001Z", tv.GetStringValue())
+		got, err := NullTimeFromValue(tv)
+		require.NoError(t, err)
+		assert.Equal(t, sql.NullTime{Time: when, Valid: true}, got)
+	})
+
+	t.Run("invalid values are null", func(t *testing.T) {
+		t.Parallel()
+		for _, v := range []*structpb.Value{
+			NullStringToValue(sql.NullString{}),
+			NullInt64ToValue(sql.NullInt64{}),
+			NullFloat64ToValue(sql.NullFloat64{}),
+			NullBoolToValue(sql.NullBool{}),
+			NullTimeToValue(sql.NullTime{}),
+		} {
+			assert.Equal(t, KindNull, KindOf(v))
+		}
+	})
+
+	t.Run("null and nil values are invalid", func(t *testing.T) {
+		t.Parallel()
+		for _, v := range []*structpb.Value{nil, structpb.NewNullValue()} {
+			s, err := NullStringFromValue(v)
+			require.NoError(t, err)
+			assert.False(t, s.Valid)
+			i, err := NullInt64FromValue(v)
+			require.NoError(t, err)
+			assert.False(t, i.Valid)
+			tm, err := NullTimeFromValue(v)
+			require.NoError(t, err)
+			assert.False(t, tm.Valid)
+		}
+	})
+
+	t.Run("coercion", func(t *testing.T) {
+		t.Parallel()
+		i, err := NullInt64FromValue(structpb.NewStringValue("42"))
+		require.NoError(t, err)
+		assert.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, i)
+
+		tm, err := NullTimeFromValue(structpb.NewNumberValue(0))
+		require.NoError(t, err)
+		assert.Equal(t, sql.NullTime{Time: time.Unix(0, 0).UTC(), Valid: true}, tm)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		i, err := NullInt64FromValue(structpb.NewNumberValue(1.5))
+		require.ErrorIs(t, err, ErrNotCoercible)
+		assert.Equal(t, sql.NullInt64{}, i)
+
+		_, err = NullBoolFromValue(structpb.NewListValue(&structpb.ListValue{}))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+
+		_, err = NullTimeFromValue(structpb.NewStringValue("yesterday"))
+		require.ErrorIs(t, err, ErrNotCoercible)
+	})
+}
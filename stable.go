@@ -0,0 +1,209 @@
+package protobaggins
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MarshalStable encodes v in the protocol buffer wire format of google.protobuf.Value
+// such that values with equal canonical forms, see Canonicalize, always give the same
+// bytes, for content addressing and signing. Struct keys are sorted and fields come in
+// field number order. The encoding is written here rather than by proto.Marshal, whose
+// deterministic output may change between library versions, so stored bytes and
+// signatures stay valid. Any protobuf decoder reads it as the canonical form of v.
+// Invalid UTF-8 in strings or keys fails. A nil v encodes as null
+func MarshalStable(v *structpb.Value) ([]byte, error) {
+	var sm stableMarshaler
+	size, err := sm.measure("", v)
+	if err != nil {
+		return nil, err
+	}
+	sm.buf = make([]byte, 0, size)
+	sm.write(v)
+	return sm.buf, nil
+}
+
+// MarshalStableStruct is MarshalStable for a Struct, encoded as a google.protobuf.Struct.
+// A nil Struct is an empty one
+func MarshalStableStruct(s *structpb.Struct) ([]byte, error) {
+	var sm stableMarshaler
+	size, err := sm.measureFields("", s)
+	if err != nil {
+		return nil, err
+	}
+	sm.buf = make([]byte, 0, size)
+	sm.writeFields(s)
+	return sm.buf, nil
+}
+
+// UnmarshalStable decodes b written by MarshalStable. Input that MarshalStable would
+// not have produced for the decoded value fails with ErrNotStable, so that a value has
+// a single accepted encoding when b is verified against a digest or signature
+func UnmarshalStable(b []byte) (*structpb.Value, error) {
+	v := &structpb.Value{}
+	if err := proto.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	if v.GetKind() == nil {
+		return nil, fmt.Errorf("%w: value without a kind", ErrNotStable)
+	}
+	want, err := MarshalStable(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStable(b, want); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// UnmarshalStableStruct decodes b written by MarshalStableStruct, see UnmarshalStable
+func UnmarshalStableStruct(b []byte) (*structpb.Struct, error) {
+	s := &structpb.Struct{}
+	if err := proto.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	want, err := MarshalStableStruct(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStable(b, want); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// checkStable compares b to want, the stable encoding of the value decoded from b
+func checkStable(b, want []byte) error {
+	if !bytes.Equal(b, want) {
+		return fmt.Errorf("%w: %d bytes differ from the %d byte stable encoding", ErrNotStable, len(b), len(want))
+	}
+	return nil
+}
+
+// stableMarshaler encodes in two passes: measure records the sizes of every Struct,
+// list, entry and item in the order write needs them, so lengths can be written before the contents
+type stableMarshaler struct {
+	sizes []int
+	next  int
+	buf   []byte
+}
+
+// measure returns the size of the contents of the Value message for v
+func (sm *stableMarshaler) measure(path string, v *structpb.Value) (int, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return 2, nil
+	case *structpb.Value_NumberValue:
+		return 9, nil
+	case *structpb.Value_StringValue:
+		if !utf8.ValidString(kind.StringValue) {
+			return 0, fmt.Errorf("%s: invalid UTF-8 in string: %q", describePath(path), kind.StringValue)
+		}
+		return 1 + protowire.SizeBytes(len(kind.StringValue)), nil
+	case *structpb.Value_ListValue:
+		i := len(sm.sizes)
+		sm.sizes = append(sm.sizes, 0)
+		var size int
+		for idx, item := range kind.ListValue.GetValues() {
+			j := len(sm.sizes)
+			sm.sizes = append(sm.sizes, 0)
+			itemSize, err := sm.measure(joinIndex(path, idx), item)
+			if err != nil {
+				return 0, err
+			}
+			sm.sizes[j] = itemSize
+			size += protowire.SizeTag(wireListValues) + protowire.SizeBytes(itemSize)
+		}
+		sm.sizes[i] = size
+		return 1 + protowire.SizeBytes(size), nil
+	case *structpb.Value_StructValue:
+		size, err := sm.measureFields(path, kind.StructValue)
+		if err != nil {
+			return 0, err
+		}
+		return 1 + protowire.SizeBytes(size), nil
+	default:
+		return 2, nil
+	}
+}
+
+// measureFields returns the size of the contents of the Struct message for s
+func (sm *stableMarshaler) measureFields(path string, s *structpb.Struct) (int, error) {
+	i := len(sm.sizes)
+	sm.sizes = append(sm.sizes, 0)
+	var size int
+	for _, key := range sortedKeys(s) {
+		if !utf8.ValidString(key) {
+			return 0, fmt.Errorf("%s: invalid UTF-8 in key: %q", describePath(path), key)
+		}
+		j := len(sm.sizes)
+		sm.sizes = append(sm.sizes, 0)
+		fieldSize, err := sm.measure(joinKey(path, key), s.GetFields()[key])
+		if err != nil {
+			return 0, err
+		}
+		sm.sizes[j] = fieldSize
+		size += protowire.SizeTag(wireStructFields) + protowire.SizeBytes(entrySize(key, fieldSize))
+	}
+	sm.sizes[i] = size
+	return size, nil
+}
+
+// nextSize returns the next size recorded by measure
+func (sm *stableMarshaler) nextSize() int {
+	size := sm.sizes[sm.next]
+	sm.next++
+	return size
+}
+
+// write writes the contents of the Value message for v in canonical form
+func (sm *stableMarshaler) write(v *structpb.Value) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		sm.buf = protowire.AppendTag(sm.buf, wireBoolValue, protowire.VarintType)
+		sm.buf = protowire.AppendVarint(sm.buf, protowire.EncodeBool(kind.BoolValue))
+	case *structpb.Value_NumberValue:
+		sm.buf = protowire.AppendTag(sm.buf, wireNumberValue, protowire.Fixed64Type)
+		sm.buf = protowire.AppendFixed64(sm.buf, math.Float64bits(canonicalNumber(kind.NumberValue)))
+	case *structpb.Value_StringValue:
+		sm.buf = protowire.AppendTag(sm.buf, wireStringValue, protowire.BytesType)
+		sm.buf = protowire.AppendString(sm.buf, kind.StringValue)
+	case *structpb.Value_ListValue:
+		sm.buf = protowire.AppendTag(sm.buf, wireListValue, protowire.BytesType)
+		sm.buf = protowire.AppendVarint(sm.buf, uint64(sm.nextSize()))
+		for _, item := range kind.ListValue.GetValues() {
+			sm.buf = protowire.AppendTag(sm.buf, wireListValues, protowire.BytesType)
+			sm.buf = protowire.AppendVarint(sm.buf, uint64(sm.nextSize()))
+			sm.write(item)
+		}
+	case *structpb.Value_StructValue:
+		sm.buf = protowire.AppendTag(sm.buf, wireStructValue, protowire.BytesType)
+		sm.buf = protowire.AppendVarint(sm.buf, uint64(sm.sizes[sm.next]))
+		sm.writeFields(kind.StructValue)
+	default:
+		sm.buf = protowire.AppendTag(sm.buf, wireNullValue, protowire.VarintType)
+		sm.buf = protowire.AppendVarint(sm.buf, 0)
+	}
+}
+
+// writeFields writes the contents of the Struct message for s
+func (sm *stableMarshaler) writeFields(s *structpb.Struct) {
+	sm.nextSize()
+	for _, key := range sortedKeys(s) {
+		fieldSize := sm.nextSize()
+		sm.buf = protowire.AppendTag(sm.buf, wireStructFields, protowire.BytesType)
+		sm.buf = protowire.AppendVarint(sm.buf, uint64(entrySize(key, fieldSize)))
+		sm.buf = protowire.AppendTag(sm.buf, wireEntryKey, protowire.BytesType)
+		sm.buf = protowire.AppendString(sm.buf, key)
+		sm.buf = protowire.AppendTag(sm.buf, wireEntryValue, protowire.BytesType)
+		sm.buf = protowire.AppendVarint(sm.buf, uint64(fieldSize))
+		sm.write(s.GetFields()[key])
+	}
+}
@@ -0,0 +1,120 @@
+package protobaggins
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestMarshalStable(t *testing.T) {
+	t.Parallel()
+
+	newPayload := func(t *testing.T) *structpb.Value {
+		t.Helper()
+		v, err := structpb.NewValue(map[string]any{
+			"ring":  map[string]any{"bearer": "Frodo", "weight": 0.1, "found": true},
+			"party": []any{"Sam", nil, 9.0, map[string]any{"z": 1, "a": 2}},
+			"empty": map[string]any{},
+		})
+		require.NoError(t, err)
+		return v
+	}
+
+	t.Run("matches deterministic proto encoding of the canonical form", func(t *testing.T) {
+		t.Parallel()
+		v := newPayload(t)
+		v.GetStructValue().GetFields()["zero"] = structpb.NewNumberValue(math.Copysign(0, -1))
+		v.GetStructValue().GetFields()["nan"] = structpb.NewNumberValue(math.Float64frombits(0x7ff8000000000042))
+		v.GetStructValue().GetFields()["kindless"] = &structpb.Value{}
+
+		got, err := MarshalStable(v)
+		require.NoError(t, err)
+		want, err := proto.MarshalOptions{Deterministic: true}.Marshal(Canonicalize(v))
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		for range 20 {
+			again, err := MarshalStable(proto.Clone(v).(*structpb.Value))
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		}
+	})
+
+	t.Run("golden", func(t *testing.T) {
+		t.Parallel()
+		v, err := structpb.NewValue(map[string]any{"b": true, "a": []any{1, nil}})
+		require.NoError(t, err)
+		got, err := MarshalStable(v)
+		require.NoError(t, err)
+		// the encoding must not change, or stored digests and signatures stop matching
+		assert.Equal(t, "2a210a160a01611211320f0a0911000000000000f03f0a0208000a070a016212022001", hex.EncodeToString(got))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		v := newPayload(t)
+		b, err := MarshalStable(v)
+		require.NoError(t, err)
+		got, err := UnmarshalStable(b)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(v, got))
+
+		s := v.GetStructValue()
+		b, err = MarshalStableStruct(s)
+		require.NoError(t, err)
+		gotStruct, err := UnmarshalStableStruct(b)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(s, gotStruct))
+
+		b, err = MarshalStable(nil)
+		require.NoError(t, err)
+		got, err = UnmarshalStable(b)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(structpb.NewNullValue(), got))
+
+		b, err = MarshalStableStruct(nil)
+		require.NoError(t, err)
+		assert.Empty(t, b)
+	})
+
+	t.Run("rejects other encodings", func(t *testing.T) {
+		t.Parallel()
+		negativeZero, err := proto.Marshal(structpb.NewNumberValue(math.Copysign(0, -1)))
+		require.NoError(t, err)
+		_, err = UnmarshalStable(negativeZero)
+		require.ErrorIs(t, err, ErrNotStable)
+
+		_, err = UnmarshalStable(nil)
+		require.ErrorIs(t, err, ErrNotStable)
+
+		// the same field twice decodes to its last value
+		b, err := MarshalStable(structpb.NewBoolValue(true))
+		require.NoError(t, err)
+		_, err = UnmarshalStable(append(b, b...))
+		require.ErrorIs(t, err, ErrNotStable)
+
+		b, err = MarshalStableStruct(newPayload(t).GetStructValue())
+		require.NoError(t, err)
+		_, err = UnmarshalStableStruct(append(b[:len(b):len(b)], 0x0a, 0x00))
+		require.ErrorIs(t, err, ErrNotStable)
+
+		_, err = UnmarshalStable([]byte{0xff})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid UTF-8", func(t *testing.T) {
+		t.Parallel()
+		v := newPayload(t)
+		v.GetStructValue().GetFields()["party"].GetListValue().Values[0] = structpb.NewStringValue("\xff")
+		_, err := MarshalStable(v)
+		require.ErrorContains(t, err, "party[0]")
+
+		_, err = MarshalStableStruct(&structpb.Struct{Fields: map[string]*structpb.Value{"\xff": structpb.NewNullValue()}})
+		require.ErrorContains(t, err, "invalid UTF-8 in key")
+	})
+}
@@ -0,0 +1,226 @@
+// Package starlarkconv converts between structpb values and Starlark values, for
+// handing Struct payloads to embedded Starlark scripts and reading back their results.
+//
+// Structs convert to dicts with string keys, lists to lists, and whole numbers within
+// ±2^53 to ints, other numbers to floats. In the other direction tuples also become
+// lists, and ints that a float64 cannot hold exactly are handled as WithBigIntegers
+// selects.
+package starlarkconv
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/robbyt/protobaggins/internal/conv"
+	"go.starlark.net/starlark"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// BigIntegers is the policy for Starlark ints beyond ±2^53, which a float64 number
+// cannot hold exactly
+type BigIntegers int
+
+const (
+	// BigIntegersFail fails the conversion with protobaggins.ErrNotCoercible
+	BigIntegersFail BigIntegers = iota
+	// BigIntegersAsStrings converts them to decimal strings, see protobaggins.Int64ToValue
+	BigIntegersAsStrings
+	// BigIntegersAsFloats rounds them to the nearest float64
+	BigIntegersAsFloats
+)
+
+// Option configures the conversions
+type Option func(*options)
+
+type options struct {
+	bigIntegers     BigIntegers
+	numbersAsFloats bool
+}
+
+// WithBigIntegers sets the policy for ints beyond ±2^53, BigIntegersFail by default
+func WithBigIntegers(policy BigIntegers) Option {
+	return func(o *options) {
+		o.bigIntegers = policy
+	}
+}
+
+// NumbersAsFloats converts every number to a Starlark float, even whole ones
+func NumbersAsFloats() Option {
+	return func(o *options) {
+		o.numbersAsFloats = true
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ToStarlark converts v to a Starlark value: None, a bool, an int or float, a string, a
+// list or a dict whose keys are inserted in sorted order. The result is not frozen
+func ToStarlark(v *structpb.Value, opts ...Option) starlark.Value {
+	return newOptions(opts).toStarlark(v)
+}
+
+// StructToDict converts s to a Starlark dict, see ToStarlark
+func StructToDict(s *structpb.Struct, opts ...Option) *starlark.Dict {
+	return newOptions(opts).dict(s)
+}
+
+func (o options) toStarlark(v *structpb.Value) starlark.Value {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return o.dict(kind.StructValue)
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		elems := make([]starlark.Value, len(items))
+		for i, item := range items {
+			elems[i] = o.toStarlark(item)
+		}
+		return starlark.NewList(elems)
+	case *structpb.Value_StringValue:
+		return starlark.String(kind.StringValue)
+	case *structpb.Value_NumberValue:
+		f := kind.NumberValue
+		if !o.numbersAsFloats && f == math.Trunc(f) && math.Abs(f) <= conv.MaxSafeInteger {
+			return starlark.MakeInt64(int64(f))
+		}
+		return starlark.Float(f)
+	case *structpb.Value_BoolValue:
+		return starlark.Bool(kind.BoolValue)
+	default:
+		return starlark.None
+	}
+}
+
+func (o options) dict(s *structpb.Struct) *starlark.Dict {
+	keys := sortedKeys(s)
+	d := starlark.NewDict(len(keys))
+	for _, key := range keys {
+		// cannot fail, the dict is new and strings are hashable
+		_ = d.SetKey(starlark.String(key), o.toStarlark(s.GetFields()[key]))
+	}
+	return d
+}
+
+// FromStarlark converts v to a Value. Dicts must have string keys and become Structs,
+// lists and tuples become lists, and ints are converted under WithBigIntegers. Fails
+// with protobaggins.ErrUnexpectedKind for other types, such as functions and sets, and
+// with protobaggins.ErrCycle for lists and dicts that contain themselves
+func FromStarlark(v starlark.Value, opts ...Option) (*structpb.Value, error) {
+	c := converter{opts: newOptions(opts), visiting: make(map[starlark.Value]bool)}
+	return c.fromStarlark("", v)
+}
+
+// DictToStruct converts d to a Struct, see FromStarlark
+func DictToStruct(d *starlark.Dict, opts ...Option) (*structpb.Struct, error) {
+	c := converter{opts: newOptions(opts), visiting: make(map[starlark.Value]bool)}
+	return c.structValue("", d)
+}
+
+type converter struct {
+	opts options
+	// visiting holds the lists and dicts being converted, to detect cycles
+	visiting map[starlark.Value]bool
+}
+
+func (c *converter) fromStarlark(path string, v starlark.Value) (*structpb.Value, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return structpb.NewNullValue(), nil
+	case starlark.Bool:
+		return structpb.NewBoolValue(bool(v)), nil
+	case starlark.String:
+		return structpb.NewStringValue(string(v)), nil
+	case starlark.Float:
+		return structpb.NewNumberValue(float64(v)), nil
+	case starlark.Int:
+		return c.int(path, v)
+	case *starlark.Dict:
+		s, err := c.structValue(path, v)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStructValue(s), nil
+	case *starlark.List:
+		return c.list(path, v, v)
+	case starlark.Tuple:
+		return c.list(path, nil, v)
+	default:
+		return nil, fmt.Errorf("%s: %w: cannot convert a Starlark %s", conv.DescribePath(path), protobaggins.ErrUnexpectedKind, v.Type())
+	}
+}
+
+func (c *converter) int(path string, i starlark.Int) (*structpb.Value, error) {
+	if n, ok := i.Int64(); ok && -conv.MaxSafeInteger <= n && n <= conv.MaxSafeInteger {
+		return structpb.NewNumberValue(float64(n)), nil
+	}
+	switch c.opts.bigIntegers {
+	case BigIntegersAsStrings:
+		return structpb.NewStringValue(i.String()), nil
+	case BigIntegersAsFloats:
+		return structpb.NewNumberValue(float64(i.Float())), nil
+	default:
+		return nil, fmt.Errorf("%s: %w: %s is beyond ±2^53", conv.DescribePath(path), protobaggins.ErrNotCoercible, i)
+	}
+}
+
+// enter marks the list or dict v as being converted, failing if it already is
+func (c *converter) enter(path string, v starlark.Value) error {
+	if c.visiting[v] {
+		return fmt.Errorf("%s: %w", conv.DescribePath(path), protobaggins.ErrCycle)
+	}
+	c.visiting[v] = true
+	return nil
+}
+
+func (c *converter) structValue(path string, d *starlark.Dict) (*structpb.Struct, error) {
+	if err := c.enter(path, d); err != nil {
+		return nil, err
+	}
+	defer delete(c.visiting, d)
+
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, d.Len())}
+	for _, item := range d.Items() {
+		key, ok := item[0].(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: dict key %s is a %s, not a string", conv.DescribePath(path), protobaggins.ErrUnexpectedKind, item[0], item[0].Type())
+		}
+		v, err := c.fromStarlark(protobaggins.JoinPathKey(path, string(key)), item[1])
+		if err != nil {
+			return nil, err
+		}
+		s.Fields[string(key)] = v
+	}
+	return s, nil
+}
+
+// list converts the items of seq, a list or tuple; list is seq if it is a list
+func (c *converter) list(path string, list *starlark.List, seq starlark.Indexable) (*structpb.Value, error) {
+	if list != nil {
+		if err := c.enter(path, list); err != nil {
+			return nil, err
+		}
+		defer delete(c.visiting, list)
+	}
+
+	values := make([]*structpb.Value, seq.Len())
+	for i := range values {
+		v, err := c.fromStarlark(protobaggins.JoinPathIndex(path, i), seq.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+}
+
+func sortedKeys(s *structpb.Struct) []string {
+	return slices.Sorted(maps.Keys(s.GetFields()))
+}
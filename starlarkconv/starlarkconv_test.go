@@ -0,0 +1,148 @@
+package starlarkconv
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robbyt/protobaggins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.starlark.net/starlark"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestToStarlark(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"name":  "frodo",
+		"age":   50.0,
+		"ratio": 0.5,
+		"big":   1e20,
+		"ring":  true,
+		"gone":  nil,
+		"tags":  []any{"a", 1.0},
+		"house": map[string]any{"door": "green"},
+	})
+	require.NoError(t, err)
+
+	t.Run("dict", func(t *testing.T) {
+		t.Parallel()
+		d := StructToDict(s)
+		assert.Equal(t, `{"age": 50, "big": 1e+20, "gone": None, "house": {"door": "green"}, "name": "frodo", "ratio": 0.5, "ring": True, "tags": ["a", 1]}`, d.String())
+		assert.Equal(t, d.String(), ToStarlark(structpb.NewStructValue(s)).String())
+	})
+
+	t.Run("numbers as floats", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, starlark.Float(50), ToStarlark(structpb.NewNumberValue(50), NumbersAsFloats()))
+		assert.Equal(t, starlark.MakeInt(50), ToStarlark(structpb.NewNumberValue(50)))
+		assert.Equal(t, starlark.Float(math.Inf(1)), ToStarlark(structpb.NewNumberValue(math.Inf(1))))
+	})
+
+	t.Run("usable from scripts", func(t *testing.T) {
+		t.Parallel()
+		thread := &starlark.Thread{Name: "test"}
+		v, err := starlark.Eval(thread, "policy", `input["age"] + len(input["tags"])`, starlark.StringDict{"input": StructToDict(s)})
+		require.NoError(t, err)
+		assert.Equal(t, starlark.MakeInt(52), v)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, starlark.None, ToStarlark(nil))
+		assert.Equal(t, 0, StructToDict(nil).Len())
+	})
+}
+
+func TestFromStarlark(t *testing.T) {
+	t.Parallel()
+
+	t.Run("script result", func(t *testing.T) {
+		t.Parallel()
+		thread := &starlark.Thread{Name: "test"}
+		globals, err := starlark.ExecFile(thread, "policy.star", `
+result = {
+    "allow": True,
+    "score": 3,
+    "ratio": 0.25,
+    "reasons": ["ok", None],
+    "pair": (1, "two"),
+    "nested": {"deep": -7},
+}
+`, nil)
+		require.NoError(t, err)
+
+		got, err := DictToStruct(globals["result"].(*starlark.Dict))
+		require.NoError(t, err)
+		want, err := structpb.NewStruct(map[string]any{
+			"allow":   true,
+			"score":   3.0,
+			"ratio":   0.25,
+			"reasons": []any{"ok", nil},
+			"pair":    []any{1.0, "two"},
+			"nested":  map[string]any{"deep": -7.0},
+		})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(want, got), "%v", got)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": []any{1.0, 1.5, "x", nil, false}, "b": map[string]any{}})
+		require.NoError(t, err)
+		got, err := DictToStruct(StructToDict(s))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(s, got))
+	})
+
+	t.Run("big integers", func(t *testing.T) {
+		t.Parallel()
+		big := starlark.MakeInt64(1<<53 + 1)
+		_, err := FromStarlark(big)
+		require.ErrorIs(t, err, protobaggins.ErrNotCoercible)
+
+		v, err := FromStarlark(big, WithBigIntegers(BigIntegersAsStrings))
+		require.NoError(t, err)
+		assert.Equal(t, "9007199254740993", v.GetStringValue())
+
+		huge := starlark.MakeUint64(math.MaxUint64).Add(starlark.MakeInt(1))
+		v, err = FromStarlark(huge, WithBigIntegers(BigIntegersAsStrings))
+		require.NoError(t, err)
+		assert.Equal(t, "18446744073709551616", v.GetStringValue())
+
+		v, err = FromStarlark(big, WithBigIntegers(BigIntegersAsFloats))
+		require.NoError(t, err)
+		assert.InDelta(t, float64(1<<53), v.GetNumberValue(), 0)
+
+		v, err = FromStarlark(starlark.MakeInt64(-(1 << 53)))
+		require.NoError(t, err)
+		assert.InDelta(t, -float64(1<<53), v.GetNumberValue(), 0)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		d := starlark.NewDict(1)
+		require.NoError(t, d.SetKey(starlark.MakeInt(1), starlark.None))
+		_, err := FromStarlark(d)
+		require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind)
+
+		fn := starlark.NewBuiltin("fn", nil)
+		_, err = FromStarlark(starlark.NewList([]starlark.Value{starlark.None, fn}))
+		require.ErrorIs(t, err, protobaggins.ErrUnexpectedKind)
+		assert.ErrorContains(t, err, "[1]: ")
+
+		l := starlark.NewList(nil)
+		require.NoError(t, l.Append(l))
+		_, err = FromStarlark(l)
+		require.ErrorIs(t, err, protobaggins.ErrCycle)
+	})
+
+	t.Run("shared values are not cycles", func(t *testing.T) {
+		t.Parallel()
+		shared := starlark.NewList([]starlark.Value{starlark.String("x")})
+		_, err := FromStarlark(starlark.Tuple{shared, shared})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,92 @@
+package protobaggins
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ValueStats measures a Value, see Stats
+type ValueStats struct {
+	// Nodes is the number of values, counting the root and every container
+	Nodes int
+	// MaxDepth is the length of the longest path, the root having depth 0 and its
+	// fields or items depth 1
+	MaxDepth int
+	// StringBytes is the total length of strings and struct keys
+	StringBytes int
+	// JSONSize is the length of v in the compact JSON that StructToJSON writes,
+	// counting non-finite numbers as null
+	JSONSize int
+}
+
+// Stats measures v in a single pass without serializing it, to enforce quotas cheaply.
+// Unlike Profile it keeps no per-path details. A nil v measures as zero
+func Stats(v *structpb.Value) ValueStats {
+	var st stats
+	if v != nil {
+		st.value(v, 0)
+	}
+	return st.ValueStats
+}
+
+// StructStats is Stats for a Struct
+func StructStats(s *structpb.Struct) ValueStats {
+	if s == nil {
+		return ValueStats{}
+	}
+	return Stats(structpb.NewStructValue(s))
+}
+
+type stats struct {
+	ValueStats
+	// scratch is reused to format strings and numbers without allocating
+	scratch []byte
+}
+
+func (st *stats) value(v *structpb.Value, depth int) {
+	st.Nodes++
+	st.MaxDepth = max(st.MaxDepth, depth)
+
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		// braces, a colon per field and the commas between them
+		st.JSONSize += 2 + max(2*len(fields)-1, 0)
+		for key, field := range fields {
+			st.StringBytes += len(key)
+			st.string(key)
+			st.value(field, depth+1)
+		}
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		st.JSONSize += 2 + max(len(items)-1, 0)
+		for _, item := range items {
+			st.value(item, depth+1)
+		}
+	case *structpb.Value_StringValue:
+		st.StringBytes += len(kind.StringValue)
+		st.string(kind.StringValue)
+	case *structpb.Value_NumberValue:
+		if f := kind.NumberValue; math.IsNaN(f) || math.IsInf(f, 0) {
+			st.JSONSize += len("null")
+		} else {
+			st.scratch = appendJSONNumber(st.scratch[:0], f)
+			st.JSONSize += len(st.scratch)
+		}
+	case *structpb.Value_BoolValue:
+		if kind.BoolValue {
+			st.JSONSize += len("true")
+		} else {
+			st.JSONSize += len("false")
+		}
+	default:
+		st.JSONSize += len("null")
+	}
+}
+
+// string adds the quoted length of s to JSONSize
+func (st *stats) string(s string) {
+	st.scratch = appendJSONString(st.scratch[:0], s)
+	st.JSONSize += len(st.scratch)
+}
@@ -0,0 +1,47 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"name":  "frodo \"ring\" baggins\n",
+		"age":   50.5,
+		"big":   1e21,
+		"tiny":  -1e-7,
+		"ok":    true,
+		"no":    false,
+		"none":  nil,
+		"tags":  []any{"a", []any{}, map[string]any{}},
+		"héllo": map[string]any{" ": "<&>", "n": 3},
+	})
+	require.NoError(t, err)
+
+	data, err := StructToJSON(s)
+	require.NoError(t, err)
+
+	got := StructStats(s)
+	assert.Equal(t, len(data), got.JSONSize)
+	assert.Equal(t, 15, got.Nodes)
+	assert.Equal(t, 2, got.MaxDepth)
+	wantBytes := len("name") + len("frodo \"ring\" baggins\n") + len("age") + len("big") + len("tiny") +
+		len("ok") + len("no") + len("none") + len("tags") + len("a") + len("héllo") + len(" ") + len("<&>") + len("n")
+	assert.Equal(t, wantBytes, got.StringBytes)
+
+	t.Run("scalars", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, ValueStats{Nodes: 1, JSONSize: 3}, Stats(structpb.NewNumberValue(1.5)))
+		assert.Equal(t, ValueStats{Nodes: 1, JSONSize: 4}, Stats(structpb.NewNumberValue(math.NaN())))
+		assert.Equal(t, ValueStats{Nodes: 1, JSONSize: 4}, Stats(&structpb.Value{}))
+		assert.Equal(t, ValueStats{}, Stats(nil))
+		assert.Equal(t, ValueStats{}, StructStats(nil))
+	})
+}
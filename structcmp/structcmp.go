@@ -0,0 +1,135 @@
+// Package structcmp provides go-cmp options that compare structpb values semantically,
+// so cmp.Diff on messages containing Struct fields reports JSON-like differences
+// instead of protobuf internals
+package structcmp
+
+import (
+	"slices"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const transformerName = "structcmp.Transform"
+
+// Option configures Transform
+type Option func(*options)
+
+type options struct {
+	ignorePaths []string
+	epsilon     float64
+}
+
+// IgnorePaths skips the values at the given paths and anywhere beneath them
+// Paths are relative to each Struct, Value or ListValue and use the notation of
+// protobaggins.Diff, e.g. "metadata.createdAt" or "items[0]"
+func IgnorePaths(paths ...string) Option {
+	return func(o *options) {
+		o.ignorePaths = append(o.ignorePaths, paths...)
+	}
+}
+
+// FloatEpsilon treats numbers within structpb values as equal when they differ by at
+// most epsilon. Floats elsewhere in the compared messages are not affected
+func FloatEpsilon(epsilon float64) Option {
+	return func(o *options) {
+		o.epsilon = epsilon
+	}
+}
+
+// Transform returns options for cmp.Equal and cmp.Diff that compare messages with
+// protocmp.Transform, which they include, and compare google.protobuf.Struct, Value
+// and ListValue messages as the plain maps, slices and scalars they represent.
+// Do not pass protocmp.Transform again alongside it
+func Transform(opts ...Option) cmp.Option {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cmpOpts := cmp.Options{
+		protocmp.Transform(),
+		cmp.FilterValues(func(x, y protocmp.Message) bool {
+			return isStructType(x) && isStructType(y)
+		}, cmp.Transformer(transformerName, o.plain)),
+	}
+	if o.epsilon > 0 {
+		cmpOpts = append(cmpOpts, cmp.FilterPath(withinTransform, cmpopts.EquateApprox(0, o.epsilon)))
+	}
+	return cmpOpts
+}
+
+func isStructType(m protocmp.Message) bool {
+	if m == nil {
+		return false
+	}
+	switch m.Unwrap().(type) {
+	case *structpb.Struct, *structpb.Value, *structpb.ListValue:
+		return true
+	}
+	return false
+}
+
+// withinTransform reports whether the path passes through the structpb transformer
+func withinTransform(p cmp.Path) bool {
+	for _, step := range p {
+		if t, ok := step.(cmp.Transform); ok && t.Name() == transformerName {
+			return true
+		}
+	}
+	return false
+}
+
+// plain converts a transformed structpb message back to the value it represents
+func (o *options) plain(m protocmp.Message) any {
+	switch msg := m.Unwrap().(type) {
+	case *structpb.Struct:
+		return o.plainValue("", structpb.NewStructValue(msg))
+	case *structpb.ListValue:
+		return o.plainValue("", structpb.NewListValue(msg))
+	default:
+		return o.plainValue("", msg.(*structpb.Value))
+	}
+}
+
+func (o *options) plainValue(path string, v *structpb.Value) any {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return kind.BoolValue
+	case *structpb.Value_NumberValue:
+		return kind.NumberValue
+	case *structpb.Value_StringValue:
+		return kind.StringValue
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		out := make([]any, 0, len(items))
+		for i, item := range items {
+			itemPath := protobaggins.JoinPathIndex(path, i)
+			if !o.ignored(itemPath) {
+				out = append(out, o.plainValue(itemPath, item))
+			}
+		}
+		return out
+	case *structpb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		out := make(map[string]any, len(fields))
+		for key, field := range fields {
+			keyPath := protobaggins.JoinPathKey(path, key)
+			if !o.ignored(keyPath) {
+				out[key] = o.plainValue(keyPath, field)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func (o *options) ignored(path string) bool {
+	return slices.ContainsFunc(o.ignorePaths, func(prefix string) bool {
+		return protobaggins.WithinPath(path, prefix)
+	})
+}
@@ -0,0 +1,91 @@
+package structcmp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type record struct {
+	Name    string
+	Payload *structpb.Struct
+	Tags    *structpb.ListValue
+	Score   *wrapperspb.DoubleValue
+}
+
+func mustStruct(t *testing.T, m map[string]any) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(m)
+	require.NoError(t, err)
+	return s
+}
+
+func TestTransform(t *testing.T) {
+	t.Parallel()
+
+	newRecord := func(t *testing.T, replicas float64, createdAt string) record {
+		t.Helper()
+		tags, err := structpb.NewList([]any{"a", "b"})
+		require.NoError(t, err)
+		return record{
+			Name: "web",
+			Payload: mustStruct(t, map[string]any{
+				"spec":     map[string]any{"replicas": replicas, "image": "nginx"},
+				"metadata": map[string]any{"createdAt": createdAt},
+			}),
+			Tags:  tags,
+			Score: wrapperspb.Double(1.0),
+		}
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		t.Parallel()
+		a, b := newRecord(t, 3, "now"), newRecord(t, 3, "now")
+		assert.Empty(t, cmp.Diff(a, b, Transform()))
+	})
+
+	t.Run("readable diff", func(t *testing.T) {
+		t.Parallel()
+		diff := cmp.Diff(newRecord(t, 3, "now"), newRecord(t, 4, "now"), Transform())
+		assert.Contains(t, diff, `"replicas": float64(3)`)
+		assert.Contains(t, diff, `"replicas": float64(4)`)
+		assert.NotContains(t, diff, "number_value")
+	})
+
+	t.Run("float epsilon", func(t *testing.T) {
+		t.Parallel()
+		a, b := newRecord(t, 3, "now"), newRecord(t, 3.0001, "now")
+		assert.False(t, cmp.Equal(a, b, Transform()))
+		assert.True(t, cmp.Equal(a, b, Transform(FloatEpsilon(0.001))))
+
+		// floats outside structpb values are compared exactly
+		b = newRecord(t, 3, "now")
+		b.Score = wrapperspb.Double(1.0001)
+		assert.False(t, cmp.Equal(a, b, Transform(FloatEpsilon(0.001))))
+	})
+
+	t.Run("ignore paths", func(t *testing.T) {
+		t.Parallel()
+		a, b := newRecord(t, 3, "now"), newRecord(t, 3, "later")
+		assert.False(t, cmp.Equal(a, b, Transform()))
+		assert.True(t, cmp.Equal(a, b, Transform(IgnorePaths("metadata.createdAt"))))
+		assert.True(t, cmp.Equal(a, b, Transform(IgnorePaths("metadata"))))
+		assert.False(t, cmp.Equal(a, b, Transform(IgnorePaths("meta"))))
+
+		b.Tags.Values[0] = structpb.NewStringValue("z")
+		assert.True(t, cmp.Equal(a, b, Transform(IgnorePaths("metadata", "[0]"))))
+	})
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+		a := structpb.NewStructValue(mustStruct(t, map[string]any{"a": []any{1, nil}}))
+		b := structpb.NewStructValue(mustStruct(t, map[string]any{"a": []any{1, nil}}))
+		assert.True(t, cmp.Equal(a, b, Transform()))
+		assert.True(t, cmp.Equal(&structpb.Value{}, structpb.NewNullValue(), Transform()))
+		assert.False(t, cmp.Equal(structpb.NewStringValue("1"), structpb.NewNumberValue(1), Transform()))
+	})
+}
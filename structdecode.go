@@ -0,0 +1,405 @@
+package protobaggins
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DecodeStruct populates out, which must be a non-nil pointer to a struct or a map, from
+// s using reflection. Keys are matched to fields by the names EncodeStruct produces,
+// falling back to a case-insensitive match, and unknown keys are ignored. Scalars are
+// coerced like the To* functions, so 42.0 and "42" both decode into an int, and:
+//   - time.Time and *timestamppb.Timestamp accept RFC 3339 strings and numbers of
+//     seconds since the Unix epoch
+//   - time.Duration and *durationpb.Duration accept strings like "1m30s" and numbers
+//     of nanoseconds
+//   - []byte accepts the forms of BytesFromValue, url.URL those of URLFromValue
+//   - types implementing ValueUnmarshaler accept any value other than null
+//   - types implementing encoding.TextUnmarshaler accept strings
+//
+// Null clears pointers, slices, maps and interfaces and leaves other fields unchanged
+// Every field that fails to decode is reported, each error prefixed with its path, and a
+// nil s leaves out unchanged
+func DecodeStruct(s *structpb.Struct, out any, opts ...Option) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("DecodeStruct: expected a non-nil pointer, got %T", out)
+	}
+	if target := rv.Elem(); target.Kind() != reflect.Struct && target.Kind() != reflect.Map {
+		return fmt.Errorf("DecodeStruct: expected a pointer to a struct or map, got %T", out)
+	}
+
+	if s == nil {
+		return nil
+	}
+
+	d := decoder{opts: newOptions(opts)}
+	var errs []error
+	d.decodeReflect("", structpb.NewStructValue(s), rv.Elem(), &errs)
+	return errors.Join(errs...)
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	timeType            = reflect.TypeFor[time.Time]()
+	timestampType       = reflect.TypeFor[*timestamppb.Timestamp]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	durationpbType      = reflect.TypeFor[*durationpb.Duration]()
+	urlType             = reflect.TypeFor[url.URL]()
+	locationType        = reflect.TypeFor[*time.Location]()
+	bytesType           = reflect.TypeFor[[]byte]()
+)
+
+// decodeReflect stores v in the settable rv, recording failures in errs
+func (d *decoder) decodeReflect(path string, v *structpb.Value, rv reflect.Value, errs *[]error) {
+	fail := func(err error) {
+		if path != "" {
+			err = fmt.Errorf("%s: %w", path, err)
+		}
+		*errs = append(*errs, err)
+	}
+
+	if _, isNull := v.GetKind().(*structpb.Value_NullValue); isNull || v.GetKind() == nil {
+		switch rv.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			rv.SetZero()
+		}
+		return
+	}
+
+	if handled, err := decodeSpecial(v, rv); handled {
+		if err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		d.decodeReflect(path, v, rv.Elem(), errs)
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.NumMethod() > 0 {
+			fail(fmt.Errorf("cannot decode into non-empty interface %s", rv.Type()))
+			return
+		}
+		rv.Set(reflect.ValueOf(d.decode(v)))
+	case reflect.Bool:
+		b, err := ToBool(v)
+		if err != nil {
+			fail(err)
+			return
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := ToInt64(v)
+		if err == nil && rv.OverflowInt(i) {
+			err = fmt.Errorf("%w: %d overflows %s", ErrNotCoercible, i, rv.Type())
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := ToInt64(v)
+		if err == nil && (i < 0 || rv.OverflowUint(uint64(i))) {
+			err = fmt.Errorf("%w: %d overflows %s", ErrNotCoercible, i, rv.Type())
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		rv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := ToFloat64(v)
+		if err == nil && rv.OverflowFloat(f) {
+			err = fmt.Errorf("%w: %v overflows %s", ErrNotCoercible, f, rv.Type())
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		rv.SetFloat(f)
+	case reflect.String:
+		str, err := ToString(v)
+		if err != nil {
+			fail(err)
+			return
+		}
+		rv.SetString(str)
+	case reflect.Slice, reflect.Array:
+		list := v.GetListValue()
+		if list == nil {
+			fail(fmt.Errorf("%w: cannot decode %s into %s", ErrUnexpectedKind, KindOf(v), rv.Type()))
+			return
+		}
+		d.decodeReflectList(path, list, rv, errs)
+	case reflect.Map:
+		st := v.GetStructValue()
+		if st == nil {
+			fail(fmt.Errorf("%w: cannot decode %s into %s", ErrUnexpectedKind, KindOf(v), rv.Type()))
+			return
+		}
+		d.decodeReflectMap(path, st, rv, errs)
+	case reflect.Struct:
+		st := v.GetStructValue()
+		if st == nil {
+			fail(fmt.Errorf("%w: cannot decode %s into %s", ErrUnexpectedKind, KindOf(v), rv.Type()))
+			return
+		}
+		d.decodeReflectStruct(path, st, rv, errs)
+	default:
+		fail(fmt.Errorf("unsupported type %s", rv.Type()))
+	}
+}
+
+// decodeSpecial handles the types that are not decoded by their kind
+// Returns false if rv is not one of them
+func decodeSpecial(v *structpb.Value, rv reflect.Value) (bool, error) {
+	if rv.CanAddr() && reflect.PointerTo(rv.Type()).Implements(valueUnmarshalerType) {
+		return true, rv.Addr().Interface().(ValueUnmarshaler).UnmarshalStructValue(v)
+	}
+
+	switch rv.Type() {
+	case timeType:
+		t, err := decodeTime(v)
+		if err == nil {
+			rv.Set(reflect.ValueOf(t))
+		}
+		return true, err
+	case timestampType:
+		t, err := decodeTime(v)
+		if err == nil {
+			rv.Set(reflect.ValueOf(timestamppb.New(t)))
+		}
+		return true, err
+	case durationType:
+		dur, err := decodeDuration(v)
+		if err == nil {
+			rv.SetInt(int64(dur))
+		}
+		return true, err
+	case durationpbType:
+		dur, err := decodeDuration(v)
+		if err == nil {
+			rv.Set(reflect.ValueOf(durationpb.New(dur)))
+		}
+		return true, err
+	case urlType:
+		u, err := URLFromValue(v)
+		if err == nil {
+			rv.Set(reflect.ValueOf(*u))
+		}
+		return true, err
+	case locationType:
+		loc, err := LocationFromValue(v)
+		if err == nil {
+			rv.Set(reflect.ValueOf(loc))
+		}
+		return true, err
+	case bytesType:
+		// lists of numbers fall through to the generic slice handling
+		if v.GetListValue() != nil {
+			return false, nil
+		}
+		b, err := BytesFromValue(v)
+		if err == nil {
+			rv.SetBytes(b)
+		}
+		return true, err
+	}
+
+	str, isString := v.GetKind().(*structpb.Value_StringValue)
+	if isString && rv.CanAddr() && reflect.PointerTo(rv.Type()).Implements(textUnmarshalerType) {
+		return true, rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str.StringValue))
+	}
+	return false, nil
+}
+
+func decodeTime(v *structpb.Value) (time.Time, error) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(kind.StringValue))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %q is not an RFC 3339 time", ErrNotCoercible, kind.StringValue)
+		}
+		return t, nil
+	case *structpb.Value_NumberValue:
+		f := kind.NumberValue
+		if math.IsNaN(f) || f < -1<<62 || f > 1<<62 {
+			return time.Time{}, fmt.Errorf("%w: %v is out of range for a time", ErrNotCoercible, f)
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: cannot decode %s as a time", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+func decodeDuration(v *structpb.Value) (time.Duration, error) {
+	if str, ok := v.GetKind().(*structpb.Value_StringValue); ok {
+		dur, err := time.ParseDuration(strings.TrimSpace(str.StringValue))
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q is not a duration", ErrNotCoercible, str.StringValue)
+		}
+		return dur, nil
+	}
+	if _, ok := v.GetKind().(*structpb.Value_NumberValue); !ok {
+		return 0, fmt.Errorf("%w: cannot decode %s as a duration", ErrUnexpectedKind, KindOf(v))
+	}
+	ns, err := ToInt64(v)
+	return time.Duration(ns), err
+}
+
+func (d *decoder) decodeReflectList(path string, list *structpb.ListValue, rv reflect.Value, errs *[]error) {
+	values := list.GetValues()
+	n := len(values)
+	if rv.Kind() == reflect.Array {
+		n = min(n, rv.Len())
+		for i := n; i < rv.Len(); i++ {
+			rv.Index(i).SetZero()
+		}
+	} else {
+		rv.Set(reflect.MakeSlice(rv.Type(), n, n))
+	}
+	for i := range n {
+		d.decodeReflect(joinIndex(path, i), values[i], rv.Index(i), errs)
+	}
+}
+
+func (d *decoder) decodeReflectMap(path string, st *structpb.Struct, rv reflect.Value, errs *[]error) {
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(rv.Type(), len(st.GetFields())))
+	}
+	names := d.opts.keyCaseNames(st.GetFields())
+	for _, key := range sortedKeys(st) {
+		value := st.GetFields()[key]
+		if !d.opts.decodedField(key, value) {
+			continue
+		}
+		name, ok := d.opts.decodedName(names, key)
+		if !ok {
+			continue
+		}
+		keyPath := joinKey(path, key)
+
+		mapKey, err := parseMapKey(name, rv.Type().Key())
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", keyPath, err))
+			continue
+		}
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		d.decodeReflect(keyPath, value, elem, errs)
+		rv.SetMapIndex(mapKey, elem)
+	}
+}
+
+func (d *decoder) decodeReflectStruct(path string, st *structpb.Struct, rv reflect.Value, errs *[]error) {
+	fields := structFields(rv.Type())
+	names := d.opts.keyCaseNames(st.GetFields())
+	for _, key := range sortedKeys(st) {
+		value := st.GetFields()[key]
+		if !d.opts.decodedField(key, value) {
+			continue
+		}
+		name, ok := d.opts.decodedName(names, key)
+		if !ok {
+			continue
+		}
+		f, ok := fieldForKey(fields, name)
+		if !ok {
+			continue
+		}
+		keyPath := joinKey(path, key)
+
+		fv, err := settableFieldByIndex(rv, f.index)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", keyPath, err))
+			continue
+		}
+		d.decodeReflect(keyPath, value, fv, errs)
+	}
+}
+
+// fieldForKey finds the field named key, preferring an exact match over a
+// case-insensitive one as encoding/json does
+func fieldForKey(fields []structField, key string) (structField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
+
+// settableFieldByIndex is like fieldByIndex but allocates nil embedded pointers on the
+// way, failing when they are unexported and cannot be set
+func settableFieldByIndex(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", rv.Type().Elem())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, nil
+}
+
+// parseMapKey is the inverse of mapKeyString
+func parseMapKey(key string, t reflect.Type) (reflect.Value, error) {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) && t.Kind() != reflect.String {
+		kv := reflect.New(t)
+		if err := kv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, err
+		}
+		return kv.Elem(), nil
+	}
+
+	kv := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		kv.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%w: map key %q cannot be parsed as %s", ErrNotCoercible, key, t)
+		}
+		kv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := strconv.ParseUint(key, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%w: map key %q cannot be parsed as %s", ErrNotCoercible, key, t)
+		}
+		kv.SetUint(i)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported map key type %s", t)
+	}
+	return kv, nil
+}
@@ -0,0 +1,208 @@
+package protobaggins
+
+import (
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type decodeAddress struct {
+	Street string `json:"street"`
+	Zip    *int   `json:"zip"`
+}
+
+type decodeBase struct {
+	ID string `json:"id"`
+}
+
+type decodeHobbit struct {
+	decodeBase
+	Name     string                      `json:"name"`
+	Nickname string                      `baggins:"nick" json:"nickname"`
+	Age      int8                        `json:"age"`
+	Rings    uint                        `json:"rings"`
+	Height   float32                     `json:"height"`
+	Brave    bool                        `json:"brave"`
+	Home     *decodeAddress              `json:"home"`
+	Past     []decodeAddress             `json:"past"`
+	Pair     [2]string                   `json:"pair"`
+	Tags     map[string]string           `json:"tags"`
+	Scores   map[int]float64             `json:"scores"`
+	Hosts    map[netip.Addr]bool         `json:"hosts"`
+	Born     time.Time                   `json:"born"`
+	Nap      time.Duration               `json:"nap"`
+	Link     *url.URL                    `json:"link"`
+	Data     []byte                      `json:"data"`
+	Addr     netip.Addr                  `json:"addr"`
+	Any      any                         `json:"any"`
+	Nested   map[string][]*decodeAddress `json:"nested"`
+	Untagged string
+	Secret   string `json:"-"`
+}
+
+func mustStruct(t *testing.T, m map[string]any) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(m)
+	require.NoError(t, err)
+	return s
+}
+
+func TestDecodeStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("fields, nesting and coercion", func(t *testing.T) {
+		t.Parallel()
+		s := mustStruct(t, map[string]any{
+			"id":       "h1",
+			"name":     "frodo",
+			"nick":     "mr. underhill",
+			"age":      "50",
+			"rings":    1.0,
+			"height":   1.06,
+			"brave":    "yes",
+			"home":     map[string]any{"street": "bagshot row", "zip": 12.0},
+			"past":     []any{map[string]any{"street": "brandy hall"}},
+			"pair":     []any{"sam", 3.0, "ignored"},
+			"tags":     map[string]any{"ring": "one"},
+			"scores":   map[string]any{"3": "1.5"},
+			"hosts":    map[string]any{"10.0.0.1": true},
+			"born":     "2968-09-22T00:00:00Z",
+			"nap":      "1h30m",
+			"link":     "https://shire.example/bag-end",
+			"data":     "aGVsbG8=",
+			"addr":     "192.0.2.1",
+			"any":      []any{1.0, "x"},
+			"nested":   map[string]any{"a": []any{map[string]any{"street": "hill"}, nil}},
+			"untagged": "matched case-insensitively",
+			"Secret":   "ignored",
+			"unknown":  "ignored",
+		})
+
+		var h decodeHobbit
+		require.NoError(t, DecodeStruct(s, &h))
+
+		assert.Equal(t, "h1", h.ID)
+		assert.Equal(t, "frodo", h.Name)
+		assert.Equal(t, "mr. underhill", h.Nickname)
+		assert.Equal(t, int8(50), h.Age)
+		assert.Equal(t, uint(1), h.Rings)
+		assert.InDelta(t, 1.06, h.Height, 1e-6)
+		assert.True(t, h.Brave)
+		require.NotNil(t, h.Home)
+		assert.Equal(t, "bagshot row", h.Home.Street)
+		require.NotNil(t, h.Home.Zip)
+		assert.Equal(t, 12, *h.Home.Zip)
+		assert.Equal(t, []decodeAddress{{Street: "brandy hall"}}, h.Past)
+		assert.Equal(t, [2]string{"sam", "3"}, h.Pair)
+		assert.Equal(t, map[string]string{"ring": "one"}, h.Tags)
+		assert.Equal(t, map[int]float64{3: 1.5}, h.Scores)
+		assert.Equal(t, map[netip.Addr]bool{netip.MustParseAddr("10.0.0.1"): true}, h.Hosts)
+		assert.True(t, h.Born.Equal(time.Date(2968, 9, 22, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, 90*time.Minute, h.Nap)
+		require.NotNil(t, h.Link)
+		assert.Equal(t, "shire.example", h.Link.Host)
+		assert.Equal(t, []byte("hello"), h.Data)
+		assert.Equal(t, netip.MustParseAddr("192.0.2.1"), h.Addr)
+		assert.Equal(t, []any{1.0, "x"}, h.Any)
+		assert.Equal(t, map[string][]*decodeAddress{"a": {{Street: "hill"}, nil}}, h.Nested)
+		assert.Equal(t, "matched case-insensitively", h.Untagged)
+		assert.Empty(t, h.Secret)
+	})
+
+	t.Run("round trips EncodeStruct", func(t *testing.T) {
+		t.Parallel()
+		zip := 7
+		in := decodeAddress{Street: "bagshot row", Zip: &zip}
+		s, err := EncodeStruct(in)
+		require.NoError(t, err)
+
+		var out decodeAddress
+		require.NoError(t, DecodeStruct(s, &out))
+		assert.Equal(t, in, out)
+	})
+
+	t.Run("null clears references and keeps scalars", func(t *testing.T) {
+		t.Parallel()
+		zip := 1
+		out := decodeAddress{Street: "kept", Zip: &zip}
+		s := mustStruct(t, map[string]any{"street": nil, "zip": nil})
+		require.NoError(t, DecodeStruct(s, &out))
+		assert.Equal(t, decodeAddress{Street: "kept"}, out)
+	})
+
+	t.Run("numeric times and durations", func(t *testing.T) {
+		t.Parallel()
+		var out struct {
+			At  time.Time     `json:"at"`
+			Nap time.Duration `json:"nap"`
+		}
+		s := mustStruct(t, map[string]any{"at": 1.5, "nap": 1e9})
+		require.NoError(t, DecodeStruct(s, &out))
+		assert.True(t, out.At.Equal(time.Unix(1, 5e8)))
+		assert.Equal(t, time.Second, out.Nap)
+	})
+
+	t.Run("decodes into a map", func(t *testing.T) {
+		t.Parallel()
+		out := map[string]int{"kept": 1}
+		s := mustStruct(t, map[string]any{"a": 2.0})
+		require.NoError(t, DecodeStruct(s, &out))
+		assert.Equal(t, map[string]int{"kept": 1, "a": 2}, out)
+	})
+
+	t.Run("reports every failure with its path", func(t *testing.T) {
+		t.Parallel()
+		s := mustStruct(t, map[string]any{
+			"age":    300.0,
+			"rings":  -1.0,
+			"height": 1.5,
+			"home":   "not a struct",
+			"past":   []any{map[string]any{"zip": "x"}},
+			"born":   "yesterday",
+			"scores": map[string]any{"three": 1.0},
+		})
+
+		var h decodeHobbit
+		err := DecodeStruct(s, &h)
+		require.Error(t, err)
+		require.ErrorIs(t, err, ErrNotCoercible)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		assert.Equal(t, `age: value not coercible: 300 overflows int8
+born: value not coercible: "yesterday" is not an RFC 3339 time
+home: unexpected value kind: cannot decode string into protobaggins.decodeAddress
+past[0].zip: value not coercible: "x" is not a number
+rings: value not coercible: -1 overflows uint
+scores.three: value not coercible: map key "three" cannot be parsed as int`, err.Error())
+		assert.InDelta(t, 1.5, h.Height, 0, "valid fields are still decoded")
+	})
+
+	t.Run("honors key filters", func(t *testing.T) {
+		t.Parallel()
+		s := mustStruct(t, map[string]any{"street": "x", "zip": 1.0})
+		var out decodeAddress
+		require.NoError(t, DecodeStruct(s, &out, WithKeyFilter(func(k string) bool { return k != "zip" })))
+		assert.Equal(t, decodeAddress{Street: "x"}, out)
+	})
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		out := decodeAddress{Street: "kept"}
+		require.NoError(t, DecodeStruct(nil, &out))
+		assert.Equal(t, "kept", out.Street)
+	})
+
+	t.Run("rejects bad targets", func(t *testing.T) {
+		t.Parallel()
+		s := &structpb.Struct{}
+		var out decodeAddress
+		require.Error(t, DecodeStruct(s, out))
+		require.Error(t, DecodeStruct(s, (*decodeAddress)(nil)))
+		n := 1
+		require.Error(t, DecodeStruct(s, &n))
+	})
+}
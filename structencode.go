@@ -0,0 +1,298 @@
+package protobaggins
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// EncodeStruct converts a Go struct, or a pointer to one, into a *structpb.Struct using
+// reflection. Field names and options come from the `baggins` tag, falling back to the
+// `json` tag and then the field name, with the encoding/json syntax: "-" skips a field,
+// omitempty drops false, 0, "", nil and empty collections, and omitzero drops zero
+// values. Embedded structs are inlined. Nested structs, pointers, slices, arrays and maps
+// with string or integer keys are converted recursively, while []byte, URLs and times are
+// converted as NewValue does
+func EncodeStruct(v any, opts ...Option) (*structpb.Struct, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("EncodeStruct: expected a struct, got %T", v)
+	}
+
+	e := newEncoder(newOptions(opts))
+	pbValue, err := e.encodeReflect(rv)
+	if err != nil {
+		return nil, err
+	}
+	return pbValue.GetStructValue(), nil
+}
+
+// WithReflection converts values that are not natively supported, such as structs,
+// []string or map[string]int, with reflection as EncodeStruct does, instead of failing
+// or skipping them. Structs become Structs of their exported fields
+func WithReflection() Option {
+	return func(o *options) {
+		o.reflection = true
+	}
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// encodeReflect converts typed Go values that structpb.NewValue does not accept
+func (e *encoder) encodeReflect(rv reflect.Value) (*structpb.Value, error) {
+	pbValue, err := e.encodeReflectValue(rv)
+	if err != nil {
+		return nil, e.conversionError(rv.Type(), err)
+	}
+	return pbValue, nil
+}
+
+func (e *encoder) encodeReflectValue(rv reflect.Value) (*structpb.Value, error) {
+	if !rv.IsValid() {
+		return structpb.NewNullValue(), nil
+	}
+	if rv.CanInterface() {
+		if fn, ok := e.opts.lookupValueConverter(rv.Interface()); ok {
+			return convertCustom(fn, rv.Interface())
+		}
+		if m, ok := reflectValueMarshaler(rv); ok {
+			return marshalValue(m)
+		}
+		switch v := rv.Interface().(type) {
+		case []byte, url.URL, *url.URL, time.Location, *time.Location, time.Time, *timestamppb.Timestamp, *big.Int, *big.Float, json.Number:
+			return e.encode(v)
+		case time.Duration, *durationpb.Duration:
+			// without WithDurations, durations keep their integer nanoseconds
+			if e.opts.durations != durationUnsupported {
+				return e.encode(v)
+			}
+		}
+		if pbValue, ok := e.encodeEnum(rv.Interface()); ok {
+			return pbValue, nil
+		}
+		if pbValue, ok, err := encodeMarshaler(rv.Interface()); ok {
+			return pbValue, err
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+		return e.encodeReflect(rv.Elem())
+	case reflect.Bool:
+		return e.newBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.newNumber(float64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.newNumber(float64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return e.newNumber(rv.Float()), nil
+	case reflect.String:
+		if !utf8.ValidString(rv.String()) {
+			return nil, fmt.Errorf("invalid UTF-8 in string: %q", rv.String())
+		}
+		return e.newString(rv.String()), nil
+	case reflect.Slice:
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+		return e.encodeReflectList(rv)
+	case reflect.Array:
+		return e.encodeReflectList(rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			return structpb.NewNullValue(), nil
+		}
+		return e.encodeReflectMap(rv)
+	case reflect.Struct:
+		return e.encodeReflectStruct(rv)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", rv.Type())
+	}
+}
+
+func (e *encoder) encodeReflectList(rv reflect.Value) (*structpb.Value, error) {
+	if err := e.enter(rv); err != nil {
+		return nil, err
+	}
+	defer e.leave(rv)
+
+	values := make([]*structpb.Value, 0, rv.Len())
+	var errs entryErrors
+	for i := range rv.Len() {
+		e.pushIndex(i)
+		pbValue, err := e.encodeReflect(rv.Index(i))
+		if err == nil {
+			err = e.count("", pbValue)
+		}
+		e.pop()
+		if err != nil {
+			if err := errs.add(e, "", interfaceOf(rv.Index(i)), err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		values = append(values, pbValue)
+	}
+	if len(errs) > 0 {
+		return nil, errs.join(false)
+	}
+	return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+}
+
+func (e *encoder) encodeReflectMap(rv reflect.Value) (*structpb.Value, error) {
+	if err := e.enter(rv); err != nil {
+		return nil, err
+	}
+	defer e.leave(rv)
+
+	fields := make(map[string]*structpb.Value, rv.Len())
+	var errs entryErrors
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			if err := errs.add(e, key, interfaceOf(iter.Value()), e.conversionError(iter.Key().Type(), err)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		value := iter.Value()
+		if !e.opts.keepKey(key) || e.opts.omitReflectEntry(value) {
+			continue
+		}
+
+		pbValue, name, err := e.encodeField(fields, key, func() (*structpb.Value, error) {
+			return e.encodeReflect(value)
+		})
+		if err != nil {
+			if err := errs.add(e, key, interfaceOf(value), err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		fields[name] = pbValue
+	}
+	if len(errs) > 0 {
+		return nil, errs.join(true)
+	}
+	return structpb.NewStructValue(&structpb.Struct{Fields: fields}), nil
+}
+
+func (e *encoder) encodeReflectStruct(rv reflect.Value) (*structpb.Value, error) {
+	if err := e.enter(rv); err != nil {
+		return nil, err
+	}
+	defer e.leave(rv)
+
+	fields := make(map[string]*structpb.Value)
+	var errs entryErrors
+	for _, f := range structFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) || (f.omitZero && fv.IsZero()) {
+			continue
+		}
+		if !e.opts.keepKey(f.name) || e.opts.omitReflectEntry(fv) {
+			continue
+		}
+
+		pbValue, name, err := e.encodeField(fields, f.name, func() (*structpb.Value, error) {
+			return e.encodeReflect(fv)
+		})
+		if err != nil {
+			if err := errs.add(e, f.name, interfaceOf(fv), err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		fields[name] = pbValue
+	}
+	if len(errs) > 0 {
+		return nil, errs.join(false)
+	}
+	return structpb.NewStructValue(&structpb.Struct{Fields: fields}), nil
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, reporting false instead of panicking when
+// the path goes through a nil embedded pointer
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+func mapKeyString(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if key.Type().Implements(textMarshalerType) && key.CanInterface() {
+		if key.Kind() == reflect.Pointer && key.IsNil() {
+			return "", nil
+		}
+		text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("unsupported map key type %s", key.Type())
+	}
+}
+
+// interfaceOf returns the value held by rv, or nil if it is not accessible
+func interfaceOf(rv reflect.Value) any {
+	if !rv.IsValid() || !rv.CanInterface() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+func isNil(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	default:
+		return !rv.IsValid()
+	}
+}
+
+// isEmptyValue reports whether omitempty drops rv, as in encoding/json
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return rv.IsZero()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,203 @@
+package protobaggins
+
+import (
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type encodeAddress struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type encodeBase struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+}
+
+type encodeHobbit struct {
+	encodeBase
+	Name     string            `json:"name"`
+	Nickname string            `baggins:"nick" json:"nickname"`
+	Age      int               `json:"age,omitempty"`
+	Height   float32           `json:"height,omitzero"`
+	Home     *encodeAddress    `json:"home"`
+	Past     []encodeAddress   `json:"past,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Scores   map[int]float64   `json:"scores,omitempty"`
+	Secret   string            `json:"-"`
+	Dash     string            `json:"-,"`
+	Any      any               `json:"any"`
+	Link     *url.URL          `json:"link,omitempty"`
+	Untagged bool
+	private  string
+}
+
+func TestEncodeStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tags and nesting", func(t *testing.T) {
+		t.Parallel()
+		link, err := url.Parse("https://shire.example/bag-end")
+		require.NoError(t, err)
+
+		h := encodeHobbit{
+			encodeBase: encodeBase{ID: "h1", Version: 2},
+			Name:       "frodo",
+			Nickname:   "mr. underhill",
+			Home:       &encodeAddress{Street: "bagshot row"},
+			Past:       []encodeAddress{{Street: "brandy hall", Zip: "B1"}},
+			Tags:       map[string]string{"ring": "one"},
+			Scores:     map[int]float64{3: 1.5},
+			Secret:     "ring",
+			Dash:       "dash",
+			Any:        []any{1, "x"},
+			Link:       link,
+			Untagged:   true,
+			private:    "hidden",
+		}
+
+		s, err := EncodeStruct(&h)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"id":       "h1",
+			"version":  float64(2),
+			"name":     "frodo",
+			"nick":     "mr. underhill",
+			"home":     map[string]any{"street": "bagshot row"},
+			"past":     []any{map[string]any{"street": "brandy hall", "zip": "B1"}},
+			"tags":     map[string]any{"ring": "one"},
+			"scores":   map[string]any{"3": 1.5},
+			"-":        "dash",
+			"any":      []any{float64(1), "x"},
+			"link":     "https://shire.example/bag-end",
+			"Untagged": true,
+		}, s.AsMap())
+	})
+
+	t.Run("omitted and nil fields", func(t *testing.T) {
+		t.Parallel()
+		s, err := EncodeStruct(encodeHobbit{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"id": "", "version": float64(0), "name": "", "nick": "", "-": "",
+			"home": nil, "any": nil, "Untagged": false,
+		}, s.AsMap())
+	})
+
+	t.Run("options apply", func(t *testing.T) {
+		t.Parallel()
+		s, err := EncodeStruct(encodeHobbit{Name: "sam"}, WithOmitNulls(), WithKeyFilter(func(key string) bool {
+			return key != "id" && key != "version"
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"name": "sam", "nick": "", "-": "", "Untagged": false}, s.AsMap())
+	})
+
+	t.Run("unsupported field", func(t *testing.T) {
+		t.Parallel()
+		_, err := EncodeStruct(struct {
+			C chan int `json:"c"`
+		}{C: make(chan int)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "c: unsupported type chan int")
+
+		s, err := EncodeStruct(struct {
+			C chan int `json:"c"`
+			N int      `json:"n"`
+		}{}, WithSkipErrors())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"n": float64(0)}, s.AsMap())
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		t.Parallel()
+		_, err := EncodeStruct(map[string]any{})
+		require.Error(t, err)
+		_, err = EncodeStruct((*encodeHobbit)(nil))
+		require.Error(t, err)
+	})
+
+	t.Run("conflicting embedded names", func(t *testing.T) {
+		t.Parallel()
+		type A struct{ Name, Only string }
+		type B struct{ Name string }
+		type C struct {
+			Name string `json:"Name"`
+		}
+		s, err := EncodeStruct(struct {
+			A
+			B
+		}{A{Name: "a", Only: "o"}, B{Name: "b"}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"Only": "o"}, s.AsMap(), "ambiguous fields are dropped")
+
+		s, err = EncodeStruct(struct {
+			A
+			C
+		}{A{Name: "a"}, C{Name: "c"}})
+		require.NoError(t, err)
+		assert.Equal(t, "c", s.GetFields()["Name"].GetStringValue(), "tagged field wins")
+	})
+
+	t.Run("nil embedded pointer", func(t *testing.T) {
+		t.Parallel()
+		type Outer struct {
+			*encodeBase
+			Name string `json:"name"`
+		}
+		s, err := EncodeStruct(Outer{Name: "x"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"name": "x"}, s.AsMap())
+	})
+}
+
+func TestWithReflection(t *testing.T) {
+	t.Parallel()
+
+	in := map[string]any{
+		"home":   encodeAddress{Street: "bagshot row"},
+		"names":  []string{"frodo", "sam"},
+		"counts": map[string]int{"rings": 1},
+		"ids":    map[int]bool{7: true},
+		"ip":     net.IPv4(192, 0, 2, 1),
+		"hosts":  []net.IP{net.IPv4(192, 0, 2, 2)},
+		"bad":    make(chan int),
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		t.Parallel()
+		values := MapToStructValues(in)
+		assert.NotContains(t, values, "home")
+		assert.NotContains(t, values, "names")
+		assert.Equal(t, "192.0.2.1", values["ip"].GetStringValue())
+	})
+
+	t.Run("converts typed values", func(t *testing.T) {
+		t.Parallel()
+		values, err := NewConverter(WithReflection(), WithSkipErrors()).MapToStructValues(in)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"home":   map[string]any{"street": "bagshot row"},
+			"names":  []any{"frodo", "sam"},
+			"counts": map[string]any{"rings": 1.0},
+			"ids":    map[string]any{"7": true},
+			"ip":     "192.0.2.1",
+			"hosts":  []any{"192.0.2.2"},
+		}, StructValuesToMap(values))
+
+		_, err = NewValue(in, WithReflection())
+		require.ErrorContains(t, err, "unsupported type chan int")
+	})
+
+	t.Run("pointers and nil", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue([]any{&encodeAddress{Street: "x"}, (*encodeAddress)(nil)}, WithReflection())
+		require.NoError(t, err)
+		assert.Equal(t, []any{map[string]any{"street": "x"}, nil}, v.AsInterface())
+	})
+}
@@ -0,0 +1,154 @@
+package protobaggins
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// StructTag is the struct tag consulted before `json` when encoding and decoding Go
+// structs. Both use the encoding/json syntax, e.g. `baggins:"name,omitempty"`
+const StructTag = "baggins"
+
+// structField describes an exported field of a Go struct as seen by EncodeStruct and
+// DecodeStruct
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	omitZero  bool
+	tagged    bool
+}
+
+var structFieldCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the encodable fields of t, including those promoted from
+// embedded structs, following the visibility rules of encoding/json
+func structFields(t reflect.Type) []structField {
+	if cached, ok := structFieldCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	var all []structField
+	collectFields(t, nil, map[reflect.Type]bool{}, &all)
+
+	// a name used by several fields goes to the shallowest one, or to the only tagged
+	// one among equally shallow fields, otherwise all of them are dropped
+	byName := make(map[string][]structField)
+	var order []string
+	for _, f := range all {
+		if _, seen := byName[f.name]; !seen {
+			order = append(order, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	fields := make([]structField, 0, len(order))
+	for _, name := range order {
+		if f, ok := dominantField(byName[name]); ok {
+			fields = append(fields, f)
+		}
+	}
+
+	structFieldCache.Store(t, fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, index []int, visited map[reflect.Type]bool, out *[]structField) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, opts, skip := lookupTag(sf)
+		if skip {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectFields(ft, fieldIndex, visited, out)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		tagged := name != ""
+		if !tagged {
+			name = sf.Name
+		}
+		*out = append(*out, structField{
+			name:      name,
+			index:     fieldIndex,
+			omitEmpty: hasOption(opts, "omitempty"),
+			omitZero:  hasOption(opts, "omitzero"),
+			tagged:    tagged,
+		})
+	}
+}
+
+// lookupTag returns the name and options of the baggins tag, or else the json tag
+// A tag of exactly "-" skips the field, while "-," names it "-"
+func lookupTag(sf reflect.StructField) (name, opts string, skip bool) {
+	tag, ok := sf.Tag.Lookup(StructTag)
+	if !ok {
+		tag = sf.Tag.Get("json")
+	}
+	if tag == "-" {
+		return "", "", true
+	}
+	name, opts, _ = strings.Cut(tag, ",")
+	return name, opts, false
+}
+
+func hasOption(opts, option string) bool {
+	for opt := range strings.SplitSeq(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func dominantField(candidates []structField) (structField, bool) {
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+
+	depth := len(candidates[0].index)
+	for _, f := range candidates[1:] {
+		depth = min(depth, len(f.index))
+	}
+
+	var shallowest []structField
+	for _, f := range candidates {
+		if len(f.index) == depth {
+			shallowest = append(shallowest, f)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+
+	var tagged []structField
+	for _, f := range shallowest {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return structField{}, false
+}
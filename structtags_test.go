@@ -0,0 +1,46 @@
+package protobaggins
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructFields(t *testing.T) {
+	t.Parallel()
+
+	type Embedded struct {
+		Inner string `json:"inner"`
+	}
+	type sample struct {
+		Embedded
+		Plain    string
+		Renamed  string `json:"renamed,omitempty"`
+		Override string `baggins:"mine,omitzero" json:"theirs"`
+		Skipped  string `json:"-"`
+		Dash     string `json:"-,"`
+		Options  string `json:",omitempty"`
+		hidden   string
+	}
+
+	fields := structFields(reflect.TypeFor[sample]())
+	byName := make(map[string]structField)
+	var names []string
+	for _, f := range fields {
+		byName[f.name] = f
+		names = append(names, f.name)
+	}
+
+	assert.Equal(t, []string{"inner", "Plain", "renamed", "mine", "-", "Options"}, names)
+	assert.Equal(t, []int{0, 0}, byName["inner"].index)
+	assert.True(t, byName["renamed"].omitEmpty)
+	assert.True(t, byName["mine"].omitZero)
+	assert.False(t, byName["mine"].omitEmpty)
+	assert.True(t, byName["Options"].omitEmpty)
+	assert.False(t, byName["Options"].tagged)
+	assert.True(t, byName["renamed"].tagged)
+
+	again := structFields(reflect.TypeFor[sample]())
+	assert.Equal(t, &fields[0], &again[0], "fields are cached per type")
+}
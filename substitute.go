@@ -0,0 +1,125 @@
+package protobaggins
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// placeholderPattern matches ${name} and {{name}}, allowing spaces inside the braces
+var placeholderPattern = regexp.MustCompile(`\$\{\s*([^{}\s]+)\s*\}|\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// Substitute renders a template Struct by replacing ${name} and {{name}} placeholders in
+// its string leaves with values from vars. Names may reach into nested structs with dots,
+// e.g. ${db.port}. See SubstituteFunc for the replacement rules
+func Substitute(s, vars *structpb.Struct) (*structpb.Struct, error) {
+	return SubstituteFunc(s, func(name string) (*structpb.Value, error) {
+		if v, ok := vars.GetFields()[name]; ok {
+			return v, nil
+		}
+		v := structpb.NewStructValue(vars)
+		for part := range strings.SplitSeq(name, ".") {
+			next, ok := v.GetStructValue().GetFields()[part]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUndefinedVariable, name)
+			}
+			v = next
+		}
+		return v, nil
+	})
+}
+
+// SubstituteFunc renders a template Struct, resolving placeholder names with lookup.
+// A string that consists of a single placeholder is replaced by the looked up value
+// itself, so "${port}" can become the number 8080. Placeholders inside longer strings
+// are interpolated: strings as-is, numbers and bools in their shortest form, and other
+// values as JSON. The template is not modified. Lookup errors are returned together,
+// each annotated with the path of the leaf that needed it
+func SubstituteFunc(s *structpb.Struct, lookup func(name string) (*structpb.Value, error)) (*structpb.Struct, error) {
+	if s == nil {
+		return nil, nil
+	}
+	out, _ := proto.Clone(s).(*structpb.Struct)
+
+	type pathError struct {
+		path string
+		err  error
+	}
+	var failures []pathError
+	// whole-value replacements are applied after the walk so that substituted values
+	// are not themselves searched for placeholders
+	replacements := make(map[*structpb.Value]*structpb.Value)
+
+	walkStruct("", out, func(path string, v *structpb.Value) {
+		str, ok := v.GetKind().(*structpb.Value_StringValue)
+		if !ok {
+			return
+		}
+
+		if m := placeholderPattern.FindStringSubmatch(str.StringValue); m != nil && m[0] == str.StringValue {
+			replacement, err := lookup(placeholderName(m))
+			if err != nil {
+				failures = append(failures, pathError{path, err})
+				return
+			}
+			replacements[v] = replacement
+			return
+		}
+
+		str.StringValue = placeholderPattern.ReplaceAllStringFunc(str.StringValue, func(match string) string {
+			replacement, err := lookup(placeholderName(placeholderPattern.FindStringSubmatch(match)))
+			if err != nil {
+				failures = append(failures, pathError{path, err})
+				return match
+			}
+			return interpolate(replacement)
+		})
+	})
+
+	if len(failures) == 0 {
+		for v, replacement := range replacements {
+			if replacement == nil {
+				replacement = structpb.NewNullValue()
+			}
+			v.Kind = proto.Clone(replacement).(*structpb.Value).GetKind()
+		}
+		return out, nil
+	}
+	slices.SortFunc(failures, func(a, b pathError) int { return cmp.Compare(a.path, b.path) })
+	errs := make([]error, len(failures))
+	for i, f := range failures {
+		errs[i] = fmt.Errorf("%s: %w", f.path, f.err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// placeholderName returns the name captured by whichever placeholder form matched
+func placeholderName(submatch []string) string {
+	if submatch[1] != "" {
+		return submatch[1]
+	}
+	return submatch[2]
+}
+
+// interpolate renders a value for embedding in a longer string
+func interpolate(v *structpb.Value) string {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return kind.StringValue
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(kind.NumberValue, 'f', -1, 64)
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(kind.BoolValue)
+	case nil, *structpb.Value_NullValue:
+		return "null"
+	default:
+		return formatValue(v)
+	}
+}
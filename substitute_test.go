@@ -0,0 +1,118 @@
+package protobaggins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSubstitute(t *testing.T) {
+	t.Parallel()
+
+	vars, err := structpb.NewStruct(map[string]any{
+		"name":    "frodo",
+		"port":    8080,
+		"debug":   true,
+		"tags":    []any{"ring", "bearer"},
+		"db":      map[string]any{"host": "bag-end", "port": 5432},
+		"dot.key": "literal",
+		"loop":    "${loop}",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		template map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "whole value keeps type",
+			template: map[string]any{"port": "${port}", "debug": "{{ debug }}", "tags": "${tags}"},
+			expected: map[string]any{"port": float64(8080), "debug": true, "tags": []any{"ring", "bearer"}},
+		},
+		{
+			name:     "interpolation",
+			template: map[string]any{"url": "http://${db.host}:{{db.port}}/${name}?debug=${debug}", "list": "tags=${tags}"},
+			expected: map[string]any{"url": "http://bag-end:5432/frodo?debug=true", "list": `tags=["ring","bearer"]`},
+		},
+		{
+			name:     "nested template",
+			template: map[string]any{"items": []any{"${name}", map[string]any{"owner": "${db}"}}},
+			expected: map[string]any{"items": []any{"frodo", map[string]any{"owner": map[string]any{"host": "bag-end", "port": float64(5432)}}}},
+		},
+		{
+			name:     "literal dotted key",
+			template: map[string]any{"v": "${dot.key}"},
+			expected: map[string]any{"v": "literal"},
+		},
+		{
+			name:     "substituted values are not expanded again",
+			template: map[string]any{"v": "${loop}", "w": "x ${loop}"},
+			expected: map[string]any{"v": "${loop}", "w": "x ${loop}"},
+		},
+		{
+			name:     "non-placeholders untouched",
+			template: map[string]any{"a": "$name", "b": "{name}", "c": 1, "d": nil},
+			expected: map[string]any{"a": "$name", "b": "{name}", "c": float64(1), "d": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			template, err := structpb.NewStruct(tt.template)
+			require.NoError(t, err)
+			before := template.AsMap()
+
+			got, err := Substitute(template, vars)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got.AsMap())
+			assert.Equal(t, before, template.AsMap(), "template is not modified")
+		})
+	}
+
+	t.Run("undefined variables", func(t *testing.T) {
+		t.Parallel()
+		template, err := structpb.NewStruct(map[string]any{
+			"b": "${missing}",
+			"a": []any{"x {{db.user}}"},
+		})
+		require.NoError(t, err)
+
+		_, err = Substitute(template, vars)
+		require.ErrorIs(t, err, ErrUndefinedVariable)
+		assert.Equal(t, "a[0]: undefined variable: db.user\nb: undefined variable: missing", err.Error())
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		got, err := Substitute(nil, vars)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestSubstituteFunc(t *testing.T) {
+	t.Parallel()
+
+	errSecret := errors.New("secret store unavailable")
+	template, err := structpb.NewStruct(map[string]any{"token": "${secret}", "user": "${user}"})
+	require.NoError(t, err)
+
+	got, err := SubstituteFunc(template, func(name string) (*structpb.Value, error) {
+		if name == "user" {
+			return structpb.NewStringValue("sam"), nil
+		}
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"token": nil, "user": "sam"}, got.AsMap())
+
+	_, err = SubstituteFunc(template, func(name string) (*structpb.Value, error) {
+		return nil, errSecret
+	})
+	assert.ErrorIs(t, err, errSecret)
+}
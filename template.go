@@ -0,0 +1,194 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// TemplateFuncs returns functions rendering structpb values in templates, to pass to
+// the Funcs method of a text/template or html/template Template. Each one accepts a
+// *structpb.Struct, *structpb.Value or *structpb.ListValue, or what get returns:
+//   - get PATH V returns the value at PATH within V, see LookupPath, or nil if there is
+//     none. Structs are returned as they are, lists as a []any to range over and other
+//     values as a string, float64, bool or nil
+//   - has PATH V reports whether there is a value at PATH within V
+//   - default DEF V returns DEF if V is nil, null or the empty string, and V otherwise,
+//     e.g. {{ get "user.name" . | default "anonymous" }}
+//   - toJSON V serializes V as compact JSON
+//   - keys V returns the sorted keys of the Struct V
+//   - values V returns the values of the Struct V, as get does, in the order of keys
+//
+// Malformed paths fail the template
+func TemplateFuncs() map[string]any {
+	return map[string]any{
+		"get":     templateGet,
+		"has":     templateHas,
+		"default": templateDefault,
+		"toJSON":  templateToJSON,
+		"keys":    templateKeys,
+		"values":  templateValues,
+	}
+}
+
+func templateGet(path string, v any) (any, error) {
+	found, err := templateLookup(path, v)
+	if err != nil || found == nil {
+		return nil, err
+	}
+	return templateValue(found), nil
+}
+
+func templateHas(path string, v any) (bool, error) {
+	found, err := templateLookup(path, v)
+	return found != nil, err
+}
+
+func templateDefault(def, v any) any {
+	switch v := v.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	case *structpb.Value:
+		switch KindOf(v) {
+		case KindNull, KindUnset:
+			return def
+		case KindString:
+			if v.GetStringValue() == "" {
+				return def
+			}
+		}
+	case *structpb.Struct:
+		if v == nil {
+			return def
+		}
+	case *structpb.ListValue:
+		if v == nil {
+			return def
+		}
+	}
+	return v
+}
+
+func templateToJSON(v any) (string, error) {
+	value, err := templateInput(v)
+	if err != nil {
+		return "", err
+	}
+	data, err := MarshalJSON(value)
+	return string(data), err
+}
+
+func templateKeys(v any) ([]string, error) {
+	s, err := templateStruct(v)
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(s), nil
+}
+
+func templateValues(v any) ([]any, error) {
+	s, err := templateStruct(v)
+	if err != nil {
+		return nil, err
+	}
+	keys := sortedKeys(s)
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		values[i] = templateValue(s.GetFields()[key])
+	}
+	return values, nil
+}
+
+// templateLookup is LookupPath returning nil, rather than an error, if there is no
+// value at path
+func templateLookup(path string, v any) (*structpb.Value, error) {
+	value, err := templateInput(v)
+	if err != nil {
+		return nil, err
+	}
+	found, err := LookupPath(value, path)
+	if errors.Is(err, ErrPathNotFound) || errors.Is(err, ErrUnexpectedKind) {
+		return nil, nil
+	}
+	return found, err
+}
+
+func templateStruct(v any) (*structpb.Struct, error) {
+	value, err := templateInput(v)
+	if err != nil {
+		return nil, err
+	}
+	if KindOf(value) != KindStruct {
+		return nil, fmt.Errorf("%w: expected a struct, got %s", ErrUnexpectedKind, KindOf(value))
+	}
+	return value.GetStructValue(), nil
+}
+
+// templateValue converts v to what get returns
+func templateValue(v *structpb.Value) any {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return kind.StructValue
+	case *structpb.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		list := make([]any, len(items))
+		for i, item := range items {
+			list[i] = templateValue(item)
+		}
+		return list
+	case *structpb.Value_StringValue:
+		return kind.StringValue
+	case *structpb.Value_NumberValue:
+		return kind.NumberValue
+	case *structpb.Value_BoolValue:
+		return kind.BoolValue
+	default:
+		return nil
+	}
+}
+
+// templateInput converts v, an argument of a template function, to a Value
+func templateInput(v any) (*structpb.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return structpb.NewNullValue(), nil
+	case *structpb.Value:
+		if v == nil {
+			return structpb.NewNullValue(), nil
+		}
+		return v, nil
+	case *structpb.Struct:
+		if v == nil {
+			return structpb.NewNullValue(), nil
+		}
+		return structpb.NewStructValue(v), nil
+	case *structpb.ListValue:
+		if v == nil {
+			return structpb.NewNullValue(), nil
+		}
+		return structpb.NewListValue(v), nil
+	case []any:
+		list := &structpb.ListValue{Values: make([]*structpb.Value, len(v))}
+		for i, item := range v {
+			value, err := templateInput(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", joinIndex("", i), err)
+			}
+			list.Values[i] = value
+		}
+		return structpb.NewListValue(list), nil
+	case string:
+		return structpb.NewStringValue(v), nil
+	case float64:
+		return structpb.NewNumberValue(v), nil
+	case bool:
+		return structpb.NewBoolValue(v), nil
+	default:
+		return nil, fmt.Errorf("%w: %T is not a structpb value", ErrUnexpectedKind, v)
+	}
+}
@@ -0,0 +1,78 @@
+package protobaggins
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	t.Parallel()
+
+	payload, err := structpb.NewStruct(map[string]any{
+		"user":   map[string]any{"name": "frodo", "nick": ""},
+		"items":  []any{map[string]any{"name": "ring"}, map[string]any{"name": "sting"}},
+		"counts": map[string]any{"b": 2, "a": 1},
+		"none":   nil,
+	})
+	require.NoError(t, err)
+
+	render := func(t *testing.T, text string) (string, error) {
+		t.Helper()
+		tmpl, err := template.New("test").Funcs(TemplateFuncs()).Parse(text)
+		require.NoError(t, err)
+		var out strings.Builder
+		err = tmpl.Execute(&out, payload)
+		return out.String(), err
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "get", text: `{{ get "user.name" . }}`, want: "frodo"},
+		{name: "get missing", text: `{{ get "user.age" . }}`, want: "<no value>"},
+		{name: "get chained", text: `{{ get "user" . | get "name" }}`, want: "frodo"},
+		{name: "range", text: `{{ range get "items" . }}{{ get "name" . }};{{ end }}`, want: "ring;sting;"},
+		{name: "has", text: `{{ has "user.name" . }} {{ has "user.age" . }} {{ has "user.name.first" . }}`, want: "true false false"},
+		{name: "default", text: `{{ get "user.age" . | default 33 }} {{ get "user.nick" . | default "none" }} {{ get "none" . | default "null" }}`, want: "33 none null"},
+		{name: "default unused", text: `{{ get "user.name" . | default "anonymous" }}`, want: "frodo"},
+		{name: "toJSON", text: `{{ get "counts" . | toJSON }} {{ get "items" . | toJSON }}`, want: `{"a":1,"b":2} [{"name":"ring"},{"name":"sting"}]`},
+		{name: "keys and values", text: `{{ keys (get "counts" .) }} {{ values (get "counts" .) }}`, want: "[a b] [1 2]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := render(t, tt.text)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := render(t, `{{ get "user[" . }}`)
+		require.Error(t, err)
+
+		_, err = render(t, `{{ keys (get "items" .) }}`)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+
+		_, err = render(t, `{{ get "name" 42 }}`)
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("html/template", func(t *testing.T) {
+		t.Parallel()
+		tmpl, err := htmltemplate.New("test").Funcs(TemplateFuncs()).Parse(`<b>{{ get "user.name" . }}</b>`)
+		require.NoError(t, err)
+		var out strings.Builder
+		require.NoError(t, tmpl.Execute(&out, payload))
+		assert.Equal(t, "<b>frodo</b>", out.String())
+	})
+}
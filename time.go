@@ -0,0 +1,70 @@
+package protobaggins
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TimeToProto converts a time.Time to a *timestamppb.Timestamp
+// Returns nil for the zero time, so that unset Go times stay unset in messages
+func TimeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// TimeFromProto converts a *timestamppb.Timestamp to a UTC time.Time
+// Returns the zero time for nil, where ts.AsTime would return the Unix epoch
+func TimeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// WithUnixTimes encodes time.Time and *timestamppb.Timestamp values as numbers of
+// seconds since the Unix epoch, with nanoseconds as the fraction, instead of as
+// RFC 3339 strings
+func WithUnixTimes() Option {
+	return func(o *options) {
+		o.unixTimes = true
+	}
+}
+
+// encodeTime converts t to an RFC 3339 string, or a Unix time with WithUnixTimes
+func (e *encoder) encodeTime(t time.Time) *structpb.Value {
+	if e.opts.unixTimes {
+		return structpb.NewNumberValue(float64(t.Unix()) + float64(t.Nanosecond())/1e9)
+	}
+	return structpb.NewStringValue(t.Format(time.RFC3339Nano))
+}
+
+// LocationToValue converts loc to its name, such as "Europe/Paris" or "UTC"
+// Returns a null value for nil
+func LocationToValue(loc *time.Location) *structpb.Value {
+	if loc == nil {
+		return structpb.NewNullValue()
+	}
+	return structpb.NewStringValue(loc.String())
+}
+
+// LocationFromValue loads the location named by v with time.LoadLocation, the decode
+// hook for LocationToValue. Returns nil without error for nil or null values
+func LocationFromValue(v *structpb.Value) (*time.Location, error) {
+	switch kind := v.GetKind().(type) {
+	case nil, *structpb.Value_NullValue:
+		return nil, nil
+	case *structpb.Value_StringValue:
+		loc, err := time.LoadLocation(kind.StringValue)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNotCoercible, err)
+		}
+		return loc, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot decode %s as location", ErrUnexpectedKind, KindOf(v))
+	}
+}
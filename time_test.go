@@ -0,0 +1,167 @@
+package protobaggins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestTimeToProto(t *testing.T) {
+	t.Parallel()
+
+	t.Run("zero time is nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, TimeToProto(time.Time{}))
+	})
+
+	t.Run("epoch is not zero", func(t *testing.T) {
+		t.Parallel()
+		ts := TimeToProto(time.Unix(0, 0))
+		require.NotNil(t, ts)
+		assert.Zero(t, ts.GetSeconds())
+	})
+
+	t.Run("keeps nanoseconds", func(t *testing.T) {
+		t.Parallel()
+		ts := TimeToProto(time.Unix(10, 5))
+		assert.Equal(t, int64(10), ts.GetSeconds())
+		assert.Equal(t, int32(5), ts.GetNanos())
+	})
+}
+
+func TestTimeFromProto(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil is the zero time", func(t *testing.T) {
+		t.Parallel()
+		assert.True(t, TimeFromProto(nil).IsZero())
+	})
+
+	t.Run("round trips", func(t *testing.T) {
+		t.Parallel()
+		want := time.Date(2024, 5, 1, 9, 30, 0, 7, time.UTC)
+		assert.Equal(t, want, TimeFromProto(TimeToProto(want)))
+	})
+
+	t.Run("zero time round trips", func(t *testing.T) {
+		t.Parallel()
+		assert.True(t, TimeFromProto(TimeToProto(time.Time{})).IsZero())
+	})
+}
+
+func TestTimeValues(t *testing.T) {
+	t.Parallel()
+
+	when := time.Date(2024, 5, 1, 9, 30, 0, 500000000, time.FixedZone("shire", 3600))
+
+	t.Run("RFC 3339 strings by default", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(when)
+		require.NoError(t, err)
+		assert.Equal(t, "2024-05-01T09:30:00.5+01:00", v.GetStringValue())
+
+		v, err = NewValue(timestamppb.New(when))
+		require.NoError(t, err)
+		assert.Equal(t, "2024-05-01T08:30:00.5Z", v.GetStringValue())
+	})
+
+	t.Run("Unix times", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(&when, WithUnixTimes())
+		require.NoError(t, err)
+		assert.InDelta(t, float64(when.Unix())+0.5, v.GetNumberValue(), 0)
+	})
+
+	t.Run("nil pointers are null", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue((*time.Time)(nil))
+		require.NoError(t, err)
+		assert.Equal(t, KindNull, KindOf(v))
+
+		v, err = NewValue((*timestamppb.Timestamp)(nil))
+		require.NoError(t, err)
+		assert.Equal(t, KindNull, KindOf(v))
+	})
+
+	t.Run("inside maps", func(t *testing.T) {
+		t.Parallel()
+		values := MapToStructValues(map[string]any{
+			"nested": map[string]any{"at": when},
+			"list":   []any{timestamppb.New(when)},
+		})
+		require.Len(t, values, 2)
+		assert.Equal(t, "2024-05-01T09:30:00.5+01:00",
+			values["nested"].GetStructValue().GetFields()["at"].GetStringValue())
+		assert.Equal(t, "2024-05-01T08:30:00.5Z",
+			values["list"].GetListValue().GetValues()[0].GetStringValue())
+	})
+
+	t.Run("round trip through structs", func(t *testing.T) {
+		t.Parallel()
+		type event struct {
+			At    time.Time              `json:"at"`
+			Stamp *timestamppb.Timestamp `json:"stamp"`
+		}
+		for _, opts := range [][]Option{nil, {WithUnixTimes()}} {
+			s, err := EncodeStruct(event{At: when, Stamp: timestamppb.New(when)}, opts...)
+			require.NoError(t, err)
+
+			var out event
+			require.NoError(t, DecodeStruct(s, &out))
+			assert.True(t, out.At.Equal(when))
+			assert.True(t, out.Stamp.AsTime().Equal(when))
+		}
+	})
+}
+
+func TestLocationValues(t *testing.T) {
+	t.Parallel()
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		v := LocationToValue(paris)
+		assert.Equal(t, "Europe/Paris", v.GetStringValue())
+		loc, err := LocationFromValue(v)
+		require.NoError(t, err)
+		assert.Equal(t, "Europe/Paris", loc.String())
+
+		assert.Equal(t, KindNull, KindOf(LocationToValue(nil)))
+		loc, err = LocationFromValue(structpb.NewNullValue())
+		require.NoError(t, err)
+		assert.Nil(t, loc)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := LocationFromValue(structpb.NewStringValue("Middle/Earth"))
+		require.ErrorIs(t, err, ErrNotCoercible)
+		_, err = LocationFromValue(structpb.NewNumberValue(1))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+	})
+
+	t.Run("converters", func(t *testing.T) {
+		t.Parallel()
+		type event struct {
+			Zone  *time.Location
+			Local time.Location
+		}
+		v, err := NewValue(map[string]any{"zone": paris, "utc": *time.UTC})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"zone": "Europe/Paris", "utc": "UTC"}, v.AsInterface())
+
+		v, err = NewValue(event{Zone: paris, Local: *time.UTC}, WithReflection())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"Zone": "Europe/Paris", "Local": "UTC"}, v.AsInterface())
+
+		var out struct{ Zone *time.Location }
+		require.NoError(t, DecodeStruct(v.GetStructValue(), &out))
+		assert.Equal(t, paris.String(), out.Zone.String())
+	})
+}
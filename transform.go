@@ -0,0 +1,36 @@
+package protobaggins
+
+import (
+	"regexp"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// TransformMatching rewrites every string leaf in s that matches pattern, replacing each
+// match with the result of fn. When filters are given, only leaves whose path satisfies
+// all of them are considered. The Struct is modified in place.
+// Returns the number of string leaves that were changed
+func TransformMatching(
+	s *structpb.Struct,
+	pattern *regexp.Regexp,
+	fn func(match string) string,
+	filters ...PathFilter,
+) int {
+	if s == nil || pattern == nil || fn == nil {
+		return 0
+	}
+
+	changed := 0
+	walkStruct("", s, func(path string, v *structpb.Value) {
+		str, ok := v.GetKind().(*structpb.Value_StringValue)
+		if !ok || !matchesAll(path, filters) || !pattern.MatchString(str.StringValue) {
+			return
+		}
+		replaced := pattern.ReplaceAllStringFunc(str.StringValue, fn)
+		if replaced != str.StringValue {
+			str.StringValue = replaced
+			changed++
+		}
+	})
+	return changed
+}
@@ -0,0 +1,83 @@
+package protobaggins
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestTransformMatching(t *testing.T) {
+	t.Parallel()
+
+	hostPattern := regexp.MustCompile(`[a-z]+\.internal\.example\.com`)
+	rewriteHost := func(match string) string {
+		return strings.Replace(match, ".internal.", ".public.", 1)
+	}
+
+	newInput := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(map[string]any{
+			"endpoint": "https://api.internal.example.com/v1",
+			"mirrors": []any{
+				"cdn.internal.example.com",
+				"https://elsewhere.org",
+			},
+			"nested": map[string]any{
+				"host":  "db.internal.example.com",
+				"count": 3,
+			},
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("nil inputs", func(t *testing.T) {
+		t.Parallel()
+		assert.Zero(t, TransformMatching(nil, hostPattern, rewriteHost))
+		assert.Zero(t, TransformMatching(newInput(t), nil, rewriteHost))
+		assert.Zero(t, TransformMatching(newInput(t), hostPattern, nil))
+	})
+
+	t.Run("rewrites all matching leaves", func(t *testing.T) {
+		t.Parallel()
+		s := newInput(t)
+
+		changed := TransformMatching(s, hostPattern, rewriteHost)
+
+		assert.Equal(t, 3, changed)
+		fields := s.GetFields()
+		assert.Equal(t, "https://api.public.example.com/v1", fields["endpoint"].GetStringValue())
+		mirrors := fields["mirrors"].GetListValue().GetValues()
+		assert.Equal(t, "cdn.public.example.com", mirrors[0].GetStringValue())
+		assert.Equal(t, "https://elsewhere.org", mirrors[1].GetStringValue())
+		nested := fields["nested"].GetStructValue().GetFields()
+		assert.Equal(t, "db.public.example.com", nested["host"].GetStringValue())
+		assert.InEpsilon(t, float64(3), nested["count"].GetNumberValue(), 0.001)
+	})
+
+	t.Run("path filter limits the rewrite", func(t *testing.T) {
+		t.Parallel()
+		s := newInput(t)
+
+		onlyNested := func(path string) bool { return strings.HasPrefix(path, "nested.") }
+		changed := TransformMatching(s, hostPattern, rewriteHost, onlyNested)
+
+		assert.Equal(t, 1, changed)
+		fields := s.GetFields()
+		assert.Equal(t, "https://api.internal.example.com/v1", fields["endpoint"].GetStringValue())
+		assert.Equal(t, "db.public.example.com",
+			fields["nested"].GetStructValue().GetFields()["host"].GetStringValue())
+	})
+
+	t.Run("unchanged replacements are not counted", func(t *testing.T) {
+		t.Parallel()
+		s := newInput(t)
+
+		changed := TransformMatching(s, hostPattern, func(match string) string { return match })
+		assert.Zero(t, changed)
+	})
+}
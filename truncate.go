@@ -0,0 +1,319 @@
+package protobaggins
+
+import (
+	"cmp"
+	"container/heap"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultTruncationMarker is appended to strings shortened by TruncateStrings
+const DefaultTruncationMarker = "..."
+
+// TruncateOption configures TruncateStrings
+type TruncateOption func(*truncateOptions)
+
+type truncateOptions struct {
+	runes   bool
+	marker  string
+	filters []PathFilter
+}
+
+// TruncateRunes measures string length in runes instead of bytes
+func TruncateRunes() TruncateOption {
+	return func(o *truncateOptions) {
+		o.runes = true
+	}
+}
+
+// TruncateMarker sets the marker appended to truncated strings, use "" for none
+func TruncateMarker(marker string) TruncateOption {
+	return func(o *truncateOptions) {
+		o.marker = marker
+	}
+}
+
+// TruncatePaths limits truncation to leaves whose path satisfies all filters
+func TruncatePaths(filters ...PathFilter) TruncateOption {
+	return func(o *truncateOptions) {
+		o.filters = append(o.filters, filters...)
+	}
+}
+
+// TruncateStrings shortens every string leaf in s that is longer than maxLen so that,
+// including the marker, it is at most maxLen bytes (or runes, see TruncateRunes) long.
+// Byte truncation never splits a multi-byte character. The Struct is modified in place.
+// Returns the paths of the strings that were truncated
+func TruncateStrings(s *structpb.Struct, maxLen int, opts ...TruncateOption) []string {
+	if s == nil || maxLen < 0 {
+		return nil
+	}
+
+	o := truncateOptions{marker: DefaultTruncationMarker}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var truncated []string
+	walkStruct("", s, func(path string, v *structpb.Value) {
+		str, ok := v.GetKind().(*structpb.Value_StringValue)
+		if !ok || !matchesAll(path, o.filters) {
+			return
+		}
+		if shortened, ok := o.truncate(str.StringValue, maxLen); ok {
+			str.StringValue = shortened
+			truncated = append(truncated, path)
+		}
+	})
+	return truncated
+}
+
+// truncate shortens str to maxLen units including the marker, reporting whether it changed
+func (o *truncateOptions) truncate(str string, maxLen int) (string, bool) {
+	length := func(s string) int { return len(s) }
+	if o.runes {
+		length = utf8.RuneCountInString
+	}
+	if length(str) <= maxLen {
+		return str, false
+	}
+
+	marker := o.marker
+	if length(marker) > maxLen {
+		marker = ""
+	}
+	keep := maxLen - length(marker)
+
+	if o.runes {
+		i, n := 0, 0
+		for i < len(str) && n < keep {
+			_, size := utf8.DecodeRuneInString(str[i:])
+			i += size
+			n++
+		}
+		return str[:i] + marker, true
+	}
+
+	for keep > 0 && !utf8.RuneStart(str[keep]) {
+		keep--
+	}
+	return str[:keep] + marker, true
+}
+
+// truncateToSizeFloor is the length, in bytes, below which TruncateToSize does not trim
+// strings, preferring to summarize their containers instead
+const truncateToSizeFloor = 64
+
+// Elision records a value that TruncateToSize shortened or replaced
+type Elision struct {
+	// Path locates the value in the Struct
+	Path string
+	// Kind is the kind of the original value
+	Kind Kind
+	// Size is the serialized size of the original value in bytes
+	Size int
+}
+
+// TruncateToSize shrinks s in place until its serialized size is at most maxBytes, so
+// that large payloads can be attached to logs and traces. It first trims the longest
+// strings, keeping at least their first 64 bytes and appending DefaultTruncationMarker,
+// then replaces the largest lists and structs with a short summary string such as
+// "<elided list of 120 values, 34567 bytes>". Strings that already end in the marker
+// are not trimmed again. The result may still exceed maxBytes when s has too many
+// top-level fields to fit. Returns what was elided, largest first
+func TruncateToSize(s *structpb.Struct, maxBytes int) []Elision {
+	if s == nil {
+		return nil
+	}
+
+	// sizes are measured once and updated along the ancestors of each elided value
+	root, nodes := newSizeTree(s)
+	var elided []Elision
+	o := truncateOptions{marker: DefaultTruncationMarker}
+
+	var strs []*sizeNode
+	for _, n := range nodes {
+		str, ok := n.value.GetKind().(*structpb.Value_StringValue)
+		if ok && len(str.StringValue) > truncateToSizeFloor && !strings.HasSuffix(str.StringValue, o.marker) {
+			strs = append(strs, n)
+		}
+	}
+	slices.SortFunc(strs, compareSizeNodes)
+	for _, n := range strs {
+		excess := root.contents - maxBytes
+		if excess <= 0 {
+			break
+		}
+		elided = append(elided, Elision{Path: n.path, Kind: KindString, Size: n.size})
+		str := n.value.GetStringValue()
+		shortened, _ := o.truncate(str, max(truncateToSizeFloor, len(str)-excess))
+		n.value.Kind = &structpb.Value_StringValue{StringValue: shortened}
+		n.resize(proto.Size(n.value))
+	}
+
+	var containers sizeHeap
+	for _, n := range nodes {
+		if kind := KindOf(n.value); kind == KindList || kind == KindStruct {
+			containers = append(containers, sizeEntry{node: n, size: n.size})
+		}
+	}
+	heap.Init(&containers)
+	for root.contents > maxBytes && containers.Len() > 0 {
+		entry := heap.Pop(&containers).(sizeEntry)
+		n := entry.node
+		switch {
+		case n.removed:
+			continue
+		case n.size != entry.size:
+			// something inside was summarized since n was queued
+			heap.Push(&containers, sizeEntry{node: n, size: n.size})
+			continue
+		}
+		summary := elisionSummary(n.value, n.size)
+		if n.size <= proto.Size(summary) {
+			continue
+		}
+		original := Elision{Path: n.path, Kind: KindOf(n.value), Size: n.size}
+		n.value.Kind = summary.GetKind()
+		n.removeChildren()
+		n.resize(proto.Size(summary))
+
+		// anything trimmed inside the container is now part of the summary
+		elided = slices.DeleteFunc(elided, func(e Elision) bool { return WithinPath(e.Path, n.path) })
+		elided = append(elided, original)
+	}
+
+	slices.SortStableFunc(elided, func(a, b Elision) int { return cmp.Compare(b.Size, a.Size) })
+	return elided
+}
+
+// sizeNode is a value in the Struct shrunk by TruncateToSize, or the Struct itself at
+// the root, with the serialized size of its Value message and, for a container, of
+// its contents, kept up to date as values beneath it are elided
+type sizeNode struct {
+	path     string
+	value    *structpb.Value
+	parent   *sizeNode
+	key      string
+	inList   bool
+	size     int
+	contents int
+	children []*sizeNode
+	removed  bool
+}
+
+// newSizeTree measures s, returning its root node and the nodes of every value in it
+func newSizeTree(s *structpb.Struct) (*sizeNode, []*sizeNode) {
+	root := &sizeNode{value: structpb.NewStructValue(s)}
+	var nodes []*sizeNode
+	root.measure(&nodes)
+	return root, nodes
+}
+
+// measure creates the nodes of the values inside n, appending them to nodes, and sets
+// the size of n
+func (n *sizeNode) measure(nodes *[]*sizeNode) {
+	add := func(child *sizeNode) {
+		*nodes = append(*nodes, child)
+		child.measure(nodes)
+		n.children = append(n.children, child)
+		n.contents += child.contribution()
+	}
+	switch kind := n.value.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		for key, field := range kind.StructValue.GetFields() {
+			add(&sizeNode{path: joinKey(n.path, key), value: field, parent: n, key: key})
+		}
+		n.size = 1 + protowire.SizeBytes(n.contents)
+	case *structpb.Value_ListValue:
+		for i, item := range kind.ListValue.GetValues() {
+			add(&sizeNode{path: joinIndex(n.path, i), value: item, parent: n, inList: true})
+		}
+		n.size = 1 + protowire.SizeBytes(n.contents)
+	default:
+		n.size = proto.Size(n.value)
+	}
+}
+
+// contribution returns the size n adds to the contents of its parent
+func (n *sizeNode) contribution() int {
+	if n.inList {
+		return protowire.SizeTag(wireListValues) + protowire.SizeBytes(n.size)
+	}
+	return protowire.SizeTag(wireStructFields) + protowire.SizeBytes(entrySize(n.key, n.size))
+}
+
+// resize sets the size of n, updating the sizes of its ancestors
+func (n *sizeNode) resize(size int) {
+	for p := n.parent; p != nil; n, p = p, p.parent {
+		before := n.contribution()
+		n.size = size
+		p.contents += n.contribution() - before
+		size = 1 + protowire.SizeBytes(p.contents)
+	}
+	n.size = size
+}
+
+// removeChildren marks the values inside n as removed, once n has been summarized
+func (n *sizeNode) removeChildren() {
+	for _, child := range n.children {
+		child.removed = true
+		child.removeChildren()
+	}
+	n.children = nil
+}
+
+// compareSizeNodes orders nodes largest first, breaking ties by path
+func compareSizeNodes(a, b *sizeNode) int {
+	if c := cmp.Compare(b.size, a.size); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.path, b.path)
+}
+
+// sizeEntry is a container queued by TruncateToSize with its size when it was queued
+type sizeEntry struct {
+	node *sizeNode
+	size int
+}
+
+// sizeHeap is a heap.Interface of containers, largest first with ties broken by path
+type sizeHeap []sizeEntry
+
+func (h sizeHeap) Len() int { return len(h) }
+
+func (h sizeHeap) Less(i, j int) bool {
+	if h[i].size != h[j].size {
+		return h[i].size > h[j].size
+	}
+	return h[i].node.path < h[j].node.path
+}
+
+func (h sizeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *sizeHeap) Push(x any) { *h = append(*h, x.(sizeEntry)) }
+
+func (h *sizeHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// elisionSummary describes a container of size bytes replaced by TruncateToSize
+func elisionSummary(v *structpb.Value, size int) *structpb.Value {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_ListValue:
+		return structpb.NewStringValue(fmt.Sprintf("<elided list of %d values, %d bytes>",
+			len(kind.ListValue.GetValues()), size))
+	default:
+		return structpb.NewStringValue(fmt.Sprintf("<elided struct of %d fields, %d bytes>",
+			len(v.GetStructValue().GetFields()), size))
+	}
+}
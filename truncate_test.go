@@ -0,0 +1,242 @@
+package protobaggins
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestTruncateStrings(t *testing.T) {
+	t.Parallel()
+
+	newInput := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		s, err := structpb.NewStruct(map[string]any{
+			"short": "ok",
+			"long":  strings.Repeat("a", 20),
+			"list":  []any{strings.Repeat("b", 20), 42},
+			"nested": map[string]any{
+				"unicode": "héllo wörld",
+			},
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, TruncateStrings(nil, 10))
+	})
+
+	t.Run("truncates long strings in bytes", func(t *testing.T) {
+		t.Parallel()
+		s := newInput(t)
+
+		paths := TruncateStrings(s, 10)
+
+		assert.ElementsMatch(t, []string{"long", "list[0]", "nested.unicode"}, paths)
+		fields := s.GetFields()
+		assert.Equal(t, "ok", fields["short"].GetStringValue())
+		assert.Equal(t, "aaaaaaa...", fields["long"].GetStringValue())
+		assert.Equal(t, "bbbbbbb...", fields["list"].GetListValue().GetValues()[0].GetStringValue())
+
+		unicode := fields["nested"].GetStructValue().GetFields()["unicode"].GetStringValue()
+		assert.Equal(t, "héllo ...", unicode)
+		assert.LessOrEqual(t, len(unicode), 10)
+	})
+
+	t.Run("byte truncation keeps valid utf8", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"umlauts": "ööööö"})
+		require.NoError(t, err)
+
+		// keeping 3 bytes would split the second "ö", so only the first is kept
+		TruncateStrings(s, 6)
+		assert.Equal(t, "ö...", s.GetFields()["umlauts"].GetStringValue())
+	})
+
+	t.Run("truncates in runes", func(t *testing.T) {
+		t.Parallel()
+		s := newInput(t)
+
+		paths := TruncateStrings(s, 10, TruncateRunes())
+
+		assert.ElementsMatch(t, []string{"long", "list[0]", "nested.unicode"}, paths)
+		unicode := s.GetFields()["nested"].GetStructValue().GetFields()["unicode"].GetStringValue()
+		assert.Equal(t, "héllo w...", unicode)
+	})
+
+	t.Run("custom marker", func(t *testing.T) {
+		t.Parallel()
+		s := newInput(t)
+
+		TruncateStrings(s, 5, TruncateMarker("~"))
+		assert.Equal(t, "aaaa~", s.GetFields()["long"].GetStringValue())
+
+		TruncateStrings(s, 3, TruncateMarker(""))
+		assert.Equal(t, "aaa", s.GetFields()["long"].GetStringValue())
+	})
+
+	t.Run("marker longer than limit is dropped", func(t *testing.T) {
+		t.Parallel()
+		s := newInput(t)
+
+		TruncateStrings(s, 2)
+		assert.Equal(t, "aa", s.GetFields()["long"].GetStringValue())
+	})
+
+	t.Run("path filter", func(t *testing.T) {
+		t.Parallel()
+		s := newInput(t)
+
+		paths := TruncateStrings(s, 10, TruncatePaths(func(path string) bool { return path == "long" }))
+
+		assert.Equal(t, []string{"long"}, paths)
+		assert.Len(t, s.GetFields()["list"].GetListValue().GetValues()[0].GetStringValue(), 20)
+	})
+}
+
+func TestTruncateToSize(t *testing.T) {
+	t.Parallel()
+
+	newPayload := func(t *testing.T) *structpb.Struct {
+		t.Helper()
+		items := make([]any, 50)
+		for i := range items {
+			items[i] = map[string]any{"id": float64(i), "name": "item"}
+		}
+		s, err := structpb.NewStruct(map[string]any{
+			"id":    "req-1",
+			"body":  strings.Repeat("b", 5000),
+			"items": items,
+			"meta":  map[string]any{"trace": strings.Repeat("t", 100)},
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("already fits", func(t *testing.T) {
+		t.Parallel()
+		s := newPayload(t)
+		assert.Empty(t, TruncateToSize(s, proto.Size(s)))
+	})
+
+	t.Run("trims strings first", func(t *testing.T) {
+		t.Parallel()
+		s := newPayload(t)
+		bodySize := proto.Size(s.GetFields()["body"])
+		limit := proto.Size(s) - 1000
+
+		elided := TruncateToSize(s, limit)
+		assert.LessOrEqual(t, proto.Size(s), limit)
+		assert.Equal(t, []Elision{{Path: "body", Kind: KindString, Size: bodySize}}, elided)
+		body := s.GetFields()["body"].GetStringValue()
+		assert.True(t, strings.HasSuffix(body, DefaultTruncationMarker))
+		assert.Greater(t, len(body), 3000)
+	})
+
+	t.Run("summarizes largest containers", func(t *testing.T) {
+		t.Parallel()
+		s := newPayload(t)
+		itemsSize := proto.Size(s.GetFields()["items"])
+
+		elided := TruncateToSize(s, 400)
+		assert.LessOrEqual(t, proto.Size(s), 400)
+		require.Len(t, elided, 3)
+		assert.Equal(t, "body", elided[0].Path)
+		assert.Equal(t, Elision{Path: "items", Kind: KindList, Size: itemsSize}, elided[1])
+		assert.Equal(t, "meta.trace", elided[2].Path)
+		assert.Equal(t, fmt.Sprintf("<elided list of 50 values, %d bytes>", itemsSize),
+			s.GetFields()["items"].GetStringValue())
+		assert.Len(t, s.GetFields()["body"].GetStringValue(), truncateToSizeFloor)
+		assert.Equal(t, "req-1", s.GetFields()["id"].GetStringValue())
+	})
+
+	t.Run("elisions inside summarized containers are dropped", func(t *testing.T) {
+		t.Parallel()
+		s := newPayload(t)
+		elided := TruncateToSize(s, 150)
+		var paths []string
+		for _, e := range elided {
+			paths = append(paths, e.Path)
+		}
+		assert.ElementsMatch(t, []string{"items", "body", "meta"}, paths)
+		assert.Contains(t, s.GetFields()["meta"].GetStringValue(), "<elided struct of 1 fields")
+	})
+
+	t.Run("best effort when nothing is left to elide", func(t *testing.T) {
+		t.Parallel()
+		s := newPayload(t)
+		TruncateToSize(s, 0)
+		assert.Greater(t, proto.Size(s), 0)
+		assert.Equal(t, "req-1", s.GetFields()["id"].GetStringValue())
+	})
+
+	t.Run("marked strings are not trimmed again", func(t *testing.T) {
+		t.Parallel()
+		s := newPayload(t)
+		limit := proto.Size(s) - 1000
+		TruncateToSize(s, limit)
+		body := s.GetFields()["body"].GetStringValue()
+
+		elided := TruncateToSize(s, limit-1000)
+		assert.Equal(t, body, s.GetFields()["body"].GetStringValue())
+		assert.NotContains(t, s.GetFields()["body"].GetStringValue(), DefaultTruncationMarker+DefaultTruncationMarker)
+		for _, e := range elided {
+			assert.NotEqual(t, "body", e.Path)
+		}
+	})
+
+	t.Run("large payloads", func(t *testing.T) {
+		t.Parallel()
+		items := make([]any, 20000)
+		for i := range items {
+			items[i] = map[string]any{"id": float64(i), "tags": []any{"a", "b"}, "note": strings.Repeat("n", 100)}
+		}
+		s, err := structpb.NewStruct(map[string]any{"items": items, "groups": []any{items[:100], items[100:200]}})
+		require.NoError(t, err)
+
+		for _, limit := range []int{proto.Size(s) / 2, 5000, 200} {
+			TruncateToSize(s, limit)
+			assert.LessOrEqual(t, proto.Size(s), limit)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, TruncateToSize(nil, 10))
+	})
+}
+
+func TestSizeTree(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"a": []any{1, "two", nil, true, map[string]any{"b": strings.Repeat("x", 300)}},
+		"c": map[string]any{"d": []any{}, "e": map[string]any{}},
+	})
+	require.NoError(t, err)
+
+	root, nodes := newSizeTree(s)
+	assert.Equal(t, proto.Size(s), root.contents)
+	for _, n := range nodes {
+		assert.Equal(t, proto.Size(n.value), n.size, n.path)
+	}
+
+	// resizing keeps every ancestor exact
+	for _, n := range nodes {
+		if n.path == "a[4].b" {
+			n.value.Kind = &structpb.Value_StringValue{StringValue: "short"}
+			n.resize(proto.Size(n.value))
+		}
+	}
+	assert.Equal(t, proto.Size(s), root.contents)
+	for _, n := range nodes {
+		assert.Equal(t, proto.Size(n.value), n.size, n.path)
+	}
+}
@@ -0,0 +1,76 @@
+package protobaggins
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ValueConverterFunc converts a Go value of a registered type to a *structpb.Value
+type ValueConverterFunc func(v any) (*structpb.Value, error)
+
+var valueConverters sync.Map // reflect.Type -> ValueConverterFunc
+
+// RegisterValueConverter teaches every conversion in this package, including the lossy
+// MapToStructValues and TryNewStructValue, to encode values of type t with fn, e.g.
+//
+//	protobaggins.RegisterValueConverter(reflect.TypeFor[uuid.UUID](), func(v any) (*structpb.Value, error) {
+//		return structpb.NewStringValue(v.(uuid.UUID).String()), nil
+//	})
+//
+// The type must match exactly: registering T does not cover *T. A nil fn removes the
+// registration. Converters given with WithValueConverter take precedence
+func RegisterValueConverter(t reflect.Type, fn ValueConverterFunc) {
+	if t == nil {
+		return
+	}
+	if fn == nil {
+		valueConverters.Delete(t)
+		return
+	}
+	valueConverters.Store(t, fn)
+}
+
+// WithValueConverter encodes values of type t with fn for this conversion only,
+// overriding both the built-in handling and RegisterValueConverter
+func WithValueConverter(t reflect.Type, fn ValueConverterFunc) Option {
+	return func(o *options) {
+		if t == nil || fn == nil {
+			return
+		}
+		if o.valueConverters == nil {
+			o.valueConverters = make(map[reflect.Type]ValueConverterFunc)
+		}
+		o.valueConverters[t] = fn
+	}
+}
+
+// lookupValueConverter returns the converter for v's dynamic type, if any
+func (o *options) lookupValueConverter(v any) (ValueConverterFunc, bool) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, false
+	}
+	if fn, ok := o.valueConverters[t]; ok {
+		return fn, true
+	}
+	fn, ok := valueConverters.Load(t)
+	if !ok {
+		return nil, false
+	}
+	return fn.(ValueConverterFunc), true
+}
+
+// convertCustom applies a registered converter, treating a nil result as null
+func convertCustom(fn ValueConverterFunc, v any) (*structpb.Value, error) {
+	pbValue, err := fn(v)
+	if err != nil {
+		return nil, fmt.Errorf("%T: %w", v, err)
+	}
+	if pbValue == nil {
+		return structpb.NewNullValue(), nil
+	}
+	return pbValue, nil
+}
@@ -0,0 +1,92 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type testUUID [4]byte
+
+type testDecimal struct {
+	units int64
+	scale int
+}
+
+type testEnum int
+
+func TestRegisterValueConverter(t *testing.T) {
+	t.Parallel()
+
+	RegisterValueConverter(reflect.TypeFor[testUUID](), func(v any) (*structpb.Value, error) {
+		return structpb.NewStringValue(fmt.Sprintf("%x", v.(testUUID))), nil
+	})
+	RegisterValueConverter(reflect.TypeFor[testDecimal](), func(v any) (*structpb.Value, error) {
+		d := v.(testDecimal)
+		if d.scale < 0 {
+			return nil, errors.New("negative scale")
+		}
+		return structpb.NewStringValue(fmt.Sprintf("%de-%d", d.units, d.scale)), nil
+	})
+
+	id := testUUID{0xde, 0xad, 0xbe, 0xef}
+
+	t.Run("lossy functions", func(t *testing.T) {
+		t.Parallel()
+		values := MapToStructValues(map[string]any{
+			"id":    id,
+			"price": testDecimal{units: 1999, scale: 2},
+			"bad":   testDecimal{scale: -1},
+		})
+		assert.Equal(t, "deadbeef", values["id"].GetStringValue())
+		assert.Equal(t, "1999e-2", values["price"].GetStringValue())
+		assert.NotContains(t, values, "bad")
+
+		assert.Equal(t, "deadbeef", TryNewStructValue(id).GetStringValue())
+		assert.Nil(t, TryNewStructValue(&id), "registering T does not cover *T")
+	})
+
+	t.Run("nested and in structs", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(map[string]any{"ids": []any{id}})
+		require.NoError(t, err)
+		assert.Equal(t, "deadbeef", v.GetStructValue().GetFields()["ids"].GetListValue().GetValues()[0].GetStringValue())
+
+		s, err := EncodeStruct(struct{ ID testUUID }{ID: id})
+		require.NoError(t, err)
+		assert.Equal(t, "deadbeef", s.GetFields()["ID"].GetStringValue())
+	})
+
+	t.Run("errors name the type", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewValue(testDecimal{scale: -1})
+		require.ErrorContains(t, err, "protobaggins.testDecimal: negative scale")
+	})
+}
+
+func TestWithValueConverter(t *testing.T) {
+	t.Parallel()
+
+	enumNames := func(v any) (*structpb.Value, error) {
+		return structpb.NewStringValue([]string{"OFF", "ON"}[v.(testEnum)]), nil
+	}
+
+	v, err := NewValue([]any{testEnum(1), testEnum(0)}, WithValueConverter(reflect.TypeFor[testEnum](), enumNames))
+	require.NoError(t, err)
+	assert.Equal(t, []any{"ON", "OFF"}, v.AsInterface())
+
+	_, err = NewValue(testEnum(1))
+	require.Error(t, err, "the option does not register globally")
+
+	// per-call converters override built-in handling, and nil results become null
+	v, err = NewConverter(WithValueConverter(reflect.TypeFor[string](), func(any) (*structpb.Value, error) {
+		return nil, nil
+	})).NewValue("x")
+	require.NoError(t, err)
+	assert.Equal(t, KindNull, KindOf(v))
+}
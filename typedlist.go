@@ -0,0 +1,72 @@
+package protobaggins
+
+import (
+	"errors"
+	"reflect"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Scalar is the element type of the typed list and map converters
+type Scalar interface {
+	~string | ~bool |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// SliceToList converts s to a *structpb.ListValue without converting it to []any
+// first. Items are converted like NewValue does under opts, so e.g. WithNonFinite
+// applies to floats, and items of named types like EncodeStruct does. Every failure
+// is reported as a ConversionError at the index of its item. A nil slice gives an empty
+// list
+func SliceToList[T Scalar](s []T, opts ...Option) (*structpb.ListValue, error) {
+	e := newEncoder(newOptions(opts))
+	values := make([]*structpb.Value, 0, len(s))
+	var errs entryErrors
+	for i, item := range s {
+		e.pushIndex(i)
+		pbValue, err := e.encodeScalar(item)
+		if err == nil {
+			err = e.count("", pbValue)
+		}
+		e.pop()
+		if err != nil {
+			if err := errs.add(e, "", item, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		values = append(values, pbValue)
+	}
+	if len(errs) > 0 {
+		return nil, errs.join(false)
+	}
+	return &structpb.ListValue{Values: values}, nil
+}
+
+// encodeScalar converts v, whose type may be a named type such as time.Duration
+func (e *encoder) encodeScalar(v any) (*structpb.Value, error) {
+	if reflect.TypeOf(v).PkgPath() == "" {
+		return e.encode(v)
+	}
+	return e.encodeReflect(reflect.ValueOf(v))
+}
+
+// ListToSlice converts the items of l to a []T with the coercions of As, so whole
+// numbers and numeric strings become integers. Null items become the zero T. Every item
+// that fails to convert is reported, each error prefixed with its index, e.g. "[2]: "
+// A nil list gives an empty slice
+func ListToSlice[T Scalar](l *structpb.ListValue, opts ...Option) ([]T, error) {
+	d := decoder{opts: newOptions(opts)}
+	items := l.GetValues()
+	out := make([]T, len(items))
+	var errs []error
+	for i, item := range items {
+		d.decodeReflect(joinIndex("", i), item, reflect.ValueOf(&out[i]).Elem(), &errs)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
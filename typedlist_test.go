@@ -0,0 +1,99 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSliceToList(t *testing.T) {
+	t.Parallel()
+
+	t.Run("scalars", func(t *testing.T) {
+		t.Parallel()
+		strs, err := SliceToList([]string{"a", "b"})
+		require.NoError(t, err)
+		assert.Equal(t, []any{"a", "b"}, strs.AsSlice())
+
+		ints, err := SliceToList([]int64{1, -2})
+		require.NoError(t, err)
+		assert.Equal(t, []any{1.0, -2.0}, ints.AsSlice())
+
+		bools, err := SliceToList([]bool{true, false})
+		require.NoError(t, err)
+		assert.Equal(t, []any{true, false}, bools.AsSlice())
+
+		type level string
+		named, err := SliceToList([]level{"debug"})
+		require.NoError(t, err)
+		assert.Equal(t, []any{"debug"}, named.AsSlice())
+
+		empty, err := SliceToList[float64](nil)
+		require.NoError(t, err)
+		assert.Empty(t, empty.GetValues())
+	})
+
+	t.Run("element errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := SliceToList([]float64{1, math.NaN(), 2, math.Inf(1)}, WithNonFinite(NonFiniteError))
+		require.ErrorIs(t, err, ErrNonFinite)
+		var ce *ConversionError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "[1]", ce.Path)
+		assert.Contains(t, err.Error(), "[3]: ")
+
+		l, err := SliceToList([]float64{1, math.NaN()}, WithNonFinite(NonFiniteError), WithSkipErrors())
+		require.NoError(t, err)
+		assert.Equal(t, []any{1.0}, l.AsSlice())
+	})
+}
+
+func TestListToSlice(t *testing.T) {
+	t.Parallel()
+
+	newList := func(t *testing.T, items ...any) *structpb.ListValue {
+		t.Helper()
+		l, err := structpb.NewList(items)
+		require.NoError(t, err)
+		return l
+	}
+
+	t.Run("scalars", func(t *testing.T) {
+		t.Parallel()
+		strs, err := ListToSlice[string](newList(t, "a", "b"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, strs)
+
+		ints, err := ListToSlice[int64](newList(t, 1, "2", nil))
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 0}, ints)
+
+		floats, err := ListToSlice[float64](newList(t, 1.5))
+		require.NoError(t, err)
+		assert.Equal(t, []float64{1.5}, floats)
+
+		bools, err := ListToSlice[bool](newList(t, true))
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true}, bools)
+
+		empty, err := ListToSlice[string](nil)
+		require.NoError(t, err)
+		assert.Empty(t, empty)
+	})
+
+	t.Run("element errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := ListToSlice[int64](newList(t, 1, 1.5, "x", map[string]any{}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "[1]: ")
+		assert.Contains(t, err.Error(), "[2]: ")
+		assert.Contains(t, err.Error(), "[3]: ")
+		assert.NotContains(t, err.Error(), "[0]")
+
+		_, err = ListToSlice[uint8](newList(t, 300))
+		require.Error(t, err)
+	})
+}
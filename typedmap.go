@@ -0,0 +1,90 @@
+package protobaggins
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MapToStructValuesT converts m to a map[string]*structpb.Value without converting it
+// to map[string]any first. Values are converted like SliceToList converts items, and
+// every failure is reported as a ConversionError at its key, in sorted order. A nil map
+// gives nil
+func MapToStructValuesT[V Scalar](m map[string]V, opts ...Option) (map[string]*structpb.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	e := newEncoder(newOptions(opts))
+	fields := make(map[string]*structpb.Value, len(m))
+	var errs entryErrors
+	for k, v := range m {
+		if !e.opts.keepKey(k) || e.opts.omitEntry(v) {
+			continue
+		}
+		pbValue, name, err := e.encodeField(fields, k, func() (*structpb.Value, error) {
+			return e.encodeScalar(v)
+		})
+		if err != nil {
+			if err := errs.add(e, k, v, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		fields[name] = pbValue
+	}
+	if len(errs) > 0 {
+		return nil, errs.join(true)
+	}
+	return fields, nil
+}
+
+// StringMapToStructValues converts a map[string]string to a map[string]*structpb.Value
+// Fails only for keys and values that are not valid UTF-8, see MapToStructValuesT
+func StringMapToStructValues(m map[string]string, opts ...Option) (map[string]*structpb.Value, error) {
+	return MapToStructValuesT(m, opts...)
+}
+
+// StructValuesToMapT converts m to a map[string]V with the coercions of As, so whole
+// numbers and numeric strings become integers. Null values become the zero V unless
+// WithOmitNulls drops them. Every value that fails to convert is reported, each error
+// prefixed with its key, in sorted order. A nil map gives nil
+func StructValuesToMapT[V Scalar](m map[string]*structpb.Value, opts ...Option) (map[string]V, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	d := decoder{opts: newOptions(opts)}
+	result := make(map[string]V, len(m))
+	var errs []error
+	names := d.opts.keyCaseNames(m)
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		v := m[k]
+		if !d.opts.decodedField(k, v) {
+			continue
+		}
+		name, ok := d.opts.decodedName(names, k)
+		if !ok {
+			continue
+		}
+		var out V
+		n := len(errs)
+		d.decodeReflect(joinKey("", k), v, reflect.ValueOf(&out).Elem(), &errs)
+		if len(errs) == n {
+			result[name] = out
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+// StructValuesToStringMap converts a map[string]*structpb.Value to a map[string]string,
+// see StructValuesToMapT
+func StructValuesToStringMap(m map[string]*structpb.Value, opts ...Option) (map[string]string, error) {
+	return StructValuesToMapT[string](m, opts...)
+}
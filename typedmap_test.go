@@ -0,0 +1,84 @@
+package protobaggins
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestMapToStructValuesT(t *testing.T) {
+	t.Parallel()
+
+	t.Run("homogeneous maps", func(t *testing.T) {
+		t.Parallel()
+		strs, err := StringMapToStructValues(map[string]string{"env": "prod", "region": "eu"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"env": "prod", "region": "eu"}, StructValuesToMap(strs))
+
+		ints, err := MapToStructValuesT(map[string]int64{"replicas": 3})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"replicas": 3.0}, StructValuesToMap(ints))
+
+		none, err := MapToStructValuesT[bool](nil)
+		require.NoError(t, err)
+		assert.Nil(t, none)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		got, err := MapToStructValuesT(map[string]int{"maxRetries": 3, "secret": 1, "zero": 0},
+			WithKeyCase(KeyCaseSnake), WithOmitEmpty(),
+			WithKeyFilter(func(key string) bool { return key != "secret" }))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"max_retries": 3.0}, StructValuesToMap(got))
+	})
+
+	t.Run("per-key errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := MapToStructValuesT(map[string]float64{"b": math.Inf(1), "ok": 1, "a": math.NaN()},
+			WithNonFinite(NonFiniteError))
+		require.ErrorIs(t, err, ErrNonFinite)
+		assert.Regexp(t, `^a: .+\nb: .+$`, err.Error())
+
+		_, err = StringMapToStructValues(map[string]string{"k": "\xff"})
+		var ce *ConversionError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "k", ce.Path)
+	})
+}
+
+func TestStructValuesToMapT(t *testing.T) {
+	t.Parallel()
+
+	fields := func(t *testing.T, m map[string]any) map[string]*structpb.Value {
+		t.Helper()
+		s, err := structpb.NewStruct(m)
+		require.NoError(t, err)
+		return s.GetFields()
+	}
+
+	t.Run("coerces", func(t *testing.T) {
+		t.Parallel()
+		ints, err := StructValuesToMapT[int64](fields(t, map[string]any{"a": 1, "b": "2", "c": nil}))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"a": 1, "b": 2, "c": 0}, ints)
+
+		strs, err := StructValuesToStringMap(fields(t, map[string]any{"env": "prod", "c": nil}), WithOmitNulls())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"env": "prod"}, strs)
+
+		none, err := StructValuesToMapT[string](nil)
+		require.NoError(t, err)
+		assert.Nil(t, none)
+	})
+
+	t.Run("per-key errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := StructValuesToMapT[int32](fields(t, map[string]any{"z": 1.5, "ok": 1, "a": "x", "m": []any{}}))
+		require.Error(t, err)
+		assert.Regexp(t, `^a: .+\nm: .+\nz: .+$`, err.Error())
+	})
+}
@@ -0,0 +1,150 @@
+package protobaggins
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// URLToValue converts a *url.URL to a string *structpb.Value
+// Returns a null value if the URL is nil
+func URLToValue(u *url.URL) *structpb.Value {
+	if u == nil {
+		return structpb.NewNullValue()
+	}
+	return structpb.NewStringValue(u.String())
+}
+
+// URLToStruct explodes a *url.URL into a *structpb.Struct with scheme, opaque, user,
+// password, host, path, raw_path, force_query, query, raw_query, fragment and
+// raw_fragment fields. Empty components are omitted, except for the user and password
+// of a URL that has them, and the query is a nested Struct mapping each parameter to
+// the list of its values. raw_path and raw_fragment keep escapings that path and
+// fragment lose, such as %2F, see url.URL, and raw_query keeps the parameter order,
+// parameters without a value such as ?flag, and malformed pairs that query drops
+func URLToStruct(u *url.URL) *structpb.Struct {
+	if u == nil {
+		return nil
+	}
+
+	fields := make(map[string]*structpb.Value)
+	setString := func(key, value string) {
+		if value != "" {
+			fields[key] = structpb.NewStringValue(value)
+		}
+	}
+
+	setString("scheme", u.Scheme)
+	setString("opaque", u.Opaque)
+	if u.User != nil {
+		fields["user"] = structpb.NewStringValue(u.User.Username())
+		if password, ok := u.User.Password(); ok {
+			fields["password"] = structpb.NewStringValue(password)
+		}
+	}
+	setString("host", u.Host)
+	setString("path", u.Path)
+	setString("raw_path", u.RawPath)
+	if u.ForceQuery {
+		fields["force_query"] = structpb.NewBoolValue(true)
+	}
+	setString("fragment", u.Fragment)
+	setString("raw_fragment", u.RawFragment)
+
+	setString("raw_query", u.RawQuery)
+	if query := u.Query(); len(query) > 0 {
+		params := make(map[string]*structpb.Value, len(query))
+		for key, values := range query {
+			list := make([]*structpb.Value, len(values))
+			for i, v := range values {
+				list[i] = structpb.NewStringValue(v)
+			}
+			params[key] = structpb.NewListValue(&structpb.ListValue{Values: list})
+		}
+		fields["query"] = structpb.NewStructValue(&structpb.Struct{Fields: params})
+	}
+
+	return &structpb.Struct{Fields: fields}
+}
+
+// URLFromValue is the decode hook for URL values, accepting either the string form
+// produced by URLToValue or the exploded form produced by URLToStruct
+// Returns nil without error for nil or null values
+func URLFromValue(v *structpb.Value) (*url.URL, error) {
+	switch kind := v.GetKind().(type) {
+	case nil, *structpb.Value_NullValue:
+		return nil, nil
+	case *structpb.Value_StringValue:
+		return url.Parse(kind.StringValue)
+	case *structpb.Value_StructValue:
+		return URLFromStruct(kind.StructValue)
+	default:
+		return nil, fmt.Errorf("%w: cannot decode %s as URL", ErrUnexpectedKind, KindOf(v))
+	}
+}
+
+// URLFromStruct rebuilds a *url.URL from the exploded form produced by URLToStruct
+// Query parameters may be given either as lists of strings or as single strings. The
+// raw_query is used as long as it holds the same parameters as query, so that edits
+// to query take effect, and the query is encoded in sorted order otherwise
+func URLFromStruct(s *structpb.Struct) (*url.URL, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	fields := s.GetFields()
+	u := &url.URL{
+		Scheme:      fields["scheme"].GetStringValue(),
+		Opaque:      fields["opaque"].GetStringValue(),
+		Host:        fields["host"].GetStringValue(),
+		Path:        fields["path"].GetStringValue(),
+		RawPath:     fields["raw_path"].GetStringValue(),
+		ForceQuery:  fields["force_query"].GetBoolValue(),
+		Fragment:    fields["fragment"].GetStringValue(),
+		RawFragment: fields["raw_fragment"].GetStringValue(),
+	}
+
+	_, hasUser := fields["user"]
+	if _, hasPassword := fields["password"]; hasUser || hasPassword {
+		user := fields["user"].GetStringValue()
+		if password, ok := fields["password"]; ok {
+			u.User = url.UserPassword(user, password.GetStringValue())
+		} else {
+			u.User = url.User(user)
+		}
+	}
+
+	values := url.Values{}
+	if query, ok := fields["query"]; ok {
+		params := query.GetStructValue()
+		if params == nil {
+			return nil, fmt.Errorf("%w: URL query must be a struct, got %s", ErrUnexpectedKind, KindOf(query))
+		}
+		for key, param := range params.GetFields() {
+			switch kind := param.GetKind().(type) {
+			case *structpb.Value_StringValue:
+				values.Add(key, kind.StringValue)
+			case *structpb.Value_ListValue:
+				for _, item := range kind.ListValue.GetValues() {
+					values.Add(key, item.GetStringValue())
+				}
+			default:
+				return nil, fmt.Errorf("%w: URL query parameter %q must be a string or list, got %s",
+					ErrUnexpectedKind, key, KindOf(param))
+			}
+		}
+		u.RawQuery = values.Encode()
+	}
+	if raw := fields["raw_query"].GetStringValue(); raw != "" {
+		// ParseQuery drops malformed pairs as URLToStruct did, and reports them
+		parsed, _ := url.ParseQuery(raw)
+		if maps.EqualFunc(parsed, values, slices.Equal) {
+			u.RawQuery = raw
+		}
+	}
+
+	return u, nil
+}
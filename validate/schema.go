@@ -0,0 +1,442 @@
+// Package validate checks *structpb.Value trees against JSON Schemas (draft 2020-12)
+// directly, without converting them to map[string]any first, and reports every
+// violation with its path.
+//
+// The assertions of the core and validation vocabularies are supported, along with
+// $ref to locations within the same schema, such as "#/$defs/address" or "#" for
+// recursion. The "format" keyword is an annotation and never fails, and other unknown
+// keywords are ignored as the specification requires. Schemas using unevaluatedItems,
+// unevaluatedProperties, $dynamicRef or references to other documents are rejected
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrInvalidSchema is returned by CompileSchema for schemas it cannot use
+var ErrInvalidSchema = errors.New("invalid JSON Schema")
+
+// Schema is a compiled JSON Schema, safe for concurrent use
+type Schema struct {
+	root *node
+}
+
+// node is a compiled schema object or boolean schema
+type node struct {
+	// never is set for the false schema, which nothing satisfies
+	never bool
+
+	ref      *node
+	types    []string
+	enum     []*structpb.Value
+	constant *structpb.Value
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+	multipleOf                         *float64
+
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+
+	prefixItems              []*node
+	items                    *node
+	contains                 *node
+	minContains, maxContains *int
+	minItems, maxItems       *int
+	uniqueItems              bool
+
+	properties           map[string]*node
+	patternProperties    []patternNode
+	additionalProperties *node
+	propertyNames        *node
+	required             []string
+	dependentRequired    map[string][]string
+	dependentSchemas     map[string]*node
+	minProperties        *int
+	maxProperties        *int
+
+	allOf, anyOf, oneOf []*node
+	not                 *node
+	ifNode              *node
+	thenNode, elseNode  *node
+}
+
+type patternNode struct {
+	pattern *regexp.Regexp
+	schema  *node
+}
+
+// CompileSchema parses and compiles a JSON Schema. Fails with ErrInvalidSchema,
+// naming the location in the schema, for malformed schemas and unsupported features
+func CompileSchema(schemaJSON []byte) (*Schema, error) {
+	doc := &structpb.Value{}
+	if err := protojson.Unmarshal(schemaJSON, doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+	c := compiler{doc: doc, nodes: make(map[string]*node)}
+	root, err := c.compile("", doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// compiler compiles the subschemas of doc, each once, keyed by their JSON pointer
+type compiler struct {
+	doc   *structpb.Value
+	nodes map[string]*node
+}
+
+func (c *compiler) errorf(ptr, format string, args ...any) error {
+	return fmt.Errorf("%w at %q: %s", ErrInvalidSchema, "#"+ptr, fmt.Sprintf(format, args...))
+}
+
+// compile compiles the schema v at ptr. The node is registered before its keywords
+// are compiled, so references to enclosing schemas find it
+func (c *compiler) compile(ptr string, v *structpb.Value) (*node, error) {
+	if n, ok := c.nodes[ptr]; ok {
+		return n, nil
+	}
+	n := &node{}
+	c.nodes[ptr] = n
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		n.never = !kind.BoolValue
+		return n, nil
+	case *structpb.Value_StructValue:
+		return n, c.keywords(ptr, n, kind.StructValue.GetFields())
+	default:
+		return nil, c.errorf(ptr, "schema must be an object or a boolean, got %s", protobaggins.KindOf(v))
+	}
+}
+
+func (c *compiler) keywords(ptr string, n *node, f map[string]*structpb.Value) error {
+	for _, k := range []string{"unevaluatedItems", "unevaluatedProperties", "$dynamicRef", "$recursiveRef"} {
+		if _, ok := f[k]; ok {
+			return c.errorf(ptr, "unsupported keyword %q", k)
+		}
+	}
+
+	var err error
+	if ref, ok := f["$ref"]; ok {
+		if n.ref, err = c.resolve(child(ptr, "$ref"), ref); err != nil {
+			return err
+		}
+	}
+	if t, ok := f["type"]; ok {
+		if n.types, err = c.typeNames(child(ptr, "type"), t); err != nil {
+			return err
+		}
+	}
+	if e, ok := f["enum"]; ok {
+		if protobaggins.KindOf(e) != protobaggins.KindList {
+			return c.errorf(child(ptr, "enum"), "must be an array")
+		}
+		n.enum = e.GetListValue().GetValues()
+	}
+	if v, ok := f["const"]; ok {
+		n.constant = v
+	}
+
+	numbers := []struct {
+		key string
+		dst **float64
+	}{
+		{"minimum", &n.minimum}, {"maximum", &n.maximum},
+		{"exclusiveMinimum", &n.exclusiveMinimum}, {"exclusiveMaximum", &n.exclusiveMaximum},
+		{"multipleOf", &n.multipleOf},
+	}
+	for _, num := range numbers {
+		if *num.dst, err = c.number(ptr, f, num.key); err != nil {
+			return err
+		}
+	}
+	if n.multipleOf != nil && *n.multipleOf <= 0 {
+		return c.errorf(child(ptr, "multipleOf"), "must be greater than 0")
+	}
+
+	counts := []struct {
+		key string
+		dst **int
+	}{
+		{"minLength", &n.minLength}, {"maxLength", &n.maxLength},
+		{"minItems", &n.minItems}, {"maxItems", &n.maxItems},
+		{"minContains", &n.minContains}, {"maxContains", &n.maxContains},
+		{"minProperties", &n.minProperties}, {"maxProperties", &n.maxProperties},
+	}
+	for _, count := range counts {
+		if *count.dst, err = c.count(ptr, f, count.key); err != nil {
+			return err
+		}
+	}
+	if p, ok := f["pattern"]; ok {
+		if n.pattern, err = c.regexp(child(ptr, "pattern"), p); err != nil {
+			return err
+		}
+	}
+	if u, ok := f["uniqueItems"]; ok {
+		if protobaggins.KindOf(u) != protobaggins.KindBool {
+			return c.errorf(child(ptr, "uniqueItems"), "must be a boolean")
+		}
+		n.uniqueItems = u.GetBoolValue()
+	}
+	if n.required, err = c.strings(ptr, f, "required"); err != nil {
+		return err
+	}
+
+	subschemas := []struct {
+		key string
+		dst **node
+	}{
+		{"items", &n.items}, {"contains", &n.contains},
+		{"additionalProperties", &n.additionalProperties}, {"propertyNames", &n.propertyNames},
+		{"not", &n.not}, {"if", &n.ifNode}, {"then", &n.thenNode}, {"else", &n.elseNode},
+	}
+	for _, sub := range subschemas {
+		if *sub.dst, err = c.subschema(ptr, f, sub.key); err != nil {
+			return err
+		}
+	}
+	lists := []struct {
+		key string
+		dst *[]*node
+	}{
+		{"prefixItems", &n.prefixItems}, {"allOf", &n.allOf}, {"anyOf", &n.anyOf}, {"oneOf", &n.oneOf},
+	}
+	for _, list := range lists {
+		if *list.dst, err = c.subschemas(ptr, f, list.key); err != nil {
+			return err
+		}
+	}
+	if n.properties, err = c.schemaMap(ptr, f, "properties"); err != nil {
+		return err
+	}
+	if n.dependentSchemas, err = c.schemaMap(ptr, f, "dependentSchemas"); err != nil {
+		return err
+	}
+	if n.patternProperties, err = c.patternProperties(ptr, f); err != nil {
+		return err
+	}
+	return c.dependentRequired(ptr, n, f)
+}
+
+// child returns the pointer to the member key of the schema at ptr
+func child(ptr, key string) string {
+	return ptr + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// resolve compiles the schema referenced by ref, the value of $ref at ptr
+func (c *compiler) resolve(ptr string, ref *structpb.Value) (*node, error) {
+	if protobaggins.KindOf(ref) != protobaggins.KindString {
+		return nil, c.errorf(ptr, "must be a string")
+	}
+	fragment, ok := strings.CutPrefix(ref.GetStringValue(), "#")
+	if !ok {
+		return nil, c.errorf(ptr, "only references within the schema, starting with #, are supported")
+	}
+	target, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, c.errorf(ptr, "invalid reference %q", ref.GetStringValue())
+	}
+	if target != "" && !strings.HasPrefix(target, "/") {
+		return nil, c.errorf(ptr, "anchors are not supported, in %q", ref.GetStringValue())
+	}
+
+	v := c.doc
+	if target != "" {
+		for token := range strings.SplitSeq(target[1:], "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			v = lookup(v, token)
+			if v == nil {
+				return nil, c.errorf(ptr, "reference %q not found", ref.GetStringValue())
+			}
+		}
+	}
+	return c.compile(target, v)
+}
+
+// lookup returns the member or item token of v, or nil
+func lookup(v *structpb.Value, token string) *structpb.Value {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return kind.StructValue.GetFields()[token]
+	case *structpb.Value_ListValue:
+		i, err := strconv.Atoi(token)
+		if err == nil && i >= 0 && i < len(kind.ListValue.GetValues()) {
+			return kind.ListValue.GetValues()[i]
+		}
+	}
+	return nil
+}
+
+var typeNames = []string{"null", "boolean", "object", "array", "number", "string", "integer"}
+
+func (c *compiler) typeNames(ptr string, v *structpb.Value) ([]string, error) {
+	var names []*structpb.Value
+	switch protobaggins.KindOf(v) {
+	case protobaggins.KindString:
+		names = []*structpb.Value{v}
+	case protobaggins.KindList:
+		names = v.GetListValue().GetValues()
+	default:
+		return nil, c.errorf(ptr, "must be a string or an array")
+	}
+	types := make([]string, len(names))
+	for i, name := range names {
+		if !slices.Contains(typeNames, name.GetStringValue()) {
+			return nil, c.errorf(ptr, "unknown type %v", name.AsInterface())
+		}
+		types[i] = name.GetStringValue()
+	}
+	return types, nil
+}
+
+func (c *compiler) number(ptr string, f map[string]*structpb.Value, key string) (*float64, error) {
+	v, ok := f[key]
+	if !ok {
+		return nil, nil
+	}
+	if protobaggins.KindOf(v) != protobaggins.KindNumber {
+		return nil, c.errorf(child(ptr, key), "must be a number")
+	}
+	num := v.GetNumberValue()
+	return &num, nil
+}
+
+func (c *compiler) count(ptr string, f map[string]*structpb.Value, key string) (*int, error) {
+	v, ok := f[key]
+	if !ok {
+		return nil, nil
+	}
+	num := v.GetNumberValue()
+	if protobaggins.KindOf(v) != protobaggins.KindNumber || num < 0 || num != math.Trunc(num) || num > math.MaxInt32 {
+		return nil, c.errorf(child(ptr, key), "must be a non-negative integer")
+	}
+	count := int(num)
+	return &count, nil
+}
+
+func (c *compiler) regexp(ptr string, v *structpb.Value) (*regexp.Regexp, error) {
+	if protobaggins.KindOf(v) != protobaggins.KindString {
+		return nil, c.errorf(ptr, "must be a string")
+	}
+	re, err := regexp.Compile(v.GetStringValue())
+	if err != nil {
+		return nil, c.errorf(ptr, "%v", err)
+	}
+	return re, nil
+}
+
+func (c *compiler) strings(ptr string, f map[string]*structpb.Value, key string) ([]string, error) {
+	v, ok := f[key]
+	if !ok {
+		return nil, nil
+	}
+	if protobaggins.KindOf(v) != protobaggins.KindList {
+		return nil, c.errorf(child(ptr, key), "must be an array of strings")
+	}
+	items := v.GetListValue().GetValues()
+	result := make([]string, len(items))
+	for i, item := range items {
+		if protobaggins.KindOf(item) != protobaggins.KindString {
+			return nil, c.errorf(child(ptr, key), "must be an array of strings")
+		}
+		result[i] = item.GetStringValue()
+	}
+	return result, nil
+}
+
+func (c *compiler) subschema(ptr string, f map[string]*structpb.Value, key string) (*node, error) {
+	v, ok := f[key]
+	if !ok {
+		return nil, nil
+	}
+	return c.compile(child(ptr, key), v)
+}
+
+func (c *compiler) subschemas(ptr string, f map[string]*structpb.Value, key string) ([]*node, error) {
+	v, ok := f[key]
+	if !ok {
+		return nil, nil
+	}
+	items := v.GetListValue().GetValues()
+	if protobaggins.KindOf(v) != protobaggins.KindList || len(items) == 0 {
+		return nil, c.errorf(child(ptr, key), "must be a non-empty array")
+	}
+	nodes := make([]*node, len(items))
+	for i, item := range items {
+		n, err := c.compile(child(child(ptr, key), strconv.Itoa(i)), item)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = n
+	}
+	return nodes, nil
+}
+
+func (c *compiler) schemaMap(ptr string, f map[string]*structpb.Value, key string) (map[string]*node, error) {
+	v, ok := f[key]
+	if !ok {
+		return nil, nil
+	}
+	if protobaggins.KindOf(v) != protobaggins.KindStruct {
+		return nil, c.errorf(child(ptr, key), "must be an object")
+	}
+	nodes := make(map[string]*node, len(v.GetStructValue().GetFields()))
+	for name, sub := range v.GetStructValue().GetFields() {
+		n, err := c.compile(child(child(ptr, key), name), sub)
+		if err != nil {
+			return nil, err
+		}
+		nodes[name] = n
+	}
+	return nodes, nil
+}
+
+func (c *compiler) patternProperties(ptr string, f map[string]*structpb.Value) ([]patternNode, error) {
+	nodes, err := c.schemaMap(ptr, f, "patternProperties")
+	if err != nil || nodes == nil {
+		return nil, err
+	}
+	patterns := make([]patternNode, 0, len(nodes))
+	for _, expr := range slices.Sorted(maps.Keys(nodes)) {
+		re, err := c.regexp(child(child(ptr, "patternProperties"), expr), structpb.NewStringValue(expr))
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, patternNode{pattern: re, schema: nodes[expr]})
+	}
+	return patterns, nil
+}
+
+func (c *compiler) dependentRequired(ptr string, n *node, f map[string]*structpb.Value) error {
+	v, ok := f["dependentRequired"]
+	if !ok {
+		return nil
+	}
+	if protobaggins.KindOf(v) != protobaggins.KindStruct {
+		return c.errorf(child(ptr, "dependentRequired"), "must be an object")
+	}
+	deps := v.GetStructValue().GetFields()
+	n.dependentRequired = make(map[string][]string, len(deps))
+	for name := range deps {
+		required, err := c.strings(child(ptr, "dependentRequired"), deps, name)
+		if err != nil {
+			return err
+		}
+		n.dependentRequired[name] = required
+	}
+	return nil
+}
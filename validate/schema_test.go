@@ -0,0 +1,58 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileSchema(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{
+		`true`,
+		`false`,
+		`{}`,
+		`{"type": ["string", "null"], "minLength": 1, "pattern": "^a"}`,
+		`{"$defs": {"node": {"properties": {"next": {"$ref": "#/$defs/node"}}}}, "$ref": "#/$defs/node"}`,
+		`{"items": {"$ref": "#"}}`,
+		`{"prefixItems": [{"type": "integer"}], "contains": {"const": 1}, "maxContains": 2}`,
+		`{"patternProperties": {"^x-": true}, "additionalProperties": false, "format": "email"}`,
+		`{"$defs": {"a~b/c": {}}, "$ref": "#/$defs/a~0b~1c"}`,
+		`{"allOf": [{"$ref": "#/allOf/1"}, {"type": "object"}]}`,
+	}
+	for _, schema := range valid {
+		t.Run(schema, func(t *testing.T) {
+			t.Parallel()
+			_, err := CompileSchema([]byte(schema))
+			assert.NoError(t, err)
+		})
+	}
+
+	invalid := []struct {
+		schema string
+		msg    string
+	}{
+		{`{`, ""},
+		{`1`, `"#": schema must be an object or a boolean, got number`},
+		{`{"type": "text"}`, `"#/type": unknown type text`},
+		{`{"minLength": -1}`, `"#/minLength": must be a non-negative integer`},
+		{`{"multipleOf": 0}`, `"#/multipleOf": must be greater than 0`},
+		{`{"pattern": "("}`, `"#/pattern": `},
+		{`{"properties": {"a": {"required": "a"}}}`, `"#/properties/a/required": must be an array of strings`},
+		{`{"anyOf": []}`, `"#/anyOf": must be a non-empty array`},
+		{`{"$ref": "other.json"}`, `"#/$ref": only references within the schema`},
+		{`{"$ref": "#/$defs/missing"}`, `"#/$ref": reference "#/$defs/missing" not found`},
+		{`{"$ref": "#anchor"}`, `anchors are not supported`},
+		{`{"unevaluatedProperties": false}`, `unsupported keyword "unevaluatedProperties"`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.schema, func(t *testing.T) {
+			t.Parallel()
+			_, err := CompileSchema([]byte(tt.schema))
+			require.ErrorIs(t, err, ErrInvalidSchema)
+			assert.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
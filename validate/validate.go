@@ -0,0 +1,315 @@
+package validate
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/robbyt/protobaggins"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxDepth bounds the nesting of schemas applied to a value, so that schemas that
+// reference themselves without descending into the value cannot recurse forever
+const maxDepth = 10000
+
+// Violation is a way in which a value does not satisfy a schema
+type Violation struct {
+	// Path locates the offending value, in the notation of protobaggins.LookupPath,
+	// e.g. "items[3].name". It is empty for the validated value itself
+	Path string
+	// Keyword is the schema keyword that failed, e.g. "minimum"
+	Keyword string
+	// Message describes the failure
+	Message string
+}
+
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + v.Message
+}
+
+// ValidationError reports every violation found by Validate
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = v.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks v against the schema, returning a *ValidationError listing every
+// violation, in the order of the value with object members sorted by key, or nil if
+// v is valid. A nil value is validated as null
+func (s *Schema) Validate(v *structpb.Value) error {
+	if violations := s.root.check("", v, 0); len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// ValidateStruct is Validate for a Struct
+func (s *Schema) ValidateStruct(st *structpb.Struct) error {
+	return s.Validate(structpb.NewStructValue(st))
+}
+
+func violation(path, keyword, format string, args ...any) []Violation {
+	return []Violation{{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)}}
+}
+
+// valid reports whether v satisfies n, for the keywords that only need to know that
+func (n *node) valid(path string, v *structpb.Value, depth int) bool {
+	return len(n.check(path, v, depth)) == 0
+}
+
+// check returns the violations of n by v, the value at path
+func (n *node) check(path string, v *structpb.Value, depth int) []Violation {
+	if depth > maxDepth {
+		return violation(path, "$ref", "schema nesting exceeds %d levels", maxDepth)
+	}
+	depth++
+	if n.never {
+		return violation(path, "false", "no value is allowed")
+	}
+
+	var out []Violation
+	if n.ref != nil {
+		out = append(out, n.ref.check(path, v, depth)...)
+	}
+	if len(n.types) > 0 && !slices.ContainsFunc(n.types, func(t string) bool { return hasType(v, t) }) {
+		out = append(out, violation(path, "type", "expected %s, got %s", strings.Join(n.types, " or "), typeOf(v))...)
+	}
+	if n.enum != nil && !slices.ContainsFunc(n.enum, func(e *structpb.Value) bool { return protobaggins.Equal(e, v) }) {
+		out = append(out, violation(path, "enum", "value is not one of the allowed values")...)
+	}
+	if n.constant != nil && !protobaggins.Equal(n.constant, v) {
+		out = append(out, violation(path, "const", "value does not equal the constant")...)
+	}
+
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		out = append(out, n.checkNumber(path, kind.NumberValue)...)
+	case *structpb.Value_StringValue:
+		out = append(out, n.checkString(path, kind.StringValue)...)
+	case *structpb.Value_ListValue:
+		out = append(out, n.checkArray(path, kind.ListValue.GetValues(), depth)...)
+	case *structpb.Value_StructValue:
+		out = append(out, n.checkObject(path, kind.StructValue.GetFields(), depth)...)
+	}
+	return append(out, n.checkApplicators(path, v, depth)...)
+}
+
+// hasType reports whether v is an instance of the JSON Schema type t
+func hasType(v *structpb.Value, t string) bool {
+	switch t {
+	case "integer":
+		f := v.GetNumberValue()
+		return protobaggins.KindOf(v) == protobaggins.KindNumber && f == math.Trunc(f) && !math.IsInf(f, 0)
+	case "number":
+		return protobaggins.KindOf(v) == protobaggins.KindNumber
+	default:
+		return typeOf(v) == t
+	}
+}
+
+// typeOf names the JSON type of v, treating nil as null
+func typeOf(v *structpb.Value) string {
+	switch protobaggins.KindOf(v) {
+	case protobaggins.KindBool:
+		return "boolean"
+	case protobaggins.KindNumber:
+		return "number"
+	case protobaggins.KindString:
+		return "string"
+	case protobaggins.KindList:
+		return "array"
+	case protobaggins.KindStruct:
+		return "object"
+	default:
+		return "null"
+	}
+}
+
+func (n *node) checkNumber(path string, f float64) []Violation {
+	var out []Violation
+	if n.minimum != nil && f < *n.minimum {
+		out = append(out, violation(path, "minimum", "%v is less than the minimum of %v", f, *n.minimum)...)
+	}
+	if n.maximum != nil && f > *n.maximum {
+		out = append(out, violation(path, "maximum", "%v is greater than the maximum of %v", f, *n.maximum)...)
+	}
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		out = append(out, violation(path, "exclusiveMinimum", "%v is not greater than %v", f, *n.exclusiveMinimum)...)
+	}
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		out = append(out, violation(path, "exclusiveMaximum", "%v is not less than %v", f, *n.exclusiveMaximum)...)
+	}
+	if n.multipleOf != nil {
+		// tolerate the rounding of decimal fractions, so that 0.3 is a multiple of 0.1
+		q := f / *n.multipleOf
+		if math.IsInf(q, 0) || math.IsNaN(q) || math.Abs(q-math.Round(q)) > 1e-9*max(1, math.Abs(q)) {
+			out = append(out, violation(path, "multipleOf", "%v is not a multiple of %v", f, *n.multipleOf)...)
+		}
+	}
+	return out
+}
+
+func (n *node) checkString(path, s string) []Violation {
+	var out []Violation
+	length := utf8.RuneCountInString(s)
+	if n.minLength != nil && length < *n.minLength {
+		out = append(out, violation(path, "minLength", "length %d is less than %d", length, *n.minLength)...)
+	}
+	if n.maxLength != nil && length > *n.maxLength {
+		out = append(out, violation(path, "maxLength", "length %d is greater than %d", length, *n.maxLength)...)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		out = append(out, violation(path, "pattern", "%q does not match %q", s, n.pattern)...)
+	}
+	return out
+}
+
+func (n *node) checkArray(path string, items []*structpb.Value, depth int) []Violation {
+	var out []Violation
+	if n.minItems != nil && len(items) < *n.minItems {
+		out = append(out, violation(path, "minItems", "%d items are fewer than %d", len(items), *n.minItems)...)
+	}
+	if n.maxItems != nil && len(items) > *n.maxItems {
+		out = append(out, violation(path, "maxItems", "%d items are more than %d", len(items), *n.maxItems)...)
+	}
+	if n.uniqueItems {
+	unique:
+		for i := range items {
+			for j := range i {
+				if protobaggins.Equal(items[i], items[j]) {
+					out = append(out, violation(path, "uniqueItems", "items %d and %d are equal", j, i)...)
+					break unique
+				}
+			}
+		}
+	}
+
+	for i, item := range items {
+		itemPath := protobaggins.JoinPathIndex(path, i)
+		switch {
+		case i < len(n.prefixItems):
+			out = append(out, n.prefixItems[i].check(itemPath, item, depth)...)
+		case n.items != nil:
+			out = append(out, n.items.check(itemPath, item, depth)...)
+		}
+	}
+
+	if n.contains != nil {
+		matches := 0
+		for i, item := range items {
+			if n.contains.valid(protobaggins.JoinPathIndex(path, i), item, depth) {
+				matches++
+			}
+		}
+		minContains := 1
+		if n.minContains != nil {
+			minContains = *n.minContains
+		}
+		if matches < minContains {
+			out = append(out, violation(path, "contains", "%d items match the contains schema, fewer than %d", matches, minContains)...)
+		}
+		if n.maxContains != nil && matches > *n.maxContains {
+			out = append(out, violation(path, "maxContains", "%d items match the contains schema, more than %d", matches, *n.maxContains)...)
+		}
+	}
+	return out
+}
+
+func (n *node) checkObject(path string, fields map[string]*structpb.Value, depth int) []Violation {
+	var out []Violation
+	if n.minProperties != nil && len(fields) < *n.minProperties {
+		out = append(out, violation(path, "minProperties", "%d properties are fewer than %d", len(fields), *n.minProperties)...)
+	}
+	if n.maxProperties != nil && len(fields) > *n.maxProperties {
+		out = append(out, violation(path, "maxProperties", "%d properties are more than %d", len(fields), *n.maxProperties)...)
+	}
+	for _, name := range n.required {
+		if _, ok := fields[name]; !ok {
+			out = append(out, violation(path, "required", "missing required property %q", name)...)
+		}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		fieldPath := protobaggins.JoinPathKey(path, k)
+		v := fields[k]
+		if n.propertyNames != nil && !n.propertyNames.valid(fieldPath, structpb.NewStringValue(k), depth) {
+			out = append(out, violation(fieldPath, "propertyNames", "property name %q is not allowed", k)...)
+		}
+		for _, name := range n.dependentRequired[k] {
+			if _, ok := fields[name]; !ok {
+				out = append(out, violation(path, "dependentRequired", "property %q requires property %q", k, name)...)
+			}
+		}
+		if dep, ok := n.dependentSchemas[k]; ok {
+			out = append(out, dep.check(path, structpb.NewStructValue(&structpb.Struct{Fields: fields}), depth)...)
+		}
+
+		matched := false
+		if prop, ok := n.properties[k]; ok {
+			matched = true
+			out = append(out, prop.check(fieldPath, v, depth)...)
+		}
+		for _, pp := range n.patternProperties {
+			if pp.pattern.MatchString(k) {
+				matched = true
+				out = append(out, pp.schema.check(fieldPath, v, depth)...)
+			}
+		}
+		if !matched && n.additionalProperties != nil {
+			if n.additionalProperties.never {
+				out = append(out, violation(fieldPath, "additionalProperties", "property %q is not allowed", k)...)
+			} else {
+				out = append(out, n.additionalProperties.check(fieldPath, v, depth)...)
+			}
+		}
+	}
+	return out
+}
+
+func (n *node) checkApplicators(path string, v *structpb.Value, depth int) []Violation {
+	var out []Violation
+	for _, sub := range n.allOf {
+		out = append(out, sub.check(path, v, depth)...)
+	}
+	if n.anyOf != nil && !slices.ContainsFunc(n.anyOf, func(sub *node) bool { return sub.valid(path, v, depth) }) {
+		out = append(out, violation(path, "anyOf", "value matches none of the anyOf schemas")...)
+	}
+	if n.oneOf != nil {
+		matches := 0
+		for _, sub := range n.oneOf {
+			if sub.valid(path, v, depth) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			out = append(out, violation(path, "oneOf", "value matches %d of the oneOf schemas, not exactly one", matches)...)
+		}
+	}
+	if n.not != nil && n.not.valid(path, v, depth) {
+		out = append(out, violation(path, "not", "value matches the not schema")...)
+	}
+	if n.ifNode != nil {
+		switch {
+		case n.ifNode.valid(path, v, depth) && n.thenNode != nil:
+			out = append(out, n.thenNode.check(path, v, depth)...)
+		case !n.ifNode.valid(path, v, depth) && n.elseNode != nil:
+			out = append(out, n.elseNode.check(path, v, depth)...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	mustValue := func(t *testing.T, v any) *structpb.Value {
+		t.Helper()
+		pbValue, err := structpb.NewValue(v)
+		require.NoError(t, err)
+		return pbValue
+	}
+	violations := func(t *testing.T, schema string, v any) []Violation {
+		t.Helper()
+		s, err := CompileSchema([]byte(schema))
+		require.NoError(t, err)
+		err = s.Validate(mustValue(t, v))
+		if err == nil {
+			return nil
+		}
+		var ve *ValidationError
+		require.ErrorAs(t, err, &ve)
+		return ve.Violations
+	}
+	keywords := func(vs []Violation) []string {
+		result := make([]string, len(vs))
+		for i, v := range vs {
+			result[i] = v.Path + " " + v.Keyword
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		schema string
+		value  any
+		want   []string
+	}{
+		{"true", `true`, 1, nil},
+		{"false", `false`, 1, []string{" false"}},
+		{"type", `{"type": "string"}`, 1, []string{" type"}},
+		{"integer", `{"type": "integer"}`, 1.0, nil},
+		{"not an integer", `{"type": "integer"}`, 1.5, []string{" type"}},
+		{"nullable", `{"type": ["string", "null"]}`, nil, nil},
+		{"enum", `{"enum": ["a", 1, {"b": [true]}]}`, map[string]any{"b": []any{true}}, nil},
+		{"not in enum", `{"enum": ["a", 1]}`, "b", []string{" enum"}},
+		{"const", `{"const": 1}`, 2, []string{" const"}},
+		{"numbers", `{"minimum": 1, "exclusiveMaximum": 3, "multipleOf": 0.1}`, 0.3, []string{" minimum"}},
+		{"number bounds", `{"maximum": 1, "exclusiveMinimum": 1, "multipleOf": 2}`, 1, []string{" exclusiveMinimum", " multipleOf"}},
+		{"strings", `{"minLength": 2, "maxLength": 3, "pattern": "^[a-z]+$"}`, "é", []string{" minLength", " pattern"}},
+		{"code points", `{"maxLength": 1}`, "é", nil},
+		{"arrays", `{"minItems": 3, "uniqueItems": true, "items": {"type": "integer"}}`, []any{1, "a", 1}, []string{" uniqueItems", "[1] type"}},
+		{"prefix items", `{"prefixItems": [{"type": "string"}], "items": false}`, []any{"a", 1}, []string{"[1] false"}},
+		{"contains", `{"contains": {"type": "string"}, "maxContains": 1}`, []any{"a", "b"}, []string{" maxContains"}},
+		{"contains none", `{"contains": {"type": "string"}}`, []any{1}, []string{" contains"}},
+		{"min contains zero", `{"contains": {"type": "string"}, "minContains": 0}`, []any{1}, nil},
+		{
+			"objects",
+			`{"required": ["a", "z"], "properties": {"a": {"type": "string"}}, "patternProperties": {"^x-": {"type": "integer"}}, "additionalProperties": false}`,
+			map[string]any{"a": 1, "x-b": "c", "other": 1},
+			[]string{" required", "a type", "other additionalProperties", "x-b type"},
+		},
+		{"property names", `{"propertyNames": {"maxLength": 2}, "maxProperties": 1}`, map[string]any{"abc": 1, "d": 2}, []string{" maxProperties", "abc propertyNames"}},
+		{"dependent required", `{"dependentRequired": {"card": ["billing"]}}`, map[string]any{"card": 1}, []string{" dependentRequired"}},
+		{"dependent schemas", `{"dependentSchemas": {"card": {"required": ["billing"]}}}`, map[string]any{"card": 1}, []string{" required"}},
+		{"all of", `{"allOf": [{"minimum": 2}, {"maximum": 0}]}`, 1, []string{" minimum", " maximum"}},
+		{"any of", `{"anyOf": [{"type": "string"}, {"minimum": 2}]}`, 1, []string{" anyOf"}},
+		{"one of", `{"oneOf": [{"type": "integer"}, {"minimum": 0}]}`, 1, []string{" oneOf"}},
+		{"not", `{"not": {"type": "integer"}}`, 1, []string{" not"}},
+		{"if then", `{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`, 15, []string{" multipleOf"}},
+		{"if else", `{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`, 7, []string{" maximum"}},
+		{
+			"references",
+			`{"$defs": {"node": {"type": "object", "properties": {"value": {"type": "integer"}, "next": {"$ref": "#/$defs/node"}}}}, "$ref": "#/$defs/node"}`,
+			map[string]any{"value": 1, "next": map[string]any{"value": "2", "next": map[string]any{"value": 3}}},
+			[]string{"next.value type"},
+		},
+		{"recursion without descending", `{"$ref": "#"}`, 1, []string{" $ref"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := keywords(violations(t, tt.schema, tt.value))
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		s, err := CompileSchema([]byte(`{"type": "object", "properties": {"items": {"items": {"type": "string"}}}}`))
+		require.NoError(t, err)
+		st, err := structpb.NewStruct(map[string]any{"items": []any{"a", 2, true}})
+		require.NoError(t, err)
+		err = s.ValidateStruct(st)
+		require.EqualError(t, err, "items[1]: expected string, got number\nitems[2]: expected string, got boolean")
+
+		require.EqualError(t, s.Validate(nil), "(root): expected object, got null")
+		require.NoError(t, s.Validate(mustValue(t, map[string]any{})))
+	})
+}
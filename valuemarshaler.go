@@ -0,0 +1,57 @@
+package protobaggins
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ValueMarshaler is implemented by types that convert themselves to a *structpb.Value
+// Every conversion to protocol buffer values honors it, at any depth, unless a converter
+// for the type was given with WithValueConverter or RegisterValueConverter
+type ValueMarshaler interface {
+	MarshalStructValue() (*structpb.Value, error)
+}
+
+// ValueUnmarshaler is implemented by types that populate themselves from a
+// *structpb.Value. DecodeStruct honors it for fields, map values and list items, except
+// for null values, which clear pointers as usual and leave other values unchanged
+type ValueUnmarshaler interface {
+	UnmarshalStructValue(v *structpb.Value) error
+}
+
+var (
+	valueMarshalerType   = reflect.TypeFor[ValueMarshaler]()
+	valueUnmarshalerType = reflect.TypeFor[ValueUnmarshaler]()
+)
+
+// marshalValue calls m, encoding nil pointers as null like encoding/json does
+func marshalValue(m ValueMarshaler) (*structpb.Value, error) {
+	if isNilPointer(m) {
+		return structpb.NewNullValue(), nil
+	}
+	pbValue, err := m.MarshalStructValue()
+	if err != nil {
+		return nil, fmt.Errorf("%T: %w", m, err)
+	}
+	if pbValue == nil {
+		return structpb.NewNullValue(), nil
+	}
+	return pbValue, nil
+}
+
+// reflectValueMarshaler returns the ValueMarshaler implemented by rv or, when rv is
+// addressable, by a pointer to it
+func reflectValueMarshaler(rv reflect.Value) (ValueMarshaler, bool) {
+	if !rv.CanInterface() {
+		return nil, false
+	}
+	if rv.Type().Implements(valueMarshalerType) {
+		return rv.Interface().(ValueMarshaler), true
+	}
+	if rv.CanAddr() && reflect.PointerTo(rv.Type()).Implements(valueMarshalerType) {
+		return rv.Addr().Interface().(ValueMarshaler), true
+	}
+	return nil, false
+}
@@ -0,0 +1,135 @@
+package protobaggins
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// testMoney marshals to a struct of units and currency, or from a string like "12 EUR"
+type testMoney struct {
+	Units    int
+	Currency string
+}
+
+func (m testMoney) MarshalStructValue() (*structpb.Value, error) {
+	if m.Currency == "" {
+		return nil, errors.New("missing currency")
+	}
+	return structpb.NewStringValue(fmt.Sprintf("%d %s", m.Units, m.Currency)), nil
+}
+
+func (m *testMoney) UnmarshalStructValue(v *structpb.Value) error {
+	_, err := fmt.Sscanf(v.GetStringValue(), "%d %s", &m.Units, &m.Currency)
+	return err
+}
+
+// testLevel implements ValueMarshaler on its pointer only
+type testLevel struct{ name string }
+
+func (l *testLevel) MarshalStructValue() (*structpb.Value, error) {
+	return structpb.NewStringValue(strings.ToUpper(l.name)), nil
+}
+
+func TestValueMarshaler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("conversion functions", func(t *testing.T) {
+		t.Parallel()
+		price := testMoney{Units: 12, Currency: "EUR"}
+		assert.Equal(t, "12 EUR", TryNewStructValue(price).GetStringValue())
+		assert.Equal(t, "12 EUR", TryNewStructValue(&price).GetStringValue())
+
+		values := MapToStructValues(map[string]any{"price": price, "bad": testMoney{}})
+		assert.Equal(t, "12 EUR", values["price"].GetStringValue())
+		assert.NotContains(t, values, "bad")
+
+		_, err := NewValue([]any{testMoney{}})
+		require.ErrorContains(t, err, "protobaggins.testMoney: missing currency")
+
+		v, err := NewValue(map[string]any{"nil": (*testMoney)(nil)})
+		require.NoError(t, err)
+		assert.Equal(t, KindNull, KindOf(v.GetStructValue().GetFields()["nil"]))
+	})
+
+	t.Run("encode struct", func(t *testing.T) {
+		t.Parallel()
+		type order struct {
+			Price  testMoney
+			Prices map[string]testMoney
+			Level  testLevel
+		}
+		s, err := EncodeStruct(&order{
+			Price:  testMoney{Units: 3, Currency: "USD"},
+			Prices: map[string]testMoney{"a": {Units: 1, Currency: "GBP"}},
+			Level:  testLevel{name: "debug"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"Price":  "3 USD",
+			"Prices": map[string]any{"a": "1 GBP"},
+			"Level":  "DEBUG",
+		}, s.AsMap())
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		t.Parallel()
+		v, err := NewValue(testMoney{Units: 1, Currency: "EUR"}, WithValueConverter(
+			reflect.TypeFor[testMoney](), func(any) (*structpb.Value, error) {
+				return structpb.NewNumberValue(1), nil
+			}))
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, v.GetNumberValue(), 0)
+	})
+}
+
+func TestValueUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	type order struct {
+		Price   testMoney
+		Refund  *testMoney
+		History []testMoney
+		ByName  map[string]testMoney
+	}
+	s, err := structpb.NewStruct(map[string]any{
+		"Price":   "12 EUR",
+		"Refund":  "3 EUR",
+		"History": []any{"1 USD", "2 GBP"},
+		"ByName":  map[string]any{"a": "5 JPY"},
+	})
+	require.NoError(t, err)
+
+	var out order
+	require.NoError(t, DecodeStruct(s, &out))
+	assert.Equal(t, order{
+		Price:   testMoney{Units: 12, Currency: "EUR"},
+		Refund:  &testMoney{Units: 3, Currency: "EUR"},
+		History: []testMoney{{1, "USD"}, {2, "GBP"}},
+		ByName:  map[string]testMoney{"a": {5, "JPY"}},
+	}, out)
+
+	t.Run("null clears pointers", func(t *testing.T) {
+		t.Parallel()
+		out := order{Refund: &testMoney{}}
+		require.NoError(t, DecodeStruct(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"Refund": structpb.NewNullValue(),
+		}}, &out))
+		assert.Nil(t, out.Refund)
+	})
+
+	t.Run("errors carry the path", func(t *testing.T) {
+		t.Parallel()
+		var out order
+		err := DecodeStruct(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"Price": structpb.NewBoolValue(true),
+		}}, &out)
+		require.ErrorContains(t, err, "Price: ")
+	})
+}
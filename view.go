@@ -0,0 +1,140 @@
+package protobaggins
+
+import (
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// View is a read-only view over a *structpb.Value that converts nothing until asked
+// Unlike StructValuesToMap, which copies the whole tree, navigating a View costs no
+// allocations, so it suits hot paths that read a few fields of large payloads, e.g.
+//
+//	image, ok := NewStructView(s).Get("spec").Get("containers").Index(0).Get("image").AsString()
+//
+// Navigating to a missing key or index, or through a value of the wrong kind, gives
+// the zero View, which does not exist and on which every accessor fails
+type View struct {
+	v *structpb.Value
+}
+
+// NewView returns a View over v
+func NewView(v *structpb.Value) View {
+	return View{v: v}
+}
+
+// NewStructView returns a View over s
+func NewStructView(s *structpb.Struct) View {
+	if s == nil {
+		return View{}
+	}
+	return View{v: structpb.NewStructValue(s)}
+}
+
+// Exists reports whether the view refers to a value
+func (w View) Exists() bool {
+	return w.v != nil
+}
+
+// Kind returns the kind of the value, KindUnset if it does not exist
+func (w View) Kind() Kind {
+	return KindOf(w.v)
+}
+
+// Value returns the underlying value, nil if it does not exist. It must not be modified
+func (w View) Value() *structpb.Value {
+	return w.v
+}
+
+// Get returns a view of the value at key in a Struct
+func (w View) Get(key string) View {
+	return View{v: w.v.GetStructValue().GetFields()[key]}
+}
+
+// Index returns a view of the item at index i in a list
+func (w View) Index(i int) View {
+	items := w.v.GetListValue().GetValues()
+	if i < 0 || i >= len(items) {
+		return View{}
+	}
+	return View{v: items[i]}
+}
+
+// Path returns a view of the value at path, in the notation described at PathFilter
+func (w View) Path(path string) View {
+	found, ok := GetPath(w.v, path)
+	if !ok {
+		return View{}
+	}
+	return View{v: found}
+}
+
+// Len returns the number of items in a list or fields in a Struct, and 0 otherwise
+func (w View) Len() int {
+	switch kind := w.v.GetKind().(type) {
+	case *structpb.Value_ListValue:
+		return len(kind.ListValue.GetValues())
+	case *structpb.Value_StructValue:
+		return len(kind.StructValue.GetFields())
+	default:
+		return 0
+	}
+}
+
+// Keys returns the keys of a Struct in sorted order, and nil otherwise
+func (w View) Keys() []string {
+	s := w.v.GetStructValue()
+	if s == nil {
+		return nil
+	}
+	return sortedKeys(s)
+}
+
+// AsString returns the value if it is a string
+func (w View) AsString() (string, bool) {
+	s, ok := w.v.GetKind().(*structpb.Value_StringValue)
+	if !ok {
+		return "", false
+	}
+	return s.StringValue, true
+}
+
+// AsFloat returns the value if it is a number
+func (w View) AsFloat() (float64, bool) {
+	n, ok := w.v.GetKind().(*structpb.Value_NumberValue)
+	if !ok {
+		return 0, false
+	}
+	return n.NumberValue, true
+}
+
+// AsInt returns the value if it is a whole number within the range of int64
+func (w View) AsInt() (int64, bool) {
+	f, ok := w.AsFloat()
+	if !ok {
+		return 0, false
+	}
+	i, err := floatToInt64(f)
+	return i, err == nil
+}
+
+// AsBool returns the value if it is a bool
+func (w View) AsBool() (bool, bool) {
+	b, ok := w.v.GetKind().(*structpb.Value_BoolValue)
+	if !ok {
+		return false, false
+	}
+	return b.BoolValue, true
+}
+
+// IsNull reports whether the value is null
+func (w View) IsNull() bool {
+	return w.Kind() == KindNull
+}
+
+// Interface converts the value and everything within it to Go values like
+// AsInterface does, and returns nil if it does not exist
+func (w View) Interface() any {
+	if w.v == nil {
+		return nil
+	}
+	return w.v.AsInterface()
+}
@@ -0,0 +1,83 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestView(t *testing.T) {
+	t.Parallel()
+
+	s, err := structpb.NewStruct(map[string]any{
+		"spec": map[string]any{
+			"replicas": 3,
+			"paused":   false,
+			"ratio":    0.5,
+			"containers": []any{
+				map[string]any{"image": "nginx", "args": nil},
+			},
+		},
+	})
+	require.NoError(t, err)
+	root := NewStructView(s)
+
+	t.Run("navigation", func(t *testing.T) {
+		t.Parallel()
+		image, ok := root.Get("spec").Get("containers").Index(0).Get("image").AsString()
+		require.True(t, ok)
+		assert.Equal(t, "nginx", image)
+
+		replicas, ok := root.Path("spec.replicas").AsInt()
+		require.True(t, ok)
+		assert.Equal(t, int64(3), replicas)
+
+		_, ok = root.Path("spec.ratio").AsInt()
+		assert.False(t, ok, "not a whole number")
+		ratio, ok := root.Path("spec.ratio").AsFloat()
+		require.True(t, ok)
+		assert.InDelta(t, 0.5, ratio, 0)
+
+		paused, ok := root.Get("spec").Get("paused").AsBool()
+		require.True(t, ok)
+		assert.False(t, paused)
+
+		assert.True(t, root.Path("spec.containers[0].args").IsNull())
+		assert.Equal(t, KindList, root.Path("spec.containers").Kind())
+		assert.Equal(t, 1, root.Path("spec.containers").Len())
+		assert.Equal(t, 4, root.Get("spec").Len())
+		assert.Equal(t, []string{"containers", "paused", "ratio", "replicas"}, root.Get("spec").Keys())
+		assert.Same(t, s.Fields["spec"], root.Get("spec").Value())
+	})
+
+	t.Run("missing values", func(t *testing.T) {
+		t.Parallel()
+		for _, missing := range []View{
+			root.Get("nope").Get("deeper"),
+			root.Get("spec").Index(0),
+			root.Path("spec.containers").Index(5),
+			root.Path("spec.containers").Index(-1),
+			root.Path("spec["),
+			NewView(nil),
+			NewStructView(nil),
+		} {
+			assert.False(t, missing.Exists())
+			assert.Equal(t, KindUnset, missing.Kind())
+			assert.Zero(t, missing.Len())
+			assert.Nil(t, missing.Keys())
+			assert.Nil(t, missing.Interface())
+			_, ok := missing.AsString()
+			assert.False(t, ok)
+		}
+		_, ok := root.Get("spec").AsString()
+		assert.False(t, ok, "wrong kind")
+	})
+
+	t.Run("interface converts on demand", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, []any{map[string]any{"image": "nginx", "args": nil}}, root.Path("spec.containers").Interface())
+	})
+
+}
@@ -0,0 +1,122 @@
+package protobaggins
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrSkipChildren can be returned by a Walk callback to not descend into the current
+// value. It is ignored in post-order, where the children were already visited
+var ErrSkipChildren = errors.New("skip children")
+
+// ErrStopWalk can be returned by a Walk callback to stop the walk without an error
+var ErrStopWalk = errors.New("stop walk")
+
+// WalkOption configures Walk
+type WalkOption func(*walkOptions)
+
+type walkOptions struct {
+	postOrder bool
+}
+
+// WalkPostOrder visits the contents of a struct or list before the container itself
+func WalkPostOrder() WalkOption {
+	return func(o *walkOptions) {
+		o.postOrder = true
+	}
+}
+
+// Walk calls fn for v and every value nested in it, in pre-order unless WalkPostOrder
+// is given. Struct keys are visited in sorted order and list items by index.
+// The path holds the struct keys and decimal list indexes leading to the value, empty
+// for v itself. It is reused between calls, so fn must copy it to retain it.
+// The walk stops at the first error returned by fn, which Walk returns unless it is
+// ErrStopWalk. A nil v is not visited
+func Walk(v *structpb.Value, fn func(path []string, v *structpb.Value) error, opts ...WalkOption) error {
+	var o walkOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	w := walker{fn: fn, postOrder: o.postOrder}
+	if err := w.walk(v); err != nil && !errors.Is(err, ErrStopWalk) {
+		return err
+	}
+	return nil
+}
+
+type walker struct {
+	fn        func(path []string, v *structpb.Value) error
+	postOrder bool
+	path      []string
+}
+
+func (w *walker) walk(v *structpb.Value) error {
+	if v == nil {
+		return nil
+	}
+	if !w.postOrder {
+		if err := w.fn(slices.Clip(w.path), v); err != nil {
+			if errors.Is(err, ErrSkipChildren) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		for _, key := range sortedKeys(kind.StructValue) {
+			if err := w.child(key, fields[key]); err != nil {
+				return err
+			}
+		}
+	case *structpb.Value_ListValue:
+		for i, item := range kind.ListValue.GetValues() {
+			if err := w.child(strconv.Itoa(i), item); err != nil {
+				return err
+			}
+		}
+	}
+
+	if w.postOrder {
+		if err := w.fn(slices.Clip(w.path), v); err != nil && !errors.Is(err, ErrSkipChildren) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *walker) child(elem string, v *structpb.Value) error {
+	w.path = append(w.path, elem)
+	err := w.walk(v)
+	w.path = w.path[:len(w.path)-1]
+	return err
+}
+
+// walkValue visits v and all of its descendants in pre-order, passing each node's path
+func walkValue(path string, v *structpb.Value, fn func(path string, v *structpb.Value)) {
+	if v == nil {
+		return
+	}
+	fn(path, v)
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		walkStruct(path, kind.StructValue, fn)
+	case *structpb.Value_ListValue:
+		for i, item := range kind.ListValue.GetValues() {
+			walkValue(joinIndex(path, i), item, fn)
+		}
+	}
+}
+
+// walkStruct visits every field of s and their descendants in pre-order
+func walkStruct(path string, s *structpb.Struct, fn func(path string, v *structpb.Value)) {
+	for key, field := range s.GetFields() {
+		walkValue(joinKey(path, key), field, fn)
+	}
+}
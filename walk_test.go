@@ -0,0 +1,125 @@
+package protobaggins
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestWalkValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil value", func(t *testing.T) {
+		t.Parallel()
+		called := false
+		walkValue("", nil, func(string, *structpb.Value) { called = true })
+		assert.False(t, called)
+	})
+
+	t.Run("visits every node with its path", func(t *testing.T) {
+		t.Parallel()
+		v, err := structpb.NewValue(map[string]any{
+			"name": "frodo",
+			"items": []any{
+				"ring",
+				map[string]any{"kind": "sword"},
+			},
+		})
+		require.NoError(t, err)
+
+		var paths []string
+		walkValue("", v, func(path string, _ *structpb.Value) {
+			paths = append(paths, path)
+		})
+
+		assert.ElementsMatch(t, []string{
+			"", "name", "items", "items[0]", "items[1]", "items[1].kind",
+		}, paths)
+	})
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	v, err := structpb.NewValue(map[string]any{
+		"name":  "frodo",
+		"items": []any{"ring", map[string]any{"kind": "sword"}},
+	})
+	require.NoError(t, err)
+
+	collect := func(t *testing.T, fn func(path []string) error, opts ...WalkOption) ([]string, error) {
+		t.Helper()
+		var paths []string
+		err := Walk(v, func(path []string, _ *structpb.Value) error {
+			paths = append(paths, strings.Join(path, "/"))
+			return fn(path)
+		}, opts...)
+		return paths, err
+	}
+	none := func([]string) error { return nil }
+
+	t.Run("pre-order", func(t *testing.T) {
+		t.Parallel()
+		paths, err := collect(t, none)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", "items", "items/0", "items/1", "items/1/kind", "name"}, paths)
+	})
+
+	t.Run("post-order", func(t *testing.T) {
+		t.Parallel()
+		paths, err := collect(t, none, WalkPostOrder())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"items/0", "items/1/kind", "items/1", "items", "name", ""}, paths)
+	})
+
+	t.Run("skip children", func(t *testing.T) {
+		t.Parallel()
+		paths, err := collect(t, func(path []string) error {
+			if len(path) == 1 && path[0] == "items" {
+				return ErrSkipChildren
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", "items", "name"}, paths)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		t.Parallel()
+		paths, err := collect(t, func(path []string) error {
+			if len(path) == 2 {
+				return ErrStopWalk
+			}
+			return nil
+		}, WalkPostOrder())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"items/0"}, paths)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		boom := errors.New("boom")
+		paths, err := collect(t, func(path []string) error {
+			if len(path) == 3 {
+				return boom
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, boom)
+		assert.Equal(t, []string{"", "items", "items/0", "items/1", "items/1/kind"}, paths)
+	})
+
+	t.Run("nil value", func(t *testing.T) {
+		t.Parallel()
+		called := false
+		require.NoError(t, Walk(nil, func([]string, *structpb.Value) error {
+			called = true
+			return nil
+		}))
+		assert.False(t, called)
+	})
+}
@@ -0,0 +1,446 @@
+package protobaggins
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Field numbers of google.protobuf.Struct, its map entries, Value and ListValue
+const (
+	wireStructFields protowire.Number = 1
+	wireEntryKey     protowire.Number = 1
+	wireEntryValue   protowire.Number = 2
+	wireNullValue    protowire.Number = 1
+	wireNumberValue  protowire.Number = 2
+	wireStringValue  protowire.Number = 3
+	wireBoolValue    protowire.Number = 4
+	wireStructValue  protowire.Number = 5
+	wireListValue    protowire.Number = 6
+	wireListValues   protowire.Number = 1
+)
+
+// wireFlushSize is the amount of output a WireEncoder buffers before writing it
+const wireFlushSize = 32 << 10
+
+// WireEncoder writes Go maps and slices in the protocol buffer wire format of
+// google.protobuf.Struct and ListValue without building *structpb.Value trees, for
+// payloads too large to hold twice in memory. The output is that of proto.Marshal with
+// the Deterministic option for the values NewValue would return, so keys are sorted
+//
+// Values of types other than nil, bool, string, numbers, map[string]any and []any, and
+// values the options change, are converted with NewValue one at a time. A value is
+// checked completely before anything is written, so conversion failures leave the
+// writer untouched; only the size of each map, list and entry is kept for that. A
+// WireEncoder is not safe for concurrent use
+type WireEncoder struct {
+	w    io.Writer
+	opts options
+	buf  []byte
+}
+
+// NewWireEncoder returns a WireEncoder writing to w and converting values under opts
+func NewWireEncoder(w io.Writer, opts ...Option) *WireEncoder {
+	return &WireEncoder{w: w, opts: newOptions(opts)}
+}
+
+// EncodeStruct writes m as a google.protobuf.Struct message. A nil map is an empty one
+func (we *WireEncoder) EncodeStruct(m map[string]any) error {
+	return we.run(m, func(ww *wireWriter, size int) {
+		ww.writeFields(m)
+	})
+}
+
+// EncodeStructField writes m as the Struct field num of an enclosing message, with its
+// tag and length, so that a message can be written field by field
+func (we *WireEncoder) EncodeStructField(num protowire.Number, m map[string]any) error {
+	return we.run(m, func(ww *wireWriter, size int) {
+		ww.buf = protowire.AppendTag(ww.buf, num, protowire.BytesType)
+		ww.buf = protowire.AppendVarint(ww.buf, uint64(size))
+		ww.writeFields(m)
+	})
+}
+
+// EncodeList writes s as a google.protobuf.ListValue message. A nil slice is an empty one
+func (we *WireEncoder) EncodeList(s []any) error {
+	return we.run(s, func(ww *wireWriter, size int) {
+		ww.writeItems(s)
+	})
+}
+
+// run measures the map or slice v, then writes it with write, flushing the output
+func (we *WireEncoder) run(v any, write func(ww *wireWriter, size int)) error {
+	ww := wireWriter{encoder: newEncoder(we.opts), out: we.w, buf: we.buf[:0]}
+	var size int
+	var err error
+	container := reflect.ValueOf(v)
+	if err := ww.enter(container); err != nil {
+		return ww.conversionError(container.Type(), err)
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		size, err = ww.measureFields(v)
+	case []any:
+		size, err = ww.measureItems(v)
+	}
+	ww.leave(container)
+	if err != nil {
+		return err
+	}
+
+	write(&ww, size)
+	ww.flush()
+	we.buf = ww.buf
+	return ww.writeErr
+}
+
+// wireNode records what the measuring pass found about a map entry, list item or
+// container, in the order the writing pass needs it
+type wireNode struct {
+	// size is the length of the Value message of an entry or item, or of the contents
+	// of a container
+	size int
+	// value is set for values converted with NewValue
+	value *structpb.Value
+	// skip is set for entries and items dropped under WithSkipErrors
+	skip bool
+}
+
+type wireWriter struct {
+	*encoder
+	nodes    []wireNode
+	next     int
+	out      io.Writer
+	buf      []byte
+	writeErr error
+}
+
+// push records a node, returning its index
+func (ww *wireWriter) push(n wireNode) int {
+	ww.nodes = append(ww.nodes, n)
+	return len(ww.nodes) - 1
+}
+
+// nextNode returns the next node recorded by the measuring pass
+func (ww *wireWriter) nextNode() wireNode {
+	n := ww.nodes[ww.next]
+	ww.next++
+	return n
+}
+
+// scalarSize returns the size of the Value message for v when it is written without
+// converting it first
+func (ww *wireWriter) scalarSize(v any) (int, bool) {
+	if _, ok := ww.opts.lookupValueConverter(v); ok {
+		return 0, false
+	}
+	switch v := v.(type) {
+	case nil, bool:
+		return 2, true
+	case string:
+		return 1 + protowire.SizeBytes(len(v)), utf8.ValidString(v)
+	case int, int64, uint, uint64:
+		return 9, !ww.opts.largeIntegerStrings
+	case int8, int16, int32, uint8, uint16, uint32:
+		return 9, true
+	case float32:
+		return 9, !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
+	case float64:
+		return 9, !math.IsNaN(v) && !math.IsInf(v, 0)
+	default:
+		return 0, false
+	}
+}
+
+// measure returns the size of the Value message for v, recording nodes for containers
+// and converted values
+func (ww *wireWriter) measure(v any) (int, error) {
+	if size, ok := ww.scalarSize(v); ok {
+		return size, nil
+	}
+
+	var container reflect.Value
+	switch v.(type) {
+	case map[string]any, []any:
+		container = reflect.ValueOf(v)
+	default:
+		pbValue, err := ww.encode(v)
+		if err != nil {
+			return 0, err
+		}
+		ww.push(wireNode{value: pbValue})
+		return proto.Size(pbValue), nil
+	}
+
+	if err := ww.enter(container); err != nil {
+		return 0, ww.conversionError(container.Type(), err)
+	}
+	defer ww.leave(container)
+	i := ww.push(wireNode{})
+	var size int
+	var err error
+	switch v := v.(type) {
+	case map[string]any:
+		size, err = ww.measureFields(v)
+	case []any:
+		size, err = ww.measureItems(v)
+	}
+	if err != nil {
+		return 0, err
+	}
+	ww.nodes[i].size = size
+	return 1 + protowire.SizeBytes(size), nil
+}
+
+// measureFields returns the size of the contents of the Struct for m
+func (ww *wireWriter) measureFields(m map[string]any) (int, error) {
+	var total int
+	var errs entryErrors
+	var names map[string]*structpb.Value
+	if ww.opts.keyCase != keyCaseUnchanged {
+		names = make(map[string]*structpb.Value, len(m))
+	}
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		v := m[k]
+		if !ww.opts.keepKey(k) || ww.opts.omitEntry(v) {
+			continue
+		}
+		i := ww.push(wireNode{})
+		size, err := ww.measureEntry(names, k, v)
+		if err != nil {
+			// nodes recorded within a failed entry are never written
+			ww.nodes = ww.nodes[:i+1]
+			ww.nodes[i].skip = true
+			if err := errs.add(ww.encoder, k, v, err); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		ww.nodes[i].size = size
+		total += protowire.SizeTag(wireStructFields) + protowire.SizeBytes(entrySize(ww.opts.renameKey(k), size))
+	}
+	if len(errs) > 0 {
+		return 0, errs.join(true)
+	}
+	return total, nil
+}
+
+// measureEntry returns the size of the Value message of the map entry k, recording its
+// name in names when keys are renamed
+func (ww *wireWriter) measureEntry(names map[string]*structpb.Value, k string, v any) (int, error) {
+	ww.pushKey(k)
+	defer ww.encoder.pop()
+
+	if !utf8.ValidString(k) {
+		return 0, ww.conversionError(nil, fmt.Errorf("invalid UTF-8 in key: %q", k))
+	}
+	name := k
+	if names != nil {
+		var err error
+		if name, err = ww.renameField(names, k); err != nil {
+			return 0, ww.conversionError(nil, err)
+		}
+	}
+	size, err := ww.measure(v)
+	if err != nil {
+		return 0, ww.conversionError(reflect.TypeOf(v), err)
+	}
+	if err := ww.chargeWire(name, v); err != nil {
+		return 0, err
+	}
+	if names != nil {
+		names[name] = nil
+	}
+	return size, nil
+}
+
+// entrySize returns the size of a map entry whose Value message has size bytes
+func entrySize(name string, size int) int {
+	return protowire.SizeTag(wireEntryKey) + protowire.SizeBytes(len(name)) +
+		protowire.SizeTag(wireEntryValue) + protowire.SizeBytes(size)
+}
+
+// measureItems returns the size of the contents of the ListValue for s
+func (ww *wireWriter) measureItems(s []any) (int, error) {
+	var total int
+	var errs entryErrors
+	for idx, v := range s {
+		i := ww.push(wireNode{})
+		ww.pushIndex(idx)
+		size, err := ww.measure(v)
+		if err != nil {
+			err = ww.conversionError(reflect.TypeOf(v), err)
+		} else {
+			err = ww.chargeWire("", v)
+		}
+		ww.encoder.pop()
+		if err != nil {
+			ww.nodes = ww.nodes[:i+1]
+			ww.nodes[i].skip = true
+			if err := errs.add(ww.encoder, "", v, err); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		ww.nodes[i].size = size
+		total += protowire.SizeTag(wireListValues) + protowire.SizeBytes(size)
+	}
+	if len(errs) > 0 {
+		return 0, errs.join(false)
+	}
+	return total, nil
+}
+
+// chargeWire accounts for v under the resource limits like count does for its Value
+func (ww *wireWriter) chargeWire(key string, v any) error {
+	if ww.opts.maxNodes == 0 && ww.opts.maxBytes == 0 {
+		return nil
+	}
+	if _, ok := ww.scalarSize(v); !ok {
+		switch v.(type) {
+		case map[string]any, []any:
+			return ww.charge(key, 0)
+		default:
+			// the node of a converted value is the last one recorded
+			return ww.count(key, ww.nodes[len(ww.nodes)-1].value)
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return ww.charge(key, len(v))
+	case nil, bool:
+		return ww.charge(key, 1)
+	default:
+		return ww.charge(key, 8)
+	}
+}
+
+// writeFields writes the contents of the Struct for m
+func (ww *wireWriter) writeFields(m map[string]any) {
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		v := m[k]
+		if !ww.opts.keepKey(k) || ww.opts.omitEntry(v) {
+			continue
+		}
+		entry := ww.nextNode()
+		if entry.skip {
+			continue
+		}
+		name := ww.opts.renameKey(k)
+		ww.buf = protowire.AppendTag(ww.buf, wireStructFields, protowire.BytesType)
+		ww.buf = protowire.AppendVarint(ww.buf, uint64(entrySize(name, entry.size)))
+		ww.buf = protowire.AppendTag(ww.buf, wireEntryKey, protowire.BytesType)
+		ww.buf = protowire.AppendString(ww.buf, name)
+		ww.buf = protowire.AppendTag(ww.buf, wireEntryValue, protowire.BytesType)
+		ww.buf = protowire.AppendVarint(ww.buf, uint64(entry.size))
+		ww.writeValue(v)
+		ww.maybeFlush()
+	}
+}
+
+// writeItems writes the contents of the ListValue for s
+func (ww *wireWriter) writeItems(s []any) {
+	for _, v := range s {
+		item := ww.nextNode()
+		if item.skip {
+			continue
+		}
+		ww.buf = protowire.AppendTag(ww.buf, wireListValues, protowire.BytesType)
+		ww.buf = protowire.AppendVarint(ww.buf, uint64(item.size))
+		ww.writeValue(v)
+		ww.maybeFlush()
+	}
+}
+
+// writeValue writes the contents of the Value message for v
+func (ww *wireWriter) writeValue(v any) {
+	if _, ok := ww.scalarSize(v); ok {
+		ww.writeScalar(v)
+		return
+	}
+
+	n := ww.nextNode()
+	switch v := v.(type) {
+	case map[string]any:
+		ww.buf = protowire.AppendTag(ww.buf, wireStructValue, protowire.BytesType)
+		ww.buf = protowire.AppendVarint(ww.buf, uint64(n.size))
+		ww.writeFields(v)
+	case []any:
+		ww.buf = protowire.AppendTag(ww.buf, wireListValue, protowire.BytesType)
+		ww.buf = protowire.AppendVarint(ww.buf, uint64(n.size))
+		ww.writeItems(v)
+	default:
+		var err error
+		ww.buf, err = proto.MarshalOptions{Deterministic: true}.MarshalAppend(ww.buf, n.value)
+		if err != nil && ww.writeErr == nil {
+			ww.writeErr = err
+		}
+	}
+}
+
+// writeScalar writes a value accepted by scalarSize
+func (ww *wireWriter) writeScalar(v any) {
+	var f float64
+	switch v := v.(type) {
+	case nil:
+		ww.buf = protowire.AppendTag(ww.buf, wireNullValue, protowire.VarintType)
+		ww.buf = protowire.AppendVarint(ww.buf, 0)
+		return
+	case bool:
+		ww.buf = protowire.AppendTag(ww.buf, wireBoolValue, protowire.VarintType)
+		ww.buf = protowire.AppendVarint(ww.buf, protowire.EncodeBool(v))
+		return
+	case string:
+		ww.buf = protowire.AppendTag(ww.buf, wireStringValue, protowire.BytesType)
+		ww.buf = protowire.AppendString(ww.buf, v)
+		return
+	case int:
+		f = float64(v)
+	case int8:
+		f = float64(v)
+	case int16:
+		f = float64(v)
+	case int32:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case uint:
+		f = float64(v)
+	case uint8:
+		f = float64(v)
+	case uint16:
+		f = float64(v)
+	case uint32:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	case float32:
+		f = float64(v)
+	case float64:
+		f = v
+	}
+	ww.buf = protowire.AppendTag(ww.buf, wireNumberValue, protowire.Fixed64Type)
+	ww.buf = protowire.AppendFixed64(ww.buf, math.Float64bits(f))
+}
+
+func (ww *wireWriter) maybeFlush() {
+	if len(ww.buf) >= wireFlushSize {
+		ww.flush()
+	}
+}
+
+// flush writes the buffered output, keeping the first write error
+func (ww *wireWriter) flush() {
+	if ww.writeErr == nil && len(ww.buf) > 0 {
+		_, ww.writeErr = ww.out.Write(ww.buf)
+	}
+	ww.buf = ww.buf[:0]
+}
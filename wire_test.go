@@ -0,0 +1,140 @@
+package protobaggins
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestWireEncoder(t *testing.T) {
+	t.Parallel()
+
+	marshal := func(t *testing.T, m proto.Message) []byte {
+		t.Helper()
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+		require.NoError(t, err)
+		return b
+	}
+
+	input := map[string]any{
+		"name":    "frodo",
+		"age":     50,
+		"height":  float32(1.25),
+		"ring":    true,
+		"nothing": nil,
+		"int8":    int8(-3),
+		"uint64":  uint64(1) << 60,
+		"nan":     math.NaN(),
+		"when":    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		"tags":    []string{"a", "b"},
+		"nested": map[string]any{
+			"list":  []any{1, "two", []any{}, map[string]any{}, map[string]int{"x": 1}},
+			"empty": "",
+			"long":  strings.Repeat("x", 300),
+		},
+	}
+
+	for name, opts := range map[string][]Option{
+		"defaults":      {},
+		"null policies": {WithNonFinite(NonFiniteNull), WithLargeIntegerStrings(), WithOmitNulls()},
+		"renamed keys":  {WithKeyCase(KeyCasePascal), WithKeyFilter(func(key string) bool { return key != "age" })},
+		"arena":         {WithUnixTimes(), WithOmitEmpty(), WithArena()},
+	} {
+		opts = append([]Option{WithReflection(), WithNonFinite(NonFiniteString)}, opts...)
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			want, err := NewValue(input, opts...)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, NewWireEncoder(&buf, opts...).EncodeStruct(input))
+			assert.Equal(t, marshal(t, want.GetStructValue()), buf.Bytes())
+
+			var decoded structpb.Struct
+			require.NoError(t, proto.Unmarshal(buf.Bytes(), &decoded))
+			assert.True(t, proto.Equal(want.GetStructValue(), &decoded))
+		})
+	}
+
+	t.Run("struct field and list", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{"a": []any{1, "b"}})
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		enc := NewWireEncoder(&buf)
+		// struct_value is field 5 of google.protobuf.Value
+		require.NoError(t, enc.EncodeStructField(5, map[string]any{"a": []any{1, "b"}}))
+		assert.Equal(t, marshal(t, structpb.NewStructValue(s)), buf.Bytes())
+
+		buf.Reset()
+		list := []any{nil, 1.5, map[string]any{"k": "v"}}
+		require.NoError(t, enc.EncodeList(list))
+		want, err := structpb.NewList(list)
+		require.NoError(t, err)
+		assert.Equal(t, marshal(t, want), buf.Bytes())
+	})
+
+	t.Run("large output", func(t *testing.T) {
+		t.Parallel()
+		items := make([]any, 20000)
+		for i := range items {
+			items[i] = map[string]any{"i": i, "s": "value"}
+		}
+		m := map[string]any{"items": items}
+		want, err := NewValue(m)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, NewWireEncoder(&buf).EncodeStruct(m))
+		assert.Equal(t, marshal(t, want.GetStructValue()), buf.Bytes())
+	})
+
+	t.Run("failures write nothing", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		m := map[string]any{"ok": 1, "bad": []any{"x", make(chan int)}, "key\xff": 1}
+		err := NewWireEncoder(&buf).EncodeStruct(m)
+		require.Error(t, err)
+		assert.Empty(t, buf.Bytes())
+		var ce *ConversionError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "bad[1]", ce.Path)
+
+		_, wantErr := NewValue(m)
+		assert.Equal(t, wantErr.Error(), err.Error())
+
+		err = NewWireEncoder(&buf, WithMaxDepth(2)).EncodeStruct(map[string]any{"a": map[string]any{"b": []any{}}})
+		require.ErrorIs(t, err, ErrMaxDepth)
+		err = NewWireEncoder(&buf, WithMaxNodes(3)).EncodeList([]any{1, 2, []any{3}})
+		require.ErrorIs(t, err, ErrTooLarge)
+		assert.Empty(t, buf.Bytes())
+	})
+
+	t.Run("skipped values", func(t *testing.T) {
+		t.Parallel()
+		m := map[string]any{"ok": 1, "bad": []any{"x", make(chan int), map[string]any{"c": func() {}}}, "worse": map[string]any{"x": complex(1, 1)}}
+		want, err := NewValue(m, WithSkipErrors())
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, NewWireEncoder(&buf, WithSkipErrors()).EncodeStruct(m))
+		assert.Equal(t, marshal(t, want.GetStructValue()), buf.Bytes())
+	})
+
+	t.Run("write errors", func(t *testing.T) {
+		t.Parallel()
+		errBroken := errors.New("broken pipe")
+		err := NewWireEncoder(failingWriter{errBroken}).EncodeStruct(map[string]any{"a": 1})
+		require.ErrorIs(t, err, errBroken)
+	})
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
@@ -0,0 +1,178 @@
+package protobaggins
+
+import "google.golang.org/protobuf/types/known/wrapperspb"
+
+// The *ToWrapper and *FromWrapper functions convert between Go scalars and the
+// google.protobuf wrapper messages. A nil wrapper means the field is unset, so the
+// FromWrapper functions return the zero value for it and the FromWrapperOr functions
+// return the given fallback instead
+
+// wrapper is implemented by pointers to the wrapperspb message types
+type wrapper[M any, T any] interface {
+	*M
+	GetValue() T
+}
+
+func fromWrapperOr[W wrapper[M, T], M any, T any](w W, fallback T) T {
+	if w == nil {
+		return fallback
+	}
+	return w.GetValue()
+}
+
+// BoolToWrapper converts a Go bool to a *wrapperspb.BoolValue
+func BoolToWrapper(v bool) *wrapperspb.BoolValue {
+	return wrapperspb.Bool(v)
+}
+
+// BoolFromWrapper converts a *wrapperspb.BoolValue to a Go bool
+// Returns false if the wrapper is nil
+func BoolFromWrapper(w *wrapperspb.BoolValue) bool {
+	return w.GetValue()
+}
+
+// BoolFromWrapperOr converts a *wrapperspb.BoolValue to a Go bool
+// Returns fallback if the wrapper is nil
+func BoolFromWrapperOr(w *wrapperspb.BoolValue, fallback bool) bool {
+	return fromWrapperOr(w, fallback)
+}
+
+// Int32ToWrapper converts a Go int32 to a *wrapperspb.Int32Value
+func Int32ToWrapper(v int32) *wrapperspb.Int32Value {
+	return wrapperspb.Int32(v)
+}
+
+// Int32FromWrapper converts a *wrapperspb.Int32Value to a Go int32
+// Returns 0 if the wrapper is nil
+func Int32FromWrapper(w *wrapperspb.Int32Value) int32 {
+	return w.GetValue()
+}
+
+// Int32FromWrapperOr converts a *wrapperspb.Int32Value to a Go int32
+// Returns fallback if the wrapper is nil
+func Int32FromWrapperOr(w *wrapperspb.Int32Value, fallback int32) int32 {
+	return fromWrapperOr(w, fallback)
+}
+
+// Int64ToWrapper converts a Go int64 to a *wrapperspb.Int64Value
+func Int64ToWrapper(v int64) *wrapperspb.Int64Value {
+	return wrapperspb.Int64(v)
+}
+
+// Int64FromWrapper converts a *wrapperspb.Int64Value to a Go int64
+// Returns 0 if the wrapper is nil
+func Int64FromWrapper(w *wrapperspb.Int64Value) int64 {
+	return w.GetValue()
+}
+
+// Int64FromWrapperOr converts a *wrapperspb.Int64Value to a Go int64
+// Returns fallback if the wrapper is nil
+func Int64FromWrapperOr(w *wrapperspb.Int64Value, fallback int64) int64 {
+	return fromWrapperOr(w, fallback)
+}
+
+// Uint32ToWrapper converts a Go uint32 to a *wrapperspb.UInt32Value
+func Uint32ToWrapper(v uint32) *wrapperspb.UInt32Value {
+	return wrapperspb.UInt32(v)
+}
+
+// Uint32FromWrapper converts a *wrapperspb.UInt32Value to a Go uint32
+// Returns 0 if the wrapper is nil
+func Uint32FromWrapper(w *wrapperspb.UInt32Value) uint32 {
+	return w.GetValue()
+}
+
+// Uint32FromWrapperOr converts a *wrapperspb.UInt32Value to a Go uint32
+// Returns fallback if the wrapper is nil
+func Uint32FromWrapperOr(w *wrapperspb.UInt32Value, fallback uint32) uint32 {
+	return fromWrapperOr(w, fallback)
+}
+
+// Uint64ToWrapper converts a Go uint64 to a *wrapperspb.UInt64Value
+func Uint64ToWrapper(v uint64) *wrapperspb.UInt64Value {
+	return wrapperspb.UInt64(v)
+}
+
+// Uint64FromWrapper converts a *wrapperspb.UInt64Value to a Go uint64
+// Returns 0 if the wrapper is nil
+func Uint64FromWrapper(w *wrapperspb.UInt64Value) uint64 {
+	return w.GetValue()
+}
+
+// Uint64FromWrapperOr converts a *wrapperspb.UInt64Value to a Go uint64
+// Returns fallback if the wrapper is nil
+func Uint64FromWrapperOr(w *wrapperspb.UInt64Value, fallback uint64) uint64 {
+	return fromWrapperOr(w, fallback)
+}
+
+// Float32ToWrapper converts a Go float32 to a *wrapperspb.FloatValue
+func Float32ToWrapper(v float32) *wrapperspb.FloatValue {
+	return wrapperspb.Float(v)
+}
+
+// Float32FromWrapper converts a *wrapperspb.FloatValue to a Go float32
+// Returns 0 if the wrapper is nil
+func Float32FromWrapper(w *wrapperspb.FloatValue) float32 {
+	return w.GetValue()
+}
+
+// Float32FromWrapperOr converts a *wrapperspb.FloatValue to a Go float32
+// Returns fallback if the wrapper is nil
+func Float32FromWrapperOr(w *wrapperspb.FloatValue, fallback float32) float32 {
+	return fromWrapperOr(w, fallback)
+}
+
+// Float64ToWrapper converts a Go float64 to a *wrapperspb.DoubleValue
+func Float64ToWrapper(v float64) *wrapperspb.DoubleValue {
+	return wrapperspb.Double(v)
+}
+
+// Float64FromWrapper converts a *wrapperspb.DoubleValue to a Go float64
+// Returns 0 if the wrapper is nil
+func Float64FromWrapper(w *wrapperspb.DoubleValue) float64 {
+	return w.GetValue()
+}
+
+// Float64FromWrapperOr converts a *wrapperspb.DoubleValue to a Go float64
+// Returns fallback if the wrapper is nil
+func Float64FromWrapperOr(w *wrapperspb.DoubleValue, fallback float64) float64 {
+	return fromWrapperOr(w, fallback)
+}
+
+// StringToWrapper converts a Go string to a *wrapperspb.StringValue
+func StringToWrapper(v string) *wrapperspb.StringValue {
+	return wrapperspb.String(v)
+}
+
+// StringFromWrapper converts a *wrapperspb.StringValue to a Go string
+// Returns an empty string if the wrapper is nil
+func StringFromWrapper(w *wrapperspb.StringValue) string {
+	return w.GetValue()
+}
+
+// StringFromWrapperOr converts a *wrapperspb.StringValue to a Go string
+// Returns fallback if the wrapper is nil
+func StringFromWrapperOr(w *wrapperspb.StringValue, fallback string) string {
+	return fromWrapperOr(w, fallback)
+}
+
+// BytesToWrapper converts a Go []byte to a *wrapperspb.BytesValue
+// Returns nil if b is nil, an empty non-nil slice gives an empty wrapper
+func BytesToWrapper(b []byte) *wrapperspb.BytesValue {
+	if b == nil {
+		return nil
+	}
+	return wrapperspb.Bytes(b)
+}
+
+// BytesFromWrapper converts a *wrapperspb.BytesValue to a Go []byte
+// Returns nil if the wrapper is nil
+func BytesFromWrapper(w *wrapperspb.BytesValue) []byte {
+	return w.GetValue()
+}
+
+// BytesFromWrapperOr converts a *wrapperspb.BytesValue to a Go []byte
+// Returns fallback if the wrapper is nil
+func BytesFromWrapperOr(w *wrapperspb.BytesValue, fallback []byte) []byte {
+	return fromWrapperOr(w, fallback)
+}
@@ -0,0 +1,66 @@
+package protobaggins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrappers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trips", func(t *testing.T) {
+		t.Parallel()
+		assert.True(t, BoolFromWrapper(BoolToWrapper(true)))
+		assert.Equal(t, int32(-3), Int32FromWrapper(Int32ToWrapper(-3)))
+		assert.Equal(t, int64(1<<40), Int64FromWrapper(Int64ToWrapper(1<<40)))
+		assert.Equal(t, uint32(3), Uint32FromWrapper(Uint32ToWrapper(3)))
+		assert.Equal(t, uint64(1<<63), Uint64FromWrapper(Uint64ToWrapper(1<<63)))
+		assert.InDelta(t, float32(1.5), Float32FromWrapper(Float32ToWrapper(1.5)), 0)
+		assert.InDelta(t, 2.5, Float64FromWrapper(Float64ToWrapper(2.5)), 0)
+		assert.Equal(t, "ring", StringFromWrapper(StringToWrapper("ring")))
+		assert.Equal(t, []byte("ring"), BytesFromWrapper(BytesToWrapper([]byte("ring"))))
+	})
+
+	t.Run("nil gives the zero value", func(t *testing.T) {
+		t.Parallel()
+		assert.False(t, BoolFromWrapper(nil))
+		assert.Zero(t, Int32FromWrapper(nil))
+		assert.Zero(t, Int64FromWrapper(nil))
+		assert.Zero(t, Uint32FromWrapper(nil))
+		assert.Zero(t, Uint64FromWrapper(nil))
+		assert.Zero(t, Float32FromWrapper(nil))
+		assert.Zero(t, Float64FromWrapper(nil))
+		assert.Empty(t, StringFromWrapper(nil))
+		assert.Nil(t, BytesFromWrapper(nil))
+	})
+
+	t.Run("nil gives the fallback", func(t *testing.T) {
+		t.Parallel()
+		assert.True(t, BoolFromWrapperOr(nil, true))
+		assert.Equal(t, int32(1), Int32FromWrapperOr(nil, 1))
+		assert.Equal(t, int64(2), Int64FromWrapperOr(nil, 2))
+		assert.Equal(t, uint32(3), Uint32FromWrapperOr(nil, 3))
+		assert.Equal(t, uint64(4), Uint64FromWrapperOr(nil, 4))
+		assert.InDelta(t, float32(5), Float32FromWrapperOr(nil, 5), 0)
+		assert.InDelta(t, 6.0, Float64FromWrapperOr(nil, 6), 0)
+		assert.Equal(t, "x", StringFromWrapperOr(nil, "x"))
+		assert.Equal(t, []byte("x"), BytesFromWrapperOr(nil, []byte("x")))
+	})
+
+	t.Run("set zero values win over the fallback", func(t *testing.T) {
+		t.Parallel()
+		assert.False(t, BoolFromWrapperOr(BoolToWrapper(false), true))
+		assert.Zero(t, Int64FromWrapperOr(Int64ToWrapper(0), 2))
+		assert.Empty(t, StringFromWrapperOr(StringToWrapper(""), "x"))
+	})
+
+	t.Run("bytes keep nil and empty apart", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, BytesToWrapper(nil))
+		w := BytesToWrapper([]byte{})
+		require.NotNil(t, w)
+		assert.Empty(t, BytesFromWrapperOr(w, []byte("x")))
+	})
+}
@@ -0,0 +1,280 @@
+package protobaggins
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLToStruct parses the first document of a YAML stream into a *structpb.Struct.
+// An empty or null document gives an empty Struct, any other non-mapping document
+// fails with ErrUnexpectedKind. Beyond what NewValue would accept:
+//   - non-string mapping keys are stringified, so `1: one` gives {"1": "one"}
+//   - anchors, aliases and `<<` merge keys are expanded, aliases that refer to a node
+//     containing them are rejected as cycles, and documents that aliases expand to
+//     more than ten times their number of nodes fail with ErrTooLarge
+//   - duplicate keys are rejected, including keys that are equal once stringified,
+//     such as 1 and "1"
+//   - timestamps become RFC 3339 strings and !!binary values base64 strings
+func YAMLToStruct(data []byte) (*structpb.Struct, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return &structpb.Struct{}, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return &structpb.Struct{}, nil
+	}
+	c := yamlConverter{active: map[*yaml.Node]bool{}, budget: yamlValueBudget(root)}
+	v, err := c.convert("", root)
+	if err != nil {
+		return nil, err
+	}
+	s := v.GetStructValue()
+	if s == nil {
+		return nil, fmt.Errorf("%w: YAML document must be a mapping, got %s", ErrUnexpectedKind, KindOf(v))
+	}
+	return s, nil
+}
+
+// yamlMinValueBudget and yamlExpansionFactor bound the values a document converts to,
+// so that aliases cannot expand it without limit as in the billion laughs attack
+const (
+	yamlMinValueBudget  = 10000
+	yamlExpansionFactor = 10
+)
+
+// yamlValueBudget returns the number of values the document at n may convert to
+func yamlValueBudget(n *yaml.Node) int {
+	return max(yamlMinValueBudget, yamlExpansionFactor*countYAMLNodes(n))
+}
+
+// countYAMLNodes counts the nodes of the document at n without following aliases
+func countYAMLNodes(n *yaml.Node) int {
+	count := 1
+	for _, child := range n.Content {
+		count += countYAMLNodes(child)
+	}
+	return count
+}
+
+// yamlConverter tracks the aliased nodes being expanded to detect cycles, and the
+// values converted so far against the budget
+type yamlConverter struct {
+	active map[*yaml.Node]bool
+	values int
+	budget int
+}
+
+func (c *yamlConverter) convert(path string, n *yaml.Node) (*structpb.Value, error) {
+	c.values++
+	if c.values > c.budget {
+		return nil, fmt.Errorf("%s: %w: aliases expand the document to more than %d values",
+			describePath(path), ErrTooLarge, c.budget)
+	}
+
+	switch n.Kind {
+	case yaml.AliasNode:
+		if c.active[n.Alias] {
+			return nil, fmt.Errorf("%s: alias *%s refers to a node containing it", describePath(path), n.Value)
+		}
+		c.active[n.Alias] = true
+		defer delete(c.active, n.Alias)
+		return c.convert(path, n.Alias)
+	case yaml.MappingNode:
+		s, err := c.convertMapping(path, n)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStructValue(s), nil
+	case yaml.SequenceNode:
+		values := make([]*structpb.Value, len(n.Content))
+		for i, item := range n.Content {
+			v, err := c.convert(joinIndex(path, i), item)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case yaml.ScalarNode:
+		return convertYAMLScalar(path, n)
+	default:
+		return nil, fmt.Errorf("%s: unsupported YAML node at line %d", describePath(path), n.Line)
+	}
+}
+
+func (c *yamlConverter) convertMapping(path string, n *yaml.Node) (*structpb.Struct, error) {
+	fields := make(map[string]*structpb.Value, len(n.Content)/2)
+	var merged []*structpb.Struct
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		keyNode, valueNode := n.Content[i], n.Content[i+1]
+
+		if keyNode.Kind == yaml.ScalarNode && keyNode.Tag == "!!merge" {
+			sources, err := c.mergeSources(path, valueNode)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, sources...)
+			continue
+		}
+
+		key, err := yamlKey(keyNode)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", describePath(path), err)
+		}
+		keyPath := joinKey(path, key)
+		if _, ok := fields[key]; ok {
+			return nil, fmt.Errorf("%s: duplicate key at line %d", keyPath, keyNode.Line)
+		}
+		v, err := c.convert(keyPath, valueNode)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = v
+	}
+
+	// explicit keys win over merged ones, and earlier merge sources over later ones
+	for _, source := range merged {
+		for key, v := range source.GetFields() {
+			if _, ok := fields[key]; !ok {
+				fields[key] = v
+			}
+		}
+	}
+	return &structpb.Struct{Fields: fields}, nil
+}
+
+// mergeSources converts the value of a `<<` key, a mapping or a sequence of them
+func (c *yamlConverter) mergeSources(path string, n *yaml.Node) ([]*structpb.Struct, error) {
+	nodes := []*yaml.Node{n}
+	if n.Kind == yaml.SequenceNode {
+		nodes = n.Content
+	}
+	sources := make([]*structpb.Struct, 0, len(nodes))
+	for _, node := range nodes {
+		v, err := c.convert(path, node)
+		if err != nil {
+			return nil, err
+		}
+		s := v.GetStructValue()
+		if s == nil {
+			return nil, fmt.Errorf("%s: merge key at line %d must refer to a mapping", describePath(path), node.Line)
+		}
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
+
+// yamlKey stringifies a scalar mapping key
+func yamlKey(n *yaml.Node) (string, error) {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind != yaml.ScalarNode {
+		return "", fmt.Errorf("mapping key at line %d must be a scalar", n.Line)
+	}
+	v, err := convertYAMLScalar("", n)
+	if err != nil {
+		return "", err
+	}
+	if n.Tag == "!!null" {
+		return "null", nil
+	}
+	return ToString(v)
+}
+
+func convertYAMLScalar(path string, n *yaml.Node) (*structpb.Value, error) {
+	switch n.Tag {
+	case "!!binary":
+		// keep the base64 text, which is how structpb.NewValue encodes []byte
+		return structpb.NewStringValue(strings.Join(strings.Fields(n.Value), "")), nil
+	case "!!timestamp":
+		var t time.Time
+		if err := n.Decode(&t); err == nil {
+			return structpb.NewStringValue(t.Format(time.RFC3339Nano)), nil
+		}
+		return structpb.NewStringValue(n.Value), nil
+	}
+
+	var decoded any
+	if err := n.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("%s: %w", describePath(path), err)
+	}
+	switch v := decoded.(type) {
+	case nil:
+		return structpb.NewNullValue(), nil
+	case bool:
+		return structpb.NewBoolValue(v), nil
+	case int:
+		return structpb.NewNumberValue(float64(v)), nil
+	case int64:
+		return structpb.NewNumberValue(float64(v)), nil
+	case uint64:
+		return structpb.NewNumberValue(float64(v)), nil
+	case float64:
+		return structpb.NewNumberValue(v), nil
+	case string:
+		return structpb.NewStringValue(v), nil
+	default:
+		return structpb.NewStringValue(n.Value), nil
+	}
+}
+
+// StructToYAML serializes s as a YAML document with sorted keys. Strings that would
+// read back as another type, such as "true" or "42", are quoted
+func StructToYAML(s *structpb.Struct) ([]byte, error) {
+	if s == nil {
+		s = &structpb.Struct{}
+	}
+	return yaml.Marshal(yamlNode(structpb.NewStructValue(s)))
+}
+
+func yamlNode(v *structpb.Value) *yaml.Node {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range sortedKeys(kind.StructValue) {
+			n.Content = append(n.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				yamlNode(kind.StructValue.GetFields()[key]))
+		}
+		return n
+	case *structpb.Value_ListValue:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range kind.ListValue.GetValues() {
+			n.Content = append(n.Content, yamlNode(item))
+		}
+		return n
+	case *structpb.Value_StringValue:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: kind.StringValue}
+	case *structpb.Value_NumberValue:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: formatYAMLNumber(kind.NumberValue)}
+	case *structpb.Value_BoolValue:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: strconv.FormatBool(kind.BoolValue)}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: "null"}
+	}
+}
+
+func formatYAMLNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return ".nan"
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	default:
+		return string(appendJSONNumber(nil, f))
+	}
+}
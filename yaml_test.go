@@ -0,0 +1,196 @@
+package protobaggins
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestYAMLToStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("scalars and nesting", func(t *testing.T) {
+		t.Parallel()
+		s, err := YAMLToStruct([]byte(`
+name: frodo
+age: 50
+height: 1.06
+ring: true
+home: ~
+quoted: "42"
+items: [ring, sting]
+nested: {a: {b: 1}}
+`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":   "frodo",
+			"age":    50.0,
+			"height": 1.06,
+			"ring":   true,
+			"home":   nil,
+			"quoted": "42",
+			"items":  []any{"ring", "sting"},
+			"nested": map[string]any{"a": map[string]any{"b": 1.0}},
+		}, s.AsMap())
+	})
+
+	t.Run("non-string keys are stringified", func(t *testing.T) {
+		t.Parallel()
+		s, err := YAMLToStruct([]byte("1: one\ntrue: yes\n1.5: x\n~: nothing\n"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"1": "one", "true": "yes", "1.5": "x", "null": "nothing"}, s.AsMap())
+	})
+
+	t.Run("complex keys are rejected", func(t *testing.T) {
+		t.Parallel()
+		_, err := YAMLToStruct([]byte("? [a, b]\n: x\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must be a scalar")
+	})
+
+	t.Run("anchors and merge keys", func(t *testing.T) {
+		t.Parallel()
+		s, err := YAMLToStruct([]byte(`
+base: &base {region: shire, size: small}
+extra: &extra {size: tiny, door: round}
+hole:
+  <<: [*base, *extra]
+  size: large
+copy: *base
+`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"region": "shire", "size": "large", "door": "round"},
+			s.AsMap()["hole"])
+		assert.Equal(t, s.AsMap()["base"], s.AsMap()["copy"])
+	})
+
+	t.Run("cycles are rejected", func(t *testing.T) {
+		t.Parallel()
+		_, err := YAMLToStruct([]byte("a: &x\n  b: *x\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "refers to a node containing it")
+	})
+
+	t.Run("alias expansion is bounded", func(t *testing.T) {
+		t.Parallel()
+		// each level refers to the previous one ten times, so level 5 holds 10^5 values
+		var b strings.Builder
+		b.WriteString("l0: &l0 [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]\n")
+		for i := 1; i <= 5; i++ {
+			fmt.Fprintf(&b, "l%d: &l%d [", i, i)
+			for j := range 10 {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				fmt.Fprintf(&b, "*l%d", i-1)
+			}
+			b.WriteString("]\n")
+		}
+		_, err := YAMLToStruct([]byte(b.String()))
+		require.ErrorIs(t, err, ErrTooLarge)
+		assert.Contains(t, err.Error(), "aliases expand the document to more than 10000 values")
+	})
+
+	t.Run("duplicate keys are rejected", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"a: 1\na: 2\n", "1: one\n\"1\": uno\n", "n:\n  ~: a\n  \"null\": b\n"} {
+			_, err := YAMLToStruct([]byte(input))
+			require.Error(t, err, input)
+			assert.Contains(t, err.Error(), "duplicate key at line", input)
+		}
+	})
+
+	t.Run("timestamps and binary", func(t *testing.T) {
+		t.Parallel()
+		s, err := YAMLToStruct([]byte("at: 2001-12-14t21:59:43.10-05:00\ndata: !!binary aGVs\n  bG8=\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "2001-12-14T21:59:43.1-05:00", s.GetFields()["at"].GetStringValue())
+		assert.Equal(t, "aGVsbG8=", s.GetFields()["data"].GetStringValue())
+	})
+
+	t.Run("empty and null documents", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"", "# comment\n", "~\n", "---\n"} {
+			s, err := YAMLToStruct([]byte(input))
+			require.NoError(t, err, input)
+			assert.Empty(t, s.GetFields(), input)
+		}
+	})
+
+	t.Run("must be a mapping", func(t *testing.T) {
+		t.Parallel()
+		_, err := YAMLToStruct([]byte("- a\n"))
+		require.ErrorIs(t, err, ErrUnexpectedKind)
+		assert.Contains(t, err.Error(), "must be a mapping, got list")
+	})
+
+	t.Run("syntax errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := YAMLToStruct([]byte("a: [unclosed\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line")
+	})
+}
+
+func TestStructToYAML(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sorted keys and quoting", func(t *testing.T) {
+		t.Parallel()
+		s, err := structpb.NewStruct(map[string]any{
+			"name":   "frodo",
+			"age":    50,
+			"height": 1.5,
+			"ring":   true,
+			"home":   nil,
+			"fake":   "true",
+			"digits": "42",
+			"items":  []any{"ring", map[string]any{"b": 1}},
+		})
+		require.NoError(t, err)
+
+		got, err := StructToYAML(s)
+		require.NoError(t, err)
+		assert.Equal(t, `age: 50
+digits: "42"
+fake: "true"
+height: 1.5
+home: null
+items:
+    - ring
+    - b: 1
+name: frodo
+ring: true
+`, string(got))
+	})
+
+	t.Run("round trips", func(t *testing.T) {
+		t.Parallel()
+		s := &structpb.Struct{Fields: map[string]*structpb.Value{
+			"ninf":  structpb.NewNumberValue(math.Inf(-1)),
+			"big":   structpb.NewNumberValue(1e21),
+			"multi": structpb.NewStringValue("line one\nline two\n"),
+			"empty": structpb.NewStructValue(&structpb.Struct{}),
+			"none":  structpb.NewListValue(&structpb.ListValue{}),
+			"null":  structpb.NewStringValue("null"),
+		}}
+		data, err := StructToYAML(s)
+		require.NoError(t, err)
+		back, err := YAMLToStruct(data)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(s, back), "got %v from\n%s", back, data)
+	})
+
+	t.Run("nil struct", func(t *testing.T) {
+		t.Parallel()
+		got, err := StructToYAML(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "{}\n", string(got))
+	})
+}